@@ -0,0 +1,57 @@
+package voyageai
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The maximum number of aliases followed when resolving a model name. See
+// [VoyageClientOpts.Aliases].
+const MaxAliasDepth = 8
+
+// Returned, wrapped, when a model alias cannot be resolved because the aliases form a cycle or
+// a chain longer than [MaxAliasDepth].
+var ErrAliasCycle = errors.New("voyage: model alias cycle")
+
+// ResolveModel returns the model that model names under the client's
+// [VoyageClientOpts.Aliases], following aliases of aliases. Names that are not aliases are
+// returned as is.
+func (c *VoyageClient) ResolveModel(model string) (string, error) {
+	aliases := c.opts.Aliases
+	if len(aliases) == 0 {
+		return model, nil
+	}
+	chain := []string{model}
+	for {
+		target, ok := aliases[model]
+		if !ok {
+			return model, nil
+		}
+		if slices.Contains(chain, target) {
+			return "", fmt.Errorf("%w: %s", ErrAliasCycle, strings.Join(append(chain, target), " -> "))
+		}
+		if len(chain) > MaxAliasDepth {
+			return "", fmt.Errorf("%w: %q is more than %d aliases deep", ErrAliasCycle, chain[0], MaxAliasDepth)
+		}
+		chain = append(chain, target)
+		model = target
+	}
+}
+
+// resolveFallbacks returns alts with their models resolved.
+func resolveFallbacks[O any](c *VoyageClient, alts []Fallback[O]) ([]Fallback[O], error) {
+	if len(c.opts.Aliases) == 0 {
+		return alts, nil
+	}
+	out := make([]Fallback[O], len(alts))
+	for i, alt := range alts {
+		model, err := c.ResolveModel(alt.Model)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Fallback[O]{Model: model, Opts: alt.Opts}
+	}
+	return out, nil
+}
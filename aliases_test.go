@@ -0,0 +1,96 @@
+package voyageai_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func newAliasClient(api *mockServer) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: api.URL,
+		Aliases: map[string]string{
+			"default-embed": "voyage-3.5",
+			"cheap-embed":   "small-embed",
+			"small-embed":   "voyage-3.5-lite",
+			"fast-rerank":   "rerank-2-lite",
+			"loop-a":        "loop-b",
+			"loop-b":        "loop-a",
+		},
+	})
+}
+
+func TestAliases(t *testing.T) {
+	api := newMockServer(t)
+	client := newAliasClient(api)
+
+	for alias, want := range map[string]string{
+		"default-embed": "voyage-3.5",
+		"cheap-embed":   "voyage-3.5-lite",
+		"voyage-3":      "voyage-3",
+	} {
+		api.requests = nil
+		resp, err := client.Embed([]string{"hello"}, alias, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", alias, err)
+		}
+		if got := api.requests[0].Model; got != want {
+			t.Errorf("%s: expected %s on the wire, got %s", alias, want, got)
+		}
+		if resp.Model != want || resp.Metadata.RequestedModel != want || resp.Metadata.ServedModel != want {
+			t.Errorf("%s: expected %s in the response, got %s and %+v", alias, want, resp.Model, resp.Metadata)
+		}
+	}
+
+	resp, err := client.Rerank("q", []string{"doc"}, "fast-rerank", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Model != "rerank-2-lite" || api.reranks[0].Model != "rerank-2-lite" {
+		t.Errorf("Expected the rerank alias to resolve, got %s", resp.Model)
+	}
+
+	var models []string
+	for _, b := range client.UsageBuckets() {
+		models = append(models, b.Model)
+	}
+	if strings.Join(models, ",") != "voyage-3,voyage-3.5,voyage-3.5-lite,rerank-2-lite" {
+		t.Errorf("Expected usage attributed to the resolved models, got %v", models)
+	}
+
+	// Model limits are looked up by the resolved name.
+	if _, _, err := client.TruncateToContext("text", "cheap-embed"); err != nil {
+		t.Errorf("Expected the alias's context length to be known, got %v", err)
+	}
+}
+
+func TestAliasCycle(t *testing.T) {
+	api := newMockServer(t)
+	client := newAliasClient(api)
+
+	_, err := client.Embed([]string{"hello"}, "loop-a", nil)
+	if !errors.Is(err, voyageai.ErrAliasCycle) || !strings.Contains(err.Error(), "loop-a -> loop-b -> loop-a") {
+		t.Errorf("Expected ErrAliasCycle, got %v", err)
+	}
+	if _, err := client.Rerank("q", []string{"d"}, "loop-b", nil); !errors.Is(err, voyageai.ErrAliasCycle) {
+		t.Errorf("Expected ErrAliasCycle for rerank, got %v", err)
+	}
+	if len(api.requests) != 0 || len(api.reranks) != 0 {
+		t.Error("Expected no request to be sent")
+	}
+
+	deep := map[string]string{}
+	for i := range voyageai.MaxAliasDepth + 1 {
+		deep[string(rune('a'+i))] = string(rune('a' + i + 1))
+	}
+	client = voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: api.URL, Aliases: deep})
+	if _, err := client.ResolveModel("a"); !errors.Is(err, voyageai.ErrAliasCycle) {
+		t.Errorf("Expected a chain deeper than MaxAliasDepth to fail, got %v", err)
+	}
+	if got, err := client.ResolveModel("b"); err != nil || got != string(rune('a'+voyageai.MaxAliasDepth+1)) {
+		t.Errorf("Expected a chain of MaxAliasDepth to resolve, got %q and %v", got, err)
+	}
+}
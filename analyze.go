@@ -0,0 +1,277 @@
+package voyageai
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Defaults for [AnalyzeOpts].
+const (
+	DefaultSamplePairs     = 1000
+	DefaultSimilarityBins  = 20
+	DefaultDeadDimVariance = 1e-8
+)
+
+// Options for [AnalyzeEmbeddings].
+type AnalyzeOpts struct {
+	// The number of random pairs whose cosine similarity is sampled. Defaults to
+	// [DefaultSamplePairs]. If there are no more pairs than this, every pair is used.
+	SamplePairs int
+	Seed        int64 // Seeds the choice of pairs, so a seed always samples the same pairs.
+	// The number of equal-width bins of the similarity histogram, which spans -1 to 1. Defaults
+	// to [DefaultSimilarityBins].
+	SimilarityBins int
+	// Dimensions whose variance is at most this are dead. Defaults to [DefaultDeadDimVariance].
+	DeadVariance float64
+	Workers      int // The number of goroutines used. Defaults to GOMAXPROCS.
+}
+
+// The distribution of a set of values.
+type Summary struct {
+	Min, Max, Mean, StdDev float64
+}
+
+// A bin of a histogram, counting the values from Low up to, but not including, High. The last bin
+// also counts values equal to its High.
+type HistogramBin struct {
+	Low, High float64
+	Count     int
+}
+
+// Statistics of a set of vectors, computed by [AnalyzeEmbeddings].
+type EmbeddingStats struct {
+	Count, Dim int
+
+	Norms       Summary   // The distribution of the vectors' L2 norms.
+	ZeroVectors int       // The number of vectors whose values are all zero.
+	DimMean     []float64 // The mean of each dimension.
+	DimVariance []float64 // The variance of each dimension.
+	// The dimensions whose variance is at most [AnalyzeOpts.DeadVariance], in ascending order.
+	// They carry no information to tell vectors apart.
+	DeadDimensions []int
+
+	SampledPairs        int
+	Similarity          Summary // The distribution of the cosine similarity of the sampled pairs.
+	SimilarityHistogram []HistogramBin
+
+	// The number of vectors equal to an earlier vector, value for value.
+	Duplicates int
+}
+
+// AnalyzeEmbeddings computes diagnostics of vecs for spotting problems such as collapsed or
+// unnormalized embeddings: their norms, the mean and variance of every dimension, the cosine
+// similarity of randomly sampled pairs and the number of exact duplicates. Norms and dimension
+// statistics are computed in a single pass split across workers.
+//
+// All vectors must have the same dimension, and there must be at least one.
+func AnalyzeEmbeddings(vecs [][]float32, opts AnalyzeOpts) (*EmbeddingStats, error) {
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) == 0 {
+		return nil, errors.New("voyage: no vectors to analyze")
+	}
+	if opts.SamplePairs <= 0 {
+		opts.SamplePairs = DefaultSamplePairs
+	}
+	if opts.SimilarityBins <= 0 {
+		opts.SimilarityBins = DefaultSimilarityBins
+	}
+	if opts.DeadVariance <= 0 {
+		opts.DeadVariance = DefaultDeadDimVariance
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(vecs))
+
+	stats := &EmbeddingStats{Count: len(vecs), Dim: dim}
+	norms := make([]float64, len(vecs))
+	parts := make([]dimMoments, workers)
+	parallelRanges(len(vecs), workers, func(w, start, end int) {
+		m := newDimMoments(dim)
+		for i, v := range vecs[start:end] {
+			norms[start+i] = norm(v)
+			m.add(v)
+		}
+		parts[w] = m
+	})
+	total := parts[0]
+	for _, m := range parts[1:] {
+		total.merge(m)
+	}
+	stats.DimMean = total.mean
+	stats.DimVariance = make([]float64, dim)
+	for d, m2 := range total.m2 {
+		stats.DimVariance[d] = m2 / float64(total.n)
+		if stats.DimVariance[d] <= opts.DeadVariance {
+			stats.DeadDimensions = append(stats.DeadDimensions, d)
+		}
+	}
+	stats.Norms = summarize(norms)
+	for _, n := range norms {
+		if n == 0 {
+			stats.ZeroVectors++
+		}
+	}
+
+	pairs := samplePairs(len(vecs), opts.SamplePairs, opts.Seed)
+	sims := make([]float64, len(pairs))
+	parallelRanges(len(pairs), min(workers, max(len(pairs), 1)), func(_, start, end int) {
+		for p := start; p < end; p++ {
+			i, j := pairs[p][0], pairs[p][1]
+			if norms[i] != 0 && norms[j] != 0 {
+				sims[p] = dot(vecs[i], vecs[j]) / (norms[i] * norms[j])
+			}
+		}
+	})
+	stats.SampledPairs = len(pairs)
+	if len(sims) > 0 {
+		stats.Similarity = summarize(sims)
+	}
+	stats.SimilarityHistogram = histogram(sims, opts.SimilarityBins, -1, 1)
+
+	seen := make(map[string]bool, len(vecs))
+	var key []byte
+	for _, v := range vecs {
+		key = key[:0]
+		for _, x := range v {
+			key = binary.LittleEndian.AppendUint32(key, math.Float32bits(x))
+		}
+		if seen[string(key)] {
+			stats.Duplicates++
+		}
+		seen[string(key)] = true
+	}
+	return stats, nil
+}
+
+// String summarizes s on one line, leaving out the per-dimension statistics and the histogram.
+func (s *EmbeddingStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "EmbeddingStats{count=%d dim=%d norm=%s zero=%d dead=%d", s.Count, s.Dim, s.Norms, s.ZeroVectors, len(s.DeadDimensions))
+	if s.SampledPairs > 0 {
+		fmt.Fprintf(&b, " similarity=%s pairs=%d", s.Similarity, s.SampledPairs)
+	}
+	fmt.Fprintf(&b, " duplicates=%d}", s.Duplicates)
+	return b.String()
+}
+
+// String formats s as its mean and standard deviation followed by its range.
+func (s Summary) String() string {
+	return fmt.Sprintf("%.4g±%.4g[%.4g,%.4g]", s.Mean, s.StdDev, s.Min, s.Max)
+}
+
+// parallelRanges splits [0, n) into one contiguous range per worker and calls f for each range
+// concurrently, returning once all calls have.
+func parallelRanges(n, workers int, f func(worker, start, end int)) {
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(w, n*w/workers, n*(w+1)/workers)
+		}()
+	}
+	wg.Wait()
+}
+
+// dimMoments accumulates the mean and the sum of squared deviations of every dimension with
+// Welford's algorithm.
+type dimMoments struct {
+	n        int
+	mean, m2 []float64
+}
+
+func newDimMoments(dim int) dimMoments {
+	return dimMoments{mean: make([]float64, dim), m2: make([]float64, dim)}
+}
+
+func (m *dimMoments) add(v []float32) {
+	m.n++
+	for d, x := range v {
+		delta := float64(x) - m.mean[d]
+		m.mean[d] += delta / float64(m.n)
+		m.m2[d] += delta * (float64(x) - m.mean[d])
+	}
+}
+
+// merge adds the vectors accumulated by o, as by Chan et al.'s parallel algorithm.
+func (m *dimMoments) merge(o dimMoments) {
+	if o.n == 0 {
+		return
+	}
+	n := m.n + o.n
+	for d := range m.mean {
+		delta := o.mean[d] - m.mean[d]
+		m.mean[d] += delta * float64(o.n) / float64(n)
+		m.m2[d] += o.m2[d] + delta*delta*float64(m.n)*float64(o.n)/float64(n)
+	}
+	m.n = n
+}
+
+// samplePairs returns up to k distinct pairs of indices below n, chosen at random from seed, or
+// every pair if there are no more than k.
+func samplePairs(n, k int, seed int64) [][2]int {
+	var pairs [][2]int
+	if n*(n-1)/2 <= k {
+		for i := range n {
+			for j := i + 1; j < n; j++ {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+		return pairs
+	}
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	seen := make(map[[2]int]bool, k)
+	for len(pairs) < k {
+		i, j := rng.IntN(n), rng.IntN(n-1)
+		if j >= i {
+			j++
+		}
+		p := [2]int{min(i, j), max(i, j)}
+		if !seen[p] {
+			seen[p] = true
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// summarize returns the distribution of values, which must not be empty.
+func summarize(values []float64) Summary {
+	s := Summary{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range values {
+		s.Min, s.Max = min(s.Min, v), max(s.Max, v)
+		s.Mean += v
+	}
+	s.Mean /= float64(len(values))
+	for _, v := range values {
+		s.StdDev += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(len(values)))
+	return s
+}
+
+// histogram counts values into bins equal-width bins spanning lo to hi. Values outside the span
+// are counted in the first or last bin.
+func histogram(values []float64, bins int, lo, hi float64) []HistogramBin {
+	out := make([]HistogramBin, bins)
+	width := (hi - lo) / float64(bins)
+	for i := range out {
+		out[i].Low, out[i].High = lo+float64(i)*width, lo+float64(i+1)*width
+	}
+	for _, v := range values {
+		i := min(max(int((v-lo)/width), 0), bins-1)
+		out[i].Count++
+	}
+	return out
+}
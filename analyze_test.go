@@ -0,0 +1,86 @@
+package voyageai_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestAnalyzeEmbeddings(t *testing.T) {
+	vecs := [][]float32{{3, 4, 0}, {4, 3, 0}, {3, 4, 0}, {0, 0, 0}}
+	stats, err := voyageai.AnalyzeEmbeddings(vecs, voyageai.AnalyzeOpts{SimilarityBins: 4, Workers: 3})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	if stats.Count != 4 || stats.Dim != 3 || stats.ZeroVectors != 1 || stats.Duplicates != 1 {
+		t.Errorf("Unexpected counts %+v", stats)
+	}
+	if n := stats.Norms; n.Min != 0 || n.Max != 5 || !near(n.Mean, 3.75) || !near(n.StdDev, math.Sqrt(4.6875)) {
+		t.Errorf("Unexpected norms %+v", n)
+	}
+	if !near(stats.DimMean[0], 2.5) || !near(stats.DimMean[1], 2.75) || stats.DimMean[2] != 0 {
+		t.Errorf("Unexpected means %v", stats.DimMean)
+	}
+	if !near(stats.DimVariance[0], 2.25) || !near(stats.DimVariance[1], 2.6875) || stats.DimVariance[2] != 0 {
+		t.Errorf("Unexpected variances %v", stats.DimVariance)
+	}
+	if !reflect.DeepEqual(stats.DeadDimensions, []int{2}) {
+		t.Errorf("Expected dimension 2 to be dead, got %v", stats.DeadDimensions)
+	}
+
+	// With only 6 pairs, all are used: 0.96 twice, 1 once and 0 for the zero vector's pairs.
+	if s := stats.Similarity; stats.SampledPairs != 6 || s.Min != 0 || !near(s.Max, 1) || !near(s.Mean, 2.92/6) {
+		t.Errorf("Unexpected similarity %+v over %d pairs", s, stats.SampledPairs)
+	}
+	var counts []int
+	for _, b := range stats.SimilarityHistogram {
+		counts = append(counts, b.Count)
+	}
+	if !reflect.DeepEqual(counts, []int{0, 0, 3, 3}) || stats.SimilarityHistogram[3].Low != 0.5 {
+		t.Errorf("Unexpected histogram %+v", stats.SimilarityHistogram)
+	}
+
+	if s := stats.String(); !strings.HasPrefix(s, "EmbeddingStats{count=4 dim=3 norm=3.75±2.165[0,5] zero=1 dead=1") || !strings.HasSuffix(s, "pairs=6 duplicates=1}") {
+		t.Errorf("Unexpected summary %s", s)
+	}
+
+	if _, err := voyageai.AnalyzeEmbeddings(nil, voyageai.AnalyzeOpts{}); err == nil {
+		t.Error("Expected an error for no vectors")
+	}
+	if _, err := voyageai.AnalyzeEmbeddings([][]float32{{1, 2}, {1}}, voyageai.AnalyzeOpts{}); err == nil {
+		t.Error("Expected an error for mixed dimensions")
+	}
+}
+
+func TestAnalyzeEmbeddingsDeterministic(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 0))
+	vecs := make([][]float32, 200)
+	for i := range vecs {
+		vecs[i] = randomVector(rng, 8)
+	}
+
+	a, err := voyageai.AnalyzeEmbeddings(vecs, voyageai.AnalyzeOpts{SamplePairs: 500, Seed: 7, Workers: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	b, _ := voyageai.AnalyzeEmbeddings(vecs, voyageai.AnalyzeOpts{SamplePairs: 500, Seed: 7, Workers: 4})
+	if a.SampledPairs != 500 || !reflect.DeepEqual(a.SimilarityHistogram, b.SimilarityHistogram) || a.Similarity != b.Similarity {
+		t.Errorf("Expected a seed to sample the same similarities, got %v and %v", a.SimilarityHistogram, b.SimilarityHistogram)
+	}
+	for d := range a.DimMean {
+		if math.Abs(a.DimMean[d]-b.DimMean[d]) > 1e-9 || math.Abs(a.DimVariance[d]-b.DimVariance[d]) > 1e-9 {
+			t.Errorf("Dimension %d: expected the same statistics for any number of workers", d)
+		}
+	}
+
+	c, _ := voyageai.AnalyzeEmbeddings(vecs, voyageai.AnalyzeOpts{SamplePairs: 500, Seed: 8})
+	if reflect.DeepEqual(a.SimilarityHistogram, c.SimilarityHistogram) {
+		t.Error("Expected another seed to sample other pairs")
+	}
+}
@@ -0,0 +1,229 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Receives an [AuditEvent] for every API request made by a client. See [VoyageClientOpts.Audit].
+// Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Record(ctx context.Context, event AuditEvent) error
+}
+
+// Options for auditing a client's requests.
+type AuditOpts struct {
+	Sink AuditSink
+	// Fail a call whose event could not be recorded. By default sink errors are logged to
+	// [VoyageClientOpts.Logger] and the call's result is returned as is.
+	Strict bool
+}
+
+// A record of one API request, including its retries. Inputs are recorded as hashes, never as
+// content.
+type AuditEvent struct {
+	Time     time.Time     `json:"time"`        // When the first attempt started.
+	Duration time.Duration `json:"duration_ns"` // The time from the first attempt until the last one finished.
+	Endpoint string        `json:"endpoint"`    // The API endpoint, such as "embeddings" or "rerank".
+	Model    string        `json:"model"`       // The requested model.
+	// The hex-encoded SHA-256 hash of every input, as by [HashInput]. Rerank requests list the
+	// query first, then the documents. Multimodal inputs are hashed as their JSON encoding.
+	InputHashes []string    `json:"input_hashes"`
+	Usage       UsageObject `json:"usage"`                // The usage reported by a successful response.
+	Attempts    int         `json:"attempts"`             // The number of HTTP requests sent.
+	StatusCode  int         `json:"status_code"`          // The HTTP status of the last attempt, or zero if no response was received.
+	Error       string      `json:"error,omitempty"`      // The error returned for the request, if any.
+	RequestID   string      `json:"request_id,omitempty"` // The request ID reported by the API, if any.
+	TenantID    string      `json:"tenant_id,omitempty"`  // The tenant's ID if the call was made with [WithTenant].
+	// The metadata of the call, attached with [WithRequestMetadata].
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// audit sends an event for a completed request to the client's audit sink. It returns an error
+// only in strict mode.
+func (c *VoyageClient) audit(ctx context.Context, endpoint string, reqBody, respBody any, start, end time.Time, attempts int, info responseInfo, reqErr error) error {
+	a := c.opts.Audit
+	if a == nil || a.Sink == nil {
+		return nil
+	}
+	ev := AuditEvent{
+		Time:        start,
+		Duration:    end.Sub(start),
+		Endpoint:    endpoint,
+		Model:       requestModel(reqBody),
+		InputHashes: inputHashes(reqBody),
+		Attempts:    attempts,
+		StatusCode:  info.StatusCode,
+		RequestID:   info.RequestID,
+		Metadata:    RequestMetadata(ctx),
+	}
+	if t, ok := tenantFrom(ctx); ok {
+		ev.TenantID = t.ID
+	}
+	if reqErr != nil {
+		ev.Error = reqErr.Error()
+	} else if r, ok := respBody.(usageReporter); ok {
+		_, ev.Usage = r.reportedUsage()
+	}
+
+	// Record the event even if the call was cancelled.
+	err := a.Sink.Record(context.WithoutCancel(ctx), ev)
+	if err == nil {
+		return nil
+	}
+	if a.Strict {
+		return fmt.Errorf("voyage: audit: %w", err)
+	}
+	c.loggerFor(ctx).Warn("voyage: audit sink failed", "endpoint", endpoint, "model", ev.Model, "error", err)
+	return nil
+}
+
+func (c *VoyageClient) logger() *slog.Logger {
+	if c.opts.Logger != nil {
+		return c.opts.Logger
+	}
+	return slog.Default()
+}
+
+// inputHashes returns the hashes of the inputs of a request body, as described on [AuditEvent].
+func inputHashes(reqBody any) []string {
+	var hashes []string
+	switch r := reqBody.(type) {
+	case *EmbeddingRequest:
+		for _, s := range r.Input {
+			hashes = append(hashes, HashInput(s))
+		}
+	case *RerankRequest:
+		hashes = append(hashes, HashInput(r.Query))
+		for _, s := range r.Documents {
+			hashes = append(hashes, HashInput(s))
+		}
+	case *rerankSharedRequest:
+		hashes = append(hashes, HashInput(r.Query))
+		var docs []string
+		json.Unmarshal(r.Documents, &docs)
+		for _, s := range docs {
+			hashes = append(hashes, HashInput(s))
+		}
+	case *ContextualizedEmbeddingRequest:
+		for _, chunks := range r.Inputs {
+			for _, s := range chunks {
+				hashes = append(hashes, HashInput(s))
+			}
+		}
+	case *MultimodalRerankRequest:
+		for _, in := range append([]MultimodalContent{r.Query}, r.Documents...) {
+			b, _ := json.Marshal(in)
+			hashes = append(hashes, HashInput(string(b)))
+		}
+	case *MultimodalRequest:
+		for _, in := range r.Inputs {
+			b, _ := json.Marshal(in)
+			hashes = append(hashes, HashInput(string(b)))
+		}
+	}
+	return hashes
+}
+
+// An [AuditSink] that appends events as JSON lines to a file, rotating it by size. Every event
+// is synced to disk before Record returns.
+type JSONLAuditSink struct {
+	path     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewJSONLAuditSink opens the file at path for appending, creating it if needed. Once writing
+// an event would grow the file beyond maxBytes, the file is renamed to path followed by a dot
+// and the UTC time of the rotation, and a new file is started. Files are never rotated if
+// maxBytes is not positive.
+func NewJSONLAuditSink(path string, maxBytes int64) (*JSONLAuditSink, error) {
+	s := &JSONLAuditSink{path: path, maxBytes: maxBytes}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *JSONLAuditSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, fi.Size()
+	return nil
+}
+
+func (s *JSONLAuditSink) Record(ctx context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return os.ErrClosed
+	}
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// rotate moves the current file aside and opens a new one.
+func (s *JSONLAuditSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	s.f = nil
+	stamp := time.Now().UTC().Format("20060102T150405.000000000Z")
+	name := s.path + "." + stamp
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s.%s-%d", s.path, stamp, i)
+	}
+	if err := os.Rename(s.path, name); err != nil {
+		// Keep appending to the current file rather than losing events.
+		if openErr := s.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	return s.open()
+}
+
+// Close closes the current file. Further events fail with [os.ErrClosed].
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
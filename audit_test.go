@@ -0,0 +1,169 @@
+package voyageai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// recordingSink is an AuditSink that keeps every event and fails with err if set.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []voyageai.AuditEvent
+	err    error
+}
+
+func (s *recordingSink) Record(ctx context.Context, ev voyageai.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, ev)
+	return s.err
+}
+
+func TestAuditEvents(t *testing.T) {
+	api := newMockServer(t)
+	api.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if req.Input[0] == "bad" {
+			return 400
+		}
+		return 0
+	}
+	sink := &recordingSink{}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: api.URL,
+		Audit:   &voyageai.AuditOpts{Sink: sink},
+	})
+
+	ctx := voyageai.WithTenant(context.Background(), voyageai.Tenant{ID: "acme"})
+	if _, err := client.EmbedContext(ctx, []string{"hello", "world"}, "voyage-3", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.Embed([]string{"bad"}, "voyage-3", nil); err == nil {
+		t.Fatal("Expected the bad request to fail")
+	}
+	if _, err := client.Rerank("query", []string{"doc"}, "rerank-2", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(sink.events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(sink.events))
+	}
+	ok := sink.events[0]
+	if ok.Time.IsZero() || ok.Duration < 0 {
+		t.Errorf("Expected a timestamp and duration, got %v and %v", ok.Time, ok.Duration)
+	}
+	ok.Time, ok.Duration = ok.Time.UTC(), 0
+	want := voyageai.AuditEvent{
+		Time:        ok.Time,
+		Endpoint:    "embeddings",
+		Model:       "voyage-3",
+		InputHashes: []string{voyageai.HashInput("hello"), voyageai.HashInput("world")},
+		Usage:       voyageai.UsageObject{TotalTokens: 10},
+		Attempts:    1,
+		StatusCode:  200,
+		RequestID:   "req-1",
+		TenantID:    "acme",
+	}
+	if !reflect.DeepEqual(ok, want) {
+		t.Errorf("Expected %+v, got %+v", want, ok)
+	}
+
+	failed := sink.events[1]
+	if failed.StatusCode != 400 || failed.RequestID != "req-2" || !strings.Contains(failed.Error, "400") || failed.TenantID != "" {
+		t.Errorf("Expected a failed event without tenant, got %+v", failed)
+	}
+	if !reflect.DeepEqual(failed.Usage, voyageai.UsageObject{}) {
+		t.Errorf("Expected no usage for a failed call, got %+v", failed.Usage)
+	}
+
+	rerank := sink.events[2]
+	if rerank.Endpoint != "rerank" || !reflect.DeepEqual(rerank.InputHashes, []string{voyageai.HashInput("query"), voyageai.HashInput("doc")}) {
+		t.Errorf("Expected hashes of the query and document, got %+v", rerank)
+	}
+}
+
+func TestAuditSinkFailure(t *testing.T) {
+	api := newMockServer(t)
+	sink := &recordingSink{err: errors.New("disk full")}
+	var logs bytes.Buffer
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: api.URL,
+		Audit:   &voyageai.AuditOpts{Sink: sink},
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if _, err := client.Embed([]string{"a"}, "voyage-3", nil); err != nil {
+		t.Errorf("Expected a sink failure not to fail the call, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "disk full") {
+		t.Errorf("Expected the sink failure to be logged, got %q", logs.String())
+	}
+
+	strict := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: api.URL,
+		Audit:   &voyageai.AuditOpts{Sink: sink, Strict: true},
+	})
+	_, err := strict.Embed([]string{"a"}, "voyage-3", nil)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the sink failure to fail the call in strict mode, got %v", err)
+	}
+}
+
+func TestJSONLAuditSinkRotates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	sink, err := voyageai.NewJSONLAuditSink(path, 400)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer sink.Close()
+
+	for i := range 5 {
+		ev := voyageai.AuditEvent{Endpoint: "embeddings", Model: "voyage-3", Attempts: i + 1, InputHashes: []string{voyageai.HashInput("x")}}
+		if err := sink.Record(context.Background(), ev); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	files, _ := filepath.Glob(path + "*")
+	if len(files) < 2 {
+		t.Fatalf("Expected the log to rotate, got %v", files)
+	}
+	var attempts []int
+	for _, f := range files {
+		info, _ := os.Stat(f)
+		if info.Size() > 400 {
+			t.Errorf("Expected %s to stay within 400 bytes, got %d", f, info.Size())
+		}
+		data, _ := os.ReadFile(f)
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			var ev voyageai.AuditEvent
+			if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+				t.Fatalf("Expected JSON lines in %s, got %q", f, sc.Text())
+			}
+			attempts = append(attempts, ev.Attempts)
+		}
+	}
+	if len(attempts) != 5 {
+		t.Errorf("Expected all 5 events across the files, got %v", attempts)
+	}
+
+	sink.Close()
+	if err := sink.Record(context.Background(), voyageai.AuditEvent{}); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected os.ErrClosed after Close, got %v", err)
+	}
+}
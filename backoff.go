@@ -0,0 +1,40 @@
+package voyageai
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/zamedic/voyageai/resilience"
+)
+
+// Defaults of [VoyageClientOpts.RetryBaseDelay] and [VoyageClientOpts.RetryMaxDelay].
+const (
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// retryBackoff returns the wait before each retry of a request with cfg: the constant
+// [RequestConfig.Backoff] if set, or the client's exponential backoff, but at least as long as a
+// rate limited response asked with its Retry-After header.
+func (c *VoyageClient) retryBackoff(cfg RequestConfig) func(int, error) time.Duration {
+	backoff := resilience.ConstantBackoff(cfg.Backoff)
+	if cfg.Backoff <= 0 {
+		exp := resilience.Exponential{Base: c.opts.RetryBaseDelay, Max: c.opts.RetryMaxDelay, Multiplier: c.opts.RetryMultiplier}
+		if exp.Base == 0 {
+			exp.Base = DefaultRetryBaseDelay
+		}
+		if exp.Max <= 0 {
+			exp.Max = DefaultRetryMaxDelay
+		}
+		backoff = exp.Delay
+	}
+	return func(retry int, err error) time.Duration {
+		d := backoff(retry, err)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+			d = max(d, apiErr.RetryAfter)
+		}
+		return d
+	}
+}
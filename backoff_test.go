@@ -0,0 +1,99 @@
+package voyageai_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// rateLimitedServer answers the first limited requests with 429 and a Retry-After of one second,
+// and later ones with fakeVector embeddings.
+func rateLimitedServer(t *testing.T, limited int32) (*httptest.Server, *atomic.Int32) {
+	var count atomic.Int32
+	api := newMockServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) <= limited {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"detail":"slow down"}`))
+			return
+		}
+		api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, count := rateLimitedServer(t, 2)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 3, RetryBaseDelay: time.Millisecond})
+
+	start := time.Now()
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed < 2*time.Second {
+		t.Errorf("Expected two waits of a second each, took %v", elapsed)
+	}
+}
+
+func TestRetryAfterExhausted(t *testing.T) {
+	srv, count := rateLimitedServer(t, 100)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+
+	start := time.Now()
+	_, err := client.Embed([]string{"a"}, "voyage-3.5", nil)
+	var apiErr *voyageai.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 || string(apiErr.Response) != `{"detail":"slow down"}` {
+		t.Fatalf("Expected the last APIError, got %v", err)
+	}
+	if apiErr.Header.Get("Retry-After") != "1" {
+		t.Errorf("Expected the response headers, got %v", apiErr.Header)
+	}
+	if apiErr.RetryAfter != time.Second {
+		t.Errorf("Expected a parsed Retry-After of 1s, got %v", apiErr.RetryAfter)
+	}
+	if n := count.Load(); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected a wait of a second, took %v", elapsed)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n <= 3 {
+			return 500
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:            "APIKEY",
+		BaseURL:        srv.URL,
+		MaxRetries:     4,
+		RetryBaseDelay: 40 * time.Millisecond,
+		RetryMaxDelay:  80 * time.Millisecond,
+	})
+
+	start := time.Now()
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	// Waits of 40ms, 80ms and 80ms, each jittered down to no less than half.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the retries to back off, took %v", elapsed)
+	}
+	if n := srv.requestCount(); n != 4 {
+		t.Errorf("Expected 4 attempts, got %d", n)
+	}
+}
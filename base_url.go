@@ -0,0 +1,33 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type baseURLKey struct{}
+
+// WithBaseURL returns a copy of ctx that makes calls using it send their requests, including
+// retries, to the API at u instead of the client's [VoyageClientOpts.BaseURL]. Other calls are
+// unaffected. u must be an absolute http or https URL; it is checked when a call is made.
+func WithBaseURL(ctx context.Context, u string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, u)
+}
+
+// endpointURL returns the URL of the endpoint at path under the base URL of ctx or the client.
+func (c *VoyageClient) endpointURL(ctx context.Context, path string) (string, error) {
+	base, ok := ctx.Value(baseURLKey{}).(string)
+	if !ok {
+		return c.baseURL + path, nil
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("voyage: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("voyage: invalid base URL %q: must be an absolute http or https URL", base)
+	}
+	return strings.TrimSuffix(base, "/") + path, nil
+}
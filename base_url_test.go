@@ -0,0 +1,74 @@
+package voyageai_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestWithBaseURL(t *testing.T) {
+	primary := newMockServer(t)
+	staging := newMockServer(t)
+	mirror := newMockServer(t)
+	mirror.fail = func(n int, _ voyageai.EmbeddingRequest) int {
+		if n == 1 {
+			return 503
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: primary.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+
+	var wg sync.WaitGroup
+	for _, target := range []struct {
+		srv  *mockServer
+		base string
+		text string
+	}{
+		{staging, staging.URL, "to staging"},
+		{mirror, mirror.URL + "/", "to mirror"},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := voyageai.WithBaseURL(context.Background(), target.base)
+			if _, err := client.EmbedContext(ctx, []string{target.text}, "voyage-3", nil); err != nil {
+				t.Error(err.Error())
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := client.EmbedContext(context.Background(), []string{"to primary"}, "voyage-3", nil); err != nil {
+			t.Error(err.Error())
+		}
+	}()
+	wg.Wait()
+
+	for srv, want := range map[*mockServer][]string{
+		primary: {"to primary"},
+		staging: {"to staging"},
+		mirror:  {"to mirror", "to mirror"}, // The retry goes to the override too.
+	} {
+		if got := srv.inputs(); len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWithBaseURLInvalid(t *testing.T) {
+	srv := newMockServer(t)
+	client := srv.client()
+	for _, base := range []string{"not a url", "ftp://example.com", "http://", "://missing"} {
+		ctx := voyageai.WithBaseURL(context.Background(), base)
+		if _, err := client.RerankContext(ctx, "q", []string{"d"}, "rerank-2", nil); err == nil {
+			t.Errorf("Expected %q to be rejected", base)
+		}
+	}
+	if srv.rerankCount() != 0 {
+		t.Error("Expected no requests to be sent")
+	}
+}
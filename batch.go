@@ -0,0 +1,402 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The default number of texts sent per request by [VoyageClient.EmbedBatch].
+// The API accepts up to 1000 texts per request, subject to the model's token limit.
+const DefaultBatchSize = 128
+
+// Optional arguments for [VoyageClient.EmbedBatch].
+type BatchOpts struct {
+	// The maximum number of texts per request. Defaults to [DefaultBatchSize], and is capped at
+	// the model's [ModelInfo.MaxBatchInputs] in the client's registry.
+	BatchSize    int
+	Checkpointer Checkpointer // Records completed ranges so an interrupted run can be resumed. No checkpointing is done by default.
+	// The maximum number of tokens per request, as counted by the client's [Tokenizer]. A text
+	// with more tokens is sent on its own. Defaults to, and is capped at, the model's
+	// [ModelInfo.MaxBatchTokens] in the client's registry; unlimited if neither is set.
+	MaxBatchTokens int
+
+	// If set, each completed request's embeddings are written to ResultWriter as they arrive,
+	// serialized as ResultFormat, instead of being kept in memory. The response returned by the
+	// batch call then has no Data, only the aggregated usage.
+	ResultWriter io.Writer
+	ResultFormat ResultFormat
+
+	// Paces the requests so they are spread evenly over this duration, in proportion to the number
+	// of inputs in each, instead of being sent as fast as possible. The last request is sent
+	// shortly before the window ends.
+	SpreadOver time.Duration
+	// Paces the requests so no more than this many are sent per minute. Unlimited by default.
+	RequestsPerMinute float64
+	// Called after every completed request.
+	OnProgress func(BatchProgress)
+	// Skip the inputs of a request that fails after its retries instead of stopping the run. The
+	// response then has no embeddings for them, and the batch call returns it along with the
+	// errors of the failed requests joined. Record the failed inputs with [WithFailureReport].
+	ContinueOnError bool
+	// The maximum number of requests of the run in flight at once. Defaults to 1, which sends
+	// them one after another. With more, requests complete in any order: results are written,
+	// checkpointed and reported to OnProgress as they arrive, one at a time. The client's
+	// [VoyageClientOpts.MaxConcurrentRequests] still applies.
+	Concurrency int
+}
+
+// A half-open range [Start, End) of input indices.
+type batchRange struct {
+	Start int
+	End   int
+}
+
+// batchLimits returns the maximum texts and tokens per request of a batch run with model: those of
+// batchOpts, lowered to the model's [ModelInfo.MaxBatchInputs] and [ModelInfo.MaxBatchTokens] in
+// the client's registry. A zero token limit is unlimited.
+func (c *VoyageClient) batchLimits(model string, batchOpts *BatchOpts) (size, maxTokens int) {
+	size, maxTokens = batchOpts.BatchSize, batchOpts.MaxBatchTokens
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if resolved, err := c.ResolveModel(model); err == nil {
+		model = resolved
+	}
+	if info, ok := c.lookupModel(model); ok {
+		if info.MaxBatchInputs > 0 {
+			size = min(size, info.MaxBatchInputs)
+		}
+		if info.MaxBatchTokens > 0 && (maxTokens <= 0 || info.MaxBatchTokens < maxTokens) {
+			maxTokens = info.MaxBatchTokens
+		}
+	}
+	return size, maxTokens
+}
+
+// splitBatches splits each of the given ranges into consecutive ranges of at most size indices.
+func splitBatches(pending []batchRange, size int) []batchRange {
+	var ranges []batchRange
+	for _, p := range pending {
+		for start := p.Start; start < p.End; start += size {
+			ranges = append(ranges, batchRange{Start: start, End: min(start+size, p.End)})
+		}
+	}
+	return ranges
+}
+
+// splitInputs splits the pending ranges of texts into requests of at most size texts and
+// maxTokens tokens. If maxTokens is positive or count is set, it also returns the tokens of each
+// request.
+func (c *VoyageClient) splitInputs(texts []string, model string, pending []batchRange, size, maxTokens int, count bool) ([]batchRange, []int, error) {
+	if maxTokens <= 0 && !count {
+		return splitBatches(pending, size), nil, nil
+	}
+	var ranges []batchRange
+	var tokens []int
+	for _, p := range pending {
+		cur, curTokens := batchRange{Start: p.Start, End: p.Start}, 0
+		for i := p.Start; i < p.End; i++ {
+			n, err := c.countTokens(model, texts[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			full := cur.End-cur.Start >= size || maxTokens > 0 && curTokens+n > maxTokens
+			if cur.End > cur.Start && full {
+				ranges, tokens = append(ranges, cur), append(tokens, curTokens)
+				cur, curTokens = batchRange{Start: i, End: i}, 0
+			}
+			cur.End++
+			curTokens += n
+		}
+		if cur.End > cur.Start {
+			ranges, tokens = append(ranges, cur), append(tokens, curTokens)
+		}
+	}
+	return ranges, tokens, nil
+}
+
+// EmbedBatch embeds an arbitrarily large list of texts by splitting it into requests of at most
+// [BatchOpts.BatchSize] texts and [BatchOpts.MaxBatchTokens] tokens. The returned [EmbeddingResponse] contains one [EmbeddingObject] per
+// input, indexed by its position in texts, and the usage aggregated over every request.
+//
+// When a [Checkpointer] is configured, progress is saved after every completed request and a
+// previously interrupted run over the same inputs resumes where it left off: completed ranges are
+// not sent again and usage continues from the saved totals. With a [BatchOpts.ResultWriter], the
+// checkpoint only records the completed ranges and [CheckpointState.ResultBytes], the length of
+// the output written for them. Results are written before the checkpoint is saved, so a run
+// interrupted between the two writes a range again when resumed, unless the output is first
+// truncated to ResultBytes. Without a ResultWriter the embeddings are kept in the checkpoint,
+// which limits the run to [MaxCheckpointEmbeddings] inputs.
+//
+// A request that fails stops the run, unless [BatchOpts.ContinueOnError] is set. Failed inputs
+// are written to the failure report of ctx; see [WithFailureReport].
+//
+// Requests are sent one after another unless [BatchOpts.Concurrency] allows more in flight. They
+// can be paced with [BatchOpts.SpreadOver] and [BatchOpts.RequestsPerMinute] using the
+// client's [Clock]. Cancelling ctx stops the run while it waits for the next request to be due.
+// To shut a run down gracefully instead, use a [BatchRunner].
+func (c *VoyageClient) EmbedBatch(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts, batchOpts *BatchOpts) (*EmbeddingResponse, error) {
+	r := c.NewBatchRunner(texts, model, opts, batchOpts)
+	r.run(ctx)
+	return r.resp, r.err
+}
+
+// run performs the batch job. Requests are sent with ctx, and no new request is dispatched once
+// r.dispatchCtx is done.
+func (r *BatchRunner) run(ctx context.Context) {
+	defer close(r.done)
+	reqCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	dispatchCtx, stopDispatch := context.WithCancel(reqCtx)
+	defer stopDispatch()
+	r.mu.Lock()
+	r.abort, r.stopDispatch = abort, stopDispatch
+	if r.stopping {
+		stopDispatch()
+	}
+	r.mu.Unlock()
+
+	r.resp, r.err = r.embed(ctx, reqCtx, dispatchCtx)
+}
+
+func (r *BatchRunner) embed(ctx, reqCtx, dispatchCtx context.Context) (*EmbeddingResponse, error) {
+	c, texts, model, opts, batchOpts := r.client, r.texts, r.model, r.opts, r.batchOpts
+	if batchOpts == nil {
+		batchOpts = &BatchOpts{}
+	}
+	size, maxTokens := c.batchLimits(model, batchOpts)
+
+	var results *resultWriter
+	if batchOpts.ResultWriter != nil {
+		var err error
+		if results, err = newResultWriter(batchOpts.ResultWriter, batchOpts.ResultFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	if batchOpts.Checkpointer != nil && results == nil && len(texts) > MaxCheckpointEmbeddings {
+		return nil, fmt.Errorf("voyage: checkpointing %d inputs requires a ResultWriter, as at most %d embeddings are kept in a checkpoint", len(texts), MaxCheckpointEmbeddings)
+	}
+	state := &CheckpointState{
+		Model:       model,
+		Fingerprint: fingerprintInputs(model, texts),
+		Total:       len(texts),
+	}
+	if cp := batchOpts.Checkpointer; cp != nil {
+		saved, ok, err := cp.Load()
+		if err != nil {
+			return nil, fmt.Errorf("voyage: load checkpoint: %w", err)
+		}
+		if ok {
+			if saved.Fingerprint != state.Fingerprint {
+				return nil, fmt.Errorf("voyage: checkpoint does not match the inputs of this run")
+			}
+			state = saved
+		}
+	}
+
+	ranges := r.ranges
+	if ranges == nil {
+		var err error
+		if ranges, _, err = c.splitInputs(texts, model, state.pending(), size, maxTokens, false); err != nil {
+			return nil, err
+		}
+	}
+	pacer := newBatchPacer(c.clock(), batchOpts, ranges)
+	completed := len(texts) - pacer.total
+	r.setCompleted(completed)
+	report := failureReporter(ctx)
+
+	// The first error that ends the run cancels the requests in flight and stops dispatching.
+	reqCtx, cancelRequests := context.WithCancel(reqCtx)
+	defer cancelRequests()
+	dispatchCtx, stopDispatch := context.WithCancel(dispatchCtx)
+	defer stopDispatch()
+	var (
+		mu          sync.Mutex // Guards the state below, the pacer, results and the checkpoint.
+		wg          sync.WaitGroup
+		failures    []error
+		fatal       error
+		interrupted bool // A request failed because the run is being stopped.
+	)
+	fail := func(err error) {
+		if fatal == nil {
+			fatal = err
+			cancelRequests()
+			stopDispatch()
+		}
+	}
+
+	send := func(rg batchRange) {
+		sent := c.clock().Now()
+		var outcome requestOutcome
+		resp, err := c.embedContext(withRequestOutcome(reqCtx, &outcome), texts[rg.Start:rg.End], model, opts)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if fatal != nil {
+			return
+		}
+		if err != nil && r.isStopping() && ctx.Err() == nil {
+			interrupted = true
+			return
+		}
+		if err != nil && reqCtx.Err() != nil {
+			fail(fmt.Errorf("voyage: embed inputs %d-%d: %w", rg.Start, rg.End-1, err))
+			return
+		}
+
+		embs := make([][]float32, rg.End-rg.Start)
+		if err == nil {
+			for _, obj := range resp.Data {
+				if obj.Index < 0 || obj.Index >= len(embs) {
+					err = fmt.Errorf("response index %d out of range", obj.Index)
+					break
+				}
+				embs[obj.Index] = obj.Embedding
+			}
+		}
+		if err != nil {
+			if report != nil {
+				indices := make([]int, rg.End-rg.Start)
+				for i := range indices {
+					indices[i] = rg.Start + i
+				}
+				if err := report.write(c.clock().Now(), "embeddings", model, indices, texts[rg.Start:rg.End], nil, &outcome, err); err != nil {
+					fail(fmt.Errorf("voyage: write failure report: %w", err))
+					return
+				}
+			}
+			err = fmt.Errorf("voyage: embed inputs %d-%d: %w", rg.Start, rg.End-1, err)
+			if !batchOpts.ContinueOnError {
+				fail(err)
+				return
+			}
+			failures = append(failures, err)
+			pacer.done(c.clock().Now().Sub(sent))
+			return
+		}
+
+		if results != nil {
+			n, err := results.write(model, rg.Start, texts[rg.Start:rg.End], embs)
+			if err != nil {
+				fail(fmt.Errorf("voyage: write results: %w", err))
+				return
+			}
+			state.ResultBytes += n
+			state.markDone(rg.Start, rg.End)
+		} else {
+			state.Completed = append(state.Completed, CompletedRange{Start: rg.Start, End: rg.End, Embeddings: embs})
+		}
+		state.Usage = addUsage(state.Usage, resp.Usage)
+
+		if cp := batchOpts.Checkpointer; cp != nil {
+			if err := cp.Save(state); err != nil {
+				fail(fmt.Errorf("voyage: save checkpoint: %w", err))
+				return
+			}
+		}
+
+		pacer.done(c.clock().Now().Sub(sent))
+		completed += rg.End - rg.Start
+		r.setCompleted(completed)
+		if batchOpts.OnProgress != nil {
+			batchOpts.OnProgress(BatchProgress{
+				Completed:           completed,
+				Total:               len(texts),
+				Usage:               state.Usage,
+				ProjectedCompletion: pacer.projected(),
+			})
+		}
+	}
+
+	slots := make(chan struct{}, max(batchOpts.Concurrency, 1))
+	var dispatchErr error
+	for _, rg := range ranges {
+		select {
+		case slots <- struct{}{}:
+		case <-dispatchCtx.Done():
+		}
+		err := dispatchCtx.Err()
+		if err == nil {
+			err = pacer.wait(dispatchCtx)
+		}
+		if err != nil {
+			dispatchErr = err
+			break
+		}
+		mu.Lock()
+		pacer.dispatch()
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			send(rg)
+		}()
+	}
+	wg.Wait()
+
+	if fatal != nil {
+		return nil, fatal
+	}
+	if dispatchErr != nil || interrupted {
+		if r.isStopping() && ctx.Err() == nil {
+			return r.stopped(state, batchOpts.Checkpointer)
+		}
+		if dispatchErr != nil {
+			return nil, dispatchErr
+		}
+	}
+
+	return state.response(), errors.Join(failures...)
+}
+
+// addUsage returns the sum of two usage objects.
+func addUsage(a, b UsageObject) UsageObject {
+	sum := func(x, y *int) *int {
+		if x == nil && y == nil {
+			return nil
+		}
+		var total int
+		if x != nil {
+			total += *x
+		}
+		if y != nil {
+			total += *y
+		}
+		return &total
+	}
+	return UsageObject{
+		TotalTokens: a.TotalTokens + b.TotalTokens,
+		ImagePixels: sum(a.ImagePixels, b.ImagePixels),
+		TextTokens:  sum(a.TextTokens, b.TextTokens),
+	}
+}
+
+// response assembles the completed ranges into a single response ordered by input index.
+func (s *CheckpointState) response() *EmbeddingResponse {
+	resp := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]EmbeddingObject, 0, s.Total),
+		Model:  s.Model,
+		Usage:  s.Usage,
+	}
+	ranges := append([]CompletedRange(nil), s.Completed...)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	for _, r := range ranges {
+		for i, emb := range r.Embeddings {
+			resp.Data = append(resp.Data, EmbeddingObject{
+				Object:    "embedding",
+				Embedding: emb,
+				Index:     r.Start + i,
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,111 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// A batch job planned with [VoyageClient.PlanEmbedBatch], for review before it is run with
+// [BatchPlan.Execute]. Plans can be stored and reviewed as JSON.
+type BatchPlan struct {
+	Model    string                `json:"model"` // The model, with aliases resolved.
+	Opts     *EmbeddingRequestOpts `json:"opts,omitempty"`
+	Texts    []string              `json:"texts"`
+	Requests []PlannedRequest      `json:"requests"`
+
+	TotalTokens   int     `json:"total_tokens"`   // The estimated tokens of all requests.
+	EstimatedCost float64 `json:"estimated_cost"` // The cost in US dollars estimated with [EstimateCost]. Zero for models without a known price.
+	// The shortest time the requests can be sent in under the pacing below and the client's
+	// [RateLimitOpts] limits, excluding the time the requests themselves take.
+	ProjectedDuration time.Duration `json:"projected_duration_ns"`
+
+	// The pacing of [BatchOpts], applied by Execute.
+	SpreadOver        time.Duration `json:"spread_over_ns,omitempty"`
+	RequestsPerMinute float64       `json:"requests_per_minute,omitempty"`
+}
+
+// One request of a [BatchPlan].
+type PlannedRequest struct {
+	Start  int `json:"start"`  // The index of the first text in the request.
+	End    int `json:"end"`    // The index after the last text in the request.
+	Tokens int `json:"tokens"` // The estimated tokens, as counted by the client's [Tokenizer].
+}
+
+// PlanEmbedBatch plans the requests [VoyageClient.EmbedBatch] would send for texts, with their
+// estimated tokens, cost and duration. No request is made. The Checkpointer, ResultWriter and
+// OnProgress fields of batchOpts are not part of the plan.
+func (c *VoyageClient) PlanEmbedBatch(texts []string, model string, opts *EmbeddingRequestOpts, batchOpts *BatchOpts) (*BatchPlan, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if batchOpts == nil {
+		batchOpts = &BatchOpts{}
+	}
+	size, maxTokens := c.batchLimits(model, batchOpts)
+	ranges, tokens, err := c.splitInputs(texts, model, []batchRange{{Start: 0, End: len(texts)}}, size, maxTokens, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &BatchPlan{
+		Model:             model,
+		Opts:              opts,
+		Texts:             texts,
+		SpreadOver:        batchOpts.SpreadOver,
+		RequestsPerMinute: batchOpts.RequestsPerMinute,
+	}
+	for i, rg := range ranges {
+		p.Requests = append(p.Requests, PlannedRequest{Start: rg.Start, End: rg.End, Tokens: tokens[i]})
+		p.TotalTokens += tokens[i]
+	}
+	p.EstimatedCost, _ = c.estimateCost(model, p.TotalTokens)
+	p.ProjectedDuration = c.projectDuration(p)
+	return p, nil
+}
+
+// projectDuration returns the time needed to send the requests of p under its pacing and the
+// limits of the client's default limiter. A custom [Limiter] is not accounted for.
+func (c *VoyageClient) projectDuration(p *BatchPlan) time.Duration {
+	n := len(p.Requests)
+	if n == 0 {
+		return 0
+	}
+	d := p.SpreadOver
+	if p.RequestsPerMinute > 0 {
+		d = max(d, time.Duration(float64(n-1)/p.RequestsPerMinute*float64(time.Minute)))
+	}
+	// The default limiter starts with a minute's worth of requests and tokens.
+	if rl := c.opts.RateLimit; rl != nil && rl.Limiter == nil {
+		if rl.RequestsPerMinute > 0 && float64(n) > rl.RequestsPerMinute {
+			d = max(d, time.Duration((float64(n)-rl.RequestsPerMinute)/rl.RequestsPerMinute*float64(time.Minute)))
+		}
+		if tpm := rl.TokensPerMinute; tpm > 0 && float64(p.TotalTokens) > tpm {
+			d = max(d, time.Duration((float64(p.TotalTokens)-tpm)/tpm*float64(time.Minute)))
+		}
+	}
+	return d
+}
+
+// Execute sends exactly the requests of the plan with client and returns the results as
+// [VoyageClient.EmbedBatch] would.
+func (p *BatchPlan) Execute(ctx context.Context, client *VoyageClient) (*EmbeddingResponse, error) {
+	next := 0
+	ranges := make([]batchRange, len(p.Requests))
+	for i, req := range p.Requests {
+		if req.Start != next || req.End <= req.Start || req.End > len(p.Texts) {
+			return nil, fmt.Errorf("voyage: plan request %d covers texts %d-%d, expected a range starting at %d", i, req.Start, req.End, next)
+		}
+		ranges[i] = batchRange{Start: req.Start, End: req.End}
+		next = req.End
+	}
+	if next != len(p.Texts) {
+		return nil, fmt.Errorf("voyage: plan covers %d of %d texts", next, len(p.Texts))
+	}
+
+	r := client.NewBatchRunner(p.Texts, p.Model, p.Opts, &BatchOpts{SpreadOver: p.SpreadOver, RequestsPerMinute: p.RequestsPerMinute})
+	r.ranges = ranges
+	r.run(ctx)
+	return r.resp, r.err
+}
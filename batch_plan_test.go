@@ -0,0 +1,100 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestPlanEmbedBatch(t *testing.T) {
+	api := newMockServer(t)
+	client := newBudgetClient(api, voyageai.VoyageClientOpts{
+		RateLimit: &voyageai.RateLimitOpts{RequestsPerMinute: 2},
+	})
+	// Text i has i+1 bytes, so i+1 tokens.
+	var texts []string
+	for i := range 10 {
+		texts = append(texts, strings.Repeat("x", i+1))
+	}
+	texts = append(texts, strings.Repeat("y", 40)) // Over the token limit on its own.
+
+	plan, err := client.PlanEmbedBatch(texts, "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 3, MaxBatchTokens: 15})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	want := []voyageai.PlannedRequest{
+		{Start: 0, End: 3, Tokens: 6},
+		{Start: 3, End: 6, Tokens: 15},
+		{Start: 6, End: 8, Tokens: 15},
+		{Start: 8, End: 9, Tokens: 9},
+		{Start: 9, End: 10, Tokens: 10},
+		{Start: 10, End: 11, Tokens: 40},
+	}
+	if !reflect.DeepEqual(plan.Requests, want) {
+		t.Fatalf("Expected requests %+v, got %+v", want, plan.Requests)
+	}
+	cost, _ := voyageai.EstimateCost("voyage-3.5", 95)
+	if plan.TotalTokens != 95 || plan.EstimatedCost != cost {
+		t.Errorf("Expected 95 tokens costing %v, got %d and %v", cost, plan.TotalTokens, plan.EstimatedCost)
+	}
+	// Six requests at two per minute, with the first two sent at once.
+	if plan.ProjectedDuration != 2*time.Minute {
+		t.Errorf("Expected a projected duration of 2m, got %v", plan.ProjectedDuration)
+	}
+
+	// Plans survive a round trip through JSON.
+	b, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var reviewed voyageai.BatchPlan
+	if err := json.Unmarshal(b, &reviewed); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(&reviewed, plan) {
+		t.Errorf("Expected the plan to round trip, got %+v", reviewed)
+	}
+}
+
+func TestBatchPlanExecute(t *testing.T) {
+	api := newMockServer(t)
+	client := newBudgetClient(api, voyageai.VoyageClientOpts{})
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	plan, err := client.PlanEmbedBatch(texts, "voyage-3.5", nil, &voyageai.BatchOpts{MaxBatchTokens: 5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	resp, err := plan.Execute(context.Background(), client)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(api.requests) != len(plan.Requests) {
+		t.Fatalf("Expected %d requests, got %d", len(plan.Requests), len(api.requests))
+	}
+	for i, req := range api.requests {
+		planned := plan.Requests[i]
+		if !reflect.DeepEqual(req.Input, texts[planned.Start:planned.End]) {
+			t.Errorf("Request %d: expected %v, got %v", i, texts[planned.Start:planned.End], req.Input)
+		}
+	}
+	if len(resp.Data) != len(texts) || resp.Usage.TotalTokens != plan.TotalTokens {
+		t.Errorf("Expected %d embeddings and %d tokens, got %d and %d", len(texts), plan.TotalTokens, len(resp.Data), resp.Usage.TotalTokens)
+	}
+	for i, obj := range resp.Data {
+		if obj.Index != i {
+			t.Errorf("Expected embeddings in input order, got index %d at %d", obj.Index, i)
+		}
+	}
+
+	// A plan that no longer covers its texts is rejected.
+	plan.Requests = plan.Requests[1:]
+	if _, err := plan.Execute(context.Background(), client); err == nil {
+		t.Error("Expected an error for a plan with a gap")
+	}
+}
@@ -0,0 +1,136 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Returned by [BatchRunner.Wait] when the run was stopped with [BatchRunner.Stop] before every
+// input was embedded.
+var ErrBatchStopped = errors.New("voyage: batch run stopped")
+
+// A batch run that can be shut down gracefully, for example when a service receives SIGTERM.
+// It runs the same job as [VoyageClient.EmbedBatch], which uses a BatchRunner internally.
+//
+// [VoyageClient.EmbedStream] already drains gracefully: closing its input channel flushes the
+// pending inputs and lets the requests in flight finish.
+type BatchRunner struct {
+	client    *VoyageClient
+	texts     []string
+	model     string
+	opts      *EmbeddingRequestOpts
+	batchOpts *BatchOpts
+	ranges    []batchRange // The requests to send, if planned in advance with [BatchPlan].
+
+	done chan struct{} // Closed when the run has finished.
+	resp *EmbeddingResponse
+	err  error
+
+	mu           sync.Mutex
+	started      bool
+	stopping     bool
+	completed    int
+	abort        context.CancelFunc // Cancels the requests in flight.
+	stopDispatch context.CancelFunc // Prevents further requests.
+}
+
+// The state of a [BatchRunner] after [BatchRunner.Stop].
+type BatchStopReport struct {
+	Completed int // The number of inputs embedded, including those restored from a checkpoint.
+	Remaining int // The number of inputs not embedded.
+}
+
+// NewBatchRunner returns a [BatchRunner] that embeds texts as [VoyageClient.EmbedBatch] would.
+func (c *VoyageClient) NewBatchRunner(texts []string, model string, opts *EmbeddingRequestOpts, batchOpts *BatchOpts) *BatchRunner {
+	return &BatchRunner{
+		client:    c,
+		texts:     texts,
+		model:     model,
+		opts:      opts,
+		batchOpts: batchOpts,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the batch in the background, sending its requests with ctx. It fails if the runner
+// was already started.
+func (r *BatchRunner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return errors.New("voyage: batch runner already started")
+	}
+	r.started = true
+	go r.run(ctx)
+	return nil
+}
+
+// Wait blocks until the run has finished and returns its result, as [VoyageClient.EmbedBatch]
+// would. If the run was stopped, the response holds the inputs completed so far and the error is
+// [ErrBatchStopped].
+func (r *BatchRunner) Wait() (*EmbeddingResponse, error) {
+	<-r.done
+	return r.resp, r.err
+}
+
+// Stop shuts the run down gracefully: no further requests are sent, the requests in flight are
+// allowed to finish and their results are written and checkpointed, and the checkpoint is saved
+// one last time. If drainCtx is done first, the requests in flight are cancelled, their inputs are
+// left for a resumed run and the error of drainCtx is returned.
+//
+// A run stopped before it starts sends no requests.
+func (r *BatchRunner) Stop(drainCtx context.Context) (BatchStopReport, error) {
+	r.mu.Lock()
+	r.stopping = true
+	started, stopDispatch, abort := r.started, r.stopDispatch, r.abort
+	r.mu.Unlock()
+	if !started {
+		return BatchStopReport{Remaining: len(r.texts)}, nil
+	}
+	if stopDispatch != nil {
+		stopDispatch()
+	}
+
+	var err error
+	select {
+	case <-r.done:
+	case <-drainCtx.Done():
+		// The run may not have set abort yet; it checks stopping before sending anything.
+		r.mu.Lock()
+		abort = r.abort
+		r.mu.Unlock()
+		if abort != nil {
+			abort()
+		}
+		<-r.done
+		err = fmt.Errorf("voyage: drain batch run: %w", drainCtx.Err())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return BatchStopReport{Completed: r.completed, Remaining: len(r.texts) - r.completed}, err
+}
+
+func (r *BatchRunner) isStopping() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopping
+}
+
+func (r *BatchRunner) setCompleted(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.completed = n
+}
+
+// stopped saves the checkpoint of a stopped run and returns its partial result.
+func (r *BatchRunner) stopped(state *CheckpointState, cp Checkpointer) (*EmbeddingResponse, error) {
+	if cp != nil {
+		if err := cp.Save(state); err != nil {
+			return nil, fmt.Errorf("voyage: save checkpoint: %w", err)
+		}
+	}
+	return state.response(), ErrBatchStopped
+}
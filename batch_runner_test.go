@@ -0,0 +1,154 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestBatchRunnerStop(t *testing.T) {
+	srv := newMockServer(t)
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	srv.fail = func(n int, _ voyageai.EmbeddingRequest) int {
+		if n == 2 {
+			close(inFlight)
+			<-release
+		}
+		return 0
+	}
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprint("text ", i)
+	}
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	batchOpts := &voyageai.BatchOpts{BatchSize: 2, Checkpointer: cp}
+	client := srv.client()
+
+	r := client.NewBatchRunner(texts, "test-model", nil, batchOpts)
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.Start(context.Background()); err == nil {
+		t.Error("Expected a second Start to fail")
+	}
+	<-inFlight
+
+	stopped := make(chan voyageai.BatchStopReport)
+	drainCtx := &watchedContext{Context: context.Background(), waiting: make(chan struct{})}
+	go func() {
+		report, err := r.Stop(drainCtx)
+		if err != nil {
+			t.Error(err.Error())
+		}
+		stopped <- report
+	}()
+	// Let the request in flight complete once Stop is draining.
+	<-drainCtx.waiting
+	close(release)
+	report := <-stopped
+
+	if report.Completed != 4 || report.Remaining != 6 {
+		t.Errorf("Expected 4 completed and 6 remaining, got %+v", report)
+	}
+	partial, err := r.Wait()
+	if !errors.Is(err, voyageai.ErrBatchStopped) {
+		t.Errorf("Expected ErrBatchStopped, got %v", err)
+	}
+	if len(partial.Data) != 4 {
+		t.Errorf("Expected the in-flight request's results to be kept, got %d", len(partial.Data))
+	}
+	if srv.requestCount() != 2 {
+		t.Errorf("Expected no requests after Stop, got %d", srv.requestCount())
+	}
+
+	state, ok, err := cp.Load()
+	if err != nil || !ok {
+		t.Fatalf("Expected a checkpoint, got %v", err)
+	}
+	if len(state.Completed) != 2 || state.Completed[1].End != 4 {
+		t.Errorf("Expected both completed ranges in the checkpoint, got %+v", state.Completed)
+	}
+
+	// Resuming sends only the remaining inputs and yields every result exactly once.
+	resp, err := client.EmbedBatch(context.Background(), texts, "test-model", nil, batchOpts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := srv.inputs(); !reflect.DeepEqual(got, texts) {
+		t.Errorf("Expected every input to be sent exactly once, got %v", got)
+	}
+	if len(resp.Data) != len(texts) {
+		t.Fatalf("Expected %d results, got %d", len(texts), len(resp.Data))
+	}
+	for i, obj := range resp.Data {
+		if obj.Index != i || !reflect.DeepEqual(obj.Embedding, fakeVector(texts[i])) {
+			t.Errorf("Unexpected result %d: %+v", i, obj)
+		}
+	}
+}
+
+// watchedContext closes waiting the first time its Done method is called.
+type watchedContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func (c *watchedContext) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.waiting) })
+	return c.Context.Done()
+}
+
+func TestBatchRunnerDrainTimeout(t *testing.T) {
+	srv := newMockServer(t)
+	inFlight := make(chan struct{})
+	unblock := make(chan struct{})
+	t.Cleanup(func() { close(unblock) })
+	srv.fail = func(n int, _ voyageai.EmbeddingRequest) int {
+		if n == 2 {
+			close(inFlight)
+			<-unblock
+		}
+		return 0
+	}
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	r := srv.client().NewBatchRunner([]string{"a", "b", "c", "d", "e"}, "test-model", nil, &voyageai.BatchOpts{BatchSize: 2, Checkpointer: cp})
+	r.Start(context.Background())
+	<-inFlight
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err := r.Stop(drainCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the drain deadline to be reported, got %v", err)
+	}
+	if report.Completed != 2 || report.Remaining != 3 {
+		t.Errorf("Expected 2 completed and 3 remaining, got %+v", report)
+	}
+	if _, err := r.Wait(); !errors.Is(err, voyageai.ErrBatchStopped) {
+		t.Errorf("Expected ErrBatchStopped, got %v", err)
+	}
+	if state, _, _ := cp.Load(); len(state.Completed) != 1 {
+		t.Errorf("Expected only the first range to be checkpointed, got %+v", state.Completed)
+	}
+}
+
+func TestBatchRunnerStopBeforeStart(t *testing.T) {
+	srv := newMockServer(t)
+	r := srv.client().NewBatchRunner([]string{"a"}, "test-model", nil, nil)
+	if report, err := r.Stop(context.Background()); err != nil || report.Remaining != 1 {
+		t.Errorf("Unexpected stop result %+v, %v", report, err)
+	}
+	r.Start(context.Background())
+	if _, err := r.Wait(); !errors.Is(err, voyageai.ErrBatchStopped) || srv.requestCount() != 0 {
+		t.Errorf("Expected a stopped runner to send nothing, got %v and %d requests", err, srv.requestCount())
+	}
+}
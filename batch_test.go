@@ -0,0 +1,91 @@
+package voyageai_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedBatchConcurrency(t *testing.T) {
+	texts := make([]string, 20)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("document %d", i)
+	}
+
+	srv := newMockServer(t)
+	var inFlight, peak atomic.Int32
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		// Later requests answer first, so results arrive out of order.
+		time.Sleep(time.Duration(10-n%10) * 5 * time.Millisecond)
+		return 0
+	}
+
+	var progress []int
+	resp, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, &voyageai.BatchOpts{
+		BatchSize:   2,
+		Concurrency: 4,
+		OnProgress:  func(p voyageai.BatchProgress) { progress = append(progress, p.Completed) },
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if p := peak.Load(); p < 2 || p > 4 {
+		t.Errorf("Expected between 2 and 4 requests in flight at once, got %d", p)
+	}
+	if len(resp.Data) != len(texts) {
+		t.Fatalf("Expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+	for i, obj := range resp.Data {
+		if obj.Index != i || !slices.Equal(obj.Embedding, fakeVector(texts[i])) {
+			t.Errorf("Embedding %d: wrong index %d or vector", i, obj.Index)
+		}
+	}
+	want := 0
+	for _, text := range texts {
+		want += len(text)
+	}
+	if resp.Usage.TotalTokens != want {
+		t.Errorf("Expected usage of %d tokens, got %d", want, resp.Usage.TotalTokens)
+	}
+	if len(progress) != 10 || progress[9] != len(texts) {
+		t.Errorf("Expected 10 progress reports ending at %d, got %v", len(texts), progress)
+	}
+}
+
+func TestEmbedBatchConcurrencyStopsOnError(t *testing.T) {
+	texts := make([]string, 40)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("document %d", i)
+	}
+
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if req.Input[0] == "document 4" {
+			return 400
+		}
+		time.Sleep(5 * time.Millisecond)
+		return 0
+	}
+
+	_, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, &voyageai.BatchOpts{BatchSize: 2, Concurrency: 3})
+	if err == nil || !strings.Contains(err.Error(), "embed inputs 4-5") {
+		t.Fatalf("Expected the failing request's error, got %v", err)
+	}
+	if n := srv.requestCount(); n >= 20 {
+		t.Errorf("Expected the run to stop dispatching after the failure, sent %d requests", n)
+	}
+}
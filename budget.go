@@ -0,0 +1,148 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Returned, wrapped, when a request would exceed one of the client's budgets. See
+// [VoyageClientOpts.MaxTokensPerRequest], [VoyageClientOpts.MaxTokensPerClient] and
+// [VoyageClientOpts.MaxEstimatedCost].
+var ErrBudgetExceeded = errors.New("voyage: budget exceeded")
+
+// usageTracker accumulates the usage of a client's requests and enforces its budgets.
+type usageTracker struct {
+	mu       sync.Mutex
+	lifetime UsageObject // Everything reported since the client was created.
+
+	// Consumption counted against the budgets since the last reset, and the estimates of
+	// requests currently in flight.
+	tokens, reservedTokens int
+	cost, reservedCost     float64
+}
+
+// Usage returns the usage reported by every successful request made with the client, including
+// requests made through helpers such as [VoyageClient.EmbedBatch]. Cache hits use nothing.
+func (c *VoyageClient) Usage() UsageObject {
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	return addUsage(c.usage.lifetime, UsageObject{}) // Copies the pointer fields.
+}
+
+// ResetBudget clears the consumption counted against [VoyageClientOpts.MaxTokensPerClient] and
+// [VoyageClientOpts.MaxEstimatedCost], allowing requests again. [VoyageClient.Usage] is unaffected.
+func (c *VoyageClient) ResetBudget() {
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	c.usage.tokens, c.usage.cost = 0, 0
+}
+
+func (c *VoyageClient) budgeted() bool {
+	return c.opts.MaxTokensPerRequest > 0 || c.opts.MaxTokensPerClient > 0 || c.opts.MaxEstimatedCost > 0
+}
+
+// reserve admits a request estimated at tokens against the budgets. The estimate counts as
+// consumed until release is called, so concurrent requests cannot jointly overshoot a budget.
+func (c *VoyageClient) reserve(model string, tokens int) (release func(), err error) {
+	if !c.budgeted() {
+		return func() {}, nil
+	}
+	if max := c.opts.MaxTokensPerRequest; max > 0 && tokens > max {
+		return nil, fmt.Errorf("%w: request has an estimated %d tokens, over the per-request cap of %d", ErrBudgetExceeded, tokens, max)
+	}
+	cost, _ := c.estimateCost(model, tokens)
+
+	u := c.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if max := c.opts.MaxTokensPerClient; max > 0 && u.tokens+u.reservedTokens+tokens > max {
+		return nil, fmt.Errorf("%w: %d tokens used and %d in flight, this request needs an estimated %d more, over the cap of %d",
+			ErrBudgetExceeded, u.tokens, u.reservedTokens, tokens, max)
+	}
+	if max := c.opts.MaxEstimatedCost; max > 0 && u.cost+u.reservedCost+cost > max {
+		return nil, fmt.Errorf("%w: $%.6f spent and $%.6f in flight, this request needs an estimated $%.6f more, over the cap of $%.6f",
+			ErrBudgetExceeded, u.cost, u.reservedCost, cost, max)
+	}
+	u.reservedTokens += tokens
+	u.reservedCost += cost
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			u.mu.Lock()
+			defer u.mu.Unlock()
+			u.reservedTokens -= tokens
+			u.reservedCost -= cost
+		})
+	}, nil
+}
+
+// reserveTexts is like reserve with the tokens of texts counted by the client's tokenizer.
+func (c *VoyageClient) reserveTexts(model string, texts ...string) (release func(), err error) {
+	if !c.budgeted() {
+		return func() {}, nil
+	}
+	tokens, err := c.countTokens(model, texts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.reserve(model, tokens)
+}
+
+// reserveRerank reserves the tokens of a rerank request, which bills the query once per document.
+func (c *VoyageClient) reserveRerank(query string, documents []string, model string) (release func(), err error) {
+	if !c.budgeted() {
+		return func() {}, nil
+	}
+	q, err := c.countTokens(model, query)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := c.countTokens(model, documents...)
+	if err != nil {
+		return nil, err
+	}
+	return c.reserve(model, q*len(documents)+docs)
+}
+
+// countTokens returns the total tokens of texts as counted by the client's tokenizer.
+func (c *VoyageClient) countTokens(model string, texts ...string) (int, error) {
+	tok, _ := c.tokenizer()
+	total := 0
+	for _, text := range texts {
+		n, err := tok.CountTokens(model, text)
+		if err != nil {
+			return 0, fmt.Errorf("voyage: count tokens: %w", err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// A response that reports usage.
+type usageReporter interface {
+	reportedUsage() (model string, usage UsageObject)
+}
+
+func (r *EmbeddingResponse) reportedUsage() (string, UsageObject) { return r.Model, r.Usage }
+func (r *RerankResponse) reportedUsage() (string, UsageObject)    { return r.Model, r.Usage }
+
+// recordUsage adds the usage reported by a successful response to the client's totals and those
+// of the call's tenant.
+func (c *VoyageClient) recordUsage(ctx context.Context, respBody any) {
+	r, ok := respBody.(usageReporter)
+	if !ok {
+		return
+	}
+	model, usage := r.reportedUsage()
+	cost, _ := c.estimateCost(model, usage.TotalTokens)
+	c.tenants.addUsage(tenantID(ctx), usage, cost)
+
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	c.usage.lifetime = addUsage(c.usage.lifetime, usage)
+	c.usage.tokens += usage.TotalTokens
+	c.usage.cost += cost
+}
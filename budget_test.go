@@ -0,0 +1,118 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// byteTokenizer counts one token per byte, matching the usage reported by mockServer.
+type byteTokenizer struct{}
+
+func (byteTokenizer) CountTokens(model, text string) (int, error) {
+	return len(text), nil
+}
+
+func newBudgetClient(srv *mockServer, opts voyageai.VoyageClientOpts) *voyageai.VoyageClient {
+	opts.Key, opts.BaseURL, opts.Tokenizer = "APIKEY", srv.URL, byteTokenizer{}
+	return voyageai.NewClient(&opts)
+}
+
+func TestClientTokenBudget(t *testing.T) {
+	srv := newMockServer(t)
+	client := newBudgetClient(srv, voyageai.VoyageClientOpts{MaxTokensPerClient: 100})
+	ten := strings.Repeat("x", 10)
+
+	for i := range 10 {
+		if _, err := client.Embed([]string{ten}, "voyage-3.5", nil); err != nil {
+			t.Fatalf("Call %d: %v", i, err)
+		}
+	}
+	_, err := client.Embed([]string{ten}, "voyage-3.5", nil)
+	if !errors.Is(err, voyageai.ErrBudgetExceeded) {
+		t.Fatalf("Expected the budget to be exhausted, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "100 tokens used") || !strings.Contains(err.Error(), "cap of 100") {
+		t.Errorf("Expected the error to state consumption and cap, got %q", err)
+	}
+	if srv.requestCount() != 10 {
+		t.Errorf("Expected the rejected call not to reach the server, got %d requests", srv.requestCount())
+	}
+	if client.Usage().TotalTokens != 100 {
+		t.Errorf("Expected 100 tokens of usage, got %d", client.Usage().TotalTokens)
+	}
+
+	client.ResetBudget()
+	if _, err := client.Embed([]string{ten}, "voyage-3.5", nil); err != nil {
+		t.Errorf("Expected calls to be allowed after a reset, got %v", err)
+	}
+	if client.Usage().TotalTokens != 110 {
+		t.Errorf("Expected lifetime usage to survive the reset, got %d", client.Usage().TotalTokens)
+	}
+}
+
+func TestClientTokenBudgetConcurrent(t *testing.T) {
+	srv := newMockServer(t)
+	client := newBudgetClient(srv, voyageai.VoyageClientOpts{MaxTokensPerClient: 100})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok, rejected := 0, 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Embed([]string{strings.Repeat("x", 10)}, "voyage-3.5", nil)
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, voyageai.ErrBudgetExceeded) {
+				rejected++
+			} else if err == nil {
+				ok++
+			}
+		}()
+	}
+	wg.Wait()
+	if ok != 10 || rejected != 40 || srv.requestCount() != 10 {
+		t.Errorf("Expected exactly 10 calls within budget, got %d ok, %d rejected, %d requests", ok, rejected, srv.requestCount())
+	}
+}
+
+func TestRequestTokenCap(t *testing.T) {
+	srv := newMockServer(t)
+	client := newBudgetClient(srv, voyageai.VoyageClientOpts{MaxTokensPerRequest: 20})
+
+	if _, err := client.Embed([]string{strings.Repeat("x", 15), strings.Repeat("y", 15)}, "voyage-3.5", nil); !errors.Is(err, voyageai.ErrBudgetExceeded) {
+		t.Errorf("Expected the request to be rejected, got %v", err)
+	}
+	// The query is billed once per document: 5*3 + 3*2 = 21 tokens.
+	if _, err := client.RerankContext(context.Background(), "query", []string{"aa", "bb", "cc"}, "rerank-2", nil); !errors.Is(err, voyageai.ErrBudgetExceeded) {
+		t.Errorf("Expected the rerank to be rejected, got %v", err)
+	}
+	if srv.requestCount() != 0 || srv.rerankCount() != 0 {
+		t.Error("Expected no requests to reach the server")
+	}
+	if _, err := client.Embed([]string{strings.Repeat("x", 20)}, "voyage-3.5", nil); err != nil {
+		t.Errorf("Expected a request at the cap to be allowed, got %v", err)
+	}
+}
+
+func TestEstimatedCostBudget(t *testing.T) {
+	srv := newMockServer(t)
+	// voyage-3.5 costs $0.06 per million tokens, so the budget covers 1000 tokens.
+	client := newBudgetClient(srv, voyageai.VoyageClientOpts{MaxEstimatedCost: 0.00006})
+	text := strings.Repeat("x", 400)
+
+	for range 2 {
+		if _, err := client.Embed([]string{text}, "voyage-3.5", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if _, err := client.Embed([]string{text}, "voyage-3.5", nil); !errors.Is(err, voyageai.ErrBudgetExceeded) {
+		t.Errorf("Expected the cost budget to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package voyageai
+
+import "context"
+
+// The default number of requests [VoyageClient.BulkEmbed] keeps in flight.
+const DefaultBulkWorkers = 4
+
+// Optional arguments for [VoyageClient.BulkEmbed].
+type BulkOpts struct {
+	Workers   int // The number of requests in flight at once. Defaults to [DefaultBulkWorkers].
+	BatchSize int // The maximum number of texts per request, as for [BatchOpts.BatchSize].
+	// Like [VoyageClientOpts.MaxRetries], for each request of the call. Defaults to the client's
+	// setting.
+	MaxRetries int
+	// Called after every completed request, one at a time, with the progress of the whole call.
+	OnProgress func(BatchProgress)
+}
+
+// The embeddings produced by [VoyageClient.BulkEmbed].
+type BulkResult struct {
+	Embeddings [][]float32 // The embedding of each text, in input order. Nil for the texts of failed requests.
+	Failed     []int       // The indices of the texts whose request failed, in ascending order.
+	Usage      UsageObject // The usage aggregated over the successful requests.
+}
+
+// BulkEmbed embeds a large number of texts, such as a whole corpus, with up to
+// [BulkOpts.Workers] requests in flight, and returns their embeddings in input order.
+//
+// Each request is retried on its own, and one that still fails does not stop the others: its
+// texts are listed in [BulkResult.Failed] and the errors of the failed requests are returned
+// joined, along with the result. Cancelling ctx stops the call and returns only its error. For
+// checkpointing, pacing or streaming results to a writer, use [VoyageClient.EmbedBatch], which
+// BulkEmbed is built on.
+func (c *VoyageClient) BulkEmbed(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts, bulkOpts *BulkOpts) (*BulkResult, error) {
+	if bulkOpts == nil {
+		bulkOpts = &BulkOpts{}
+	}
+	workers := bulkOpts.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+	if bulkOpts.MaxRetries > 0 {
+		ctx = WithRequestConfig(ctx, RequestConfig{MaxRetries: bulkOpts.MaxRetries})
+	}
+
+	resp, err := c.EmbedBatch(ctx, texts, model, opts, &BatchOpts{
+		BatchSize:       bulkOpts.BatchSize,
+		Concurrency:     workers,
+		OnProgress:      bulkOpts.OnProgress,
+		ContinueOnError: true,
+	})
+	if resp == nil {
+		return nil, err
+	}
+
+	result := &BulkResult{Embeddings: make([][]float32, len(texts)), Usage: resp.Usage}
+	for _, obj := range resp.Data {
+		result.Embeddings[obj.Index] = obj.Embedding
+	}
+	for i, emb := range result.Embeddings {
+		if emb == nil {
+			result.Failed = append(result.Failed, i)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,67 @@
+package voyageai_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestBulkEmbed(t *testing.T) {
+	srv := newMockServer(t)
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[req.Input[0]]++
+		switch {
+		case req.Input[0] == "text 2" && attempts["text 2"] == 1:
+			return 500 // Recovers on retry.
+		case req.Input[0] == "text 6":
+			return 500 // Fails every attempt.
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, RetryBaseDelay: time.Millisecond})
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	var progress []voyageai.BatchProgress
+	res, err := client.BulkEmbed(context.Background(), texts, "test-model", nil, &voyageai.BulkOpts{
+		Workers:    3,
+		BatchSize:  2,
+		MaxRetries: 3,
+		OnProgress: func(p voyageai.BatchProgress) { progress = append(progress, p) },
+	})
+	if err == nil || res == nil {
+		t.Fatalf("Expected a result and the failed request's error, got %v, %v", res, err)
+	}
+	if !slices.Equal(res.Failed, []int{6, 7}) {
+		t.Errorf("Expected inputs 6 and 7 to fail, got %v", res.Failed)
+	}
+	for i, emb := range res.Embeddings {
+		if i == 6 || i == 7 {
+			if emb != nil {
+				t.Errorf("Expected no embedding for failed input %d", i)
+			}
+		} else if !slices.Equal(emb, fakeVector(texts[i])) {
+			t.Errorf("Wrong embedding for input %d", i)
+		}
+	}
+	if attempts["text 2"] != 2 || attempts["text 6"] != 3 {
+		t.Errorf("Expected each batch to be retried on its own, got %v", attempts)
+	}
+	if len(progress) != 4 || progress[3].Completed != 8 || progress[3].Total != 10 {
+		t.Errorf("Expected progress after every successful request, got %+v", progress)
+	}
+	if res.Usage.TotalTokens == 0 {
+		t.Error("Expected usage to be aggregated")
+	}
+}
@@ -0,0 +1,365 @@
+package voyageai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A cached value along with its lifetime.
+type CacheEntry struct {
+	Value     []byte
+	StoredAt  time.Time
+	ExpiresAt time.Time // The time after which the entry is no longer fresh. Zero if it never expires.
+}
+
+// Expired reports whether the entry is no longer fresh at now.
+func (e CacheEntry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Stores response cache entries. See [CacheOpts].
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry stored under key. ok is false if there is none.
+	// Stores may return expired entries; the client checks [CacheEntry.ExpiresAt] itself.
+	Get(ctx context.Context, key string) (entry CacheEntry, ok bool, err error)
+	// Set stores entry under key, replacing any existing entry.
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+// Configures the response cache of a [VoyageClient].
+//
+// Embeddings are cached per input text, so a request whose inputs are partly cached only sends the
+// remaining inputs. Rerank results are cached per query and document set; see
+// [VoyageClient.RerankContext]. Cache hits report no usage. Errors from the store are treated as
+// misses and never fail a request.
+type CacheOpts struct {
+	Store CacheStore    // Where entries are kept. Required.
+	TTL   time.Duration // How long entries stay fresh. Entries never expire by default.
+	// If positive, a call that fails with a rate limit, server or network error is answered
+	// from cache entries that expired at most this long ago, provided every result of the call
+	// is cached. Such responses have [ResponseMetadata.Stale] set. Other errors, such as
+	// validation errors, are always returned. Off by default.
+	StaleIfError time.Duration
+}
+
+// An in-memory [CacheStore] that evicts the least recently used entries once full.
+type MemoryCache struct {
+	max int
+
+	mu    sync.Mutex
+	order *list.List // Of *memoryItem, most recently used first.
+	items map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// Returns a new [MemoryCache] holding at most maxEntries entries, or an unbounded number if
+// maxEntries is not positive.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{max: maxEntries, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (m *MemoryCache) Get(_ context.Context, key string) (CacheEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true, nil
+}
+
+func (m *MemoryCache) Set(_ context.Context, key string, entry CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	if m.max > 0 && m.order.Len() > m.max {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// cacheGet returns the value stored under key. fresh is false if the entry has expired but may
+// still be served under [CacheOpts.StaleIfError], in which case staleness is how long ago it
+// expired. ok is false if there is no usable entry.
+func (c *VoyageClient) cacheGet(ctx context.Context, key string) (value []byte, fresh bool, staleness time.Duration, ok bool) {
+	entry, ok, err := c.opts.Cache.Store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, 0, false
+	}
+	now := c.clock().Now()
+	if !entry.Expired(now) {
+		return entry.Value, true, 0, true
+	}
+	staleness = now.Sub(entry.ExpiresAt)
+	if staleness > c.opts.Cache.StaleIfError {
+		return nil, false, 0, false
+	}
+	return entry.Value, false, staleness, true
+}
+
+// serveStale reports whether a request that failed with err may be answered from expired cache
+// entries: only rate limit, server and network errors qualify, and only while ctx is live.
+func serveStale(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return isOverloaded(err) || isNetError(err)
+}
+
+// cacheSet stores value under key with the configured TTL.
+func (c *VoyageClient) cacheSet(ctx context.Context, key string, value []byte) {
+	now := c.clock().Now()
+	entry := CacheEntry{Value: value, StoredAt: now}
+	if c.opts.Cache.TTL > 0 {
+		entry.ExpiresAt = now.Add(c.opts.Cache.TTL)
+	}
+	_ = c.opts.Cache.Store.Set(ctx, key, entry)
+}
+
+func (c *VoyageClient) cacheEnabled() bool {
+	return c.opts.Cache != nil && c.opts.Cache.Store != nil
+}
+
+// cacheKey hashes the given parts into a key with the given prefix.
+func cacheKey(prefix string, parts ...string) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, p := range parts {
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p)))
+		h.Write(n[:])
+		h.Write([]byte(p))
+	}
+	return prefix + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// optString formats an optional request parameter for use in a cache key.
+func optString[T any](v *T) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(*v)
+}
+
+// embedCacheKey returns the cache key of the embedding of the input with the given
+// [HashInput] hash.
+func embedCacheKey(model string, opts *EmbeddingRequestOpts, inputHash string) string {
+	if opts == nil {
+		opts = &EmbeddingRequestOpts{}
+	}
+	return cacheKey("embed", model,
+		optString(opts.InputType),
+		optString(opts.Truncation),
+		optString(opts.OutputDimension),
+		optString(opts.OutputDType),
+		optString(opts.EncodingFormat),
+		inputHash,
+	)
+}
+
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 0, 4*len(v))
+	for _, f := range v {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte) ([]float32, bool) {
+	if len(b)%4 != 0 {
+		return nil, false
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v, true
+}
+
+// embedCached answers an embedding request from the cache where possible, sending only the inputs
+// that are not cached and caching their results.
+func (c *VoyageClient) embedCached(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts, send func([]string) (*EmbeddingResponse, error)) (*EmbeddingResponse, error) {
+	resp := &EmbeddingResponse{Object: "list", Model: model, Data: make([]EmbeddingObject, len(texts))}
+	keys := make([]string, len(texts))
+	var missing []int
+	stale := map[int][]float32{}
+	staleness := map[int]time.Duration{}
+	for i, text := range texts {
+		keys[i] = embedCacheKey(model, opts, HashInput(text))
+		if b, fresh, age, ok := c.cacheGet(ctx, keys[i]); ok {
+			if v, ok := decodeVector(b); ok {
+				if fresh {
+					resp.Data[i] = EmbeddingObject{Object: "embedding", Embedding: v, Index: i}
+					c.observeCache(ctx, CacheMetrics{Endpoint: "embeddings", Model: model, Result: CacheHit})
+					continue
+				}
+				stale[i], staleness[i] = v, age
+			}
+		}
+		missing = append(missing, i)
+		c.observeCache(ctx, CacheMetrics{Endpoint: "embeddings", Model: model, Result: CacheMiss})
+	}
+	if len(missing) == 0 {
+		return resp, nil
+	}
+
+	batch := make([]string, len(missing))
+	for j, i := range missing {
+		batch[j] = texts[i]
+	}
+	fetched, err := send(batch)
+	if err != nil {
+		if len(stale) < len(missing) || !serveStale(ctx, err) {
+			return fetched, err
+		}
+		for _, i := range missing {
+			resp.Data[i] = EmbeddingObject{Object: "embedding", Embedding: stale[i], Index: i}
+			c.observeCache(ctx, CacheMetrics{Endpoint: "embeddings", Model: model, Result: CacheStale, Staleness: staleness[i]})
+		}
+		resp.Metadata.Stale = true
+		return resp, nil
+	}
+	for _, obj := range fetched.Data {
+		if obj.Index < 0 || obj.Index >= len(missing) {
+			return nil, fmt.Errorf("voyage: response has embedding index %d for %d inputs", obj.Index, len(missing))
+		}
+		i := missing[obj.Index]
+		resp.Data[i] = EmbeddingObject{Object: obj.Object, Embedding: obj.Embedding, Index: i}
+		c.cacheSet(ctx, keys[i], encodeVector(obj.Embedding))
+	}
+	resp.Object, resp.Model, resp.Usage = fetched.Object, fetched.Model, fetched.Usage
+	resp.Metadata.HTTP = fetched.Metadata.HTTP
+	return resp, nil
+}
+
+// A rerank result as cached, identified by the document's hash rather than its position.
+type cachedRerankResult struct {
+	DocHash string  `json:"doc"`
+	Score   float32 `json:"score"`
+}
+
+type cachedRerank struct {
+	Model    string               `json:"model"`
+	Complete bool                 `json:"complete"` // Whether Results covers every document, rather than only the top ones.
+	Results  []cachedRerankResult `json:"results"`
+}
+
+// rerankCacheKey returns the cache key of a rerank request. The documents are identified by the
+// sorted list of their hashes, so the key does not depend on their order. top_k and
+// return_documents are not part of the key; see [VoyageClient.RerankContext].
+func rerankCacheKey(query string, docHashes []string, model string, opts *RerankRequestOpts) string {
+	sorted := append([]string(nil), docHashes...)
+	slices.Sort(sorted)
+	var truncation *bool
+	if opts != nil {
+		truncation = opts.Truncation
+	}
+	parts := append([]string{model, optString(truncation), HashInput(query), strconv.Itoa(len(sorted))}, sorted...)
+	return cacheKey("rerank", parts...)
+}
+
+// rerankCached answers a rerank request from the cache if possible, and otherwise calls send and
+// caches its results.
+func (c *VoyageClient) rerankCached(ctx context.Context, query string, documents []string, model string, opts *RerankRequestOpts, send func() (*RerankResponse, error)) (*RerankResponse, error) {
+	if opts == nil {
+		opts = &RerankRequestOpts{}
+	}
+	hashes := make([]string, len(documents))
+	for i, doc := range documents {
+		hashes[i] = HashInput(doc)
+	}
+	key := rerankCacheKey(query, hashes, model, opts)
+
+	var stale *RerankResponse
+	var staleness time.Duration
+	if b, fresh, age, ok := c.cacheGet(ctx, key); ok {
+		var cached cachedRerank
+		if json.Unmarshal(b, &cached) == nil && (cached.Complete || opts.TopK != nil && *opts.TopK <= len(cached.Results)) {
+			if fresh {
+				c.observeCache(ctx, CacheMetrics{Endpoint: "rerank", Model: model, Result: CacheHit})
+				return cached.response(hashes, documents, opts), nil
+			}
+			stale, staleness = cached.response(hashes, documents, opts), age
+		}
+	}
+	c.observeCache(ctx, CacheMetrics{Endpoint: "rerank", Model: model, Result: CacheMiss})
+
+	resp, err := send()
+	if err != nil {
+		if stale == nil || !serveStale(ctx, err) {
+			return resp, err
+		}
+		c.observeCache(ctx, CacheMetrics{Endpoint: "rerank", Model: model, Result: CacheStale, Staleness: staleness})
+		stale.Metadata.Stale = true
+		return stale, nil
+	}
+	cached := cachedRerank{Model: resp.Model, Complete: len(resp.Data) == len(documents)}
+	for _, obj := range resp.Data {
+		if obj.Index < 0 || obj.Index >= len(documents) {
+			return resp, nil
+		}
+		cached.Results = append(cached.Results, cachedRerankResult{DocHash: hashes[obj.Index], Score: obj.RelevanceScore})
+	}
+	if b, err := json.Marshal(&cached); err == nil {
+		c.cacheSet(ctx, key, b)
+	}
+	return resp, nil
+}
+
+// response maps the cached results back onto the positions of the documents in the current
+// request, keeping at most opts.TopK results.
+func (r *cachedRerank) response(hashes []string, documents []string, opts *RerankRequestOpts) *RerankResponse {
+	positions := map[string][]int{}
+	for i, h := range hashes {
+		positions[h] = append(positions[h], i)
+	}
+	n := len(r.Results)
+	if opts.TopK != nil {
+		n = min(n, max(*opts.TopK, 0))
+	}
+
+	resp := &RerankResponse{Object: "list", Model: r.Model, Data: make([]RerankObject, 0, n)}
+	for _, res := range r.Results[:n] {
+		pos := positions[res.DocHash]
+		if len(pos) == 0 {
+			continue
+		}
+		i := pos[0]
+		positions[res.DocHash] = pos[1:]
+		obj := RerankObject{Index: i, RelevanceScore: res.Score}
+		if opts.ReturnDocuments != nil && *opts.ReturnDocuments {
+			obj.Document = &documents[i]
+		}
+		resp.Data = append(resp.Data, obj)
+	}
+	return resp
+}
@@ -0,0 +1,172 @@
+package voyageai
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// The envelope written by [EncryptedStore] is a version byte, the first four bytes of the
+// SHA-256 hash of the key used, a 12 byte nonce, and the AES-256-GCM ciphertext and tag. The
+// cache key is authenticated as additional data, so entries cannot be moved between keys.
+//
+// Values are encrypted, and cache keys hashed, with separate subkeys derived from the key with
+// HKDF-SHA256.
+const (
+	envelopeVersion = 1
+	keyIDLen        = 4
+)
+
+// The HKDF info strings of the subkeys derived from an [EncryptedStore] key.
+const (
+	encryptionKeyInfo = "voyageai cache encryption"
+	macKeyInfo        = "voyageai cache key hash"
+)
+
+// Options for [NewEncryptedStore].
+type EncryptedStoreOpts struct {
+	// The key new entries are encrypted with. Must be 32 bytes. The AES-256 key and the key
+	// cache keys are hashed with are derived from it.
+	Key []byte
+	// Keys that earlier entries were encrypted with, tried when reading entries not written
+	// with Key. To rotate keys, move the current key here and set a new Key.
+	OldKeys [][]byte
+	// Store entries under an HMAC-SHA256 of their cache key instead of the key itself, so the
+	// inner store does not learn the input hashes and options that cache keys contain. Each
+	// miss then costs one lookup per key.
+	HashKeys bool
+	// Receives a warning for every entry that fails to decrypt. Defaults to [slog.Default].
+	Logger *slog.Logger
+}
+
+// A [CacheStore] that encrypts entry values before passing them to another store, for caches
+// persisted to disk or shared services. Expiry times are stored in the clear so the inner store
+// can still expire entries. Entries that fail to decrypt, because they were tampered with or
+// written with an unknown key, are treated as misses.
+type EncryptedStore struct {
+	inner    CacheStore
+	keys     []encryptionKey // The current key first.
+	hashKeys bool
+	logger   *slog.Logger
+}
+
+type encryptionKey struct {
+	id     [keyIDLen]byte
+	aead   cipher.AEAD
+	macKey []byte // The HMAC-SHA256 key cache keys are hashed with.
+}
+
+// NewEncryptedStore returns a store that encrypts entries with AES-256-GCM, using a random nonce
+// per entry, and keeps them in inner. The encryption key and the HMAC key of
+// [EncryptedStoreOpts.HashKeys] are derived from each key separately.
+func NewEncryptedStore(inner CacheStore, opts EncryptedStoreOpts) (*EncryptedStore, error) {
+	s := &EncryptedStore{inner: inner, hashKeys: opts.HashKeys, logger: opts.Logger}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	for i, raw := range append([][]byte{opts.Key}, opts.OldKeys...) {
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("voyage: encryption key %d has %d bytes, expected 32", i, len(raw))
+		}
+		encKey, err := hkdf.Key(sha256.New, raw, nil, encryptionKeyInfo, 32)
+		if err != nil {
+			return nil, err
+		}
+		macKey, err := hkdf.Key(sha256.New, raw, nil, macKeyInfo, 32)
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k := encryptionKey{aead: aead, macKey: macKey}
+		sum := sha256.Sum256(raw)
+		copy(k.id[:], sum[:])
+		s.keys = append(s.keys, k)
+	}
+	return s, nil
+}
+
+// storeKey returns the key an entry is kept under in the inner store when written with k.
+func (s *EncryptedStore) storeKey(key string, k encryptionKey) string {
+	if !s.hashKeys {
+		return key
+	}
+	mac := hmac.New(sha256.New, k.macKey)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *EncryptedStore) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	lookups := s.keys[:1]
+	if s.hashKeys {
+		lookups = s.keys
+	}
+	for _, k := range lookups {
+		entry, ok, err := s.inner.Get(ctx, s.storeKey(key, k))
+		if err != nil {
+			return CacheEntry{}, false, err
+		}
+		if !ok {
+			continue
+		}
+		value, err := s.open(key, entry.Value)
+		if err != nil {
+			s.logger.Warn("voyage: dropping cache entry that failed to decrypt", "error", err)
+			return CacheEntry{}, false, nil
+		}
+		entry.Value = value
+		return entry, true, nil
+	}
+	return CacheEntry{}, false, nil
+}
+
+func (s *EncryptedStore) Set(ctx context.Context, key string, entry CacheEntry) error {
+	k := s.keys[0]
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := make([]byte, 0, 1+keyIDLen+len(nonce)+len(entry.Value)+k.aead.Overhead())
+	sealed = append(sealed, envelopeVersion)
+	sealed = append(sealed, k.id[:]...)
+	sealed = append(sealed, nonce...)
+	sealed = k.aead.Seal(sealed, nonce, entry.Value, []byte(key))
+	entry.Value = sealed
+	return s.inner.Set(ctx, s.storeKey(key, k), entry)
+}
+
+// open decrypts an envelope stored under key.
+func (s *EncryptedStore) open(key string, sealed []byte) ([]byte, error) {
+	if len(sealed) < 1+keyIDLen {
+		return nil, errors.New("envelope too short")
+	}
+	if sealed[0] != envelopeVersion {
+		return nil, fmt.Errorf("unknown envelope version %d", sealed[0])
+	}
+	for _, k := range s.keys {
+		if string(k.id[:]) != string(sealed[1:1+keyIDLen]) {
+			continue
+		}
+		rest := sealed[1+keyIDLen:]
+		if len(rest) < k.aead.NonceSize() {
+			return nil, errors.New("envelope too short")
+		}
+		nonce, ciphertext := rest[:k.aead.NonceSize()], rest[k.aead.NonceSize():]
+		return k.aead.Open(nil, nonce, ciphertext, []byte(key))
+	}
+	return nil, errors.New("entry was encrypted with an unknown key")
+}
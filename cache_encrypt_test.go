@@ -0,0 +1,173 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newEncryptedStore(t *testing.T, inner voyageai.CacheStore, opts voyageai.EncryptedStoreOpts) (*voyageai.EncryptedStore, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	s, err := voyageai.NewEncryptedStore(inner, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return s, &logs
+}
+
+func TestEncryptedStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	inner := voyageai.NewMemoryCache(0)
+	s, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(1)})
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := voyageai.CacheEntry{Value: []byte("secret embedding"), ExpiresAt: expires}
+	if err := s.Set(ctx, "k", entry); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, ok, err := s.Get(ctx, "k")
+	if err != nil || !ok || string(got.Value) != "secret embedding" || !got.ExpiresAt.Equal(expires) {
+		t.Fatalf("Expected the entry back, got %+v, %v, %v", got, ok, err)
+	}
+
+	raw, _, _ := inner.Get(ctx, "k")
+	if bytes.Contains(raw.Value, []byte("secret")) {
+		t.Error("Expected the inner store to hold ciphertext")
+	}
+	if !raw.ExpiresAt.Equal(expires) {
+		t.Error("Expected the expiry to be stored in the clear")
+	}
+
+	// Every entry gets its own nonce.
+	s.Set(ctx, "k2", entry)
+	raw2, _, _ := inner.Get(ctx, "k2")
+	if bytes.Equal(raw.Value, raw2.Value) {
+		t.Error("Expected different ciphertexts for the same value")
+	}
+
+	if _, ok, _ := s.Get(ctx, "missing"); ok {
+		t.Error("Expected a miss for a missing key")
+	}
+}
+
+func TestEncryptedStoreTamper(t *testing.T) {
+	ctx := context.Background()
+	inner := voyageai.NewMemoryCache(0)
+	s, logs := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(1)})
+	s.Set(ctx, "k", voyageai.CacheEntry{Value: []byte("value")})
+
+	raw, _, _ := inner.Get(ctx, "k")
+	tampered := append([]byte(nil), raw.Value...)
+	tampered[len(tampered)-1] ^= 1
+	inner.Set(ctx, "k", voyageai.CacheEntry{Value: tampered})
+	if _, ok, err := s.Get(ctx, "k"); ok || err != nil {
+		t.Errorf("Expected a tampered entry to be a miss, got %v and %v", ok, err)
+	}
+	if !strings.Contains(logs.String(), "failed to decrypt") {
+		t.Errorf("Expected a warning, got %q", logs.String())
+	}
+
+	// An entry copied to another key does not authenticate.
+	inner.Set(ctx, "other", raw)
+	if _, ok, _ := s.Get(ctx, "other"); ok {
+		t.Error("Expected an entry moved to another key to be a miss")
+	}
+}
+
+func TestEncryptedStoreWrongKey(t *testing.T) {
+	ctx := context.Background()
+	inner := voyageai.NewMemoryCache(0)
+	a, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(1)})
+	b, logs := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(2)})
+	a.Set(ctx, "k", voyageai.CacheEntry{Value: []byte("value")})
+
+	if _, ok, err := b.Get(ctx, "k"); ok || err != nil {
+		t.Errorf("Expected a miss with the wrong key, got %v and %v", ok, err)
+	}
+	if !strings.Contains(logs.String(), "unknown key") {
+		t.Errorf("Expected a warning about the key, got %q", logs.String())
+	}
+
+	if _, err := voyageai.NewEncryptedStore(inner, voyageai.EncryptedStoreOpts{Key: []byte("short")}); err == nil {
+		t.Error("Expected an error for a short key")
+	}
+}
+
+func TestEncryptedStoreRotation(t *testing.T) {
+	ctx := context.Background()
+	for _, hashKeys := range []bool{false, true} {
+		inner := voyageai.NewMemoryCache(0)
+		old, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(1), HashKeys: hashKeys})
+		old.Set(ctx, "before", voyageai.CacheEntry{Value: []byte("old")})
+
+		rotated, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(2), OldKeys: [][]byte{testKey(1)}, HashKeys: hashKeys})
+		got, ok, _ := rotated.Get(ctx, "before")
+		if !ok || string(got.Value) != "old" {
+			t.Errorf("hashKeys=%v: expected an old entry to decrypt after rotation, got %v", hashKeys, ok)
+		}
+		rotated.Set(ctx, "after", voyageai.CacheEntry{Value: []byte("new")})
+
+		// New entries use only the new key.
+		newOnly, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(2), HashKeys: hashKeys})
+		if got, ok, _ := newOnly.Get(ctx, "after"); !ok || string(got.Value) != "new" {
+			t.Errorf("hashKeys=%v: expected the new entry under the new key, got %v", hashKeys, ok)
+		}
+		if _, ok, _ := old.Get(ctx, "after"); ok {
+			t.Errorf("hashKeys=%v: expected the old key not to decrypt new entries", hashKeys)
+		}
+
+		if _, ok, _ := inner.Get(ctx, "after"); ok == hashKeys {
+			t.Errorf("hashKeys=%v: expected the plain key to be stored only without hashing", hashKeys)
+		}
+	}
+}
+
+func TestEncryptedStoreSubkeys(t *testing.T) {
+	ctx := context.Background()
+	inner := &keyRecorder{CacheStore: voyageai.NewMemoryCache(0)}
+	s, _ := newEncryptedStore(t, inner, voyageai.EncryptedStoreOpts{Key: testKey(1), HashKeys: true})
+	if err := s.Set(ctx, "k", voyageai.CacheEntry{Value: []byte("secret")}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Neither the key hash nor the ciphertext is made with the key itself.
+	mac := hmac.New(sha256.New, testKey(1))
+	mac.Write([]byte("k"))
+	if len(inner.keys) != 1 || inner.keys[0] == hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Expected the cache key not to be hashed with the key itself, got %v", inner.keys)
+	}
+	stored, _, _ := inner.Get(ctx, inner.keys[0])
+	block, _ := aes.NewCipher(testKey(1))
+	aead, _ := cipher.NewGCM(block)
+	sealed := stored.Value[5:]
+	if _, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte("k")); err == nil {
+		t.Error("Expected the value not to be encrypted with the key itself")
+	}
+}
+
+// keyRecorder is a CacheStore recording the keys entries are set under.
+type keyRecorder struct {
+	voyageai.CacheStore
+	keys []string
+}
+
+func (r *keyRecorder) Set(ctx context.Context, key string, entry voyageai.CacheEntry) error {
+	r.keys = append(r.keys, key)
+	return r.CacheStore.Set(ctx, key, entry)
+}
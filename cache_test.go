@@ -0,0 +1,253 @@
+package voyageai_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func newCachedClient(srv *mockServer, clock *fakeClock) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Cache:   &voyageai.CacheOpts{Store: voyageai.NewMemoryCache(0), TTL: time.Hour},
+		Clock:   clock,
+	})
+}
+
+func TestEmbedCache(t *testing.T) {
+	srv := newMockServer(t)
+	client := newCachedClient(srv, newFakeClock())
+	ctx := context.Background()
+
+	if _, err := client.EmbedContext(ctx, []string{"a", "b"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	resp, err := client.EmbedContext(ctx, []string{"b", "c", "a"}, "test-model", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if got := srv.inputs(); fmt.Sprint(got) != "[a b c]" {
+		t.Errorf("Expected only the uncached input to be sent again, got %v", got)
+	}
+	for i, text := range []string{"b", "c", "a"} {
+		obj := resp.Data[i]
+		if obj.Index != i || fmt.Sprint(obj.Embedding) != fmt.Sprint(fakeVector(text)) {
+			t.Errorf("Unexpected embedding %d: %+v", i, obj)
+		}
+	}
+	if resp.Usage.TotalTokens != 1 {
+		t.Errorf("Expected usage for the one uncached input, got %d", resp.Usage.TotalTokens)
+	}
+
+	// Different options are cached separately.
+	if _, err := client.EmbedContext(ctx, []string{"a"}, "test-model", &voyageai.EmbeddingRequestOpts{InputType: voyageai.Opt("query")}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if srv.requestCount() != 3 {
+		t.Errorf("Expected a request for the new input type, got %d requests", srv.requestCount())
+	}
+}
+
+func TestRerankCache(t *testing.T) {
+	ctx := context.Background()
+	docs := []string{"a red apple", "a green pear", "a yellow banana"}
+
+	t.Run("hit remaps indices", func(t *testing.T) {
+		srv := newMockServer(t)
+		client := newCachedClient(srv, newFakeClock())
+		first, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		shuffled := []string{docs[2], docs[0], docs[1]}
+		opts := &voyageai.RerankRequestOpts{ReturnDocuments: voyageai.Opt(true)}
+		resp, err := client.RerankContext(ctx, "red apple", shuffled, "rerank-2", opts)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if srv.rerankCount() != 1 {
+			t.Errorf("Expected a cache hit, got %d requests", srv.rerankCount())
+		}
+		if len(resp.Data) != len(docs) || resp.Usage.TotalTokens != 0 {
+			t.Fatalf("Unexpected cached response: %+v", resp)
+		}
+		for i, obj := range resp.Data {
+			if shuffled[obj.Index] != docs[first.Data[i].Index] || obj.RelevanceScore != first.Data[i].RelevanceScore {
+				t.Errorf("Result %d: expected %q, got %q", i, docs[first.Data[i].Index], shuffled[obj.Index])
+			}
+			if obj.Document == nil || *obj.Document != shuffled[obj.Index] {
+				t.Errorf("Result %d: expected the document to be returned", i)
+			}
+		}
+	})
+
+	t.Run("top k", func(t *testing.T) {
+		srv := newMockServer(t)
+		client := newCachedClient(srv, newFakeClock())
+		if _, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", &voyageai.RerankRequestOpts{TopK: voyageai.Opt(2)}); err != nil {
+			t.Fatal(err.Error())
+		}
+		resp, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", &voyageai.RerankRequestOpts{TopK: voyageai.Opt(1)})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if srv.rerankCount() != 1 || len(resp.Data) != 1 || resp.Data[0].Index != 0 {
+			t.Errorf("Expected a trimmed cache hit, got %d requests and %+v", srv.rerankCount(), resp.Data)
+		}
+
+		// A larger top k than cached needs a new request.
+		if _, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		if srv.rerankCount() != 2 {
+			t.Errorf("Expected a cache miss for all results, got %d requests", srv.rerankCount())
+		}
+	})
+
+	t.Run("changed document", func(t *testing.T) {
+		srv := newMockServer(t)
+		client := newCachedClient(srv, newFakeClock())
+		if _, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		changed := []string{docs[0], docs[1], "a yellow bananas"}
+		if _, err := client.RerankContext(ctx, "red apple", changed, "rerank-2", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		if srv.rerankCount() != 2 {
+			t.Errorf("Expected a cache miss, got %d requests", srv.rerankCount())
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		srv := newMockServer(t)
+		clock := newFakeClock()
+		client := newCachedClient(srv, clock)
+		for _, advance := range []time.Duration{0, 59 * time.Minute, 2 * time.Minute} {
+			clock.Advance(advance)
+			if _, err := client.RerankContext(ctx, "red apple", docs, "rerank-2", nil); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+		if srv.rerankCount() != 2 {
+			t.Errorf("Expected the entry to expire after an hour, got %d requests", srv.rerankCount())
+		}
+	})
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := voyageai.NewMemoryCache(2)
+	cache.Set(ctx, "a", voyageai.CacheEntry{Value: []byte("1")})
+	cache.Set(ctx, "b", voyageai.CacheEntry{Value: []byte("2")})
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", voyageai.CacheEntry{Value: []byte("3")})
+
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if e, ok, _ := cache.Get(ctx, "a"); !ok || string(e.Value) != "1" {
+		t.Error("Expected a to be kept")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func newStaleClient(srv *mockServer, clock *fakeClock, metrics voyageai.MetricsHook) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Cache:   &voyageai.CacheOpts{Store: voyageai.NewMemoryCache(0), TTL: time.Hour, StaleIfError: 30 * time.Minute},
+		Clock:   clock,
+		Metrics: metrics,
+	})
+}
+
+func TestEmbedCacheStaleIfError(t *testing.T) {
+	srv := newMockServer(t)
+	clock := newFakeClock()
+	metrics := &recordingMetrics{}
+	client := newStaleClient(srv, clock, metrics)
+	ctx := context.Background()
+
+	fresh, err := client.EmbedContext(ctx, []string{"a", "b"}, "test-model", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	srv.fail = func(int, voyageai.EmbeddingRequest) int { return 503 }
+	clock.Advance(time.Hour + 10*time.Minute)
+	resp, err := client.EmbedContext(ctx, []string{"b", "a"}, "test-model", nil)
+	if err != nil {
+		t.Fatalf("Expected the stale entries to be served, got %v", err)
+	}
+	if !resp.Metadata.Stale || !reflect.DeepEqual(resp.Data[0].Embedding, fresh.Data[1].Embedding) {
+		t.Errorf("Expected stale results, got %+v", resp)
+	}
+	if got := metrics.cacheResults(); got[voyageai.CacheStale] != 2 {
+		t.Errorf("Expected 2 stale serves to be observed, got %v", got)
+	}
+	for _, m := range metrics.cache {
+		if m.Result == voyageai.CacheStale && m.Staleness != 10*time.Minute {
+			t.Errorf("Expected a staleness of 10m, got %v", m.Staleness)
+		}
+	}
+
+	// Inputs without a cached entry cannot be served stale.
+	if _, err := client.EmbedContext(ctx, []string{"a", "new"}, "test-model", nil); err == nil {
+		t.Error("Expected an error when not every input is cached")
+	}
+
+	// Client errors are never masked.
+	srv.fail = func(int, voyageai.EmbeddingRequest) int { return 400 }
+	if _, err := client.EmbedContext(ctx, []string{"a"}, "test-model", nil); err == nil {
+		t.Error("Expected a 400 to be returned")
+	}
+
+	// Entries past the staleness bound are not served.
+	srv.fail = func(int, voyageai.EmbeddingRequest) int { return 503 }
+	clock.Advance(25 * time.Minute)
+	if _, err := client.EmbedContext(ctx, []string{"a"}, "test-model", nil); err == nil {
+		t.Error("Expected an error once the entry is too stale")
+	}
+}
+
+func TestRerankCacheStaleIfError(t *testing.T) {
+	srv := newMockServer(t)
+	clock := newFakeClock()
+	metrics := &recordingMetrics{}
+	client := newStaleClient(srv, clock, metrics)
+	ctx := context.Background()
+	docs := []string{"red apple", "green pear"}
+
+	fresh, err := client.RerankContext(ctx, "red", docs, "rerank-2", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	srv.failRerank = func(int, voyageai.RerankRequest) int { return 429 }
+
+	clock.Advance(90 * time.Minute)
+	resp, err := client.RerankContext(ctx, "red", docs, "rerank-2", nil)
+	if err != nil {
+		t.Fatalf("Expected the stale result to be served, got %v", err)
+	}
+	if !resp.Metadata.Stale || resp.Data[0].Index != fresh.Data[0].Index {
+		t.Errorf("Expected the stale result, got %+v", resp)
+	}
+	if got := metrics.cacheResults(); got[voyageai.CacheStale] != 1 || got[voyageai.CacheHit] != 0 {
+		t.Errorf("Unexpected cache observations %v", got)
+	}
+
+	clock.Advance(time.Hour)
+	if _, err := client.RerankContext(ctx, "red", docs, "rerank-2", nil); err == nil {
+		t.Error("Expected an error once the entry is too stale")
+	}
+}
@@ -0,0 +1,79 @@
+package voyageai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Options for [VoyageClient.WarmCacheFromJSONL].
+type WarmCacheOpts struct {
+	// The request options the records were embedded with. They are part of the cache key, so they
+	// must match the options of the calls expected to hit the warmed entries.
+	EmbedOpts *EmbeddingRequestOpts
+	// How long the warmed entries stay fresh. Defaults to the cache's TTL.
+	TTL time.Duration
+}
+
+// WarmCacheFromJSONL fills the client's cache from [EmbeddingRecord] lines as written by a
+// [ResultFormatJSONL] result writer, so later calls for the same texts are served from the cache.
+// Entries are keyed exactly as live embedding calls with model and opts.EmbedOpts would key them.
+//
+// Records for a different model are skipped, as are records whose dimension differs from
+// [EmbeddingRequestOpts.OutputDimension] or, if that is not set, from the first loaded record.
+// It returns the number of records loaded and skipped, and fails on malformed lines, store errors
+// or if the client has no cache.
+func (c *VoyageClient) WarmCacheFromJSONL(ctx context.Context, r io.Reader, model string, opts *WarmCacheOpts) (loaded int, skipped int, err error) {
+	if !c.cacheEnabled() {
+		return 0, 0, errors.New("voyage: the client has no cache")
+	}
+	if opts == nil {
+		opts = &WarmCacheOpts{}
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = c.opts.Cache.TTL
+	}
+	dim := 0
+	if opts.EmbedOpts != nil && opts.EmbedOpts.OutputDimension != nil {
+		dim = *opts.EmbedOpts.OutputDimension
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxIndexFieldLen)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return loaded, skipped, err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec EmbeddingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return loaded, skipped, fmt.Errorf("voyage: line %d: %w", line, err)
+		}
+		if rec.Model != model || len(rec.Embedding) == 0 || dim != 0 && len(rec.Embedding) != dim {
+			skipped++
+			continue
+		}
+		dim = len(rec.Embedding)
+
+		now := c.clock().Now()
+		entry := CacheEntry{Value: encodeVector(rec.Embedding), StoredAt: now}
+		if ttl > 0 {
+			entry.ExpiresAt = now.Add(ttl)
+		}
+		if err := c.opts.Cache.Store.Set(ctx, embedCacheKey(model, opts.EmbedOpts, rec.InputHash), entry); err != nil {
+			return loaded, skipped, fmt.Errorf("voyage: line %d: store: %w", line, err)
+		}
+		loaded++
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, skipped, fmt.Errorf("voyage: read records: %w", err)
+	}
+	return loaded, skipped, nil
+}
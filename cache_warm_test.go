@@ -0,0 +1,71 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestWarmCacheFromJSONL(t *testing.T) {
+	ctx := context.Background()
+	texts := []string{"alpha", "beta", "gamma"}
+
+	var export bytes.Buffer
+	live := newMockServer(t)
+	want, err := live.client().EmbedBatch(ctx, texts, "test-model", nil, &voyageai.BatchOpts{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := live.client().EmbedBatch(ctx, texts, "other-model", nil, &voyageai.BatchOpts{ResultWriter: &export}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := live.client().EmbedBatch(ctx, texts, "test-model", nil, &voyageai.BatchOpts{ResultWriter: &export}); err != nil {
+		t.Fatal(err.Error())
+	}
+	export.WriteString(`{"index":9,"model":"test-model","input_hash":"` + voyageai.HashInput("delta") + `","embedding":[1,2,3]}` + "\n")
+
+	srv := newMockServer(t)
+	clock := newFakeClock()
+	client := newCachedClient(srv, clock)
+	loaded, skipped, err := client.WarmCacheFromJSONL(ctx, &export, "test-model", &voyageai.WarmCacheOpts{TTL: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if loaded != 3 || skipped != 4 {
+		t.Errorf("Expected 3 loaded and 4 skipped records, got %d and %d", loaded, skipped)
+	}
+
+	resp, err := client.EmbedContext(ctx, []string{"gamma", "alpha"}, "test-model", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if srv.requestCount() != 0 {
+		t.Errorf("Expected the warmed entries to be served from the cache, got %d requests", srv.requestCount())
+	}
+	if !reflect.DeepEqual(resp.Data[0].Embedding, want.Data[2].Embedding) || !reflect.DeepEqual(resp.Data[1].Embedding, want.Data[0].Embedding) {
+		t.Errorf("Expected the exported embeddings, got %+v", resp.Data)
+	}
+
+	// Entries use the given TTL rather than the cache's.
+	clock.Advance(90 * time.Minute)
+	client.EmbedContext(ctx, []string{"beta", "delta"}, "test-model", nil)
+	if got := srv.inputs(); !reflect.DeepEqual(got, []string{"delta"}) {
+		t.Errorf("Expected only the skipped text to be sent, got %v", got)
+	}
+}
+
+func TestWarmCacheFromJSONLErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, _, err := newMockServer(t).client().WarmCacheFromJSONL(ctx, strings.NewReader(""), "m", nil); err == nil {
+		t.Error("Expected an error for a client without a cache")
+	}
+	client := newCachedClient(newMockServer(t), newFakeClock())
+	if _, _, err := client.WarmCacheFromJSONL(ctx, strings.NewReader("{}\nnot json\n"), "m", nil); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error for line 2, got %v", err)
+	}
+}
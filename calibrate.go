@@ -0,0 +1,156 @@
+package voyageai
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// A pair of vectors labeled as relevant to each other or not, for [CalibrateThreshold].
+type LabeledPair struct {
+	A, B     []float32
+	Relevant bool
+}
+
+// The quantity [CalibrateThreshold] maximizes.
+type Objective int
+
+const (
+	ObjectiveF1       Objective = iota // The harmonic mean of precision and recall.
+	ObjectiveYoudenJ                   // Recall plus specificity minus one, which weighs both classes equally however unbalanced they are.
+	ObjectiveAccuracy                  // The fraction of pairs classified correctly.
+)
+
+// The threshold chosen by [CalibrateThreshold] and how it classifies the pairs.
+type CalibrationResult struct {
+	// Pairs scoring at least Threshold under [MetricCosine] (similarity), or at most Threshold
+	// under [MetricEuclidean] (distance), are classified as relevant.
+	Threshold float64
+	Objective float64 // The value of the objective at Threshold.
+
+	Precision, Recall, F1, Accuracy, YoudenJ float64
+	TruePositives, FalsePositives            int
+	TrueNegatives, FalseNegatives            int
+
+	// The scores of the relevant and irrelevant pairs, in ascending order, and their
+	// distributions.
+	RelevantScores, IrrelevantScores   []float64
+	RelevantSummary, IrrelevantSummary Summary
+}
+
+// CalibrateThreshold picks the score threshold that best separates relevant from irrelevant
+// pairs, by the given objective. Every pair is scored under metric, then each cut between
+// consecutive distinct scores is tried; the threshold is placed midway across the best cut. Of
+// equally good cuts, the strictest is chosen.
+//
+// It returns an error unless pairs include both relevant and irrelevant pairs, and the two vectors
+// of each pair have the same dimension.
+func CalibrateThreshold(pairs []LabeledPair, metric Metric, objective Objective) (CalibrationResult, error) {
+	var res CalibrationResult
+	if objective < ObjectiveF1 || objective > ObjectiveAccuracy {
+		return res, fmt.Errorf("voyage: unknown calibration objective %d", objective)
+	}
+	type scored struct {
+		score    float64
+		relevant bool
+	}
+	all := make([]scored, len(pairs))
+	for i, p := range pairs {
+		if len(p.A) != len(p.B) {
+			return res, fmt.Errorf("voyage: pair %d has vectors of dimensions %d and %d", i, len(p.A), len(p.B))
+		}
+		s := cosine(p.A, p.B)
+		if metric == MetricEuclidean {
+			s = euclidean(p.A, p.B)
+		}
+		all[i] = scored{score: s, relevant: p.Relevant}
+		if p.Relevant {
+			res.RelevantScores = append(res.RelevantScores, s)
+		} else {
+			res.IrrelevantScores = append(res.IrrelevantScores, s)
+		}
+	}
+	positives, negatives := len(res.RelevantScores), len(res.IrrelevantScores)
+	if positives == 0 || negatives == 0 {
+		return CalibrationResult{}, fmt.Errorf("voyage: calibration needs relevant and irrelevant pairs, got %d and %d", positives, negatives)
+	}
+	sort.Float64s(res.RelevantScores)
+	sort.Float64s(res.IrrelevantScores)
+	res.RelevantSummary = summarize(res.RelevantScores)
+	res.IrrelevantSummary = summarize(res.IrrelevantScores)
+
+	// Order pairs from most to least relevant looking, so each prefix is the set classified
+	// relevant by some threshold.
+	better := func(a, b float64) bool { return a > b }
+	if metric == MetricEuclidean {
+		better = func(a, b float64) bool { return a < b }
+	}
+	slices.SortStableFunc(all, func(a, b scored) int {
+		switch {
+		case better(a.score, b.score):
+			return -1
+		case better(b.score, a.score):
+			return 1
+		}
+		return 0
+	})
+
+	best := math.Inf(-1)
+	tp, fp := 0, 0
+	for i := 0; i < len(all); {
+		// Classify every pair with the same score alike.
+		j := i
+		for ; j < len(all) && all[j].score == all[i].score; j++ {
+			if all[j].relevant {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		m := classification(tp, fp, negatives-fp, positives-tp)
+		if v := m.value(objective); v > best {
+			best = v
+			threshold := all[j-1].score
+			if j < len(all) {
+				threshold = (threshold + all[j].score) / 2
+			}
+			res.Threshold, res.Objective = threshold, v
+			res.Precision, res.Recall, res.F1, res.Accuracy, res.YoudenJ = m.precision, m.recall, m.f1, m.accuracy, m.youdenJ
+			res.TruePositives, res.FalsePositives = tp, fp
+			res.TrueNegatives, res.FalseNegatives = negatives-fp, positives-tp
+		}
+		i = j
+	}
+	return res, nil
+}
+
+// The quality of a binary classification.
+type classMetrics struct {
+	precision, recall, f1, accuracy, youdenJ float64
+}
+
+func classification(tp, fp, tn, fn int) classMetrics {
+	var m classMetrics
+	if tp+fp > 0 {
+		m.precision = float64(tp) / float64(tp+fp)
+	}
+	m.recall = float64(tp) / float64(tp+fn)
+	if m.precision+m.recall > 0 {
+		m.f1 = 2 * m.precision * m.recall / (m.precision + m.recall)
+	}
+	m.accuracy = float64(tp+tn) / float64(tp+fp+tn+fn)
+	m.youdenJ = m.recall + float64(tn)/float64(tn+fp) - 1
+	return m
+}
+
+func (m classMetrics) value(o Objective) float64 {
+	switch o {
+	case ObjectiveYoudenJ:
+		return m.youdenJ
+	case ObjectiveAccuracy:
+		return m.accuracy
+	default:
+		return m.f1
+	}
+}
@@ -0,0 +1,105 @@
+package voyageai_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// similarPair returns a pair of unit vectors whose cosine similarity is s.
+func similarPair(s float64, relevant bool) voyageai.LabeledPair {
+	return voyageai.LabeledPair{
+		A:        []float32{1, 0},
+		B:        []float32{float32(s), float32(math.Sqrt(1 - s*s))},
+		Relevant: relevant,
+	}
+}
+
+func pairsWithSimilarities(relevant, irrelevant []float64) []voyageai.LabeledPair {
+	var pairs []voyageai.LabeledPair
+	for _, s := range relevant {
+		pairs = append(pairs, similarPair(s, true))
+	}
+	for _, s := range irrelevant {
+		pairs = append(pairs, similarPair(s, false))
+	}
+	return pairs
+}
+
+func TestCalibrateThresholdSeparable(t *testing.T) {
+	pairs := pairsWithSimilarities([]float64{0.9, 0.8, 0.85}, []float64{0.3, 0.5, 0.4})
+	for _, o := range []voyageai.Objective{voyageai.ObjectiveF1, voyageai.ObjectiveYoudenJ, voyageai.ObjectiveAccuracy} {
+		res, err := voyageai.CalibrateThreshold(pairs, voyageai.MetricCosine, o)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(res.Threshold-0.65) > 1e-6 || res.Precision != 1 || res.Recall != 1 || res.Objective != 1 {
+			t.Errorf("Objective %d: expected a perfect split at 0.65, got %+v", o, res)
+		}
+	}
+
+	res, _ := voyageai.CalibrateThreshold(pairs, voyageai.MetricCosine, voyageai.ObjectiveF1)
+	if len(res.RelevantScores) != 3 || math.Abs(res.RelevantScores[0]-0.8) > 1e-6 || math.Abs(res.IrrelevantSummary.Mean-0.4) > 1e-6 || math.Abs(res.RelevantSummary.Max-0.9) > 1e-6 {
+		t.Errorf("Unexpected distributions %v, %+v and %+v", res.RelevantScores, res.RelevantSummary, res.IrrelevantSummary)
+	}
+
+	// Under the Euclidean metric, close pairs are relevant.
+	var dist []voyageai.LabeledPair
+	for _, d := range []float32{1, 2, 3, 5} {
+		dist = append(dist, voyageai.LabeledPair{A: []float32{0, 0}, B: []float32{d, 0}, Relevant: d < 3})
+	}
+	res, err := voyageai.CalibrateThreshold(dist, voyageai.MetricEuclidean, voyageai.ObjectiveF1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Threshold != 2.5 || res.F1 != 1 {
+		t.Errorf("Expected a distance threshold of 2.5, got %+v", res)
+	}
+}
+
+func TestCalibrateThresholdOverlapping(t *testing.T) {
+	pairs := pairsWithSimilarities([]float64{0.9, 0.7, 0.6, 0.4}, []float64{0.65, 0.5, 0.3, 0.2})
+
+	res, err := voyageai.CalibrateThreshold(pairs, voyageai.MetricCosine, voyageai.ObjectiveF1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Counting everything above 0.3 as relevant finds all 4 relevant pairs and 2 others.
+	if math.Abs(res.Threshold-0.35) > 1e-6 || math.Abs(res.F1-0.8) > 1e-9 || math.Abs(res.Precision-2.0/3) > 1e-9 || res.Recall != 1 {
+		t.Errorf("Unexpected F1 calibration %+v", res)
+	}
+	if res.TruePositives != 4 || res.FalsePositives != 2 || res.TrueNegatives != 2 || res.FalseNegatives != 0 || res.Accuracy != 0.75 {
+		t.Errorf("Unexpected confusion counts %+v", res)
+	}
+
+	// Accuracy and Youden's J peak at several cuts; the strictest is chosen.
+	for _, o := range []voyageai.Objective{voyageai.ObjectiveYoudenJ, voyageai.ObjectiveAccuracy} {
+		res, err := voyageai.CalibrateThreshold(pairs, voyageai.MetricCosine, o)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(res.Threshold-0.675) > 1e-6 || res.Precision != 1 || res.Recall != 0.5 || res.YoudenJ != 0.5 || res.Accuracy != 0.75 {
+			t.Errorf("Objective %d: unexpected calibration %+v", o, res)
+		}
+	}
+}
+
+func TestCalibrateThresholdErrors(t *testing.T) {
+	if _, err := voyageai.CalibrateThreshold(pairsWithSimilarities([]float64{0.9, 0.8}, nil), voyageai.MetricCosine, voyageai.ObjectiveF1); err == nil {
+		t.Error("Expected an error for relevant pairs only")
+	}
+	if _, err := voyageai.CalibrateThreshold(pairsWithSimilarities(nil, []float64{0.1}), voyageai.MetricCosine, voyageai.ObjectiveF1); err == nil {
+		t.Error("Expected an error for irrelevant pairs only")
+	}
+	if _, err := voyageai.CalibrateThreshold(nil, voyageai.MetricCosine, voyageai.ObjectiveF1); err == nil {
+		t.Error("Expected an error for no pairs")
+	}
+	mismatched := []voyageai.LabeledPair{{A: []float32{1}, B: []float32{1, 0}, Relevant: true}, similarPair(0.1, false)}
+	if _, err := voyageai.CalibrateThreshold(mismatched, voyageai.MetricCosine, voyageai.ObjectiveF1); err == nil {
+		t.Error("Expected an error for a pair of mixed dimensions")
+	}
+	if _, err := voyageai.CalibrateThreshold(pairsWithSimilarities([]float64{0.9}, []float64{0.1}), voyageai.MetricCosine, voyageai.Objective(9)); err == nil {
+		t.Error("Expected an error for an unknown objective")
+	}
+}
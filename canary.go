@@ -0,0 +1,190 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The defaults of [CanaryOpts].
+const (
+	DefaultCanaryInterval       = time.Minute
+	DefaultCanaryDriftThreshold = 0.99
+)
+
+// Options for [NewCanary].
+type CanaryOpts struct {
+	Interval time.Duration // The time between checks. Defaults to [DefaultCanaryInterval].
+	// The lowest acceptable cosine similarity between a reference's embedding and its baseline.
+	// Defaults to [DefaultCanaryDriftThreshold].
+	DriftThreshold float64
+	// A check taking longer than this fails. No limit by default.
+	MaxLatency time.Duration
+	EmbedOpts  *EmbeddingRequestOpts // Optional parameters for the embedding requests.
+	// Called with the result of every check. It is never called concurrently.
+	OnResult func(CanaryResult)
+}
+
+// The outcome of one canary check.
+type CanaryResult struct {
+	Time    time.Time     // When the check started, according to the client's [Clock].
+	Latency time.Duration // How long the embedding request took.
+	Err     error         // The request error, if the request failed.
+	// The cosine similarity of each reference's embedding to its baseline, in reference order.
+	// Nil if the request failed or the check set the baseline.
+	Similarities  []float64
+	MinSimilarity float64
+	Drifted       []int // The indices of references whose similarity fell below the threshold.
+	BaselineSet   bool  // Whether this check recorded the baseline because none was set.
+	Passed        bool  // Whether the request succeeded in time without drift.
+}
+
+// A monitor that periodically embeds fixed reference texts and reports errors, latency and drift
+// from baseline embeddings. See [NewCanary].
+type Canary struct {
+	client *VoyageClient
+	model  string
+	refs   []string
+	opts   CanaryOpts
+
+	mu       sync.Mutex
+	baseline [][]float32
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewCanary returns a [Canary] that checks refTexts with model. The first check records the
+// baseline unless one is set with [Canary.SetBaseline].
+func NewCanary(client *VoyageClient, model string, refTexts []string, opts CanaryOpts) *Canary {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultCanaryInterval
+	}
+	if opts.DriftThreshold == 0 {
+		opts.DriftThreshold = DefaultCanaryDriftThreshold
+	}
+	return &Canary{client: client, model: model, refs: refTexts, opts: opts}
+}
+
+// Baseline returns a copy of the baseline embeddings, one per reference, or nil if none is set.
+func (c *Canary) Baseline() [][]float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyVectors(c.baseline)
+}
+
+// SetBaseline replaces the baseline embeddings, for example with ones exported by
+// [Canary.Baseline] from an earlier run. There must be one per reference.
+func (c *Canary) SetBaseline(vecs [][]float32) error {
+	if len(vecs) != len(c.refs) {
+		return fmt.Errorf("voyage: baseline has %d vectors for %d references", len(vecs), len(c.refs))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseline = copyVectors(vecs)
+	return nil
+}
+
+func copyVectors(vecs [][]float32) [][]float32 {
+	if vecs == nil {
+		return nil
+	}
+	out := make([][]float32, len(vecs))
+	for i, v := range vecs {
+		out[i] = append([]float32(nil), v...)
+	}
+	return out
+}
+
+// Start runs a check immediately and then every [CanaryOpts.Interval] until ctx is done or
+// [Canary.Stop] is called. It returns an error if the canary is already running.
+func (c *Canary) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return errors.New("voyage: canary already started")
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx, c.done)
+	return nil
+}
+
+// Stop stops the canary and waits for a check in progress to finish.
+func (c *Canary) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (c *Canary) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	clock := c.client.clock()
+	for {
+		result := c.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if c.opts.OnResult != nil {
+			c.opts.OnResult(result)
+		}
+		select {
+		case <-clock.After(c.opts.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check runs a single check and returns its result.
+func (c *Canary) Check(ctx context.Context) CanaryResult {
+	clock := c.client.clock()
+	result := CanaryResult{Time: clock.Now()}
+	resp, err := c.client.embedContext(ctx, c.refs, c.model, c.opts.EmbedOpts)
+	result.Latency = clock.Now().Sub(result.Time)
+	if err == nil && len(resp.Data) != len(c.refs) {
+		err = fmt.Errorf("voyage: canary got %d embeddings for %d references", len(resp.Data), len(c.refs))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	embs := make([][]float32, len(c.refs))
+	for _, obj := range resp.Data {
+		if obj.Index >= 0 && obj.Index < len(embs) {
+			embs[obj.Index] = obj.Embedding
+		}
+	}
+
+	c.mu.Lock()
+	baseline := c.baseline
+	if baseline == nil {
+		c.baseline = embs
+		result.BaselineSet = true
+	}
+	c.mu.Unlock()
+
+	if baseline != nil {
+		result.MinSimilarity = 1
+		result.Similarities = make([]float64, len(embs))
+		for i, emb := range embs {
+			var sim float64 // A change of dimension counts as complete drift.
+			if len(emb) == len(baseline[i]) {
+				sim = cosine(emb, baseline[i])
+			}
+			result.Similarities[i] = sim
+			result.MinSimilarity = min(result.MinSimilarity, sim)
+			if sim < c.opts.DriftThreshold {
+				result.Drifted = append(result.Drifted, i)
+			}
+		}
+	}
+	result.Passed = len(result.Drifted) == 0 && (c.opts.MaxLatency <= 0 || result.Latency <= c.opts.MaxLatency)
+	return result
+}
@@ -0,0 +1,93 @@
+package voyageai_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestCanary(t *testing.T) {
+	var drift, failing atomic.Bool
+	srv := newMockServer(t)
+	srv.embed = func(text string) []float32 {
+		v := fakeVector(text)
+		if drift.Load() && text == "second" {
+			v[0], v[1] = v[1], -v[0]
+		}
+		return v
+	}
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if failing.Load() {
+			return 400
+		}
+		return 0
+	}
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, Clock: clock})
+
+	results := make(chan voyageai.CanaryResult, 1)
+	canary := voyageai.NewCanary(client, "test-model", []string{"first", "second"}, voyageai.CanaryOpts{
+		Interval: time.Minute,
+		OnResult: func(r voyageai.CanaryResult) { results <- r },
+	})
+	if err := canary.Start(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer canary.Stop()
+	if err := canary.Start(context.Background()); err == nil {
+		t.Error("Expected an error starting twice")
+	}
+	next := func() voyageai.CanaryResult {
+		t.Helper()
+		select {
+		case r := <-results:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a canary result")
+			return voyageai.CanaryResult{}
+		}
+	}
+	tick := func() voyageai.CanaryResult {
+		t.Helper()
+		clock.waitForTimers(t, 1)
+		clock.Advance(time.Minute)
+		return next()
+	}
+
+	if r := next(); !r.BaselineSet || !r.Passed || r.Err != nil {
+		t.Errorf("Expected the first check to set the baseline, got %+v", r)
+	}
+	if r := tick(); !r.Passed || r.MinSimilarity < 0.9999 || len(r.Similarities) != 2 {
+		t.Errorf("Expected a healthy check, got %+v", r)
+	}
+
+	drift.Store(true)
+	if r := tick(); r.Passed || len(r.Drifted) != 1 || r.Drifted[0] != 1 || r.Similarities[0] < 0.9999 {
+		t.Errorf("Expected the second reference to drift, got %+v", r)
+	}
+
+	failing.Store(true)
+	if r := tick(); r.Passed || r.Err == nil {
+		t.Errorf("Expected a failing check, got %+v", r)
+	}
+}
+
+func TestCanaryBaseline(t *testing.T) {
+	srv := newMockServer(t)
+	canary := voyageai.NewCanary(srv.client(), "test-model", []string{"a"}, voyageai.CanaryOpts{})
+	if err := canary.SetBaseline([][]float32{{1, 2}, {3, 4}}); err == nil {
+		t.Error("Expected an error for the wrong number of vectors")
+	}
+
+	exported := voyageai.NewCanary(srv.client(), "test-model", []string{"a"}, voyageai.CanaryOpts{})
+	exported.Check(context.Background())
+	if err := canary.SetBaseline(exported.Baseline()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if r := canary.Check(context.Background()); r.BaselineSet || !r.Passed {
+		t.Errorf("Expected the imported baseline to be used, got %+v", r)
+	}
+}
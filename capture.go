@@ -0,0 +1,35 @@
+package voyageai
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type rawCaptureKey struct{}
+
+// rawCapture serializes writes to the caller's writer.
+type rawCapture struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithRawCapture returns a copy of ctx that makes calls using it write the raw JSON body of every
+// successful API response to w, after decompression, before it is decoded. Responses to failed
+// attempts are not captured. Calls that make several requests, such as
+// [VoyageClient.EmbedBatch], write one body after another; writes are never concurrent.
+func WithRawCapture(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, rawCaptureKey{}, &rawCapture{w: w})
+}
+
+// captureRaw writes body to the capture writer of ctx, if any.
+func captureRaw(ctx context.Context, body []byte) error {
+	rc, ok := ctx.Value(rawCaptureKey{}).(*rawCapture)
+	if !ok {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	_, err := rc.w.Write(body)
+	return err
+}
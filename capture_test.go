@@ -0,0 +1,98 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestWithRawCapture(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n == 1 {
+			return 500
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+
+	var buf bytes.Buffer
+	ctx := voyageai.WithRawCapture(context.Background(), &buf)
+	resp, err := client.EmbedContext(ctx, []string{"a", "b"}, "test-model", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Only the successful attempt is captured.
+	var captured voyageai.EmbeddingResponse
+	if err := json.Unmarshal(buf.Bytes(), &captured); err != nil {
+		t.Fatalf("Captured bytes are not a single response: %v: %s", err, buf.Bytes())
+	}
+	captured.Metadata = resp.Metadata // Set by the client, not part of the response body.
+	if !reflect.DeepEqual(&captured, resp) {
+		t.Errorf("Expected the captured response to match the returned one:\n%+v\n%+v", captured, *resp)
+	}
+
+	// Calls without the option capture nothing.
+	buf.Reset()
+	if _, err := client.EmbedContext(context.Background(), []string{"c"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if buf.Len() != 0 {
+		t.Error("Expected nothing to be captured")
+	}
+}
+
+func TestWithRawCaptureRerank(t *testing.T) {
+	srv := newMockServer(t)
+	var buf bytes.Buffer
+	ctx := voyageai.WithRawCapture(context.Background(), &buf)
+	resp, err := srv.client().RerankContext(ctx, "red", []string{"red apple", "green pear"}, "rerank-2", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var captured voyageai.RerankResponse
+	if err := json.Unmarshal(buf.Bytes(), &captured); err != nil {
+		t.Fatal(err.Error())
+	}
+	captured.Metadata = resp.Metadata // Set by the client, not part of the response body.
+	if !reflect.DeepEqual(&captured, resp) {
+		t.Errorf("Expected the captured response to match the returned one")
+	}
+}
+
+func TestWithRawCaptureGzip(t *testing.T) {
+	body := `{"object":"list","data":[{"object":"embedding","embedding":[1,2],"index":0}],"model":"m","usage":{"total_tokens":1}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Error("Expected the transport to accept gzip")
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(body))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+	resp, err := client.EmbedContext(voyageai.WithRawCapture(context.Background(), &buf), []string{"x"}, "m", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if buf.String() != body {
+		t.Errorf("Expected the decompressed body, got %q", buf.String())
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Embedding[1] != 2 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,168 @@
+package voyageai
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The most inputs of a checkpointed [VoyageClient.EmbedBatch] run without a
+// [BatchOpts.ResultWriter]. Such runs keep their embeddings in the checkpoint, which is saved in
+// full after every request, so larger runs must write their results to a ResultWriter and
+// checkpoint only the completed ranges.
+const MaxCheckpointEmbeddings = 1000
+
+// Persists the progress of a batch run. See [BatchOpts].
+type Checkpointer interface {
+	// Save persists the given state, replacing any previously saved state.
+	Save(state *CheckpointState) error
+	// Load returns the last saved state. ok is false if nothing has been saved yet.
+	Load() (state *CheckpointState, ok bool, err error)
+}
+
+// The progress of a batch run.
+type CheckpointState struct {
+	Model       string           `json:"model"`       // Name of the model used for the run.
+	Fingerprint string           `json:"fingerprint"` // A hash of the model and inputs, used to reject mismatched resumes.
+	Total       int              `json:"total"`       // The number of inputs in the run.
+	Completed   []CompletedRange `json:"completed"`   // The input ranges that have been embedded so far.
+	Usage       UsageObject      `json:"usage"`       // Usage accumulated over the completed ranges.
+	// The length of the output written to [BatchOpts.ResultWriter] for the completed ranges,
+	// across resumed runs. Output past it belongs to ranges completed after the last save.
+	ResultBytes int64 `json:"result_bytes,omitempty"`
+}
+
+// A range of inputs [Start, End) that has been embedded, along with its results if they are
+// kept in the checkpoint.
+type CompletedRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// The embeddings for inputs Start through End-1. Empty when results were sent to a result
+	// writer. See [MaxCheckpointEmbeddings].
+	Embeddings [][]float32 `json:"embeddings,omitempty"`
+}
+
+// pending returns the input ranges not yet covered by a completed range, in ascending order.
+func (s *CheckpointState) pending() []batchRange {
+	done := make([]batchRange, 0, len(s.Completed))
+	for _, r := range s.Completed {
+		done = append(done, batchRange{Start: r.Start, End: r.End})
+	}
+	sort.Slice(done, func(i, j int) bool { return done[i].Start < done[j].Start })
+
+	var gaps []batchRange
+	next := 0
+	for _, r := range done {
+		if r.Start > next {
+			gaps = append(gaps, batchRange{Start: next, End: r.Start})
+		}
+		next = max(next, r.End)
+	}
+	if next < s.Total {
+		gaps = append(gaps, batchRange{Start: next, End: s.Total})
+	}
+	return gaps
+}
+
+// fingerprintInputs returns a stable hash of a model name and list of texts.
+func fingerprintInputs(model string, texts []string) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, s := range append([]string{model}, texts...) {
+		binary.LittleEndian.PutUint64(n[:], uint64(len(s)))
+		h.Write(n[:])
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// A [Checkpointer] that stores its state as JSON in a single file.
+// Saves are crash-consistent: the state is written to a temporary file which then replaces the
+// checkpoint, so the file always holds either the previous or the new state.
+type FileCheckpointer struct {
+	Path string
+}
+
+// Returns a new [FileCheckpointer] that stores its state at path.
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{Path: path}
+}
+
+func (f *FileCheckpointer) Save(state *CheckpointState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	return writeFileAtomic(f.Path, b)
+}
+
+func (f *FileCheckpointer) Load() (*CheckpointState, bool, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var state CheckpointState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, false, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	return &state, true, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as path, syncs it and
+// renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	return writeFileAtomicFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicFunc is like writeFileAtomic but the contents are produced by write.
+func writeFileAtomicFunc(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// markDone records [start, end) as completed, merging it with adjacent or overlapping ranges.
+// The ranges carry no embeddings.
+func (s *CheckpointState) markDone(start, end int) {
+	ranges := append(s.Completed, CompletedRange{Start: start, End: end})
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	s.Completed = merged
+}
@@ -0,0 +1,172 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedBatchResumesFromCheckpoint(t *testing.T) {
+	texts := make([]string, 25)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("document %d", i)
+	}
+
+	srv := newMockServer(t)
+	answered := map[string]int{}
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		// Simulate the process dying while the third request is in flight.
+		if n == 3 {
+			return 500
+		}
+		for _, text := range req.Input {
+			answered[text]++
+		}
+		return 0
+	}
+
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	batchOpts := &voyageai.BatchOpts{BatchSize: 4, Checkpointer: cp}
+
+	cl := srv.client()
+	if _, err := cl.EmbedBatch(context.Background(), texts, "test-model", nil, batchOpts); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+
+	state, ok, err := cp.Load()
+	if err != nil || !ok {
+		t.Fatalf("Expected a saved checkpoint, got ok=%v err=%v", ok, err)
+	}
+	if len(state.Completed) != 2 || state.Usage.TotalTokens == 0 {
+		t.Fatalf("Expected two completed ranges with usage, got %+v", state)
+	}
+	savedTokens := state.Usage.TotalTokens
+
+	resp, err := cl.EmbedBatch(context.Background(), texts, "test-model", nil, batchOpts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, text := range texts {
+		if answered[text] != 1 {
+			t.Errorf("Expected %q to be embedded exactly once, got %d", text, answered[text])
+		}
+	}
+
+	if len(resp.Data) != len(texts) {
+		t.Fatalf("Expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+	total := 0
+	for i, obj := range resp.Data {
+		if obj.Index != i {
+			t.Errorf("Expected index %d, got %d", i, obj.Index)
+		}
+		if !slices.Equal(obj.Embedding, fakeVector(texts[i])) {
+			t.Errorf("Wrong embedding for input %d", i)
+		}
+		total += len(texts[i])
+	}
+	if resp.Usage.TotalTokens != total || resp.Usage.TotalTokens <= savedTokens {
+		t.Errorf("Expected usage to continue from the saved totals, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestEmbedBatchRejectsMismatchedCheckpoint(t *testing.T) {
+	srv := newMockServer(t)
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	batchOpts := &voyageai.BatchOpts{BatchSize: 2, Checkpointer: cp}
+
+	cl := srv.client()
+	if _, err := cl.EmbedBatch(context.Background(), []string{"a", "b", "c"}, "test-model", nil, batchOpts); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := cl.EmbedBatch(context.Background(), []string{"x", "y"}, "test-model", nil, batchOpts); err == nil {
+		t.Fatal("Expected an error when resuming with different inputs")
+	}
+}
+
+func TestFileCheckpointerLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	cp := voyageai.NewFileCheckpointer(filepath.Join(dir, "run.checkpoint"))
+
+	if _, ok, err := cp.Load(); ok || err != nil {
+		t.Fatalf("Expected no state before the first save, got ok=%v err=%v", ok, err)
+	}
+
+	for i := range 3 {
+		state := &voyageai.CheckpointState{Model: "m", Total: 10, Completed: []voyageai.CompletedRange{{Start: 0, End: i + 1}}}
+		if err := cp.Save(state); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the checkpoint file, got %d entries", len(entries))
+	}
+
+	state, _, err := cp.Load()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if state.Completed[0].End != 3 {
+		t.Errorf("Expected the last saved state, got %+v", state.Completed)
+	}
+}
+
+func TestEmbedBatchCheckpointsResultWriterRanges(t *testing.T) {
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("document %d", i)
+	}
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n == 3 {
+			return 400
+		}
+		return 0
+	}
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	var out bytes.Buffer
+	batchOpts := &voyageai.BatchOpts{BatchSize: 2, Checkpointer: cp, ResultWriter: &out}
+	if _, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, batchOpts); err == nil {
+		t.Fatal("Expected the run to fail")
+	}
+
+	state, _, err := cp.Load()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(state.Completed) != 1 || state.Completed[0].Start != 0 || state.Completed[0].End != 4 || state.Completed[0].Embeddings != nil {
+		t.Errorf("Expected a single merged range without embeddings, got %+v", state.Completed)
+	}
+	if state.ResultBytes != int64(out.Len()) {
+		t.Errorf("Expected the checkpoint to record the %d bytes written, got %d", out.Len(), state.ResultBytes)
+	}
+}
+
+func TestEmbedBatchLimitsInlineCheckpoints(t *testing.T) {
+	srv := newMockServer(t)
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "run.checkpoint"))
+	texts := make([]string, voyageai.MaxCheckpointEmbeddings+1)
+	for i := range texts {
+		texts[i] = "text"
+	}
+	_, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, &voyageai.BatchOpts{Checkpointer: cp})
+	if err == nil || !strings.Contains(err.Error(), "requires a ResultWriter") {
+		t.Errorf("Expected a checkpoint of too many embeddings to be rejected, got %v", err)
+	}
+	if srv.requestCount() != 0 {
+		t.Errorf("Expected no request to be sent, got %d", srv.requestCount())
+	}
+}
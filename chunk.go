@@ -0,0 +1,132 @@
+package voyageai
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The default maximum size of a chunk produced by [ChunkText], in estimated tokens.
+const DefaultChunkTokens = 512
+
+// How [ChunkText] splits text.
+type ChunkStrategy int
+
+const (
+	StrategyFixed    ChunkStrategy = iota // Fixed-size chunks on word boundaries.
+	StrategyMarkdown                      // Sections of a Markdown document. See [ChunkMarkdown].
+	StrategyCode                          // Declarations of source code. See [ChunkCode].
+	// [StrategyMarkdown] for Markdown files, [StrategyCode] for source files and [StrategyFixed]
+	// for others, by their extension. See [ChunkFile]. Text without a file name is split with
+	// StrategyFixed.
+	StrategyAuto
+)
+
+// Options for splitting text into chunks. See [ChunkText].
+type ChunkOpts struct {
+	MaxTokens int           // The maximum estimated tokens per chunk. Defaults to [DefaultChunkTokens].
+	Overlap   int           // The number of estimated tokens repeated at the start of the following chunk. Defaults to 0.
+	Strategy  ChunkStrategy // How the text is split. Defaults to [StrategyFixed].
+	// Embed each chunk with its [Chunk.HeadingPath] on a line before its text, in
+	// [VoyageClient.EmbedChunks] and [VoyageClient.IndexFS].
+	HeadingPrefix bool
+}
+
+// A contiguous piece of a larger text.
+type Chunk struct {
+	Text  string // The chunk's text, equal to the source text sliced from Start to End.
+	Start int    // The byte offset of the start of the chunk in the source text.
+	End   int    // The byte offset just past the end of the chunk in the source text.
+
+	Headings  []string // The titles of the headings enclosing the chunk, outermost first. Set by [ChunkMarkdown].
+	Oversized bool     // The chunk exceeds the token limit because it is a code block or table, which is never split.
+	Symbol    string   // The name of the declaration the chunk holds. Set by [ChunkCode] for Go.
+	Line      int      // The line of the start of the chunk, starting at 1. Set by [ChunkCode].
+}
+
+// HeadingPath returns the chunk's headings joined by " > ", such as "Install > Linux".
+func (c Chunk) HeadingPath() string {
+	return strings.Join(c.Headings, " > ")
+}
+
+// embedText returns the text embedded for c, prefixed with its heading path if opts ask for it.
+func (c Chunk) embedText(opts ChunkOpts) string {
+	if !opts.HeadingPrefix || len(c.Headings) == 0 {
+		return c.Text
+	}
+	return c.HeadingPath() + "\n\n" + c.Text
+}
+
+// A run of non-space characters, with its byte offsets and the estimated tokens (in quarters)
+// preceding its start and end.
+type wordSpan struct {
+	start, end   int
+	qStart, qEnd int
+}
+
+// ChunkText splits text into chunks of at most [ChunkOpts.MaxTokens] estimated tokens (see
+// [EstimateTokens]). Chunks start and end on word boundaries unless a single word exceeds the
+// limit, in which case it is split. Consecutive chunks share up to [ChunkOpts.Overlap] tokens.
+// Text consisting only of whitespace produces no chunks.
+//
+// With [StrategyMarkdown] or [StrategyCode], text is split by [ChunkMarkdown] or [ChunkCode]
+// instead.
+func ChunkText(text string, opts ChunkOpts) []Chunk {
+	return ChunkFile("", text, opts)
+}
+
+// chunkFixed splits text as [ChunkText] does with [StrategyFixed].
+func chunkFixed(text string, opts ChunkOpts) []Chunk {
+	maxQ := opts.MaxTokens * 4
+	if maxQ <= 0 {
+		maxQ = DefaultChunkTokens * 4
+	}
+	overlapQ := max(opts.Overlap*4, 0)
+
+	words := splitWords(text, maxQ)
+	var chunks []Chunk
+	for i := 0; i < len(words); {
+		j := i
+		for j+1 < len(words) && words[j+1].qEnd-words[i].qStart <= maxQ {
+			j++
+		}
+		start, end := words[i].start, words[j].end
+		chunks = append(chunks, Chunk{Text: text[start:end], Start: start, End: end})
+		if j == len(words)-1 {
+			break
+		}
+
+		next := j + 1
+		for k := j; k > i && words[j].qEnd-words[k].qStart <= overlapQ; k-- {
+			next = k
+		}
+		i = next
+	}
+	return chunks
+}
+
+// splitWords returns the whitespace-separated words of text. Words longer than maxQ quarter
+// tokens are split into pieces that fit.
+func splitWords(text string, maxQ int) []wordSpan {
+	var words []wordSpan
+	q := 0
+	inWord := false
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			inWord = false
+		} else {
+			rq := runeQuarters(r)
+			if !inWord || q+rq-words[len(words)-1].qStart > maxQ {
+				words = append(words, wordSpan{start: i, qStart: q})
+			}
+			inWord = true
+			w := &words[len(words)-1]
+			w.end = i + size
+			w.qEnd = q + rq
+		}
+		q += runeQuarters(r)
+		i += size
+	}
+	return words
+}
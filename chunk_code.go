@@ -0,0 +1,266 @@
+package voyageai
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+)
+
+// File extensions split with [StrategyCode] by [StrategyAuto].
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".mjs": true, ".ts": true, ".tsx": true,
+	".java": true, ".kt": true, ".scala": true, ".c": true, ".h": true, ".cc": true, ".cpp": true,
+	".hpp": true, ".cs": true, ".rs": true, ".rb": true, ".php": true, ".swift": true, ".sh": true,
+	".lua": true,
+}
+
+// File extensions split with [StrategyMarkdown] by [StrategyAuto].
+var markdownExtensions = map[string]bool{".md": true, ".markdown": true, ".mdx": true}
+
+// ChunkFile splits the contents of the named file into chunks. It is [ChunkText], except that
+// with [StrategyAuto] the strategy is chosen by the file's extension, and that the name tells
+// [ChunkCode] the file's language.
+func ChunkFile(name, text string, opts ChunkOpts) []Chunk {
+	if opts.Strategy == StrategyAuto {
+		switch ext := strings.ToLower(path.Ext(name)); {
+		case markdownExtensions[ext]:
+			opts.Strategy = StrategyMarkdown
+		case codeExtensions[ext]:
+			opts.Strategy = StrategyCode
+		default:
+			opts.Strategy = StrategyFixed
+		}
+	}
+	switch opts.Strategy {
+	case StrategyMarkdown:
+		return ChunkMarkdown(text, opts)
+	case StrategyCode:
+		return ChunkCode(name, text, opts)
+	}
+	return chunkFixed(text, opts)
+}
+
+// ChunkCode splits source code into chunks that follow its declarations, recording the first
+// line of each chunk in [Chunk.Line].
+//
+// Go files, named with a .go extension, are split at top-level declarations: each function,
+// method, type, constant or variable declaration is a chunk together with its doc comment, and
+// [Chunk.Symbol] names it, such as "Client.Do" for a method. The package clause and imports form
+// the first chunk. Declarations longer than [ChunkOpts.MaxTokens] are split into windows of whole
+// lines, all with the declaration's symbol. Go files that do not parse are split as other files.
+//
+// Other files are split into top-level blocks, which start after a blank line at a line that is
+// neither indented nor inside braces, such as a function or class in most languages. Consecutive
+// blocks are merged while they fit, and longer blocks are split into windows of whole lines.
+// Lines longer than the limit are split as by [ChunkText]. [ChunkOpts.Overlap] is ignored.
+func ChunkCode(name, text string, opts ChunkOpts) []Chunk {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+	s := &codeSplitter{text: text, maxTokens: maxTokens, lines: lineStarts(text)}
+	if strings.EqualFold(path.Ext(name), ".go") && s.splitGo(name) {
+		return s.chunks
+	}
+	s.chunks = nil
+	s.splitBlocks()
+	return s.chunks
+}
+
+// codeSplitter accumulates the chunks of a source file.
+type codeSplitter struct {
+	text      string
+	maxTokens int
+	lines     []int // The byte offset of the start of every line.
+	chunks    []Chunk
+}
+
+// lineStarts returns the byte offset of the start of every line of text.
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// line returns the index of the line containing the byte at offset.
+func (s *codeSplitter) line(offset int) int {
+	lo, hi := 0, len(s.lines)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if s.lines[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// lineEnd returns the offset just past the last character of line i, before its line ending.
+func (s *codeSplitter) lineEnd(i int) int {
+	end := len(s.text)
+	if i+1 < len(s.lines) {
+		end = s.lines[i+1]
+	}
+	return s.lines[i] + len(strings.TrimRight(s.text[s.lines[i]:end], "\r\n"))
+}
+
+// emit adds the text from start to end, without surrounding whitespace, as one chunk or, if it is
+// longer than the limit, as windows of whole lines.
+func (s *codeSplitter) emit(start, end int, symbol string) {
+	for start < end && strings.ContainsRune(" \t\r\n", rune(s.text[start])) {
+		start++
+	}
+	for end > start && strings.ContainsRune(" \t\r\n", rune(s.text[end-1])) {
+		end--
+	}
+	if start == end {
+		return
+	}
+	if EstimateTokens(s.text[start:end]) <= s.maxTokens {
+		s.chunks = append(s.chunks, Chunk{Text: s.text[start:end], Start: start, End: end, Symbol: symbol, Line: s.line(start) + 1})
+		return
+	}
+	first, last := s.line(start), s.line(end-1)
+	wStart := -1
+	for i := first; i <= last; i++ {
+		lStart, lEnd := max(s.lines[i], start), min(s.lineEnd(i), end)
+		switch {
+		case wStart >= 0 && EstimateTokens(s.text[wStart:lEnd]) <= s.maxTokens:
+			continue
+		case wStart >= 0:
+			s.emit(wStart, s.lineEnd(i-1), symbol)
+			wStart = -1
+		}
+		if EstimateTokens(s.text[lStart:lEnd]) <= s.maxTokens {
+			wStart = lStart
+			continue
+		}
+		for _, ch := range ChunkText(s.text[lStart:lEnd], ChunkOpts{MaxTokens: s.maxTokens}) {
+			s.chunks = append(s.chunks, Chunk{Text: ch.Text, Start: lStart + ch.Start, End: lStart + ch.End, Symbol: symbol, Line: i + 1})
+		}
+	}
+	if wStart >= 0 {
+		s.emit(wStart, end, symbol)
+	}
+}
+
+// splitGo splits a Go file at its top-level declarations. It reports false if the file does not
+// parse.
+func (s *codeSplitter) splitGo(name string) bool {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, s.text, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return false
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	// The package clause, with the file's doc comment, and the imports.
+	headerEnd := offset(f.Name.End())
+	decls := f.Decls
+	for len(decls) > 0 {
+		if d, ok := decls[0].(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			headerEnd = offset(d.End())
+			decls = decls[1:]
+			continue
+		}
+		break
+	}
+	headerStart := offset(f.Package)
+	if f.Doc != nil {
+		headerStart = offset(f.Doc.Pos())
+	}
+	s.emit(headerStart, headerEnd, "package "+f.Name.Name)
+
+	for _, d := range decls {
+		start := offset(d.Pos())
+		var symbol string
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = offset(d.Doc.Pos())
+			}
+			symbol = d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbol = receiverName(d.Recv.List[0].Type) + "." + symbol
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = offset(d.Doc.Pos())
+			}
+			var names []string
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range spec.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+			symbol = strings.Join(names, ", ")
+		}
+		s.emit(start, offset(d.End()), symbol)
+	}
+	return true
+}
+
+// receiverName returns the name of a method's receiver type, without pointers or type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// splitBlocks splits text into top-level blocks, merging consecutive blocks while they fit.
+func (s *codeSplitter) splitBlocks() {
+	// Find where blocks start: after a blank line, at an unindented line outside braces.
+	var starts []int
+	depth := 0
+	blank := true
+	for i, start := range s.lines {
+		line := s.text[start:s.lineEnd(i)]
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && depth <= 0 && line[0] != ' ' && line[0] != '\t' {
+			starts = append(starts, start)
+		}
+		blank = false
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+	}
+	if len(starts) == 0 || starts[0] != 0 {
+		starts = append([]int{0}, starts...)
+	}
+	starts = append(starts, len(s.text))
+
+	for i := 0; i+1 < len(starts); {
+		j := i + 1
+		for j+1 < len(starts) && EstimateTokens(strings.TrimSpace(s.text[starts[i]:starts[j+1]])) <= s.maxTokens {
+			j++
+		}
+		s.emit(starts[i], starts[j], "")
+		i = j
+	}
+}
@@ -0,0 +1,159 @@
+package voyageai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/zamedic/voyageai"
+)
+
+const goFixture = `// Package store keeps things.
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrMissing is returned for unknown keys.
+var ErrMissing = errors.New("missing")
+
+const (
+	small = 1
+	large = 2
+)
+
+// Store is a concurrency-safe map.
+type Store[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]V
+}
+
+// Get returns the value stored under k.
+func (s *Store[K, V]) Get(k K) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[k]
+	if !ok {
+		return v, ErrMissing
+	}
+	return v, nil
+}
+
+func helper() {}
+`
+
+func TestChunkCodeGoDeclarations(t *testing.T) {
+	chunks := voyageai.ChunkCode("store.go", goFixture, voyageai.ChunkOpts{MaxTokens: 100})
+	want := []struct {
+		symbol, prefix string
+		line           int
+	}{
+		{"package store", "// Package store keeps things.\npackage store\n\nimport (", 1},
+		{"ErrMissing", "// ErrMissing is returned", 9},
+		{"small, large", "const (", 12},
+		{"Store", "// Store is a concurrency-safe map.\ntype Store", 17},
+		{"Store.Get", "// Get returns the value stored under k.\nfunc (s *Store[K, V]) Get", 23},
+		{"helper", "func helper() {}", 34},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		c := chunks[i]
+		if c.Symbol != w.symbol || c.Line != w.line || !strings.HasPrefix(c.Text, w.prefix) {
+			t.Errorf("Chunk %d: expected %s at line %d starting %q, got %s at line %d: %q", i, w.symbol, w.line, w.prefix, c.Symbol, c.Line, c.Text)
+		}
+		if goFixture[c.Start:c.End] != c.Text {
+			t.Errorf("Chunk %d text does not match its offsets", i)
+		}
+	}
+	if !strings.HasSuffix(chunks[0].Text, ")") || !strings.HasSuffix(chunks[4].Text, "return v, nil\n}") {
+		t.Error("Expected the imports and the method to be whole")
+	}
+}
+
+func TestChunkCodeSplitsOversizedDeclarations(t *testing.T) {
+	chunks := voyageai.ChunkCode("store.go", goFixture, voyageai.ChunkOpts{MaxTokens: 20})
+	var get []voyageai.Chunk
+	for _, c := range chunks {
+		if voyageai.EstimateTokens(c.Text) > 20 {
+			t.Errorf("Chunk %q exceeds the token limit", c.Text)
+		}
+		if goFixture[c.Start:c.End] != c.Text {
+			t.Errorf("Chunk %q text does not match its offsets", c.Text)
+		}
+		if c.Symbol == "Store.Get" {
+			get = append(get, c)
+		}
+	}
+	if len(get) < 2 {
+		t.Fatalf("Expected the method to be split into windows, got %+v", get)
+	}
+	if get[0].Line != 23 || !strings.HasPrefix(get[0].Text, "// Get") {
+		t.Errorf("Expected the first window to start at the doc comment, got line %d: %q", get[0].Line, get[0].Text)
+	}
+	for i := 1; i < len(get); i++ {
+		if get[i].Line <= get[i-1].Line || goFixture[get[i].Start-1] != '\t' && goFixture[get[i].Start-1] != '\n' {
+			t.Errorf("Expected window %d to start on a new line, got %q", i, get[i].Text)
+		}
+	}
+}
+
+func TestChunkCodeOtherLanguages(t *testing.T) {
+	src := "import os, sys, json\n\n\ndef first():\n    x = 1\n\n    return x\n\n\nclass Second:\n    def method(self):\n        pass\n"
+	chunks := voyageai.ChunkCode("app.py", src, voyageai.ChunkOpts{MaxTokens: 13})
+	want := []string{"import os, sys, json", "def first():\n    x = 1\n\n    return x", "class Second:\n    def method(self):\n        pass"}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %+v", len(want), chunks)
+	}
+	for i, c := range chunks {
+		if c.Text != want[i] || c.Symbol != "" {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], c.Text)
+		}
+	}
+	if chunks[1].Line != 4 || chunks[2].Line != 10 {
+		t.Errorf("Expected the blocks on lines 4 and 10, got %d and %d", chunks[1].Line, chunks[2].Line)
+	}
+
+	js := "function a() {\n  if (x) {\n\n    y();\n  }\n}\n\nfunction b() {}\n"
+	chunks = voyageai.ChunkText(js, voyageai.ChunkOpts{MaxTokens: 12, Strategy: voyageai.StrategyCode})
+	if len(chunks) != 2 || !strings.HasSuffix(chunks[0].Text, "  }\n}") {
+		t.Errorf("Expected blank lines inside braces to be kept in the block, got %+v", chunks)
+	}
+
+	// Go that does not parse is split as other languages.
+	chunks = voyageai.ChunkCode("broken.go", "package x\n\nfunc (\n", voyageai.ChunkOpts{})
+	if len(chunks) != 1 || chunks[0].Symbol != "" {
+		t.Errorf("Expected one chunk without a symbol, got %+v", chunks)
+	}
+}
+
+func TestIndexFSChunksByExtension(t *testing.T) {
+	srv := newMockServer(t)
+	fsys := fstest.MapFS{
+		"store.go":  {Data: []byte(goFixture)},
+		"README.md": {Data: []byte("# Store\n\nA map.\n\n## Usage\n\nCall Get.")},
+	}
+	symbols := map[string]bool{}
+	headings := map[string]bool{}
+	_, err := srv.client().IndexFS(context.Background(), fsys, voyageai.IndexOpts{
+		ChunkOpts: voyageai.ChunkOpts{MaxTokens: 100, Strategy: voyageai.StrategyAuto},
+		Model:     "test-model",
+	}, func(ce voyageai.ChunkEmbedding) error {
+		symbols[ce.Symbol] = true
+		headings[ce.HeadingPath()] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to index: %+v", err)
+	}
+	if !symbols["Store.Get"] || !symbols["helper"] {
+		t.Errorf("Expected the Go file split by declaration, got symbols %v", symbols)
+	}
+	if !headings["Store"] {
+		t.Errorf("Expected the Markdown file split by heading, got %v", headings)
+	}
+}
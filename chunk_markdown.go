@@ -0,0 +1,209 @@
+package voyageai
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Matches an ATX heading, capturing its markers and its title without closing markers.
+	markdownHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	// Matches the opening line of a fenced code block, capturing the fence.
+	markdownFenceOpen = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	// Matches the delimiter row below the header of a table, such as |---|:--:|.
+	markdownTableDelimiter = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+)
+
+// A heading, code block, table or paragraph of a Markdown document.
+type markdownBlock struct {
+	start, end int
+	level      int    // The level of a heading, or 0.
+	title      string // The title of a heading.
+	atomic     bool   // A code block or table, which is never split.
+}
+
+// ChunkMarkdown splits a Markdown document into chunks along its structure. The document is
+// divided into sections at every ATX heading (# Title), and each chunk records the headings
+// enclosing it in [Chunk.Headings].
+//
+// Small sections are merged with the sections that follow while they fit in [ChunkOpts.MaxTokens]
+// estimated tokens and are nested at least as deep, such as their subsections and siblings, so a
+// chunk never climbs out of its parent section. A merged chunk's headings are those its sections
+// share. Sections longer than the limit
+// are split between paragraphs, code blocks and tables. Fenced code blocks and tables are never
+// split: one longer than the limit is a chunk of its own with [Chunk.Oversized] set. Paragraphs
+// longer than the limit are split as by [ChunkText]. [ChunkOpts.Overlap] is ignored.
+func ChunkMarkdown(text string, opts ChunkOpts) []Chunk {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+	tokens := func(start, end int) int { return EstimateTokens(text[start:end]) }
+
+	// Group the blocks into sections, each starting at a heading, except perhaps the first.
+	type section struct {
+		level    int
+		headings []string
+		blocks   []markdownBlock
+	}
+	var sections []section
+	var path []string
+	var levels []int
+	for _, b := range markdownBlocks(text) {
+		if b.level == 0 {
+			if len(sections) == 0 {
+				sections = append(sections, section{})
+			}
+			s := &sections[len(sections)-1]
+			s.blocks = append(s.blocks, b)
+			continue
+		}
+		for len(levels) > 0 && levels[len(levels)-1] >= b.level {
+			path, levels = path[:len(path)-1], levels[:len(levels)-1]
+		}
+		path, levels = append(path, b.title), append(levels, b.level)
+		sections = append(sections, section{level: b.level, headings: append([]string(nil), path...), blocks: []markdownBlock{b}})
+	}
+
+	var chunks []Chunk
+	emit := func(start, end int, headings []string, oversized bool) {
+		chunks = append(chunks, Chunk{Text: text[start:end], Start: start, End: end, Headings: headings, Oversized: oversized})
+	}
+	for i := 0; i < len(sections); {
+		s := sections[i]
+		start, end := s.blocks[0].start, s.blocks[len(s.blocks)-1].end
+		if tokens(start, end) > maxTokens {
+			// Pack the section's blocks into as few chunks as fit.
+			pStart, pEnd := -1, -1
+			flush := func() {
+				if pStart >= 0 {
+					emit(pStart, pEnd, s.headings, false)
+				}
+				pStart = -1
+			}
+			// from returns where a block too long for a chunk starts its chunk: at the section's
+			// heading if nothing else is pending, so the heading is not a chunk of its own.
+			from := func(b markdownBlock) int {
+				h := s.blocks[0]
+				if h.level > 0 && pStart == h.start && pEnd == h.end {
+					pStart = -1
+					return h.start
+				}
+				flush()
+				return b.start
+			}
+			for _, b := range s.blocks {
+				switch {
+				case pStart >= 0 && tokens(pStart, b.end) <= maxTokens:
+					pEnd = b.end
+				case tokens(b.start, b.end) <= maxTokens:
+					flush()
+					pStart, pEnd = b.start, b.end
+				case b.atomic:
+					emit(from(b), b.end, s.headings, true)
+				default:
+					bStart := from(b)
+					for _, ch := range ChunkText(text[bStart:b.end], ChunkOpts{MaxTokens: maxTokens}) {
+						emit(bStart+ch.Start, bStart+ch.End, s.headings, false)
+					}
+				}
+			}
+			flush()
+			i++
+			continue
+		}
+
+		headings := s.headings
+		j := i + 1
+		for ; j < len(sections); j++ {
+			next := sections[j]
+			nextEnd := next.blocks[len(next.blocks)-1].end
+			if next.level < s.level || tokens(start, nextEnd) > maxTokens {
+				break
+			}
+			end = nextEnd
+			headings = commonPrefix(headings, next.headings)
+		}
+		emit(start, end, headings, false)
+		i = j
+	}
+	return chunks
+}
+
+// markdownBlocks splits text into blocks, leaving out the blank lines between them.
+func markdownBlocks(text string) []markdownBlock {
+	type line struct {
+		start, end int // The offsets of the line, without its line ending.
+		text       string
+	}
+	var lines []line
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		next := start + end + 1
+		if end < 0 {
+			end, next = len(text)-start, len(text)
+		}
+		s := strings.TrimSuffix(text[start:start+end], "\r")
+		lines = append(lines, line{start: start, end: start + len(s), text: s})
+		start = next
+	}
+
+	var blocks []markdownBlock
+	para := -1 // The index of the paragraph the next text line continues, if any.
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if strings.TrimSpace(l.text) == "" {
+			para = -1
+			continue
+		}
+		if m := markdownFenceOpen.FindStringSubmatch(l.text); m != nil {
+			// The block runs to a closing fence at least as long, or the end of the document.
+			fence := strings.TrimLeft(m[1], " ")
+			j := i + 1
+			for ; j < len(lines); j++ {
+				t := strings.TrimLeft(lines[j].text, " ")
+				if len(lines[j].text)-len(t) <= 3 && strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]+" \t") == "" {
+					break
+				}
+			}
+			j = min(j, len(lines)-1)
+			blocks = append(blocks, markdownBlock{start: l.start, end: lines[j].end, atomic: true})
+			i, para = j, -1
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(l.text); m != nil {
+			blocks = append(blocks, markdownBlock{start: l.start, end: l.end, level: len(m[1]), title: strings.TrimSpace(m[2])})
+			para = -1
+			continue
+		}
+		if strings.Contains(l.text, "|") && i+1 < len(lines) && markdownTableDelimiter.MatchString(lines[i+1].text) {
+			j := i + 1
+			for j+1 < len(lines) && strings.Contains(lines[j+1].text, "|") && strings.TrimSpace(lines[j+1].text) != "" {
+				j++
+			}
+			blocks = append(blocks, markdownBlock{start: l.start, end: lines[j].end, atomic: true})
+			i, para = j, -1
+			continue
+		}
+		if para >= 0 {
+			blocks[para].end = l.end
+			continue
+		}
+		blocks = append(blocks, markdownBlock{start: l.start, end: l.end})
+		para = len(blocks) - 1
+	}
+	for i, b := range blocks {
+		s := text[b.start:b.end]
+		blocks[i].start += len(s) - len(strings.TrimLeft(s, " \t"))
+		blocks[i].end -= len(s) - len(strings.TrimRight(s, " \t"))
+	}
+	return blocks
+}
+
+func commonPrefix(a, b []string) []string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n:n]
+}
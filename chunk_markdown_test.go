@@ -0,0 +1,148 @@
+package voyageai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+const installGuide = `Intro paragraph before any heading.
+
+# Install
+
+Pick your platform.
+
+## Linux
+
+Use the package manager.
+
+### Troubleshooting
+
+If the service fails to start, check the logs.
+
+## macOS
+
+Use Homebrew.
+
+# Usage
+
+| Flag | Meaning |
+|------|---------|
+| -v   | verbose |
+`
+
+func chunkTexts(chunks []voyageai.Chunk) []string {
+	var out []string
+	for _, c := range chunks {
+		out = append(out, c.HeadingPath()+": "+c.Text)
+	}
+	return out
+}
+
+func TestChunkMarkdownNestedHeadings(t *testing.T) {
+	chunks := voyageai.ChunkText(installGuide, voyageai.ChunkOpts{MaxTokens: 10, Strategy: voyageai.StrategyMarkdown})
+	want := []string{
+		": Intro paragraph before any heading.",
+		"Install: # Install\n\nPick your platform.",
+		"Install > Linux: ## Linux\n\nUse the package manager.",
+		"Install > Linux > Troubleshooting: ### Troubleshooting\n\nIf the service",
+		"Install > Linux > Troubleshooting: fails to start, check the logs.",
+		"Install > macOS: ## macOS\n\nUse Homebrew.",
+		"Usage: # Usage\n\n| Flag | Meaning |\n|------|---------|\n| -v   | verbose |",
+	}
+	if got := chunkTexts(chunks); strings.Join(got, "\n--\n") != strings.Join(want, "\n--\n") {
+		t.Fatalf("Expected\n%s\ngot\n%s", strings.Join(want, "\n--\n"), strings.Join(got, "\n--\n"))
+	}
+	for i, c := range chunks {
+		if installGuide[c.Start:c.End] != c.Text {
+			t.Errorf("Chunk %d text does not match its offsets", i)
+		}
+		if c.Oversized != (i == len(chunks)-1) {
+			t.Errorf("Chunk %d: expected only the table to be oversized", i)
+		}
+	}
+	if got := chunks[3].Headings; len(got) != 3 || got[2] != "Troubleshooting" {
+		t.Errorf("Expected the full heading path, got %q", got)
+	}
+}
+
+func TestChunkMarkdownMergesWithinParent(t *testing.T) {
+	chunks := voyageai.ChunkMarkdown(installGuide, voyageai.ChunkOpts{MaxTokens: 30})
+	want := []string{
+		// The introduction has no heading, so it shares none with the sections merged into it.
+		": Intro paragraph before any heading.\n\n# Install\n\nPick your platform.\n\n## Linux\n\nUse the package manager.",
+		// macOS is not a subsection of Troubleshooting, so it is not merged although it would fit.
+		"Install > Linux > Troubleshooting: ### Troubleshooting\n\nIf the service fails to start, check the logs.",
+		"Install > macOS: ## macOS\n\nUse Homebrew.",
+		"Usage: # Usage\n\n| Flag | Meaning |\n|------|---------|\n| -v   | verbose |",
+	}
+	if got := chunkTexts(chunks); strings.Join(got, "\n--\n") != strings.Join(want, "\n--\n") {
+		t.Fatalf("Expected\n%s\ngot\n%s", strings.Join(want, "\n--\n"), strings.Join(got, "\n--\n"))
+	}
+
+	siblings := "# A\n\n## B\n\nb text\n\n## C\n\nc text\n\n# D\n\nd text"
+	for _, tc := range []struct {
+		maxTokens int
+		want      []string
+	}{
+		{5, []string{"A: # A\n\n## B\n\nb text", "A > C: ## C\n\nc text", "D: # D\n\nd text"}},
+		{8, []string{"A: # A\n\n## B\n\nb text\n\n## C\n\nc text", "D: # D\n\nd text"}},
+		// Top-level sections are siblings too, and share no heading.
+		{12, []string{": " + siblings}},
+	} {
+		chunks = voyageai.ChunkMarkdown(siblings, voyageai.ChunkOpts{MaxTokens: tc.maxTokens})
+		if got := chunkTexts(chunks); strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("MaxTokens %d: expected %q, got %q", tc.maxTokens, tc.want, got)
+		}
+	}
+}
+
+func TestChunkMarkdownKeepsCodeFenceWhole(t *testing.T) {
+	code := "```sh\n# not a heading\nmake build\n\nmake test && make install PREFIX=/usr/local\n```"
+	doc := "# Build\n\nRun the following commands:\n\n" + code + "\n\nThen start the service."
+	chunks := voyageai.ChunkMarkdown(doc, voyageai.ChunkOpts{MaxTokens: 12})
+	want := []string{
+		"Build: # Build\n\nRun the following commands:",
+		"Build: " + code,
+		"Build: Then start the service.",
+	}
+	if got := chunkTexts(chunks); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+	if !chunks[1].Oversized || chunks[0].Oversized || chunks[2].Oversized {
+		t.Error("Expected only the code block to be flagged oversized")
+	}
+
+	// An unclosed fence runs to the end of the document.
+	chunks = voyageai.ChunkMarkdown("# A\n\n```\n# B\n", voyageai.ChunkOpts{})
+	if len(chunks) != 1 || chunks[0].HeadingPath() != "A" {
+		t.Errorf("Expected one chunk under A, got %+v", chunks)
+	}
+}
+
+func TestEmbedChunksHeadingPrefix(t *testing.T) {
+	srv := newMockServer(t)
+	chunks, _, err := srv.client().EmbedChunks(context.Background(), installGuide, "test-model", voyageai.ChunkOpts{
+		MaxTokens:     10,
+		Strategy:      voyageai.StrategyMarkdown,
+		HeadingPrefix: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to embed chunks: %+v", err)
+	}
+	inputs := srv.requests[0].Input
+	if len(inputs) != len(chunks) {
+		t.Fatalf("Expected %d inputs, got %d", len(chunks), len(inputs))
+	}
+	if inputs[0] != "Intro paragraph before any heading." {
+		t.Errorf("Expected no prefix without headings, got %q", inputs[0])
+	}
+	if want := "Install > Linux\n\n## Linux\n\nUse the package manager."; inputs[2] != want {
+		t.Errorf("Expected %q, got %q", want, inputs[2])
+	}
+	if chunks[2].Text != "## Linux\n\nUse the package manager." {
+		t.Errorf("Expected the chunk text without its prefix, got %q", chunks[2].Text)
+	}
+}
@@ -0,0 +1,67 @@
+package voyageai_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestChunkTextRespectsLimitAndOffsets(t *testing.T) {
+	text := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	chunks := voyageai.ChunkText(text, voyageai.ChunkOpts{MaxTokens: 50})
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+
+	for i, c := range chunks {
+		if c.Text != text[c.Start:c.End] {
+			t.Errorf("Chunk %d text does not match its offsets", i)
+		}
+		if n := voyageai.EstimateTokens(c.Text); n > 50 {
+			t.Errorf("Chunk %d has %d estimated tokens", i, n)
+		}
+		if strings.TrimSpace(c.Text) != c.Text {
+			t.Errorf("Chunk %d does not start and end on a word", i)
+		}
+		if i > 0 && c.Start < chunks[i-1].End {
+			t.Errorf("Chunk %d overlaps the previous chunk without Overlap set", i)
+		}
+	}
+	if chunks[len(chunks)-1].End != len(strings.TrimRight(text, " ")) {
+		t.Error("Expected the last chunk to reach the end of the text")
+	}
+}
+
+func TestChunkTextOverlap(t *testing.T) {
+	text := strings.Repeat("abcdefg ", 100)
+	chunks := voyageai.ChunkText(text, voyageai.ChunkOpts{MaxTokens: 20, Overlap: 6})
+	for i := 1; i < len(chunks); i++ {
+		shared := chunks[i-1].End - chunks[i].Start
+		if shared <= 0 {
+			t.Fatalf("Expected chunk %d to overlap the previous chunk", i)
+		}
+		if n := voyageai.EstimateTokens(text[chunks[i].Start:chunks[i-1].End]); n > 6 {
+			t.Errorf("Chunk %d overlaps by %d tokens", i, n)
+		}
+	}
+}
+
+func TestChunkTextSplitsLongWords(t *testing.T) {
+	text := "short " + strings.Repeat("x", 100) + " tail"
+	chunks := voyageai.ChunkText(text, voyageai.ChunkOpts{MaxTokens: 10})
+	var rebuilt strings.Builder
+	for _, c := range chunks {
+		if voyageai.EstimateTokens(c.Text) > 10 {
+			t.Errorf("Chunk %q exceeds the limit", c.Text)
+		}
+		rebuilt.WriteString(strings.ReplaceAll(c.Text, " ", ""))
+	}
+	if rebuilt.String() != strings.ReplaceAll(text, " ", "") {
+		t.Error("Expected the chunks to cover the whole text")
+	}
+
+	if got := voyageai.ChunkText(" \n\t ", voyageai.ChunkOpts{}); len(got) != 0 {
+		t.Errorf("Expected no chunks for blank text, got %d", len(got))
+	}
+}
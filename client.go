@@ -2,13 +2,19 @@ package voyageai
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/zamedic/voyageai/resilience"
 )
 
 // A client for the Voyage AI API.
@@ -17,6 +23,18 @@ type VoyageClient struct {
 	client  *http.Client
 	opts    *VoyageClientOpts
 	baseURL string
+	sem     *prioritySem // Limits concurrent requests, nil if unlimited.
+	usage   *usageTracker
+	stats   *clientStats
+	tenants *tenantCounters
+	health  *healthTracker
+	rollup  *usageRollup
+	limiter *priorityLimiter // Admits requests against a rate limit, nil if unlimited.
+	// Admit requests against the rate limits of their models, keyed by model name.
+	modelLimiters map[string]*priorityLimiter
+	shadow        *shadowDispatcher // Mirrors requests, nil if disabled.
+	// Limits retries across all calls, nil if unlimited.
+	retryBudget *retryBudget
 }
 
 // Optional arguments for the client configuration.
@@ -24,7 +42,147 @@ type VoyageClientOpts struct {
 	Key        string // A Voyage AI API key
 	TimeOut    int    // The timeout for all client requests, in milliseconds. No timeout is set by default.
 	MaxRetries int    // The maximum number of retries. Requests will not be retried by default.
-	BaseURL    string // The BaseURL for the API. Defaults to the Voyage AI API but can be changed for testing and/or mocking.
+	// The wait before the first retry, which grows by RetryMultiplier for every further retry up
+	// to RetryMaxDelay, with jitter. A rate limited request waits at least as long as its
+	// Retry-After header asks. Defaults to [DefaultRetryBaseDelay]; retries are sent immediately
+	// if negative.
+	RetryBaseDelay  time.Duration
+	RetryMaxDelay   time.Duration // The longest wait between retries. Defaults to [DefaultRetryMaxDelay].
+	RetryMultiplier float64       // The growth of the wait per retry. Defaults to 2 if not greater than 1.
+	// The maximum number of retries per minute across all calls made with the client, as a token
+	// bucket that starts full and refills continuously. Once it is empty, failed requests are not
+	// retried and fail with [ErrRetryBudgetExhausted]. Unlimited by default.
+	MaxRetriesPerMinute int
+	BaseURL             string // The BaseURL for the API. Defaults to the Voyage AI API but can be changed for testing and/or mocking.
+	// Timeout, retry and backoff settings per endpoint, overriding TimeOut and MaxRetries for
+	// requests to that endpoint. See [RequestConfig] for the order of precedence.
+	Endpoints map[Endpoint]RequestConfig
+	// How long reading a response body may wait for more bytes before the request fails with
+	// [ErrResponseStalled]. Unlike TimeOut it does not limit slow responses that keep sending,
+	// only connections that stop. Disabled by default.
+	IdleReadTimeout time.Duration
+	// The maximum number of requests in flight at once across all calls made with the client.
+	// Further requests wait for a slot to free up, in order of priority. See [WithPriority].
+	// Unlimited by default.
+	MaxConcurrentRequests int
+	// How long a low priority request waits for a slot, or for its turn at a rate limit, before
+	// it is treated as high priority. Defaults to [DefaultPriorityAging].
+	PriorityAging time.Duration
+	// Counts tokens for client-side checks such as [VoyageClient.TruncateToContext].
+	// Defaults to an estimate based on [EstimateTokens].
+	Tokenizer Tokenizer
+	// Caches embedding and rerank results. Nothing is cached by default.
+	Cache *CacheOpts
+	// If set, [VoyageClient.MultimodalEmbed] checks image URL inputs with
+	// [VoyageClient.ValidateImageURLs] before sending a request. Off by default; can be skipped
+	// per call with [MultimodalRequestOpts.SkipImageURLValidation].
+	ValidateImageURLs *ImageURLValidation
+	// Check requests against the limits of their model in Models before sending them: the
+	// number of inputs or documents, the total tokens, the size of each base64 image and, for
+	// requests with truncation disabled, the tokens of each input. A request that breaks a limit
+	// fails with a [*ValidationError] listing every offending input, rather than with the API's
+	// error. Tokens are counted with the Tokenizer, so inputs close to a limit may be misjudged.
+	// Off by default.
+	ValidateInputs bool
+
+	// Called with the call's context and the headers of every outgoing request, including each
+	// retry, so the caller's trace context can be propagated. For example, with OpenTelemetry:
+	//
+	//	TraceInjector: func(ctx context.Context, h http.Header) {
+	//		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+	//	}
+	TraceInjector func(ctx context.Context, header http.Header)
+
+	// The HTTP client that sends every request, such as one with a tuned connection pool or a
+	// corporate proxy. Its Timeout, if set, applies in addition to TimeOut. Cannot be combined with
+	// Transport, TLSConfig or PinnedSPKIHashes. Defaults to a client of the package's own.
+	HTTPClient *http.Client
+	// The transport requests are sent with, such as one adding tracing or mTLS, in a client of
+	// the package's own. Cannot be combined with TLSConfig or PinnedSPKIHashes, which configure
+	// the default transport. Defaults to [http.DefaultTransport].
+	Transport http.RoundTripper
+	// The TLS configuration for connections to the API, such as custom root CAs. Defaults to
+	// the system configuration.
+	TLSConfig *tls.Config
+	// Base64-encoded SHA-256 hashes of subject public keys, as used by HTTP public key pinning.
+	// If set, connections whose verified certificate chain contains none of these keys fail with
+	// a [*CertificatePinError], after the usual certificate verification. List the next key
+	// alongside the current one before rotating certificates. Cannot be combined with
+	// TLSConfig.InsecureSkipVerify.
+	PinnedSPKIHashes []string
+
+	// The source of time used for cache expiry. Defaults to the system clock.
+	Clock Clock
+
+	// Receives request and cache measurements. None by default. A hook that also implements
+	// [resilience.MetricsHook] observes every attempt, as it would those of a
+	// [resilience.Transport].
+	Metrics MetricsHook
+	// Traces every API request, such as with the OpenTelemetry instrumentation of the
+	// otelvoyage package. None by default.
+	Tracer RequestTracer
+	// Called before every retry of a request, after the retry budget allowed it and before the
+	// backoff. None by default.
+	OnRetry func(RetryEvent)
+	// How [VoyageClient.IsHealthy] judges the client's health. Uses the defaults of [HealthOpts]
+	// if nil.
+	Health *HealthOpts
+
+	// Limits the rate of requests, possibly across processes by sharing a [Limiter]. Unlimited
+	// by default.
+	RateLimit *RateLimitOpts
+
+	// Model names substituted before a request is built, such as "default-embed" for
+	// "voyage-3.5", so models can be repointed through configuration. Aliases may name other
+	// aliases, up to [MaxAliasDepth] deep. Requests, responses, usage and model limits all use
+	// the resolved name.
+	Aliases map[string]string
+
+	// Mirrors a sample of successful requests to a second target, such as a new gateway or
+	// model, and hands both responses to a comparator, without delaying or failing the calls.
+	// Disabled by default.
+	Shadow *ShadowOpts
+
+	// Alternative models tried when a call fails because its model is rate limited or the API
+	// returns a server error. None by default.
+	Fallbacks *FallbackOpts
+
+	// If set, [VoyageClient.EmbedContext] and [VoyageClient.RerankContext] clean up their inputs
+	// with [SanitizeText] before sending them, and list the inputs they altered in the response's
+	// Sanitized field. Other helpers, such as [VoyageClient.EmbedBatch], send their inputs as
+	// given. Off by default.
+	Sanitize *SanitizeOpts
+
+	// Keep the response fields this client does not know in the Extra fields of responses,
+	// their items and their usage, so fields added to the API are readable before the client
+	// supports them. Responses built from the cache have none. Off by default, in which case
+	// the Extra fields of the client's responses are nil.
+	PreserveUnknownFields bool
+
+	// Receives an event for every API request, for compliance logging. None by default.
+	Audit *AuditOpts
+	// Receives warnings that do not fail a call, such as audit sink failures. Defaults to
+	// [slog.Default].
+	Logger *slog.Logger
+
+	// How [VoyageClient.UsageBuckets] groups usage over time. Uses the defaults of [RollupOpts]
+	// if nil.
+	UsageRollup *RollupOpts
+
+	// Rejects requests whose inputs have more tokens than this, as counted by the Tokenizer, with
+	// [ErrBudgetExceeded]. Unlimited by default.
+	MaxTokensPerRequest int
+	// Once the client's requests have used this many tokens, further requests fail with
+	// [ErrBudgetExceeded] until [VoyageClient.ResetBudget] is called. Requests in flight count
+	// with their estimated tokens, and a request is rejected up front if its estimate would
+	// exceed the remaining budget. Unlimited by default.
+	MaxTokensPerClient int
+	// Like MaxTokensPerClient, for the cost in US dollars estimated with [EstimateCost].
+	// Requests to models without a known price are not counted. Unlimited by default.
+	MaxEstimatedCost float64
+	// The limits and pricing of models, read by client-side checks, batch planning and cost
+	// estimates. Defaults to the package registry; see [LoadModelRegistry].
+	Models *ModelRegistry
 }
 
 // Returns a pointer to the given input. Useful when creating [EmbeddingRequestOpts], [MultimodalRequestOpts], and [RerankRequestOpts] literals.
@@ -32,125 +190,402 @@ func Opt[T any](opt T) *T {
 	return &opt
 }
 
-// Returns a new instance of [VoyageClient]
+// Returns a new instance of [VoyageClient]. If the HTTP or TLS options are invalid, every request
+// the client sends fails with the reason; use [NewClientWithError] to get it at construction.
 func NewClient(opts *VoyageClientOpts) *VoyageClient {
-	client := &http.Client{}
 	if opts == nil {
 		opts = &VoyageClientOpts{}
 	}
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		client = &http.Client{Transport: failingTransport{err}}
+	}
+	return newClient(opts, client)
+}
 
-	if opts.TimeOut != 0.0 {
-		client.Timeout = time.Duration(opts.TimeOut) * time.Millisecond
+// NewClientWithError is like [NewClient] but returns an error if the HTTP or TLS options are
+// invalid, such as malformed PinnedSPKIHashes, pins combined with InsecureSkipVerify, or an
+// HTTPClient combined with a Transport.
+func NewClientWithError(opts *VoyageClientOpts) (*VoyageClient, error) {
+	if opts == nil {
+		opts = &VoyageClientOpts{}
+	}
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
 	}
+	return newClient(opts, client), nil
+}
+
+// newClient returns a client sending its requests with client.
+func newClient(opts *VoyageClientOpts, client *http.Client) *VoyageClient {
 
 	baseURL := "https://api.voyageai.com/v1"
 	if opts.BaseURL != "" {
 		baseURL = opts.BaseURL
 	}
 
-	if opts.Key == "" {
-		return &VoyageClient{
-			apikey:  os.Getenv("VOYAGE_API_KEY"),
-			client:  client,
-			baseURL: baseURL,
-			opts:    opts,
-		}
+	apikey := opts.Key
+	if apikey == "" {
+		apikey = os.Getenv("VOYAGE_API_KEY")
 	}
 
-	return &VoyageClient{
-		apikey:  opts.Key,
+	c := &VoyageClient{
+		apikey:  apikey,
 		client:  client,
 		baseURL: baseURL,
 		opts:    opts,
+		usage:   &usageTracker{},
+		stats:   &clientStats{},
+		tenants: &tenantCounters{},
+		health:  newHealthTracker(opts.Health),
+		rollup:  newUsageRollup(opts.UsageRollup),
+		shadow:  newShadowDispatcher(opts.Shadow),
+	}
+	if opts.MaxConcurrentRequests > 0 {
+		c.sem = newPrioritySem(opts.MaxConcurrentRequests, c.clock(), opts.PriorityAging)
+	}
+	c.limiter = newLimiter(c.clock(), opts.PriorityAging, opts.RateLimit)
+	c.modelLimiters = newModelLimiters(c.clock(), opts.PriorityAging, opts.RateLimit)
+	if opts.MaxRetriesPerMinute > 0 {
+		c.retryBudget = newRetryBudget(c.clock(), opts.MaxRetriesPerMinute)
+	}
+	return c
+}
+
+// acquire blocks until a request slot is available or ctx is done.
+func (c *VoyageClient) acquire(ctx context.Context) error {
+	if c.sem == nil {
+		return ctx.Err()
+	}
+	return c.sem.acquire(ctx, priorityFrom(ctx))
+}
+
+func (c *VoyageClient) release() {
+	if c.sem != nil {
+		c.sem.release()
 	}
 }
 
 func (c *VoyageClient) do(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", "BEARER "+c.apikey)
+	key := c.apikey
+	if t, ok := tenantFrom(req.Context()); ok && t.Key != "" {
+		key = t.Key
+	}
+	req.Header.Set("Authorization", "BEARER "+key)
 	return c.client.Do(req)
 }
 
 // handleAPIError returns true if the given error is recoverable and false otherwise.
 // The request retry loop will continue if the error is recoverable and it will abort otherwise.
+// The returned error unwraps to resp.
 func (c *VoyageClient) handleAPIError(resp *APIError) (bool, error) {
-
+	retry := resilience.ShouldRetryStatus(resp.StatusCode)
 	switch resp.StatusCode {
 	case 400:
-		return false, fmt.Errorf("voyage: bad request, detail: %s", resp.Response)
+		return retry, &apiFailure{fmt.Errorf("voyage: bad request, detail: %s", resp.Response), resp}
 	case 401:
-		return false, fmt.Errorf("voyage: unauthorized, detail: %s", resp.Response)
+		return retry, &apiFailure{fmt.Errorf("voyage: unauthorized, detail: %s", resp.Response), resp}
 	case 422:
-		return false, fmt.Errorf("voyage: Malformed Request, detail: %s", resp.Response)
+		return retry, &apiFailure{fmt.Errorf("voyage: Malformed Request, detail: %s", resp.Response), resp}
 	case 429:
-		return true, fmt.Errorf("voyage: Rate Limit Reached, detail: %s", resp.Response)
+		return retry, &apiFailure{fmt.Errorf("voyage: Rate Limit Reached, detail: %s", resp.Response), resp}
 	default:
-		return true, fmt.Errorf("voyage: Server Error")
+		return retry, &apiFailure{fmt.Errorf("voyage: Server Error"), resp}
 	}
 }
 
-func (c *VoyageClient) handleAPIRequest(reqBody any, respBody any, url string) error {
-	maxRetries := c.opts.MaxRetries
-	if maxRetries == 0 {
-		maxRetries = 1
-	}
+// apiFailure describes a failed API response while keeping the [APIError] available to
+// [errors.As].
+type apiFailure struct {
+	err error
+	api *APIError
+}
 
-	var lastErr error
+func (e *apiFailure) Error() string   { return e.err.Error() }
+func (e *apiFailure) Unwrap() []error { return []error{e.err, e.api} }
 
-	for i := 0; i < maxRetries; i++ {
-		if err := c.executeRequest(reqBody, respBody, url); err != nil {
-			if shouldRetry, apiErr := c.classifyError(err); shouldRetry {
-				lastErr = apiErr
-				continue
-			}
-			return err
+// handleAPIRequest sends a request to the endpoint at path, relative to the base URL.
+func (c *VoyageClient) handleAPIRequest(ctx context.Context, reqBody any, respBody any, path string) error {
+	url, err := c.endpointURL(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := c.validateRequest(reqBody); err != nil {
+		return err
+	}
+	endpoint := strings.TrimPrefix(path, "/")
+	sendCtx, endTrace := ctx, func(RequestMetrics) {}
+	if c.opts.Tracer != nil {
+		sendCtx, endTrace = c.opts.Tracer.StartRequest(ctx, endpoint, requestModel(reqBody))
+	}
+	start := c.clock().Now()
+	attempts, info, err := c.sendWithRetries(sendCtx, endpoint, reqBody, respBody, url, c.requestConfig(ctx, endpoint))
+	end := c.clock().Now()
+	recordOutcome(ctx, attempts, info)
+	if err == nil {
+		err = decodeResponse(reqBody, respBody)
+	}
+	if err == nil {
+		if r, ok := respBody.(interface{ metadata() *ResponseMetadata }); ok {
+			r.metadata().HTTP = newHTTPResponse(info.StatusCode, info.Header)
 		}
-		return nil
+		c.mirror(ctx, path, reqBody, respBody)
+	}
+	c.health.record(ctx, endpoint, err, end.Sub(start), end)
+	if c.opts.Metrics != nil || c.opts.Tracer != nil {
+		m := RequestMetrics{
+			Endpoint:   endpoint,
+			Model:      requestModel(reqBody),
+			Attempts:   attempts,
+			Duration:   end.Sub(start),
+			Err:        err,
+			Metadata:   RequestMetadata(ctx),
+			Inputs:     requestInputs(reqBody),
+			StatusCode: info.StatusCode,
+		}
+		if r, ok := respBody.(usageReporter); ok && err == nil {
+			_, m.Usage = r.reportedUsage()
+		}
+		endTrace(m)
+		c.observeRequest(ctx, m)
+	}
+	if r, ok := respBody.(usageReporter); ok && err == nil {
+		model, usage := r.reportedUsage()
+		cost, _ := c.estimateCost(model, usage.TotalTokens)
+		c.rollup.record(end, endpoint, model, usage, cost)
 	}
+	if auditErr := c.audit(ctx, endpoint, reqBody, respBody, start, end, attempts, info, err); auditErr != nil && err == nil {
+		err = auditErr
+	}
+	return err
+}
 
-	return lastErr
+// Details of the HTTP response to the last attempt of a request.
+type responseInfo struct {
+	StatusCode int         // Zero if no response was received.
+	RequestID  string      // The request ID reported by the API, if any.
+	Header     http.Header // Nil if no response was received.
 }
 
-func (c *VoyageClient) classifyError(err error) (shouldRetry bool, apiErr error) {
-	var apiError *APIError
-	if errors.As(err, &apiError) {
-		return c.handleAPIError(apiError)
+// The response headers that may carry the API's request ID, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id"}
+
+// requestID returns the request ID of a response with the given headers, or "".
+func requestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
 	}
-	return false, err
+	return ""
 }
 
-func (c *VoyageClient) executeRequest(reqBody any, respBody any, url string) error {
-	reqBytes, err := json.Marshal(reqBody)
+// sendWithRetries sends the request through a [resilience.Transport] that retries recoverable
+// errors, and returns the number of attempts made and the details of the last response.
+func (c *VoyageClient) sendWithRetries(ctx context.Context, endpoint string, reqBody any, respBody any, url string, cfg RequestConfig) (int, responseInfo, error) {
+	var info responseInfo
+	req, err := c.newRequest(ctx, reqBody, url)
+	if err != nil {
+		return 0, info, err
+	}
+	limiter := &attemptLimiter{c: c, reqBody: reqBody}
+	var denied error
+	policy := resilience.RetryPolicy{
+		MaxAttempts: cfg.MaxRetries,
+		Backoff:     c.retryBackoff(cfg),
+		AllowRetry: func(ctx context.Context, err error) error {
+			if err := c.allowRetry(ctx, err); err != nil {
+				denied = err
+				return err
+			}
+			c.observeRetry(ctx, RetryEvent{Endpoint: endpoint, Model: requestModel(reqBody), Attempt: limiter.attempts, Err: err})
+			return nil
+		},
+		Clock: c.clock(),
+	}
+	hook, _ := c.opts.Metrics.(resilience.MetricsHook)
+	t := resilience.NewTransport(attemptTransport{c, cfg.Timeout}, resilience.Options{
+		RetryPolicy: policy,
+		Limiter:     limiter,
+		Classifier:  c.classify,
+		MetricsHook: hook,
+	})
+
+	resp, err := t.RoundTrip(req)
+	if resp != nil {
+		defer resp.Body.Close()
+		info = responseInfo{StatusCode: resp.StatusCode, RequestID: requestID(resp.Header), Header: resp.Header}
+	}
+	if denied != nil {
+		// The transport hands over the last response when the retry budget refuses a retry.
+		return limiter.attempts, info, denied
+	}
 	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
+		return limiter.attempts, info, err
 	}
+	if err := c.decodeBody(ctx, resp, respBody); err != nil {
+		return limiter.attempts, info, err
+	}
+	c.recordUsage(ctx, respBody)
+	return limiter.attempts, info, nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
+// newRequest returns the HTTP request that sends reqBody to url.
+func (c *VoyageClient) newRequest(ctx context.Context, reqBody any, url string) (*http.Request, error) {
+	var streamed *streamedBody
+	if r, ok := reqBody.(*MultimodalRequest); ok {
+		var err error
+		if streamed, err = newStreamedBody(r); err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+	}
+	var reqBytes []byte
+	if streamed == nil {
+		var err error
+		if reqBytes, err = json.Marshal(reqBody); err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBytes))
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if streamed != nil {
+		req.Body, req.ContentLength = streamed.open(), streamed.length
+		req.GetBody = func() (io.ReadCloser, error) { return streamed.open(), nil }
+	}
+	if c.opts.TraceInjector != nil {
+		c.opts.TraceInjector(ctx, req.Header)
 	}
+	return req, nil
+}
 
-	resp, err := c.do(req)
+// classify is the [resilience.Classifier] of the client's requests. It retries stalled responses
+// and the API errors [VoyageClient.handleAPIError] finds recoverable, which it returns as the
+// failure wrapping their [*APIError].
+func (c *VoyageClient) classify(resp *http.Response, err error) error {
 	if err != nil {
-		return fmt.Errorf("execute request: %w", err)
+		if errors.Is(err, ErrResponseStalled) {
+			return err
+		}
+		return nil
 	}
-	defer resp.Body.Close()
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if retry, err := c.handleAPIError(newAPIError(resp, body, c.clock().Now())); retry {
+		return err
+	}
+	return nil
+}
 
+// decodeBody decodes a response read by [attemptTransport] into respBody, or returns the API
+// error it reports.
+func (c *VoyageClient) decodeBody(ctx context.Context, resp *http.Response, respBody any) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("read response: %w", err)
 	}
-
 	if resp.StatusCode >= 400 {
-		return &APIError{StatusCode: resp.StatusCode, Response: body}
+		// A recoverable error that used up the retries fails as it was retried.
+		apiErr := newAPIError(resp, body, c.clock().Now())
+		if retry, err := c.handleAPIError(apiErr); retry {
+			return err
+		}
+		return apiErr
 	}
 
 	if err := json.Unmarshal(body, respBody); err != nil {
 		return fmt.Errorf("unmarshal response: %w", err)
 	}
-
+	if x, ok := respBody.(extraCarrier); ok && !c.opts.PreserveUnknownFields {
+		x.clearExtras()
+	}
+	if err := captureRaw(ctx, body); err != nil {
+		return fmt.Errorf("capture response: %w", err)
+	}
 	return nil
 }
 
+// The [resilience.Limiter] of one request, admitting each of its attempts through the client's
+// limiters for the request body.
+type attemptLimiter struct {
+	c        *VoyageClient
+	reqBody  any
+	limiters []*priorityLimiter // The limiters that admitted the current attempt.
+	attempts int                // The attempts started.
+}
+
+// Acquire waits for the client's limiters, which count the tokens of the request body
+// themselves.
+func (l *attemptLimiter) Acquire(ctx context.Context, _ int) error {
+	l.attempts++
+	var err error
+	l.limiters, err = l.c.admit(ctx, l.reqBody)
+	return err
+}
+
+func (l *attemptLimiter) Feedback(ctx context.Context, statusCode int) {
+	for _, lim := range l.limiters {
+		lim.Feedback(ctx, statusCode)
+	}
+}
+
+// The base [http.RoundTripper] of the client's requests. It sends one attempt in a request slot
+// with the client's HTTP client and reads the whole response, so that an attempt whose response
+// stalls fails with [ErrResponseStalled] and can be retried.
+type attemptTransport struct {
+	c       *VoyageClient
+	timeout time.Duration // Limits the HTTP exchange if positive.
+}
+
+func (t attemptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c, ctx := t.c, req.Context()
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	c.stats.attempts.Add(1)
+	c.tenants.addStats(tenantID(ctx), func(s *ClientStats) { s.Attempts++ })
+
+	// The timeout covers the HTTP exchange, not the wait for a request slot.
+	reqCtx := ctx
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	var cancelStalled context.CancelCauseFunc
+	if c.opts.IdleReadTimeout > 0 {
+		reqCtx, cancelStalled = context.WithCancelCause(reqCtx)
+		defer cancelStalled(nil)
+	}
+	resp, err := c.do(req.Clone(reqCtx))
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var r io.Reader = resp.Body
+	if cancelStalled != nil {
+		w := watchIdle(resp.Body, c.opts.IdleReadTimeout, cancelStalled)
+		defer w.stop()
+		r = w
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		if errors.Is(context.Cause(reqCtx), ErrResponseStalled) {
+			err = ErrResponseStalled
+		}
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
 // Returns a pointer to an [EmbeddingResponse] or an error if the request failed.
 //
 // Parameters:
@@ -158,6 +593,67 @@ func (c *VoyageClient) executeRequest(reqBody any, respBody any, url string) err
 //   - model - Name of the model. Recommended options: voyage-3-large, voyage-3.5, voyage-3.5-lite, voyage-code-3, voyage-finance-2, voyage-law-2.
 //   - opts - optional parameters, see [EmbeddingRequestOpts]
 func (c *VoyageClient) Embed(texts []string, model string, opts *EmbeddingRequestOpts) (*EmbeddingResponse, error) {
+	return c.EmbedContext(context.Background(), texts, model, opts)
+}
+
+// EmbedContext is like [VoyageClient.Embed] but the request is bound to ctx, which can be used to cancel it.
+//
+// If the model is rate limited or failing, the client's [VoyageClientOpts.Fallbacks] are tried
+// in turn, and the response's Metadata records which model served it.
+func (c *VoyageClient) EmbedContext(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts) (*EmbeddingResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	alts, err := resolveFallbacks(c, c.fallbacks().Embed)
+	if err != nil {
+		return nil, err
+	}
+	texts, sanitized := c.sanitizeInputs(texts)
+	resp, err := withFallback(model, opts, alts, func(model string, opts *EmbeddingRequestOpts) (*EmbeddingResponse, error) {
+		return c.embedContext(ctx, texts, model, opts)
+	})
+	if resp != nil {
+		resp.Sanitized = sanitized
+	}
+	return resp, err
+}
+
+// embedContext is like [VoyageClient.EmbedContext] without fallbacks. It is used by helpers
+// that combine the results of several requests, which must all come from the same model.
+func (c *VoyageClient) embedContext(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts) (*EmbeddingResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	var truncated []int
+	if opts != nil && opts.TruncateToContext {
+		if texts, truncated, err = c.truncateInputs(texts, model); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *EmbeddingResponse
+	if c.cacheEnabled() {
+		resp, err = c.embedCached(ctx, texts, model, opts, func(texts []string) (*EmbeddingResponse, error) {
+			return c.embed(ctx, texts, model, opts)
+		})
+	} else {
+		resp, err = c.embed(ctx, texts, model, opts)
+	}
+	if resp != nil {
+		resp.Truncated = truncated
+	}
+	return resp, err
+}
+
+func (c *VoyageClient) embed(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts) (*EmbeddingResponse, error) {
+	release, err := c.reserveTexts(model, texts...)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var reqBody EmbeddingRequest
 	var respBody EmbeddingResponse
 	if opts != nil {
@@ -169,6 +665,7 @@ func (c *VoyageClient) Embed(texts []string, model string, opts *EmbeddingReques
 			OutputDimension: opts.OutputDimension,
 			OutputDType:     opts.OutputDType,
 			EncodingFormat:  opts.EncodingFormat,
+			SendNull:        opts.SendNull,
 		}
 	} else {
 		reqBody = EmbeddingRequest{
@@ -177,7 +674,7 @@ func (c *VoyageClient) Embed(texts []string, model string, opts *EmbeddingReques
 		}
 	}
 
-	err := c.handleAPIRequest(&reqBody, &respBody, c.baseURL+"/embeddings")
+	err = c.handleAPIRequest(ctx, &reqBody, &respBody, "/embeddings")
 	return &respBody, err
 }
 
@@ -190,6 +687,52 @@ func (c *VoyageClient) Embed(texts []string, model string, opts *EmbeddingReques
 //
 // [Voyage AI docs]: https://docs.voyageai.com/docs/multimodal-embeddings
 func (c *VoyageClient) MultimodalEmbed(inputs []MultimodalContent, model string, opts *MultimodalRequestOpts) (*EmbeddingResponse, error) {
+	return c.MultimodalEmbedContext(context.Background(), inputs, model, opts)
+}
+
+// MultimodalEmbedContext is like [VoyageClient.MultimodalEmbed] but the request is bound to ctx, which can be used to cancel it.
+// Fallbacks are applied as for [VoyageClient.EmbedContext].
+func (c *VoyageClient) MultimodalEmbedContext(ctx context.Context, inputs []MultimodalContent, model string, opts *MultimodalRequestOpts) (*EmbeddingResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	alts, err := resolveFallbacks(c, c.fallbacks().Multimodal)
+	if err != nil {
+		return nil, err
+	}
+	return withFallback(model, opts, alts, func(model string, opts *MultimodalRequestOpts) (*EmbeddingResponse, error) {
+		return c.multimodalEmbed(ctx, inputs, model, opts)
+	})
+}
+
+func (c *VoyageClient) multimodalEmbed(ctx context.Context, inputs []MultimodalContent, model string, opts *MultimodalRequestOpts) (*EmbeddingResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.ValidateImageURLs != nil && (opts == nil || !opts.SkipImageURLValidation) {
+		if err := c.ValidateImageURLs(ctx, inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkImagePayload(inputs); err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, in := range inputs {
+		for _, part := range in.Content {
+			texts = append(texts, string(part.Text))
+		}
+	}
+	release, err := c.reserveTexts(model, texts...)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var reqBody MultimodalRequest
 	var respBody EmbeddingResponse
 	if opts != nil {
@@ -199,6 +742,7 @@ func (c *VoyageClient) MultimodalEmbed(inputs []MultimodalContent, model string,
 			InputType:     opts.InputType,
 			Truncation:    opts.Truncation,
 			OuputEncoding: opts.OuputEncoding,
+			SendNull:      opts.SendNull,
 		}
 	} else {
 		reqBody = MultimodalRequest{
@@ -207,11 +751,7 @@ func (c *VoyageClient) MultimodalEmbed(inputs []MultimodalContent, model string,
 		}
 	}
 
-	if c.opts.MaxRetries == 0 {
-		c.opts.MaxRetries = 1
-	}
-
-	err := c.handleAPIRequest(&reqBody, &respBody, c.baseURL+"/multimodalembeddings")
+	err = c.handleAPIRequest(ctx, &reqBody, &respBody, "/multimodalembeddings")
 	return &respBody, err
 }
 
@@ -227,6 +767,62 @@ func (c *VoyageClient) MultimodalEmbed(inputs []MultimodalContent, model string,
 //
 // [Voyage AI docs]: https://docs.voyageai.com/docs/multimodal-embeddings/
 func (c *VoyageClient) Rerank(query string, documents []string, model string, opts *RerankRequestOpts) (*RerankResponse, error) {
+	return c.RerankContext(context.Background(), query, documents, model, opts)
+}
+
+// RerankContext is like [VoyageClient.Rerank] but the request is bound to ctx, which can be used to cancel it.
+//
+// When the client has a cache, results are cached by model, truncation setting, query and the set
+// of documents, regardless of their order. A cached result is returned with its indices mapped to
+// the positions of the documents in this call, and is trimmed to [RerankRequestOpts.TopK] if that
+// is smaller than the cached result.
+//
+// Fallbacks are applied as for [VoyageClient.EmbedContext].
+func (c *VoyageClient) RerankContext(ctx context.Context, query string, documents []string, model string, opts *RerankRequestOpts) (*RerankResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	alts, err := resolveFallbacks(c, c.fallbacks().Rerank)
+	if err != nil {
+		return nil, err
+	}
+	documents, sanitized := c.sanitizeInputs(documents)
+	if c.opts.Sanitize != nil {
+		var report SanitizeReport
+		if query, report = SanitizeText(query, *c.opts.Sanitize); report.Changed() {
+			sanitized = append([]SanitizedInput{{Index: -1, Report: report}}, sanitized...)
+		}
+	}
+	resp, err := withFallback(model, opts, alts, func(model string, opts *RerankRequestOpts) (*RerankResponse, error) {
+		return c.rerankContext(ctx, query, documents, model, opts)
+	})
+	if resp != nil {
+		resp.Sanitized = sanitized
+	}
+	return resp, err
+}
+
+func (c *VoyageClient) rerankContext(ctx context.Context, query string, documents []string, model string, opts *RerankRequestOpts) (*RerankResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if c.cacheEnabled() {
+		return c.rerankCached(ctx, query, documents, model, opts, func() (*RerankResponse, error) {
+			return c.rerank(ctx, query, documents, model, opts)
+		})
+	}
+	return c.rerank(ctx, query, documents, model, opts)
+}
+
+func (c *VoyageClient) rerank(ctx context.Context, query string, documents []string, model string, opts *RerankRequestOpts) (*RerankResponse, error) {
+	release, err := c.reserveRerank(query, documents, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var reqBody RerankRequest
 	var respBody RerankResponse
 	if opts != nil {
@@ -237,6 +833,7 @@ func (c *VoyageClient) Rerank(query string, documents []string, model string, op
 			TopK:            opts.TopK,
 			ReturnDocuments: opts.ReturnDocuments,
 			Truncation:      opts.Truncation,
+			SendNull:        opts.SendNull,
 		}
 	} else {
 		reqBody = RerankRequest{
@@ -246,10 +843,6 @@ func (c *VoyageClient) Rerank(query string, documents []string, model string, op
 		}
 	}
 
-	if c.opts.MaxRetries == 0 {
-		c.opts.MaxRetries = 1
-	}
-
-	err := c.handleAPIRequest(&reqBody, &respBody, c.baseURL+"/rerank")
+	err = c.handleAPIRequest(ctx, &reqBody, &respBody, "/rerank")
 	return &respBody, err
 }
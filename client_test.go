@@ -2,8 +2,11 @@ package voyageai_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"image"
 	"image/color"
 	"image/png"
@@ -11,11 +14,16 @@ import (
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/zamedic/voyageai"
+	"github.com/zamedic/voyageai/resilience"
 )
 
 func TestNewClientNilOpts(t *testing.T) {
@@ -80,10 +88,11 @@ func TestEmbedRequiredArgsResponse(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	_, err := cl.Embed([]string{"input1", "input2"}, "test-model", nil)
@@ -164,10 +173,11 @@ func TestEmbedCustomArgsResponse(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	embedOpts := voyageai.EmbeddingRequestOpts{
@@ -238,10 +248,11 @@ func TestRerankRequiredArgsResponse(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	_, err := cl.Rerank("query", []string{"input1", "input2"}, "test-model", nil)
@@ -315,10 +326,11 @@ func TestRerankCustomArgsResponse(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	opts := voyageai.RerankRequestOpts{
@@ -428,10 +440,11 @@ func TestMultimodalRequiredArgsRequest(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	dummyImage1, err := createDummyImage(rand.Intn(1200), rand.Intn(630))
@@ -448,7 +461,7 @@ func TestMultimodalRequiredArgsRequest(t *testing.T) {
 		{
 			Content: []voyageai.MultimodalInput{
 				voyageai.Multimodal(voyageai.MustGetBase64(dummyImage1)),
-				voyageai.Multimodal(voyageai.MustGetBase64(dummyImage2)),
+				voyageai.Multimodal(voyageai.MustGetBase64Raw(dummyImage2)),
 			},
 		},
 	}
@@ -532,10 +545,11 @@ func TestMultimodalRequiredCustomRequest(t *testing.T) {
 	defer s.Close()
 
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: 3,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	dummyImage1, err := createDummyImage(rand.Intn(1200), rand.Intn(630))
@@ -573,7 +587,7 @@ func TestMaxRetries(t *testing.T) {
 	retries := 0
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") == "" {
-			apiErr := voyageai.APIError{Detail: "User unauthorized"}
+			apiErr := voyageai.VoyageError{Detail: "User unauthorized"}
 			b, err := json.Marshal(apiErr)
 			if err != nil {
 				t.Fatalf("Could not create error response")
@@ -628,18 +642,222 @@ func TestMaxRetries(t *testing.T) {
 
 	maxRetries := rand.Intn(10) + 1
 	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{
-		Key:        "APIKEY",
-		TimeOut:    1500,
-		MaxRetries: maxRetries,
-		BaseURL:    s.URL,
+		Key:            "APIKEY",
+		TimeOut:        1500,
+		MaxRetries:     maxRetries,
+		RetryBaseDelay: time.Millisecond,
+		BaseURL:        s.URL,
 	})
 
 	_, err := cl.Embed([]string{"input1", "input2"}, "test-model", nil)
-	if err != nil {
-		t.Fatal(err.Error())
+	if err == nil {
+		t.Fatal("Expected an error after exhausting all retries")
 	}
 
 	if retries != maxRetries {
 		t.Errorf("Expected retries to equal %d but got %d", maxRetries, retries)
 	}
 }
+
+// attemptRecorder records the limiter feedback, backoffs and attempts of a client or a
+// resilience.Transport. It is a voyageai.Limiter, Clock and MetricsHook, and a
+// resilience.MetricsHook.
+type attemptRecorder struct {
+	mu       sync.Mutex
+	feedback []int
+	waits    []time.Duration
+	attempts []resilience.AttemptMetrics
+}
+
+func (r *attemptRecorder) Acquire(ctx context.Context, tokens int) error { return nil }
+
+func (r *attemptRecorder) Feedback(ctx context.Context, statusCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.feedback = append(r.feedback, statusCode)
+}
+
+func (r *attemptRecorder) Now() time.Time { return time.Now() }
+
+func (r *attemptRecorder) After(d time.Duration) <-chan time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waits = append(r.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+func (r *attemptRecorder) ObserveRequest(voyageai.RequestMetrics) {}
+func (r *attemptRecorder) ObserveCache(voyageai.CacheMetrics)     {}
+
+// ObserveAttempt records m without the parts that differ between runs: its duration, error and
+// server address.
+func (r *attemptRecorder) ObserveAttempt(m resilience.AttemptMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, _ := url.Parse(m.URL)
+	m.URL, m.Duration, m.Err = u.Path, 0, nil
+	r.attempts = append(r.attempts, m)
+}
+
+// The client must send its requests like a resilience.Transport with the same policy: the same
+// attempts, limiter feedback and Retry-After backoff.
+func TestClientRetriesLikeTransport(t *testing.T) {
+	var _ resilience.Limiter = voyageai.Limiter(nil)
+
+	for _, statuses := range [][]int{{429, 503}, {400}, {404, 429, 500}, {500, 500, 500}, {}} {
+		newServer := func() *httptest.Server {
+			var mu sync.Mutex
+			script := statuses
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(script) > 0 {
+					w.Header().Set("Retry-After", "2")
+					w.WriteHeader(script[0])
+					script = script[1:]
+					w.Write([]byte(`{"detail":"scripted failure"}`))
+					return
+				}
+				w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.5],"index":0}],"model":"test-model","usage":{"total_tokens":1}}`))
+			}))
+			t.Cleanup(srv.Close)
+			return srv
+		}
+
+		viaClient := &attemptRecorder{}
+		srv := newServer()
+		client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+			Key:        "APIKEY",
+			BaseURL:    srv.URL,
+			MaxRetries: 3,
+			Endpoints:  map[voyageai.Endpoint]voyageai.RequestConfig{voyageai.EndpointEmbeddings: {Backoff: time.Millisecond}},
+			RateLimit:  &voyageai.RateLimitOpts{Limiter: viaClient},
+			Clock:      viaClient,
+			Metrics:    viaClient,
+		})
+		_, clientErr := client.Embed([]string{"a"}, "test-model", nil)
+
+		standalone := &attemptRecorder{}
+		raw := newServer()
+		hc := &http.Client{Transport: resilience.NewTransport(nil, resilience.Options{
+			RetryPolicy: resilience.RetryPolicy{
+				MaxAttempts: 3,
+				Backoff: func(retry int, err error) time.Duration {
+					var se *resilience.StatusError
+					if errors.As(err, &se) && se.StatusCode == http.StatusTooManyRequests {
+						if d, ok := resilience.ParseRetryAfter(se.Header, standalone.Now()); ok {
+							return max(d, time.Millisecond)
+						}
+					}
+					return time.Millisecond
+				},
+				Clock: standalone,
+			},
+			Limiter:     standalone,
+			MetricsHook: standalone,
+		})}
+		resp, err := hc.Post(raw.URL+"/embeddings", "application/json", strings.NewReader(`{"input":["a"],"model":"test-model"}`))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		resp.Body.Close()
+
+		if (clientErr == nil) != (resp.StatusCode == 200) {
+			t.Errorf("%v: the client returned %v, the transport status %d", statuses, clientErr, resp.StatusCode)
+		}
+		if len(viaClient.attempts) == 0 || len(viaClient.feedback) != len(viaClient.attempts) {
+			t.Errorf("%v: expected feedback for every attempt, got %v for %+v", statuses, viaClient.feedback, viaClient.attempts)
+		}
+		if !reflect.DeepEqual(viaClient.feedback, standalone.feedback) {
+			t.Errorf("%v: the client gave the limiter %v, the transport %v", statuses, viaClient.feedback, standalone.feedback)
+		}
+		if !reflect.DeepEqual(viaClient.waits, standalone.waits) {
+			t.Errorf("%v: the client waited %v, the transport %v", statuses, viaClient.waits, standalone.waits)
+		}
+		if !reflect.DeepEqual(viaClient.attempts, standalone.attempts) {
+			t.Errorf("%v: the client observed %+v, the transport %+v", statuses, viaClient.attempts, standalone.attempts)
+		}
+	}
+}
+
+func TestAPIErrorClasses(t *testing.T) {
+	tests := []struct {
+		status                     int
+		rateLimit, auth, retryable bool
+	}{
+		{400, false, false, false},
+		{401, false, true, false},
+		{403, false, true, true},
+		{429, true, false, true},
+		{500, false, false, true},
+		{503, false, false, true},
+	}
+	for _, tt := range tests {
+		srv := newMockServer(t)
+		srv.fail = func(int, voyageai.EmbeddingRequest) int { return tt.status }
+		_, err := srv.client().Embed([]string{"a"}, "test-model", nil)
+
+		var apiErr *voyageai.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%d: expected an APIError, got %v", tt.status, err)
+		}
+		if apiErr.StatusCode != tt.status || apiErr.RequestID != "req-1" || apiErr.Detail != "scripted failure" {
+			t.Errorf("%d: expected the status, request ID and detail, got %d, %q, %q", tt.status, apiErr.StatusCode, apiErr.RequestID, apiErr.Detail)
+		}
+		if apiErr.IsRateLimit() != tt.rateLimit || apiErr.IsAuth() != tt.auth || apiErr.IsRetryable() != tt.retryable {
+			t.Errorf("%d: expected rate limit %v, auth %v, retryable %v, got %v, %v, %v", tt.status,
+				tt.rateLimit, tt.auth, tt.retryable, apiErr.IsRateLimit(), apiErr.IsAuth(), apiErr.IsRetryable())
+		}
+	}
+}
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestCustomHTTPClient(t *testing.T) {
+	srv := newMockServer(t)
+	var sent []string
+	tracing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.Path)
+		req.Header.Set("X-Trace", "abc")
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	for name, opts := range map[string]voyageai.VoyageClientOpts{
+		"Transport":  {Transport: tracing},
+		"HTTPClient": {HTTPClient: &http.Client{Transport: tracing}},
+	} {
+		sent = nil
+		opts.Key, opts.BaseURL = "APIKEY", srv.URL
+		if _, err := voyageai.NewClient(&opts).Embed([]string{"a"}, "test-model", nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(sent) != 1 || sent[0] != "/embeddings" {
+			t.Errorf("%s: expected the request to go through the transport, got %v", name, sent)
+		}
+		if h := srv.headers[len(srv.headers)-1]; h.Get("X-Trace") != "abc" {
+			t.Errorf("%s: expected the transport's header, got %v", name, h)
+		}
+	}
+
+	for _, opts := range []voyageai.VoyageClientOpts{
+		{HTTPClient: &http.Client{}, Transport: tracing},
+		{HTTPClient: &http.Client{}, PinnedSPKIHashes: []string{"pin"}},
+		{Transport: tracing, TLSConfig: &tls.Config{}},
+	} {
+		_, err := voyageai.NewClientWithError(&opts)
+		if err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+			continue
+		}
+		// NewClient does not panic, but fails every request with the same error.
+		opts.Key, opts.BaseURL = "APIKEY", srv.URL
+		if _, sendErr := voyageai.NewClient(&opts).Embed([]string{"a"}, "test-model", nil); sendErr == nil || !strings.Contains(sendErr.Error(), err.Error()) {
+			t.Errorf("Expected requests to fail with %q, got %v", err, sendErr)
+		}
+	}
+}
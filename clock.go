@@ -0,0 +1,25 @@
+package voyageai
+
+import "time"
+
+// A source of time. The client reads the time through a Clock, which tests can replace to control
+// expiry and pacing without sleeping. See [VoyageClientOpts.Clock].
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the [Clock] backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clock returns the configured clock, or the system clock.
+func (c *VoyageClient) clock() Clock {
+	if c.opts.Clock != nil {
+		return c.opts.Clock
+	}
+	return systemClock{}
+}
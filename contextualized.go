@@ -0,0 +1,104 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+)
+
+// A data structure that matches the expected fields of the /contextualizedembeddings endpoint.
+// Use [ContextualizedEmbeddingRequestOpts] when building a request for use with [VoyageClient].
+// For more details, see the Voyage AI docs "[Contextualized Chunk Embeddings]."
+//
+// [Contextualized Chunk Embeddings]: https://docs.voyageai.com/docs/contextualized-chunk-embeddings
+type ContextualizedEmbeddingRequest struct {
+	Inputs          [][]string `json:"inputs"` // The chunks of each document, in document order.
+	Model           string     `json:"model"`
+	InputType       *string    `json:"input_type,omitempty"`
+	OutputDimension *int       `json:"output_dimension,omitempty"`
+	OutputDType     *string    `json:"output_dtype,omitempty"`
+	EncodingFormat  *string    `json:"encoding_format,omitempty"`
+	SendNull        []string   `json:"-"` // The JSON names of optional fields sent as null when unset, rather than left out.
+}
+
+// Additional request options that can be passed to [VoyageClient.ContextualizedEmbed].
+type ContextualizedEmbeddingRequestOpts struct {
+	InputType       *string `json:"input_type,omitempty"`       // Type of the input text. Defaults to null. Other options: query, document.
+	OutputDimension *int    `json:"output_dimension,omitempty"` // The number of dimensions for resulting output embeddings. Defaults to null.
+	OutputDType     *string `json:"output_dtype,omitempty"`     // The data type for the embeddings to be returned, such as [DTypeInt8]. Defaults to float.
+	EncodingFormat  *string `json:"encoding_format,omitempty"`  // Format in which the embeddings are encoded. Defaults to null. Other options: [EncodingBase64].
+	// The JSON names of optional fields sent as an explicit null when unset, such as
+	// "input_type", rather than left out. Unset fields are left out by default.
+	SendNull []string `json:"-"`
+}
+
+// The embeddings of one document's chunks in a [ContextualizedEmbeddingResponse].
+type ContextualizedEmbeddingResult struct {
+	Object string            `json:"object"` // The object type, which is always "list".
+	Data   []EmbeddingObject `json:"data"`   // The embedding of each chunk, indexed by its position in the document.
+	Index  int               `json:"index"`  // The position of the document in the request.
+}
+
+// The response from the /contextualizedembeddings endpoint.
+type ContextualizedEmbeddingResponse struct {
+	Object string                          `json:"object"` // The object type, which is always "list".
+	Data   []ContextualizedEmbeddingResult `json:"data"`   // The results of each document.
+	Model  string                          `json:"model"`  // Name of the model.
+	Usage  UsageObject                     `json:"usage"`  // An object containing usage details
+}
+
+func (r *ContextualizedEmbeddingResponse) reportedUsage() (string, UsageObject) {
+	return r.Model, r.Usage
+}
+
+// Returns a pointer to a [ContextualizedEmbeddingResponse] or an error if the request failed.
+// Each chunk is embedded with the context of the other chunks of its document, so that, for
+// example, a chunk that mentions "the company" is embedded knowing which company.
+//
+// Parameters:
+//   - inputs - The chunks of each document. A query is a document of one chunk.
+//   - model - Name of the model, such as voyage-context-3.
+//   - opts - Optional parameters, see [ContextualizedEmbeddingRequestOpts]
+func (c *VoyageClient) ContextualizedEmbed(inputs [][]string, model string, opts *ContextualizedEmbeddingRequestOpts) (*ContextualizedEmbeddingResponse, error) {
+	return c.ContextualizedEmbedContext(context.Background(), inputs, model, opts)
+}
+
+// ContextualizedEmbedContext is like [VoyageClient.ContextualizedEmbed] but the request is bound to ctx, which can be used to cancel it.
+func (c *VoyageClient) ContextualizedEmbedContext(ctx context.Context, inputs [][]string, model string, opts *ContextualizedEmbeddingRequestOpts) (*ContextualizedEmbeddingResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	var texts []string
+	for _, chunks := range inputs {
+		texts = append(texts, chunks...)
+	}
+	release, err := c.reserveTexts(model, texts...)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	reqBody := ContextualizedEmbeddingRequest{Inputs: inputs, Model: model}
+	if opts != nil {
+		reqBody.InputType = opts.InputType
+		reqBody.OutputDimension = opts.OutputDimension
+		reqBody.OutputDType = opts.OutputDType
+		reqBody.EncodingFormat = opts.EncodingFormat
+		reqBody.SendNull = opts.SendNull
+	}
+	var respBody ContextualizedEmbeddingResponse
+	err = c.handleAPIRequest(ctx, &reqBody, &respBody, "/contextualizedembeddings")
+	return &respBody, err
+}
+
+// decodeEmbeddings decodes the base64 embeddings of every document of r as the output data type
+// dtype, if set.
+func (r *ContextualizedEmbeddingResponse) decodeEmbeddings(dtype *string) error {
+	for i := range r.Data {
+		doc := EmbeddingResponse{Data: r.Data[i].Data}
+		if err := doc.decodeEmbeddings(dtype); err != nil {
+			return fmt.Errorf("document %d: %w", r.Data[i].Index, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package voyageai_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// newContextualServer answers /contextualizedembeddings requests with the fakeVector of each
+// chunk, as base64 if the request asks for it, and records the raw request bodies.
+func newContextualServer(t *testing.T) (*httptest.Server, *[]map[string]any) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/contextualizedembeddings" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]any
+		var req voyageai.ContextualizedEmbeddingRequest
+		raw, err := io.ReadAll(r.Body)
+		if err != nil || json.Unmarshal(raw, &body) != nil || json.Unmarshal(raw, &req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, body)
+
+		resp := map[string]any{"object": "list", "model": req.Model}
+		var docs []map[string]any
+		tokens := 0
+		for i, chunks := range req.Inputs {
+			var data []map[string]any
+			for j, chunk := range chunks {
+				var emb any = fakeVector(chunk)
+				if req.EncodingFormat != nil && *req.EncodingFormat == voyageai.EncodingBase64 {
+					var b []byte
+					for _, v := range fakeVector(chunk) {
+						b = binary.LittleEndian.AppendUint32(b, math.Float32bits(v))
+					}
+					emb = base64.StdEncoding.EncodeToString(b)
+				}
+				data = append(data, map[string]any{"object": "embedding", "embedding": emb, "index": j})
+				tokens += len(chunk)
+			}
+			docs = append(docs, map[string]any{"object": "list", "data": data, "index": i})
+		}
+		resp["data"] = docs
+		resp["usage"] = map[string]any{"total_tokens": tokens}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestContextualizedEmbed(t *testing.T) {
+	srv, bodies := newContextualServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+
+	inputs := [][]string{{"Acme was founded in 1990.", "The company makes anvils."}, {"Who makes anvils?"}}
+	for _, encoding := range []string{"", voyageai.EncodingBase64} {
+		opts := &voyageai.ContextualizedEmbeddingRequestOpts{InputType: voyageai.Opt("document"), SendNull: []string{"output_dimension"}}
+		if encoding != "" {
+			opts.EncodingFormat = &encoding
+		}
+		resp, err := client.ContextualizedEmbed(inputs, voyageai.ModelVoyageContext3, opts)
+		if err != nil {
+			t.Fatalf("%q: %v", encoding, err)
+		}
+		if resp.Model != voyageai.ModelVoyageContext3 || len(resp.Data) != len(inputs) {
+			t.Fatalf("%q: expected a result per document, got %+v", encoding, resp)
+		}
+		for i, doc := range resp.Data {
+			if doc.Index != i || len(doc.Data) != len(inputs[i]) {
+				t.Fatalf("%q: document %d: expected %d chunks, got %+v", encoding, i, len(inputs[i]), doc)
+			}
+			for j, obj := range doc.Data {
+				if obj.Index != j || !slices.Equal(obj.Embedding, fakeVector(inputs[i][j])) {
+					t.Errorf("%q: document %d chunk %d: wrong embedding", encoding, i, j)
+				}
+			}
+		}
+		if resp.Usage.TotalTokens == 0 {
+			t.Errorf("%q: expected usage", encoding)
+		}
+	}
+
+	body := (*bodies)[0]
+	if body["model"] != voyageai.ModelVoyageContext3 || body["input_type"] != "document" {
+		t.Errorf("Expected the model and input type, got %v", body)
+	}
+	if v, ok := body["output_dimension"]; !ok || v != nil {
+		t.Errorf("Expected output_dimension to be sent as null, got %v", body)
+	}
+	if _, ok := body["encoding_format"]; ok {
+		t.Errorf("Expected unset options to be left out, got %v", body)
+	}
+	if got := client.Usage(); got.TotalTokens == 0 {
+		t.Errorf("Expected the client to track usage, got %+v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package voyageai
+
+// The state a client derived with [VoyageClient.With] can share with its parent.
+type SharedState uint8
+
+const (
+	// The concurrency slots of MaxConcurrentRequests, the RateLimit limiters and the
+	// MaxRetriesPerMinute retry budget.
+	ShareLimits SharedState = 1 << iota
+	// Usage, token and cost budgets, [VoyageClient.Stats], tenant counters, usage rollups and
+	// health.
+	ShareAccounting
+
+	ShareAll = ShareLimits | ShareAccounting
+)
+
+// Changes a client derived with [VoyageClient.With].
+type Option func(*derivation)
+
+type derivation struct {
+	opts  VoyageClientOpts
+	share SharedState
+}
+
+// Configure returns an [Option] that changes the derived client's options, which start as a copy
+// of the parent's.
+func Configure(f func(opts *VoyageClientOpts)) Option {
+	return func(d *derivation) { f(&d.opts) }
+}
+
+// Isolate returns an [Option] that gives the derived client its own state in place of the
+// parent's, built from the derived client's options.
+func Isolate(state SharedState) Option {
+	return func(d *derivation) { d.share &^= state }
+}
+
+// With returns a client derived from c, such as one with another API key, base URL or retry
+// settings. The derived client uses the same HTTP client, and so the same connections and TLS
+// settings, and the same cache unless its options set another. By default it also shares all of c's
+// [SharedState]. Options that configure shared state, such as MaxConcurrentRequests and
+// RateLimit, are ignored until that state is isolated with [Isolate]; budgets such as
+// MaxTokensPerClient apply the derived client's limits to the shared consumption.
+//
+// c is not changed, and both clients may be used concurrently. A derived client owns no
+// resources of its own, so dropping it never affects c.
+func (c *VoyageClient) With(opts ...Option) *VoyageClient {
+	d := &derivation{opts: *c.opts, share: ShareAll}
+	for _, opt := range opts {
+		opt(d)
+	}
+	child := newClient(&d.opts, c.client)
+	if d.share&ShareLimits != 0 {
+		child.sem, child.limiter, child.modelLimiters, child.retryBudget = c.sem, c.limiter, c.modelLimiters, c.retryBudget
+	}
+	if d.share&ShareAccounting != 0 {
+		child.usage, child.stats, child.tenants = c.usage, c.stats, c.tenants
+		child.health, child.rollup = c.health, c.rollup
+	}
+	return child
+}
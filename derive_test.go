@@ -0,0 +1,103 @@
+package voyageai_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestClientWith(t *testing.T) {
+	api := newMockServer(t)
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(api.handle))
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	quota := newSharedQuota(100)
+	parentOpts := &voyageai.VoyageClientOpts{Key: "parent", BaseURL: srv.URL, RateLimit: &voyageai.RateLimitOpts{Limiter: quota}}
+	parent := voyageai.NewClient(parentOpts)
+	child := parent.With(voyageai.Configure(func(o *voyageai.VoyageClientOpts) {
+		o.Key = "child"
+		o.MaxRetries = 3
+	}))
+	if parentOpts.Key != "parent" || parentOpts.MaxRetries != 0 {
+		t.Errorf("Expected the parent's options to be unchanged, got %+v", parentOpts)
+	}
+
+	ctx := context.Background()
+	for _, c := range []*voyageai.VoyageClient{parent, child, parent} {
+		if _, err := c.EmbedContext(ctx, []string{"hello"}, "voyage-3.5", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	for i, want := range []string{"BEARER parent", "BEARER child", "BEARER parent"} {
+		if got := api.headers[i].Get("Authorization"); got != want {
+			t.Errorf("Request %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("Expected parent and child to reuse one connection, got %d", n)
+	}
+	// Stats, usage and the limiter are shared.
+	if parent.Stats().Attempts != 3 || child.Stats().Attempts != 3 || child.Usage().TotalTokens != 15 {
+		t.Errorf("Expected shared accounting, got %+v and %+v", parent.Stats(), child.Usage())
+	}
+	if quota.permits != 97 {
+		t.Errorf("Expected every request to use the shared limiter, got %d permits left", quota.permits)
+	}
+
+	// Isolated state is the child's own, built from its options.
+	isolated := parent.With(
+		voyageai.Configure(func(o *voyageai.VoyageClientOpts) { o.RateLimit = nil }),
+		voyageai.Isolate(voyageai.ShareAll),
+	)
+	if _, err := isolated.EmbedContext(ctx, []string{"hello"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if isolated.Stats().Attempts != 1 || parent.Stats().Attempts != 3 || quota.permits != 97 {
+		t.Errorf("Expected isolated accounting and limits, got %d and %d attempts and %d permits", isolated.Stats().Attempts, parent.Stats().Attempts, quota.permits)
+	}
+}
+
+func TestClientWithConcurrent(t *testing.T) {
+	api := newMockServer(t)
+	parent := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "parent", BaseURL: api.URL, MaxConcurrentRequests: 2})
+	child := parent.With(voyageai.Configure(func(o *voyageai.VoyageClientOpts) { o.Key = "child" }))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		c := parent
+		if i%2 == 1 {
+			c = child
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.EmbedContext(context.Background(), []string{"hello"}, "voyage-3.5", nil); err != nil {
+				t.Error(err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+	if n := parent.Stats().Attempts; n != 20 {
+		t.Errorf("Expected 20 shared attempts, got %d", n)
+	}
+	keys := map[string]int{}
+	for _, h := range api.headers {
+		keys[h.Get("Authorization")]++
+	}
+	if keys["BEARER parent"] != 10 || keys["BEARER child"] != 10 {
+		t.Errorf("Expected each client to keep its own key, got %v", keys)
+	}
+}
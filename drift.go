@@ -0,0 +1,193 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// The default number of nearest neighbors compared by [VoyageClient.CompareModels].
+const DefaultDriftK = 10
+
+// Options for [VoyageClient.CompareModels].
+type CompareOpts struct {
+	K         int                   // The number of nearest neighbors compared per input. Defaults to [DefaultDriftK], capped at len(texts)-1.
+	EmbedOpts *EmbeddingRequestOpts // Optional parameters for the embedding requests, used for both models.
+	BatchOpts *BatchOpts            // How the inputs are batched for each model.
+}
+
+// How the neighborhood of one input changed between two models.
+type InputDrift struct {
+	Index int // The position of the input in the compared texts.
+	// The fraction of the input's K nearest neighbors under model A that are also among its K
+	// nearest neighbors under model B.
+	OverlapAtK float64
+	// The Kendall rank correlation (tau-a) between the two models' similarity orderings of the
+	// union of both neighbor sets, from -1 (reversed) to 1 (identical). 1 if the union has fewer
+	// than two members.
+	KendallTau float64
+}
+
+// The result of [VoyageClient.CompareModels].
+type DriftReport struct {
+	ModelA, ModelB string
+	K              int
+	Inputs         []InputDrift // One entry per input, in input order.
+
+	MeanOverlap    float64
+	MinOverlap     float64
+	MeanKendallTau float64
+	MinKendallTau  float64
+
+	UsageA UsageObject // Usage of the requests made with model A.
+	UsageB UsageObject // Usage of the requests made with model B.
+}
+
+// CompareModels embeds texts with both models and reports how much the nearest-neighbor
+// structure of the inputs changes between them. Vectors from different models live in unrelated
+// spaces and cannot be compared directly, so each input's neighbors are found within texts under
+// each model (by cosine similarity) and the two neighborhoods are compared instead.
+//
+// Both models are embedded concurrently with [VoyageClient.EmbedBatch]. At least two texts are
+// required.
+func (c *VoyageClient) CompareModels(ctx context.Context, texts []string, modelA, modelB string, opts *CompareOpts) (*DriftReport, error) {
+	if len(texts) < 2 {
+		return nil, errors.New("voyage: comparing models needs at least two texts")
+	}
+	if opts == nil {
+		opts = &CompareOpts{}
+	}
+	k := opts.K
+	if k <= 0 {
+		k = DefaultDriftK
+	}
+	k = min(k, len(texts)-1)
+
+	var wg sync.WaitGroup
+	resps := make([]*EmbeddingResponse, 2)
+	errs := make([]error, 2)
+	for i, model := range []string{modelA, modelB} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = c.EmbedBatch(ctx, texts, model, opts.EmbedOpts, opts.BatchOpts)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("voyage: embed with %s: %w", model, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	simA, err := similarityMatrix(resps[0])
+	if err != nil {
+		return nil, err
+	}
+	simB, err := similarityMatrix(resps[1])
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{
+		ModelA: modelA,
+		ModelB: modelB,
+		K:      k,
+		Inputs: make([]InputDrift, len(texts)),
+		UsageA: resps[0].Usage,
+		UsageB: resps[1].Usage,
+	}
+	report.MinOverlap, report.MinKendallTau = 1, 1
+	for i := range texts {
+		nnA := nearest(simA[i], i, k)
+		nnB := nearest(simB[i], i, k)
+
+		inB := make(map[int]bool, k)
+		for _, j := range nnB {
+			inB[j] = true
+		}
+		union := append([]int(nil), nnB...)
+		shared := 0
+		for _, j := range nnA {
+			if inB[j] {
+				shared++
+			} else {
+				union = append(union, j)
+			}
+		}
+
+		d := InputDrift{
+			Index:      i,
+			OverlapAtK: float64(shared) / float64(k),
+			KendallTau: kendallTau(simA[i], simB[i], union),
+		}
+		report.Inputs[i] = d
+		report.MeanOverlap += d.OverlapAtK
+		report.MeanKendallTau += d.KendallTau
+		report.MinOverlap = min(report.MinOverlap, d.OverlapAtK)
+		report.MinKendallTau = min(report.MinKendallTau, d.KendallTau)
+	}
+	report.MeanOverlap /= float64(len(texts))
+	report.MeanKendallTau /= float64(len(texts))
+	return report, nil
+}
+
+// similarityMatrix returns the pairwise cosine similarities of the embeddings in resp.
+func similarityMatrix(resp *EmbeddingResponse) ([][]float64, error) {
+	vecs := make([][]float32, len(resp.Data))
+	for i, obj := range resp.Data {
+		vecs[i] = obj.Embedding
+	}
+	if _, err := checkDims(vecs); err != nil {
+		return nil, err
+	}
+	sim := make([][]float64, len(vecs))
+	for i := range sim {
+		sim[i] = make([]float64, len(vecs))
+	}
+	for i := range vecs {
+		for j := i + 1; j < len(vecs); j++ {
+			s := cosine(vecs[i], vecs[j])
+			sim[i][j], sim[j][i] = s, s
+		}
+	}
+	return sim, nil
+}
+
+// nearest returns the indices of the k highest similarities in row, excluding self. Ties are
+// broken by index.
+func nearest(row []float64, self, k int) []int {
+	idx := make([]int, 0, len(row)-1)
+	for j := range row {
+		if j != self {
+			idx = append(idx, j)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return row[idx[a]] > row[idx[b]] })
+	return idx[:k]
+}
+
+// kendallTau returns the tau-a rank correlation between the orderings of items by a and by b.
+func kendallTau(a, b []float64, items []int) float64 {
+	n := len(items)
+	if n < 2 {
+		return 1
+	}
+	var score int
+	for x := 0; x < n; x++ {
+		for y := x + 1; y < n; y++ {
+			da := a[items[x]] - a[items[y]]
+			db := b[items[x]] - b[items[y]]
+			switch {
+			case da*db > 0:
+				score++
+			case da*db < 0:
+				score--
+			}
+		}
+	}
+	return float64(score) / float64(n*(n-1)/2)
+}
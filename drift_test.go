@@ -0,0 +1,82 @@
+package voyageai_test
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestCompareModels(t *testing.T) {
+	// Each input is a unit vector at an angle that depends on the model. Under model-a the nearest
+	// neighbors are 0→1, 1→0, 2→1 and 3→2; under model-b they are 0→1, 1→0, 2→3 and 3→2.
+	angles := map[string][]float64{
+		"model-a": {0, 10, 25, 90},
+		"model-b": {0, 10, 80, 90},
+	}
+	srv := newMockServer(t)
+	srv.embedModel = func(model, text string) []float32 {
+		i, _ := strconv.Atoi(strings.TrimPrefix(text, "t"))
+		rad := angles[model][i] * math.Pi / 180
+		return []float32{float32(math.Cos(rad)), float32(math.Sin(rad))}
+	}
+
+	texts := []string{"t0", "t1", "t2", "t3"}
+	report, err := srv.client().CompareModels(context.Background(), texts, "model-a", "model-b", &voyageai.CompareOpts{K: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	wantOverlap := []float64{1, 1, 0, 1}
+	// Input 2 compares its neighbors {1, 3}: model-a prefers 1 and model-b prefers 3.
+	wantTau := []float64{1, 1, -1, 1}
+	for i, d := range report.Inputs {
+		if d.Index != i || d.OverlapAtK != wantOverlap[i] || d.KendallTau != wantTau[i] {
+			t.Errorf("Input %d: expected overlap %v and tau %v, got %+v", i, wantOverlap[i], wantTau[i], d)
+		}
+	}
+	if report.MeanOverlap != 0.75 || report.MinOverlap != 0 || report.MeanKendallTau != 0.5 || report.MinKendallTau != -1 {
+		t.Errorf("Unexpected aggregates: %+v", report)
+	}
+	if report.UsageA.TotalTokens != 8 || report.UsageB.TotalTokens != 8 {
+		t.Errorf("Expected usage per model, got %+v and %+v", report.UsageA, report.UsageB)
+	}
+	if srv.requestCount() != 2 {
+		t.Errorf("Expected one request per model, got %d", srv.requestCount())
+	}
+}
+
+func TestCompareModelsRanks(t *testing.T) {
+	// With K covering every other input the overlap is always complete, so only the order matters.
+	srv := newMockServer(t)
+	srv.embedModel = func(model, text string) []float32 {
+		i, _ := strconv.Atoi(strings.TrimPrefix(text, "t"))
+		deg := []float64{0, 10, 35, 90}[i]
+		if model == "model-b" {
+			deg = 90 - deg
+		}
+		rad := deg * math.Pi / 180
+		return []float32{float32(math.Cos(rad)), float32(math.Sin(rad))}
+	}
+	report, err := srv.client().CompareModels(context.Background(), []string{"t0", "t1", "t2", "t3"}, "model-a", "model-b", &voyageai.CompareOpts{K: 10})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if report.K != 3 || report.MeanOverlap != 1 {
+		t.Errorf("Expected K capped at 3 with full overlap, got %+v", report)
+	}
+	// Mirroring the angles preserves every distance, so the orderings agree.
+	if report.MeanKendallTau != 1 {
+		t.Errorf("Expected identical orderings, got tau %v", report.MeanKendallTau)
+	}
+}
+
+func TestCompareModelsNeedsTwoTexts(t *testing.T) {
+	srv := newMockServer(t)
+	if _, err := srv.client().CompareModels(context.Background(), []string{"only"}, "a", "b", nil); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,177 @@
+package voyageai
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Optional arguments for [FindNearDuplicates].
+type DuplicateOpts struct {
+	// If non-zero, vectors are hashed into 2^SketchBits buckets by the signs of their projections
+	// onto random hyperplanes, and only vectors in the same bucket are compared. This makes large
+	// inputs much cheaper at the cost of missing some duplicates. At most 30 bits are used.
+	SketchBits int
+	Seed       int64 // Seeds the random hyperplanes used by SketchBits.
+	Workers    int   // The number of goroutines used to compare vectors. Defaults to GOMAXPROCS.
+}
+
+// A set of near-duplicate vectors.
+type DuplicateGroup struct {
+	Representative int   // The lowest index in the group.
+	Members        []int // The indices of all vectors in the group, in ascending order, including the representative.
+}
+
+// FindNearDuplicates groups vectors whose cosine similarity is above threshold. Similarity is
+// treated as transitive, so a group may contain vectors that are only similar through a chain of
+// other members. Only groups with at least two members are returned, ordered by representative.
+func FindNearDuplicates(vecs [][]float32, threshold float32, opts *DuplicateOpts) ([]DuplicateGroup, error) {
+	if opts == nil {
+		opts = &DuplicateOpts{}
+	}
+	if threshold < -1 || threshold > 1 {
+		return nil, fmt.Errorf("voyage: similarity threshold must be in [-1, 1], got %v", threshold)
+	}
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([][]float32, len(vecs))
+	for i, v := range vecs {
+		units[i] = normalized(v)
+	}
+
+	var buckets [][]int
+	if opts.SketchBits > 0 {
+		buckets = sketchBuckets(units, dim, min(opts.SketchBits, 30), opts.Seed)
+	} else {
+		all := make([]int, len(vecs))
+		for i := range all {
+			all[i] = i
+		}
+		buckets = [][]int{all}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// Each job compares one row of a bucket against the rows after it.
+	type job struct{ bucket, row int }
+	jobs := make(chan job)
+	pairs := make([][][2]int, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				b := buckets[j.bucket]
+				a := b[j.row]
+				for _, o := range b[j.row+1:] {
+					if dot(units[a], units[o]) > float64(threshold) {
+						pairs[w] = append(pairs[w], [2]int{a, o})
+					}
+				}
+			}
+		}()
+	}
+	for bi, b := range buckets {
+		for row := range b {
+			jobs <- job{bucket: bi, row: row}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	uf := newUnionFind(len(vecs))
+	for _, ps := range pairs {
+		for _, p := range ps {
+			uf.union(p[0], p[1])
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range vecs {
+		root := uf.find(i)
+		members[root] = append(members[root], i)
+	}
+	var groups []DuplicateGroup
+	for _, m := range members {
+		if len(m) > 1 {
+			groups = append(groups, DuplicateGroup{Representative: m[0], Members: m})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Representative < groups[j].Representative })
+	return groups, nil
+}
+
+// sketchBuckets groups the indices of vecs by the signs of their projections onto bits random
+// hyperplanes.
+func sketchBuckets(vecs [][]float32, dim, bits int, seed int64) [][]int {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	planes := make([][]float32, bits)
+	for i := range planes {
+		planes[i] = make([]float32, dim)
+		for d := range planes[i] {
+			planes[i][d] = float32(rng.NormFloat64())
+		}
+	}
+
+	byKey := map[uint32][]int{}
+	var keys []uint32
+	for i, v := range vecs {
+		var key uint32
+		for b, p := range planes {
+			if dot(v, p) >= 0 {
+				key |= 1 << b
+			}
+		}
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], i)
+	}
+
+	buckets := make([][]int, 0, len(keys))
+	for _, k := range keys {
+		buckets = append(buckets, byKey[k])
+	}
+	return buckets
+}
+
+type unionFind struct {
+	parent []int
+}
+
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parent: make([]int, n)}
+	for i := range uf.parent {
+		uf.parent[i] = i
+	}
+	return uf
+}
+
+func (uf *unionFind) find(x int) int {
+	for uf.parent[x] != x {
+		uf.parent[x] = uf.parent[uf.parent[x]]
+		x = uf.parent[x]
+	}
+	return x
+}
+
+// union merges the sets of a and b, keeping the lower root as the representative.
+func (uf *unionFind) union(a, b int) {
+	ra, rb := uf.find(a), uf.find(b)
+	if ra == rb {
+		return
+	}
+	if ra > rb {
+		ra, rb = rb, ra
+	}
+	uf.parent[rb] = ra
+}
@@ -0,0 +1,79 @@
+package voyageai_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = float32(rng.NormFloat64())
+	}
+	return v
+}
+
+func jitter(rng *rand.Rand, v []float32, scale float64) []float32 {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x + float32(rng.NormFloat64()*scale)
+	}
+	return out
+}
+
+// duplicateFixture returns 30 vectors containing three planted groups of near-duplicates among
+// unrelated noise vectors, along with the expected groups.
+func duplicateFixture() ([][]float32, [][]int) {
+	rng := rand.New(rand.NewPCG(1, 0))
+	want := [][]int{{2, 9, 17}, {4, 5, 21, 28}, {11, 25}}
+
+	vecs := make([][]float32, 30)
+	for i := range vecs {
+		vecs[i] = randomVector(rng, 64)
+	}
+	for _, group := range want {
+		base := randomVector(rng, 64)
+		for _, idx := range group {
+			vecs[idx] = jitter(rng, base, 0.05)
+		}
+	}
+	return vecs, want
+}
+
+func TestFindNearDuplicates(t *testing.T) {
+	vecs, want := duplicateFixture()
+
+	for _, opts := range []*voyageai.DuplicateOpts{nil, {Workers: 3}, {SketchBits: 4, Seed: 3}} {
+		groups, err := voyageai.FindNearDuplicates(vecs, 0.95, opts)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(groups) != len(want) {
+			t.Fatalf("Expected %d groups, got %+v", len(want), groups)
+		}
+		for i, g := range groups {
+			if !slices.Equal(g.Members, want[i]) {
+				t.Errorf("Expected group %v, got %v", want[i], g.Members)
+			}
+			if g.Representative != want[i][0] {
+				t.Errorf("Expected representative %d, got %d", want[i][0], g.Representative)
+			}
+		}
+	}
+}
+
+func TestFindNearDuplicatesErrors(t *testing.T) {
+	if _, err := voyageai.FindNearDuplicates([][]float32{{1, 2}, {1, 2, 3}}, 0.9, nil); err == nil {
+		t.Error("Expected an error for mismatched dimensions")
+	}
+	if _, err := voyageai.FindNearDuplicates([][]float32{{1}}, 1.5, nil); err == nil {
+		t.Error("Expected an error for an out of range threshold")
+	}
+	groups, err := voyageai.FindNearDuplicates(nil, 0.9, nil)
+	if err != nil || len(groups) != 0 {
+		t.Errorf("Expected no groups for empty input, got %v, %v", groups, err)
+	}
+}
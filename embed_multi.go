@@ -0,0 +1,78 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Returned by [VoyageClient.EmbedMulti] when embedding with some of the models failed.
+type MultiModelError struct {
+	Errors map[string]error // The error for each model that failed, keyed by model name.
+}
+
+func (e *MultiModelError) Error() string {
+	models := make([]string, 0, len(e.Errors))
+	for m := range e.Errors {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+	msgs := make([]string, len(models))
+	for i, m := range models {
+		msgs[i] = fmt.Sprintf("%s: %v", m, e.Errors[m])
+	}
+	return fmt.Sprintf("voyage: %d model(s) failed: %s", len(models), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per-model errors, so [errors.Is] and [errors.As] match any of them.
+func (e *MultiModelError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// EmbedMulti embeds texts with every model in models, running one [VoyageClient.EmbedBatch] job
+// per model concurrently. opts optionally holds the request options for each model, keyed by
+// model name. The number of requests in flight across all models is bounded by
+// [VoyageClientOpts.MaxConcurrentRequests].
+//
+// The result maps each model that succeeded to its response, whose Usage covers that model's
+// requests. A failing model does not affect the others: if any model fails, the successful
+// results are returned along with a [*MultiModelError] describing the failures.
+func (c *VoyageClient) EmbedMulti(ctx context.Context, texts []string, models []string, opts map[string]*EmbeddingRequestOpts) (map[string]*EmbeddingResponse, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = map[string]*EmbeddingResponse{}
+		errs    = map[string]error{}
+	)
+	seen := map[string]bool{}
+	for _, model := range models {
+		if seen[model] {
+			continue
+		}
+		seen[model] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.EmbedBatch(ctx, texts, model, opts[model], nil)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[model] = err
+				return
+			}
+			results[model] = resp
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, &MultiModelError{Errors: errs}
+	}
+	return results, nil
+}
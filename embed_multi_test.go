@@ -0,0 +1,75 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedMulti(t *testing.T) {
+	m := newMockServer(t)
+	m.embedModel = func(model, text string) []float32 {
+		if model == "voyage-3-lite" {
+			return []float32{1, 0}
+		}
+		return []float32{0, 1}
+	}
+	texts := []string{"alpha", "beta", "gamma"}
+	dims := 256
+	results, err := m.client().EmbedMulti(context.Background(), texts, []string{"voyage-3", "voyage-3-lite", "voyage-3"},
+		map[string]*voyageai.EmbeddingRequestOpts{"voyage-3-lite": {OutputDimension: &dims}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected results for 2 models, got %d", len(results))
+	}
+	for model, want := range map[string]float32{"voyage-3": 0, "voyage-3-lite": 1} {
+		resp := results[model]
+		if resp == nil || len(resp.Data) != 3 || resp.Data[0].Embedding[0] != want {
+			t.Errorf("Unexpected response for %s: %+v", model, resp)
+			continue
+		}
+		if resp.Usage.TotalTokens != len("alphabetagamma") {
+			t.Errorf("Expected per-model usage for %s, got %d", model, resp.Usage.TotalTokens)
+		}
+	}
+
+	models := map[string]int{}
+	for _, req := range m.requests {
+		models[req.Model]++
+		if req.Model == "voyage-3-lite" && (req.OutputDimension == nil || *req.OutputDimension != 256) {
+			t.Errorf("Expected per-model options to be sent, got %+v", req)
+		}
+	}
+	if models["voyage-3"] != 1 || models["voyage-3-lite"] != 1 {
+		t.Errorf("Expected one request per model, got %v", models)
+	}
+}
+
+func TestEmbedMultiPartialFailure(t *testing.T) {
+	m := newMockServer(t)
+	m.fail = func(_ int, req voyageai.EmbeddingRequest) int {
+		if req.Model == "broken" {
+			return 400
+		}
+		return 0
+	}
+	results, err := m.client().EmbedMulti(context.Background(), []string{"a", "b"}, []string{"voyage-3", "broken"}, nil)
+
+	var merr *voyageai.MultiModelError
+	if !errors.As(err, &merr) {
+		t.Fatalf("Expected a MultiModelError, got %v", err)
+	}
+	if len(merr.Errors) != 1 || merr.Errors["broken"] == nil {
+		t.Errorf("Expected only the broken model to fail, got %v", merr.Errors)
+	}
+	if resp := results["voyage-3"]; resp == nil || len(resp.Data) != 2 {
+		t.Errorf("Expected the other model's results to be intact, got %+v", results)
+	}
+	if _, ok := results["broken"]; ok {
+		t.Error("Expected no result for the failed model")
+	}
+}
@@ -0,0 +1,204 @@
+package voyageai
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// The encoding of embeddings as base64 strings of their little-endian bytes, for
+// [EmbeddingRequestOpts.EncodingFormat] and [MultimodalRequestOpts.OuputEncoding]. It saves
+// bandwidth over arrays of numbers, and is decoded by the client.
+const EncodingBase64 = "base64"
+
+// The output data types of embeddings, for [EmbeddingRequestOpts.OutputDType]. Read quantized
+// embeddings with [EmbeddingObject.Int8s], [EmbeddingObject.Uint8s] and
+// [EmbeddingObject.UnpackBits].
+const (
+	DTypeFloat   = "float"   // 32-bit floats.
+	DTypeInt8    = "int8"    // Integers from -128 to 127.
+	DTypeUint8   = "uint8"   // Integers from 0 to 255.
+	DTypeBinary  = "binary"  // Bit-packed, as int8 values in offset binary.
+	DTypeUbinary = "ubinary" // Bit-packed, as uint8 values.
+)
+
+// UnmarshalJSON decodes an embedding object whose embedding is either an array of numbers or,
+// as with the "base64" encoding format, a base64 string. A base64 embedding is decoded as
+// float32 values; the client re-decodes it as the output data type it requested. Members the
+// object does not know are kept in o.Extra.
+func (o *EmbeddingObject) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Object    string          `json:"object"`
+		Embedding json.RawMessage `json:"embedding"`
+		Index     int             `json:"index"`
+	}
+	extra, err := unmarshalWithExtra(b, &raw, embeddingObjectFields)
+	if err != nil {
+		return err
+	}
+	o.Object, o.Index, o.Embedding, o.packed, o.Extra = raw.Object, raw.Index, nil, nil, extra
+	if len(raw.Embedding) == 0 || string(raw.Embedding) == "null" {
+		return nil
+	}
+	if raw.Embedding[0] != '"' {
+		return json.Unmarshal(raw.Embedding, &o.Embedding)
+	}
+	var s string
+	if err := json.Unmarshal(raw.Embedding, &s); err != nil {
+		return err
+	}
+	packed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("decode base64 embedding: %w", err)
+	}
+	o.packed = packed
+	if len(packed)%4 == 0 {
+		o.Embedding = float32sOf(packed)
+	}
+	return nil
+}
+
+// Float32s returns the embedding as float32 values, as sent for the "float" output data type.
+func (o EmbeddingObject) Float32s() ([]float32, error) {
+	if o.Embedding == nil && o.packed != nil {
+		return nil, fmt.Errorf("voyage: %d bytes are not a float32 embedding", len(o.packed))
+	}
+	return o.Embedding, nil
+}
+
+// Int8s returns the embedding as int8 values, as sent for the "int8" and "binary" output data
+// types.
+func (o EmbeddingObject) Int8s() ([]int8, error) {
+	out := make([]int8, 0, max(len(o.packed), len(o.Embedding)))
+	if o.packed != nil {
+		for _, b := range o.packed {
+			out = append(out, int8(b))
+		}
+		return out, nil
+	}
+	for i, v := range o.Embedding {
+		if v != float32(math.Trunc(float64(v))) || v < math.MinInt8 || v > math.MaxInt8 {
+			return nil, fmt.Errorf("voyage: embedding value %d is not an int8: %v", i, v)
+		}
+		out = append(out, int8(v))
+	}
+	return out, nil
+}
+
+// Uint8s returns the embedding as uint8 values, as sent for the "uint8" and "ubinary" output
+// data types.
+func (o EmbeddingObject) Uint8s() ([]uint8, error) {
+	if o.packed != nil {
+		return append([]uint8(nil), o.packed...), nil
+	}
+	out := make([]uint8, 0, len(o.Embedding))
+	for i, v := range o.Embedding {
+		if v != float32(math.Trunc(float64(v))) || v < 0 || v > math.MaxUint8 {
+			return nil, fmt.Errorf("voyage: embedding value %d is not a uint8: %v", i, v)
+		}
+		out = append(out, uint8(v))
+	}
+	return out, nil
+}
+
+// UnpackBits unpacks a bit-packed embedding of the "binary" or "ubinary" output data type,
+// given as dtype, into its full dimension: one value per bit, most significant bit first, 1 for
+// a set bit and 0 otherwise. Binary embeddings are int8 values in offset binary, so each value
+// is the packed byte minus 128.
+func (o EmbeddingObject) UnpackBits(dtype string) ([]float32, error) {
+	var packed []uint8
+	switch dtype {
+	case DTypeBinary:
+		values, err := o.Int8s()
+		if err != nil {
+			return nil, err
+		}
+		packed = make([]uint8, len(values))
+		for i, v := range values {
+			packed[i] = uint8(int(v) + 128)
+		}
+	case DTypeUbinary:
+		var err error
+		if packed, err = o.Uint8s(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("voyage: output data type %q is not bit-packed", dtype)
+	}
+	out := make([]float32, 0, 8*len(packed))
+	for _, b := range packed {
+		for bit := 7; bit >= 0; bit-- {
+			out = append(out, float32(b>>bit&1))
+		}
+	}
+	return out, nil
+}
+
+// decodeAs sets the Embedding of a base64 embedding from its bytes read as the output data type
+// dtype, which defaults to "float".
+func (o *EmbeddingObject) decodeAs(dtype string) error {
+	if o.packed == nil {
+		return nil
+	}
+	switch dtype {
+	case "", DTypeFloat:
+		_, err := o.Float32s()
+		return err
+	case DTypeInt8, DTypeBinary:
+		v, _ := o.Int8s()
+		o.Embedding = make([]float32, len(v))
+		for i, x := range v {
+			o.Embedding[i] = float32(x)
+		}
+	case DTypeUint8, DTypeUbinary:
+		o.Embedding = make([]float32, len(o.packed))
+		for i, x := range o.packed {
+			o.Embedding[i] = float32(x)
+		}
+	default:
+		return fmt.Errorf("voyage: unknown output data type %q", dtype)
+	}
+	return nil
+}
+
+// decodeEmbeddings decodes the base64 embeddings of r as the output data type dtype, if set.
+func (r *EmbeddingResponse) decodeEmbeddings(dtype *string) error {
+	var t string
+	if dtype != nil {
+		t = *dtype
+	}
+	for i := range r.Data {
+		if err := r.Data[i].decodeAs(t); err != nil {
+			return fmt.Errorf("voyage: decode embedding %d: %w", r.Data[i].Index, err)
+		}
+	}
+	return nil
+}
+
+// decodeResponse decodes the base64 embeddings of the response respBody to reqBody, if it has
+// any, as the output data type of the request.
+func decodeResponse(reqBody, respBody any) error {
+	r, ok := respBody.(interface{ decodeEmbeddings(dtype *string) error })
+	if !ok {
+		return nil
+	}
+	var dtype *string
+	switch req := reqBody.(type) {
+	case *EmbeddingRequest:
+		dtype = req.OutputDType
+	case *ContextualizedEmbeddingRequest:
+		dtype = req.OutputDType
+	}
+	return r.decodeEmbeddings(dtype)
+}
+
+// float32sOf reads b as little-endian float32 values.
+func float32sOf(b []byte) []float32 {
+	out := make([]float32, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return out
+}
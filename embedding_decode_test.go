@@ -0,0 +1,191 @@
+package voyageai_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+var (
+	testFloats = []float32{0.5, -1.25, 3}
+	testInt8s  = []int8{-128, 0, 127}
+	testUint8s = []uint8{0, 128, 255}
+	// 1024 dimensions with every third bit set.
+	testBits = func() []float32 {
+		bits := make([]float32, 1024)
+		for i := range bits {
+			if i%3 == 0 {
+				bits[i] = 1
+			}
+		}
+		return bits
+	}()
+)
+
+// packBits packs bits into bytes, most significant bit first.
+func packBits(bits []float32) []uint8 {
+	packed := make([]uint8, len(bits)/8)
+	for i, b := range bits {
+		if b == 1 {
+			packed[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return packed
+}
+
+// dtypeServer answers embedding requests with the test values of the requested output data
+// type, as numbers or, if requested, as base64.
+func dtypeServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OutputDType    string `json:"output_dtype"`
+			EncodingFormat string `json:"encoding_format"`
+			OutputEncoding string `json:"output_encoding"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var numbers any
+		var raw []byte
+		switch req.OutputDType {
+		case "", "float":
+			numbers = testFloats
+			for _, f := range testFloats {
+				raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(f))
+			}
+		case "int8":
+			numbers = testInt8s
+			for _, v := range testInt8s {
+				raw = append(raw, byte(v))
+			}
+		case "uint8":
+			numbers, raw = testUint8s, testUint8s
+		case "binary":
+			// Offset binary: each value is the packed byte minus 128.
+			var values []int8
+			for _, b := range packBits(testBits) {
+				values = append(values, int8(int(b)-128))
+				raw = append(raw, byte(int8(int(b)-128)))
+			}
+			numbers = values
+		case "ubinary":
+			var values []int
+			for _, b := range packBits(testBits) {
+				values = append(values, int(b))
+			}
+			numbers, raw = values, packBits(testBits)
+		}
+		var embedding any = numbers
+		if req.EncodingFormat == "base64" || req.OutputEncoding == "base64" {
+			embedding = base64.StdEncoding.EncodeToString(raw)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"data":   []any{map[string]any{"object": "embedding", "embedding": embedding, "index": 0}},
+			"model":  "voyage-3.5",
+			"usage":  map[string]any{"total_tokens": 1},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmbeddingDTypes(t *testing.T) {
+	srv := dtypeServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+
+	for _, dtype := range []string{voyageai.DTypeFloat, voyageai.DTypeInt8, voyageai.DTypeUint8, voyageai.DTypeBinary, voyageai.DTypeUbinary} {
+		for _, encoding := range []string{"", voyageai.EncodingBase64} {
+			opts := &voyageai.EmbeddingRequestOpts{OutputDType: &dtype}
+			if encoding != "" {
+				opts.EncodingFormat = &encoding
+			}
+			resp, err := client.Embed([]string{"a"}, "voyage-3.5", opts)
+			if err != nil {
+				t.Fatalf("%s %q: %v", dtype, encoding, err)
+			}
+			obj := resp.Data[0]
+
+			switch dtype {
+			case "float":
+				if got, err := obj.Float32s(); err != nil || !reflect.DeepEqual(got, testFloats) {
+					t.Errorf("%s %q: expected %v, got %v, %v", dtype, encoding, testFloats, got, err)
+				}
+			case "int8":
+				if got, err := obj.Int8s(); err != nil || !reflect.DeepEqual(got, testInt8s) {
+					t.Errorf("%s %q: expected %v, got %v, %v", dtype, encoding, testInt8s, got, err)
+				}
+				if want := []float32{-128, 0, 127}; !reflect.DeepEqual(obj.Embedding, want) {
+					t.Errorf("%s %q: expected the values in Embedding, got %v", dtype, encoding, obj.Embedding)
+				}
+			case "uint8":
+				if got, err := obj.Uint8s(); err != nil || !reflect.DeepEqual(got, testUint8s) {
+					t.Errorf("%s %q: expected %v, got %v, %v", dtype, encoding, testUint8s, got, err)
+				}
+			case "binary", "ubinary":
+				if len(obj.Embedding) != 128 {
+					t.Errorf("%s %q: expected 128 packed values, got %d", dtype, encoding, len(obj.Embedding))
+				}
+				if got, err := obj.UnpackBits(dtype); err != nil || !reflect.DeepEqual(got, testBits) {
+					t.Errorf("%s %q: unexpected bits %v, %v", dtype, encoding, got, err)
+				}
+			}
+
+			// Decoded responses round trip as numbers.
+			b, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			var decoded voyageai.EmbeddingResponse
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err.Error())
+			}
+			if !reflect.DeepEqual(decoded.Data[0].Embedding, obj.Embedding) {
+				t.Errorf("%s %q: expected the embedding to round trip, got %v", dtype, encoding, decoded.Data[0].Embedding)
+			}
+		}
+	}
+}
+
+func TestMultimodalBase64(t *testing.T) {
+	srv := dtypeServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+	encoding := voyageai.EncodingBase64
+	resp, err := client.MultimodalEmbed([]voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{{Type: "text", Text: "a"}}}}, "voyage-multimodal-3", &voyageai.MultimodalRequestOpts{OuputEncoding: &encoding})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(resp.Data[0].Embedding, testFloats) {
+		t.Errorf("Expected %v, got %v", testFloats, resp.Data[0].Embedding)
+	}
+}
+
+func TestEmbeddingObjectConversionErrors(t *testing.T) {
+	obj := voyageai.EmbeddingObject{Embedding: []float32{0.5, 200}}
+	if _, err := obj.Int8s(); err == nil {
+		t.Error("Expected floats not to convert to int8")
+	}
+	if _, err := obj.Uint8s(); err == nil {
+		t.Error("Expected 0.5 not to convert to uint8")
+	}
+	if _, err := obj.UnpackBits("float"); err == nil {
+		t.Error("Expected float embeddings not to unpack")
+	}
+
+	var odd voyageai.EmbeddingObject
+	if err := json.Unmarshal([]byte(`{"object":"embedding","embedding":"AQID","index":2}`), &odd); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, _ := odd.Uint8s(); odd.Index != 2 || !reflect.DeepEqual(got, []uint8{1, 2, 3}) {
+		t.Errorf("Unexpected object %+v with bytes %v", odd, got)
+	}
+	if _, err := odd.Float32s(); err == nil {
+		t.Error("Expected three bytes not to be float32 values")
+	}
+}
@@ -0,0 +1,60 @@
+package voyageai
+
+import (
+	"context"
+	"time"
+)
+
+// An API endpoint, as used to key [VoyageClientOpts.Endpoints].
+type Endpoint string
+
+const (
+	EndpointEmbeddings Endpoint = "embeddings"
+	EndpointRerank     Endpoint = "rerank"
+	EndpointMultimodal Endpoint = "multimodalembeddings"
+	EndpointContextual Endpoint = "contextualizedembeddings"
+)
+
+// Retry and timeout settings for requests to one endpoint, or for one call. Zero fields are
+// unset and fall back to the next level: settings passed with [WithRequestConfig] take precedence
+// over [VoyageClientOpts.Endpoints], which take precedence over the client-wide
+// [VoyageClientOpts.TimeOut] and [VoyageClientOpts.MaxRetries].
+type RequestConfig struct {
+	Timeout    time.Duration // The time allowed for each attempt.
+	MaxRetries int           // Like [VoyageClientOpts.MaxRetries].
+	// A constant wait before each retry, replacing the exponential backoff of
+	// [VoyageClientOpts.RetryBaseDelay]. Retry-After headers are still honored.
+	Backoff time.Duration
+}
+
+// merge returns c with its unset fields taken from fallback.
+func (c RequestConfig) merge(fallback RequestConfig) RequestConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = fallback.Timeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = fallback.MaxRetries
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = fallback.Backoff
+	}
+	return c
+}
+
+type requestConfigKey struct{}
+
+// WithRequestConfig returns a copy of ctx whose calls use cfg for their requests, overriding
+// the client's per-endpoint and client-wide settings in the fields cfg sets.
+func WithRequestConfig(ctx context.Context, cfg RequestConfig) context.Context {
+	return context.WithValue(ctx, requestConfigKey{}, cfg)
+}
+
+// requestConfig resolves the settings of a request to endpoint made with ctx.
+func (c *VoyageClient) requestConfig(ctx context.Context, endpoint string) RequestConfig {
+	cfg, _ := ctx.Value(requestConfigKey{}).(RequestConfig)
+	cfg = cfg.merge(c.opts.Endpoints[Endpoint(endpoint)])
+	return cfg.merge(RequestConfig{
+		Timeout:    time.Duration(c.opts.TimeOut) * time.Millisecond,
+		MaxRetries: c.opts.MaxRetries,
+	})
+}
@@ -0,0 +1,145 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// scriptedServer answers every endpoint after an optional delay, failing with 500 while the
+// endpoint's failure script says so, and counts the requests to each endpoint.
+type scriptedServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	delay   map[string]time.Duration
+	failing map[string]bool
+	counts  map[string]int
+}
+
+func newScriptedServer(t *testing.T) *scriptedServer {
+	s := &scriptedServer{delay: map[string]time.Duration{}, failing: map[string]bool{}, counts: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, "/")
+		s.mu.Lock()
+		s.counts[endpoint]++
+		delay, failing := s.delay[endpoint], s.failing[endpoint]
+		s.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		if failing {
+			w.WriteHeader(500)
+			return
+		}
+		if endpoint == "rerank" {
+			json.NewEncoder(w).Encode(voyageai.RerankResponse{Object: "list", Data: []voyageai.RerankObject{{Index: 0}}})
+			return
+		}
+		json.NewEncoder(w).Encode(voyageai.EmbeddingResponse{Object: "list", Data: []voyageai.EmbeddingObject{{Embedding: []float32{1}}}})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// take returns and resets the number of requests to endpoint.
+func (s *scriptedServer) take(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.counts[endpoint]
+	s.counts[endpoint] = 0
+	return n
+}
+
+func TestRequestConfigPrecedence(t *testing.T) {
+	api := newScriptedServer(t)
+	api.delay["rerank"] = 100 * time.Millisecond
+	api.failing["embeddings"] = true
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:            "APIKEY",
+		BaseURL:        api.URL,
+		TimeOut:        2000,
+		MaxRetries:     4,
+		RetryBaseDelay: time.Millisecond,
+		Endpoints: map[voyageai.Endpoint]voyageai.RequestConfig{
+			voyageai.EndpointRerank: {Timeout: 20 * time.Millisecond, MaxRetries: 1},
+		},
+	})
+	ctx := context.Background()
+
+	// Per-endpoint settings beat the client-wide ones.
+	if _, err := client.Rerank("q", []string{"d"}, "rerank-2", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the rerank timeout to apply, got %v", err)
+	}
+	if n := api.take("rerank"); n != 1 {
+		t.Errorf("Expected 1 rerank attempt, got %d", n)
+	}
+
+	// Endpoints without settings use the client-wide ones.
+	if _, err := client.Embed([]string{"x"}, "voyage-3", nil); err == nil {
+		t.Error("Expected the embedding request to fail")
+	}
+	if n := api.take("embeddings"); n != 4 {
+		t.Errorf("Expected 4 embedding attempts, got %d", n)
+	}
+
+	// Per-call settings beat both.
+	callCtx := voyageai.WithRequestConfig(ctx, voyageai.RequestConfig{Timeout: time.Second})
+	if _, err := client.RerankContext(callCtx, "q", []string{"d"}, "rerank-2", nil); err != nil {
+		t.Errorf("Expected the per-call timeout to apply, got %v", err)
+	}
+	api.mu.Lock()
+	api.delay["rerank"], api.failing["rerank"] = 0, true
+	api.mu.Unlock()
+	if _, err := client.RerankContext(voyageai.WithRequestConfig(ctx, voyageai.RequestConfig{MaxRetries: 3}), "q", []string{"d"}, "rerank-2", nil); err == nil {
+		t.Error("Expected the rerank request to fail")
+	}
+	if n := api.take("rerank"); n != 4 {
+		t.Errorf("Expected 1 successful and 3 failed rerank attempts, got %d", n)
+	}
+	if _, err := client.EmbedContext(voyageai.WithRequestConfig(ctx, voyageai.RequestConfig{MaxRetries: 2}), []string{"x"}, "voyage-3", nil); err == nil {
+		t.Error("Expected the embedding request to fail")
+	}
+	if n := api.take("embeddings"); n != 2 {
+		t.Errorf("Expected 2 embedding attempts, got %d", n)
+	}
+}
+
+func TestRequestConfigBackoff(t *testing.T) {
+	api := newScriptedServer(t)
+	api.failing["embeddings"] = true
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: api.URL,
+		Clock:   clock,
+		Endpoints: map[voyageai.Endpoint]voyageai.RequestConfig{
+			voyageai.EndpointEmbeddings: {MaxRetries: 2, Backoff: time.Second},
+		},
+	})
+	done := make(chan error)
+	go func() {
+		_, err := client.Embed([]string{"x"}, "voyage-3", nil)
+		done <- err
+	}()
+	clock.waitForTimers(t, 1)
+	if n := api.take("embeddings"); n != 1 {
+		t.Errorf("Expected the retry to wait for the backoff, got %d attempts", n)
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err == nil {
+		t.Error("Expected the request to fail")
+	}
+	if n := api.take("embeddings"); n != 1 {
+		t.Errorf("Expected one retry after the backoff, got %d", n)
+	}
+}
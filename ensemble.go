@@ -0,0 +1,104 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// A model contributing to an ensemble embedding. See [VoyageClient.EnsembleEmbed].
+type EnsembleSpec struct {
+	Model  string
+	Opts   *EmbeddingRequestOpts // Optional parameters for the model's requests.
+	Weight float32               // The factor the model's embeddings are scaled by. Zero means 1.
+}
+
+// Options for [VoyageClient.EnsembleEmbed].
+type EnsembleOpts struct {
+	Normalize bool // Scale every ensemble vector to unit length after concatenating it.
+}
+
+// The part of an ensemble vector produced by one [EnsembleSpec].
+type EnsembleComponent struct {
+	Model     string
+	Offset    int         // The index of the component's first dimension in the ensemble vector.
+	Dimension int         // The number of dimensions of the component.
+	Usage     UsageObject // The usage of the component's requests.
+}
+
+// Slice returns the component's part of an ensemble vector.
+func (c EnsembleComponent) Slice(v []float32) []float32 {
+	return v[c.Offset : c.Offset+c.Dimension]
+}
+
+// The layout and usage of the vectors returned by [VoyageClient.EnsembleEmbed].
+type UsageSummary struct {
+	Dimension  int                 // The dimension of the ensemble vectors.
+	Components []EnsembleComponent // One per spec, in spec order.
+	Usage      UsageObject         // The usage of every request.
+}
+
+// EnsembleEmbed embeds texts with the model of every spec concurrently, each with
+// [VoyageClient.EmbedBatch], and returns one vector per input: the concatenation of its
+// embeddings in spec order, each scaled by its spec's weight. The summary records where each
+// component lies in the vectors, so it can be sliced back out with [EnsembleComponent.Slice].
+// Weights apply before normalization, so with [EnsembleOpts.Normalize] they set the components'
+// shares of the unit length.
+//
+// Any failing model fails the call.
+func (c *VoyageClient) EnsembleEmbed(ctx context.Context, texts []string, specs []EnsembleSpec, opts *EnsembleOpts) ([][]float32, *UsageSummary, error) {
+	if len(specs) == 0 {
+		return nil, nil, fmt.Errorf("voyage: no ensemble models")
+	}
+	if opts == nil {
+		opts = &EnsembleOpts{}
+	}
+	resps := make([]*EmbeddingResponse, len(specs))
+	errs := make([]error, len(specs))
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = c.EmbedBatch(ctx, texts, spec.Model, spec.Opts, nil)
+		}()
+	}
+	wg.Wait()
+
+	summary := &UsageSummary{Components: make([]EnsembleComponent, len(specs))}
+	for i, spec := range specs {
+		if errs[i] != nil {
+			return nil, nil, fmt.Errorf("voyage: ensemble model %s: %w", spec.Model, errs[i])
+		}
+		vecs := make([][]float32, len(resps[i].Data))
+		for j, obj := range resps[i].Data {
+			vecs[j] = obj.Embedding
+		}
+		dim, err := checkDims(vecs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("voyage: ensemble model %s: %w", spec.Model, err)
+		}
+		summary.Components[i] = EnsembleComponent{Model: spec.Model, Offset: summary.Dimension, Dimension: dim, Usage: resps[i].Usage}
+		summary.Dimension += dim
+		summary.Usage = addUsage(summary.Usage, resps[i].Usage)
+	}
+
+	out := make([][]float32, len(texts))
+	for j := range out {
+		v := make([]float32, 0, summary.Dimension)
+		for i, spec := range specs {
+			w := spec.Weight
+			if w == 0 {
+				w = 1
+			}
+			for _, x := range resps[i].Data[j].Embedding {
+				v = append(v, w*x)
+			}
+		}
+		if opts.Normalize {
+			v = normalized(v)
+		}
+		out[j] = v
+	}
+	return out, summary, nil
+}
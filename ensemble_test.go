@@ -0,0 +1,75 @@
+package voyageai_test
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEnsembleEmbed(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embedModel = func(model, text string) []float32 {
+		if model == "voyage-code-3" {
+			return []float32{3, 4}
+		}
+		return []float32{float32(len(text)), 0, 1}
+	}
+	specs := []voyageai.EnsembleSpec{
+		{Model: "voyage-code-3", Weight: 0.5},
+		{Model: "voyage-3.5"},
+	}
+	texts := []string{"a", "abc"}
+	vecs, summary, err := srv.client().EnsembleEmbed(context.Background(), texts, specs, nil)
+	if err != nil {
+		t.Fatalf("Failed to embed: %+v", err)
+	}
+	want := [][]float32{{1.5, 2, 1, 0, 1}, {1.5, 2, 3, 0, 1}}
+	for i := range want {
+		if !slices.Equal(vecs[i], want[i]) {
+			t.Errorf("Input %d: expected %v, got %v", i, want[i], vecs[i])
+		}
+	}
+
+	if summary.Dimension != 5 || len(summary.Components) != 2 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	code, general := summary.Components[0], summary.Components[1]
+	if code.Model != "voyage-code-3" || code.Offset != 0 || code.Dimension != 2 || general.Offset != 2 || general.Dimension != 3 {
+		t.Errorf("Unexpected components %+v", summary.Components)
+	}
+	if got := general.Slice(vecs[1]); !slices.Equal(got, []float32{3, 0, 1}) {
+		t.Errorf("Expected the second component back, got %v", got)
+	}
+	if code.Usage.TotalTokens == 0 || summary.Usage.TotalTokens != code.Usage.TotalTokens+general.Usage.TotalTokens {
+		t.Errorf("Expected the usage of both models, got %+v", summary)
+	}
+
+	vecs, _, err = srv.client().EnsembleEmbed(context.Background(), texts, specs, &voyageai.EnsembleOpts{Normalize: true})
+	if err != nil {
+		t.Fatalf("Failed to embed: %+v", err)
+	}
+	var n float64
+	for _, x := range vecs[1] {
+		n += float64(x * x)
+	}
+	if math.Abs(n-1) > 1e-6 || math.Abs(float64(vecs[1][0]/vecs[1][2])-0.5) > 1e-6 {
+		t.Errorf("Expected a unit vector in the same direction, got %v", vecs[1])
+	}
+}
+
+func TestEnsembleEmbedFailure(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if req.Model == "voyage-code-3" {
+			return 400
+		}
+		return 0
+	}
+	_, _, err := srv.client().EnsembleEmbed(context.Background(), []string{"a"}, []voyageai.EnsembleSpec{{Model: "voyage-3.5"}, {Model: "voyage-code-3"}}, nil)
+	if err == nil {
+		t.Error("Expected the failing model to fail the call")
+	}
+}
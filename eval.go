@@ -0,0 +1,189 @@
+package voyageai
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Relevance judgments for one query, mapping document IDs to their gain. Documents that are not
+// listed, or have a gain of zero or less, are not relevant. Use a gain of 1 for binary judgments.
+type Judgments map[string]float64
+
+// Retrieval metrics for one query. See [Evaluate].
+type QueryMetrics struct {
+	Query     string
+	Recall    float64 // The fraction of relevant documents ranked in the top K.
+	Precision float64 // The fraction of the top K positions holding relevant documents.
+	MRR       float64 // The reciprocal rank of the first relevant document in the whole ranking, or 0.
+	NDCG      float64 // The normalized discounted cumulative gain of the top K, with linear gains.
+}
+
+// The result of [Evaluate].
+type EvalReport struct {
+	K       int
+	Queries []QueryMetrics // One entry per judged query, sorted by query.
+	Mean    QueryMetrics   // The metrics averaged over Queries, with an empty Query.
+	// Queries that have a ranking but no relevant documents in the judgments. Their metrics are
+	// undefined, so they are excluded from Queries and Mean.
+	Unjudged []string
+}
+
+// Evaluate scores rankings against relevance judgments at cutoff k.
+//
+// rankings maps each query to document IDs, best first. The given order is taken as the rank,
+// so callers with tied scores must order ties themselves, for example by ID; repeated IDs count
+// at their first position only. Every query with at least one relevant document in judgments is
+// evaluated; a judged query without a ranking scores zero on every metric.
+func Evaluate(rankings map[string][]string, judgments map[string]Judgments, k int) (*EvalReport, error) {
+	if k <= 0 {
+		return nil, errors.New("voyage: evaluation cutoff must be positive")
+	}
+	report := &EvalReport{K: k}
+	for query, ranking := range rankings {
+		if !hasRelevant(judgments[query]) {
+			report.Unjudged = append(report.Unjudged, query)
+			continue
+		}
+		report.Queries = append(report.Queries, scoreQuery(query, ranking, judgments[query], k))
+	}
+	for query, j := range judgments {
+		if _, ranked := rankings[query]; !ranked && hasRelevant(j) {
+			report.Queries = append(report.Queries, QueryMetrics{Query: query})
+		}
+	}
+	sort.Slice(report.Queries, func(i, j int) bool { return report.Queries[i].Query < report.Queries[j].Query })
+	sort.Strings(report.Unjudged)
+
+	for _, q := range report.Queries {
+		report.Mean.Recall += q.Recall
+		report.Mean.Precision += q.Precision
+		report.Mean.MRR += q.MRR
+		report.Mean.NDCG += q.NDCG
+	}
+	if n := float64(len(report.Queries)); n > 0 {
+		report.Mean.Recall /= n
+		report.Mean.Precision /= n
+		report.Mean.MRR /= n
+		report.Mean.NDCG /= n
+	}
+	return report, nil
+}
+
+func hasRelevant(j Judgments) bool {
+	for _, gain := range j {
+		if gain > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func scoreQuery(query string, ranking []string, j Judgments, k int) QueryMetrics {
+	m := QueryMetrics{Query: query}
+	var gains []float64
+	for _, g := range j {
+		if g > 0 {
+			gains = append(gains, g)
+		}
+	}
+
+	seen := map[string]bool{}
+	rank, hits := 0, 0
+	var dcg float64
+	for _, id := range ranking {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		rank++
+		g := j[id]
+		if g <= 0 {
+			continue
+		}
+		if m.MRR == 0 {
+			m.MRR = 1 / float64(rank)
+		}
+		if rank <= k {
+			hits++
+			dcg += g / math.Log2(float64(rank+1))
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(gains)))
+	var idcg float64
+	for i, g := range gains[:min(k, len(gains))] {
+		idcg += g / math.Log2(float64(i+2))
+	}
+	m.Recall = float64(hits) / float64(len(gains))
+	m.Precision = float64(hits) / float64(k)
+	m.NDCG = dcg / idcg
+	return m
+}
+
+// The change in metrics for one query between two evaluations. See [CompareEvals].
+type QueryDelta struct {
+	Query                        string
+	Recall, Precision, MRR, NDCG float64 // Candidate minus baseline.
+}
+
+// The result of [CompareEvals].
+type EvalDiff struct {
+	Queries []QueryDelta // One entry per query judged in both reports, sorted by query.
+	Mean    QueryDelta   // The deltas averaged over Queries.
+	// The number of queries whose nDCG improved, worsened or stayed the same in the candidate.
+	Wins, Losses, Ties int
+	// Queries judged in only one of the reports, which are not compared.
+	OnlyBaseline, OnlyCandidate []string
+}
+
+// CompareEvals compares two evaluations of the same queries, typically two models or rerankers,
+// query by query. Reports should use the same cutoff.
+func CompareEvals(baseline, candidate *EvalReport) *EvalDiff {
+	base := make(map[string]QueryMetrics, len(baseline.Queries))
+	for _, q := range baseline.Queries {
+		base[q.Query] = q
+	}
+	diff := &EvalDiff{}
+	compared := map[string]bool{}
+	for _, c := range candidate.Queries {
+		b, ok := base[c.Query]
+		if !ok {
+			diff.OnlyCandidate = append(diff.OnlyCandidate, c.Query)
+			continue
+		}
+		compared[c.Query] = true
+		d := QueryDelta{
+			Query:     c.Query,
+			Recall:    c.Recall - b.Recall,
+			Precision: c.Precision - b.Precision,
+			MRR:       c.MRR - b.MRR,
+			NDCG:      c.NDCG - b.NDCG,
+		}
+		diff.Queries = append(diff.Queries, d)
+		switch {
+		case d.NDCG > 1e-12:
+			diff.Wins++
+		case d.NDCG < -1e-12:
+			diff.Losses++
+		default:
+			diff.Ties++
+		}
+		diff.Mean.Recall += d.Recall
+		diff.Mean.Precision += d.Precision
+		diff.Mean.MRR += d.MRR
+		diff.Mean.NDCG += d.NDCG
+	}
+	for _, b := range baseline.Queries {
+		if !compared[b.Query] {
+			diff.OnlyBaseline = append(diff.OnlyBaseline, b.Query)
+		}
+	}
+	if n := float64(len(diff.Queries)); n > 0 {
+		diff.Mean.Recall /= n
+		diff.Mean.Precision /= n
+		diff.Mean.MRR /= n
+		diff.Mean.NDCG /= n
+	}
+	return diff
+}
@@ -0,0 +1,98 @@
+package voyageai_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestEvaluate(t *testing.T) {
+	rankings := map[string][]string{
+		"q1": {"d1", "d2", "d3", "d4"},
+		"q2": {"d5", "d6", "d5", "d7"},
+		"q3": {"d1"},
+	}
+	judgments := map[string]voyageai.Judgments{
+		"q1": {"d1": 1, "d3": 1, "d9": 1},
+		"q2": {"d7": 3, "d6": 1},
+		"q4": {"d1": 1},
+	}
+	report, err := voyageai.Evaluate(rankings, judgments, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(report.Queries) != 3 || fmt.Sprint(report.Unjudged) != "[q3]" {
+		t.Fatalf("Unexpected queries %+v, unjudged %v", report.Queries, report.Unjudged)
+	}
+
+	// q1: relevant d1 at rank 1 and d3 at rank 3 of three relevant documents.
+	q1 := report.Queries[0]
+	idcg1 := 1 + 1/math.Log2(3) + 1/math.Log2(4)
+	if !near(q1.Recall, 2.0/3) || !near(q1.Precision, 2.0/3) || q1.MRR != 1 || !near(q1.NDCG, (1+1/math.Log2(4))/idcg1) {
+		t.Errorf("q1: %+v", q1)
+	}
+
+	// q2: the repeated d5 is ignored, so d6 (gain 1) is at rank 2 and d7 (gain 3) at rank 3.
+	q2 := report.Queries[1]
+	dcg2 := 1/math.Log2(3) + 3/math.Log2(4)
+	idcg2 := 3 + 1/math.Log2(3)
+	if q2.Recall != 1 || !near(q2.Precision, 2.0/3) || q2.MRR != 0.5 || !near(q2.NDCG, dcg2/idcg2) {
+		t.Errorf("q2: %+v", q2)
+	}
+
+	// q4 is judged but has no ranking.
+	if q4 := report.Queries[2]; q4.Query != "q4" || q4.Recall != 0 || q4.NDCG != 0 {
+		t.Errorf("q4: %+v", q4)
+	}
+	if !near(report.Mean.MRR, 0.5) || !near(report.Mean.Recall, (2.0/3+1)/3) {
+		t.Errorf("Unexpected means %+v", report.Mean)
+	}
+
+	if _, err := voyageai.Evaluate(rankings, judgments, 0); err == nil {
+		t.Error("Expected an error for a zero cutoff")
+	}
+}
+
+func TestCompareEvals(t *testing.T) {
+	judgments := map[string]voyageai.Judgments{
+		"q1": {"a": 1},
+		"q2": {"b": 1},
+		"q3": {"c": 1},
+		"q4": {"d": 1},
+	}
+	baseline, _ := voyageai.Evaluate(map[string][]string{
+		"q1": {"x", "a"},
+		"q2": {"b", "x"},
+		"q3": {"c"},
+	}, judgments, 2)
+	candidate, _ := voyageai.Evaluate(map[string][]string{
+		"q1": {"a", "x"},
+		"q2": {"x", "b"},
+		"q3": {"c"},
+	}, judgments, 2)
+	// q4 is judged but unranked in both, so it is compared as a tie at zero.
+
+	diff := voyageai.CompareEvals(baseline, candidate)
+	if diff.Wins != 1 || diff.Losses != 1 || diff.Ties != 2 {
+		t.Errorf("Expected 1 win, 1 loss and 2 ties, got %+v", diff)
+	}
+	q1 := diff.Queries[0]
+	if q1.Query != "q1" || !near(q1.MRR, 0.5) || !near(q1.NDCG, 1-1/math.Log2(3)) || q1.Recall != 0 {
+		t.Errorf("q1: %+v", q1)
+	}
+	if !near(diff.Mean.NDCG, 0) || !near(diff.Mean.MRR, 0) {
+		t.Errorf("Expected the win and loss to cancel out, got %+v", diff.Mean)
+	}
+
+	partial, _ := voyageai.Evaluate(map[string][]string{"q1": {"a"}}, map[string]voyageai.Judgments{"q1": {"a": 1}, "q5": {"e": 1}}, 2)
+	diff = voyageai.CompareEvals(baseline, partial)
+	if fmt.Sprint(diff.OnlyBaseline) != "[q2 q3 q4]" || fmt.Sprint(diff.OnlyCandidate) != "[q5]" {
+		t.Errorf("Unexpected unmatched queries %v and %v", diff.OnlyBaseline, diff.OnlyCandidate)
+	}
+}
@@ -40,7 +40,7 @@ func main() {
 				},
 				{
 					Type:        "image_base64",
-					ImageBase64: voyageai.MustGetBase64(img),
+					ImageBase64: voyageai.MustGetBase64Raw(img),
 				},
 			},
 		},
@@ -0,0 +1,179 @@
+package voyageai
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// The JSON names of the fields each response type decodes.
+var (
+	embeddingResponseFields = jsonFieldNames(reflect.TypeFor[EmbeddingResponse]())
+	embeddingObjectFields   = jsonFieldNames(reflect.TypeFor[EmbeddingObject]())
+	rerankResponseFields    = jsonFieldNames(reflect.TypeFor[RerankResponse]())
+	rerankObjectFields      = jsonFieldNames(reflect.TypeFor[RerankObject]())
+	usageObjectFields       = jsonFieldNames(reflect.TypeFor[UsageObject]())
+)
+
+// jsonFieldNames returns the JSON names of the encoded fields of the struct type t.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "-" && f.IsExported() {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// UnmarshalJSON decodes r, keeping the members it does not know in r.Extra.
+func (r *EmbeddingResponse) UnmarshalJSON(b []byte) error {
+	type plain EmbeddingResponse
+	extra, err := unmarshalWithExtra(b, (*plain)(r), embeddingResponseFields)
+	r.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes r, keeping the members it does not know in r.Extra.
+func (r *RerankResponse) UnmarshalJSON(b []byte) error {
+	type plain RerankResponse
+	extra, err := unmarshalWithExtra(b, (*plain)(r), rerankResponseFields)
+	r.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes o, keeping the members it does not know in o.Extra.
+func (o *RerankObject) UnmarshalJSON(b []byte) error {
+	type plain RerankObject
+	extra, err := unmarshalWithExtra(b, (*plain)(o), rerankObjectFields)
+	o.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes u, keeping the members it does not know in u.Extra.
+func (u *UsageObject) UnmarshalJSON(b []byte) error {
+	type plain UsageObject
+	extra, err := unmarshalWithExtra(b, (*plain)(u), usageObjectFields)
+	u.Extra = extra
+	return err
+}
+
+// unmarshalWithExtra decodes the JSON object b into the struct v, and returns the members of b
+// not named in known, or nil if there are none.
+func unmarshalWithExtra(b []byte, v any, known map[string]bool) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return unknownFields(fields, known), nil
+}
+
+// A response whose Extra fields are dropped unless [VoyageClientOpts.PreserveUnknownFields] is
+// set.
+type extraCarrier interface {
+	// clearExtras drops the Extra fields of the response, its items and its usage.
+	clearExtras()
+}
+
+func (r *EmbeddingResponse) clearExtras() {
+	r.Extra, r.Usage.Extra = nil, nil
+	for i := range r.Data {
+		r.Data[i].Extra = nil
+	}
+}
+
+func (r *RerankResponse) clearExtras() {
+	r.Extra, r.Usage.Extra = nil, nil
+	for i := range r.Data {
+		r.Data[i].Extra = nil
+	}
+}
+
+func (r *ContextualizedEmbeddingResponse) clearExtras() {
+	r.Usage.Extra = nil
+	for i := range r.Data {
+		for j := range r.Data[i].Data {
+			r.Data[i].Data[j].Extra = nil
+		}
+	}
+}
+
+// unknownFields returns the members of fields not named in known, or nil if there are none.
+func unknownFields(fields map[string]json.RawMessage, known map[string]bool) map[string]json.RawMessage {
+	var extra map[string]json.RawMessage
+	for name, v := range fields {
+		if !known[name] {
+			if extra == nil {
+				extra = map[string]json.RawMessage{}
+			}
+			extra[name] = v
+		}
+	}
+	return extra
+}
+
+// MarshalJSON encodes r along with its Extra fields.
+func (r EmbeddingResponse) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingResponse
+	return marshalWithExtra(plain(r), r.Extra, embeddingResponseFields)
+}
+
+// MarshalJSON encodes o along with its Extra fields.
+func (o EmbeddingObject) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingObject
+	return marshalWithExtra(plain(o), o.Extra, embeddingObjectFields)
+}
+
+// MarshalJSON encodes r along with its Extra fields.
+func (r RerankResponse) MarshalJSON() ([]byte, error) {
+	type plain RerankResponse
+	return marshalWithExtra(plain(r), r.Extra, rerankResponseFields)
+}
+
+// MarshalJSON encodes o along with its Extra fields.
+func (o RerankObject) MarshalJSON() ([]byte, error) {
+	type plain RerankObject
+	return marshalWithExtra(plain(o), o.Extra, rerankObjectFields)
+}
+
+// MarshalJSON encodes u along with its Extra fields.
+func (u UsageObject) MarshalJSON() ([]byte, error) {
+	type plain UsageObject
+	return marshalWithExtra(plain(u), u.Extra, usageObjectFields)
+}
+
+// marshalWithExtra encodes the struct v, then appends the members of extra in name order. Members
+// named like one of v's fields are left out.
+func marshalWithExtra(v any, extra map[string]json.RawMessage, known map[string]bool) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	b = b[:len(b)-1] // Drop the closing brace.
+	for _, name := range names {
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		key, _ := json.Marshal(name)
+		b = append(append(b, key...), ':')
+		if v := extra[name]; len(v) > 0 {
+			b = append(b, v...)
+		} else {
+			b = append(b, "null"...)
+		}
+	}
+	return append(b, '}'), nil
+}
@@ -0,0 +1,126 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+const (
+	embedWithExtras = `{"object":"list","data":[{"object":"embedding","embedding":[0.5],"index":0,"flags":{"truncated":true}}],` +
+		`"model":"voyage-3.5","usage":{"total_tokens":3,"cached_tokens":2},"region":"us","trace":{"spans":[1,2]}}`
+	rerankWithExtras = `{"object":"list","data":[{"index":0,"relevance_score":0.75,"chunks":[{"start":0}]}],` +
+		`"model":"rerank-2","usage":{"total_tokens":4,"cached_tokens":1},"region":"eu"}`
+)
+
+func newExtrasClient(t *testing.T, preserve bool) *voyageai.VoyageClient {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/rerank") {
+			w.Write([]byte(rerankWithExtras))
+			return
+		}
+		w.Write([]byte(embedWithExtras))
+	}))
+	t.Cleanup(srv.Close)
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, PreserveUnknownFields: preserve})
+}
+
+func TestPreserveUnknownFields(t *testing.T) {
+	client := newExtrasClient(t, true)
+	resp, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(resp.Extra["region"]) != `"us"` || string(resp.Extra["trace"]) != `{"spans":[1,2]}` || len(resp.Extra) != 2 {
+		t.Errorf("Unexpected response extras %v", resp.Extra)
+	}
+	if string(resp.Data[0].Extra["flags"]) != `{"truncated":true}` || len(resp.Data[0].Extra) != 1 {
+		t.Errorf("Unexpected embedding extras %v", resp.Data[0].Extra)
+	}
+	if resp.Usage.Extra == nil || string(resp.Usage.Extra["cached_tokens"]) != "2" {
+		t.Errorf("Unexpected usage extras %v", resp.Usage.Extra)
+	}
+
+	// Extras survive a round trip.
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var want, got any
+	json.Unmarshal([]byte(embedWithExtras), &want)
+	json.Unmarshal(b, &got)
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("Expected the response to round trip, got %s", b)
+	}
+
+	rr, err := client.RerankContext(context.Background(), "q", []string{"d"}, "rerank-2", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(rr.Extra["region"]) != `"eu"` || string(rr.Data[0].Extra["chunks"]) != `[{"start":0}]` || string(rr.Usage.Extra["cached_tokens"]) != "1" {
+		t.Errorf("Unexpected rerank extras %v, %v and %v", rr.Extra, rr.Data[0].Extra, rr.Usage.Extra)
+	}
+	b, _ = json.Marshal(rr)
+	json.Unmarshal([]byte(rerankWithExtras), &want)
+	json.Unmarshal(b, &got)
+	wantJSON, _ = json.Marshal(want)
+	gotJSON, _ = json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("Expected the rerank response to round trip, got %s", b)
+	}
+}
+
+func TestUnknownFieldsDroppedByDefault(t *testing.T) {
+	client := newExtrasClient(t, false)
+	resp, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Extra != nil || resp.Data[0].Extra != nil || resp.Usage.Extra != nil {
+		t.Errorf("Expected no extras without PreserveUnknownFields, got %+v", resp)
+	}
+	b, _ := json.Marshal(resp)
+	if strings.Contains(string(b), "region") {
+		t.Errorf("Expected unknown fields to be dropped, got %s", b)
+	}
+}
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		body string
+		new  func() any
+	}{
+		{embedWithExtras, func() any { return new(voyageai.EmbeddingResponse) }},
+		{rerankWithExtras, func() any { return new(voyageai.RerankResponse) }},
+	} {
+		first := tc.new()
+		if err := json.Unmarshal([]byte(tc.body), first); err != nil {
+			t.Fatal(err.Error())
+		}
+		b, err := json.Marshal(first)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var want, got any
+		json.Unmarshal([]byte(tc.body), &want)
+		json.Unmarshal(b, &got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s to round trip, got %s", tc.body, b)
+		}
+		second := tc.new()
+		if err := json.Unmarshal(b, second); err != nil {
+			t.Fatal(err.Error())
+		}
+		if !reflect.DeepEqual(second, first) {
+			t.Errorf("Expected %+v after a second decode, got %+v", first, second)
+		}
+	}
+}
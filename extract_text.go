@@ -0,0 +1,205 @@
+package voyageai
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Options for [ExtractText].
+type ExtractOpts struct {
+	KeepHeadings    bool // Keep the text of headings, each on its own line. Headings are left out by default.
+	KeepLinksAsText bool // Follow the text of each link with its URL in parentheses.
+	// The most characters returned. Longer text is cut at the last word boundary before the limit.
+	// Unlimited by default.
+	MaxLength int
+}
+
+// Elements whose content is not part of a page's text, such as scripts, navigation and forms.
+var extractSkipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Iframe: true, atom.Svg: true,
+	atom.Math: true, atom.Canvas: true, atom.Object: true,
+}
+
+// ARIA roles of navigation and footer-like landmarks, which are skipped like their elements.
+var extractSkippedRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"search": true, "menu": true, "menubar": true, "dialog": true, "alert": true,
+}
+
+// How text on either side of an element is separated.
+type textBreak int
+
+const (
+	breakNone textBreak = iota
+	breakSpace
+	breakLine
+	breakParagraph
+)
+
+var extractBreaks = map[atom.Atom]textBreak{
+	atom.P: breakParagraph, atom.H1: breakParagraph, atom.H2: breakParagraph, atom.H3: breakParagraph,
+	atom.H4: breakParagraph, atom.H5: breakParagraph, atom.H6: breakParagraph,
+	atom.Article: breakParagraph, atom.Section: breakParagraph, atom.Main: breakParagraph,
+	atom.Blockquote: breakParagraph, atom.Pre: breakParagraph, atom.Table: breakParagraph,
+	atom.Ul: breakParagraph, atom.Ol: breakParagraph, atom.Dl: breakParagraph,
+	atom.Figure: breakParagraph, atom.Hr: breakParagraph, atom.Address: breakParagraph,
+	atom.Div: breakLine, atom.Li: breakLine, atom.Tr: breakLine, atom.Dt: breakLine, atom.Dd: breakLine,
+	atom.Caption: breakLine, atom.Figcaption: breakLine, atom.Details: breakLine, atom.Summary: breakLine,
+	atom.Br: breakLine,
+	atom.Td: breakSpace, atom.Th: breakSpace,
+}
+
+// ExtractText returns the readable text of an HTML document, ready for [ChunkText] or
+// [VoyageClient.Embed]. Scripts, styles, navigation, headers, footers, forms and hidden elements
+// are left out, entities are decoded and runs of whitespace are collapsed to one space.
+// Paragraphs and other blocks are separated by a blank line, and line breaks, list items and
+// table rows by a newline. Whitespace in pre elements is kept.
+//
+// Malformed HTML is parsed the way browsers parse it, so the only errors are from reading
+// htmlSrc.
+func ExtractText(htmlSrc io.Reader, opts ExtractOpts) (string, error) {
+	doc, err := html.Parse(htmlSrc)
+	if err != nil {
+		return "", fmt.Errorf("voyage: parse html: %w", err)
+	}
+	w := &textWriter{opts: opts}
+	w.walk(doc, false)
+	return truncateText(w.b.String(), opts.MaxLength), nil
+}
+
+// textWriter accumulates the text of a document, collapsing whitespace and the breaks between
+// elements.
+type textWriter struct {
+	opts    ExtractOpts
+	b       strings.Builder
+	pending textBreak // The strongest break since the last text written.
+}
+
+func (w *textWriter) walk(n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data, pre)
+		return
+	case html.ElementNode:
+		if skipElement(n) || !w.opts.KeepHeadings && isHeading(n.DataAtom) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+	brk := extractBreaks[n.DataAtom]
+	w.brk(brk)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c, pre || n.DataAtom == atom.Pre)
+	}
+	if n.DataAtom == atom.A && w.opts.KeepLinksAsText {
+		if href := htmlAttr(n, "href"); href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			w.brk(breakSpace)
+			w.write("(" + href + ")")
+		}
+	}
+	w.brk(brk)
+}
+
+// text writes s, collapsing its whitespace unless it is preformatted.
+func (w *textWriter) text(s string, pre bool) {
+	if pre {
+		if strings.TrimSpace(s) != "" {
+			w.write(s)
+		}
+		return
+	}
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" {
+			w.brk(breakSpace)
+		}
+		return
+	}
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(r) {
+		w.brk(breakSpace)
+	}
+	w.write(strings.Join(words, " "))
+	if r, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(r) {
+		w.brk(breakSpace)
+	}
+}
+
+// write writes s after the pending break, which is dropped at the start of the text.
+func (w *textWriter) write(s string) {
+	if w.b.Len() > 0 {
+		w.b.WriteString([...]string{"", " ", "\n", "\n\n"}[w.pending])
+	}
+	w.pending = breakNone
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) brk(b textBreak) {
+	w.pending = max(w.pending, b)
+}
+
+// skipElement reports whether n and its content are left out of the text.
+func skipElement(n *html.Node) bool {
+	if extractSkipped[n.DataAtom] {
+		return true
+	}
+	for _, a := range n.Attr {
+		switch {
+		case a.Namespace != "":
+		case a.Key == "hidden":
+			return true
+		case a.Key == "aria-hidden" && a.Val == "true":
+			return true
+		case a.Key == "role" && extractSkippedRoles[strings.ToLower(strings.TrimSpace(a.Val))]:
+			return true
+		}
+	}
+	return false
+}
+
+func isHeading(a atom.Atom) bool {
+	switch a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// truncateText cuts s to at most limit characters, back to the last word boundary if that falls
+// inside a word. A limit of 0 or less leaves s whole.
+func truncateText(s string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	cut := 0
+	for range limit {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+	}
+	next, _ := utf8.DecodeRuneInString(s[cut:])
+	s = s[:cut]
+	if !unicode.IsSpace(next) {
+		if i := strings.LastIndexFunc(s, unicode.IsSpace); i > 0 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimRightFunc(s, unicode.IsSpace)
+}
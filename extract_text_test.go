@@ -0,0 +1,136 @@
+package voyageai_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/zamedic/voyageai"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Tuning vector search | Example Blog</title>
+  <style>body { font-family: sans-serif; }</style>
+  <script>window.dataLayer = window.dataLayer || [];</script>
+</head>
+<body>
+  <header class="site-header">
+    <a href="/">Example Blog</a>
+    <nav><ul><li><a href="/posts">Posts</a></li><li><a href="/about">About</a></li></ul></nav>
+  </header>
+  <div id="cookie-banner" role="dialog">We use cookies. <button>Accept</button></div>
+  <main>
+    <article>
+      <h1>Tuning vector search</h1>
+      <p class="byline">By Ada, 3 March</p>
+      <p>Embeddings turn   text into
+         vectors. Similar texts get <em>similar</em> vectors.</p>
+      <h2>Choosing a threshold</h2>
+      <p>Start with a <a href="https://example.com/calibrate">calibration set</a>, then measure.</p>
+      <ul>
+        <li>Label pairs</li>
+        <li>Score them</li>
+      </ul>
+      <pre>score = cos(a, b)
+  if score &gt; t</pre>
+      <p hidden>Draft note: expand this section.</p>
+    </article>
+    <aside><h3>Related posts</h3><a href="/posts/1">Reranking 101</a></aside>
+  </main>
+  <form action="/subscribe"><label>Email</label><input name="email"><button>Subscribe</button></form>
+  <footer>&copy; 2024 Example Blog. <a href="/privacy">Privacy</a></footer>
+  <script src="/app.js"></script>
+</body>
+</html>`
+
+func TestExtractTextPage(t *testing.T) {
+	got, err := voyageai.ExtractText(strings.NewReader(articlePage), voyageai.ExtractOpts{})
+	if err != nil {
+		t.Fatalf("Failed to extract text: %+v", err)
+	}
+	want := "By Ada, 3 March\n\n" +
+		"Embeddings turn text into vectors. Similar texts get similar vectors.\n\n" +
+		"Start with a calibration set, then measure.\n\n" +
+		"Label pairs\nScore them\n\n" +
+		"score = cos(a, b)\n  if score > t"
+	if got != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, got)
+	}
+}
+
+func TestExtractTextHeadingsAndLinks(t *testing.T) {
+	got, err := voyageai.ExtractText(strings.NewReader(articlePage), voyageai.ExtractOpts{KeepHeadings: true, KeepLinksAsText: true})
+	if err != nil {
+		t.Fatalf("Failed to extract text: %+v", err)
+	}
+	if !strings.HasPrefix(got, "Tuning vector search\n\nBy Ada") {
+		t.Errorf("Expected the title heading first, got %q", got)
+	}
+	if !strings.Contains(got, "vectors.\n\nChoosing a threshold\n\nStart with") {
+		t.Errorf("Expected the section heading between paragraphs, got %q", got)
+	}
+	if !strings.Contains(got, "calibration set (https://example.com/calibrate), then") {
+		t.Errorf("Expected the link's URL after its text, got %q", got)
+	}
+	if strings.Contains(got, "Related posts") || strings.Contains(got, "Privacy") {
+		t.Errorf("Expected headings and links in skipped elements to stay out, got %q", got)
+	}
+}
+
+func TestExtractTextEntities(t *testing.T) {
+	src := `<p>Fish &amp; chips &lt;3 &eacute;t&eacute;&nbsp;&#8212;&#x20AC;5 &quot;ok&quot; &unknown;</p>`
+	got, err := voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{})
+	if err != nil {
+		t.Fatalf("Failed to extract text: %+v", err)
+	}
+	if want := `Fish & chips <3 été —€5 "ok" &unknown;`; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestExtractTextMalformed(t *testing.T) {
+	src := `<div><p>First <b>bold <i>both</b> italic</p><p>Second<table><tr><td>a<td>b</table><p>Unclosed <span>end`
+	got, err := voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{})
+	if err != nil {
+		t.Fatalf("Failed to extract text: %+v", err)
+	}
+	if want := "First bold both italic\n\nSecond\n\na b\n\nUnclosed end"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestExtractTextMaxLength(t *testing.T) {
+	src := `<p>Ünïcödé words are never split in the middle</p>`
+	got, err := voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{MaxLength: 20})
+	if err != nil {
+		t.Fatalf("Failed to extract text: %+v", err)
+	}
+	if want := "Ünïcödé words are"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	got, _ = voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{MaxLength: 13})
+	if want := "Ünïcödé words"; got != want {
+		t.Errorf("Expected a cut at a word end to keep the word, got %q", got)
+	}
+	if got, _ = voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{MaxLength: 4}); utf8.RuneCountInString(got) > 4 {
+		t.Errorf("Expected at most 4 characters, got %q", got)
+	}
+	if got, _ = voyageai.ExtractText(strings.NewReader(src), voyageai.ExtractOpts{MaxLength: 1000}); got != "Ünïcödé words are never split in the middle" {
+		t.Errorf("Expected short text to be whole, got %q", got)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestExtractTextReadError(t *testing.T) {
+	if _, err := voyageai.ExtractText(failingReader{}, voyageai.ExtractOpts{}); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// A record of the failure report, describing one input that could not be embedded by a batch
+// call. See [WithFailureReport].
+//
+// The report is JSON Lines: one record per line, encoded with the field names below. Fields are
+// only ever added to the schema, never renamed or removed.
+type FailureRecord struct {
+	// The position of the input in the texts of [VoyageClient.EmbedBatch], or of the document in
+	// the source of [VoyageClient.MigrateEmbeddings].
+	Index      int       `json:"index"`
+	ID         string    `json:"id,omitempty"`          // The document ID, for a migration.
+	InputHash  string    `json:"input_hash"`            // The hex-encoded SHA-256 hash of the input text. See [HashInput].
+	Endpoint   string    `json:"endpoint"`              // The API endpoint, such as "embeddings".
+	Model      string    `json:"model"`                 // The model requested.
+	Attempts   int       `json:"attempts"`              // The number of requests sent, including retries. Zero if none was sent.
+	StatusCode int       `json:"status_code,omitempty"` // The HTTP status of the last response. Omitted if none was received.
+	Error      string    `json:"error"`                 // The error that failed the request.
+	Time       time.Time `json:"time"`                  // When the failure was recorded, by the client's [Clock].
+}
+
+type failureReportKey struct{}
+
+// failureReport passes failure records to emit one at a time.
+type failureReport struct {
+	mu   sync.Mutex
+	emit func(FailureRecord) error
+}
+
+// WithFailureReport returns a copy of ctx that makes the batch calls using it,
+// [VoyageClient.EmbedBatch], [VoyageClient.MigrateEmbeddings] and
+// [VoyageClient.ReprocessFailures], write a [FailureRecord] to w
+// for every input of a request that fails after its retries. Each record is written to w as
+// soon as the request fails, in a single Write. Inputs that are embedded are never reported, nor
+// are the inputs of a request that fails because ctx is done.
+//
+// A batch call that fails to write the report stops with the write error.
+func WithFailureReport(ctx context.Context, w io.Writer) context.Context {
+	enc := json.NewEncoder(w)
+	return context.WithValue(ctx, failureReportKey{}, &failureReport{emit: func(rec FailureRecord) error { return enc.Encode(rec) }})
+}
+
+// failureReporter returns the failure report of ctx, or nil if there is none.
+func failureReporter(ctx context.Context) *failureReport {
+	fr, _ := ctx.Value(failureReportKey{}).(*failureReport)
+	return fr
+}
+
+// write records a failure of every input of texts, whose positions are given by indices. ids, if
+// not nil, holds the ID of each input.
+func (fr *failureReport) write(now time.Time, endpoint, model string, indices []int, texts, ids []string, out *requestOutcome, err error) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	for i, text := range texts {
+		rec := FailureRecord{
+			Index:      indices[i],
+			InputHash:  HashInput(text),
+			Endpoint:   endpoint,
+			Model:      model,
+			Attempts:   out.attempts,
+			StatusCode: out.statusCode,
+			Error:      err.Error(),
+			Time:       now,
+		}
+		if ids != nil {
+			rec.ID = ids[i]
+		}
+		if err := fr.emit(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type requestOutcomeKey struct{}
+
+// requestOutcome receives how the last API request made with a context went.
+type requestOutcome struct {
+	attempts   int
+	statusCode int
+}
+
+// withRequestOutcome returns a copy of ctx whose API requests record their outcome in out.
+func withRequestOutcome(ctx context.Context, out *requestOutcome) context.Context {
+	return context.WithValue(ctx, requestOutcomeKey{}, out)
+}
+
+// recordOutcome records the outcome of a request in the requestOutcome of ctx, if any.
+func recordOutcome(ctx context.Context, attempts int, info responseInfo) {
+	if out, ok := ctx.Value(requestOutcomeKey{}).(*requestOutcome); ok {
+		out.attempts, out.statusCode = attempts, info.StatusCode
+	}
+}
@@ -0,0 +1,170 @@
+package voyageai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// reportBuffer collects a failure report, counting the records written so far.
+type reportBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *reportBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *reportBuffer) lines() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Count(b.buf.Bytes(), []byte("\n"))
+}
+
+func (b *reportBuffer) records(t *testing.T) []voyageai.FailureRecord {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var recs []voyageai.FailureRecord
+	sc := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for sc.Scan() {
+		var rec voyageai.FailureRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("Invalid report line %q: %v", sc.Text(), err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestEmbedBatchFailureReport(t *testing.T) {
+	srv := newMockServer(t)
+	report := &reportBuffer{}
+	var linesAtThirdRange int
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		switch {
+		case slices.Contains(req.Input, "text 4"):
+			return 500
+		case slices.Contains(req.Input, "text 7"):
+			linesAtThirdRange = report.lines()
+			return 400
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+	texts := slices.Collect(textsOf(12))
+
+	ctx := voyageai.WithFailureReport(context.Background(), report)
+	resp, err := client.EmbedBatch(ctx, texts, "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 3, ContinueOnError: true})
+	var apiErr *voyageai.APIError
+	if !errors.As(err, &apiErr) || !strings.Contains(err.Error(), "inputs 3-5") || !strings.Contains(err.Error(), "inputs 6-8") {
+		t.Fatalf("Expected the failures of both ranges, got %v", err)
+	}
+	var embedded []int
+	for _, obj := range resp.Data {
+		embedded = append(embedded, obj.Index)
+	}
+	if !slices.Equal(embedded, []int{0, 1, 2, 9, 10, 11}) {
+		t.Errorf("Expected the other ranges to be embedded, got %v", embedded)
+	}
+	if linesAtThirdRange != 3 {
+		t.Errorf("Expected the first failed range reported before the next request, got %d records", linesAtThirdRange)
+	}
+
+	recs := report.records(t)
+	if len(recs) != 6 {
+		t.Fatalf("Expected 6 records, got %+v", recs)
+	}
+	for i, rec := range recs {
+		index, attempts, status := i+3, 2, 500
+		if i >= 3 {
+			attempts, status = 1, 400
+		}
+		if rec.Index != index || rec.Attempts != attempts || rec.StatusCode != status {
+			t.Errorf("Record %d: expected input %d after %d attempts with status %d, got %+v", i, index, attempts, status, rec)
+		}
+		if rec.InputHash != voyageai.HashInput(texts[index]) || rec.Endpoint != "embeddings" || rec.Model != "voyage-3.5" || rec.ID != "" {
+			t.Errorf("Record %d: unexpected fields %+v", i, rec)
+		}
+		if rec.Error == "" || rec.Time.IsZero() {
+			t.Errorf("Record %d: expected an error and a time, got %+v", i, rec)
+		}
+	}
+}
+
+func TestEmbedBatchFailureReportStops(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n == 2 {
+			return 400
+		}
+		return 0
+	}
+	report := &reportBuffer{}
+	ctx := voyageai.WithFailureReport(context.Background(), report)
+	_, err := srv.client().EmbedBatch(ctx, slices.Collect(textsOf(9)), "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 3})
+	if err == nil {
+		t.Fatal("Expected the run to stop")
+	}
+	if srv.requestCount() != 2 {
+		t.Errorf("Expected no request after the failure, got %d", srv.requestCount())
+	}
+	recs := report.records(t)
+	if len(recs) != 3 || recs[0].Index != 3 || recs[2].Index != 5 {
+		t.Errorf("Expected inputs 3-5 to be reported, got %+v", recs)
+	}
+}
+
+func TestMigrateEmbeddingsFailureReport(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if slices.Contains(req.Input, "text 7") {
+			return 422
+		}
+		return 0
+	}
+	report := &reportBuffer{}
+	ctx := voyageai.WithFailureReport(context.Background(), report)
+	_, err := srv.client().MigrateEmbeddings(ctx, syntheticDocuments(12), "voyage-3.5", func(voyageai.MigratedDocument) error {
+		return nil
+	}, &voyageai.MigrateOpts{BatchSize: 5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	recs := report.records(t)
+	var ids []string
+	for i, rec := range recs {
+		ids = append(ids, rec.ID)
+		if rec.Index != i+5 || rec.StatusCode != 422 || rec.Attempts != 1 {
+			t.Errorf("Record %d: unexpected fields %+v", i, rec)
+		}
+	}
+	if strings.Join(ids, " ") != "doc-5 doc-6 doc-7 doc-8 doc-9" {
+		t.Errorf("Expected only the failed batch to be reported, got %v", ids)
+	}
+}
+
+func TestFailureReportWriteError(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int { return 400 }
+	ctx := voyageai.WithFailureReport(context.Background(), &failingWriter{})
+	_, err := srv.client().EmbedBatch(ctx, slices.Collect(textsOf(6)), "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 3, ContinueOnError: true})
+	if err == nil || !strings.Contains(err.Error(), "write failure report") {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+	if srv.requestCount() != 1 {
+		t.Errorf("Expected the run to stop, got %d requests", srv.requestCount())
+	}
+}
@@ -0,0 +1,69 @@
+package voyageai
+
+import "errors"
+
+// An alternative model for a request. See [FallbackOpts].
+type Fallback[O any] struct {
+	Model string
+	Opts  *O // The options used with Model. If nil, the options of the call are used.
+}
+
+// Alternative models, per endpoint, tried in order when a call fails. See
+// [VoyageClientOpts.Fallbacks].
+//
+// A fallback is only tried once the previous model's request has failed with a rate limit (429)
+// or server (5xx) error after exhausting its retries; validation and other client errors are
+// returned as is. Fallbacks apply to single calls such as [VoyageClient.EmbedContext], but not to
+// helpers such as [VoyageClient.EmbedBatch] that combine several requests into one result, since
+// vectors from different models cannot be mixed.
+type FallbackOpts struct {
+	Embed      []Fallback[EmbeddingRequestOpts]
+	Multimodal []Fallback[MultimodalRequestOpts]
+	Rerank     []Fallback[RerankRequestOpts]
+}
+
+func (c *VoyageClient) fallbacks() *FallbackOpts {
+	if c.opts.Fallbacks == nil {
+		return &FallbackOpts{}
+	}
+	return c.opts.Fallbacks
+}
+
+// isOverloaded reports whether err was caused by a rate limit or server error response.
+func isOverloaded(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == 429 || apiErr.StatusCode >= 500)
+}
+
+func (r *EmbeddingResponse) metadata() *ResponseMetadata { return &r.Metadata }
+func (r *RerankResponse) metadata() *ResponseMetadata    { return &r.Metadata }
+
+// withFallback calls call with model and then, while the call fails with an overloaded error,
+// with each of alts in turn, skipping alternatives for model itself. The response's metadata
+// records which model served it.
+func withFallback[O, R any, PR interface {
+	*R
+	metadata() *ResponseMetadata
+}](model string, opts *O, alts []Fallback[O], call func(model string, opts *O) (PR, error)) (PR, error) {
+	served := model
+	resp, err := call(model, opts)
+	for _, alt := range alts {
+		if err == nil || !isOverloaded(err) {
+			break
+		}
+		if alt.Model == model {
+			continue
+		}
+		altOpts := alt.Opts
+		if altOpts == nil {
+			altOpts = opts
+		}
+		served = alt.Model
+		resp, err = call(alt.Model, altOpts)
+	}
+	if resp != nil {
+		meta := resp.metadata()
+		meta.RequestedModel, meta.ServedModel, meta.Fallback = model, served, served != model
+	}
+	return resp, err
+}
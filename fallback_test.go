@@ -0,0 +1,107 @@
+package voyageai_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func newFallbackClient(m *mockServer) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:            "APIKEY",
+		BaseURL:        m.URL,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+		Fallbacks: &voyageai.FallbackOpts{
+			Embed: []voyageai.Fallback[voyageai.EmbeddingRequestOpts]{
+				{Model: "voyage-3-large"},
+				{Model: "voyage-3.5-lite", Opts: &voyageai.EmbeddingRequestOpts{InputType: voyageai.Opt("query")}},
+			},
+			Rerank: []voyageai.Fallback[voyageai.RerankRequestOpts]{{Model: "rerank-2-lite"}},
+		},
+	})
+}
+
+func TestFallbackOnRateLimit(t *testing.T) {
+	m := newMockServer(t)
+	m.fail = func(_ int, req voyageai.EmbeddingRequest) int {
+		if req.Model == "voyage-3-large" {
+			return 429
+		}
+		return 0
+	}
+	resp, err := newFallbackClient(m).EmbedContext(context.Background(), []string{"hello"}, "voyage-3-large", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Metadata.HTTP == nil {
+		t.Error("Expected the details of the fallback's HTTP response")
+	}
+	want := voyageai.ResponseMetadata{RequestedModel: "voyage-3-large", ServedModel: "voyage-3.5-lite", Fallback: true, HTTP: resp.Metadata.HTTP}
+	if resp.Metadata != want || resp.Model != "voyage-3.5-lite" {
+		t.Errorf("Expected the fallback to serve the request, got %+v from %s", resp.Metadata, resp.Model)
+	}
+
+	var models []string
+	for _, req := range m.requests {
+		models = append(models, req.Model)
+	}
+	if len(models) != 3 || models[0] != "voyage-3-large" || models[1] != "voyage-3-large" || models[2] != "voyage-3.5-lite" {
+		t.Errorf("Expected the primary to exhaust its retries before the fallback, got %v", models)
+	}
+	if last := m.requests[2]; last.InputType == nil || *last.InputType != "query" {
+		t.Errorf("Expected the fallback's options to be used, got %+v", last)
+	}
+}
+
+func TestFallbackNotUsed(t *testing.T) {
+	m := newMockServer(t)
+	client := newFallbackClient(m)
+	resp, err := client.EmbedContext(context.Background(), []string{"hello"}, "voyage-3-large", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.Metadata.Fallback || resp.Metadata.ServedModel != "voyage-3-large" {
+		t.Errorf("Expected the primary to serve the request, got %+v", resp.Metadata)
+	}
+
+	// Validation errors are returned without trying the fallbacks.
+	m.fail = func(int, voyageai.EmbeddingRequest) int { return 400 }
+	before := m.requestCount()
+	if _, err := client.EmbedContext(context.Background(), []string{"hello"}, "voyage-3-large", nil); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if n := m.requestCount() - before; n != 1 {
+		t.Errorf("Expected a single request for a 400, got %d", n)
+	}
+
+	// Batched helpers never mix models.
+	m.fail = func(_ int, req voyageai.EmbeddingRequest) int {
+		if req.Model == "voyage-3-large" {
+			return 503
+		}
+		return 0
+	}
+	if _, err := client.EmbedBatch(context.Background(), []string{"a", "b"}, "voyage-3-large", nil, nil); err == nil {
+		t.Error("Expected EmbedBatch to fail without falling back")
+	}
+}
+
+func TestFallbackRerank(t *testing.T) {
+	m := newMockServer(t)
+	m.failRerank = func(_ int, req voyageai.RerankRequest) int {
+		if req.Model == "rerank-2" {
+			return 500
+		}
+		return 0
+	}
+	resp, err := newFallbackClient(m).RerankContext(context.Background(), "red", []string{"red apple", "pear"}, "rerank-2", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !resp.Metadata.Fallback || resp.Metadata.ServedModel != "rerank-2-lite" {
+		t.Errorf("Expected the rerank fallback to serve the request, got %+v", resp.Metadata)
+	}
+}
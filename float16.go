@@ -0,0 +1,152 @@
+package voyageai
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The format written by [EncodeFloat16] is, with all integers little-endian:
+//
+//	magic   [4]byte "VF16"
+//	version uint16
+//	count   uint32
+//	dim     uint32
+//	count*dim IEEE 754 half-precision values, vector by vector
+const (
+	float16Magic   = "VF16"
+	float16Version = 1
+	float16Header  = 4 + 2 + 4 + 4
+)
+
+// EncodeFloat16 serializes vecs, which must all have the same dimension, as half-precision
+// floats, halving their size. Values are rounded to the nearest half-precision value, ties to
+// even; values too large for half precision become infinities. See [DecodeFloat16].
+func EncodeFloat16(vecs [][]float32) ([]byte, error) {
+	dim := 0
+	if len(vecs) > 0 {
+		var err error
+		if dim, err = checkDims(vecs); err != nil {
+			return nil, err
+		}
+	}
+	b := make([]byte, 0, float16Header+2*len(vecs)*dim)
+	b = append(b, float16Magic...)
+	b = binary.LittleEndian.AppendUint16(b, float16Version)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(vecs)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(dim))
+	for _, v := range vecs {
+		for _, f := range v {
+			b = binary.LittleEndian.AppendUint16(b, float32ToHalf(f))
+		}
+	}
+	return b, nil
+}
+
+// DecodeFloat16 parses vectors written by [EncodeFloat16].
+func DecodeFloat16(b []byte) ([][]float32, error) {
+	if len(b) < float16Header || string(b[:4]) != float16Magic {
+		return nil, errors.New("voyage: not float16 vector data")
+	}
+	if version := binary.LittleEndian.Uint16(b[4:]); version != float16Version {
+		return nil, fmt.Errorf("voyage: unsupported float16 format version %d", version)
+	}
+	count := int(binary.LittleEndian.Uint32(b[6:]))
+	dim := int(binary.LittleEndian.Uint32(b[10:]))
+	data := b[float16Header:]
+	if uint64(count)*uint64(dim)*2 != uint64(len(data)) {
+		return nil, fmt.Errorf("voyage: float16 data holds %d bytes, expected %d vectors of dimension %d", len(data), count, dim)
+	}
+	vecs := make([][]float32, count)
+	for i := range vecs {
+		vecs[i] = make([]float32, dim)
+		for j := range vecs[i] {
+			vecs[i][j] = halfToFloat32(binary.LittleEndian.Uint16(data[2*(i*dim+j):]))
+		}
+	}
+	return vecs, nil
+}
+
+// ToFloat16Bits converts v to IEEE 754 half-precision bit patterns, rounding to nearest, ties to
+// even. Subnormals, infinities and NaNs are preserved where half precision can represent them.
+func ToFloat16Bits(v []float32) []uint16 {
+	out := make([]uint16, len(v))
+	for i, f := range v {
+		out[i] = float32ToHalf(f)
+	}
+	return out
+}
+
+// FromFloat16Bits converts IEEE 754 half-precision bit patterns to float32 values. The
+// conversion is exact.
+func FromFloat16Bits(bits []uint16) []float32 {
+	out := make([]float32, len(bits))
+	for i, h := range bits {
+		out[i] = halfToFloat32(h)
+	}
+	return out
+}
+
+func float32ToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int32(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if exp == 0xff {
+		if mant == 0 {
+			return sign | 0x7c00
+		}
+		// Keep the top payload bits, making sure the result is still a NaN.
+		return sign | 0x7c00 | uint16(mant>>13) | 0x200
+	}
+
+	e := exp - 127 + 15
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+	if e <= 0 {
+		// A half-precision subnormal: its mantissa is the value scaled by 2^24.
+		shift := uint32(14 - e)
+		if exp == 0 || shift > 25 {
+			return sign
+		}
+		return sign | uint16(roundShift(mant|0x800000, shift))
+	}
+	// A carry out of the mantissa correctly bumps the exponent, up to infinity.
+	return sign | uint16(uint32(e)<<10+roundShift(mant, 13))
+}
+
+// roundShift returns x / 2^s rounded to nearest, ties to even.
+func roundShift(x, s uint32) uint32 {
+	q := x >> s
+	rem := x & (1<<s - 1)
+	half := uint32(1) << (s - 1)
+	if rem > half || rem == half && q&1 == 1 {
+		q++
+	}
+	return q
+}
+
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Normalize the subnormal.
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
@@ -0,0 +1,132 @@
+package voyageai_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestFloat16RoundTripAllPatterns(t *testing.T) {
+	for h := range 1 << 16 {
+		f := voyageai.FromFloat16Bits([]uint16{uint16(h)})[0]
+		back := voyageai.ToFloat16Bits([]float32{f})[0]
+		if math.IsNaN(float64(f)) {
+			if back&0x7c00 != 0x7c00 || back&0x3ff == 0 || back&0x8000 != uint16(h)&0x8000 {
+				t.Errorf("Expected NaN %#04x to stay a NaN with its sign, got %#04x", h, back)
+			}
+			continue
+		}
+		if back != uint16(h) {
+			t.Errorf("Expected %#04x to round trip through %v, got %#04x", h, f, back)
+		}
+	}
+}
+
+func TestFloat16EdgeCases(t *testing.T) {
+	for _, tc := range []struct {
+		in   float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{float32(math.Copysign(0, -1)), 0x8000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{65504, 0x7bff},                            // The largest finite half.
+		{65519, 0x7bff},                            // Just below the midpoint to infinity.
+		{65520, 0x7c00},                            // The midpoint rounds to even, which is infinity.
+		{1e10, 0x7c00},                             // Overflow.
+		{float32(math.Inf(-1)), 0xfc00},            // Infinity.
+		{1 + 1.0/2048, 0x3c00},                     // A tie rounds to the even mantissa 0.
+		{1 + 3.0/2048, 0x3c02},                     // A tie rounds to the even mantissa 2.
+		{1 + 1.0/2048 + 1.0/65536, 0x3c01},         // Just above a tie rounds up.
+		{float32(math.Ldexp(1, -14)), 0x0400},      // The smallest normal.
+		{float32(math.Ldexp(1, -24)), 0x0001},      // The smallest subnormal.
+		{float32(math.Ldexp(1, -25)), 0x0000},      // A tie with zero rounds to zero.
+		{float32(math.Ldexp(1.5, -25)), 0x0001},    // Above the tie rounds up.
+		{float32(math.Ldexp(3, -25)), 0x0002},      // A tie between subnormals rounds to even.
+		{float32(math.Ldexp(1023.5, -24)), 0x0400}, // Rounding up from the largest subnormal gives the smallest normal.
+		{float32(math.Ldexp(1, -30)), 0x0000},      // Underflow.
+		{-float32(math.Ldexp(1, -30)), 0x8000},     // Underflow keeps the sign.
+		{math.SmallestNonzeroFloat32, 0x0000},      // A float32 subnormal.
+	} {
+		if got := voyageai.ToFloat16Bits([]float32{tc.in})[0]; got != tc.want {
+			t.Errorf("ToFloat16Bits(%v): expected %#04x, got %#04x", tc.in, tc.want, got)
+		}
+	}
+	if got := voyageai.ToFloat16Bits([]float32{float32(math.NaN())})[0]; got&0x7c00 != 0x7c00 || got&0x3ff == 0 {
+		t.Errorf("Expected NaN to convert to a NaN, got %#04x", got)
+	}
+	// NaNs whose payload lives only in the low bits must not become infinities.
+	if got := voyageai.ToFloat16Bits([]float32{math.Float32frombits(0x7f800001)})[0]; got&0x3ff == 0 {
+		t.Errorf("Expected a NaN, got %#04x", got)
+	}
+}
+
+func TestEncodeFloat16(t *testing.T) {
+	vecs := [][]float32{{1, 0.5, -3}, {65504, 0, 0.25}}
+	b, err := voyageai.EncodeFloat16(vecs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(b) != 14+2*6 {
+		t.Errorf("Expected a 14 byte header and 2 bytes per value, got %d bytes", len(b))
+	}
+	got, err := voyageai.DecodeFloat16(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(got, vecs) {
+		t.Errorf("Expected %v, got %v", vecs, got)
+	}
+
+	if _, err := voyageai.DecodeFloat16(b[:len(b)-1]); err == nil {
+		t.Error("Expected an error for truncated data")
+	}
+	if _, err := voyageai.EncodeFloat16([][]float32{{1}, {1, 2}}); err == nil {
+		t.Error("Expected an error for mismatched dimensions")
+	}
+	if b, err := voyageai.EncodeFloat16(nil); err != nil || len(b) != 14 {
+		t.Errorf("Expected an empty encoding, got %d bytes, %v", len(b), err)
+	}
+}
+
+func TestFloat16PreservesRanking(t *testing.T) {
+	rng := rand.New(rand.NewPCG(12, 0))
+	vecs := make([][]float32, 200)
+	for i := range vecs {
+		vecs[i] = randomVector(rng, 256)
+	}
+	b, _ := voyageai.EncodeFloat16(vecs)
+	halves, err := voyageai.DecodeFloat16(b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	index := func(vecs [][]float32) *voyageai.VectorIndex {
+		idx := voyageai.NewVectorIndex(256, voyageai.MetricCosine)
+		for i, v := range vecs {
+			idx.Add(fmt.Sprint(i), v, nil)
+		}
+		return idx
+	}
+	exact, approx := index(vecs), index(halves)
+	ids := func(hits []voyageai.Hit) []string {
+		var out []string
+		for _, h := range hits {
+			out = append(out, h.ID)
+		}
+		return out
+	}
+	for range 20 {
+		q := randomVector(rng, 256)
+		want, _ := exact.Search(q, 10)
+		got, _ := approx.Search(q, 10)
+		if !reflect.DeepEqual(ids(got), ids(want)) {
+			t.Errorf("Expected the top 10 to be preserved, got %v and %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package voyageai
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The default RRF rank constant of [FuseScores].
+const DefaultRRFK = 60
+
+// An item and its score in a ranking.
+type ScoredID struct {
+	ID    string
+	Score float64
+}
+
+// How [FuseScores] combines two rankings.
+type FusionMethod int
+
+const (
+	// Reciprocal rank fusion: each list contributes weight/(K+rank) for the items it contains,
+	// where rank starts at 1 for its highest score. Only the order of each list matters.
+	FusionRRF FusionMethod = iota
+	// Each list's scores are min-max normalized to [0, 1] and the fused score is their weighted sum.
+	// A list whose scores are all equal, including a list of one, normalizes them all to 1.
+	FusionWeightedSum
+)
+
+// Options for [FuseScores].
+type FusionOpts struct {
+	Method FusionMethod
+	// The weights of the dense and lexical lists, in that order. Both default to 1 if both are zero.
+	Weights [2]float64
+	// The RRF rank constant. Defaults to [DefaultRRFK].
+	K int
+	// Drop items that are missing from either list instead of scoring them from the list they
+	// appear in.
+	RequireBoth bool
+	// For [FusionWeightedSum], the normalized score used for an item missing from a list.
+	// Defaults to 0, the score of the lowest ranked item. Missing items contribute nothing to RRF.
+	MissingScore float64
+}
+
+// FuseScores merges a dense (embedding) ranking and a lexical ranking of the same collection,
+// aligning items by ID, and returns the fused ranking with the highest score first. Ties are
+// broken by ID. The inputs need not be sorted. It returns an error if either list contains an
+// ID twice.
+func FuseScores(dense, lexical []ScoredID, opts FusionOpts) ([]ScoredID, error) {
+	weights := opts.Weights
+	if weights == [2]float64{} {
+		weights = [2]float64{1, 1}
+	}
+	if weights[0] < 0 || weights[1] < 0 {
+		return nil, fmt.Errorf("voyage: fusion weights must not be negative, got %v", weights)
+	}
+	k := opts.K
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	lists := [2][]ScoredID{dense, lexical}
+	var contrib [2]map[string]float64
+	for l, list := range lists {
+		contrib[l] = make(map[string]float64, len(list))
+		var scores []float64
+		switch opts.Method {
+		case FusionRRF:
+			scores = make([]float64, len(list))
+			for rank, i := range rankOrder(list) {
+				scores[i] = 1 / float64(k+rank+1)
+			}
+		case FusionWeightedSum:
+			scores = minMax64(list)
+		default:
+			return nil, fmt.Errorf("voyage: unknown fusion method %d", opts.Method)
+		}
+		for i, item := range list {
+			if _, dup := contrib[l][item.ID]; dup {
+				return nil, fmt.Errorf("voyage: duplicate id %q in fusion input %d", item.ID, l)
+			}
+			contrib[l][item.ID] = weights[l] * scores[i]
+		}
+	}
+
+	var fused []ScoredID
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, item := range list {
+			if seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			total := 0.0
+			missing := false
+			for l := range lists {
+				s, ok := contrib[l][item.ID]
+				if !ok {
+					missing = true
+					if opts.Method == FusionWeightedSum {
+						s = weights[l] * opts.MissingScore
+					}
+				}
+				total += s
+			}
+			if missing && opts.RequireBoth {
+				continue
+			}
+			fused = append(fused, ScoredID{ID: item.ID, Score: total})
+		}
+	}
+	sortScored(fused)
+	return fused, nil
+}
+
+// rankOrder returns the indices of list from highest to lowest score, ties broken by ID.
+func rankOrder(list []ScoredID) []int {
+	idx := make([]int, len(list))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		x, y := list[idx[a]], list[idx[b]]
+		if x.Score != y.Score {
+			return x.Score > y.Score
+		}
+		return x.ID < y.ID
+	})
+	return idx
+}
+
+// minMax64 returns the scores of list rescaled to [0, 1]. All-equal scores become 1.
+func minMax64(list []ScoredID) []float64 {
+	out := make([]float64, len(list))
+	if len(list) == 0 {
+		return out
+	}
+	lo, hi := list[0].Score, list[0].Score
+	for _, item := range list {
+		lo, hi = min(lo, item.Score), max(hi, item.Score)
+	}
+	for i, item := range list {
+		if hi == lo {
+			out[i] = 1
+		} else {
+			out[i] = (item.Score - lo) / (hi - lo)
+		}
+	}
+	return out
+}
+
+// sortScored sorts items by descending score, ties broken by ID.
+func sortScored(items []ScoredID) {
+	sort.Slice(items, func(a, b int) bool {
+		if items[a].Score != items[b].Score {
+			return items[a].Score > items[b].Score
+		}
+		return items[a].ID < items[b].ID
+	})
+}
@@ -0,0 +1,99 @@
+package voyageai_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func expectRanking(t *testing.T, got []voyageai.ScoredID, want []voyageai.ScoredID) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || math.Abs(got[i].Score-want[i].Score) > 1e-9 {
+			t.Errorf("Position %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestFuseScoresRRF(t *testing.T) {
+	dense := []voyageai.ScoredID{{"a", 0.9}, {"b", 0.8}, {"c", 0.1}}
+	lexical := []voyageai.ScoredID{{"c", 12}, {"a", 7}, {"d", 3}}
+
+	got, err := voyageai.FuseScores(dense, lexical, voyageai.FusionOpts{Method: voyageai.FusionRRF, K: 10})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// a: 1/11 + 1/12, c: 1/13 + 1/11, b: 1/12, d: 1/13.
+	expectRanking(t, got, []voyageai.ScoredID{
+		{"a", 1.0/11 + 1.0/12},
+		{"c", 1.0/13 + 1.0/11},
+		{"b", 1.0 / 12},
+		{"d", 1.0 / 13},
+	})
+
+	got, _ = voyageai.FuseScores(dense, lexical, voyageai.FusionOpts{Method: voyageai.FusionRRF, K: 10, RequireBoth: true})
+	expectRanking(t, got, []voyageai.ScoredID{{"a", 1.0/11 + 1.0/12}, {"c", 1.0/13 + 1.0/11}})
+
+	got, _ = voyageai.FuseScores(dense, lexical, voyageai.FusionOpts{Method: voyageai.FusionRRF, K: 10, Weights: [2]float64{2, 0}})
+	expectRanking(t, got, []voyageai.ScoredID{{"a", 2.0 / 11}, {"b", 2.0 / 12}, {"c", 2.0 / 13}, {"d", 0}})
+}
+
+func TestFuseScoresWeightedSum(t *testing.T) {
+	dense := []voyageai.ScoredID{{"a", 0.9}, {"b", 0.5}, {"c", 0.1}}
+	lexical := []voyageai.ScoredID{{"c", 10}, {"a", 5}, {"d", 0}}
+	opts := voyageai.FusionOpts{Method: voyageai.FusionWeightedSum, Weights: [2]float64{0.7, 0.3}}
+
+	got, err := voyageai.FuseScores(dense, lexical, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Normalized dense: a 1, b 0.5, c 0; lexical: c 1, a 0.5, d 0.
+	expectRanking(t, got, []voyageai.ScoredID{
+		{"a", 0.7 + 0.15},
+		{"b", 0.35},
+		{"c", 0.3},
+		{"d", 0},
+	})
+
+	opts.MissingScore = 0.5
+	got, _ = voyageai.FuseScores(dense, lexical, opts)
+	expectRanking(t, got, []voyageai.ScoredID{
+		{"a", 0.85},
+		{"b", 0.35 + 0.15},
+		{"d", 0.35},
+		{"c", 0.3},
+	})
+}
+
+func TestFuseScoresDisjointAndTies(t *testing.T) {
+	dense := []voyageai.ScoredID{{"y", 1}, {"x", 1}}
+	lexical := []voyageai.ScoredID{{"z", 4}}
+	got, err := voyageai.FuseScores(dense, lexical, voyageai.FusionOpts{Method: voyageai.FusionWeightedSum})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Equal scores normalize to 1 and ties are broken by ID.
+	expectRanking(t, got, []voyageai.ScoredID{{"x", 1}, {"y", 1}, {"z", 1}})
+
+	got, _ = voyageai.FuseScores(dense, lexical, voyageai.FusionOpts{Method: voyageai.FusionRRF, RequireBoth: true})
+	if len(got) != 0 {
+		t.Errorf("Expected no items in both lists, got %v", got)
+	}
+}
+
+func TestFuseScoresErrors(t *testing.T) {
+	dup := []voyageai.ScoredID{{"a", 1}, {"a", 2}}
+	if _, err := voyageai.FuseScores(dup, nil, voyageai.FusionOpts{}); err == nil {
+		t.Error("Expected an error for duplicate ids")
+	}
+	if _, err := voyageai.FuseScores(nil, nil, voyageai.FusionOpts{Method: 9}); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+	if _, err := voyageai.FuseScores(nil, nil, voyageai.FusionOpts{Weights: [2]float64{-1, 1}}); err == nil {
+		t.Error("Expected an error for a negative weight")
+	}
+}
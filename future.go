@@ -0,0 +1,75 @@
+package voyageai
+
+import "context"
+
+// The eventual result of an asynchronous call such as [VoyageClient.EmbedAsync].
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// runAsync calls fn with ctx in a new goroutine and returns a [Future] for its result.
+func runAsync[T any](ctx context.Context, fn func(context.Context) (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.val, f.err = fn(ctx)
+	}()
+	return f
+}
+
+// Done returns a channel that is closed when the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the result is available or ctx is done. Giving up on waiting does not
+// cancel the call; cancel the context the call was started with for that.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// TryGet returns the result without blocking. ok is false if the call has not completed yet.
+func (f *Future[T]) TryGet() (val T, ok bool, err error) {
+	select {
+	case <-f.done:
+		return f.val, true, f.err
+	default:
+		var zero T
+		return zero, false, nil
+	}
+}
+
+// EmbedAsync starts [VoyageClient.EmbedContext] in the background and returns a [Future] for its
+// result. The call is bound to ctx: cancelling it aborts the request, or prevents it from being
+// sent if it is still waiting for one of the client's request slots (see
+// [VoyageClientOpts.MaxConcurrentRequests]), so an abandoned future does not leak a goroutine
+// beyond ctx's lifetime.
+func (c *VoyageClient) EmbedAsync(ctx context.Context, texts []string, model string, opts *EmbeddingRequestOpts) *Future[*EmbeddingResponse] {
+	return runAsync(ctx, func(ctx context.Context) (*EmbeddingResponse, error) {
+		return c.EmbedContext(ctx, texts, model, opts)
+	})
+}
+
+// MultimodalEmbedAsync starts [VoyageClient.MultimodalEmbedContext] in the background and returns
+// a [Future] for its result. See [VoyageClient.EmbedAsync].
+func (c *VoyageClient) MultimodalEmbedAsync(ctx context.Context, inputs []MultimodalContent, model string, opts *MultimodalRequestOpts) *Future[*EmbeddingResponse] {
+	return runAsync(ctx, func(ctx context.Context) (*EmbeddingResponse, error) {
+		return c.MultimodalEmbedContext(ctx, inputs, model, opts)
+	})
+}
+
+// RerankAsync starts [VoyageClient.RerankContext] in the background and returns a [Future] for its
+// result. See [VoyageClient.EmbedAsync].
+func (c *VoyageClient) RerankAsync(ctx context.Context, query string, documents []string, model string, opts *RerankRequestOpts) *Future[*RerankResponse] {
+	return runAsync(ctx, func(ctx context.Context) (*RerankResponse, error) {
+		return c.RerankContext(ctx, query, documents, model, opts)
+	})
+}
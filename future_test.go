@@ -0,0 +1,82 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedAsyncCompletes(t *testing.T) {
+	srv := newMockServer(t)
+	f := srv.client().EmbedAsync(context.Background(), []string{"hello"}, "test-model", nil)
+
+	<-f.Done()
+	resp, ok, err := f.TryGet()
+	if !ok || err != nil {
+		t.Fatalf("Expected a completed result, got ok=%v err=%v", ok, err)
+	}
+	if !slices.Equal(resp.Data[0].Embedding, fakeVector("hello")) {
+		t.Error("Wrong embedding")
+	}
+
+	resp2, err := f.Wait(context.Background())
+	if err != nil || resp2 != resp {
+		t.Error("Expected Wait to return the same result")
+	}
+}
+
+func TestFutureWaitTimeout(t *testing.T) {
+	srv := newMockServer(t)
+	release := make(chan struct{})
+	srv.fail = func(int, voyageai.EmbeddingRequest) int {
+		<-release
+		return 0
+	}
+	defer close(release)
+
+	f := srv.client().EmbedAsync(context.Background(), []string{"slow"}, "test-model", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := f.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to time out, got %v", err)
+	}
+	if _, ok, _ := f.TryGet(); ok {
+		t.Error("Expected the call to still be running")
+	}
+}
+
+func TestEmbedAsyncCancelledBeforeStart(t *testing.T) {
+	srv := newMockServer(t)
+	release := make(chan struct{})
+	srv.fail = func(int, voyageai.EmbeddingRequest) int {
+		<-release
+		return 0
+	}
+
+	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxConcurrentRequests: 1})
+	first := cl.EmbedAsync(context.Background(), []string{"first"}, "test-model", nil)
+	for srv.requestCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	second := cl.RerankAsync(ctx, "query", []string{"doc"}, "test-model", nil)
+	cancel()
+
+	if _, err := second.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the queued call to be cancelled, got %v", err)
+	}
+
+	close(release)
+	if _, err := first.Wait(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := srv.requestCount(); n != 1 {
+		t.Errorf("Expected the cancelled call never to reach the server, got %d requests", n)
+	}
+}
@@ -0,0 +1,231 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for [HealthOpts].
+const (
+	DefaultHealthWindow        = 50
+	DefaultHealthFailureStreak = 5
+	DefaultHealthErrorRate     = 0.5
+	DefaultHealthMinRequests   = 10
+	DefaultHealthRecovery      = 3
+)
+
+// Configures how a client derives its health from the outcomes of recent requests. See
+// [VoyageClient.IsHealthy].
+//
+// Each endpoint is tracked separately over its last Window requests, counting a request once
+// its retries are done. Rate limit, server and network errors count as failures. Other errors,
+// such as validation errors, are counted as hard failures but do not affect health, since they
+// say nothing about the API; cancelled calls are ignored.
+//
+// A healthy endpoint becomes unhealthy after FailureStreak consecutive failures, or once at least
+// MinRequests requests are in the window and their error rate reaches ErrorRate. An unhealthy
+// endpoint recovers after RecoverySuccesses consecutive successes.
+type HealthOpts struct {
+	Window            int     // Defaults to [DefaultHealthWindow].
+	FailureStreak     int     // Defaults to [DefaultHealthFailureStreak].
+	ErrorRate         float64 // Defaults to [DefaultHealthErrorRate].
+	MinRequests       int     // Defaults to [DefaultHealthMinRequests].
+	RecoverySuccesses int     // Defaults to [DefaultHealthRecovery].
+}
+
+func (o HealthOpts) withDefaults() HealthOpts {
+	if o.Window <= 0 {
+		o.Window = DefaultHealthWindow
+	}
+	if o.FailureStreak <= 0 {
+		o.FailureStreak = DefaultHealthFailureStreak
+	}
+	if o.ErrorRate <= 0 {
+		o.ErrorRate = DefaultHealthErrorRate
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = DefaultHealthMinRequests
+	}
+	if o.RecoverySuccesses <= 0 {
+		o.RecoverySuccesses = DefaultHealthRecovery
+	}
+	return o
+}
+
+// The health of one endpoint. See [VoyageClient.Health].
+type EndpointHealth struct {
+	Healthy       bool
+	FailureStreak int           // The number of consecutive failures up to the latest request.
+	SuccessStreak int           // The number of consecutive successes up to the latest request.
+	Requests      int           // The number of requests in the window.
+	Failures      int           // The number of failures in the window.
+	HardFailures  int           // The number of hard failures in the window.
+	ErrorRate     float64       // Failures divided by the requests in the window that were not hard failures.
+	MeanLatency   time.Duration // The mean duration of the requests in the window.
+	LastError     error         // The error of the latest failed or hard-failed request.
+	LastErrorAt   time.Time
+	LastSuccessAt time.Time
+}
+
+// The health of a client. See [VoyageClient.Health].
+type ClientHealth struct {
+	Healthy   bool                      // Whether every endpoint is healthy.
+	Endpoints map[string]EndpointHealth // Keyed by endpoint, such as "embeddings". Endpoints without requests are omitted.
+}
+
+type outcome uint8
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeHardFailure
+)
+
+type healthSample struct {
+	outcome outcome
+	latency time.Duration
+}
+
+// endpointHealth tracks one endpoint. Updates take a short lock; the healthy flag is read
+// without one.
+type endpointHealth struct {
+	unhealthy atomic.Bool
+
+	mu            sync.Mutex
+	window        []healthSample // A ring buffer of the latest samples.
+	next          int
+	counts        [3]int
+	latency       time.Duration // The sum of the latencies in the window.
+	failureStreak int
+	successStreak int
+	lastErr       error
+	lastErrAt     time.Time
+	lastSuccessAt time.Time
+}
+
+// healthTracker tracks the health of every endpoint of a client.
+type healthTracker struct {
+	opts      HealthOpts
+	endpoints sync.Map // Of endpoint name to *endpointHealth.
+	unhealthy atomic.Int32
+}
+
+func newHealthTracker(opts *HealthOpts) *healthTracker {
+	if opts == nil {
+		opts = &HealthOpts{}
+	}
+	return &healthTracker{opts: opts.withDefaults()}
+}
+
+// record adds the outcome of a request to endpoint that finished at now.
+func (t *healthTracker) record(ctx context.Context, endpoint string, err error, latency time.Duration, now time.Time) {
+	var o outcome
+	switch {
+	case err == nil:
+		o = outcomeSuccess
+	case ctx.Err() != nil:
+		return
+	case isOverloaded(err) || isNetError(err):
+		o = outcomeFailure
+	default:
+		o = outcomeHardFailure
+	}
+
+	v, ok := t.endpoints.Load(endpoint)
+	if !ok {
+		v, _ = t.endpoints.LoadOrStore(endpoint, &endpointHealth{window: make([]healthSample, 0, t.opts.Window)})
+	}
+	e := v.(*endpointHealth)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sample := healthSample{outcome: o, latency: latency}
+	if len(e.window) < t.opts.Window {
+		e.window = append(e.window, sample)
+	} else {
+		old := e.window[e.next]
+		e.counts[old.outcome]--
+		e.latency -= old.latency
+		e.window[e.next] = sample
+		e.next = (e.next + 1) % t.opts.Window
+	}
+	e.counts[o]++
+	e.latency += latency
+
+	switch o {
+	case outcomeSuccess:
+		e.successStreak++
+		e.failureStreak = 0
+		e.lastSuccessAt = now
+	case outcomeFailure:
+		e.failureStreak++
+		e.successStreak = 0
+		e.lastErr, e.lastErrAt = err, now
+	case outcomeHardFailure:
+		e.lastErr, e.lastErrAt = err, now
+	}
+
+	wasUnhealthy := e.unhealthy.Load()
+	unhealthy := wasUnhealthy
+	if wasUnhealthy {
+		unhealthy = e.successStreak < t.opts.RecoverySuccesses
+	} else {
+		judged := e.counts[outcomeSuccess] + e.counts[outcomeFailure]
+		unhealthy = e.failureStreak >= t.opts.FailureStreak ||
+			judged >= t.opts.MinRequests && float64(e.counts[outcomeFailure]) >= t.opts.ErrorRate*float64(judged)
+	}
+	if unhealthy != wasUnhealthy {
+		e.unhealthy.Store(unhealthy)
+		if unhealthy {
+			t.unhealthy.Add(1)
+		} else {
+			t.unhealthy.Add(-1)
+		}
+	}
+}
+
+func isNetError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsHealthy reports whether every endpoint the client has used is healthy, as judged from the
+// outcomes of its recent requests. See [HealthOpts]. It is cheap enough to call on every request.
+func (c *VoyageClient) IsHealthy() bool {
+	return c.health.unhealthy.Load() == 0
+}
+
+// Health returns the health of each endpoint the client has used.
+func (c *VoyageClient) Health() ClientHealth {
+	h := ClientHealth{Healthy: true, Endpoints: map[string]EndpointHealth{}}
+	c.health.endpoints.Range(func(k, v any) bool {
+		e := v.(*endpointHealth)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		eh := EndpointHealth{
+			Healthy:       !e.unhealthy.Load(),
+			FailureStreak: e.failureStreak,
+			SuccessStreak: e.successStreak,
+			Requests:      len(e.window),
+			Failures:      e.counts[outcomeFailure],
+			HardFailures:  e.counts[outcomeHardFailure],
+			LastError:     e.lastErr,
+			LastErrorAt:   e.lastErrAt,
+			LastSuccessAt: e.lastSuccessAt,
+		}
+		if judged := e.counts[outcomeSuccess] + e.counts[outcomeFailure]; judged > 0 {
+			eh.ErrorRate = float64(eh.Failures) / float64(judged)
+		}
+		if eh.Requests > 0 {
+			eh.MeanLatency = e.latency / time.Duration(eh.Requests)
+		}
+		h.Endpoints[k.(string)] = eh
+		h.Healthy = h.Healthy && eh.Healthy
+		return true
+	})
+	return h
+}
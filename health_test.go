@@ -0,0 +1,96 @@
+package voyageai_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestHealthTransitions(t *testing.T) {
+	srv := newMockServer(t)
+	status := 0
+	srv.fail = func(int, voyageai.EmbeddingRequest) int { return status }
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Health:  &voyageai.HealthOpts{FailureStreak: 3, RecoverySuccesses: 2, MinRequests: 100},
+	})
+	embed := func(code int) {
+		status = code
+		client.EmbedContext(context.Background(), []string{"x"}, "test-model", nil)
+	}
+
+	if !client.IsHealthy() {
+		t.Error("Expected a new client to be healthy")
+	}
+	embed(0)
+	embed(503)
+	embed(429)
+	if !client.IsHealthy() {
+		t.Error("Expected two failures to leave the client healthy")
+	}
+	// Hard failures neither break nor extend the streak.
+	embed(400)
+	embed(500)
+	if client.IsHealthy() {
+		t.Error("Expected the client to be unhealthy after three failures")
+	}
+
+	h := client.Health().Endpoints["embeddings"]
+	if h.Healthy || h.FailureStreak != 3 || h.Requests != 5 || h.Failures != 3 || h.HardFailures != 1 || h.ErrorRate != 0.75 {
+		t.Errorf("Unexpected health %+v", h)
+	}
+	if h.LastError == nil || h.LastSuccessAt.IsZero() {
+		t.Errorf("Expected the last error and success to be recorded, got %+v", h)
+	}
+
+	embed(0)
+	if client.IsHealthy() {
+		t.Error("Expected one success not to be enough to recover")
+	}
+	embed(0)
+	if !client.IsHealthy() || !client.Health().Healthy {
+		t.Error("Expected the client to recover after two successes")
+	}
+}
+
+func TestHealthErrorRate(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, _ voyageai.EmbeddingRequest) int {
+		if n%2 == 0 {
+			return 500
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Health:  &voyageai.HealthOpts{Window: 6, ErrorRate: 0.5, MinRequests: 6},
+	})
+	for i := range 6 {
+		client.EmbedContext(context.Background(), []string{"x"}, "test-model", nil)
+		if i < 5 && !client.IsHealthy() {
+			t.Fatalf("Expected the client to stay healthy below MinRequests, after %d requests", i+1)
+		}
+	}
+	if client.IsHealthy() {
+		t.Error("Expected a 50% error rate to make the client unhealthy")
+	}
+
+	// Other endpoints are tracked separately.
+	if _, err := client.RerankContext(context.Background(), "q", []string{"d"}, "rerank-2", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	health := client.Health()
+	if health.Healthy || !health.Endpoints["rerank"].Healthy || health.Endpoints["embeddings"].Healthy {
+		t.Errorf("Unexpected health %+v", health)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.RerankContext(ctx, "q", []string{"d"}, "rerank-2", nil)
+	if n := client.Health().Endpoints["rerank"].Requests; n != 1 {
+		t.Errorf("Expected cancelled calls to be ignored, got %d requests", n)
+	}
+}
@@ -0,0 +1,56 @@
+package voyageai
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Details of the HTTP response a call read its results from. See [ResponseMetadata.HTTP].
+type HTTPResponse struct {
+	StatusCode int
+	Header     http.Header
+	RequestID  string // The request ID reported by the API, to quote in support requests.
+	// The rate limit state reported by the response's headers.
+	RateLimit RateLimitHeaders
+}
+
+// The rate limit state an API response reports in its X-RateLimit-* headers, such as
+// X-RateLimit-Remaining-Tokens. Fields whose header is missing or malformed are zero.
+type RateLimitHeaders struct {
+	LimitRequests     int           // The requests allowed per window.
+	RemainingRequests int           // The requests left in the current window.
+	ResetRequests     time.Duration // How long until the request limit resets.
+	LimitTokens       int           // The tokens allowed per window.
+	RemainingTokens   int           // The tokens left in the current window.
+	ResetTokens       time.Duration // How long until the token limit resets.
+}
+
+// newHTTPResponse returns the details of a response with the given status and headers.
+func newHTTPResponse(status int, h http.Header) *HTTPResponse {
+	count := func(name string) int {
+		n, _ := strconv.Atoi(h.Get(name))
+		return n
+	}
+	reset := func(name string) time.Duration {
+		v := h.Get(name)
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		secs, _ := strconv.ParseFloat(v, 64)
+		return time.Duration(secs * float64(time.Second))
+	}
+	return &HTTPResponse{
+		StatusCode: status,
+		Header:     h,
+		RequestID:  requestID(h),
+		RateLimit: RateLimitHeaders{
+			LimitRequests:     count("X-RateLimit-Limit-Requests"),
+			RemainingRequests: count("X-RateLimit-Remaining-Requests"),
+			ResetRequests:     reset("X-RateLimit-Reset-Requests"),
+			LimitTokens:       count("X-RateLimit-Limit-Tokens"),
+			RemainingTokens:   count("X-RateLimit-Remaining-Tokens"),
+			ResetTokens:       reset("X-RateLimit-Reset-Tokens"),
+		},
+	}
+}
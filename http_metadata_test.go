@@ -0,0 +1,51 @@
+package voyageai_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestResponseHTTPMetadata(t *testing.T) {
+	api := newMockServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Tokens", "3000000")
+		w.Header().Set("X-RateLimit-Remaining-Tokens", "2999990")
+		w.Header().Set("X-RateLimit-Reset-Tokens", "6m0s")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "1999")
+		w.Header().Set("X-RateLimit-Reset-Requests", "0.5")
+		api.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+
+	resp, err := client.Embed([]string{"a"}, "voyage-3.5", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	h := resp.Metadata.HTTP
+	if h == nil || h.StatusCode != http.StatusOK || h.RequestID != "req-1" || h.Header.Get("Content-Type") == "" {
+		t.Fatalf("Expected the HTTP response details, got %+v", h)
+	}
+	want := voyageai.RateLimitHeaders{
+		LimitTokens:       3000000,
+		RemainingTokens:   2999990,
+		ResetTokens:       6 * time.Minute,
+		RemainingRequests: 1999,
+		ResetRequests:     500 * time.Millisecond,
+	}
+	if h.RateLimit != want {
+		t.Errorf("Expected rate limits %+v, got %+v", want, h.RateLimit)
+	}
+
+	rr, err := client.Rerank("q", []string{"a", "b"}, voyageai.ModelRerank2Lite, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rr.Metadata.HTTP == nil || rr.Metadata.HTTP.RequestID != "req-2" {
+		t.Errorf("Expected the rerank response's request ID, got %+v", rr.Metadata.HTTP)
+	}
+}
@@ -0,0 +1,37 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Returned when no bytes of a response body arrive for [VoyageClientOpts.IdleReadTimeout]. Stalled
+// requests are retried like server errors.
+var ErrResponseStalled = errors.New("voyage: response stalled")
+
+// idleReader cancels its request with [ErrResponseStalled] once a read has waited longer than the
+// idle timeout for bytes. Every read that returns bytes restarts the wait.
+type idleReader struct {
+	r     io.Reader
+	idle  time.Duration
+	timer *time.Timer
+}
+
+func watchIdle(r io.Reader, idle time.Duration, cancel context.CancelCauseFunc) *idleReader {
+	return &idleReader{r: r, idle: idle, timer: time.AfterFunc(idle, func() { cancel(ErrResponseStalled) })}
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.idle)
+	}
+	return n, err
+}
+
+// stop disarms the watchdog once the body has been read.
+func (r *idleReader) stop() {
+	r.timer.Stop()
+}
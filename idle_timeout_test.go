@@ -0,0 +1,74 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+const stallResponse = `{"object":"list","data":[{"object":"embedding","embedding":[0.5],"index":0}],"model":"voyage-3.5","usage":{"total_tokens":1}}`
+
+// newStallServer serves stallResponse in pieces, waiting gap between them. The first stalls
+// attempts stop sending halfway through the body until the client gives up.
+func newStallServer(t *testing.T, stalls int, gap time.Duration) (*httptest.Server, *atomic.Int32) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := attempts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < len(stallResponse); i += 20 {
+			if n <= int32(stalls) && i >= len(stallResponse)/2 {
+				<-r.Context().Done()
+				return
+			}
+			w.Write([]byte(stallResponse[i:min(i+20, len(stallResponse))]))
+			w.(http.Flusher).Flush()
+			time.Sleep(gap)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &attempts
+}
+
+func TestIdleReadTimeout(t *testing.T) {
+	srv, _ := newStallServer(t, 1, 0)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, IdleReadTimeout: 100 * time.Millisecond})
+	start := time.Now()
+	_, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+	if !errors.Is(err, voyageai.ErrResponseStalled) {
+		t.Fatalf("Expected ErrResponseStalled, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected the stall to be detected promptly, took %v", d)
+	}
+
+	// A stalled attempt is retried.
+	srv, attempts := newStallServer(t, 1, 0)
+	client = voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, IdleReadTimeout: 100 * time.Millisecond, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+	resp, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if attempts.Load() != 2 || len(resp.Data) != 1 {
+		t.Errorf("Expected a second attempt to succeed, got %d attempts and %+v", attempts.Load(), resp)
+	}
+}
+
+func TestIdleReadTimeoutSlowResponse(t *testing.T) {
+	// The body takes about 250ms to arrive, but never pauses for longer than 50ms.
+	srv, attempts := newStallServer(t, 0, 50*time.Millisecond)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, IdleReadTimeout: 150 * time.Millisecond})
+	resp, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if attempts.Load() != 1 || len(resp.Data) != 1 || resp.Data[0].Embedding[0] != 0.5 {
+		t.Errorf("Expected a single successful attempt, got %d attempts and %+v", attempts.Load(), resp)
+	}
+}
@@ -0,0 +1,132 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for [ImageURLValidation].
+const (
+	DefaultImageCheckConcurrency = 4
+	DefaultImageCheckTimeout     = 5 * time.Second
+	DefaultMaxImageBytes         = 20 << 20
+)
+
+// Configures the pre-flight checks of image URLs. See [VoyageClientOpts.ValidateImageURLs].
+type ImageURLValidation struct {
+	Concurrency int           // The maximum number of URLs checked at once. Defaults to [DefaultImageCheckConcurrency].
+	Timeout     time.Duration // The time allowed for each check. Defaults to [DefaultImageCheckTimeout].
+	// The largest acceptable image, according to its Content-Length. Images of unknown length
+	// are accepted. Defaults to [DefaultMaxImageBytes].
+	MaxBytes int64
+}
+
+// An image URL that failed a pre-flight check.
+type ImageURLFailure struct {
+	URL    string
+	Reason string
+}
+
+// Returned when image URLs fail their pre-flight checks. See [VoyageClient.ValidateImageURLs].
+type ImageURLError struct {
+	Failures []ImageURLFailure // In the order the URLs first appear in the inputs.
+}
+
+func (e *ImageURLError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "voyage: %d image URL(s) failed validation:", len(e.Failures))
+	for _, f := range e.Failures {
+		fmt.Fprintf(&b, " %s: %s;", f.URL, f.Reason)
+	}
+	return strings.TrimSuffix(b.String(), ";")
+}
+
+// ValidateImageURLs sends a HEAD request to every distinct image URL in inputs and checks that it
+// answers with a 2xx status, an image/* content type and an acceptable size, as configured by
+// [VoyageClientOpts.ValidateImageURLs] or the defaults if that is nil. The requests use the
+// client's HTTP client but not its API key. It returns an [*ImageURLError] listing every failing
+// URL.
+func (c *VoyageClient) ValidateImageURLs(ctx context.Context, inputs []MultimodalContent) error {
+	cfg := ImageURLValidation{}
+	if c.opts.ValidateImageURLs != nil {
+		cfg = *c.opts.ValidateImageURLs
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultImageCheckConcurrency
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultImageCheckTimeout
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultMaxImageBytes
+	}
+
+	var urls []string
+	seen := map[string]bool{}
+	for _, in := range inputs {
+		for _, part := range in.Content {
+			if u := string(part.ImageURL); part.Type == "image_url" && !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	reasons := make([]string, len(urls))
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reasons[i] = c.checkImageURL(ctx, u, cfg)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var failed ImageURLError
+	for i, reason := range reasons {
+		if reason != "" {
+			failed.Failures = append(failed.Failures, ImageURLFailure{URL: urls[i], Reason: reason})
+		}
+	}
+	if len(failed.Failures) > 0 {
+		return &failed
+	}
+	return nil
+}
+
+// checkImageURL returns why the image at url is not acceptable, or "" if it is.
+func (c *VoyageClient) checkImageURL(ctx context.Context, url string, cfg ImageURLValidation) string {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err.Error()
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "status " + resp.Status
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		return fmt.Sprintf("content type %q is not an image", ct)
+	}
+	if resp.ContentLength > cfg.MaxBytes {
+		return fmt.Sprintf("size %d bytes exceeds %d", resp.ContentLength, cfg.MaxBytes)
+	}
+	return ""
+}
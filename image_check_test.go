@@ -0,0 +1,87 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func newImageServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/good.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("/huge.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", "50000000")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func imageInputs(urls ...string) []voyageai.MultimodalContent {
+	var inputs []voyageai.MultimodalContent
+	for _, u := range urls {
+		inputs = append(inputs, voyageai.MultimodalContent{Content: []voyageai.MultimodalInput{
+			voyageai.Multimodal(voyageai.Text("caption")),
+			voyageai.Multimodal(voyageai.ImageURL(u)),
+		}})
+	}
+	return inputs
+}
+
+func TestValidateImageURLs(t *testing.T) {
+	images := newImageServer(t)
+	api := newMockServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:               "APIKEY",
+		BaseURL:           api.URL,
+		ValidateImageURLs: &voyageai.ImageURLValidation{},
+	})
+
+	inputs := imageInputs(images.URL+"/good.png", images.URL+"/missing", images.URL+"/page", images.URL+"/huge.jpg", images.URL+"/good.png")
+	_, err := client.MultimodalEmbed(inputs, "voyage-multimodal-3", nil)
+	var urlErr *voyageai.ImageURLError
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("Expected an ImageURLError, got %v", err)
+	}
+	want := []string{images.URL + "/missing", images.URL + "/page", images.URL + "/huge.jpg"}
+	if len(urlErr.Failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %+v", len(want), urlErr.Failures)
+	}
+	for i, f := range urlErr.Failures {
+		if f.URL != want[i] || f.Reason == "" {
+			t.Errorf("Failure %d: expected %s with a reason, got %+v", i, want[i], f)
+		}
+	}
+	if len(api.multimodalRequests()) != 0 {
+		t.Error("Expected no API request after a failed check")
+	}
+
+	if _, err := client.MultimodalEmbed(imageInputs(images.URL+"/good.png"), "voyage-multimodal-3", nil); err != nil {
+		t.Errorf("Expected a valid URL to pass, got %v", err)
+	}
+	if _, err := client.MultimodalEmbed(inputs, "voyage-multimodal-3", &voyageai.MultimodalRequestOpts{SkipImageURLValidation: true}); err != nil {
+		t.Errorf("Expected the check to be skipped, got %v", err)
+	}
+	if n := len(api.multimodalRequests()); n != 2 {
+		t.Errorf("Expected 2 API requests, got %d", n)
+	}
+}
+
+func TestValidateImageURLsOffByDefault(t *testing.T) {
+	api := newMockServer(t)
+	if _, err := api.client().MultimodalEmbedContext(context.Background(), imageInputs("http://127.0.0.1:0/missing"), "voyage-multimodal-3", nil); err != nil {
+		t.Errorf("Expected no validation by default, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package voyageai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+const (
+	DefaultMaxContentTokens = 32_000 // The most tokens per multimodal input by default: the model's context length.
+	// The most image pixels per input by default: [DefaultMaxContentTokens] at 560 pixels per
+	// token.
+	DefaultMaxContentPixels = DefaultMaxContentTokens * pixelsPerToken
+)
+
+// The number of image pixels counted as one token.
+const pixelsPerToken = 560
+
+// Options for [MultimodalContentsFromFS].
+type FSImageOpts struct {
+	MaxImagesPerContent int              // The most images grouped into one content. Defaults to 1.
+	MaxPixelsPerContent int              // The most image pixels in one content. Defaults to [DefaultMaxContentPixels].
+	Prepare             PrepareImageOpts // How each image is encoded. See [PrepareImage].
+	Concurrency         int              // The number of images read and prepared at once. Defaults to 1.
+}
+
+// A file left out by [MultimodalContentsFromFS].
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// The formats [MultimodalContentsFromFS] can prepare, by sniffed content type.
+var fsImageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true}
+
+// MultimodalContentsFromFS builds multimodal inputs from the images in fsys whose paths match
+// glob, as documented on [IndexOpts.Glob]. Formats are detected from file contents, not names.
+// Each image is scaled down to at most [MaxImagePixels] and encoded with [PrepareImage], then the
+// images are grouped in path order into contents of at most opts.MaxImagesPerContent images and
+// opts.MaxPixelsPerContent pixels.
+//
+// Files that are not PNG, JPEG or GIF images, cannot be decoded or cannot be prepared are left
+// out and reported in skipped, in path order. The error is only set if fsys cannot be walked or
+// ctx is done.
+func MultimodalContentsFromFS(ctx context.Context, fsys fs.FS, glob string, opts FSImageOpts) (contents []MultimodalContent, skipped []SkippedFile, err error) {
+	var paths []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && matchGlob(glob, p) {
+			paths = append(paths, p)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("voyage: walk: %w", err)
+	}
+
+	type prepared struct {
+		img    *PreparedImage
+		reason string
+	}
+	results := make([]prepared, len(paths))
+	workers := max(opts.Concurrency, 1)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				img, err := prepareFile(fsys, paths[i], opts.Prepare)
+				if err != nil {
+					results[i].reason = err.Error()
+				}
+				results[i].img = img
+			}
+		}()
+	}
+	for i := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	maxImages := opts.MaxImagesPerContent
+	if maxImages <= 0 {
+		maxImages = 1
+	}
+	maxPixels := opts.MaxPixelsPerContent
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxContentPixels
+	}
+	var cur MultimodalContent
+	pixels := 0
+	for i, r := range results {
+		if r.img == nil {
+			skipped = append(skipped, SkippedFile{Path: paths[i], Reason: r.reason})
+			continue
+		}
+		n := r.img.Width * r.img.Height
+		if n > maxPixels {
+			skipped = append(skipped, SkippedFile{Path: paths[i], Reason: fmt.Sprintf("image has %d pixels, more than %d per content", n, maxPixels)})
+			continue
+		}
+		if len(cur.Content) == maxImages || len(cur.Content) > 0 && pixels+n > maxPixels {
+			contents = append(contents, cur)
+			cur, pixels = MultimodalContent{}, 0
+		}
+		cur.Content = append(cur.Content, Multimodal(r.img.Data))
+		pixels += n
+	}
+	if len(cur.Content) > 0 {
+		contents = append(contents, cur)
+	}
+	return contents, skipped, nil
+}
+
+// prepareFile reads the image at p and prepares it. Its errors are reasons to skip the file.
+func prepareFile(fsys fs.FS, p string, opts PrepareImageOpts) (*PreparedImage, error) {
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return prepareImageData(data, opts)
+}
+
+// prepareImageData prepares a PNG, JPEG or GIF image, scaling it down to at most
+// [MaxImagePixels] first if needed.
+func prepareImageData(data []byte, opts PrepareImageOpts) (*PreparedImage, error) {
+	if ct := http.DetectContentType(data); !fsImageTypes[ct] {
+		return nil, fmt.Errorf("unsupported format %s", ct)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt image: %w", err)
+	}
+	var img *PreparedImage
+	if b := decoded.Bounds(); b.Dx()*b.Dy() <= MaxImagePixels {
+		// Prepare the original bytes so images that fit are passed through unchanged.
+		img, err = PrepareImage(bytes.NewReader(data), opts)
+	} else {
+		img, err = preparePage(decoded, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("prepare: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,78 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/zamedic/voyageai"
+)
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestMultimodalContentsFromFS(t *testing.T) {
+	png := noisyPNG(t, 8, 8, false)
+	fsys := fstest.MapFS{
+		"img/a.png":        {Data: png},
+		"img/b.jpg":        {Data: testJPEG(t, 10, 10)},
+		"img/c.png":        {Data: testJPEG(t, 12, 12)}, // A JPEG named as a PNG.
+		"img/d.webp":       {Data: append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 32)...)},
+		"img/e.png":        {Data: append(png[:40:40], "garbage"...)},
+		"img/sub/f.gif":    {Data: []byte("GIF89a not really")},
+		"img/sub/g.png":    {Data: png},
+		"notes/readme.txt": {Data: []byte("not an image")},
+	}
+
+	contents, skipped, err := voyageai.MultimodalContentsFromFS(context.Background(), fsys, "img/*", voyageai.FSImageOpts{MaxImagesPerContent: 2, Concurrency: 3})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// img/* matches only the top level of img.
+	if len(contents) != 2 || len(contents[0].Content) != 2 || len(contents[1].Content) != 1 {
+		t.Fatalf("Expected contents of 2 and 1 images, got %+v", contents)
+	}
+	for i, prefix := range []string{"data:image/png;", "data:image/jpeg;", "data:image/jpeg;"} {
+		in := contents[i/2].Content[i%2]
+		if in.Type != "image_base64" || !strings.HasPrefix(string(in.ImageBase64), prefix) {
+			t.Errorf("Image %d: expected a %s data URL, got %.30s", i, prefix, in.ImageBase64)
+		}
+	}
+
+	if len(skipped) != 2 {
+		t.Fatalf("Expected 2 skipped files, got %+v", skipped)
+	}
+	if skipped[0].Path != "img/d.webp" || !strings.Contains(skipped[0].Reason, "unsupported format image/webp") {
+		t.Errorf("Expected the WebP file to be unsupported, got %+v", skipped[0])
+	}
+	if skipped[1].Path != "img/e.png" || !strings.Contains(skipped[1].Reason, "corrupt") {
+		t.Errorf("Expected the truncated PNG to be corrupt, got %+v", skipped[1])
+	}
+
+	// Without a glob, every file is considered and the pixel limit splits contents.
+	contents, skipped, err = voyageai.MultimodalContentsFromFS(context.Background(), fsys, "", voyageai.FSImageOpts{MaxImagesPerContent: 10, MaxPixelsPerContent: 250})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// 64 + 100 pixels fit together, 144 starts a new content, and 64 joins it.
+	if len(contents) != 2 || len(contents[0].Content) != 2 || len(contents[1].Content) != 2 {
+		t.Errorf("Expected two contents of two images, got %d contents", len(contents))
+	}
+	var paths []string
+	for _, s := range skipped {
+		paths = append(paths, s.Path)
+	}
+	if strings.Join(paths, ",") != "img/d.webp,img/e.png,img/sub/f.gif,notes/readme.txt" {
+		t.Errorf("Expected the other files to be skipped in path order, got %v", skipped)
+	}
+}
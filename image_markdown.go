@@ -0,0 +1,149 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Options for [MultimodalFromMarkdown].
+type MarkdownImageOpts struct {
+	// The most tokens in one content, counting text with [EstimateTokens] and images at 560
+	// pixels per token. Defaults to [DefaultMaxContentTokens].
+	MaxTokensPerContent int
+	MaxImagesPerContent int              // The most images in one content. Unlimited by default.
+	Prepare             PrepareImageOpts // How each image is encoded. See [PrepareImage].
+}
+
+// An image reference left out by [MultimodalFromMarkdown].
+type UnresolvedImage struct {
+	Ref    string // The reference as written in the document.
+	Offset int    // The byte offset of the image syntax in the document.
+	Line   int    // The line of the image syntax, starting at 1.
+	Reason string
+}
+
+// Matches an inline image, ![alt](ref) or ![alt](<ref> "title"), capturing the reference.
+var markdownImage = regexp.MustCompile(`!\[[^\]]*\]\(\s*(<[^>\n]*>|[^\s)]+)(?:\s+(?:"[^"\n]*"|'[^'\n]*'))?\s*\)`)
+
+// Matches the opening or closing line of a fenced code block.
+var markdownFence = regexp.MustCompile("(?m)^ {0,3}(```|~~~)")
+
+// MultimodalFromMarkdown builds multimodal inputs from a Markdown document, keeping its text and
+// inline images in document order. Each image reference is opened with resolve, which may read
+// files, fetch URLs or look up a store, and the image is prepared as by
+// [MultimodalContentsFromFS]. Readers that implement [io.Closer] are closed. Images in fenced
+// code blocks are left as text.
+//
+// The document is split into as many contents as needed to keep each within
+// opts.MaxTokensPerContent and opts.MaxImagesPerContent, breaking text longer than the token
+// limit with [ChunkText].
+//
+// References that cannot be resolved or prepared are left out of the contents and reported in
+// unresolved, in document order. The error is only set if ctx is done.
+func MultimodalFromMarkdown(ctx context.Context, md string, resolve func(ref string) (io.Reader, error), opts MarkdownImageOpts) (contents []MultimodalContent, unresolved []UnresolvedImage, err error) {
+	b := &contentBuilder{maxTokens: opts.MaxTokensPerContent, maxImages: opts.MaxImagesPerContent}
+	if b.maxTokens <= 0 {
+		b.maxTokens = DefaultMaxContentTokens
+	}
+	fences := markdownFence.FindAllStringIndex(md, -1)
+	inFence := func(offset int) bool {
+		for i := 0; i+1 < len(fences); i += 2 {
+			if offset >= fences[i][0] && offset < fences[i+1][1] {
+				return true
+			}
+		}
+		// An unclosed fence runs to the end of the document.
+		return len(fences)%2 == 1 && offset >= fences[len(fences)-1][0]
+	}
+
+	pos := 0
+	for _, m := range markdownImage.FindAllStringSubmatchIndex(md, -1) {
+		if inFence(m[0]) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		b.text.WriteString(md[pos:m[0]])
+		pos = m[1]
+		ref := strings.TrimSuffix(strings.TrimPrefix(md[m[2]:m[3]], "<"), ">")
+		img, err := resolveImage(resolve, ref, opts.Prepare)
+		if err != nil {
+			unresolved = append(unresolved, UnresolvedImage{
+				Ref:    ref,
+				Offset: m[0],
+				Line:   strings.Count(md[:m[0]], "\n") + 1,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		b.flushText()
+		b.add(Multimodal(img.Data), (img.Width*img.Height+pixelsPerToken-1)/pixelsPerToken, true)
+	}
+	b.text.WriteString(md[pos:])
+	b.flushText()
+	if len(b.cur.Content) > 0 {
+		b.contents = append(b.contents, b.cur)
+	}
+	return b.contents, unresolved, nil
+}
+
+// resolveImage opens ref with resolve and prepares the image. Its errors are reasons to leave the
+// reference out.
+func resolveImage(resolve func(ref string) (io.Reader, error), ref string, opts PrepareImageOpts) (*PreparedImage, error) {
+	r, err := resolve(ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolve: %w", err)
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return prepareImageData(data, opts)
+}
+
+// contentBuilder groups inputs into contents within token and image limits.
+type contentBuilder struct {
+	maxTokens, maxImages int
+	text                 strings.Builder // Text waiting for the next image or the end of the document.
+
+	contents       []MultimodalContent
+	cur            MultimodalContent
+	tokens, images int
+}
+
+// flushText adds the pending text, split into chunks that fit a content.
+func (b *contentBuilder) flushText() {
+	s := strings.TrimSpace(b.text.String())
+	b.text.Reset()
+	if s == "" {
+		return
+	}
+	if n := EstimateTokens(s); n <= b.maxTokens {
+		b.add(Multimodal(Text(s)), n, false)
+		return
+	}
+	for _, ch := range ChunkText(s, ChunkOpts{MaxTokens: b.maxTokens}) {
+		b.add(Multimodal(Text(ch.Text)), EstimateTokens(ch.Text), false)
+	}
+}
+
+// add appends in to the current content, starting a new one first if in would not fit.
+func (b *contentBuilder) add(in MultimodalInput, tokens int, image bool) {
+	full := b.tokens+tokens > b.maxTokens || image && b.maxImages > 0 && b.images == b.maxImages
+	if len(b.cur.Content) > 0 && full {
+		b.contents = append(b.contents, b.cur)
+		b.cur, b.tokens, b.images = MultimodalContent{}, 0, 0
+	}
+	b.cur.Content = append(b.cur.Content, in)
+	b.tokens += tokens
+	if image {
+		b.images++
+	}
+}
@@ -0,0 +1,143 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// mapResolver resolves references to the images in m.
+func mapResolver(m map[string][]byte) func(string) (io.Reader, error) {
+	return func(ref string) (io.Reader, error) {
+		data, ok := m[ref]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return bytes.NewReader(data), nil
+	}
+}
+
+// inputTypes describes contents as their input types, such as "text image | text".
+func inputTypes(contents []voyageai.MultimodalContent) string {
+	var parts []string
+	for _, c := range contents {
+		var types []string
+		for _, in := range c.Content {
+			types = append(types, strings.TrimSuffix(in.Type, "_base64"))
+		}
+		parts = append(parts, strings.Join(types, " "))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestMultimodalFromMarkdown(t *testing.T) {
+	png := noisyPNG(t, 8, 8, false)
+	images := map[string][]byte{"img/a.png": png, "https://example.com/b.jpg": testJPEG(t, 10, 10)}
+	md := "# Setup\n\nInstall the tool.\n\n![diagram](img/a.png)\n" +
+		"Then configure it. ![photo](<https://example.com/b.jpg> \"A photo\")![again](img/a.png)\n\n" +
+		"```\n![not an image](img/a.png)\n```\n" +
+		"Done."
+
+	contents, unresolved, err := voyageai.MultimodalFromMarkdown(context.Background(), md, mapResolver(images), voyageai.MarkdownImageOpts{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(unresolved) != 0 {
+		t.Errorf("Expected every image to resolve, got %+v", unresolved)
+	}
+	if got := inputTypes(contents); got != "text image text image image text" {
+		t.Fatalf("Expected interleaved text and images in one content, got %q", got)
+	}
+	in := contents[0].Content
+	if in[0].Text != "# Setup\n\nInstall the tool." || in[2].Text != "Then configure it." {
+		t.Errorf("Expected the text around the images, got %q and %q", in[0].Text, in[2].Text)
+	}
+	if !strings.HasPrefix(string(in[1].ImageBase64), "data:image/png;") || !strings.HasPrefix(string(in[3].ImageBase64), "data:image/jpeg;") {
+		t.Errorf("Expected the images in document order, got %.30s and %.30s", in[1].ImageBase64, in[3].ImageBase64)
+	}
+	if in[5].Text != "```\n![not an image](img/a.png)\n```\nDone." {
+		t.Errorf("Expected images in code blocks to stay text, got %q", in[5].Text)
+	}
+}
+
+func TestMultimodalFromMarkdownUnresolved(t *testing.T) {
+	png := noisyPNG(t, 8, 8, false)
+	failing := func(ref string) (io.Reader, error) {
+		if ref == "ok.png" {
+			return bytes.NewReader(png), nil
+		}
+		if ref == "text.png" {
+			return strings.NewReader("not an image"), nil
+		}
+		return nil, errors.New("permission denied")
+	}
+	md := "Intro ![a](missing.png) middle\n![b](ok.png)\nend ![c](text.png)"
+
+	contents, unresolved, err := voyageai.MultimodalFromMarkdown(context.Background(), md, failing, voyageai.MarkdownImageOpts{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := inputTypes(contents); got != "text image text" {
+		t.Fatalf("Expected unresolved images to be left out, got %q", got)
+	}
+	if contents[0].Content[0].Text != "Intro  middle" {
+		t.Errorf("Expected the text around a missing image to be joined, got %q", contents[0].Content[0].Text)
+	}
+	if len(unresolved) != 2 {
+		t.Fatalf("Expected 2 unresolved images, got %+v", unresolved)
+	}
+	if u := unresolved[0]; u.Ref != "missing.png" || u.Offset != 6 || u.Line != 1 || !strings.Contains(u.Reason, "permission denied") {
+		t.Errorf("Unexpected report for the missing image: %+v", u)
+	}
+	if u := unresolved[1]; u.Ref != "text.png" || u.Line != 3 || u.Offset != strings.Index(md, "![c]") || !strings.Contains(u.Reason, "unsupported format") {
+		t.Errorf("Unexpected report for the invalid image: %+v", u)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := voyageai.MultimodalFromMarkdown(ctx, md, failing, voyageai.MarkdownImageOpts{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled context to stop the walk, got %v", err)
+	}
+}
+
+func TestMultimodalFromMarkdownSplits(t *testing.T) {
+	// Each 40x28 image is 2 tokens.
+	images := map[string][]byte{"i.png": noisyPNG(t, 40, 28, false)}
+	md := "![1](i.png) ![2](i.png) ![3](i.png) some text ![4](i.png)"
+
+	contents, _, err := voyageai.MultimodalFromMarkdown(context.Background(), md, mapResolver(images), voyageai.MarkdownImageOpts{MaxImagesPerContent: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := inputTypes(contents); got != "image image | image text image" {
+		t.Errorf("Expected at most two images per content, got %q", got)
+	}
+
+	contents, _, err = voyageai.MultimodalFromMarkdown(context.Background(), md, mapResolver(images), voyageai.MarkdownImageOpts{MaxTokensPerContent: 5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// "some text" is 3 tokens, filling the second content along with an image.
+	if got := inputTypes(contents); got != "image image | image text | image" {
+		t.Errorf("Expected contents of at most 5 tokens, got %q", got)
+	}
+
+	// Text longer than the limit is chunked.
+	contents, _, err = voyageai.MultimodalFromMarkdown(context.Background(), strings.Repeat("word ", 20), mapResolver(images), voyageai.MarkdownImageOpts{MaxTokensPerContent: 4})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(contents) < 5 {
+		t.Fatalf("Expected the text to be split, got %d contents", len(contents))
+	}
+	for i, c := range contents {
+		if n := voyageai.EstimateTokens(string(c.Content[0].Text)); len(c.Content) != 1 || n > 4 {
+			t.Errorf("Content %d: expected one chunk of at most 4 tokens, got %d inputs and %d tokens", i, len(c.Content), n)
+		}
+	}
+}
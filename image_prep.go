@@ -0,0 +1,192 @@
+package voyageai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// The JPEG qualities tried in turn by [PrepareImage], before resorting to smaller dimensions.
+var jpegQualityLadder = []int{85, 75, 65, 55, 45, 35}
+
+// The smallest width or height [PrepareImage] scales an image down to.
+const minImageSide = 16
+
+// Options for [PrepareImage].
+type PrepareImageOpts struct {
+	// The maximum length of the resulting data URL in bytes. The image is re-encoded, and scaled
+	// down as a last resort, until it fits. No limit by default.
+	TargetEncodedBytes int
+	// Allow images with transparency to be converted to JPEG, which drops the alpha channel.
+	// By default they are kept as PNG and only scaled down.
+	AllowTransparencyLoss bool
+}
+
+// An image encoded as a data URL by [PrepareImage], for use with [Multimodal].
+type PreparedImage struct {
+	Data          imageBase64
+	Format        string // The encoded format: "png", "jpeg" or "gif".
+	Width, Height int    // The encoded dimensions.
+	Quality       int    // The JPEG quality, if the image was re-encoded as JPEG. Zero otherwise.
+	Reencoded     bool   // Whether the image was re-encoded rather than passed through unchanged.
+	Resized       bool   // Whether the image was scaled down.
+}
+
+// PrepareImage reads an image and encodes it as a data URL of at most opts.TargetEncodedBytes.
+//
+// An image whose data URL already fits is passed through byte for byte. Otherwise it is
+// re-encoded as JPEG at decreasing qualities and, if even the lowest quality is too large,
+// scaled down in steps of 25% until it fits. Images with transparency are kept as PNG and only
+// scaled down, unless [PrepareImageOpts.AllowTransparencyLoss] is set. It returns an error if the
+// image cannot be made small enough.
+func PrepareImage(r io.Reader, opts PrepareImageOpts) (*PreparedImage, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	fits := func(n int) bool {
+		return opts.TargetEncodedBytes <= 0 || dataURLLen(format, n) <= opts.TargetEncodedBytes
+	}
+	if fits(len(raw)) {
+		return &PreparedImage{
+			Data:   dataURL(format, raw),
+			Format: format,
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+		}, nil
+	}
+
+	useJPEG := opts.AllowTransparencyLoss || isOpaque(img)
+	encode := func(img image.Image, quality int) ([]byte, string, error) {
+		var buf bytes.Buffer
+		if useJPEG {
+			err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality})
+			return buf.Bytes(), "jpeg", err
+		}
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), "png", err
+	}
+	result := func(img image.Image, data []byte, format string, quality int) *PreparedImage {
+		p := &PreparedImage{
+			Data:      dataURL(format, data),
+			Format:    format,
+			Width:     img.Bounds().Dx(),
+			Height:    img.Bounds().Dy(),
+			Reencoded: true,
+			Resized:   img.Bounds().Size() != bounds.Size(),
+		}
+		if format == "jpeg" {
+			p.Quality = quality
+		}
+		return p
+	}
+
+	qualities := jpegQualityLadder
+	if !useJPEG {
+		qualities = []int{0}
+	}
+	for _, q := range qualities {
+		data, f, err := encode(img, q)
+		if err != nil {
+			return nil, err
+		}
+		if dataURLLen(f, len(data)) <= opts.TargetEncodedBytes {
+			return result(img, data, f, q), nil
+		}
+	}
+
+	q := qualities[len(qualities)-1]
+	w, h := bounds.Dx(), bounds.Dy()
+	for {
+		w, h = w*3/4, h*3/4
+		if w < minImageSide || h < minImageSide {
+			return nil, fmt.Errorf("voyage: image cannot be encoded in %d bytes", opts.TargetEncodedBytes)
+		}
+		scaled := scaleImage(img, w, h)
+		data, f, err := encode(scaled, q)
+		if err != nil {
+			return nil, err
+		}
+		if dataURLLen(f, len(data)) <= opts.TargetEncodedBytes {
+			return result(scaled, data, f, q), nil
+		}
+	}
+}
+
+func dataURL(format string, data []byte) imageBase64 {
+	return imageBase64("data:image/" + format + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+func dataURLLen(format string, n int) int {
+	return len("data:image/;base64,") + len(format) + base64.StdEncoding.EncodedLen(n)
+}
+
+// isOpaque reports whether every pixel of img is fully opaque.
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flatten composites img over a white background, as JPEG has no alpha channel.
+func flatten(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
+
+// scaleImage returns img scaled down to w×h by averaging the source pixels covered by each
+// destination pixel.
+func scaleImage(img image.Image, w, h int) *image.NRGBA {
+	src := image.NewNRGBA(img.Bounds())
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := range w {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := sy*src.Stride + sx*4
+					for c := range sum {
+						sum[c] += int(src.Pix[i+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := y*dst.Stride + x*4
+			for c := range sum {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
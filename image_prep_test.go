@@ -0,0 +1,108 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// noisyPNG returns a PNG of random pixels, which compresses poorly. If alpha is set, every pixel is
+// half transparent.
+func noisyPNG(t *testing.T, w, h int, alpha bool) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			a := uint8(255)
+			if alpha {
+				a = 128
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256)), a})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestPrepareImageTargetSize(t *testing.T) {
+	raw := noisyPNG(t, 256, 256, false)
+	target := 40000
+	img, err := voyageai.PrepareImage(bytes.NewReader(raw), voyageai.PrepareImageOpts{TargetEncodedBytes: target})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(img.Data) > target {
+		t.Errorf("Expected at most %d bytes, got %d", target, len(img.Data))
+	}
+	if img.Format != "jpeg" || img.Quality == 0 || !img.Reencoded {
+		t.Errorf("Expected a JPEG re-encoding, got %+v", *img)
+	}
+	if ok, err := validateDataURL(string(img.Data)); !ok {
+		t.Errorf("Invalid data URL: %v", err)
+	}
+	if w, h := decodedSize(t, string(img.Data)); w != img.Width || h != img.Height {
+		t.Errorf("Expected %dx%d, decoded %dx%d", img.Width, img.Height, w, h)
+	}
+}
+
+func TestPrepareImageSmallUntouched(t *testing.T) {
+	raw := noisyPNG(t, 8, 8, false)
+	img, err := voyageai.PrepareImage(bytes.NewReader(raw), voyageai.PrepareImageOpts{TargetEncodedBytes: 1 << 20})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(raw)
+	if string(img.Data) != want || img.Reencoded || img.Resized || img.Width != 8 {
+		t.Errorf("Expected the image to pass through unchanged, got %+v", *img)
+	}
+}
+
+func TestPrepareImageTransparency(t *testing.T) {
+	raw := noisyPNG(t, 128, 128, true)
+	target := 20000
+	img, err := voyageai.PrepareImage(bytes.NewReader(raw), voyageai.PrepareImageOpts{TargetEncodedBytes: target})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if img.Format != "png" || !img.Resized || img.Width >= 128 || len(img.Data) > target {
+		t.Errorf("Expected a smaller PNG, got %s %dx%d in %d bytes", img.Format, img.Width, img.Height, len(img.Data))
+	}
+
+	img, err = voyageai.PrepareImage(bytes.NewReader(raw), voyageai.PrepareImageOpts{TargetEncodedBytes: target, AllowTransparencyLoss: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if img.Format != "jpeg" {
+		t.Errorf("Expected a JPEG when transparency loss is allowed, got %s", img.Format)
+	}
+}
+
+func TestPrepareImageImpossibleTarget(t *testing.T) {
+	if _, err := voyageai.PrepareImage(bytes.NewReader(noisyPNG(t, 64, 64, false)), voyageai.PrepareImageOpts{TargetEncodedBytes: 100}); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func decodedSize(t *testing.T, url string) (int, int) {
+	t.Helper()
+	_, b64, _ := bytes.Cut([]byte(url), []byte(","))
+	data, err := base64.StdEncoding.DecodeString(string(b64))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return cfg.Width, cfg.Height
+}
@@ -0,0 +1,68 @@
+package voyageai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Limits of the multimodal embeddings API on base64 image data.
+const (
+	MaxImageBytes        = 16 << 20 // The largest image, before encoding.
+	MaxImagePayloadBytes = 20 << 20 // The most base64 image data, data URLs included, per request.
+)
+
+// The formats [GetBase64Raw] accepts, by sniffed content type.
+var rawImageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+
+// GetBase64Raw reads all image data from img and converts it to a base64 encoded data URL for use
+// with [MultimodalInput], without decoding the image. Unlike [GetBase64], the original bytes are
+// sent unchanged, so JPEGs are not recompressed, animated GIFs keep their frames and WebP images
+// can be used. The media type is detected from the start of the data.
+//
+// It fails for formats other than PNG, JPEG, GIF and WebP, for images larger than
+// [MaxImageBytes], and for data URLs longer than [MaxImagePayloadBytes].
+func GetBase64Raw(img io.Reader) (imageBase64, error) {
+	data, err := io.ReadAll(io.LimitReader(img, MaxImageBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("voyage: read image: %w", err)
+	}
+	if len(data) > MaxImageBytes {
+		return "", fmt.Errorf("voyage: image is larger than the %d bytes allowed", MaxImageBytes)
+	}
+	mediaType := http.DetectContentType(data)
+	if !rawImageTypes[mediaType] {
+		return "", fmt.Errorf("voyage: unsupported image format %s: expected PNG, JPEG, GIF or WebP", mediaType)
+	}
+	format := strings.TrimPrefix(mediaType, "image/")
+	if n := dataURLLen(format, len(data)); n > MaxImagePayloadBytes {
+		return "", fmt.Errorf("voyage: image of %d bytes encodes to a %d byte data URL, more than the %d bytes allowed per request", len(data), n, MaxImagePayloadBytes)
+	}
+	return dataURL(format, data), nil
+}
+
+// Reads all image data and converts it to a base64 encoded data URL as [GetBase64Raw] does.
+// Panics on failure.
+func MustGetBase64Raw(img io.Reader) imageBase64 {
+	res, err := GetBase64Raw(img)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// checkImagePayload fails if the base64 images of inputs add up to more than
+// [MaxImagePayloadBytes].
+func checkImagePayload(inputs []MultimodalContent) error {
+	total := 0
+	for _, in := range inputs {
+		for _, part := range in.Content {
+			total += len(part.ImageBase64)
+		}
+	}
+	if total > MaxImagePayloadBytes {
+		return fmt.Errorf("voyage: request has %d bytes of base64 image data, more than the %d allowed", total, MaxImagePayloadBytes)
+	}
+	return nil
+}
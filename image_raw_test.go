@@ -0,0 +1,143 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// A 1x1 lossless WebP image.
+const webpFixture = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// animatedGIF returns a GIF of the given number of 8x8 frames in different colours.
+func animatedGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+	anim := &gif.GIF{}
+	for i := range frames {
+		img := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9)
+		for p := range img.Pix {
+			img.Pix[p] = uint8(10 * i)
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+// decodeDataURL returns the media type and data of a base64 data URL.
+func decodeDataURL(t *testing.T, url string) (string, []byte) {
+	t.Helper()
+	header, b64, ok := strings.Cut(url, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		t.Fatalf("Not a base64 data URL: %.40s", url)
+	}
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64"), data
+}
+
+func TestGetBase64RawPassesThrough(t *testing.T) {
+	webp, _ := base64.StdEncoding.DecodeString(webpFixture)
+	jpg := new(bytes.Buffer)
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for p := range img.Pix {
+		img.Pix[p] = uint8(p)
+	}
+	if err := jpeg.Encode(jpg, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err.Error())
+	}
+	png, err := createDummyImage(4, 4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		data      []byte
+		mediaType string
+	}{
+		{webp, "image/webp"},
+		{animatedGIF(t, 3), "image/gif"},
+		{jpg.Bytes(), "image/jpeg"},
+		{png.Bytes(), "image/png"},
+	}
+	for _, tt := range tests {
+		url, err := voyageai.GetBase64Raw(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mediaType, err)
+		}
+		mediaType, data := decodeDataURL(t, string(url))
+		if mediaType != tt.mediaType || !bytes.Equal(data, tt.data) {
+			t.Errorf("%s: expected the original %d bytes, got %s with %d bytes", tt.mediaType, len(tt.data), mediaType, len(data))
+		}
+	}
+}
+
+func TestGetBase64RawKeepsAnimation(t *testing.T) {
+	original := animatedGIF(t, 3)
+	_, raw := decodeDataURL(t, string(voyageai.MustGetBase64Raw(bytes.NewReader(original))))
+	if len(raw) != len(original) {
+		t.Errorf("Expected %d bytes, got %d", len(original), len(raw))
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(anim.Image) != 3 {
+		t.Errorf("Expected 3 frames, got %d", len(anim.Image))
+	}
+
+	// GetBase64 decodes the image and keeps only its first frame.
+	_, decoded := decodeDataURL(t, string(voyageai.MustGetBase64(bytes.NewReader(original))))
+	if anim, err := gif.DecodeAll(bytes.NewReader(decoded)); err != nil || len(anim.Image) != 1 {
+		t.Errorf("Expected GetBase64 to collapse the animation, got %v", err)
+	}
+}
+
+func TestGetBase64RawRejects(t *testing.T) {
+	pngHeader := []byte("\x89PNG\r\n\x1a\n")
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"text", []byte("hello, world"), "unsupported image format text/plain"},
+		{"bmp", append([]byte("BM"), make([]byte, 64)...), "unsupported image format image/bmp"},
+		{"too large", append(pngHeader, make([]byte, voyageai.MaxImageBytes)...), "larger than the 16777216 bytes allowed"},
+		{"data URL too long", append(pngHeader, make([]byte, 15<<20)...), "more than the 20971520 bytes allowed per request"},
+	}
+	for _, tt := range tests {
+		_, err := voyageai.GetBase64Raw(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestMultimodalImagePayloadLimit(t *testing.T) {
+	api := newMockServer(t)
+	client := api.client()
+	img := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 10<<20)...)
+	url := voyageai.MustGetBase64Raw(bytes.NewReader(img))
+
+	inputs := []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(url), voyageai.Multimodal(url)}}}
+	_, err := client.MultimodalEmbed(inputs, voyageai.ModelVoyageMultimodal3, nil)
+	if err == nil || !strings.Contains(err.Error(), "base64 image data") {
+		t.Errorf("Expected the payload to be rejected, got %v", err)
+	}
+	if len(api.headers) != 0 {
+		t.Errorf("Expected no requests, got %d", len(api.headers))
+	}
+}
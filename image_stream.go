@@ -0,0 +1,198 @@
+package voyageai
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// An image that is base64 encoded into the request body while the request is sent, so neither its
+// data nor its data URL is held in memory. Pass it to [Multimodal] in place of a data URL.
+//
+// Other uses of the request body, such as [VoyageClientOpts.Shadow] and audit input hashes, read
+// the image in full.
+type ImageStream struct {
+	MediaType string // The image's media type, such as "image/png".
+	// The size of the image data in bytes, or -1 if unknown. If the size of every streamed image
+	// of a request is known, the request is sent with a Content-Length, and a size that does not
+	// match the data fails the request; otherwise the body is sent in chunks.
+	Size int64
+	// Opens the image data. It is called for every attempt, so a retried request reads the image
+	// again.
+	Open func() (io.ReadCloser, error)
+}
+
+// StreamImageFS returns an [ImageStream] reading the named image from fsys. Its media type is
+// detected from the start of the file.
+func StreamImageFS(fsys fs.FS, name string) (*ImageStream, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("voyage: open image: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("voyage: stat image: %w", err)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("voyage: read image: %w", err)
+	}
+	mediaType := http.DetectContentType(head[:n])
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("voyage: %s is not an image: detected %s", name, mediaType)
+	}
+	return &ImageStream{
+		MediaType: mediaType,
+		Size:      info.Size(),
+		Open:      func() (io.ReadCloser, error) { return fsys.Open(name) },
+	}, nil
+}
+
+// dataURL reads the image in full and returns its data URL.
+func (s *ImageStream) dataURL() (imageBase64, error) {
+	var b strings.Builder
+	if s.Size >= 0 {
+		b.Grow(len("data:;base64,") + len(s.MediaType) + base64.StdEncoding.EncodedLen(int(s.Size)))
+	}
+	if err := s.writeDataURL(&b); err != nil {
+		return "", err
+	}
+	return imageBase64(b.String()), nil
+}
+
+// writeDataURL writes the image's data URL to w as it is read.
+func (s *ImageStream) writeDataURL(w io.Writer) error {
+	r, err := s.Open()
+	if err != nil {
+		return fmt.Errorf("voyage: open image stream: %w", err)
+	}
+	defer r.Close()
+	if _, err := io.WriteString(w, "data:"+s.MediaType+";base64,"); err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return fmt.Errorf("voyage: read image stream: %w", err)
+	}
+	return enc.Close()
+}
+
+// MarshalJSON encodes in, reading a streamed image in full.
+func (in MultimodalInput) MarshalJSON() ([]byte, error) {
+	type plain MultimodalInput
+	if in.ImageStream != nil {
+		url, err := in.ImageStream.dataURL()
+		if err != nil {
+			return nil, err
+		}
+		in.ImageBase64 = url
+	}
+	return json.Marshal(plain(in))
+}
+
+// streamedBody is the body of a multimodal request with streamed images: the JSON encoding of the
+// request, split where the images' data URLs go.
+type streamedBody struct {
+	parts  [][]byte // One more than images.
+	images []*ImageStream
+	length int64 // The length of the body, or -1 if unknown.
+}
+
+// newStreamedBody returns the body of r, or nil if r has no streamed images.
+func newStreamedBody(r *MultimodalRequest) (*streamedBody, error) {
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	placeholder := func(i int) string { return fmt.Sprintf("voyage-stream-%x-%d", nonce, i) }
+
+	// Encode a copy of r with a placeholder in place of each data URL.
+	var images []*ImageStream
+	cp := *r
+	cp.Inputs = append([]MultimodalContent(nil), r.Inputs...)
+	for i, content := range r.Inputs {
+		copied := false
+		for j, in := range content.Content {
+			if in.ImageStream == nil {
+				continue
+			}
+			if !copied {
+				cp.Inputs[i].Content = append([]MultimodalInput(nil), content.Content...)
+				copied = true
+			}
+			cp.Inputs[i].Content[j] = MultimodalInput{Type: in.Type, ImageBase64: imageBase64(placeholder(len(images)))}
+			images = append(images, in.ImageStream)
+		}
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &streamedBody{images: images}
+	for i, img := range images {
+		mark := []byte(`"` + placeholder(i) + `"`)
+		k := bytes.Index(b, mark)
+		if k < 0 {
+			return nil, fmt.Errorf("voyage: encode request: streamed image %d not found", i)
+		}
+		body.parts = append(body.parts, b[:k])
+		b = b[k+len(mark):]
+		if img.Size < 0 || body.length < 0 {
+			body.length = -1
+		} else {
+			body.length += int64(len(`"data:;base64,"`)+len(img.MediaType)) + int64(base64.StdEncoding.EncodedLen(int(img.Size)))
+		}
+	}
+	body.parts = append(body.parts, b)
+	if body.length >= 0 {
+		for _, p := range body.parts {
+			body.length += int64(len(p))
+		}
+	}
+	return body, nil
+}
+
+// open returns a reader of the body, which encodes the images as it is read. Closing it stops
+// the encoding.
+func (s *streamedBody) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriterSize(pw, 32<<10)
+		err := s.writeTo(w)
+		if err == nil {
+			err = w.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func (s *streamedBody) writeTo(w io.Writer) error {
+	for i, img := range s.images {
+		if _, err := w.Write(s.parts[i]); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, `"`); err != nil {
+			return err
+		}
+		if err := img.writeDataURL(w); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, `"`); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(s.parts[len(s.parts)-1])
+	return err
+}
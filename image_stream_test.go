@@ -0,0 +1,226 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// bodyRecorder answers /multimodalembeddings requests with one embedding per input and records
+// the raw body and Content-Length of each request. fail, if set, is the status of the first responses.
+type bodyRecorder struct {
+	*httptest.Server
+	fail    int
+	discard bool // Read bodies without recording them, so the server allocates little.
+
+	mu      sync.Mutex
+	bodies  [][]byte
+	lengths []int64 // -1 for chunked bodies.
+}
+
+func newBodyRecorder(tb testing.TB, discard bool) *bodyRecorder {
+	rec := &bodyRecorder{discard: discard}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b []byte
+		var err error
+		if rec.discard {
+			_, err = io.Copy(io.Discard, r.Body)
+		} else {
+			b, err = io.ReadAll(r.Body)
+		}
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		rec.mu.Lock()
+		rec.bodies = append(rec.bodies, b)
+		rec.lengths = append(rec.lengths, r.ContentLength)
+		fail := rec.fail > 0
+		rec.fail--
+		rec.mu.Unlock()
+		if fail {
+			w.WriteHeader(500)
+			return
+		}
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","embedding":[1,0],"index":0}],"usage":{"total_tokens":1}}`)
+	}))
+	tb.Cleanup(rec.Close)
+	return rec
+}
+
+func (rec *bodyRecorder) client(retries int) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: rec.URL, MaxRetries: retries, RetryBaseDelay: time.Millisecond})
+}
+
+func streamInputs(img voyageai.MultimodalInput) []voyageai.MultimodalContent {
+	return []voyageai.MultimodalContent{
+		{Content: []voyageai.MultimodalInput{voyageai.Multimodal(voyageai.Text("a caption")), img}},
+		{Content: []voyageai.MultimodalInput{voyageai.Multimodal(voyageai.Text("just text"))}},
+		{Content: []voyageai.MultimodalInput{img, img}},
+	}
+}
+
+func TestImageStreamMatchesEagerPayload(t *testing.T) {
+	png := noisyPNG(t, 64, 48, false)
+	fsys := fstest.MapFS{"photo.png": {Data: png}}
+	stream, err := voyageai.StreamImageFS(fsys, "photo.png")
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %+v", err)
+	}
+	if stream.MediaType != "image/png" || stream.Size != int64(len(png)) {
+		t.Fatalf("Expected a PNG of %d bytes, got %s of %d", len(png), stream.MediaType, stream.Size)
+	}
+	// PrepareImage passes the image through byte for byte, as the stream does.
+	prepared, err := voyageai.PrepareImage(bytes.NewReader(png), voyageai.PrepareImageOpts{})
+	if err != nil || prepared.Reencoded {
+		t.Fatalf("Failed to prepare the image unchanged: %+v", err)
+	}
+
+	rec := newBodyRecorder(t, false)
+	opts := &voyageai.MultimodalRequestOpts{SendNull: []string{"input_type"}}
+	unknown := *stream
+	unknown.Size = -1
+	for _, img := range []any{prepared.Data, stream, &unknown} {
+		if _, err := rec.client(0).MultimodalEmbed(streamInputs(voyageai.Multimodal(img)), "voyage-multimodal-3", opts); err != nil {
+			t.Fatalf("Failed to send the request: %+v", err)
+		}
+	}
+
+	want := rec.bodies[0]
+	if !bytes.Contains(want, []byte(base64.StdEncoding.EncodeToString(png))) {
+		t.Fatal("Expected the eager request to carry the image")
+	}
+	for i, name := range []string{"", "streamed", "chunked"} {
+		if i > 0 && !bytes.Equal(rec.bodies[i], want) {
+			t.Errorf("Expected the %s payload to match the eager payload byte for byte", name)
+		}
+	}
+	if n := rec.lengths[1]; n != int64(len(want)) {
+		t.Errorf("Expected a Content-Length of %d for known sizes, got %d", len(want), n)
+	}
+	if n := rec.lengths[2]; n != -1 {
+		t.Errorf("Expected a chunked body for an unknown size, got a Content-Length of %d", n)
+	}
+
+	// Marshalling the request outside the client reads the image in full.
+	b, err := json.Marshal(voyageai.MultimodalRequest{Inputs: streamInputs(voyageai.Multimodal(stream)), Model: "voyage-multimodal-3", SendNull: opts.SendNull})
+	if err != nil || !bytes.Equal(b, want) {
+		t.Errorf("Expected json.Marshal to match the eager payload, got error %v", err)
+	}
+}
+
+func TestImageStreamReopensOnRetry(t *testing.T) {
+	png := noisyPNG(t, 16, 16, false)
+	var opens atomic.Int32
+	stream := &voyageai.ImageStream{
+		MediaType: "image/png",
+		Size:      int64(len(png)),
+		Open: func() (io.ReadCloser, error) {
+			opens.Add(1)
+			return io.NopCloser(bytes.NewReader(png)), nil
+		},
+	}
+	rec := newBodyRecorder(t, false)
+	rec.fail = 1
+	inputs := []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(stream)}}}
+	if _, err := rec.client(2).MultimodalEmbed(inputs, "voyage-multimodal-3", nil); err != nil {
+		t.Fatalf("Failed to send the request: %+v", err)
+	}
+	if n := opens.Load(); n != 2 {
+		t.Errorf("Expected the image to be opened once per attempt, got %d", n)
+	}
+	if len(rec.bodies) != 2 || !bytes.Equal(rec.bodies[0], rec.bodies[1]) || len(rec.bodies[0]) == 0 {
+		t.Error("Expected the retry to send the same body")
+	}
+}
+
+func TestImageStreamErrors(t *testing.T) {
+	rec := newBodyRecorder(t, false)
+	stream := &voyageai.ImageStream{
+		MediaType: "image/png",
+		Size:      -1,
+		Open:      func() (io.ReadCloser, error) { return nil, errors.New("gone") },
+	}
+	inputs := []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(stream)}}}
+	if _, err := rec.client(0).MultimodalEmbedContext(context.Background(), inputs, "voyage-multimodal-3", nil); err == nil || !strings.Contains(err.Error(), "gone") {
+		t.Errorf("Expected the open error, got %v", err)
+	}
+
+	short := &voyageai.ImageStream{
+		MediaType: "image/png",
+		Size:      100,
+		Open:      func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("short")), nil },
+	}
+	inputs = []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(short)}}}
+	if _, err := rec.client(0).MultimodalEmbed(inputs, "voyage-multimodal-3", nil); err == nil {
+		t.Error("Expected a size that does not match the data to fail the request")
+	}
+
+	if _, err := voyageai.StreamImageFS(fstest.MapFS{"a.txt": {Data: []byte("hello")}}, "a.txt"); err == nil {
+		t.Error("Expected an error for a file that is not an image")
+	}
+}
+
+// benchmarkImage returns a file system holding a photo-sized PNG of noise, which does not
+// compress.
+func benchmarkImage(b *testing.B) fstest.MapFS {
+	img := image.NewNRGBA(image.Rect(0, 0, 1024, 1024))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		b.Fatal(err)
+	}
+	return fstest.MapFS{"photo.png": {Data: buf.Bytes()}}
+}
+
+// The image is prepared once, outside the loop, so only sending the request is measured.
+func BenchmarkMultimodalImageEager(b *testing.B) {
+	fsys := benchmarkImage(b)
+	prepared, err := voyageai.PrepareImage(bytes.NewReader(fsys["photo.png"].Data), voyageai.PrepareImageOpts{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	client := newBodyRecorder(b, true).client(0)
+	inputs := []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(prepared.Data)}}}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := client.MultimodalEmbed(inputs, "voyage-multimodal-3", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMultimodalImageStreamed(b *testing.B) {
+	fsys := benchmarkImage(b)
+	stream, err := voyageai.StreamImageFS(fsys, "photo.png")
+	if err != nil {
+		b.Fatal(err)
+	}
+	client := newBodyRecorder(b, true).client(0)
+	inputs := []voyageai.MultimodalContent{{Content: []voyageai.MultimodalInput{voyageai.Multimodal(stream)}}}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := client.MultimodalEmbed(inputs, "voyage-multimodal-3", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Options for [VoyageClient.IndexFS].
+type IndexOpts struct {
+	// Selects the files to index. Patterns containing a '/' are matched against the file's path,
+	// all others against its base name, using [path.Match]. All files are indexed by default.
+	Glob        string
+	ChunkOpts   ChunkOpts             // How each file is split into chunks.
+	Model       string                // Name of the embedding model.
+	EmbedOpts   *EmbeddingRequestOpts // Optional parameters for the embedding requests.
+	BatchSize   int                   // The maximum number of chunks per request. Defaults to [DefaultBatchSize].
+	Concurrency int                   // The maximum number of concurrent requests. Defaults to 1.
+}
+
+// An embedded chunk of a file, delivered by [VoyageClient.IndexFS], or of a text embedded with
+// [VoyageClient.EmbedChunks].
+type ChunkEmbedding struct {
+	Path string // The file's path within the indexed file system. Empty for [VoyageClient.EmbedChunks].
+	Chunk
+	Embedding []float32
+}
+
+// A summary of an [VoyageClient.IndexFS] run.
+type IndexResult struct {
+	Files         int // The number of files that were chunked and embedded.
+	Chunks        int // The number of chunks delivered to the sink.
+	SkippedBinary int // The number of matching files skipped because they do not contain UTF-8 text.
+}
+
+// IndexFS walks fsys, splits every matching text file into chunks with [ChunkFile], embeds the
+// chunks and calls sink with each chunk's file path, offsets and embedding. Files that do not look
+// like UTF-8 text are skipped and counted in the result.
+//
+// Requests are batched across files and issued concurrently as configured in opts. sink is never
+// called concurrently, but chunks are not delivered in any particular order. If sink returns an
+// error, the walk is stopped and that error is returned.
+func (c *VoyageClient) IndexFS(ctx context.Context, fsys fs.FS, opts IndexOpts, sink func(ChunkEmbedding) error) (*IndexResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	result := &IndexResult{}
+	pending := map[int]ChunkEmbedding{}
+
+	in := make(chan IndexedText)
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(in)
+		next := 0
+		walkErr <- fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !matchGlob(opts.Glob, p) {
+				return nil
+			}
+
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			if !isText(data) {
+				result.SkippedBinary++
+				mu.Unlock()
+				return nil
+			}
+			result.Files++
+			mu.Unlock()
+
+			for _, chunk := range ChunkFile(p, string(data), opts.ChunkOpts) {
+				mu.Lock()
+				pending[next] = ChunkEmbedding{Path: p, Chunk: chunk}
+				mu.Unlock()
+				select {
+				case in <- IndexedText{Index: next, Text: chunk.embedText(opts.ChunkOpts)}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				next++
+			}
+			return nil
+		})
+	}()
+
+	out, errc := c.EmbedStream(ctx, in, opts.Model, opts.EmbedOpts, StreamConfig{
+		BatchSize:   opts.BatchSize,
+		MaxInFlight: opts.Concurrency,
+	})
+
+	var sinkErr error
+	for res := range out {
+		if sinkErr != nil {
+			continue
+		}
+		mu.Lock()
+		ce := pending[res.Index]
+		delete(pending, res.Index)
+		mu.Unlock()
+
+		ce.Embedding = res.Embedding
+		if err := sink(ce); err != nil {
+			sinkErr = fmt.Errorf("voyage: sink %s [%d:%d]: %w", ce.Path, ce.Start, ce.End, err)
+			cancel()
+			continue
+		}
+		result.Chunks++
+	}
+
+	streamErr := <-errc
+	cancel()
+	werr := <-walkErr
+	switch {
+	case sinkErr != nil:
+		return result, sinkErr
+	case streamErr != nil:
+		return result, streamErr
+	case werr != nil:
+		return result, fmt.Errorf("voyage: walk: %w", werr)
+	}
+	return result, nil
+}
+
+// matchGlob reports whether p matches the pattern as documented on [IndexOpts.Glob].
+func matchGlob(pattern, p string) bool {
+	if pattern == "" {
+		return true
+	}
+	name := p
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(p)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// isText reports whether data looks like UTF-8 text, based on its content.
+func isText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	return strings.HasPrefix(http.DetectContentType(data), "text/")
+}
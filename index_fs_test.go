@@ -0,0 +1,95 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/zamedic/voyageai"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"docs/a.md":      {Data: []byte(strings.Repeat("alpha beta gamma ", 30))},
+		"docs/b.md":      {Data: []byte("a short note")},
+		"docs/image.md":  {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		"docs/blob.md":   {Data: []byte{0xff, 0xfe, 0x00, 0x01}},
+		"docs/skip.txt":  {Data: []byte("not matched by the glob")},
+		"docs/sub/c.md":  {Data: []byte("nested file")},
+		"docs/empty.md":  {Data: nil},
+		"other/d.md":     {Data: []byte("another directory")},
+		"other/skip.bin": {Data: []byte{0, 1, 2, 3}},
+	}
+}
+
+func TestIndexFS(t *testing.T) {
+	srv := newMockServer(t)
+	fsys := testFS()
+
+	var got []voyageai.ChunkEmbedding
+	res, err := srv.client().IndexFS(context.Background(), fsys, voyageai.IndexOpts{
+		Glob:        "*.md",
+		ChunkOpts:   voyageai.ChunkOpts{MaxTokens: 20},
+		Model:       "test-model",
+		BatchSize:   3,
+		Concurrency: 2,
+	}, func(ce voyageai.ChunkEmbedding) error {
+		got = append(got, ce)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if res.SkippedBinary != 2 {
+		t.Errorf("Expected 2 binary files to be skipped, got %d", res.SkippedBinary)
+	}
+	if res.Files != 5 || res.Chunks != len(got) {
+		t.Errorf("Unexpected result %+v for %d sink calls", res, len(got))
+	}
+
+	paths := map[string]bool{}
+	for _, ce := range got {
+		paths[ce.Path] = true
+		src := string(fsys[ce.Path].Data)
+		if src[ce.Start:ce.End] != ce.Text {
+			t.Errorf("Offsets of chunk %q in %s are wrong", ce.Text, ce.Path)
+		}
+		if !slices.Equal(ce.Embedding, fakeVector(ce.Text)) {
+			t.Errorf("Wrong embedding for chunk %q", ce.Text)
+		}
+	}
+	for _, p := range []string{"docs/a.md", "docs/b.md", "docs/sub/c.md", "other/d.md"} {
+		if !paths[p] {
+			t.Errorf("Expected chunks from %s", p)
+		}
+	}
+	if paths["docs/skip.txt"] {
+		t.Error("Expected docs/skip.txt to be excluded by the glob")
+	}
+}
+
+func TestIndexFSSinkError(t *testing.T) {
+	srv := newMockServer(t)
+	errSink := errors.New("disk full")
+
+	calls := 0
+	_, err := srv.client().IndexFS(context.Background(), testFS(), voyageai.IndexOpts{
+		Glob:      "docs/*.md",
+		ChunkOpts: voyageai.ChunkOpts{MaxTokens: 5},
+		Model:     "test-model",
+		BatchSize: 1,
+	}, func(ce voyageai.ChunkEmbedding) error {
+		calls++
+		return errSink
+	})
+	if !errors.Is(err, errSink) {
+		t.Fatalf("Expected the sink error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the sink to be called once, got %d", calls)
+	}
+}
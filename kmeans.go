@@ -0,0 +1,164 @@
+package voyageai
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+)
+
+// Options for [KMeans].
+type KMeansOpts struct {
+	MaxIter  int     // The maximum number of iterations. Defaults to 100.
+	Seed     int64   // Seeds the k-means++ initialization. Results are deterministic for a given seed.
+	MinDelta float64 // Stop once no centroid moves by more than this distance in an iteration. Defaults to 0, which stops when assignments no longer change.
+	Metric   Metric  // The distance used to assign vectors to centroids. Defaults to [MetricCosine].
+}
+
+// KMeans partitions vecs into k clusters and returns the cluster of each vector along with the
+// cluster centroids. Centroids are initialized with k-means++.
+//
+// With [MetricCosine], vectors are clustered by direction (spherical k-means) and the centroids
+// are unit length. If k is greater than the number of vectors, k is reduced to len(vecs). A
+// cluster that becomes empty during an iteration is reseeded with the vector farthest from its
+// current centroid, so every returned cluster has at least one member.
+func KMeans(vecs [][]float32, k int, opts KMeansOpts) (assignments []int, centroids [][]float32, err error) {
+	if len(vecs) == 0 {
+		return nil, nil, errors.New("voyage: k-means needs at least one vector")
+	}
+	if k <= 0 {
+		return nil, nil, errors.New("voyage: k-means needs k > 0")
+	}
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, nil, err
+	}
+	k = min(k, len(vecs))
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+
+	points := vecs
+	if opts.Metric == MetricCosine {
+		points = make([][]float32, len(vecs))
+		for i, v := range vecs {
+			points[i] = normalized(v)
+		}
+	}
+	dist := opts.Metric.distance
+
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), 0))
+	centroids = kmeansPlusPlus(points, k, dist, rng)
+	assignments = make([]int, len(points))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for range maxIter {
+		changed := false
+		for i, p := range points {
+			best, bestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := dist(p, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+
+		next := make([][]float32, k)
+		counts := make([]int, k)
+		for c := range next {
+			next[c] = make([]float32, dim)
+		}
+		for i, p := range points {
+			c := assignments[i]
+			counts[c]++
+			for d, x := range p {
+				next[c][d] += x
+			}
+		}
+		for c := range next {
+			if counts[c] == 0 {
+				// Reseed the empty cluster with the vector farthest from its centroid.
+				far, farDist := 0, -1.0
+				for i, p := range points {
+					if counts[assignments[i]] > 1 {
+						if d := dist(p, centroids[assignments[i]]); d > farDist {
+							far, farDist = i, d
+						}
+					}
+				}
+				counts[assignments[far]]--
+				for d, x := range points[far] {
+					next[assignments[far]][d] -= x
+				}
+				assignments[far] = c
+				counts[c] = 1
+				copy(next[c], points[far])
+				changed = true
+			}
+		}
+		for c := range next {
+			for d := range next[c] {
+				next[c][d] /= float32(counts[c])
+			}
+			if opts.Metric == MetricCosine {
+				next[c] = normalized(next[c])
+			}
+		}
+
+		moved := 0.0
+		for c := range centroids {
+			moved = max(moved, euclidean(centroids[c], next[c]))
+		}
+		centroids = next
+		if !changed || moved <= opts.MinDelta {
+			break
+		}
+	}
+	return assignments, centroids, nil
+}
+
+// kmeansPlusPlus picks k initial centroids, each chosen with probability proportional to its
+// squared distance from the nearest centroid picked so far.
+func kmeansPlusPlus(points [][]float32, k int, dist func(a, b []float32) float64, rng *rand.Rand) [][]float32 {
+	centroids := [][]float32{append([]float32(nil), points[rng.IntN(len(points))]...)}
+	nearest := make([]float64, len(points))
+	for i, p := range points {
+		nearest[i] = dist(p, centroids[0])
+	}
+
+	for len(centroids) < k {
+		var total float64
+		for _, d := range nearest {
+			total += d * d
+		}
+
+		pick := 0
+		if total == 0 {
+			// All remaining points coincide with a centroid: pick the first not yet chosen.
+			pick = len(centroids)
+		} else {
+			r := rng.Float64() * total
+			for i, d := range nearest {
+				r -= d * d
+				if r <= 0 {
+					pick = i
+					break
+				}
+				pick = i
+			}
+		}
+
+		centroid := append([]float32(nil), points[pick]...)
+		centroids = append(centroids, centroid)
+		for i, p := range points {
+			nearest[i] = min(nearest[i], dist(p, centroid))
+		}
+	}
+	return centroids
+}
@@ -0,0 +1,97 @@
+package voyageai_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// clusterFixture returns 60 vectors drawn around three well separated centers, and the index of
+// the center each was drawn from.
+func clusterFixture() ([][]float32, []int) {
+	rng := rand.New(rand.NewPCG(3, 0))
+	centers := [][]float32{
+		{10, 0, 0, 0},
+		{0, 10, 0, 0},
+		{0, 0, 10, 0},
+	}
+	var vecs [][]float32
+	var labels []int
+	for i := range 60 {
+		c := i % 3
+		vecs = append(vecs, jitter(rng, centers[c], 0.5))
+		labels = append(labels, c)
+	}
+	return vecs, labels
+}
+
+func TestKMeansRecoversClusters(t *testing.T) {
+	vecs, labels := clusterFixture()
+
+	for _, metric := range []voyageai.Metric{voyageai.MetricCosine, voyageai.MetricEuclidean} {
+		assignments, centroids, err := voyageai.KMeans(vecs, 3, voyageai.KMeansOpts{Seed: 42, Metric: metric})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(centroids) != 3 {
+			t.Fatalf("Expected 3 centroids, got %d", len(centroids))
+		}
+
+		// Every true cluster must map to exactly one assigned cluster.
+		mapping := map[int]int{}
+		for i, a := range assignments {
+			if m, ok := mapping[labels[i]]; ok && m != a {
+				t.Fatalf("%v: cluster %d was split", metric, labels[i])
+			}
+			mapping[labels[i]] = a
+		}
+		seen := map[int]bool{}
+		for _, a := range mapping {
+			seen[a] = true
+		}
+		if len(seen) != 3 {
+			t.Errorf("%v: expected three distinct clusters, got %v", metric, mapping)
+		}
+	}
+}
+
+func TestKMeansDeterministic(t *testing.T) {
+	vecs, _ := clusterFixture()
+	a1, c1, _ := voyageai.KMeans(vecs, 5, voyageai.KMeansOpts{Seed: 9})
+	a2, c2, _ := voyageai.KMeans(vecs, 5, voyageai.KMeansOpts{Seed: 9})
+	if !slices.Equal(a1, a2) {
+		t.Error("Expected identical assignments for the same seed")
+	}
+	for i := range c1 {
+		if !slices.Equal(c1[i], c2[i]) {
+			t.Error("Expected identical centroids for the same seed")
+		}
+	}
+}
+
+func TestKMeansDegenerateCases(t *testing.T) {
+	vecs := [][]float32{{1, 0}, {0, 1}, {1, 0}}
+	assignments, centroids, err := voyageai.KMeans(vecs, 10, voyageai.KMeansOpts{Metric: voyageai.MetricEuclidean})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(centroids) != 3 {
+		t.Errorf("Expected k to be reduced to 3, got %d centroids", len(centroids))
+	}
+	counts := map[int]int{}
+	for _, a := range assignments {
+		counts[a]++
+	}
+	if len(counts) != 3 {
+		t.Errorf("Expected every cluster to keep a member, got %v", assignments)
+	}
+
+	if _, _, err := voyageai.KMeans(nil, 2, voyageai.KMeansOpts{}); err == nil {
+		t.Error("Expected an error for empty input")
+	}
+	if _, _, err := voyageai.KMeans(vecs, 0, voyageai.KMeansOpts{}); err == nil {
+		t.Error("Expected an error for k = 0")
+	}
+}
@@ -0,0 +1,305 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// Returned, wrapped, when a request could not be admitted by the client's [Limiter] within
+	// [RateLimitOpts.MaxWait].
+	ErrLimiterTimeout = errors.New("voyage: timed out waiting for rate limit")
+	// Returned, wrapped, when the client's [Limiter] fails for another reason, such as its
+	// backend being unreachable. See [RateLimitOpts.FailOpen].
+	ErrLimiterUnavailable = errors.New("voyage: rate limiter unavailable")
+)
+
+// Admits the requests of one or more clients against a shared quota. Implementations backed by
+// a shared store, such as Redis or a database, let several processes stay within one
+// account-wide limit. See [VoyageClientOpts.RateLimit]. Implementations must be safe for
+// concurrent use.
+type Limiter interface {
+	// Acquire blocks until a request with the given estimated number of tokens may be sent, or
+	// returns an error if ctx is done first. Implementations that know up front that the wait
+	// would outlast ctx's deadline may return [ErrLimiterTimeout] immediately.
+	Acquire(ctx context.Context, tokens int) error
+	// Feedback is called with the HTTP status of every request admitted by Acquire, or zero if
+	// no response was received, so the limiter can back off when the API reports 429.
+	Feedback(ctx context.Context, statusCode int)
+}
+
+// Configures how a client limits its request rate.
+type RateLimitOpts struct {
+	// The limiter consulted before every HTTP request, including retries. The client waits in
+	// Acquire for one request at a time, taking requests in order of priority. Defaults to an
+	// in-process [TokenBucketLimiter] with the limits below.
+	Limiter Limiter
+	// The limits of the default limiter. Zero means unlimited. Ignored if Limiter is set.
+	RequestsPerMinute float64
+	TokensPerMinute   float64
+	// The longest a request waits to be admitted before failing with [ErrLimiterTimeout].
+	// Unlimited by default, in which case requests wait as long as their context allows.
+	MaxWait time.Duration
+	// Send requests anyway when the limiter fails with an error other than a timeout, logging a
+	// warning to [VoyageClientOpts.Logger]. By default such requests fail with
+	// [ErrLimiterUnavailable].
+	FailOpen bool
+	// Limits for single models, keyed by model name, as the API enforces its limits per model.
+	// Each listed model gets its own in-process [TokenBucketLimiter], consulted before the
+	// limiter above, which still applies to all models. Aliases are resolved before the lookup.
+	Models map[string]ModelRateLimit
+}
+
+// The requests and tokens per minute allowed for one model by [RateLimitOpts.Models]. Zero means
+// unlimited.
+type ModelRateLimit struct {
+	RequestsPerMinute float64
+	TokensPerMinute   float64
+}
+
+// An in-process [Limiter] with token buckets for requests and tokens per minute. Both buckets
+// start full, hold a minute's worth, and refill continuously. A request for more tokens than a
+// minute's worth waits for a full bucket.
+type TokenBucketLimiter struct {
+	clock Clock
+
+	mu       sync.Mutex
+	requests limitBucket
+	tokens   limitBucket
+}
+
+type limitBucket struct {
+	perMinute float64 // Zero if unlimited.
+	level     float64 // May go negative while requests wait for their reservation.
+	last      time.Time
+}
+
+// refill adds what accrued up to now.
+func (b *limitBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.level = math.Min(b.perMinute, b.level+elapsed.Minutes()*b.perMinute)
+	}
+	b.last = now
+}
+
+// wait returns how long until n is available.
+func (b *limitBucket) wait(n float64) time.Duration {
+	if b.perMinute == 0 || b.level >= n {
+		return 0
+	}
+	return time.Duration((n - b.level) / b.perMinute * float64(time.Minute))
+}
+
+// NewTokenBucketLimiter returns a limiter allowing requestsPerMinute requests and
+// tokensPerMinute tokens per minute, where zero is unlimited. clock defaults to the system clock.
+func NewTokenBucketLimiter(clock Clock, requestsPerMinute, tokensPerMinute float64) *TokenBucketLimiter {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	now := clock.Now()
+	return &TokenBucketLimiter{
+		clock:    clock,
+		requests: limitBucket{perMinute: requestsPerMinute, level: requestsPerMinute, last: now},
+		tokens:   limitBucket{perMinute: tokensPerMinute, level: tokensPerMinute, last: now},
+	}
+}
+
+func (l *TokenBucketLimiter) Acquire(ctx context.Context, tokens int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.requests.refill(now)
+	l.tokens.refill(now)
+	n := math.Min(float64(tokens), l.tokens.perMinute)
+	wait := max(l.requests.wait(1), l.tokens.wait(n))
+	if deadline, ok := ctx.Deadline(); ok && wait > 0 && wait > time.Until(deadline) {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: admission in %v is past the deadline", ErrLimiterTimeout, wait)
+	}
+	// Reserve now so later requests queue behind this one.
+	l.requests.level--
+	l.tokens.level -= n
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-l.clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.requests.level++
+		l.tokens.level += n
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Feedback empties the buckets when the API reports 429, so requests pause until they refill.
+func (l *TokenBucketLimiter) Feedback(ctx context.Context, statusCode int) {
+	if statusCode != 429 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.requests.refill(now)
+	l.tokens.refill(now)
+	l.requests.level = math.Min(l.requests.level, 0)
+	l.tokens.level = math.Min(l.tokens.level, 0)
+}
+
+// A Limiter whose waiters call Acquire one at a time, in order of priority, so that low priority
+// requests do not reserve the limit's capacity ahead of high priority ones. See [WithPriority].
+type priorityLimiter struct {
+	Limiter
+	turns *prioritySem // Holds a single turn to call Acquire.
+}
+
+func newPriorityLimiter(l Limiter, clock Clock, aging time.Duration) *priorityLimiter {
+	return &priorityLimiter{Limiter: l, turns: newPrioritySem(1, clock, aging)}
+}
+
+// acquire waits for the turn of a request of priority p, then for the limiter to admit it.
+func (l *priorityLimiter) acquire(ctx context.Context, p Priority, tokens int) error {
+	if err := l.turns.acquire(ctx, p); err != nil {
+		return err
+	}
+	defer l.turns.release()
+	return l.Acquire(ctx, tokens)
+}
+
+// newLimiter returns the limiter configured by opts, or nil. Its waiters age as configured by
+// [VoyageClientOpts.PriorityAging].
+func newLimiter(clock Clock, aging time.Duration, opts *RateLimitOpts) *priorityLimiter {
+	if opts == nil {
+		return nil
+	}
+	if opts.Limiter != nil {
+		return newPriorityLimiter(opts.Limiter, clock, aging)
+	}
+	if opts.RequestsPerMinute <= 0 && opts.TokensPerMinute <= 0 {
+		return nil
+	}
+	return newPriorityLimiter(NewTokenBucketLimiter(clock, opts.RequestsPerMinute, opts.TokensPerMinute), clock, aging)
+}
+
+// newModelLimiters returns a limiter for every model limited by opts, or nil.
+func newModelLimiters(clock Clock, aging time.Duration, opts *RateLimitOpts) map[string]*priorityLimiter {
+	if opts == nil {
+		return nil
+	}
+	var limiters map[string]*priorityLimiter
+	for model, limit := range opts.Models {
+		if limit.RequestsPerMinute <= 0 && limit.TokensPerMinute <= 0 {
+			continue
+		}
+		if limiters == nil {
+			limiters = map[string]*priorityLimiter{}
+		}
+		limiters[model] = newPriorityLimiter(NewTokenBucketLimiter(clock, limit.RequestsPerMinute, limit.TokensPerMinute), clock, aging)
+	}
+	return limiters
+}
+
+// limitersFor returns the limiters that admit a request body: its model's, then the client's.
+func (c *VoyageClient) limitersFor(reqBody any) []*priorityLimiter {
+	var limiters []*priorityLimiter
+	if l, ok := c.modelLimiters[requestModel(reqBody)]; ok {
+		limiters = append(limiters, l)
+	}
+	if c.limiter != nil {
+		limiters = append(limiters, c.limiter)
+	}
+	return limiters
+}
+
+// admit waits for the limiters of a request to admit it, in order of the priority of ctx, and
+// returns them. The returned error wraps [ErrLimiterTimeout], [ErrLimiterUnavailable] or ctx's
+// error.
+func (c *VoyageClient) admit(ctx context.Context, reqBody any) ([]*priorityLimiter, error) {
+	limiters := c.limitersFor(reqBody)
+	if len(limiters) == 0 {
+		return nil, nil
+	}
+	tokens, err := c.requestTokens(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	opts := c.opts.RateLimit
+	waitCtx := ctx
+	if opts.MaxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opts.MaxWait)
+		defer cancel()
+	}
+	for _, l := range limiters {
+		if err := c.acquireLimit(ctx, waitCtx, l, tokens); err != nil {
+			return nil, err
+		}
+	}
+	return limiters, nil
+}
+
+// acquireLimit waits for l to admit a request of the given tokens with waitCtx, a child of ctx
+// limited to [RateLimitOpts.MaxWait].
+func (c *VoyageClient) acquireLimit(ctx, waitCtx context.Context, l *priorityLimiter, tokens int) error {
+	opts := c.opts.RateLimit
+	err := l.acquire(waitCtx, priorityFrom(ctx), tokens)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case errors.Is(err, ErrLimiterTimeout):
+		return err
+	case errors.Is(err, context.DeadlineExceeded) && waitCtx.Err() != nil:
+		return fmt.Errorf("%w after %v", ErrLimiterTimeout, opts.MaxWait)
+	case opts.FailOpen:
+		c.loggerFor(ctx).Warn("voyage: rate limiter failed, sending request anyway", "error", err)
+		return nil
+	default:
+		return fmt.Errorf("%w: %w", ErrLimiterUnavailable, err)
+	}
+}
+
+// requestTokens estimates the tokens of a request body with the client's tokenizer. Multimodal
+// requests count as one request without tokens.
+func (c *VoyageClient) requestTokens(reqBody any) (int, error) {
+	switch r := reqBody.(type) {
+	case *EmbeddingRequest:
+		return c.countTokens(r.Model, r.Input...)
+	case *ContextualizedEmbeddingRequest:
+		var texts []string
+		for _, chunks := range r.Inputs {
+			texts = append(texts, chunks...)
+		}
+		return c.countTokens(r.Model, texts...)
+	case *RerankRequest:
+		q, err := c.countTokens(r.Model, r.Query)
+		if err != nil {
+			return 0, err
+		}
+		docs, err := c.countTokens(r.Model, r.Documents...)
+		return q*len(r.Documents) + docs, err
+	case *MultimodalRerankRequest:
+		docs := make([]string, len(r.Documents))
+		for i, doc := range r.Documents {
+			docs[i] = doc.text()
+		}
+		return c.requestTokens(&RerankRequest{Query: r.Query.text(), Documents: docs, Model: r.Model})
+	case *rerankSharedRequest:
+		var docs []string
+		json.Unmarshal(r.Documents, &docs)
+		return c.requestTokens(&RerankRequest{Query: r.Query, Documents: docs, Model: r.Model})
+	}
+	return 0, nil
+}
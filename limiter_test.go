@@ -0,0 +1,294 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// sharedQuota is a Limiter standing in for a shared backend: a pool of permits used by several
+// clients, refilled only by refill. Acquire waits for a permit until ctx is done.
+type sharedQuota struct {
+	mu       sync.Mutex
+	permits  int
+	err      error
+	refilled chan struct{}
+	statuses []int
+}
+
+func newSharedQuota(permits int) *sharedQuota {
+	return &sharedQuota{permits: permits, refilled: make(chan struct{})}
+}
+
+func (q *sharedQuota) Acquire(ctx context.Context, tokens int) error {
+	for {
+		q.mu.Lock()
+		if q.err != nil {
+			q.mu.Unlock()
+			return q.err
+		}
+		if q.permits > 0 {
+			q.permits--
+			q.mu.Unlock()
+			return nil
+		}
+		refilled := q.refilled
+		q.mu.Unlock()
+		select {
+		case <-refilled:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *sharedQuota) Feedback(ctx context.Context, statusCode int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.statuses = append(q.statuses, statusCode)
+}
+
+func (q *sharedQuota) refill(permits int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.permits += permits
+	close(q.refilled)
+	q.refilled = make(chan struct{})
+}
+
+func TestSharedLimiter(t *testing.T) {
+	api := newMockServer(t)
+	quota := newSharedQuota(3)
+	newClient := func() *voyageai.VoyageClient {
+		return voyageai.NewClient(&voyageai.VoyageClientOpts{
+			Key:       "APIKEY",
+			BaseURL:   api.URL,
+			RateLimit: &voyageai.RateLimitOpts{Limiter: quota, MaxWait: 20 * time.Millisecond},
+		})
+	}
+	a, b := newClient(), newClient()
+
+	for i, c := range []*voyageai.VoyageClient{a, a, b} {
+		if _, err := c.Embed([]string{"x"}, "voyage-3", nil); err != nil {
+			t.Fatalf("Request %d: %v", i, err)
+		}
+	}
+	// The quota is used up by both clients together.
+	for _, c := range []*voyageai.VoyageClient{a, b} {
+		if _, err := c.Embed([]string{"x"}, "voyage-3", nil); !errors.Is(err, voyageai.ErrLimiterTimeout) {
+			t.Errorf("Expected ErrLimiterTimeout, got %v", err)
+		}
+	}
+	if n := len(api.requests); n != 3 {
+		t.Errorf("Expected 3 requests to reach the API, got %d", n)
+	}
+
+	// A waiting request goes through once the quota refills.
+	done := make(chan error)
+	go func() {
+		_, err := b.Rerank("q", []string{"d"}, "rerank-2", nil)
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	quota.refill(1)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the request to be admitted after the refill, got %v", err)
+	}
+	if len(quota.statuses) != 4 || quota.statuses[3] != 200 {
+		t.Errorf("Expected feedback for every admitted request, got %v", quota.statuses)
+	}
+}
+
+func TestLimiterFailure(t *testing.T) {
+	api := newMockServer(t)
+	quota := newSharedQuota(10)
+	quota.err = errors.New("connection refused")
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:       "APIKEY",
+		BaseURL:   api.URL,
+		RateLimit: &voyageai.RateLimitOpts{Limiter: quota},
+	})
+	_, err := client.Embed([]string{"x"}, "voyage-3", nil)
+	if !errors.Is(err, voyageai.ErrLimiterUnavailable) || errors.Is(err, voyageai.ErrLimiterTimeout) || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected ErrLimiterUnavailable, got %v", err)
+	}
+	if len(api.requests) != 0 {
+		t.Error("Expected no request to be sent")
+	}
+
+	var logs bytes.Buffer
+	open := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:       "APIKEY",
+		BaseURL:   api.URL,
+		RateLimit: &voyageai.RateLimitOpts{Limiter: quota, FailOpen: true},
+		Logger:    slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if _, err := open.Embed([]string{"x"}, "voyage-3", nil); err != nil {
+		t.Errorf("Expected the request to fail open, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "connection refused") {
+		t.Errorf("Expected a warning, got %q", logs.String())
+	}
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	api := newMockServer(t)
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:       "APIKEY",
+		BaseURL:   api.URL,
+		Clock:     clock,
+		RateLimit: &voyageai.RateLimitOpts{RequestsPerMinute: 2},
+	})
+	client.Embed([]string{"x"}, "voyage-3", nil)
+	client.Embed([]string{"x"}, "voyage-3", nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Embed([]string{"x"}, "voyage-3", nil)
+		done <- err
+	}()
+	clock.waitForTimers(t, 1)
+	if len(api.requests) != 2 {
+		t.Fatal("Expected the third request to wait")
+	}
+	clock.Advance(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// A deadline that ends before the next slot fails without waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.EmbedContext(ctx, []string{"x"}, "voyage-3", nil); !errors.Is(err, voyageai.ErrLimiterTimeout) {
+		t.Errorf("Expected ErrLimiterTimeout, got %v", err)
+	}
+}
+
+func TestTokenBucketLimiterTokens(t *testing.T) {
+	clock := newFakeClock()
+	l := voyageai.NewTokenBucketLimiter(clock, 0, 100)
+	ctx := context.Background()
+	if err := l.Acquire(ctx, 80); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// 60 more tokens need 40 to accrue, which takes 24 seconds.
+	done := make(chan error)
+	go func() { done <- l.Acquire(ctx, 60) }()
+	clock.waitForTimers(t, 1)
+	clock.Advance(23 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("Expected Acquire to wait for the tokens")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// A 429 empties the bucket.
+	clock.Advance(time.Minute)
+	l.Feedback(ctx, 429)
+	go func() { done <- l.Acquire(ctx, 1) }()
+	clock.waitForTimers(t, 1)
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestModelRateLimits(t *testing.T) {
+	srv := newMockServer(t)
+	quota := newSharedQuota(100)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Aliases: map[string]string{"default-embed": "voyage-3.5"},
+		RateLimit: &voyageai.RateLimitOpts{
+			Limiter: quota,
+			MaxWait: 20 * time.Millisecond,
+			Models:  map[string]voyageai.ModelRateLimit{"voyage-3.5": {RequestsPerMinute: 2}},
+		},
+	})
+
+	for _, model := range []string{"voyage-3.5", "default-embed"} {
+		if _, err := client.Embed([]string{"a"}, model, nil); err != nil {
+			t.Fatalf("%s: %v", model, err)
+		}
+	}
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", nil); !errors.Is(err, voyageai.ErrLimiterTimeout) {
+		t.Errorf("Expected the model's limit to be reached, got %v", err)
+	}
+	for range 3 {
+		if _, err := client.Embed([]string{"a"}, "voyage-3-large", nil); err != nil {
+			t.Errorf("Expected other models to be unaffected, got %v", err)
+		}
+	}
+
+	quota.mu.Lock()
+	defer quota.mu.Unlock()
+	if len(quota.statuses) != 5 {
+		t.Errorf("Expected the shared limiter to admit the 5 requests sent, got %v", quota.statuses)
+	}
+}
+
+func TestRateLimitPriority(t *testing.T) {
+	srv, order := newSerialServer(t, 0)
+	// One request per 100ms, with the bucket's initial minute's worth used up.
+	limiter := voyageai.NewTokenBucketLimiter(nil, 600, 0)
+	for range 600 {
+		limiter.Acquire(context.Background(), 0)
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:       "APIKEY",
+		BaseURL:   srv.URL,
+		RateLimit: &voyageai.RateLimitOpts{Limiter: limiter},
+	})
+
+	// Saturate the limit with low priority batches of four sub-batches each.
+	low := voyageai.WithPriority(context.Background(), voyageai.PriorityLow)
+	var wg sync.WaitGroup
+	for b := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var texts []string
+			for i := range 4 {
+				texts = append(texts, fmt.Sprintf("low-%d-%d", b, i))
+			}
+			if _, err := client.EmbedBatch(low, texts, "test-model", nil, &voyageai.BatchOpts{BatchSize: 1}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	started := len(order())
+	start := time.Now()
+	if _, err := client.EmbedContext(context.Background(), []string{"high"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	highDelay := time.Since(start)
+	wg.Wait()
+
+	// The high priority call only waits for the low priority request already reserved when it
+	// arrived, not for those of the other batches.
+	served := order()
+	if pos := slices.Index(served, "high"); pos < started || pos > started+1 {
+		t.Errorf("Expected the high priority request at position %d, got %d: %v", started, pos, served)
+	}
+	if highDelay > 250*time.Millisecond {
+		t.Errorf("Expected the high priority call to wait about two intervals, waited %v", highDelay)
+	}
+}
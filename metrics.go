@@ -0,0 +1,159 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Receives measurements from a client. See [VoyageClientOpts.Metrics].
+// Implementations must be safe for concurrent use and should return quickly, since they are
+// called on the request path.
+type MetricsHook interface {
+	// ObserveRequest is called once per API request, after its last attempt.
+	ObserveRequest(RequestMetrics)
+	// ObserveCache is called for every response cache lookup.
+	ObserveCache(CacheMetrics)
+}
+
+// Measurements of one API request, including its retries.
+type RequestMetrics struct {
+	Endpoint string        // The API endpoint, such as "embeddings" or "rerank".
+	Model    string        // The requested model.
+	Attempts int           // The number of HTTP requests sent.
+	Duration time.Duration // The time from the first attempt until the last one finished.
+	Usage    UsageObject   // The usage reported by a successful response.
+	Err      error         // The error returned for the request, if any.
+	// The metadata of the call, attached with [WithRequestMetadata].
+	Metadata map[string]string
+	// The number of inputs of the request: texts, documents or multimodal contents.
+	Inputs int
+	// The HTTP status code of the last response. Zero if none was received.
+	StatusCode int
+}
+
+// Traces the API requests of a client, such as with OpenTelemetry spans; see
+// [VoyageClientOpts.Tracer]. Implementations must be safe for concurrent use.
+type RequestTracer interface {
+	// StartRequest is called before the first attempt of a request to endpoint with model. The
+	// returned context is used for the attempts, so a span it carries is passed on to
+	// [VoyageClientOpts.TraceInjector]. end is called once, after the last attempt.
+	StartRequest(ctx context.Context, endpoint, model string) (_ context.Context, end func(RequestMetrics))
+}
+
+// Optionally implemented by a [MetricsHook] to be told when a helper answers with a degraded
+// result instead of failing. See [ResponseMetadata.Degraded].
+type DegradationObserver interface {
+	ObserveDegraded(DegradedMetrics)
+}
+
+// A degraded result.
+type DegradedMetrics struct {
+	Endpoint string            // The endpoint whose call failed, such as "rerank".
+	Model    string            // The requested model.
+	Err      error             // The error of the failed call.
+	Metadata map[string]string // The metadata of the call, attached with [WithRequestMetadata].
+}
+
+// A retry about to be made. See [VoyageClientOpts.OnRetry].
+type RetryEvent struct {
+	Endpoint string            // The API endpoint, such as "embeddings" or "rerank".
+	Model    string            // The requested model.
+	Attempt  int               // The retry about to be made, counted from 1.
+	Err      error             // The error of the previous attempt.
+	Metadata map[string]string // The metadata of the call, attached with [WithRequestMetadata].
+}
+
+// The outcome of a response cache lookup.
+type CacheResult int
+
+const (
+	CacheHit   CacheResult = iota // A fresh entry was found.
+	CacheMiss                     // No fresh entry was found and the API was called.
+	CacheStale                    // The API call failed and an expired entry was served instead. See [CacheOpts.StaleIfError].
+)
+
+func (r CacheResult) String() string {
+	switch r {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CacheStale:
+		return "stale"
+	}
+	return "unknown"
+}
+
+// A response cache lookup. Embedding lookups are per input, rerank lookups per call.
+type CacheMetrics struct {
+	Endpoint string
+	Model    string
+	Result   CacheResult
+	// For [CacheStale], how long ago the served entry expired.
+	Staleness time.Duration
+	// The metadata of the call, attached with [WithRequestMetadata].
+	Metadata map[string]string
+}
+
+func (c *VoyageClient) observeRequest(ctx context.Context, m RequestMetrics) {
+	if c.opts.Metrics != nil {
+		m.Metadata = RequestMetadata(ctx)
+		c.opts.Metrics.ObserveRequest(m)
+	}
+}
+
+func (c *VoyageClient) observeCache(ctx context.Context, m CacheMetrics) {
+	if c.opts.Metrics != nil {
+		m.Metadata = RequestMetadata(ctx)
+		c.opts.Metrics.ObserveCache(m)
+	}
+}
+
+// observeRetry tells the client's OnRetry callback about a retry.
+func (c *VoyageClient) observeRetry(ctx context.Context, e RetryEvent) {
+	if c.opts.OnRetry != nil {
+		e.Metadata = RequestMetadata(ctx)
+		c.opts.OnRetry(e)
+	}
+}
+
+// requestInputs returns the number of inputs of a request body.
+func requestInputs(reqBody any) int {
+	switch r := reqBody.(type) {
+	case *EmbeddingRequest:
+		return len(r.Input)
+	case *MultimodalRequest:
+		return len(r.Inputs)
+	case *ContextualizedEmbeddingRequest:
+		return len(r.Inputs)
+	case *MultimodalRerankRequest:
+		return len(r.Documents)
+	case *RerankRequest:
+		return len(r.Documents)
+	case *rerankSharedRequest:
+		var docs []json.RawMessage
+		json.Unmarshal(r.Documents, &docs)
+		return len(docs)
+	}
+	return 0
+}
+
+// requestModel returns the model named in a request body.
+func requestModel(reqBody any) string {
+	switch r := reqBody.(type) {
+	case *EmbeddingRequest:
+		return r.Model
+	case *MultimodalRequest:
+		return r.Model
+	case *ContextualizedEmbeddingRequest:
+		return r.Model
+	case *MultimodalRerankRequest:
+		return r.Model
+	case *RerankRequest:
+		return r.Model
+	case *rerankSharedRequest:
+		return r.Model
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package voyageai_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// recordingMetrics is a voyageai.MetricsHook that keeps every observation.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []voyageai.RequestMetrics
+	cache    []voyageai.CacheMetrics
+}
+
+func (r *recordingMetrics) ObserveRequest(m voyageai.RequestMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, m)
+}
+
+func (r *recordingMetrics) ObserveCache(m voyageai.CacheMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = append(r.cache, m)
+}
+
+// cacheResults counts the recorded cache lookups by result.
+func (r *recordingMetrics) cacheResults() map[voyageai.CacheResult]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[voyageai.CacheResult]int{}
+	for _, m := range r.cache {
+		counts[m.Result]++
+	}
+	return counts
+}
+
+func TestMetricsObserveRequest(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, _ voyageai.EmbeddingRequest) int {
+		if n == 1 {
+			return 500
+		}
+		return 0
+	}
+	metrics := &recordingMetrics{}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 3, RetryBaseDelay: time.Millisecond, Metrics: metrics})
+
+	if _, err := client.EmbedContext(context.Background(), []string{"ab", "c"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.RerankContext(context.Background(), "q", []string{"d"}, "rerank-2", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(metrics.requests) != 2 {
+		t.Fatalf("Expected 2 observed requests, got %+v", metrics.requests)
+	}
+	embed := metrics.requests[0]
+	if embed.Endpoint != "embeddings" || embed.Model != "test-model" || embed.Attempts != 2 || embed.Usage.TotalTokens != 3 || embed.Err != nil ||
+		embed.Inputs != 2 || embed.StatusCode != 200 {
+		t.Errorf("Unexpected embedding metrics %+v", embed)
+	}
+	if rerank := metrics.requests[1]; rerank.Endpoint != "rerank" || rerank.Model != "rerank-2" || rerank.Attempts != 1 {
+		t.Errorf("Unexpected rerank metrics %+v", rerank)
+	}
+	if len(metrics.cache) != 0 {
+		t.Errorf("Expected no cache lookups without a cache, got %+v", metrics.cache)
+	}
+}
+
+func TestCacheResultString(t *testing.T) {
+	for r, want := range map[voyageai.CacheResult]string{voyageai.CacheHit: "hit", voyageai.CacheMiss: "miss", voyageai.CacheStale: "stale"} {
+		if r.String() != want {
+			t.Errorf("Expected %q, got %q", want, r.String())
+		}
+	}
+}
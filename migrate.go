@@ -0,0 +1,251 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// A text to embed, identified by the caller's ID. See [VoyageClient.MigrateEmbeddings].
+type Document struct {
+	ID       string
+	Text     string
+	Metadata map[string]string
+}
+
+// A document along with its embedding under the target model of a migration.
+type MigratedDocument struct {
+	Document
+	Embedding []float32
+}
+
+// A document that could not be embedded during a migration.
+type MigrationFailure struct {
+	ID  string
+	Err error
+}
+
+// The progress of a migration, reported to [MigrateOpts.OnProgress] after every batch.
+type MigrationProgress struct {
+	Processed int         // The number of documents embedded and delivered to the sink in this run.
+	Skipped   int         // The number of documents skipped because a previous run completed them.
+	Failed    int         // The number of documents that failed in this run.
+	Usage     UsageObject // Usage accumulated over this run and any resumed runs.
+}
+
+// The result of [VoyageClient.MigrateEmbeddings].
+type MigrationSummary struct {
+	MigrationProgress
+	// The estimated price in US dollars of Usage. Zero if the model's price is unknown; see
+	// [EstimateCost].
+	EstimatedCost float64
+	Failures      []MigrationFailure
+}
+
+// Options for [VoyageClient.MigrateEmbeddings].
+type MigrateOpts struct {
+	BatchSize   int                   // The maximum number of documents per request. Defaults to [DefaultBatchSize].
+	Concurrency int                   // The maximum number of concurrent requests. Defaults to 1.
+	EmbedOpts   *EmbeddingRequestOpts // Optional parameters for the embedding requests.
+	// Records which documents have been delivered so an interrupted migration can be resumed.
+	// Documents are identified by their position in the source, so a resumed run must read the
+	// same documents in the same order.
+	Checkpointer Checkpointer
+	// Called after every batch. It is never called concurrently.
+	OnProgress func(MigrationProgress)
+}
+
+// MigrateEmbeddings reads every document from source, embeds it with targetModel and passes the
+// result to sink. It is meant for re-embedding a stored corpus with a new model.
+//
+// Documents are embedded in batches, with up to [MigrateOpts.Concurrency] requests in flight.
+// sink is never called concurrently, but documents are not delivered in any particular order.
+// Batches that fail are recorded in the summary by document ID, and in the failure report of ctx
+// (see [WithFailureReport]), and the migration continues; they are retried by a resumed run. An error from source, sink or the checkpointer stops the
+// migration and is returned along with the summary so far.
+//
+// With a [Checkpointer], each document is recorded as done as soon as sink accepts it, and a
+// resumed run skips recorded documents, so every document is delivered once across runs as long
+// as the checkpoint is saved. A crash between sink and the save can deliver a batch twice.
+func (c *VoyageClient) MigrateEmbeddings(ctx context.Context, source iter.Seq2[Document, error], targetModel string, sink func(MigratedDocument) error, opts *MigrateOpts) (*MigrationSummary, error) {
+	if opts == nil {
+		opts = &MigrateOpts{}
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	state := &CheckpointState{Model: targetModel, Fingerprint: fingerprintInputs(targetModel, []string{"migrate"})}
+	if cp := opts.Checkpointer; cp != nil {
+		saved, ok, err := cp.Load()
+		if err != nil {
+			return nil, fmt.Errorf("voyage: load checkpoint: %w", err)
+		}
+		if ok {
+			if saved.Fingerprint != state.Fingerprint {
+				return nil, fmt.Errorf("voyage: checkpoint does not belong to a migration to %s", targetModel)
+			}
+			state = saved
+		}
+	}
+	done := append([]CompletedRange(nil), state.Completed...)
+	sort.Slice(done, func(i, j int) bool { return done[i].Start < done[j].Start })
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type item struct {
+		pos int
+		doc Document
+	}
+	var (
+		mu       sync.Mutex // Guards everything below, and serializes sink and the checkpointer.
+		summary  = &MigrationSummary{}
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	summary.Usage = state.Usage
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	save := func() {
+		if cp := opts.Checkpointer; cp != nil {
+			if err := cp.Save(state); err != nil {
+				fail(fmt.Errorf("voyage: save checkpoint: %w", err))
+			}
+		}
+	}
+	progress := func() {
+		if opts.OnProgress != nil {
+			opts.OnProgress(summary.MigrationProgress)
+		}
+	}
+
+	report := failureReporter(ctx)
+	embed := func(batch []item) {
+		texts := make([]string, len(batch))
+		for i, it := range batch {
+			texts[i] = it.doc.Text
+		}
+		var outcome requestOutcome
+		resp, err := c.embedContext(withRequestOutcome(ctx, &outcome), texts, targetModel, opts.EmbedOpts)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr != nil {
+			return
+		}
+		if err != nil && ctx.Err() != nil {
+			fail(ctx.Err())
+			return
+		}
+		embs := make([][]float32, len(batch))
+		if err == nil {
+			for _, obj := range resp.Data {
+				if obj.Index < 0 || obj.Index >= len(embs) {
+					err = fmt.Errorf("voyage: response index %d out of range", obj.Index)
+					break
+				}
+				embs[obj.Index] = obj.Embedding
+			}
+		}
+		if err != nil {
+			indices, ids := make([]int, len(batch)), make([]string, len(batch))
+			for i, it := range batch {
+				summary.Failures = append(summary.Failures, MigrationFailure{ID: it.doc.ID, Err: err})
+				indices[i], ids[i] = it.pos, it.doc.ID
+			}
+			summary.Failed += len(batch)
+			if report != nil {
+				if err := report.write(c.clock().Now(), "embeddings", targetModel, indices, texts, ids, &outcome, err); err != nil {
+					fail(fmt.Errorf("voyage: write failure report: %w", err))
+					return
+				}
+			}
+			progress()
+			return
+		}
+
+		state.Usage = addUsage(state.Usage, resp.Usage)
+		summary.Usage = state.Usage
+		for i, it := range batch {
+			if err := sink(MigratedDocument{Document: it.doc, Embedding: embs[i]}); err != nil {
+				save()
+				fail(fmt.Errorf("voyage: sink %s: %w", it.doc.ID, err))
+				return
+			}
+			state.markDone(it.pos, it.pos+1)
+			summary.Processed++
+		}
+		save()
+		progress()
+	}
+
+	sem := make(chan struct{}, concurrency)
+	dispatch := func(batch []item) bool {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			embed(batch)
+		}()
+		return true
+	}
+
+	var batch []item
+	pos, next := 0, 0
+	for doc, err := range source {
+		if err != nil {
+			mu.Lock()
+			fail(fmt.Errorf("voyage: read source: %w", err))
+			mu.Unlock()
+			break
+		}
+		p := pos
+		pos++
+		for next < len(done) && done[next].End <= p {
+			next++
+		}
+		if next < len(done) && done[next].Start <= p {
+			mu.Lock()
+			summary.Skipped++
+			mu.Unlock()
+			continue
+		}
+
+		batch = append(batch, item{pos: p, doc: doc})
+		if len(batch) == size {
+			if !dispatch(batch) {
+				break
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 && ctx.Err() == nil {
+		dispatch(batch)
+	}
+	wg.Wait()
+
+	if cost, ok := c.estimateCost(targetModel, summary.Usage.TotalTokens); ok {
+		summary.EstimatedCost = cost
+	}
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return summary, firstErr
+}
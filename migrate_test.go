@@ -0,0 +1,143 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func syntheticDocuments(n int) iter.Seq2[voyageai.Document, error] {
+	return func(yield func(voyageai.Document, error) bool) {
+		for i := range n {
+			if !yield(voyageai.Document{ID: fmt.Sprintf("doc-%d", i), Text: fmt.Sprintf("text %d", i)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestMigrateEmbeddingsResume(t *testing.T) {
+	srv := newMockServer(t)
+	client := srv.client()
+	cp := voyageai.NewFileCheckpointer(filepath.Join(t.TempDir(), "migrate.json"))
+	opts := &voyageai.MigrateOpts{BatchSize: 5, Concurrency: 2, Checkpointer: cp}
+
+	var mu sync.Mutex
+	delivered := map[string]int{}
+	record := func(d voyageai.MigratedDocument) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered[d.ID]++
+		if want := fakeVector(d.Text); fmt.Sprint(d.Embedding) != fmt.Sprint(want) {
+			panic("unexpected embedding for " + d.ID)
+		}
+	}
+
+	// Interrupt the first run once 20 documents have been delivered.
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := client.MigrateEmbeddings(ctx, syntheticDocuments(50), "voyage-3.5", func(d voyageai.MigratedDocument) error {
+		record(d)
+		if len(delivered) == 20 {
+			cancel()
+		}
+		return nil
+	}, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the first run to be canceled, got %v", err)
+	}
+	if first.Processed < 20 || first.Processed == 50 {
+		t.Fatalf("Expected the first run to stop part way, processed %d", first.Processed)
+	}
+
+	var progress []voyageai.MigrationProgress
+	opts.OnProgress = func(p voyageai.MigrationProgress) { progress = append(progress, p) }
+	second, err := client.MigrateEmbeddings(context.Background(), syntheticDocuments(50), "voyage-3.5", func(d voyageai.MigratedDocument) error {
+		record(d)
+		return nil
+	}, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(delivered) != 50 {
+		t.Errorf("Expected all 50 documents to be delivered, got %d", len(delivered))
+	}
+	for id, n := range delivered {
+		if n != 1 {
+			t.Errorf("Document %s delivered %d times", id, n)
+		}
+	}
+	if second.Skipped != first.Processed || second.Processed != 50-first.Processed {
+		t.Errorf("Expected the resumed run to skip %d documents, got %+v", first.Processed, second.MigrationProgress)
+	}
+	if second.Usage.TotalTokens != len(strings.Join(slices.Collect(textsOf(50)), "")) {
+		t.Errorf("Expected usage to continue from the checkpoint, got %d", second.Usage.TotalTokens)
+	}
+	if want, _ := voyageai.EstimateCost("voyage-3.5", second.Usage.TotalTokens); second.EstimatedCost != want || want == 0 {
+		t.Errorf("Expected an estimated cost of %v, got %v", want, second.EstimatedCost)
+	}
+	if len(progress) == 0 || progress[len(progress)-1].Processed != second.Processed {
+		t.Errorf("Expected progress to be reported, got %+v", progress)
+	}
+}
+
+func textsOf(n int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := range n {
+			if !yield(fmt.Sprintf("text %d", i)) {
+				return
+			}
+		}
+	}
+}
+
+func TestMigrateEmbeddingsFailures(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if slices.Contains(req.Input, "text 7") {
+			return 400
+		}
+		return 0
+	}
+
+	var got []string
+	summary, err := srv.client().MigrateEmbeddings(context.Background(), syntheticDocuments(12), "voyage-3.5", func(d voyageai.MigratedDocument) error {
+		got = append(got, d.ID)
+		return nil
+	}, &voyageai.MigrateOpts{BatchSize: 5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var failed []string
+	for _, f := range summary.Failures {
+		failed = append(failed, f.ID)
+	}
+	if fmt.Sprint(failed) != "[doc-5 doc-6 doc-7 doc-8 doc-9]" || summary.Failed != 5 {
+		t.Errorf("Expected the batch containing doc-7 to fail, got %v", failed)
+	}
+	if len(got) != 7 || summary.Processed != 7 {
+		t.Errorf("Expected the other batches to be delivered, got %v", got)
+	}
+}
+
+func TestMigrateEmbeddingsSourceError(t *testing.T) {
+	srv := newMockServer(t)
+	boom := errors.New("boom")
+	source := func(yield func(voyageai.Document, error) bool) {
+		yield(voyageai.Document{ID: "a", Text: "a"}, nil)
+		yield(voyageai.Document{}, boom)
+	}
+	_, err := srv.client().MigrateEmbeddings(context.Background(), source, "voyage-3.5", func(voyageai.MigratedDocument) error { return nil }, nil)
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected the source error, got %v", err)
+	}
+}
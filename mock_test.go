@@ -0,0 +1,301 @@
+package voyageai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// mockServer answers /embeddings requests with deterministic vectors (see fakeVector) and
+// records every request it receives.
+type mockServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []voyageai.EmbeddingRequest
+	// fail, if set, is called with the 1-based request number and the request. A non-zero
+	// return value is used as the response status code instead of answering the request.
+	fail func(n int, req voyageai.EmbeddingRequest) int
+	// embed, if set, replaces fakeVector to compute the embedding of each input.
+	embed func(text string) []float32
+	// embedModel, if set, is like embed but also receives the requested model.
+	embedModel func(model, text string) []float32
+
+	// The headers of every request received, in order.
+	headers []http.Header
+
+	reranks []voyageai.RerankRequest
+	// failRerank is like fail for /rerank requests.
+	failRerank func(n int, req voyageai.RerankRequest) int
+
+	multimodal []voyageai.MultimodalRequest
+	// embedContent, if set, computes the embedding of each /multimodalembeddings input. By
+	// default fakeVector is applied to the input's text and image parts joined together.
+	embedContent func(content voyageai.MultimodalContent) []float32
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	t.Helper()
+	m := &mockServer{}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockServer) handle(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.headers = append(m.headers, r.Header.Clone())
+	w.Header().Set("X-Request-Id", fmt.Sprintf("req-%d", len(m.headers)))
+	m.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/rerank") {
+		m.handleRerank(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/multimodalembeddings") {
+		m.handleMultimodal(w, r)
+		return
+	}
+
+	var req voyageai.EmbeddingRequest
+	b, err := io.ReadAll(r.Body)
+	if err != nil || json.Unmarshal(b, &req) != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	n := len(m.requests)
+	fail := m.fail
+	m.mu.Unlock()
+
+	if fail != nil {
+		if code := fail(n, req); code != 0 {
+			w.WriteHeader(code)
+			w.Write([]byte(`{"detail":"scripted failure"}`))
+			return
+		}
+	}
+
+	embed := fakeVector
+	if m.embed != nil {
+		embed = m.embed
+	}
+	if m.embedModel != nil {
+		embed = func(text string) []float32 { return m.embedModel(req.Model, text) }
+	}
+	resp := voyageai.EmbeddingResponse{Object: "list", Model: req.Model}
+	for i, text := range req.Input {
+		resp.Data = append(resp.Data, voyageai.EmbeddingObject{
+			Object:    "embedding",
+			Embedding: embed(text),
+			Index:     i,
+		})
+		resp.Usage.TotalTokens += len(text)
+	}
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func (m *mockServer) handleRerank(w http.ResponseWriter, r *http.Request) {
+	var req voyageai.RerankRequest
+	b, err := io.ReadAll(r.Body)
+	if err != nil || json.Unmarshal(b, &req) != nil {
+		w.WriteHeader(400)
+		return
+	}
+
+	m.mu.Lock()
+	m.reranks = append(m.reranks, req)
+	n := len(m.reranks)
+	fail := m.failRerank
+	m.mu.Unlock()
+
+	if fail != nil {
+		if code := fail(n, req); code != 0 {
+			w.WriteHeader(code)
+			w.Write([]byte(`{"detail":"scripted failure"}`))
+			return
+		}
+	}
+
+	resp := voyageai.RerankResponse{Object: "list", Model: req.Model}
+	for i, doc := range req.Documents {
+		resp.Data = append(resp.Data, voyageai.RerankObject{Index: i, RelevanceScore: fakeRelevance(req.Query, doc)})
+		resp.Usage.TotalTokens += len(req.Query) + len(doc)
+	}
+	sort.SliceStable(resp.Data, func(i, j int) bool { return resp.Data[i].RelevanceScore > resp.Data[j].RelevanceScore })
+	if req.TopK != nil && *req.TopK < len(resp.Data) {
+		resp.Data = resp.Data[:*req.TopK]
+	}
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func (m *mockServer) handleMultimodal(w http.ResponseWriter, r *http.Request) {
+	var req voyageai.MultimodalRequest
+	b, err := io.ReadAll(r.Body)
+	if err != nil || json.Unmarshal(b, &req) != nil {
+		w.WriteHeader(400)
+		return
+	}
+	m.mu.Lock()
+	m.multimodal = append(m.multimodal, req)
+	embed := m.embedContent
+	m.mu.Unlock()
+
+	if embed == nil {
+		embed = func(content voyageai.MultimodalContent) []float32 {
+			var parts []string
+			for _, in := range content.Content {
+				parts = append(parts, string(in.Text)+string(in.ImageBase64)+string(in.ImageURL))
+			}
+			return fakeVector(strings.Join(parts, "\n"))
+		}
+	}
+	resp := voyageai.EmbeddingResponse{Object: "list", Model: req.Model}
+	for i, content := range req.Inputs {
+		resp.Data = append(resp.Data, voyageai.EmbeddingObject{Object: "embedding", Embedding: embed(content), Index: i})
+		resp.Usage.TotalTokens += len(content.Content)
+	}
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func (m *mockServer) multimodalRequests() []voyageai.MultimodalRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]voyageai.MultimodalRequest(nil), m.multimodal...)
+}
+
+// fakeRelevance scores doc by the fraction of the query's words it contains.
+func fakeRelevance(query, doc string) float32 {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return 0
+	}
+	hits := 0
+	for _, w := range words {
+		if strings.Contains(doc, w) {
+			hits++
+		}
+	}
+	return float32(hits) / float32(len(words))
+}
+
+func (m *mockServer) rerankCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.reranks)
+}
+
+// inputs returns every input text of every request received so far, in order.
+func (m *mockServer) inputs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var texts []string
+	for _, req := range m.requests {
+		texts = append(texts, req.Input...)
+	}
+	return texts
+}
+
+func (m *mockServer) requestHeaders() []http.Header {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]http.Header(nil), m.headers...)
+}
+
+func (m *mockServer) requestCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+func (m *mockServer) client() *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: m.URL})
+}
+
+// fakeVector returns a deterministic 4-dimensional vector derived from text.
+func fakeVector(text string) []float32 {
+	h := fnv.New32a()
+	h.Write([]byte(text))
+	v := h.Sum32()
+	return []float32{float32(v & 0xff), float32(v >> 8 & 0xff), float32(v >> 16 & 0xff), float32(v >> 24)}
+}
+
+// fakeClock is a voyageai.Clock that only moves when advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []clockWaiter
+}
+
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waitForTimers blocks until at least n calls to After are pending.
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		pending := len(c.waiters)
+		c.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d timers, have %d", n, pending)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Advance moves the clock forward by d, firing any timers that become due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiting
+}
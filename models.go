@@ -0,0 +1,222 @@
+package voyageai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// The kinds of input a model takes, as recorded in [ModelInfo.Modality].
+const (
+	ModalityText       = "text"       // An embedding model for text.
+	ModalityMultimodal = "multimodal" // An embedding model for text and images.
+	ModalityRerank     = "rerank"     // A reranker.
+)
+
+// Limits and pricing of a Voyage AI model, used for client-side checks such as
+// [VoyageClient.TruncateToContext], for batch planning and for cost estimates. Zero fields are
+// unknown and not checked.
+//
+// The JSON field names are the schema of the documents read by [ModelRegistry.Load].
+type ModelInfo struct {
+	ContextLength  int `json:"context_length"`   // The maximum number of tokens in a single input. For rerankers, the limit for a query and document combined.
+	MaxQueryTokens int `json:"max_query_tokens"` // The maximum number of tokens in a rerank query. Zero for embedding models.
+	// The list price in US dollars per million tokens at the time of writing. Image pixels of
+	// multimodal requests are not priced.
+	PricePerMillionTokens float64 `json:"price_per_million_tokens"`
+
+	Modality         string   `json:"modality"`          // One of [ModalityText], [ModalityMultimodal] and [ModalityRerank].
+	Dimensions       []int    `json:"dimensions"`        // The output dimensions the model supports.
+	DefaultDimension int      `json:"default_dimension"` // The output dimension used when none is requested.
+	DTypes           []string `json:"dtypes"`            // The output data types the model supports, such as "float" and "int8".
+
+	MaxBatchInputs  int `json:"max_batch_inputs"`  // The maximum number of inputs per embedding request.
+	MaxBatchTokens  int `json:"max_batch_tokens"`  // The maximum total tokens per embedding request.
+	MaxDocuments    int `json:"max_documents"`     // The maximum number of documents per rerank request.
+	MaxRerankTokens int `json:"max_rerank_tokens"` // The maximum total tokens of a rerank request, query and documents included.
+}
+
+var (
+	flexibleDimensions = []int{256, 512, 1024, 2048}
+	quantizedDTypes    = []string{DTypeFloat, DTypeInt8, DTypeUint8, DTypeBinary, DTypeUbinary}
+	floatDTypes        = []string{DTypeFloat}
+)
+
+// The built-in models. See [ModelRegistry].
+var builtinModels = map[string]ModelInfo{
+	ModelVoyage3Large:      {ContextLength: 32000, PricePerMillionTokens: 0.18, Modality: ModalityText, Dimensions: flexibleDimensions, DefaultDimension: 1024, DTypes: quantizedDTypes, MaxBatchInputs: 1000},
+	ModelVoyage3:           {ContextLength: 32000, PricePerMillionTokens: 0.06, Modality: ModalityText, Dimensions: []int{1024}, DefaultDimension: 1024, DTypes: floatDTypes, MaxBatchInputs: 1000},
+	ModelVoyage3Lite:       {ContextLength: 32000, PricePerMillionTokens: 0.02, Modality: ModalityText, Dimensions: []int{512}, DefaultDimension: 512, DTypes: floatDTypes, MaxBatchInputs: 1000},
+	ModelVoyage35:          {ContextLength: 32000, PricePerMillionTokens: 0.06, Modality: ModalityText, Dimensions: flexibleDimensions, DefaultDimension: 1024, DTypes: quantizedDTypes, MaxBatchInputs: 1000},
+	ModelVoyage35Lite:      {ContextLength: 32000, PricePerMillionTokens: 0.02, Modality: ModalityText, Dimensions: flexibleDimensions, DefaultDimension: 1024, DTypes: quantizedDTypes, MaxBatchInputs: 1000},
+	ModelVoyageMultimodal3: {ContextLength: 32000, PricePerMillionTokens: 0.12, Modality: ModalityMultimodal, Dimensions: []int{1024}, DefaultDimension: 1024, DTypes: floatDTypes, MaxBatchInputs: 1000},
+	ModelVoyageCode3:       {ContextLength: 32000, PricePerMillionTokens: 0.18, Modality: ModalityText, Dimensions: flexibleDimensions, DefaultDimension: 1024, DTypes: quantizedDTypes, MaxBatchInputs: 1000},
+	ModelVoyageFinance2:    {ContextLength: 32000, PricePerMillionTokens: 0.12, Modality: ModalityText, Dimensions: []int{1024}, DefaultDimension: 1024, DTypes: floatDTypes, MaxBatchInputs: 1000},
+	ModelVoyageContext3:    {ContextLength: 32000, PricePerMillionTokens: 0.18, Modality: ModalityText, Dimensions: flexibleDimensions, DefaultDimension: 1024, DTypes: quantizedDTypes, MaxBatchInputs: 1000, MaxBatchTokens: 120000},
+	ModelVoyageLaw2:        {ContextLength: 16000, PricePerMillionTokens: 0.12, Modality: ModalityText, Dimensions: []int{1024}, DefaultDimension: 1024, DTypes: floatDTypes, MaxBatchInputs: 1000},
+	ModelRerank2:           {ContextLength: 16000, MaxQueryTokens: 4000, PricePerMillionTokens: 0.05, Modality: ModalityRerank, MaxDocuments: 1000},
+	ModelRerank2Lite:       {ContextLength: 8000, MaxQueryTokens: 2000, PricePerMillionTokens: 0.02, Modality: ModalityRerank, MaxDocuments: 1000},
+}
+
+// The limits and pricing of known models. A registry starts with the built-in models, and
+// [ModelRegistry.Load] adds models or changes them, so new models and limits can be used before
+// this package knows them. It is safe for concurrent use.
+//
+// Clients read model capabilities through their registry, [VoyageClientOpts.Models], which
+// defaults to the package registry that [LookupModel] and [EstimateCost] read and
+// [LoadModelRegistry] changes.
+type ModelRegistry struct {
+	mu     sync.RWMutex
+	models map[string]ModelInfo
+}
+
+// NewModelRegistry returns a registry of the built-in models.
+func NewModelRegistry() *ModelRegistry {
+	r := &ModelRegistry{models: make(map[string]ModelInfo, len(builtinModels))}
+	for name, info := range builtinModels {
+		r.models[name] = info
+	}
+	return r
+}
+
+// The package registry.
+var defaultRegistry = NewModelRegistry()
+
+// LoadModelRegistry loads a document into the package registry. See [ModelRegistry.Load].
+func LoadModelRegistry(r io.Reader) error {
+	return defaultRegistry.Load(r)
+}
+
+// Load reads a JSON document of models and merges it into the registry. The document is an
+// object whose "models" field maps model names to [ModelInfo] objects:
+//
+//	{"models": {
+//	  "voyage-4": {"context_length": 32000, "price_per_million_tokens": 0.06, "modality": "text",
+//	               "dimensions": [256, 512, 1024, 2048], "default_dimension": 1024,
+//	               "dtypes": ["float", "int8"], "max_batch_inputs": 1000, "max_batch_tokens": 320000},
+//	  "voyage-law-2": {"context_length": 32000}
+//	}}
+//
+// A known model keeps the fields its entry leaves out; a new model must have a context length.
+// Entries are validated before any is applied, and an error names the first invalid entry.
+func (r *ModelRegistry) Load(src io.Reader) error {
+	var doc struct {
+		Models map[string]json.RawMessage `json:"models"`
+	}
+	dec := json.NewDecoder(src)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("voyage: model registry: %w", err)
+	}
+	names := make([]string, 0, len(doc.Models))
+	for name := range doc.Models {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	merged := make(map[string]ModelInfo, len(names))
+	for _, name := range names {
+		info := r.models[name]
+		dec := json.NewDecoder(bytes.NewReader(doc.Models[name]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&info); err != nil {
+			return fmt.Errorf("voyage: model registry: model %q: %w", name, err)
+		}
+		if err := info.validate(); err != nil {
+			return fmt.Errorf("voyage: model registry: model %q: %w", name, err)
+		}
+		merged[name] = info
+	}
+	for name, info := range merged {
+		r.models[name] = info
+	}
+	return nil
+}
+
+// validate checks that the fields of info are consistent.
+func (info ModelInfo) validate() error {
+	switch {
+	case info.ContextLength <= 0:
+		return fmt.Errorf("context_length must be positive")
+	case info.MaxQueryTokens < 0 || info.MaxQueryTokens > info.ContextLength:
+		return fmt.Errorf("max_query_tokens must be between 0 and context_length")
+	case info.PricePerMillionTokens < 0:
+		return fmt.Errorf("price_per_million_tokens must not be negative")
+	case info.MaxBatchInputs < 0 || info.MaxBatchTokens < 0 || info.MaxDocuments < 0 || info.MaxRerankTokens < 0:
+		return fmt.Errorf("limits must not be negative")
+	}
+	switch info.Modality {
+	case "", ModalityText, ModalityMultimodal, ModalityRerank:
+	default:
+		return fmt.Errorf("unknown modality %q", info.Modality)
+	}
+	for _, d := range info.Dimensions {
+		if d <= 0 {
+			return fmt.Errorf("dimension %d must be positive", d)
+		}
+	}
+	if info.DefaultDimension != 0 && len(info.Dimensions) > 0 && !slices.Contains(info.Dimensions, info.DefaultDimension) {
+		return fmt.Errorf("default_dimension %d is not one of dimensions", info.DefaultDimension)
+	}
+	return nil
+}
+
+// Lookup returns the limits of the named model. ok is false for unknown models.
+func (r *ModelRegistry) Lookup(model string) (info ModelInfo, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok = r.models[model]
+	info.Dimensions, info.DTypes = slices.Clone(info.Dimensions), slices.Clone(info.DTypes)
+	return info, ok
+}
+
+// EstimateCost is like the package's [EstimateCost], with the prices of r.
+func (r *ModelRegistry) EstimateCost(model string, tokens int) (cost float64, ok bool) {
+	info, ok := r.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return float64(tokens) * info.PricePerMillionTokens / 1e6, true
+}
+
+// LookupModel returns the known limits of the named model in the package registry. ok is false
+// for unknown models.
+func LookupModel(model string) (info ModelInfo, ok bool) {
+	return defaultRegistry.Lookup(model)
+}
+
+// EstimateCost returns the estimated price in US dollars of the given number of tokens with the
+// named model, based on [ModelInfo.PricePerMillionTokens] in the package registry. ok is false
+// for unknown models.
+func EstimateCost(model string, tokens int) (cost float64, ok bool) {
+	return defaultRegistry.EstimateCost(model, tokens)
+}
+
+// WithModelRegistry returns an [Option] that makes the derived client read model capabilities
+// from reg. See [VoyageClientOpts.Models].
+func WithModelRegistry(reg *ModelRegistry) Option {
+	return Configure(func(opts *VoyageClientOpts) { opts.Models = reg })
+}
+
+// models returns the client's model registry.
+func (c *VoyageClient) models() *ModelRegistry {
+	if c.opts.Models != nil {
+		return c.opts.Models
+	}
+	return defaultRegistry
+}
+
+// lookupModel returns the limits of model in the client's registry.
+func (c *VoyageClient) lookupModel(model string) (ModelInfo, bool) {
+	return c.models().Lookup(model)
+}
+
+// estimateCost returns the estimated price of tokens with model under the client's registry.
+func (c *VoyageClient) estimateCost(model string, tokens int) (float64, bool) {
+	return c.models().EstimateCost(model, tokens)
+}
@@ -0,0 +1,121 @@
+package voyageai_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+const registryOverride = `{"models": {
+	"voyage-law-2": {"context_length": 20000},
+	"voyage-test-1": {"context_length": 100, "price_per_million_tokens": 1, "modality": "text",
+		"dimensions": [256, 512], "default_dimension": 512, "dtypes": ["float"],
+		"max_batch_inputs": 2, "max_batch_tokens": 10}
+}}`
+
+func TestModelRegistryLoad(t *testing.T) {
+	reg := voyageai.NewModelRegistry()
+	if err := reg.Load(strings.NewReader(registryOverride)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	law, ok := reg.Lookup(voyageai.ModelVoyageLaw2)
+	if !ok || law.ContextLength != 20000 || law.PricePerMillionTokens != 0.12 || law.MaxBatchInputs != 1000 {
+		t.Errorf("Expected the override to keep the other fields, got %+v", law)
+	}
+	added, ok := reg.Lookup("voyage-test-1")
+	if !ok || added.MaxBatchInputs != 2 || !reflect.DeepEqual(added.Dimensions, []int{256, 512}) {
+		t.Errorf("Unexpected new model %+v", added)
+	}
+	// The package registry is untouched.
+	if _, ok := voyageai.LookupModel("voyage-test-1"); ok {
+		t.Error("Expected the package registry not to know the new model")
+	}
+	if info, _ := voyageai.LookupModel(voyageai.ModelVoyageLaw2); info.ContextLength != 16000 {
+		t.Errorf("Expected the package registry to keep its limit, got %d", info.ContextLength)
+	}
+}
+
+func TestModelRegistryLoadInvalid(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{`{"models": {"voyage-3": {"context_length": 1000}, "bad": {"price_per_million_tokens": 1}}}`, `model "bad": context_length must be positive`},
+		{`{"models": {"bad": {"context_length": 10, "modality": "audio"}}}`, `model "bad": unknown modality "audio"`},
+		{`{"models": {"bad": {"context_length": 10, "dimensions": [256], "default_dimension": 1024}}}`, `model "bad": default_dimension 1024 is not one of dimensions`},
+		{`{"models": {"bad": {"context_length": 10, "max_batch_tokens": -1}}}`, `model "bad": limits must not be negative`},
+		{`{"models": {"bad": {"context_length": 10, "context_size": 10}}}`, `model "bad": json: unknown field "context_size"`},
+		{`{"model": {}}`, `json: unknown field "model"`},
+	}
+	for _, tt := range tests {
+		reg := voyageai.NewModelRegistry()
+		err := reg.Load(strings.NewReader(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+		}
+		// Nothing is applied when any entry is invalid.
+		if info, _ := reg.Lookup(voyageai.ModelVoyage3); info.ContextLength != 32000 {
+			t.Errorf("Expected the registry to be unchanged, got %+v", info)
+		}
+	}
+}
+
+func TestModelRegistryClientValidation(t *testing.T) {
+	reg := voyageai.NewModelRegistry()
+	if err := reg.Load(strings.NewReader(registryOverride)); err != nil {
+		t.Fatal(err.Error())
+	}
+	cl := newTokenizerClient("http://127.0.0.1:0").With(voyageai.WithModelRegistry(reg))
+	texts := []string{words(16001), words(20001)}
+
+	report, err := cl.ReportOversized(texts, voyageai.ModelVoyageLaw2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if report.OverLimit != 1 || report.Inputs[0].OverLimit || report.Inputs[0].Limit != 20000 {
+		t.Errorf("Expected the overridden limit to apply, got %+v", report.Inputs)
+	}
+	if _, err := cl.ReportOversized([]string{words(101)}, "voyage-test-1"); err != nil {
+		t.Errorf("Expected the new model to be known, got %v", err)
+	}
+	if _, err := newTokenizerClient("http://127.0.0.1:0").ReportOversized(texts, "voyage-test-1"); err == nil {
+		t.Error("Expected a client with the package registry not to know the new model")
+	}
+}
+
+func TestModelRegistryBatchPlanning(t *testing.T) {
+	reg := voyageai.NewModelRegistry()
+	if err := reg.Load(strings.NewReader(registryOverride)); err != nil {
+		t.Fatal(err.Error())
+	}
+	api := newMockServer(t)
+	client := newBudgetClient(api, voyageai.VoyageClientOpts{Models: reg})
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	// The registry caps the batch size and tokens the options ask for.
+	plan, err := client.PlanEmbedBatch(texts, "voyage-test-1", nil, &voyageai.BatchOpts{BatchSize: 100})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	want := []voyageai.PlannedRequest{
+		{Start: 0, End: 2, Tokens: 3},
+		{Start: 2, End: 4, Tokens: 7},
+		{Start: 4, End: 5, Tokens: 5},
+	}
+	if !reflect.DeepEqual(plan.Requests, want) {
+		t.Fatalf("Expected requests %+v, got %+v", want, plan.Requests)
+	}
+	if cost, _ := reg.EstimateCost("voyage-test-1", 15); plan.EstimatedCost != cost || cost != 15e-6 {
+		t.Errorf("Expected a cost of %v, got %v", cost, plan.EstimatedCost)
+	}
+
+	if _, err := client.EmbedBatch(context.Background(), texts, "voyage-test-1", nil, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(api.requests) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(api.requests))
+	}
+}
@@ -0,0 +1,124 @@
+package voyageai
+
+import (
+	"fmt"
+	"math"
+)
+
+// A method of normalizing scores. See [RerankResponse.NormalizedScores].
+type Normalization int
+
+const (
+	NormalizeSoftmax Normalization = iota // [Softmax] with a temperature of 1.
+	NormalizeMinMax                       // [MinMaxNormalize].
+	NormalizeZScore                       // [ZScoreNormalize].
+)
+
+func (n Normalization) String() string {
+	switch n {
+	case NormalizeSoftmax:
+		return "softmax"
+	case NormalizeMinMax:
+		return "minmax"
+	case NormalizeZScore:
+		return "zscore"
+	default:
+		return fmt.Sprintf("Normalization(%d)", int(n))
+	}
+}
+
+// Softmax converts scores into probabilities that sum to 1. Scores are divided by temperature
+// first: temperatures below 1 sharpen the distribution and temperatures above 1 flatten it.
+// The maximum score is subtracted before exponentiating, so large scores do not overflow.
+// It returns an error if temperature is not positive.
+func Softmax(scores []float32, temperature float32) ([]float32, error) {
+	if !(temperature > 0) || math.IsInf(float64(temperature), 1) {
+		return nil, fmt.Errorf("voyage: softmax temperature must be positive and finite, got %v", temperature)
+	}
+	out := make([]float32, len(scores))
+	if len(scores) == 0 {
+		return out, nil
+	}
+
+	peak := math.Inf(-1)
+	for _, s := range scores {
+		peak = max(peak, float64(s))
+	}
+	exps := make([]float64, len(scores))
+	var sum float64
+	for i, s := range scores {
+		exps[i] = math.Exp((float64(s) - peak) / float64(temperature))
+		sum += exps[i]
+	}
+	for i, e := range exps {
+		out[i] = float32(e / sum)
+	}
+	return out, nil
+}
+
+// MinMaxNormalize linearly rescales scores so the lowest becomes 0 and the highest 1. If all
+// scores are equal, every score becomes 0.5.
+func MinMaxNormalize(scores []float32) []float32 {
+	out := make([]float32, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		lo, hi = min(lo, s), max(hi, s)
+	}
+	for i, s := range scores {
+		if hi == lo {
+			out[i] = 0.5
+		} else {
+			out[i] = float32((float64(s) - float64(lo)) / (float64(hi) - float64(lo)))
+		}
+	}
+	return out
+}
+
+// ZScoreNormalize rescales scores to have a mean of 0 and a (population) standard deviation of 1.
+// If all scores are equal, every score becomes 0.
+func ZScoreNormalize(scores []float32) []float32 {
+	out := make([]float32, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+	var mean float64
+	for _, s := range scores {
+		mean += float64(s)
+	}
+	mean /= float64(len(scores))
+	var variance float64
+	for _, s := range scores {
+		d := float64(s) - mean
+		variance += d * d
+	}
+	std := math.Sqrt(variance / float64(len(scores)))
+	if std == 0 {
+		return out
+	}
+	for i, s := range scores {
+		out[i] = float32((float64(s) - mean) / std)
+	}
+	return out
+}
+
+// NormalizedScores returns the relevance scores of r normalized with the given method, aligned
+// with r.Data.
+func (r *RerankResponse) NormalizedScores(method Normalization) ([]float32, error) {
+	scores := make([]float32, len(r.Data))
+	for i, obj := range r.Data {
+		scores[i] = obj.RelevanceScore
+	}
+	switch method {
+	case NormalizeSoftmax:
+		return Softmax(scores, 1)
+	case NormalizeMinMax:
+		return MinMaxNormalize(scores), nil
+	case NormalizeZScore:
+		return ZScoreNormalize(scores), nil
+	default:
+		return nil, fmt.Errorf("voyage: unknown normalization %v", method)
+	}
+}
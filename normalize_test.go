@@ -0,0 +1,84 @@
+package voyageai_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func approxEqual(t *testing.T, name string, got, want []float32) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %v, got %v", name, want, got)
+	}
+	for i := range want {
+		if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+			return
+		}
+	}
+}
+
+func TestSoftmax(t *testing.T) {
+	// e^0, e^1, e^2 over their sum 1 + e + e^2 = 11.107337.
+	got, err := voyageai.Softmax([]float32{1, 2, 3}, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	approxEqual(t, "softmax", got, []float32{0.090031, 0.244728, 0.665241})
+
+	// Halving the temperature doubles the gaps: e^0, e^2, e^4 over 1 + e^2 + e^4 = 62.987716.
+	got, _ = voyageai.Softmax([]float32{1, 2, 3}, 0.5)
+	approxEqual(t, "sharpened", got, []float32{0.015876, 0.117310, 0.866813})
+
+	// Large magnitudes would overflow without subtracting the maximum first.
+	got, _ = voyageai.Softmax([]float32{1000, 1001, 1002}, 1)
+	approxEqual(t, "large", got, []float32{0.090031, 0.244728, 0.665241})
+	got, _ = voyageai.Softmax([]float32{-1e30, 0}, 1)
+	approxEqual(t, "extreme", got, []float32{0, 1})
+
+	got, _ = voyageai.Softmax([]float32{5, 5, 5, 5}, 1)
+	approxEqual(t, "equal", got, []float32{0.25, 0.25, 0.25, 0.25})
+
+	if got, err := voyageai.Softmax(nil, 1); err != nil || len(got) != 0 {
+		t.Errorf("Expected an empty result, got %v, %v", got, err)
+	}
+	if _, err := voyageai.Softmax([]float32{1}, 0); err == nil {
+		t.Error("Expected an error for a zero temperature")
+	}
+}
+
+func TestMinMaxNormalize(t *testing.T) {
+	approxEqual(t, "minmax", voyageai.MinMaxNormalize([]float32{2, 4, 10}), []float32{0, 0.25, 1})
+	approxEqual(t, "equal", voyageai.MinMaxNormalize([]float32{3, 3}), []float32{0.5, 0.5})
+	approxEqual(t, "empty", voyageai.MinMaxNormalize(nil), nil)
+}
+
+func TestZScoreNormalize(t *testing.T) {
+	// Mean 5, population standard deviation 2.
+	approxEqual(t, "zscore", voyageai.ZScoreNormalize([]float32{2, 4, 4, 4, 5, 5, 7, 9}), []float32{-1.5, -0.5, -0.5, -0.5, 0, 0, 1, 2})
+	approxEqual(t, "equal", voyageai.ZScoreNormalize([]float32{3, 3, 3}), []float32{0, 0, 0})
+	approxEqual(t, "empty", voyageai.ZScoreNormalize(nil), nil)
+}
+
+func TestNormalizedScores(t *testing.T) {
+	resp := &voyageai.RerankResponse{Data: []voyageai.RerankObject{
+		{Index: 2, RelevanceScore: 0.9},
+		{Index: 0, RelevanceScore: 0.5},
+		{Index: 1, RelevanceScore: 0.1},
+	}}
+	got, err := resp.NormalizedScores(voyageai.NormalizeMinMax)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	approxEqual(t, "minmax", got, []float32{1, 0.5, 0})
+
+	got, _ = resp.NormalizedScores(voyageai.NormalizeSoftmax)
+	want, _ := voyageai.Softmax([]float32{0.9, 0.5, 0.1}, 1)
+	approxEqual(t, "softmax", got, want)
+
+	if _, err := resp.NormalizedScores(voyageai.Normalization(42)); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+}
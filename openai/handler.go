@@ -0,0 +1,215 @@
+// Package openai serves the OpenAI embeddings API on top of a [voyageai.VoyageClient], so tools
+// that only speak that API can use Voyage AI models through a thin proxy.
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/zamedic/voyageai"
+)
+
+// Options for [NewOpenAIHandler].
+type HandlerOpts struct {
+	// Maps the model names clients send to Voyage AI model names. If set, requests for models
+	// missing from the map are rejected with 404; if nil, model names are passed through as is.
+	ModelMap map[string]string
+	// Called with the bearer token of every request. Requests are rejected with 401 unless it
+	// returns true. If nil, requests are not authenticated.
+	APIKeyCheck func(key string) bool
+}
+
+// An OpenAI embeddings request. Only the fields that map onto a Voyage AI request are read.
+type embeddingRequest struct {
+	Input          json.RawMessage `json:"input"`
+	Model          string          `json:"model"`
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	Dimensions     *int            `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  usage       `json:"usage"`
+}
+
+type embedding struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"` // []float32, or a base64 string of little-endian float32 values.
+}
+
+type usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// An error to be written as an OpenAI error envelope.
+type handlerError struct {
+	status  int
+	errType string
+	code    string
+	param   string
+	message string
+}
+
+func invalidRequest(param, format string, args ...any) *handlerError {
+	return &handlerError{status: http.StatusBadRequest, errType: "invalid_request_error", param: param, message: fmt.Sprintf(format, args...)}
+}
+
+// NewOpenAIHandler returns a handler serving the OpenAI embeddings API, typically mounted at
+// /v1/embeddings. It accepts POST requests whose input is a string or an array of strings, maps
+// the model name as configured in opts, embeds the input with [voyageai.VoyageClient.EmbedContext]
+// and answers in the OpenAI response format, honouring encoding_format "float" or "base64" and
+// passing dimensions on as the output dimension. Token array inputs are not supported.
+//
+// Failures are reported in the OpenAI error envelope. Errors from the Voyage AI API keep their
+// status code, except server errors and failures to reach the API, which become 502 Bad Gateway.
+func NewOpenAIHandler(client *voyageai.VoyageClient, opts HandlerOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, herr := serve(client, opts, r)
+		if herr != nil {
+			writeError(w, herr)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func serve(client *voyageai.VoyageClient, opts HandlerOpts, r *http.Request) (*embeddingResponse, *handlerError) {
+	if r.Method != http.MethodPost {
+		return nil, &handlerError{status: http.StatusMethodNotAllowed, errType: "invalid_request_error", message: "Only POST requests are supported."}
+	}
+	if opts.APIKeyCheck != nil {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !opts.APIKeyCheck(strings.TrimSpace(key)) {
+			return nil, &handlerError{status: http.StatusUnauthorized, errType: "invalid_request_error", code: "invalid_api_key", message: "Incorrect API key provided."}
+		}
+	}
+
+	var req embeddingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, invalidRequest("", "Could not parse the request body as JSON: %v", err)
+	}
+	texts, herr := parseInput(req.Input)
+	if herr != nil {
+		return nil, herr
+	}
+	if req.Model == "" {
+		return nil, invalidRequest("model", "You must provide a model parameter.")
+	}
+	model := req.Model
+	if opts.ModelMap != nil {
+		mapped, ok := opts.ModelMap[req.Model]
+		if !ok {
+			return nil, &handlerError{status: http.StatusNotFound, errType: "invalid_request_error", param: "model", code: "model_not_found", message: fmt.Sprintf("The model `%s` does not exist.", req.Model)}
+		}
+		model = mapped
+	}
+	if req.EncodingFormat != "" && req.EncodingFormat != "float" && req.EncodingFormat != "base64" {
+		return nil, invalidRequest("encoding_format", "Unsupported encoding_format %q; expected float or base64.", req.EncodingFormat)
+	}
+
+	embedOpts := &voyageai.EmbeddingRequestOpts{OutputDimension: req.Dimensions}
+	result, err := client.EmbedContext(r.Context(), texts, model, embedOpts)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+
+	resp := &embeddingResponse{
+		Object: "list",
+		Model:  req.Model,
+		Usage:  usage{PromptTokens: result.Usage.TotalTokens, TotalTokens: result.Usage.TotalTokens},
+	}
+	for _, obj := range result.Data {
+		var vec any = obj.Embedding
+		if req.EncodingFormat == "base64" {
+			vec = encodeBase64(obj.Embedding)
+		}
+		resp.Data = append(resp.Data, embedding{Object: "embedding", Index: obj.Index, Embedding: vec})
+	}
+	return resp, nil
+}
+
+// parseInput accepts the string and array of strings forms of an OpenAI input.
+func parseInput(raw json.RawMessage) ([]string, *handlerError) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, invalidRequest("input", "You must provide an input parameter.")
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, invalidRequest("input", "input must be a string or an array of strings; token arrays are not supported.")
+	}
+	if len(many) == 0 {
+		return nil, invalidRequest("input", "input must not be empty.")
+	}
+	return many, nil
+}
+
+// encodeBase64 encodes vec as little-endian float32 values, as the OpenAI API does.
+func encodeBase64(vec []float32) string {
+	buf := make([]byte, 0, 4*len(vec))
+	for _, x := range vec {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(x))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// upstreamError maps an error from the client onto an OpenAI error.
+func upstreamError(err error) *handlerError {
+	var apiErr *voyageai.APIError
+	switch {
+	case errors.Is(err, voyageai.ErrBudgetExceeded):
+		return &handlerError{status: http.StatusTooManyRequests, errType: "insufficient_quota", code: "insufficient_quota", message: err.Error()}
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		return &handlerError{status: http.StatusTooManyRequests, errType: "rate_limit_error", code: "rate_limit_exceeded", message: upstreamDetail(apiErr)}
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500:
+		return &handlerError{status: apiErr.StatusCode, errType: "invalid_request_error", message: upstreamDetail(apiErr)}
+	default:
+		return &handlerError{status: http.StatusBadGateway, errType: "server_error", message: err.Error()}
+	}
+}
+
+// upstreamDetail returns the detail message of a Voyage AI error response, or the whole error.
+func upstreamDetail(apiErr *voyageai.APIError) string {
+	if apiErr.Detail != "" {
+		return apiErr.Detail
+	}
+	return apiErr.Error()
+}
+
+func writeError(w http.ResponseWriter, herr *handlerError) {
+	env := errorEnvelope{Error: apiError{Message: herr.message, Type: herr.errType}}
+	if herr.param != "" {
+		env.Error.Param = &herr.param
+	}
+	if herr.code != "" {
+		env.Error.Code = &herr.code
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(herr.status)
+	json.NewEncoder(w).Encode(env)
+}
@@ -0,0 +1,195 @@
+package openai_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+	"github.com/zamedic/voyageai/openai"
+)
+
+// upstream is a fake Voyage AI API that embeds every input as [len(text), 0.5] and records the
+// requests it receives. If status is set, it fails every request with that status instead.
+type upstream struct {
+	mu       sync.Mutex
+	requests []voyageai.EmbeddingRequest
+	status   int
+}
+
+func newProxy(t *testing.T, up *upstream, opts openai.HandlerOpts) *httptest.Server {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req voyageai.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		up.mu.Lock()
+		up.requests = append(up.requests, req)
+		status := up.status
+		up.mu.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"detail":"upstream says no"}`))
+			return
+		}
+
+		resp := voyageai.EmbeddingResponse{Object: "list", Model: req.Model, Usage: voyageai.UsageObject{TotalTokens: 3 * len(req.Input)}}
+		for i, text := range req.Input {
+			resp.Data = append(resp.Data, voyageai.EmbeddingObject{Object: "embedding", Index: i, Embedding: []float32{float32(len(text)), 0.5}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(api.Close)
+
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: api.URL})
+	proxy := httptest.NewServer(openai.NewOpenAIHandler(client, opts))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func post(t *testing.T, url, key, body string) (int, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/embeddings", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON response, got %q", ct)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Expected a JSON body, got %q", b)
+	}
+	return resp.StatusCode, out
+}
+
+func TestHandlerEmbeds(t *testing.T) {
+	up := &upstream{}
+	proxy := newProxy(t, up, openai.HandlerOpts{
+		ModelMap:    map[string]string{"text-embedding-3-small": "voyage-3.5-lite"},
+		APIKeyCheck: func(key string) bool { return key == "sk-good" },
+	})
+
+	status, body := post(t, proxy.URL, "sk-good", `{"input":["hello","hi"],"model":"text-embedding-3-small","dimensions":256,"user":"u-1"}`)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, body)
+	}
+	want := map[string]any{
+		"object": "list",
+		"model":  "text-embedding-3-small",
+		"data": []any{
+			map[string]any{"object": "embedding", "index": 0.0, "embedding": []any{5.0, 0.5}},
+			map[string]any{"object": "embedding", "index": 1.0, "embedding": []any{2.0, 0.5}},
+		},
+		"usage": map[string]any{"prompt_tokens": 6.0, "total_tokens": 6.0},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("Expected %v, got %v", want, body)
+	}
+	sent := up.requests[0]
+	if sent.Model != "voyage-3.5-lite" || !reflect.DeepEqual(sent.Input, []string{"hello", "hi"}) {
+		t.Errorf("Expected the mapped model and inputs upstream, got %+v", sent)
+	}
+	if sent.OutputDimension == nil || *sent.OutputDimension != 256 {
+		t.Errorf("Expected output dimension 256 upstream, got %v", sent.OutputDimension)
+	}
+}
+
+func TestHandlerBase64(t *testing.T) {
+	proxy := newProxy(t, &upstream{}, openai.HandlerOpts{})
+
+	status, body := post(t, proxy.URL, "", `{"input":"abc","model":"voyage-3","encoding_format":"base64"}`)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, body)
+	}
+	data := body["data"].([]any)
+	encoded := data[0].(map[string]any)["embedding"].(string)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 8 {
+		t.Fatalf("Expected 8 bytes of base64, got %q", encoded)
+	}
+	got := []float32{
+		math.Float32frombits(binary.LittleEndian.Uint32(raw)),
+		math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])),
+	}
+	if !reflect.DeepEqual(got, []float32{3, 0.5}) {
+		t.Errorf("Expected [3 0.5], got %v", got)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	opts := openai.HandlerOpts{
+		ModelMap:    map[string]string{"text-embedding-3-small": "voyage-3.5-lite"},
+		APIKeyCheck: func(key string) bool { return key == "sk-good" },
+	}
+	tests := []struct {
+		name     string
+		key      string
+		body     string
+		upstream int
+		status   int
+		errType  string
+		code     any
+		param    any
+	}{
+		{"missing key", "", `{"input":"x","model":"text-embedding-3-small"}`, 0, 401, "invalid_request_error", "invalid_api_key", nil},
+		{"wrong key", "sk-bad", `{"input":"x","model":"text-embedding-3-small"}`, 0, 401, "invalid_request_error", "invalid_api_key", nil},
+		{"bad json", "sk-good", `{"input":`, 0, 400, "invalid_request_error", nil, nil},
+		{"no input", "sk-good", `{"model":"text-embedding-3-small"}`, 0, 400, "invalid_request_error", nil, "input"},
+		{"token input", "sk-good", `{"input":[[1,2,3]],"model":"text-embedding-3-small"}`, 0, 400, "invalid_request_error", nil, "input"},
+		{"empty input", "sk-good", `{"input":[],"model":"text-embedding-3-small"}`, 0, 400, "invalid_request_error", nil, "input"},
+		{"no model", "sk-good", `{"input":"x"}`, 0, 400, "invalid_request_error", nil, "model"},
+		{"unknown model", "sk-good", `{"input":"x","model":"ada"}`, 0, 404, "invalid_request_error", "model_not_found", "model"},
+		{"bad encoding", "sk-good", `{"input":"x","model":"text-embedding-3-small","encoding_format":"int8"}`, 0, 400, "invalid_request_error", nil, "encoding_format"},
+		{"upstream 400", "sk-good", `{"input":"x","model":"text-embedding-3-small"}`, 400, 400, "invalid_request_error", nil, nil},
+		{"upstream 429", "sk-good", `{"input":"x","model":"text-embedding-3-small"}`, 429, 429, "rate_limit_error", "rate_limit_exceeded", nil},
+		{"upstream 500", "sk-good", `{"input":"x","model":"text-embedding-3-small"}`, 500, 502, "server_error", nil, nil},
+	}
+	for _, tt := range tests {
+		up := &upstream{status: tt.upstream}
+		proxy := newProxy(t, up, opts)
+		status, body := post(t, proxy.URL, tt.key, tt.body)
+		if status != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, status)
+		}
+		env, _ := body["error"].(map[string]any)
+		if env == nil {
+			t.Errorf("%s: expected an error envelope, got %v", tt.name, body)
+			continue
+		}
+		if env["type"] != tt.errType || env["code"] != tt.code || env["param"] != tt.param {
+			t.Errorf("%s: expected type %v, code %v and param %v, got %v", tt.name, tt.errType, tt.code, tt.param, env)
+		}
+		if msg, _ := env["message"].(string); msg == "" {
+			t.Errorf("%s: expected an error message, got %v", tt.name, env)
+		}
+		if tt.upstream == 0 && len(up.requests) != 0 {
+			t.Errorf("%s: expected no upstream request, got %d", tt.name, len(up.requests))
+		}
+	}
+}
+
+func TestHandlerRejectsGet(t *testing.T) {
+	proxy := newProxy(t, &upstream{}, openai.HandlerOpts{})
+	resp, err := http.Get(proxy.URL + "/v1/embeddings")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", resp.StatusCode)
+	}
+}
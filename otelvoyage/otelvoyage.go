@@ -0,0 +1,198 @@
+// Package otelvoyage instruments a [voyageai.VoyageClient] with OpenTelemetry: a client span
+// around every API request, and metrics of request latency, token usage and cache lookups. Both
+// are opt-in, enabled by passing a provider to [New]:
+//
+//	inst, err := otelvoyage.New(otelvoyage.WithTracerProvider(tp), otelvoyage.WithMeterProvider(mp))
+//	if err != nil {
+//		return err
+//	}
+//	opts := &voyageai.VoyageClientOpts{Key: key}
+//	inst.Instrument(opts)
+//	client := voyageai.NewClient(opts)
+//
+// Attribute names follow the OpenTelemetry semantic conventions for generative AI clients where
+// they apply, such as gen_ai.request.model. Spans carry the trace context of the call, so to
+// propagate it to the API also set [voyageai.VoyageClientOpts.TraceInjector].
+package otelvoyage
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/zamedic/voyageai"
+)
+
+// The instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/zamedic/voyageai/otelvoyage"
+
+// The value of the gen_ai.system attribute.
+const system = "voyageai"
+
+// Configures [New].
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider records a span for every API request with tp. No spans are recorded
+// without it.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider records request and cache metrics with mp. No metrics are recorded without
+// it.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// The OpenTelemetry instrumentation of clients. It is a [voyageai.RequestTracer] and a
+// [voyageai.MetricsHook], and can be shared by several clients. It is safe for concurrent use.
+type Instrumentation struct {
+	tracer trace.Tracer
+
+	duration metric.Float64Histogram
+	tokens   metric.Int64Counter
+	requests metric.Int64Counter
+	cache    metric.Int64Counter
+}
+
+// New returns the instrumentation configured by opts. It fails if its instruments cannot be
+// created.
+func New(opts ...Option) (*Instrumentation, error) {
+	cfg := config{tracerProvider: tracenoop.NewTracerProvider(), meterProvider: metricnoop.NewMeterProvider()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	meter := cfg.meterProvider.Meter(ScopeName)
+	i := &Instrumentation{tracer: cfg.tracerProvider.Tracer(ScopeName)}
+	var err error
+	if i.duration, err = meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithUnit("s"), metric.WithDescription("The duration of API requests, including retries.")); err != nil {
+		return nil, err
+	}
+	if i.tokens, err = meter.Int64Counter("voyageai.client.tokens",
+		metric.WithUnit("{token}"), metric.WithDescription("The tokens used by successful API requests.")); err != nil {
+		return nil, err
+	}
+	if i.requests, err = meter.Int64Counter("voyageai.client.requests",
+		metric.WithUnit("{request}"), metric.WithDescription("The API requests made, including failed ones.")); err != nil {
+		return nil, err
+	}
+	if i.cache, err = meter.Int64Counter("voyageai.client.cache.lookups",
+		metric.WithUnit("{lookup}"), metric.WithDescription("The response cache lookups, by result.")); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Instrument sets i as the Tracer and Metrics of opts. A Metrics hook opts already has keeps
+// receiving every measurement.
+func (i *Instrumentation) Instrument(opts *voyageai.VoyageClientOpts) {
+	opts.Tracer = i
+	if opts.Metrics != nil && opts.Metrics != voyageai.MetricsHook(i) {
+		opts.Metrics = tee{i, opts.Metrics}
+	} else {
+		opts.Metrics = i
+	}
+}
+
+// StartRequest starts a client span named after the endpoint and model, implementing
+// [voyageai.RequestTracer]. The span records the request's inputs, token usage, retries and
+// HTTP status, and its error if it fails.
+func (i *Instrumentation) StartRequest(ctx context.Context, endpoint, model string) (context.Context, func(voyageai.RequestMetrics)) {
+	ctx, span := i.tracer.Start(ctx, endpoint+" "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", system),
+			attribute.String("gen_ai.operation.name", endpoint),
+			attribute.String("gen_ai.request.model", model),
+		))
+	return ctx, func(m voyageai.RequestMetrics) {
+		span.SetAttributes(
+			attribute.Int("voyageai.request.inputs", m.Inputs),
+			attribute.Int("voyageai.request.retries", max(m.Attempts-1, 0)),
+		)
+		if m.StatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", m.StatusCode))
+		}
+		if m.Err != nil {
+			span.SetAttributes(attribute.String("error.type", errorType(m)))
+			span.RecordError(m.Err)
+			span.SetStatus(codes.Error, m.Err.Error())
+		} else {
+			span.SetAttributes(attribute.Int("gen_ai.usage.input_tokens", m.Usage.TotalTokens))
+		}
+		span.End()
+	}
+}
+
+// ObserveRequest records the duration, tokens and outcome of a request, implementing
+// [voyageai.MetricsHook].
+func (i *Instrumentation) ObserveRequest(m voyageai.RequestMetrics) {
+	ctx := context.Background()
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.system", system),
+		attribute.String("gen_ai.operation.name", m.Endpoint),
+		attribute.String("gen_ai.request.model", m.Model),
+	}
+	if m.Err != nil {
+		attrs = append(attrs, attribute.String("error.type", errorType(m)))
+	}
+	set := metric.WithAttributes(attrs...)
+	i.duration.Record(ctx, m.Duration.Seconds(), set)
+	i.requests.Add(ctx, 1, set)
+	if m.Err == nil && m.Usage.TotalTokens > 0 {
+		i.tokens.Add(ctx, int64(m.Usage.TotalTokens), set)
+	}
+}
+
+// ObserveCache counts a response cache lookup, implementing [voyageai.MetricsHook].
+func (i *Instrumentation) ObserveCache(m voyageai.CacheMetrics) {
+	i.cache.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("gen_ai.operation.name", m.Endpoint),
+		attribute.String("gen_ai.request.model", m.Model),
+		attribute.String("voyageai.cache.result", m.Result.String()),
+	))
+}
+
+// errorType returns the error.type of a failed request: its HTTP status code if it received an
+// error response, or "_OTHER".
+func errorType(m voyageai.RequestMetrics) string {
+	if m.StatusCode >= 400 {
+		return strconv.Itoa(m.StatusCode)
+	}
+	return "_OTHER"
+}
+
+// tee passes measurements on to two hooks.
+type tee struct {
+	a, b voyageai.MetricsHook
+}
+
+func (t tee) ObserveRequest(m voyageai.RequestMetrics) {
+	t.a.ObserveRequest(m)
+	t.b.ObserveRequest(m)
+}
+
+func (t tee) ObserveCache(m voyageai.CacheMetrics) {
+	t.a.ObserveCache(m)
+	t.b.ObserveCache(m)
+}
+
+func (t tee) ObserveDegraded(m voyageai.DegradedMetrics) {
+	for _, h := range []voyageai.MetricsHook{t.a, t.b} {
+		if o, ok := h.(voyageai.DegradationObserver); ok {
+			o.ObserveDegraded(m)
+		}
+	}
+}
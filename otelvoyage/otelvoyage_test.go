@@ -0,0 +1,149 @@
+package otelvoyage_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/zamedic/voyageai"
+	"github.com/zamedic/voyageai/otelvoyage"
+)
+
+// newServer answers embedding requests with 4 tokens per input, failing the first request with
+// a 500 and every request for the model "broken" with a 400.
+func newServer(t *testing.T) *httptest.Server {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req voyageai.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if n.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if req.Model == "broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail":"bad model"}`))
+			return
+		}
+		resp := voyageai.EmbeddingResponse{Object: "list", Model: req.Model}
+		for i := range req.Input {
+			resp.Data = append(resp.Data, voyageai.EmbeddingObject{Object: "embedding", Embedding: []float32{1, 0}, Index: i})
+			resp.Usage.TotalTokens += 4
+		}
+		json.NewEncoder(w).Encode(&resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInstrumentation(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	inst, err := otelvoyage.New(
+		otelvoyage.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		otelvoyage.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var observed int
+	opts := &voyageai.VoyageClientOpts{
+		Key: "APIKEY", BaseURL: newServer(t).URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond,
+		Metrics: hookFunc(func(voyageai.RequestMetrics) { observed++ }),
+	}
+	inst.Instrument(opts)
+	client := voyageai.NewClient(opts)
+
+	if _, err := client.Embed([]string{"a", "b"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.Embed([]string{"a"}, "broken", nil); err == nil {
+		t.Fatal("Expected the request for the broken model to fail")
+	}
+	if observed != 2 {
+		t.Errorf("Expected the existing hook to keep receiving measurements, got %d", observed)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("Expected a span per request, got %d", len(ended))
+	}
+	ok, failed := ended[0], ended[1]
+	if ok.Name() != "embeddings voyage-3.5" || ok.Status().Code == codes.Error {
+		t.Errorf("Unexpected span %q with status %v", ok.Name(), ok.Status())
+	}
+	want := map[attribute.Key]attribute.Value{
+		"gen_ai.request.model":      attribute.StringValue("voyage-3.5"),
+		"voyageai.request.inputs":   attribute.IntValue(2),
+		"voyageai.request.retries":  attribute.IntValue(1),
+		"gen_ai.usage.input_tokens": attribute.IntValue(8),
+		"http.response.status_code": attribute.IntValue(200),
+	}
+	checkAttributes(t, ok.Attributes(), want)
+	if failed.Status().Code != codes.Error || len(failed.Events()) == 0 {
+		t.Errorf("Expected the failed request's span to record its error, got %v", failed.Status())
+	}
+	checkAttributes(t, failed.Attributes(), map[attribute.Key]attribute.Value{"error.type": attribute.StringValue("400")})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err.Error())
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	if h, ok := metrics["gen_ai.client.operation.duration"].(metricdata.Histogram[float64]); !ok || len(h.DataPoints) != 2 {
+		t.Errorf("Expected request durations by outcome, got %+v", metrics["gen_ai.client.operation.duration"])
+	}
+	if s, ok := metrics["voyageai.client.tokens"].(metricdata.Sum[int64]); !ok || len(s.DataPoints) != 1 || s.DataPoints[0].Value != 8 {
+		t.Errorf("Expected 8 tokens, got %+v", metrics["voyageai.client.tokens"])
+	}
+	if s, ok := metrics["voyageai.client.requests"].(metricdata.Sum[int64]); !ok || len(s.DataPoints) != 2 {
+		t.Errorf("Expected requests by outcome, got %+v", metrics["voyageai.client.requests"])
+	}
+}
+
+func TestInstrumentationDisabled(t *testing.T) {
+	inst, err := otelvoyage.New()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	opts := &voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: newServer(t).URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+	inst.Instrument(opts)
+	if _, err := voyageai.NewClient(opts).Embed([]string{"a"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func checkAttributes(t *testing.T, attrs []attribute.KeyValue, want map[attribute.Key]attribute.Value) {
+	t.Helper()
+	got := map[attribute.Key]attribute.Value{}
+	for _, kv := range attrs {
+		got[kv.Key] = kv.Value
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v.Emit(), got[k].Emit())
+		}
+	}
+}
+
+// hookFunc is a [voyageai.MetricsHook] calling itself with every request's measurements.
+type hookFunc func(voyageai.RequestMetrics)
+
+func (f hookFunc) ObserveRequest(m voyageai.RequestMetrics) { f(m) }
+func (hookFunc) ObserveCache(voyageai.CacheMetrics)         {}
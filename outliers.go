@@ -0,0 +1,142 @@
+package voyageai
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Defaults for [OutlierOpts].
+const (
+	DefaultOutlierK      = 5
+	DefaultOutlierZScore = 3.0
+)
+
+// Options for [DetectOutliers].
+type OutlierOpts struct {
+	K int // The number of nearest neighbors each vector is scored against. Defaults to [DefaultOutlierK].
+	// Vectors whose score is above Threshold are outliers. Must be positive unless ZScore is
+	// set, in which case it defaults to [DefaultOutlierZScore].
+	Threshold float64
+	// Compare Threshold to the number of standard deviations a score lies above the mean score
+	// of all vectors, rather than to the score itself.
+	ZScore  bool
+	Metric  Metric // The distance between vectors: 1 - cosine similarity for [MetricCosine], the L2 distance for [MetricEuclidean].
+	Workers int    // The number of goroutines used to compare vectors. Defaults to GOMAXPROCS.
+}
+
+// A vector flagged by [DetectOutliers].
+type Outlier struct {
+	Index int
+	Score float64 // The mean distance to the vector's nearest neighbors.
+}
+
+// DetectOutliers flags the vectors that lie far from the rest, such as the embeddings of noisy or
+// empty inputs. Each vector is scored with the mean distance to its K nearest neighbors, and
+// vectors whose score exceeds the threshold are returned in order of descending score. Fewer
+// than two vectors have no outliers.
+func DetectOutliers(vecs [][]float32, opts OutlierOpts) ([]Outlier, error) {
+	if opts.K <= 0 {
+		opts.K = DefaultOutlierK
+	}
+	if opts.Threshold <= 0 {
+		if !opts.ZScore {
+			return nil, errors.New("voyage: outlier threshold must be positive")
+		}
+		opts.Threshold = DefaultOutlierZScore
+	}
+	if _, err := checkDims(vecs); err != nil {
+		return nil, err
+	}
+	if len(vecs) < 2 {
+		return nil, nil
+	}
+	scores := knnScores(vecs, min(opts.K, len(vecs)-1), opts.Metric, opts.Workers)
+
+	threshold := opts.Threshold
+	if opts.ZScore {
+		var mean, variance float64
+		for _, s := range scores {
+			mean += s
+		}
+		mean /= float64(len(scores))
+		for _, s := range scores {
+			variance += (s - mean) * (s - mean)
+		}
+		std := math.Sqrt(variance / float64(len(scores)))
+		if std == 0 {
+			return nil, nil
+		}
+		threshold = mean + opts.Threshold*std
+	}
+
+	var out []Outlier
+	for i, s := range scores {
+		if s > threshold {
+			out = append(out, Outlier{Index: i, Score: s})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out, nil
+}
+
+// knnScores returns the mean distance of every vector to its k nearest other vectors under
+// metric, comparing rows in parallel.
+func knnScores(vecs [][]float32, k int, metric Metric, workers int) []float64 {
+	points := vecs
+	dist := metric.distance
+	if metric == MetricCosine {
+		points = make([][]float32, len(vecs))
+		for i, v := range vecs {
+			points[i] = normalized(v)
+		}
+		dist = func(a, b []float32) float64 { return 1 - dot(a, b) }
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	scores := make([]float64, len(points))
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nearest := make([]float64, 0, k) // Ascending.
+			for i := range rows {
+				nearest = nearest[:0]
+				for j, p := range points {
+					if j == i {
+						continue
+					}
+					d := dist(points[i], p)
+					if len(nearest) == k && d >= nearest[k-1] {
+						continue
+					}
+					if len(nearest) < k {
+						nearest = append(nearest, d)
+					} else {
+						nearest[k-1] = d
+					}
+					for n := len(nearest) - 1; n > 0 && nearest[n] < nearest[n-1]; n-- {
+						nearest[n], nearest[n-1] = nearest[n-1], nearest[n]
+					}
+				}
+				var sum float64
+				for _, d := range nearest {
+					sum += d
+				}
+				scores[i] = sum / float64(len(nearest))
+			}
+		}()
+	}
+	for i := range points {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+	return scores
+}
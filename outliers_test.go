@@ -0,0 +1,61 @@
+package voyageai_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestDetectOutliers(t *testing.T) {
+	vecs, _ := clusterFixture()
+	// Plant two vectors far from all three clusters.
+	vecs = append(vecs, []float32{0, 0, 0, 10}, []float32{-8, -8, -8, 0})
+	planted := []int{len(vecs) - 2, len(vecs) - 1}
+
+	cases := []struct {
+		name string
+		opts voyageai.OutlierOpts
+	}{
+		{"cosine absolute", voyageai.OutlierOpts{Threshold: 0.3}},
+		{"euclidean absolute", voyageai.OutlierOpts{Threshold: 5, Metric: voyageai.MetricEuclidean}},
+		{"cosine z-score", voyageai.OutlierOpts{ZScore: true}},
+		{"euclidean z-score", voyageai.OutlierOpts{ZScore: true, Metric: voyageai.MetricEuclidean, K: 3, Workers: 2}},
+	}
+	for _, c := range cases {
+		outliers, err := voyageai.DetectOutliers(vecs, c.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got []int
+		for _, o := range outliers {
+			got = append(got, o.Index)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, planted) {
+			t.Errorf("%s: expected outliers %v, got %v", c.name, planted, outliers)
+		}
+		for i := 1; i < len(outliers); i++ {
+			if outliers[i].Score > outliers[i-1].Score {
+				t.Errorf("%s: expected outliers by descending score, got %v", c.name, outliers)
+			}
+		}
+	}
+}
+
+func TestDetectOutliersEdgeCases(t *testing.T) {
+	if _, err := voyageai.DetectOutliers([][]float32{{1, 0}, {0, 1}}, voyageai.OutlierOpts{}); err == nil {
+		t.Error("Expected an error without a threshold")
+	}
+	if _, err := voyageai.DetectOutliers([][]float32{{1, 0}, {0, 1, 0}}, voyageai.OutlierOpts{Threshold: 1}); err == nil {
+		t.Error("Expected an error for mismatched dimensions")
+	}
+	if got, err := voyageai.DetectOutliers([][]float32{{1, 0}}, voyageai.OutlierOpts{Threshold: 0.1}); err != nil || got != nil {
+		t.Errorf("Expected no outliers for a single vector, got %v and %v", got, err)
+	}
+	// Identical vectors have no spread, so nothing stands out.
+	same := [][]float32{{1, 2}, {1, 2}, {1, 2}}
+	if got, _ := voyageai.DetectOutliers(same, voyageai.OutlierOpts{ZScore: true}); got != nil {
+		t.Errorf("Expected no outliers among identical vectors, got %v", got)
+	}
+}
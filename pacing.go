@@ -0,0 +1,106 @@
+package voyageai
+
+import (
+	"context"
+	"time"
+)
+
+// The progress of a batch run, reported to [BatchOpts.OnProgress] after every request.
+type BatchProgress struct {
+	Completed int         // The number of inputs embedded so far, including any resumed from a checkpoint.
+	Total     int         // The number of inputs in the run.
+	Usage     UsageObject // Usage accumulated so far.
+	// When the run is expected to finish, based on the pacing and the average request latency
+	// so far.
+	ProjectedCompletion time.Time
+}
+
+// batchPacer schedules the requests of a batch run. With a spread, each request is due once the
+// share of the window proportional to the inputs sent before it has elapsed, so requests of
+// different sizes stay on schedule; with an interval, consecutive requests are at least that far
+// apart. Both can apply, in which case the later time wins.
+type batchPacer struct {
+	clock    Clock
+	start    time.Time
+	spread   time.Duration
+	interval time.Duration
+
+	sizes    []int // The number of inputs of each request, in order.
+	total    int
+	next     int // The index of the next request.
+	sent     int // The number of inputs in requests before next.
+	finished int // The number of completed requests.
+	latency  time.Duration
+	inFlight int // The number of requests sent at once.
+}
+
+func newBatchPacer(clock Clock, opts *BatchOpts, ranges []batchRange) *batchPacer {
+	p := &batchPacer{clock: clock, start: clock.Now(), spread: opts.SpreadOver, inFlight: max(opts.Concurrency, 1)}
+	if opts.RequestsPerMinute > 0 {
+		p.interval = time.Duration(float64(time.Minute) / opts.RequestsPerMinute)
+	}
+	for _, r := range ranges {
+		p.sizes = append(p.sizes, r.End-r.Start)
+		p.total += r.End - r.Start
+	}
+	return p
+}
+
+// due returns when request i, with sent inputs before it, may be dispatched.
+func (p *batchPacer) due(i, sent int) time.Time {
+	at := p.start
+	if p.spread > 0 && p.total > 0 {
+		at = p.start.Add(time.Duration(float64(p.spread) * float64(sent) / float64(p.total)))
+	}
+	if p.interval > 0 {
+		at = later(at, p.start.Add(time.Duration(i)*p.interval))
+	}
+	return at
+}
+
+// wait blocks until the next request is due or ctx is done.
+func (p *batchPacer) wait(ctx context.Context) error {
+	if d := p.due(p.next, p.sent).Sub(p.clock.Now()); d > 0 {
+		select {
+		case <-p.clock.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return ctx.Err()
+}
+
+// dispatch records that the next request was sent.
+func (p *batchPacer) dispatch() {
+	p.sent += p.sizes[p.next]
+	p.next++
+}
+
+// done records the completion of a request, which took the given time.
+func (p *batchPacer) done(took time.Duration) {
+	p.finished++
+	p.latency += took
+}
+
+// projected returns when the last request is expected to complete.
+func (p *batchPacer) projected() time.Time {
+	now := p.clock.Now()
+	remaining := len(p.sizes) - p.finished
+	if remaining == 0 || p.finished == 0 {
+		return now
+	}
+	avg := p.latency / time.Duration(p.finished)
+	if p.spread <= 0 && p.interval <= 0 {
+		rounds := (remaining + p.inFlight - 1) / p.inFlight
+		return now.Add(avg * time.Duration(rounds))
+	}
+	last := len(p.sizes) - 1
+	return later(now, p.due(last, p.total-p.sizes[last])).Add(avg)
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
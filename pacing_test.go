@@ -0,0 +1,123 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// newPacedServer returns a mock server that records the fake clock's time at every request.
+func newPacedServer(t *testing.T, clock *fakeClock) (*mockServer, func() []time.Duration) {
+	srv := newMockServer(t)
+	start := clock.Now()
+	var mu sync.Mutex
+	var at []time.Duration
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		mu.Lock()
+		defer mu.Unlock()
+		at = append(at, clock.Now().Sub(start))
+		return 0
+	}
+	return srv, func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Duration(nil), at...)
+	}
+}
+
+func TestEmbedBatchSpreadOver(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	srv, dispatched := newPacedServer(t, clock)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, Clock: clock})
+
+	var mu sync.Mutex
+	var progress []voyageai.BatchProgress
+	done := make(chan error, 1)
+	go func() {
+		// Ten inputs in requests of 3, 3, 3 and 1 spread over ten minutes are due after 0, 3, 6
+		// and 9 minutes, in proportion to the inputs sent before each.
+		_, err := client.EmbedBatch(context.Background(), slices.Collect(textsOf(10)), "test-model", nil, &voyageai.BatchOpts{
+			BatchSize:  3,
+			SpreadOver: 10 * time.Minute,
+			OnProgress: func(p voyageai.BatchProgress) {
+				mu.Lock()
+				defer mu.Unlock()
+				progress = append(progress, p)
+			},
+		})
+		done <- err
+	}()
+	for range 3 {
+		clock.waitForTimers(t, 1)
+		clock.Advance(3 * time.Minute)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if got := fmt.Sprint(dispatched()); got != "[0s 3m0s 6m0s 9m0s]" {
+		t.Errorf("Unexpected dispatch times %s", got)
+	}
+	if len(progress) != 4 || progress[3].Completed != 10 || progress[3].Total != 10 {
+		t.Fatalf("Unexpected progress %+v", progress)
+	}
+	// Requests take no time on the fake clock, so the last one is projected at its due time.
+	if want := start.Add(9 * time.Minute); !progress[0].ProjectedCompletion.Equal(want) {
+		t.Errorf("Expected completion to be projected at %v, got %v", want, progress[0].ProjectedCompletion)
+	}
+}
+
+func TestEmbedBatchRequestsPerMinute(t *testing.T) {
+	clock := newFakeClock()
+	srv, dispatched := newPacedServer(t, clock)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, Clock: clock})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.EmbedBatch(context.Background(), slices.Collect(textsOf(4)), "test-model", nil, &voyageai.BatchOpts{BatchSize: 1, RequestsPerMinute: 30})
+		done <- err
+	}()
+	for range 3 {
+		clock.waitForTimers(t, 1)
+		clock.Advance(2 * time.Second)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := fmt.Sprint(dispatched()); got != "[0s 2s 4s 6s]" {
+		t.Errorf("Unexpected dispatch times %s", got)
+	}
+}
+
+func TestEmbedBatchPacingCancel(t *testing.T) {
+	clock := newFakeClock()
+	srv, _ := newPacedServer(t, clock)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, Clock: clock})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.EmbedBatch(ctx, slices.Collect(textsOf(4)), "test-model", nil, &voyageai.BatchOpts{BatchSize: 1, SpreadOver: time.Hour})
+		done <- err
+	}()
+	clock.waitForTimers(t, 1)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancellation, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the run to stop while waiting")
+	}
+	if srv.requestCount() != 1 {
+		t.Errorf("Expected only the first request to be sent, got %d", srv.requestCount())
+	}
+}
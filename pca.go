@@ -0,0 +1,246 @@
+package voyageai
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+)
+
+// Returned by [LoadPCA] when the input is not a complete, valid model.
+var ErrCorruptPCA = errors.New("voyage: corrupt PCA model")
+
+const (
+	pcaMagic   = "VPCA"
+	pcaVersion = 1
+
+	pcaMaxIter   = 1000
+	pcaTolerance = 1e-10
+)
+
+// A principal component analysis fitted by [FitPCA], projecting vectors onto the directions of
+// greatest variance in the training data.
+type PCAModel struct {
+	Mean       []float64   // The mean of the training vectors, subtracted before projecting.
+	Components [][]float64 // Unit-length principal axes, in order of decreasing variance.
+	Variances  []float64   // The variance of the training data along each component.
+}
+
+// FitPCA fits a PCA model with the given number of components to vecs, which must all have the
+// same dimension. The covariance is accumulated in float64 and the components are found by power
+// iteration with deflation, which suits the modest component counts used for plotting or
+// compaction. The result is deterministic for a given input.
+func FitPCA(vecs [][]float32, components int) (*PCAModel, error) {
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) < 2 {
+		return nil, errors.New("voyage: PCA needs at least two vectors")
+	}
+	if components <= 0 || components > dim {
+		return nil, fmt.Errorf("voyage: PCA components must be in [1, %d], got %d", dim, components)
+	}
+
+	mean := make([]float64, dim)
+	for _, v := range vecs {
+		for i, x := range v {
+			mean[i] += float64(x)
+		}
+	}
+	for i := range mean {
+		mean[i] /= float64(len(vecs))
+	}
+
+	cov := make([][]float64, dim)
+	for i := range cov {
+		cov[i] = make([]float64, dim)
+	}
+	centered := make([]float64, dim)
+	for _, v := range vecs {
+		for i, x := range v {
+			centered[i] = float64(x) - mean[i]
+		}
+		for i := range dim {
+			for j := i; j < dim; j++ {
+				cov[i][j] += centered[i] * centered[j]
+			}
+		}
+	}
+	for i := range dim {
+		for j := i; j < dim; j++ {
+			cov[i][j] /= float64(len(vecs) - 1)
+			cov[j][i] = cov[i][j]
+		}
+	}
+
+	m := &PCAModel{Mean: mean}
+	rng := rand.New(rand.NewPCG(1, 2))
+	next := make([]float64, dim)
+	for range components {
+		v := make([]float64, dim)
+		for i := range v {
+			v[i] = rng.Float64() - 0.5
+		}
+		orthonormalize(v, m.Components)
+
+		for range pcaMaxIter {
+			for i, row := range cov {
+				next[i] = dot64(row, v)
+			}
+			orthonormalize(next, m.Components)
+			delta := 0.0
+			for i := range v {
+				delta = max(delta, math.Abs(next[i]-v[i]))
+			}
+			copy(v, next)
+			if delta < pcaTolerance {
+				break
+			}
+		}
+
+		// Fix the sign so the largest coordinate is positive.
+		big := 0
+		for i := range v {
+			if math.Abs(v[i]) > math.Abs(v[big]) {
+				big = i
+			}
+		}
+		if v[big] < 0 {
+			for i := range v {
+				v[i] = -v[i]
+			}
+		}
+
+		variance := 0.0
+		for i, row := range cov {
+			variance += v[i] * dot64(row, v)
+		}
+		m.Components = append(m.Components, v)
+		m.Variances = append(m.Variances, variance)
+	}
+	return m, nil
+}
+
+// orthonormalize makes v orthogonal to every vector in basis and scales it to unit length.
+// A v that vanishes is left as zero.
+func orthonormalize(v []float64, basis [][]float64) {
+	for _, b := range basis {
+		p := dot64(v, b)
+		for i := range v {
+			v[i] -= p * b[i]
+		}
+	}
+	if n := math.Sqrt(dot64(v, v)); n > 0 {
+		for i := range v {
+			v[i] /= n
+		}
+	}
+}
+
+func dot64(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// Transform projects vec onto the model's components.
+func (m *PCAModel) Transform(vec []float32) ([]float32, error) {
+	if len(vec) != len(m.Mean) {
+		return nil, fmt.Errorf("voyage: vector has dimension %d, expected %d", len(vec), len(m.Mean))
+	}
+	out := make([]float32, len(m.Components))
+	for c, axis := range m.Components {
+		var sum float64
+		for i, x := range vec {
+			sum += (float64(x) - m.Mean[i]) * axis[i]
+		}
+		out[c] = float32(sum)
+	}
+	return out, nil
+}
+
+// InverseTransform maps a projection back to the original space. The result is the closest
+// vector to the original that the model's components can represent.
+func (m *PCAModel) InverseTransform(proj []float32) ([]float32, error) {
+	if len(proj) != len(m.Components) {
+		return nil, fmt.Errorf("voyage: projection has %d components, expected %d", len(proj), len(m.Components))
+	}
+	out := make([]float32, len(m.Mean))
+	for i, mu := range m.Mean {
+		sum := mu
+		for c, axis := range m.Components {
+			sum += float64(proj[c]) * axis[i]
+		}
+		out[i] = float32(sum)
+	}
+	return out, nil
+}
+
+// Save writes the model to w in a versioned binary format readable by [LoadPCA]: the magic
+// "VPCA", a uint16 version, uint32 dimension and component count, then the mean, the components
+// and their variances as float64 values, all little-endian.
+func (m *PCAModel) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	buf = append(buf, pcaMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, pcaVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(m.Mean)))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(m.Components)))
+	appendFloats := func(xs []float64) {
+		for _, x := range xs {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+		}
+	}
+	appendFloats(m.Mean)
+	for _, c := range m.Components {
+		appendFloats(c)
+	}
+	appendFloats(m.Variances)
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadPCA reads a model written by [PCAModel.Save]. It returns an error wrapping [ErrCorruptPCA]
+// if the input is truncated or malformed.
+func LoadPCA(r io.Reader) (*PCAModel, error) {
+	d := &indexDecoder{r: bufio.NewReader(r)}
+	magic := d.bytes(4)
+	if d.err == nil && string(magic) != pcaMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrCorruptPCA, magic)
+	}
+	if version := d.uint16(); d.err == nil && version != pcaVersion {
+		return nil, fmt.Errorf("voyage: unsupported PCA model version %d", version)
+	}
+	dim, k := d.uint32(), d.uint32()
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: truncated header", ErrCorruptPCA)
+	}
+	if dim == 0 || dim > maxIndexDim || k == 0 || k > dim {
+		return nil, fmt.Errorf("%w: invalid shape %d×%d", ErrCorruptPCA, k, dim)
+	}
+
+	floats := func(n uint32) []float64 {
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = math.Float64frombits(d.uint64())
+		}
+		return xs
+	}
+	m := &PCAModel{Mean: floats(dim)}
+	for range k {
+		m.Components = append(m.Components, floats(dim))
+	}
+	m.Variances = floats(k)
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: truncated data", ErrCorruptPCA)
+	}
+	return m, nil
+}
@@ -0,0 +1,127 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// pcaFixture returns points in 6 dimensions whose spread shrinks along each successive axis of a
+// rotated basis, so each added component explains less variance.
+func pcaFixture() [][]float32 {
+	rng := rand.New(rand.NewPCG(3, 4))
+	spread := []float64{10, 5, 2.5, 1, 0.5, 0.1}
+	var vecs [][]float32
+	for range 300 {
+		v := make([]float32, 6)
+		for axis, s := range spread {
+			x := rng.NormFloat64() * s
+			// Rotate by mixing each axis with the next.
+			v[axis] += float32(x * 0.8)
+			v[(axis+1)%6] += float32(x * 0.6)
+		}
+		for i := range v {
+			v[i] += 3
+		}
+		vecs = append(vecs, v)
+	}
+	return vecs
+}
+
+func reconstructionError(t *testing.T, m *voyageai.PCAModel, vecs [][]float32) float64 {
+	t.Helper()
+	var total float64
+	for _, v := range vecs {
+		proj, err := m.Transform(v)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		back, err := m.InverseTransform(proj)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for i := range v {
+			d := float64(v[i] - back[i])
+			total += d * d
+		}
+	}
+	return total / float64(len(vecs))
+}
+
+func TestFitPCA(t *testing.T) {
+	vecs := pcaFixture()
+	prev := math.Inf(1)
+	for k := 1; k <= 6; k++ {
+		m, err := voyageai.FitPCA(vecs, k)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		e := reconstructionError(t, m, vecs)
+		if e >= prev {
+			t.Errorf("Expected the error to decrease with %d components, got %v after %v", k, e, prev)
+		}
+		prev = e
+		for c := 1; c < k; c++ {
+			if m.Variances[c] > m.Variances[c-1]+1e-9 {
+				t.Errorf("Expected decreasing variances, got %v", m.Variances)
+			}
+		}
+	}
+	if prev > 1e-6 {
+		t.Errorf("Expected a full-rank model to reconstruct exactly, got error %v", prev)
+	}
+
+	m, _ := voyageai.FitPCA(vecs, 2)
+	if math.Abs(m.Mean[0]-3) > 1 {
+		t.Errorf("Expected the mean to be stored, got %v", m.Mean)
+	}
+}
+
+func TestPCASaveLoad(t *testing.T) {
+	vecs := pcaFixture()
+	m, err := voyageai.FitPCA(vecs, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	saved := buf.Bytes()
+	loaded, err := voyageai.LoadPCA(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(m, loaded) {
+		t.Error("Expected the loaded model to equal the saved one")
+	}
+	for _, v := range vecs[:10] {
+		a, _ := m.Transform(v)
+		b, _ := loaded.Transform(v)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("Expected identical projections, got %v and %v", a, b)
+		}
+	}
+
+	if _, err := voyageai.LoadPCA(bytes.NewReader(saved[:len(saved)-3])); !errors.Is(err, voyageai.ErrCorruptPCA) {
+		t.Errorf("Expected a truncated model to be rejected, got %v", err)
+	}
+}
+
+func TestFitPCAErrors(t *testing.T) {
+	if _, err := voyageai.FitPCA([][]float32{{1, 2}, {3, 4}}, 3); err == nil {
+		t.Error("Expected an error for too many components")
+	}
+	if _, err := voyageai.FitPCA([][]float32{{1, 2}}, 1); err == nil {
+		t.Error("Expected an error for a single vector")
+	}
+	m, _ := voyageai.FitPCA([][]float32{{1, 2}, {3, 5}}, 1)
+	if _, err := m.Transform([]float32{1}); err == nil {
+		t.Error("Expected an error for the wrong dimension")
+	}
+}
@@ -0,0 +1,108 @@
+package voyageai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Matched by the error of a connection whose certificates match none of
+// [VoyageClientOpts.PinnedSPKIHashes].
+var ErrCertificatePinMismatch = errors.New("voyage: certificate matches no pinned public key")
+
+// The error of a connection whose certificates match none of [VoyageClientOpts.PinnedSPKIHashes].
+// It matches [ErrCertificatePinMismatch].
+type CertificatePinError struct {
+	Presented []string // The base64 SHA-256 hashes of the public keys of the certificates the server presented.
+}
+
+func (e *CertificatePinError) Error() string {
+	return fmt.Sprintf("%v: presented %s", ErrCertificatePinMismatch, strings.Join(e.Presented, ", "))
+}
+
+func (e *CertificatePinError) Unwrap() error { return ErrCertificatePinMismatch }
+
+// failingTransport fails every request with err, the reason a client's HTTP options are invalid.
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
+
+// newHTTPClient returns the HTTP client for opts: the one they set, or one with their transport,
+// or with a transport of its own if they set TLS options.
+func newHTTPClient(opts *VoyageClientOpts) (*http.Client, error) {
+	hasTLS := opts.TLSConfig != nil || len(opts.PinnedSPKIHashes) > 0
+	switch {
+	case opts.HTTPClient != nil && (opts.Transport != nil || hasTLS):
+		return nil, errors.New("voyage: HTTPClient cannot be combined with Transport, TLSConfig or PinnedSPKIHashes")
+	case opts.Transport != nil && hasTLS:
+		return nil, errors.New("voyage: Transport cannot be combined with TLSConfig or PinnedSPKIHashes")
+	case opts.HTTPClient != nil:
+		return opts.HTTPClient, nil
+	case opts.Transport != nil:
+		return &http.Client{Transport: opts.Transport}, nil
+	case !hasTLS:
+		return &http.Client{}, nil
+	}
+	cfg := &tls.Config{}
+	if opts.TLSConfig != nil {
+		cfg = opts.TLSConfig.Clone()
+	}
+	if len(opts.PinnedSPKIHashes) > 0 {
+		if cfg.InsecureSkipVerify {
+			return nil, errors.New("voyage: PinnedSPKIHashes cannot be combined with InsecureSkipVerify")
+		}
+		pins := make([][]byte, len(opts.PinnedSPKIHashes))
+		for i, pin := range opts.PinnedSPKIHashes {
+			h, err := base64.StdEncoding.DecodeString(pin)
+			if err != nil || len(h) != sha256.Size {
+				return nil, fmt.Errorf("voyage: pin %q is not a base64 SHA-256 hash", pin)
+			}
+			pins[i] = h
+		}
+		verify := cfg.VerifyConnection
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+			return checkPins(cs, pins)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	return &http.Client{Transport: transport}, nil
+}
+
+// checkPins returns a [*CertificatePinError] unless a certificate of a verified chain has one of
+// the pinned public keys.
+func checkPins(cs tls.ConnectionState, pins [][]byte) error {
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(h[:], pin) {
+					return nil
+				}
+			}
+		}
+	}
+	err := &CertificatePinError{}
+	for _, cert := range cs.PeerCertificates {
+		h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		err.Presented = append(err.Presented, base64.StdEncoding.EncodeToString(h[:]))
+	}
+	return err
+}
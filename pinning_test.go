@@ -0,0 +1,85 @@
+package voyageai_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func newTLSMockServer(t *testing.T) (*httptest.Server, *tls.Config, string) {
+	api := newMockServer(t)
+	srv := httptest.NewTLSServer(api.Config.Handler)
+	t.Cleanup(srv.Close)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	h := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	return srv, &tls.Config{RootCAs: roots}, base64.StdEncoding.EncodeToString(h[:])
+}
+
+func TestPinnedSPKIHashes(t *testing.T) {
+	srv, cfg, pin := newTLSMockServer(t)
+	other := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	cases := []struct {
+		name  string
+		pins  []string
+		match bool
+	}{
+		{"matching pin", []string{pin}, true},
+		{"mismatched pin", []string{other}, false},
+		{"rotation", []string{other, pin}, true},
+	}
+	for _, c := range cases {
+		client, err := voyageai.NewClientWithError(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, TLSConfig: cfg, PinnedSPKIHashes: c.pins})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		_, err = client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil)
+		if c.match {
+			if err != nil {
+				t.Errorf("%s: expected the request to succeed, got %v", c.name, err)
+			}
+			continue
+		}
+		var pinErr *voyageai.CertificatePinError
+		if !errors.Is(err, voyageai.ErrCertificatePinMismatch) || !errors.As(err, &pinErr) {
+			t.Fatalf("%s: expected a pin mismatch, got %v", c.name, err)
+		}
+		if len(pinErr.Presented) != 1 || pinErr.Presented[0] != pin || !strings.Contains(err.Error(), pin) {
+			t.Errorf("%s: expected the presented hash to be named, got %v", c.name, err)
+		}
+	}
+
+	// Pins do not replace certificate verification.
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, PinnedSPKIHashes: []string{pin}})
+	if _, err := client.EmbedContext(context.Background(), []string{"a"}, "voyage-3.5", nil); err == nil || errors.Is(err, voyageai.ErrCertificatePinMismatch) {
+		t.Errorf("Expected an untrusted certificate to fail verification, got %v", err)
+	}
+}
+
+func TestPinnedSPKIHashesInvalid(t *testing.T) {
+	srv, cfg, pin := newTLSMockServer(t)
+	insecure := cfg.Clone()
+	insecure.InsecureSkipVerify = true
+	for _, opts := range []voyageai.VoyageClientOpts{
+		{TLSConfig: insecure, PinnedSPKIHashes: []string{pin}},
+		{PinnedSPKIHashes: []string{"not base64!"}},
+		{PinnedSPKIHashes: []string{base64.StdEncoding.EncodeToString([]byte("short"))}},
+	} {
+		if _, err := voyageai.NewClientWithError(&opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL, TLSConfig: insecure, PinnedSPKIHashes: []string{pin}})
+	if _, err := client.Embed([]string{"a"}, "voyage-3", nil); err == nil || !strings.Contains(err.Error(), "InsecureSkipVerify") {
+		t.Errorf("Expected the requests of a client with invalid pins to fail, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A method of combining chunk embeddings into one embedding. See [PoolChunks].
+type Pooling int
+
+const (
+	PoolMean               Pooling = iota // The mean of the embeddings.
+	PoolLengthWeightedMean                // The mean of the embeddings, weighted by the length of each chunk.
+	PoolMax                               // The maximum of each dimension.
+)
+
+func (p Pooling) String() string {
+	switch p {
+	case PoolMean:
+		return "mean"
+	case PoolLengthWeightedMean:
+		return "length-weighted-mean"
+	case PoolMax:
+		return "max"
+	default:
+		return fmt.Sprintf("Pooling(%d)", int(p))
+	}
+}
+
+// The embedded chunks of a text, returned by [VoyageClient.EmbedChunks].
+type ChunkEmbeddings []ChunkEmbedding
+
+// Pool combines the chunk embeddings into one. See [PoolChunks].
+func (ce ChunkEmbeddings) Pool(method Pooling) ([]float32, error) {
+	return PoolChunks(ce, method)
+}
+
+// PoolChunks combines the embeddings of a document's chunks into a single embedding for the
+// document, normalized to unit length. [PoolLengthWeightedMean] weighs each chunk by its
+// [EstimateTokens] count, or by its byte length from Start to End if it has no text, so that
+// short chunks such as a trailing fragment count for less.
+//
+// It returns an error if chunks is empty, their embeddings differ in dimension, or no chunk has
+// a length to weigh.
+func PoolChunks(chunks []ChunkEmbedding, method Pooling) ([]float32, error) {
+	if len(chunks) == 0 {
+		return nil, errors.New("voyage: no chunks to pool")
+	}
+	vecs := make([][]float32, len(chunks))
+	for i, ch := range chunks {
+		vecs[i] = ch.Embedding
+	}
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, err
+	}
+	if dim == 0 {
+		return nil, errors.New("voyage: chunks have empty embeddings")
+	}
+
+	pooled := make([]float64, dim)
+	switch method {
+	case PoolMean, PoolLengthWeightedMean:
+		var total float64
+		for _, ch := range chunks {
+			w := 1.0
+			if method == PoolLengthWeightedMean {
+				w = chunkWeight(ch.Chunk)
+			}
+			total += w
+			for d, x := range ch.Embedding {
+				pooled[d] += w * float64(x)
+			}
+		}
+		if total == 0 {
+			return nil, errors.New("voyage: chunks have no length to weigh")
+		}
+		for d := range pooled {
+			pooled[d] /= total
+		}
+	case PoolMax:
+		for d := range pooled {
+			pooled[d] = math.Inf(-1)
+		}
+		for _, ch := range chunks {
+			for d, x := range ch.Embedding {
+				pooled[d] = max(pooled[d], float64(x))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("voyage: unknown pooling method %v", method)
+	}
+
+	var n float64
+	for _, x := range pooled {
+		n += x * x
+	}
+	n = math.Sqrt(n)
+	out := make([]float32, dim)
+	for d, x := range pooled {
+		if n > 0 {
+			out[d] = float32(x / n)
+		}
+	}
+	return out, nil
+}
+
+// chunkWeight returns the weight of ch in a length-weighted mean.
+func chunkWeight(ch Chunk) float64 {
+	if ch.Text != "" {
+		return float64(EstimateTokens(ch.Text))
+	}
+	return float64(max(ch.End-ch.Start, 0))
+}
+
+// EmbedChunks splits text into chunks with [ChunkText], as chunkOpts configure, and embeds them with
+// [VoyageClient.EmbedBatch], returning each chunk with its embedding in text order. Pool the
+// result for an embedding of the whole text.
+func (c *VoyageClient) EmbedChunks(ctx context.Context, text string, model string, chunkOpts ChunkOpts, opts *EmbeddingRequestOpts) (ChunkEmbeddings, *UsageObject, error) {
+	chunks := ChunkText(text, chunkOpts)
+	if len(chunks) == 0 {
+		return nil, &UsageObject{}, nil
+	}
+	texts := make([]string, len(chunks))
+	for i, ch := range chunks {
+		texts[i] = ch.embedText(chunkOpts)
+	}
+	resp, err := c.EmbedBatch(ctx, texts, model, opts, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(ChunkEmbeddings, len(chunks))
+	for i, ch := range chunks {
+		out[i] = ChunkEmbedding{Chunk: ch, Embedding: resp.Data[i].Embedding}
+	}
+	return out, &resp.Usage, nil
+}
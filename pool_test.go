@@ -0,0 +1,117 @@
+package voyageai_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func assertVector(t *testing.T, got []float32, want ...float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if math.Abs(float64(got[i])-want[i]) > 1e-6 {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestPoolChunksMean(t *testing.T) {
+	chunks := []voyageai.ChunkEmbedding{
+		{Chunk: voyageai.Chunk{Text: "aaaaaaaaaaaa"}, Embedding: []float32{1, 0}},
+		{Chunk: voyageai.Chunk{Text: "aaaa"}, Embedding: []float32{0, 1}},
+	}
+	got, err := voyageai.PoolChunks(chunks, voyageai.PoolMean)
+	if err != nil {
+		t.Fatalf("Failed to pool: %+v", err)
+	}
+	// (0.5, 0.5) normalized.
+	assertVector(t, got, 1/math.Sqrt2, 1/math.Sqrt2)
+
+	// 3 tokens and 1 token: (3*(1, 0) + 1*(0, 1)) / 4 = (0.75, 0.25), normalized by sqrt(0.625).
+	got, err = voyageai.PoolChunks(chunks, voyageai.PoolLengthWeightedMean)
+	if err != nil {
+		t.Fatalf("Failed to pool: %+v", err)
+	}
+	assertVector(t, got, 3/math.Sqrt(10), 1/math.Sqrt(10))
+}
+
+func TestPoolChunksWeightsByByteLengthWithoutText(t *testing.T) {
+	chunks := voyageai.ChunkEmbeddings{
+		{Chunk: voyageai.Chunk{Start: 0, End: 10}, Embedding: []float32{2, 0, 0}},
+		{Chunk: voyageai.Chunk{Start: 10, End: 40}, Embedding: []float32{0, 0, 2}},
+		{Chunk: voyageai.Chunk{Start: 40, End: 40}, Embedding: []float32{0, 100, 0}},
+	}
+	got, err := chunks.Pool(voyageai.PoolLengthWeightedMean)
+	if err != nil {
+		t.Fatalf("Failed to pool: %+v", err)
+	}
+	// (10*(2, 0, 0) + 30*(0, 0, 2)) / 40 = (0.5, 0, 1.5); the empty chunk weighs nothing.
+	assertVector(t, got, 1/math.Sqrt(10), 0, 3/math.Sqrt(10))
+}
+
+func TestPoolChunksMax(t *testing.T) {
+	chunks := []voyageai.ChunkEmbedding{
+		{Embedding: []float32{0.1, -0.5, 0.3, -2}},
+		{Embedding: []float32{0.4, -0.2, -0.3, -1}},
+		{Embedding: []float32{-0.9, -0.7, 0.2, -3}},
+	}
+	got, err := voyageai.PoolChunks(chunks, voyageai.PoolMax)
+	if err != nil {
+		t.Fatalf("Failed to pool: %+v", err)
+	}
+	// (0.4, -0.2, 0.3, -1), whose norm is sqrt(1.29).
+	n := math.Sqrt(1.29)
+	assertVector(t, got, 0.4/n, -0.2/n, 0.3/n, -1/n)
+}
+
+func TestPoolChunksErrors(t *testing.T) {
+	if _, err := voyageai.PoolChunks(nil, voyageai.PoolMean); err == nil {
+		t.Error("Expected an error pooling no chunks")
+	}
+	mismatched := []voyageai.ChunkEmbedding{{Embedding: []float32{1, 0}}, {Embedding: []float32{1, 0, 0}}}
+	if _, err := voyageai.PoolChunks(mismatched, voyageai.PoolMax); err == nil || !strings.Contains(err.Error(), "dimension") {
+		t.Errorf("Expected a dimension mismatch error, got %v", err)
+	}
+	empty := []voyageai.ChunkEmbedding{{Embedding: []float32{1, 0}}}
+	if _, err := voyageai.PoolChunks(empty, voyageai.PoolLengthWeightedMean); err == nil {
+		t.Error("Expected an error weighing chunks without length")
+	}
+	if _, err := voyageai.PoolChunks(empty, voyageai.Pooling(9)); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+}
+
+func TestEmbedChunks(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embed = topicVector
+
+	text := strings.Repeat("cat ", 30) + strings.Repeat("dog ", 10)
+	chunks, usage, err := srv.client().EmbedChunks(context.Background(), text, "test-model", voyageai.ChunkOpts{MaxTokens: 30}, nil)
+	if err != nil {
+		t.Fatalf("Failed to embed chunks: %+v", err)
+	}
+	if len(chunks) != 2 || usage.TotalTokens == 0 {
+		t.Fatalf("Expected 2 chunks and their usage, got %d chunks and %+v", len(chunks), usage)
+	}
+	for i, ch := range chunks {
+		if text[ch.Start:ch.End] != ch.Text {
+			t.Errorf("Chunk %d text does not match its offsets", i)
+		}
+		if want := topicVector(ch.Text); ch.Embedding[0] != want[0] || ch.Embedding[1] != want[1] {
+			t.Errorf("Chunk %d: expected embedding %v, got %v", i, want, ch.Embedding)
+		}
+	}
+	doc, err := chunks.Pool(voyageai.PoolLengthWeightedMean)
+	if err != nil {
+		t.Fatalf("Failed to pool: %+v", err)
+	}
+	if len(doc) != 3 || doc[0] <= doc[1] {
+		t.Errorf("Expected the longer cat chunk to dominate, got %v", doc)
+	}
+}
@@ -0,0 +1,279 @@
+package voyageai
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Returned by [LoadPQCodebook] when the input is not a complete, valid codebook.
+var ErrCorruptPQ = errors.New("voyage: corrupt PQ codebook")
+
+const (
+	pqMagic   = "VPQC"
+	pqVersion = 1
+
+	// The most centroids a subspace can have, so that every code fits in a byte.
+	MaxPQCentroids = 256
+)
+
+// Options for [TrainPQ].
+type PQOpts struct {
+	Subspaces int   // The number of subspaces each vector is split into, and so the number of bytes per code. Must be in [1, dim].
+	Centroids int   // The number of centroids per subspace. Defaults to, and may not exceed, [MaxPQCentroids].
+	Seed      int64 // Seeds the k-means runs. Training is deterministic for a given seed and input.
+}
+
+// A product quantization codebook trained by [TrainPQ]. Vectors are split into contiguous
+// subspaces and each subspace is replaced by the index of its nearest centroid, so a vector is
+// stored in one byte per subspace.
+type PQCodebook struct {
+	// Centroids[s][c] is centroid c of subspace s. Subspace s covers the dimensions following
+	// those of subspaces 0 through s-1, and its width is the length of its centroids.
+	Centroids [][][]float32
+}
+
+// TrainPQ trains a codebook on vecs, which must all have the same dimension, by running
+// [KMeans] with [MetricEuclidean] on each subspace. When the dimension is not a multiple of
+// opts.Subspaces the first subspaces are one dimension wider. A subspace gets fewer centroids
+// than requested only if vecs holds fewer vectors.
+//
+// Training cost grows with len(vecs) × Centroids × dim, so large collections are best trained on
+// a random sample and then encoded in full.
+func TrainPQ(vecs [][]float32, opts PQOpts) (*PQCodebook, error) {
+	dim, err := checkDims(vecs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) == 0 {
+		return nil, errors.New("voyage: PQ training needs at least one vector")
+	}
+	if opts.Subspaces <= 0 || opts.Subspaces > dim {
+		return nil, fmt.Errorf("voyage: PQ subspaces must be in [1, %d], got %d", dim, opts.Subspaces)
+	}
+	centroids := opts.Centroids
+	if centroids == 0 {
+		centroids = MaxPQCentroids
+	}
+	if centroids < 0 || centroids > MaxPQCentroids {
+		return nil, fmt.Errorf("voyage: PQ centroids must be in [1, %d], got %d", MaxPQCentroids, centroids)
+	}
+
+	cb := &PQCodebook{}
+	sub := make([][]float32, len(vecs))
+	start := 0
+	for s := range opts.Subspaces {
+		end := start + dim/opts.Subspaces
+		if s < dim%opts.Subspaces {
+			end++
+		}
+		for i, v := range vecs {
+			sub[i] = v[start:end]
+		}
+		_, cs, err := KMeans(sub, centroids, KMeansOpts{Seed: opts.Seed + int64(s), Metric: MetricEuclidean})
+		if err != nil {
+			return nil, fmt.Errorf("voyage: PQ subspace %d: %w", s, err)
+		}
+		cb.Centroids = append(cb.Centroids, cs)
+		start = end
+	}
+	return cb, nil
+}
+
+// Dim returns the dimension of the vectors the codebook encodes.
+func (cb *PQCodebook) Dim() int {
+	dim := 0
+	for _, cs := range cb.Centroids {
+		dim += len(cs[0])
+	}
+	return dim
+}
+
+// Subspaces returns the number of subspaces, which is also the length of every code.
+func (cb *PQCodebook) Subspaces() int {
+	return len(cb.Centroids)
+}
+
+// Encode returns the code of vec: the index of the nearest centroid in each subspace.
+func (cb *PQCodebook) Encode(vec []float32) ([]byte, error) {
+	if dim := cb.Dim(); len(vec) != dim {
+		return nil, fmt.Errorf("voyage: vector has dimension %d, expected %d", len(vec), dim)
+	}
+	return cb.encode(vec), nil
+}
+
+func (cb *PQCodebook) encode(vec []float32) []byte {
+	code := make([]byte, len(cb.Centroids))
+	start := 0
+	for s, cs := range cb.Centroids {
+		part := vec[start : start+len(cs[0])]
+		best, bestDist := 0, math.Inf(1)
+		for c, centroid := range cs {
+			if d := squaredDistance(part, centroid); d < bestDist {
+				best, bestDist = c, d
+			}
+		}
+		code[s] = byte(best)
+		start += len(part)
+	}
+	return code
+}
+
+// Decode returns the vector a code stands for: the concatenation of its centroids.
+func (cb *PQCodebook) Decode(code []byte) ([]float32, error) {
+	if err := cb.checkCode(code); err != nil {
+		return nil, err
+	}
+	return cb.decode(code), nil
+}
+
+func (cb *PQCodebook) decode(code []byte) []float32 {
+	out := make([]float32, 0, cb.Dim())
+	for s, cs := range cb.Centroids {
+		out = append(out, cs[code[s]]...)
+	}
+	return out
+}
+
+func (cb *PQCodebook) checkCode(code []byte) error {
+	if len(code) != len(cb.Centroids) {
+		return fmt.Errorf("voyage: PQ code has length %d, expected %d", len(code), len(cb.Centroids))
+	}
+	for s, c := range code {
+		if int(c) >= len(cb.Centroids[s]) {
+			return fmt.Errorf("voyage: PQ code byte %d is %d, but subspace %d has %d centroids", s, c, s, len(cb.Centroids[s]))
+		}
+	}
+	return nil
+}
+
+// ADCScore returns the Euclidean distance between query and the vector code stands for,
+// computed asymmetrically: the query is not quantized, only the stored vector is. To score many
+// codes against one query, use a [VectorIndex] created with [NewPQVectorIndex], which computes
+// the per-subspace distances once per query.
+func (cb *PQCodebook) ADCScore(query []float32, code []byte) (float64, error) {
+	if dim := cb.Dim(); len(query) != dim {
+		return 0, fmt.Errorf("voyage: query has dimension %d, expected %d", len(query), dim)
+	}
+	if err := cb.checkCode(code); err != nil {
+		return 0, err
+	}
+	return math.Sqrt(cb.table(query, MetricEuclidean).score(code)), nil
+}
+
+// pqTable holds, for one query, a value per subspace and centroid whose sum over a code gives
+// the code's score: squared distances for [MetricEuclidean] and dot products otherwise.
+type pqTable [][]float64
+
+func (cb *PQCodebook) table(query []float32, metric Metric) pqTable {
+	t := make(pqTable, len(cb.Centroids))
+	start := 0
+	for s, cs := range cb.Centroids {
+		part := query[start : start+len(cs[0])]
+		t[s] = make([]float64, len(cs))
+		for c, centroid := range cs {
+			if metric == MetricEuclidean {
+				t[s][c] = squaredDistance(part, centroid)
+			} else {
+				t[s][c] = dot(part, centroid)
+			}
+		}
+		start += len(part)
+	}
+	return t
+}
+
+func (t pqTable) score(code []byte) float64 {
+	var sum float64
+	for s, c := range code {
+		sum += t[s][c]
+	}
+	return sum
+}
+
+func squaredDistance(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return sum
+}
+
+// Save writes the codebook to w in a versioned binary format readable by [LoadPQCodebook]: the
+// magic "VPQC", a uint16 version and uint32 subspace count, then per subspace a uint32 width and
+// centroid count followed by the centroids as float32 values, all little-endian.
+func (cb *PQCodebook) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(cb.appendTo(nil)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (cb *PQCodebook) appendTo(buf []byte) []byte {
+	buf = append(buf, pqMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, pqVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(cb.Centroids)))
+	for _, cs := range cb.Centroids {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(cs[0])))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(cs)))
+		for _, c := range cs {
+			for _, x := range c {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(x))
+			}
+		}
+	}
+	return buf
+}
+
+// LoadPQCodebook reads a codebook written by [PQCodebook.Save]. It returns an error wrapping
+// [ErrCorruptPQ] if the input is truncated or malformed.
+func LoadPQCodebook(r io.Reader) (*PQCodebook, error) {
+	return readPQCodebook(&indexDecoder{r: bufio.NewReader(r)})
+}
+
+func readPQCodebook(d *indexDecoder) (*PQCodebook, error) {
+	magic := d.bytes(4)
+	if d.err == nil && string(magic) != pqMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrCorruptPQ, magic)
+	}
+	if version := d.uint16(); d.err == nil && version != pqVersion {
+		return nil, fmt.Errorf("voyage: unsupported PQ codebook version %d", version)
+	}
+	subspaces := d.uint32()
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: truncated header", ErrCorruptPQ)
+	}
+	if subspaces == 0 || subspaces > maxIndexDim {
+		return nil, fmt.Errorf("%w: invalid subspace count %d", ErrCorruptPQ, subspaces)
+	}
+
+	cb := &PQCodebook{}
+	dim := 0
+	for s := range subspaces {
+		width, count := d.uint32(), d.uint32()
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: truncated in subspace %d", ErrCorruptPQ, s)
+		}
+		dim += int(width)
+		if width == 0 || dim > maxIndexDim || count == 0 || count > MaxPQCentroids {
+			return nil, fmt.Errorf("%w: invalid subspace %d shape %d×%d", ErrCorruptPQ, s, count, width)
+		}
+		cs := make([][]float32, count)
+		for c := range cs {
+			cs[c] = make([]float32, width)
+			for i := range cs[c] {
+				cs[c][i] = math.Float32frombits(d.uint32())
+			}
+		}
+		if d.err != nil {
+			return nil, fmt.Errorf("%w: truncated in subspace %d", ErrCorruptPQ, s)
+		}
+		cb.Centroids = append(cb.Centroids, cs)
+	}
+	return cb, nil
+}
@@ -0,0 +1,213 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// pqFixture returns 2000 vectors of dimension 64 drawn around 200 random centers, plus 50 queries
+// drawn the same way.
+func pqFixture() (vecs, queries [][]float32) {
+	rng := rand.New(rand.NewPCG(11, 0))
+	centers := make([][]float32, 200)
+	for i := range centers {
+		centers[i] = randomVector(rng, 64)
+	}
+	for i := range 2000 {
+		vecs = append(vecs, jitter(rng, centers[i%len(centers)], 0.8))
+	}
+	for range 50 {
+		queries = append(queries, jitter(rng, centers[rng.IntN(len(centers))], 0.8))
+	}
+	return vecs, queries
+}
+
+func TestPQRecall(t *testing.T) {
+	vecs, queries := pqFixture()
+	cb, err := voyageai.TrainPQ(vecs, voyageai.PQOpts{Subspaces: 16, Seed: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, metric := range []voyageai.Metric{voyageai.MetricCosine, voyageai.MetricEuclidean} {
+		exact := voyageai.NewVectorIndex(64, metric)
+		approx := voyageai.NewPQVectorIndex(cb, metric)
+		for i, v := range vecs {
+			id := fmt.Sprint(i)
+			if err := exact.Add(id, v, nil); err != nil {
+				t.Fatal(err.Error())
+			}
+			if err := approx.Add(id, v, nil); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+
+		// Recall@10: the fraction of the exact top ten found in the PQ top ten.
+		const threshold = 0.9
+		found := 0
+		for _, q := range queries {
+			want, _ := exact.Search(q, 10)
+			got, _ := approx.Search(q, 10)
+			ids := map[string]bool{}
+			for _, h := range got {
+				ids[h.ID] = true
+			}
+			for _, h := range want {
+				if ids[h.ID] {
+					found++
+				}
+			}
+		}
+		recall := float64(found) / float64(10*len(queries))
+		if recall < threshold {
+			t.Errorf("%v: recall@10 is %.2f, expected at least %.2f", metric, recall, threshold)
+		}
+	}
+}
+
+func TestPQDeterministic(t *testing.T) {
+	vecs, _ := pqFixture()
+	opts := voyageai.PQOpts{Subspaces: 8, Centroids: 32, Seed: 7}
+	a, err := voyageai.TrainPQ(vecs, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	b, err := voyageai.TrainPQ(vecs, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Expected identical codebooks for the same seed")
+	}
+}
+
+func TestPQEncodeDecode(t *testing.T) {
+	vecs, _ := pqFixture()
+	// 64 dimensions over 5 subspaces gives widths of 13, 13, 13, 13 and 12.
+	cb, err := voyageai.TrainPQ(vecs, voyageai.PQOpts{Subspaces: 5, Centroids: 64, Seed: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if cb.Dim() != 64 || cb.Subspaces() != 5 {
+		t.Fatalf("Expected a 64-dimension codebook with 5 subspaces, got %d and %d", cb.Dim(), cb.Subspaces())
+	}
+
+	code, err := cb.Encode(vecs[0])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(code) != 5 {
+		t.Fatalf("Expected a 5 byte code, got %d", len(code))
+	}
+	decoded, err := cb.Decode(code)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// ADC against the original vector is the distance to its reconstruction.
+	score, err := cb.ADCScore(vecs[0], code)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var want float64
+	for i := range decoded {
+		d := float64(vecs[0][i] - decoded[i])
+		want += d * d
+	}
+	if want = math.Sqrt(want); math.Abs(score-want) > 1e-6 {
+		t.Errorf("Expected an ADC score of %v, got %v", want, score)
+	}
+	// A decoded vector encodes to the same code.
+	if again, _ := cb.Encode(decoded); !bytes.Equal(again, code) {
+		t.Errorf("Expected %v to encode to %v, got %v", decoded, code, again)
+	}
+
+	if _, err := cb.Encode(vecs[0][:10]); err == nil {
+		t.Error("Expected an error for a vector of the wrong dimension")
+	}
+	if _, err := cb.Decode([]byte{0, 0, 0, 0, 255}); err == nil {
+		t.Error("Expected an error for a code naming a missing centroid")
+	}
+}
+
+func TestTrainPQRejectsBadOptions(t *testing.T) {
+	vecs, _ := pqFixture()
+	for _, opts := range []voyageai.PQOpts{
+		{Subspaces: 0},
+		{Subspaces: 65},
+		{Subspaces: 8, Centroids: 257},
+	} {
+		if _, err := voyageai.TrainPQ(vecs, opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestPQCodebookRoundTrip(t *testing.T) {
+	vecs, _ := pqFixture()
+	cb, err := voyageai.TrainPQ(vecs, voyageai.PQOpts{Subspaces: 8, Centroids: 16, Seed: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var buf bytes.Buffer
+	if err := cb.Save(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	data := buf.Bytes()
+
+	loaded, err := voyageai.LoadPQCodebook(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(cb, loaded) {
+		t.Error("Expected the loaded codebook to equal the saved one")
+	}
+
+	if _, err := voyageai.LoadPQCodebook(bytes.NewReader(data[:len(data)-3])); !errors.Is(err, voyageai.ErrCorruptPQ) {
+		t.Errorf("Expected ErrCorruptPQ for a truncated codebook, got %v", err)
+	}
+}
+
+func TestPQVectorIndexRoundTrip(t *testing.T) {
+	vecs, queries := pqFixture()
+	cb, err := voyageai.TrainPQ(vecs, voyageai.PQOpts{Subspaces: 8, Centroids: 16, Seed: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	x := voyageai.NewPQVectorIndex(cb, voyageai.MetricCosine)
+	for i, v := range vecs[:200] {
+		if err := x.Add(fmt.Sprint(i), v, map[string]string{"n": fmt.Sprint(i)}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	x.Delete("3")
+
+	var buf bytes.Buffer
+	if err := x.Save(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded, err := voyageai.LoadVectorIndex(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if loaded.Codebook() == nil || loaded.Len() != 199 {
+		t.Fatalf("Expected a PQ index of 199 vectors, got codebook %v and %d vectors", loaded.Codebook() != nil, loaded.Len())
+	}
+	want, _ := x.Search(queries[0], 5)
+	got, _ := loaded.Search(queries[0], 5)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	plain := voyageai.NewVectorIndex(64, voyageai.MetricCosine)
+	if err := plain.SaveAs(&buf, voyageai.StoragePQ); err == nil {
+		t.Error("Expected an error saving a plain index as PQ codes")
+	}
+}
@@ -0,0 +1,127 @@
+package voyageai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The scheduling priority of a call. See [WithPriority].
+type Priority int
+
+const (
+	// The default priority, meant for interactive traffic.
+	PriorityHigh Priority = iota
+	// A priority for background work such as batch jobs, which gives way to high priority calls.
+	PriorityLow
+)
+
+// The default of [VoyageClientOpts.PriorityAging].
+const DefaultPriorityAging = 5 * time.Second
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx that makes calls using it run at priority p.
+//
+// Priorities matter when requests have to wait. When the client's
+// [VoyageClientOpts.MaxConcurrentRequests] slots are all in use, a free slot goes to the longest
+// waiting high priority request before any low priority one. Likewise, when requests wait for a
+// [VoyageClientOpts.RateLimit], high priority requests are admitted by the limiter before low
+// priority ones, which only reserve the limit's capacity once no high priority request is
+// waiting. Helpers that send many requests, such as [VoyageClient.EmbedBatch], wait again for
+// every request, so low priority batches give way to high priority calls between sub-batches.
+//
+// To bound starvation, a low priority request that has waited for
+// [VoyageClientOpts.PriorityAging] or longer is treated as high priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// prioritySem is a counting semaphore that admits waiters by priority.
+type prioritySem struct {
+	clock Clock
+	aging time.Duration
+
+	mu        sync.Mutex
+	free      int
+	high, low []*semWaiter // FIFO queues.
+}
+
+type semWaiter struct {
+	ready    chan struct{} // Closed when the waiter has been given a slot.
+	enqueued time.Time
+}
+
+func newPrioritySem(n int, clock Clock, aging time.Duration) *prioritySem {
+	if aging <= 0 {
+		aging = DefaultPriorityAging
+	}
+	return &prioritySem{clock: clock, aging: aging, free: n}
+}
+
+// acquire blocks until a slot is available for a request of priority p, or ctx is done.
+func (s *prioritySem) acquire(ctx context.Context, p Priority) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.free > 0 && len(s.high) == 0 && len(s.low) == 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	w := &semWaiter{ready: make(chan struct{}), enqueued: s.clock.Now()}
+	if p == PriorityLow {
+		s.low = append(s.low, w)
+	} else {
+		s.high = append(s.high, w)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := remove(&s.high, w) || remove(&s.low, w)
+		s.mu.Unlock()
+		if !removed {
+			// The slot was handed over just as ctx was done.
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, handing it to the next waiter if there is one.
+func (s *prioritySem) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next *semWaiter
+	switch {
+	case len(s.low) > 0 && (len(s.high) == 0 || s.clock.Now().Sub(s.low[0].enqueued) >= s.aging):
+		next, s.low = s.low[0], s.low[1:]
+	case len(s.high) > 0:
+		next, s.high = s.high[0], s.high[1:]
+	}
+	if next == nil {
+		s.free++
+		return
+	}
+	close(next.ready)
+}
+
+func remove(queue *[]*semWaiter, w *semWaiter) bool {
+	for i, q := range *queue {
+		if q == w {
+			*queue = append((*queue)[:i], (*queue)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package voyageai_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// newSerialServer returns a mock server whose requests each take delay, along with a function
+// returning the first input of every request in the order they were served.
+func newSerialServer(t *testing.T, delay time.Duration) (*mockServer, func() []string) {
+	srv := newMockServer(t)
+	var mu sync.Mutex
+	var order []string
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		mu.Lock()
+		order = append(order, req.Input[0])
+		mu.Unlock()
+		time.Sleep(delay)
+		return 0
+	}
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(order)
+	}
+}
+
+func TestPriorityPreemptsLowPriorityBatches(t *testing.T) {
+	srv, order := newSerialServer(t, 20*time.Millisecond)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxConcurrentRequests: 1})
+
+	// Saturate the client with low priority batches of five sub-batches each.
+	low := voyageai.WithPriority(context.Background(), voyageai.PriorityLow)
+	var wg sync.WaitGroup
+	for b := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var texts []string
+			for i := range 5 {
+				texts = append(texts, fmt.Sprintf("low-%d-%d", b, i))
+			}
+			if _, err := client.EmbedBatch(low, texts, "test-model", nil, &voyageai.BatchOpts{BatchSize: 1}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	started := len(order())
+	start := time.Now()
+	if _, err := client.EmbedContext(context.Background(), []string{"high"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	highDelay := time.Since(start)
+	wg.Wait()
+
+	// The high priority call only waits for the request in flight when it arrived, ahead of the
+	// low priority requests already queued. Allow for that request finishing just before.
+	served := order()
+	if pos := slices.Index(served, "high"); pos < started || pos > started+1 {
+		t.Errorf("Expected the high priority request at position %d, got %d: %v", started, pos, served)
+	}
+	if highDelay > 100*time.Millisecond {
+		t.Errorf("Expected the high priority call to wait about one request, waited %v", highDelay)
+	}
+}
+
+func TestPriorityAging(t *testing.T) {
+	clock := newFakeClock()
+	srv := newMockServer(t)
+	unblock := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		mu.Lock()
+		order = append(order, req.Input[0])
+		mu.Unlock()
+		if n == 1 {
+			<-unblock
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:                   "APIKEY",
+		BaseURL:               srv.URL,
+		MaxConcurrentRequests: 1,
+		PriorityAging:         time.Second,
+		Clock:                 clock,
+	})
+
+	embed := func(ctx context.Context, text string, wg *sync.WaitGroup) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.EmbedContext(ctx, []string{text}, "test-model", nil); err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(20 * time.Millisecond) // Let the call reach the front of its queue.
+	}
+
+	var wg sync.WaitGroup
+	embed(context.Background(), "blocker", &wg)
+	embed(voyageai.WithPriority(context.Background(), voyageai.PriorityLow), "old-low", &wg)
+	clock.Advance(2 * time.Second)
+	embed(context.Background(), "high", &wg)
+	close(unblock)
+	wg.Wait()
+
+	if fmt.Sprint(order) != "[blocker old-low high]" {
+		t.Errorf("Expected the aged low priority request to go first, got %v", order)
+	}
+}
@@ -0,0 +1,103 @@
+package voyageai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The default maximum length of a line read by [VoyageClient.EmbedReader], in bytes.
+const DefaultMaxLineLength = 1 << 20
+
+// Configuration for [VoyageClient.EmbedReader].
+type ReaderConfig struct {
+	BatchSize     int // The maximum number of lines per request. Defaults to [DefaultBatchSize].
+	MaxLineLength int // The maximum length of a line in bytes. Defaults to [DefaultMaxLineLength].
+	// The maximum tokens per request, as counted by the client's [Tokenizer], or estimated with
+	// [EstimateTokens] if it has none. No limit is applied by default.
+	MaxBatchTokens int
+}
+
+// EmbedReader embeds each non-blank line of newline-delimited text read from r and calls handle
+// with the 1-based line number, the line and its embedding, in input order.
+//
+// Lines are read and embedded one batch at a time, so memory use is proportional to the batch
+// size rather than the size of the input. Reading stops with an error naming the line if a line
+// is longer than [ReaderConfig.MaxLineLength] or if handle returns an error.
+func (c *VoyageClient) EmbedReader(ctx context.Context, r io.Reader, model string, opts *EmbeddingRequestOpts, cfg ReaderConfig, handle func(lineNo int, text string, emb []float32) error) error {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	maxLine := cfg.MaxLineLength
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineLength
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(maxLine, 64*1024)), maxLine)
+
+	var lineNos []int
+	var texts []string
+	tokens := 0
+	flush := func() error {
+		if len(texts) == 0 {
+			return nil
+		}
+		resp, err := c.embedContext(ctx, texts, model, opts)
+		if err != nil {
+			return fmt.Errorf("voyage: embed lines %d-%d: %w", lineNos[0], lineNos[len(lineNos)-1], err)
+		}
+		embs := make([][]float32, len(texts))
+		for _, obj := range resp.Data {
+			if obj.Index >= 0 && obj.Index < len(embs) {
+				embs[obj.Index] = obj.Embedding
+			}
+		}
+		for i, text := range texts {
+			if err := handle(lineNos[i], text, embs[i]); err != nil {
+				return fmt.Errorf("voyage: handle line %d: %w", lineNos[i], err)
+			}
+		}
+		lineNos, texts, tokens = lineNos[:0], texts[:0], 0
+		return nil
+	}
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		text := string(line)
+		n, err := c.countTokens(model, text)
+		if err != nil {
+			return fmt.Errorf("voyage: line %d: %w", lineNo, err)
+		}
+		if cfg.MaxBatchTokens > 0 && tokens+n > cfg.MaxBatchTokens {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		lineNos = append(lineNos, lineNo)
+		texts = append(texts, text)
+		tokens += n
+		if len(texts) >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("voyage: line %d exceeds the maximum line length of %d bytes", lineNo+1, maxLine)
+		}
+		return fmt.Errorf("voyage: read line %d: %w", lineNo+1, err)
+	}
+	return flush()
+}
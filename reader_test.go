@@ -0,0 +1,127 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// lineReader generates n lines of text on the fly. Every tenth line is blank.
+type lineReader struct {
+	n, line int
+	buf     []byte
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.line >= r.n {
+			return 0, io.EOF
+		}
+		r.line++
+		if r.line%10 == 0 {
+			r.buf = []byte("   \n")
+		} else {
+			r.buf = fmt.Appendf(nil, "line %d\n", r.line)
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestEmbedReaderStreamsInOrder(t *testing.T) {
+	srv := newMockServer(t)
+	const lines = 5001
+
+	next := 1
+	err := srv.client().EmbedReader(context.Background(), &lineReader{n: lines}, "test-model", nil, voyageai.ReaderConfig{BatchSize: 64}, func(lineNo int, text string, emb []float32) error {
+		if next%10 == 0 {
+			next++
+		}
+		if lineNo != next {
+			t.Fatalf("Expected line %d, got %d", next, lineNo)
+		}
+		if text != fmt.Sprintf("line %d", lineNo) || !slices.Equal(emb, fakeVector(text)) {
+			t.Fatalf("Wrong text or embedding for line %d", lineNo)
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if next != lines+1 {
+		t.Errorf("Expected every non-blank line to be handled, stopped at %d", next)
+	}
+
+	for i, req := range srv.requests {
+		if len(req.Input) > 64 {
+			t.Errorf("Request %d has %d inputs, more than the batch size", i, len(req.Input))
+		}
+	}
+}
+
+func TestEmbedReaderTokenLimit(t *testing.T) {
+	srv := newMockServer(t)
+	input := strings.Repeat(strings.Repeat("a", 40)+"\n", 10)
+
+	err := srv.client().EmbedReader(context.Background(), strings.NewReader(input), "test-model", nil, voyageai.ReaderConfig{MaxBatchTokens: 25}, func(int, string, []float32) error { return nil })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Each line is estimated at 10 tokens, so at most two fit in a request.
+	if n := srv.requestCount(); n != 5 {
+		t.Errorf("Expected 5 requests, got %d", n)
+	}
+}
+
+func TestEmbedReaderTokenizer(t *testing.T) {
+	srv := newMockServer(t)
+	// Each line is estimated at 3 tokens, but counts 5 with the client's tokenizer.
+	input := strings.Repeat("a b c d e\n", 6)
+
+	client := newTokenizerClient(srv.URL)
+	err := client.EmbedReader(context.Background(), strings.NewReader(input), "test-model", nil, voyageai.ReaderConfig{MaxBatchTokens: 10}, func(int, string, []float32) error { return nil })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := srv.requestCount(); n != 3 {
+		t.Errorf("Expected 3 requests of two lines each, got %d", n)
+	}
+}
+
+func TestEmbedReaderLineTooLong(t *testing.T) {
+	srv := newMockServer(t)
+	input := "short\n\n" + strings.Repeat("x", 100) + "\nshort again\n"
+
+	err := srv.client().EmbedReader(context.Background(), strings.NewReader(input), "test-model", nil, voyageai.ReaderConfig{MaxLineLength: 50}, func(int, string, []float32) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an error naming line 3, got %v", err)
+	}
+}
+
+func TestEmbedReaderHandlerError(t *testing.T) {
+	srv := newMockServer(t)
+	errStop := errors.New("stop")
+
+	calls := 0
+	err := srv.client().EmbedReader(context.Background(), strings.NewReader("a\nb\nc\nd\n"), "test-model", nil, voyageai.ReaderConfig{BatchSize: 2}, func(lineNo int, text string, emb []float32) error {
+		calls++
+		if lineNo == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected the handler error for line 2, got %v", err)
+	}
+	if calls != 2 || srv.requestCount() != 1 {
+		t.Errorf("Expected the run to stop after line 2, got %d calls and %d requests", calls, srv.requestCount())
+	}
+}
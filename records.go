@@ -0,0 +1,185 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Renders records, such as database rows, to the text embedded for them. Create one with
+// [NewTextTemplate]. It is safe for concurrent use.
+type RecordRenderer struct {
+	tmpl *template.Template
+}
+
+// NewTextTemplate parses a [text/template] that renders a record to text. Besides the standard
+// functions, the template can use:
+//
+//   - join SEP ITEMS: the non-empty elements of the slice or array ITEMS, separated by SEP.
+//   - truncate N TEXT: TEXT shortened to at most N tokens, cut at a sentence or word boundary as
+//     [VoyageClient.TruncateToContext] does.
+//   - field LABEL VALUE: "LABEL: VALUE", or nothing if VALUE is empty.
+//
+// A value is empty if the template's if action would treat it as false, or if it formats to
+// whitespace only. Pointers are followed. Lines that render blank are dropped, so omitted fields
+// leave no gaps, and the text is trimmed.
+//
+// truncate counts tokens with [EstimateTokens], except in [EmbedRecords], which counts them with
+// the client's [Tokenizer].
+func NewTextTemplate(tmpl string) (*RecordRenderer, error) {
+	t, err := template.New("record").Option("missingkey=error").Funcs(recordFuncs(estimateCount)).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("voyage: parse record template: %w", err)
+	}
+	return &RecordRenderer{tmpl: t}, nil
+}
+
+func estimateCount(text string) (int, error) {
+	return EstimateTokens(text), nil
+}
+
+// Render renders record to text.
+func (r *RecordRenderer) Render(record any) (string, error) {
+	return renderRecord(r.tmpl, record)
+}
+
+// renderRecord executes t with record and drops its blank lines.
+func renderRecord(t *template.Template, record any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, record); err != nil {
+		return "", err
+	}
+	lines := strings.Split(b.String(), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), nil
+}
+
+// recordFuncs returns the template functions of [NewTextTemplate], counting tokens with count.
+func recordFuncs(count func(string) (int, error)) template.FuncMap {
+	return template.FuncMap{
+		"join": func(sep string, items any) (string, error) {
+			v := reflect.ValueOf(indirect(items))
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return "", fmt.Errorf("join: %T is not a slice", items)
+			}
+			var parts []string
+			for i := range v.Len() {
+				if s, ok := formatValue(v.Index(i).Interface()); ok {
+					parts = append(parts, s)
+				}
+			}
+			return strings.Join(parts, sep), nil
+		},
+		"truncate": func(n int, text any) (string, error) {
+			s, _ := formatValue(text)
+			out, _, err := truncateTokens(s, n, count)
+			if err != nil {
+				return "", fmt.Errorf("truncate: %w", err)
+			}
+			return out, nil
+		},
+		"field": func(label string, value any) string {
+			s, ok := formatValue(value)
+			if !ok {
+				return ""
+			}
+			return label + ": " + s
+		},
+	}
+}
+
+// formatValue formats v with [fmt.Sprint], following pointers. ok is false if v is empty.
+func formatValue(v any) (s string, ok bool) {
+	v = indirect(v)
+	if truth, _ := template.IsTrue(v); !truth {
+		return "", false
+	}
+	s = fmt.Sprint(v)
+	return s, strings.TrimSpace(s) != ""
+}
+
+// indirect follows the pointers of v to the value they point to, or nil.
+func indirect(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// Options for [EmbedRecords].
+type RecordEmbedOpts struct {
+	EmbedOpts *EmbeddingRequestOpts // Optional parameters for the embedding requests.
+	// Controls batching, as for [VoyageClient.EmbedBatch]. ResultWriter is ignored, since the
+	// embeddings are returned with their records.
+	BatchOpts *BatchOpts
+}
+
+// The embedding of one record, returned by [EmbedRecords].
+type EmbeddedRecord[T any] struct {
+	Index     int    // The index of the record in the input.
+	Record    T      // The record itself.
+	Text      string // The text the record was rendered to and embedded as.
+	Embedding []float32
+}
+
+// EmbedRecords renders every record with renderer and embeds the texts with
+// [VoyageClient.EmbedBatch]. The result has one entry per record, in input order, pairing the
+// record with its text and embedding. Rendering stops at the first record that fails, before any
+// request is sent.
+//
+// With [BatchOpts.ContinueOnError], the records of failed batches have no embedding, and the
+// errors are returned along with the result.
+func EmbedRecords[T any](ctx context.Context, client *VoyageClient, records []T, renderer *RecordRenderer, model string, opts RecordEmbedOpts) ([]EmbeddedRecord[T], *UsageObject, error) {
+	resolved, err := client.ResolveModel(model)
+	if err != nil {
+		return nil, nil, err
+	}
+	tok, _ := client.tokenizer()
+	t, err := renderer.tmpl.Clone()
+	if err != nil {
+		return nil, nil, fmt.Errorf("voyage: clone record template: %w", err)
+	}
+	t.Funcs(recordFuncs(func(text string) (int, error) { return tok.CountTokens(resolved, text) }))
+
+	out := make([]EmbeddedRecord[T], len(records))
+	texts := make([]string, len(records))
+	for i, record := range records {
+		text, err := renderRecord(t, record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("voyage: render record %d: %w", i, err)
+		}
+		texts[i] = text
+		out[i] = EmbeddedRecord[T]{Index: i, Record: record, Text: text}
+	}
+
+	var batchOpts BatchOpts
+	if opts.BatchOpts != nil {
+		batchOpts = *opts.BatchOpts
+	}
+	batchOpts.ResultWriter = nil
+	resp, err := client.EmbedBatch(ctx, texts, model, opts.EmbedOpts, &batchOpts)
+	if resp == nil {
+		return nil, nil, err
+	}
+	for _, obj := range resp.Data {
+		if obj.Index < 0 || obj.Index >= len(out) {
+			return nil, nil, fmt.Errorf("voyage: embedding index %d out of range", obj.Index)
+		}
+		out[obj.Index].Embedding = obj.Embedding
+	}
+	return out, &resp.Usage, err
+}
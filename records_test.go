@@ -0,0 +1,144 @@
+package voyageai_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+type product struct {
+	Name        string
+	Tags        []string
+	Price       float64
+	Notes       string
+	Description *string
+}
+
+const productTemplate = `{{field "Name" .Name}}
+{{field "Tags" (join ", " .Tags)}}
+{{field "Price" .Price}}
+{{field "Notes" .Notes}}
+{{field "Description" .Description}}`
+
+func TestTextTemplateHelpers(t *testing.T) {
+	r, err := voyageai.NewTextTemplate(productTemplate)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	desc := "A bright lamp."
+	tests := []struct {
+		record product
+		want   string
+	}{
+		{product{Name: "Lamp", Tags: []string{"light", "", "desk"}, Notes: "  "}, "Name: Lamp\nTags: light, desk"},
+		{product{Name: "Lamp", Price: 9.5, Description: &desc}, "Name: Lamp\nPrice: 9.5\nDescription: A bright lamp."},
+		{product{}, ""},
+	}
+	for _, tt := range tests {
+		got, err := r.Render(tt.record)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+
+	bad, err := voyageai.NewTextTemplate(`{{join ", " .Name}}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := bad.Render(product{Name: "Lamp"}); err == nil || !strings.Contains(err.Error(), "not a slice") {
+		t.Errorf("Expected join to reject a string, got %v", err)
+	}
+	if _, err := voyageai.NewTextTemplate(`{{field "Name"`); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestTextTemplateTruncate(t *testing.T) {
+	r, err := voyageai.NewTextTemplate(`{{truncate 5 .}}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Estimated at four ASCII characters per token, so 20 characters fit, cut at a word.
+	got, err := r.Render("One two. Three four five six seven.")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != "One two. Three four" {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+	if got, _ := r.Render("Short."); got != "Short." {
+		t.Errorf("Expected a short text unchanged, got %q", got)
+	}
+
+	// EmbedRecords counts with the client's tokenizer: one token per byte.
+	api := newMockServer(t)
+	client := newBudgetClient(api, voyageai.VoyageClientOpts{})
+	out, _, err := voyageai.EmbedRecords(context.Background(), client, []string{"One two. Three four five six seven."}, r, "voyage-3.5", voyageai.RecordEmbedOpts{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if out[0].Text != "One" || api.requests[0].Input[0] != "One" {
+		t.Errorf("Expected the client's tokenizer to be used, got %q", out[0].Text)
+	}
+}
+
+func TestEmbedRecordsPairing(t *testing.T) {
+	api := newMockServer(t)
+	client := api.client()
+	r, err := voyageai.NewTextTemplate(productTemplate)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var records []product
+	for _, name := range []string{"Lamp", "Desk", "Chair", "Shelf", "Rug", "Sofa", "Stool"} {
+		records = append(records, product{Name: name, Tags: []string{strings.ToLower(name)}})
+	}
+
+	out, usage, err := voyageai.EmbedRecords(context.Background(), client, records, r, "voyage-3.5", voyageai.RecordEmbedOpts{
+		BatchOpts: &voyageai.BatchOpts{BatchSize: 3},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(api.requests) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(api.requests))
+	}
+	if len(out) != len(records) || usage.TotalTokens == 0 {
+		t.Fatalf("Unexpected result %+v with usage %+v", out, usage)
+	}
+	for i, rec := range out {
+		wantText := "Name: " + records[i].Name + "\nTags: " + strings.ToLower(records[i].Name)
+		if rec.Index != i || !reflect.DeepEqual(rec.Record, records[i]) || rec.Text != wantText {
+			t.Errorf("Unexpected record %d: %+v", i, rec)
+		}
+		if !reflect.DeepEqual(rec.Embedding, fakeVector(wantText)) {
+			t.Errorf("Record %d is paired with the wrong embedding", i)
+		}
+	}
+}
+
+func TestEmbedRecordsRenderError(t *testing.T) {
+	api := newMockServer(t)
+	r, err := voyageai.NewTextTemplate(`{{.title}}: {{.body}}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	records := []map[string]string{
+		{"title": "a", "body": "x"},
+		{"title": "b", "body": "y"},
+		{"title": "c"},
+	}
+	_, _, err = voyageai.EmbedRecords(context.Background(), api.client(), records, r, "voyage-3.5", voyageai.RecordEmbedOpts{})
+	if err == nil || !strings.Contains(err.Error(), "render record 2") {
+		t.Errorf("Expected an error naming record 2, got %v", err)
+	}
+	if api.requestCount() != 0 {
+		t.Errorf("Expected no requests, got %d", api.requestCount())
+	}
+}
@@ -0,0 +1,141 @@
+package voyageai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"iter"
+)
+
+// Limits of the multimodal embeddings API used by [EmbedRenderedDocument].
+const (
+	MaxImagePixels          = 16_000_000    // The most pixels a single image may have.
+	DefaultMaxRequestPixels = 320_000 * 560 // The most image pixels per request: 320,000 tokens at 560 pixels per token.
+	MaxMultimodalInputs     = 1000          // The most inputs per request.
+)
+
+// Renders the pages of a document, such as a PDF or a slide deck, to images. Implementations
+// wrap an external renderer; none is included in this package.
+type PageRenderer interface {
+	// RenderPages returns the pages of doc in order. Rendering stops early if the consumer stops
+	// iterating or after a non-nil error is yielded.
+	RenderPages(ctx context.Context, doc io.Reader, opts RenderOpts) iter.Seq2[image.Image, error]
+}
+
+// Options passed on to a [PageRenderer].
+type RenderOpts struct {
+	DPI      int // The resolution to render at. Zero leaves the choice to the renderer.
+	MaxPages int // The maximum number of pages to render. Zero renders every page.
+}
+
+// Options for [EmbedRenderedDocument].
+type RenderedDocumentOpts struct {
+	Render  RenderOpts       // Passed on to the renderer.
+	Prepare PrepareImageOpts // How each page is encoded. See [PrepareImage].
+	// The maximum number of image pixels per request. Defaults to [DefaultMaxRequestPixels].
+	MaxRequestPixels int
+	// The maximum number of pages per request. Defaults to, and may not exceed, [MaxMultimodalInputs].
+	MaxPagesPerRequest int
+	EmbedOpts          *MultimodalRequestOpts // Optional parameters for the embedding requests.
+}
+
+// The embedding of one page of a document, returned by [EmbedRenderedDocument].
+type PageEmbedding struct {
+	Page          int // The 0-based page number, in the order the renderer produced the pages.
+	Width, Height int // The dimensions of the image that was embedded.
+	Embedding     []float32
+}
+
+// EmbedRenderedDocument renders doc with renderer and embeds every page as an image with a
+// multimodal model, such as [ModelVoyageMultimodal3].
+//
+// Pages larger than [MaxImagePixels] are scaled down, and every page is then encoded with
+// [PrepareImage] as configured in opts. Pages are sent in batches that stay within
+// opts.MaxRequestPixels and opts.MaxPagesPerRequest, as soon as each batch fills up, so only one
+// batch of pages is held in memory. Fallbacks are not applied, so every page is embedded with
+// model. The returned usage covers all requests.
+func EmbedRenderedDocument(ctx context.Context, client *VoyageClient, renderer PageRenderer, doc io.Reader, model string, opts RenderedDocumentOpts) ([]PageEmbedding, *UsageObject, error) {
+	maxPixels := opts.MaxRequestPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxRequestPixels
+	}
+	maxPages := opts.MaxPagesPerRequest
+	if maxPages <= 0 || maxPages > MaxMultimodalInputs {
+		maxPages = MaxMultimodalInputs
+	}
+
+	var out []PageEmbedding
+	usage := &UsageObject{}
+	var batch []MultimodalContent
+	var pages []PageEmbedding
+	batchPixels := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		resp, err := client.multimodalEmbed(ctx, batch, model, opts.EmbedOpts)
+		if err != nil {
+			return fmt.Errorf("voyage: embed pages %d-%d: %w", pages[0].Page, pages[len(pages)-1].Page, err)
+		}
+		if len(resp.Data) != len(batch) {
+			return fmt.Errorf("voyage: expected %d embeddings for pages %d-%d, got %d", len(batch), pages[0].Page, pages[len(pages)-1].Page, len(resp.Data))
+		}
+		for _, obj := range resp.Data {
+			if obj.Index < 0 || obj.Index >= len(pages) {
+				return fmt.Errorf("voyage: embedding index %d out of range", obj.Index)
+			}
+			pages[obj.Index].Embedding = obj.Embedding
+		}
+		out = append(out, pages...)
+		*usage = addUsage(*usage, resp.Usage)
+		batch, pages, batchPixels = nil, nil, 0
+		return nil
+	}
+
+	page := 0
+	for img, err := range renderer.RenderPages(ctx, doc, opts.Render) {
+		if err != nil {
+			return nil, nil, fmt.Errorf("voyage: render page %d: %w", page, err)
+		}
+		prepared, err := preparePage(img, opts.Prepare)
+		if err != nil {
+			return nil, nil, fmt.Errorf("voyage: prepare page %d: %w", page, err)
+		}
+		pixels := prepared.Width * prepared.Height
+		if pixels > maxPixels {
+			return nil, nil, fmt.Errorf("voyage: page %d has %d pixels, more than the %d allowed per request", page, pixels, maxPixels)
+		}
+		if len(batch) == maxPages || batchPixels+pixels > maxPixels {
+			if err := flush(); err != nil {
+				return nil, nil, err
+			}
+		}
+		batch = append(batch, MultimodalContent{Content: []MultimodalInput{Multimodal(prepared.Data)}})
+		pages = append(pages, PageEmbedding{Page: page, Width: prepared.Width, Height: prepared.Height})
+		batchPixels += pixels
+		page++
+	}
+	if err := flush(); err != nil {
+		return nil, nil, err
+	}
+	return out, usage, nil
+}
+
+// preparePage scales img down to at most [MaxImagePixels] and encodes it with [PrepareImage].
+func preparePage(img image.Image, opts PrepareImageOpts) (*PreparedImage, error) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	for w*h > MaxImagePixels {
+		w, h = w*3/4, h*3/4
+	}
+	if w != img.Bounds().Dx() {
+		img = scaleImage(img, w, h)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return PrepareImage(&buf, opts)
+}
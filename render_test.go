@@ -0,0 +1,142 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// fakeRenderer renders one solid page per size, with a red channel of 10 times the page number,
+// and fails after failAfter pages if set.
+type fakeRenderer struct {
+	sizes     []image.Point
+	failAfter int
+}
+
+func (f fakeRenderer) RenderPages(ctx context.Context, doc io.Reader, opts voyageai.RenderOpts) iter.Seq2[image.Image, error] {
+	return func(yield func(image.Image, error) bool) {
+		for i, size := range f.sizes {
+			if opts.MaxPages > 0 && i == opts.MaxPages {
+				return
+			}
+			if f.failAfter > 0 && i == f.failAfter {
+				yield(nil, errors.New("broken page"))
+				return
+			}
+			img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+			for p := 0; p < len(img.Pix); p += 4 {
+				img.Pix[p], img.Pix[p+3] = uint8(10*i), 0xff
+			}
+			if !yield(img, nil) {
+				return
+			}
+		}
+	}
+}
+
+// pageVector embeds an image input as its red channel and dimensions.
+func pageVector(content voyageai.MultimodalContent) []float32 {
+	data := string(content.Content[0].ImageBase64)
+	_, b64, _ := strings.Cut(data, ";base64,")
+	raw, _ := base64.StdEncoding.DecodeString(b64)
+	img, _, err := image.Decode(strings.NewReader(string(raw)))
+	if err != nil {
+		return nil
+	}
+	r, _, _, _ := color.NRGBAModel.Convert(img.At(0, 0)).RGBA()
+	return []float32{float32(r >> 8), float32(img.Bounds().Dx()), float32(img.Bounds().Dy())}
+}
+
+func TestEmbedRenderedDocument(t *testing.T) {
+	api := newMockServer(t)
+	api.embedContent = pageVector
+	renderer := fakeRenderer{sizes: []image.Point{{100, 100}, {100, 100}, {50, 100}, {100, 100}, {100, 100}, {20, 20}}}
+
+	pages, usage, err := voyageai.EmbedRenderedDocument(context.Background(), api.client(), renderer, strings.NewReader("%PDF"), "voyage-multimodal-3", voyageai.RenderedDocumentOpts{
+		MaxRequestPixels:   25000,
+		MaxPagesPerRequest: 2,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(pages) != 6 {
+		t.Fatalf("Expected 6 pages, got %d", len(pages))
+	}
+	for i, p := range pages {
+		size := renderer.sizes[i]
+		want := voyageai.PageEmbedding{Page: i, Width: size.X, Height: size.Y, Embedding: []float32{float32(10 * i), float32(size.X), float32(size.Y)}}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("Page %d: expected %+v, got %+v", i, want, p)
+		}
+	}
+	if usage.TotalTokens != 6 {
+		t.Errorf("Expected usage over every page, got %d tokens", usage.TotalTokens)
+	}
+
+	// Two pages per request at most, and 25000 pixels: 10000+10000, 5000+10000, 10000+400.
+	var sizes []int
+	for _, req := range api.multimodalRequests() {
+		sizes = append(sizes, len(req.Inputs))
+		if req.Model != "voyage-multimodal-3" {
+			t.Errorf("Expected the multimodal model, got %q", req.Model)
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int{2, 2, 2}) {
+		t.Errorf("Expected batches of [2 2 2], got %v", sizes)
+	}
+}
+
+func TestEmbedRenderedDocumentPixelLimit(t *testing.T) {
+	api := newMockServer(t)
+	api.embedContent = pageVector
+	renderer := fakeRenderer{sizes: []image.Point{{100, 100}, {100, 100}, {100, 100}, {100, 100}}}
+
+	pages, _, err := voyageai.EmbedRenderedDocument(context.Background(), api.client(), renderer, strings.NewReader(""), "voyage-multimodal-3", voyageai.RenderedDocumentOpts{
+		MaxRequestPixels: 30000,
+		Render:           voyageai.RenderOpts{MaxPages: 4},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var sizes []int
+	for _, req := range api.multimodalRequests() {
+		sizes = append(sizes, len(req.Inputs))
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 1}) {
+		t.Errorf("Expected batches of [3 1], got %v", sizes)
+	}
+	for i, p := range pages {
+		if p.Page != i || p.Embedding[0] != float32(10*i) {
+			t.Errorf("Expected page %d to carry its own embedding, got %+v", i, p)
+		}
+	}
+
+	_, _, err = voyageai.EmbedRenderedDocument(context.Background(), api.client(), renderer, strings.NewReader(""), "voyage-multimodal-3", voyageai.RenderedDocumentOpts{
+		MaxRequestPixels: 5000,
+	})
+	if err == nil || !strings.Contains(err.Error(), "page 0 has 10000 pixels") {
+		t.Errorf("Expected an error for a page over the request limit, got %v", err)
+	}
+}
+
+func TestEmbedRenderedDocumentRenderError(t *testing.T) {
+	api := newMockServer(t)
+	renderer := fakeRenderer{sizes: []image.Point{{10, 10}, {10, 10}, {10, 10}}, failAfter: 2}
+
+	_, _, err := voyageai.EmbedRenderedDocument(context.Background(), api.client(), renderer, strings.NewReader(""), "voyage-multimodal-3", voyageai.RenderedDocumentOpts{})
+	if err == nil || !strings.Contains(err.Error(), "render page 2: broken page") {
+		t.Errorf("Expected the render error, got %v", err)
+	}
+	if n := len(api.multimodalRequests()); n != 0 {
+		t.Errorf("Expected no requests before the document rendered, got %d", n)
+	}
+}
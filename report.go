@@ -0,0 +1,98 @@
+package voyageai
+
+import "fmt"
+
+// The estimated size of a single input. See [SizeReport].
+type InputSize struct {
+	Index     int  // The position of the input in the request.
+	Tokens    int  // The number of tokens in the input, as counted by the client's [Tokenizer].
+	Limit     int  // The maximum number of tokens allowed for the input.
+	OverLimit bool // Whether Tokens exceeds Limit, meaning the input would be truncated or rejected.
+}
+
+// A pre-flight assessment of a request's inputs against a model's limits, produced without
+// calling the API.
+type SizeReport struct {
+	Model       string
+	Query       *InputSize  // The rerank query. Nil for embedding requests.
+	Inputs      []InputSize // One entry per input text or document, in request order.
+	TotalTokens int         // The total tokens over all inputs, including the query.
+	OverLimit   int         // The number of entries, including the query, that exceed their limit.
+}
+
+// Oversized returns the entries of Inputs that exceed their limit.
+func (r *SizeReport) Oversized() []InputSize {
+	var over []InputSize
+	for _, in := range r.Inputs {
+		if in.OverLimit {
+			over = append(over, in)
+		}
+	}
+	return over
+}
+
+func (r *SizeReport) add(in InputSize) {
+	r.TotalTokens += in.Tokens
+	if in.OverLimit {
+		r.OverLimit++
+	}
+}
+
+// ReportOversized counts the tokens of every text with the client's [Tokenizer] and reports which
+// would exceed the context length of model. No request is made.
+func (c *VoyageClient) ReportOversized(texts []string, model string) (*SizeReport, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := c.lookupModel(model)
+	if !ok {
+		return nil, fmt.Errorf("voyage: unknown context length for model %q", model)
+	}
+	tok, _ := c.tokenizer()
+
+	report := &SizeReport{Model: model, Inputs: make([]InputSize, len(texts))}
+	for i, text := range texts {
+		n, err := tok.CountTokens(model, text)
+		if err != nil {
+			return nil, fmt.Errorf("voyage: count tokens of input %d: %w", i, err)
+		}
+		report.Inputs[i] = InputSize{Index: i, Tokens: n, Limit: info.ContextLength, OverLimit: n > info.ContextLength}
+		report.add(report.Inputs[i])
+	}
+	return report, nil
+}
+
+// ReportRerankOversized is like [VoyageClient.ReportOversized] for a rerank request. The query is
+// checked against the model's query limit, and each document against the context length left
+// after the query.
+func (c *VoyageClient) ReportRerankOversized(query string, documents []string, model string) (*SizeReport, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := c.lookupModel(model)
+	if !ok || info.MaxQueryTokens == 0 {
+		return nil, fmt.Errorf("voyage: unknown rerank limits for model %q", model)
+	}
+	tok, _ := c.tokenizer()
+
+	qn, err := tok.CountTokens(model, query)
+	if err != nil {
+		return nil, fmt.Errorf("voyage: count tokens of query: %w", err)
+	}
+	report := &SizeReport{Model: model, Inputs: make([]InputSize, len(documents))}
+	report.Query = &InputSize{Index: -1, Tokens: qn, Limit: info.MaxQueryTokens, OverLimit: qn > info.MaxQueryTokens}
+	report.add(*report.Query)
+
+	docLimit := max(info.ContextLength-qn, 0)
+	for i, doc := range documents {
+		n, err := tok.CountTokens(model, doc)
+		if err != nil {
+			return nil, fmt.Errorf("voyage: count tokens of document %d: %w", i, err)
+		}
+		report.Inputs[i] = InputSize{Index: i, Tokens: n, Limit: docLimit, OverLimit: n > docLimit}
+		report.add(report.Inputs[i])
+	}
+	return report, nil
+}
@@ -0,0 +1,61 @@
+package voyageai_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func words(n int) string {
+	return strings.TrimSpace(strings.Repeat("w ", n))
+}
+
+func TestReportOversized(t *testing.T) {
+	// The base URL is unreachable, so any request would fail the test.
+	cl := newTokenizerClient("http://127.0.0.1:0")
+	texts := []string{words(10), words(16000), words(16001), "", words(20000)}
+
+	report, err := cl.ReportOversized(texts, voyageai.ModelVoyageLaw2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	wantOver := []bool{false, false, true, false, true}
+	for i, in := range report.Inputs {
+		if in.Index != i || in.Limit != 16000 || in.OverLimit != wantOver[i] {
+			t.Errorf("Unexpected entry %+v", in)
+		}
+	}
+	if report.TotalTokens != 10+16000+16001+20000 {
+		t.Errorf("Unexpected total %d", report.TotalTokens)
+	}
+	if report.OverLimit != 2 || len(report.Oversized()) != 2 || report.Query != nil {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestReportRerankOversized(t *testing.T) {
+	cl := newTokenizerClient("http://127.0.0.1:0")
+
+	report, err := cl.ReportRerankOversized(words(2500), []string{words(5000), words(5600), words(6000)}, voyageai.ModelRerank2Lite)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if report.Query == nil || !report.Query.OverLimit || report.Query.Limit != 2000 {
+		t.Errorf("Expected the query to exceed its 2000 token limit, got %+v", report.Query)
+	}
+	// 8000 tokens minus the 2500 token query leaves 5500 per document.
+	for i, want := range []bool{false, true, true} {
+		if in := report.Inputs[i]; in.Limit != 5500 || in.OverLimit != want {
+			t.Errorf("Unexpected entry %+v", in)
+		}
+	}
+	if report.OverLimit != 3 || report.TotalTokens != 2500+5000+5600+6000 {
+		t.Errorf("Unexpected totals %+v", report)
+	}
+
+	if _, err := cl.ReportRerankOversized("q", nil, voyageai.ModelVoyage3); err == nil {
+		t.Error("Expected an error for a model without rerank limits")
+	}
+}
@@ -0,0 +1,128 @@
+package voyageai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// An input embedded by [VoyageClient.ReprocessFailures], identified as in the failure report.
+type ReprocessedInput struct {
+	Index     int
+	ID        string
+	Embedding []float32
+}
+
+// The result of [VoyageClient.ReprocessFailures].
+type ReprocessResult struct {
+	Embedded []ReprocessedInput // The inputs embedded, in the order of the report.
+	// The inputs that failed again, as written to the failure report of the call's context.
+	Failures []FailureRecord
+	Missing  int // The number of reported inputs that lookup could not find.
+	Usage    UsageObject
+}
+
+// ReprocessFailures embeds again the inputs of a failure report written by a batch call with
+// [WithFailureReport]. Each input is identified by the ID of its record or, if the record has
+// none, by its index formatted in decimal, and lookup returns its current text; inputs lookup
+// does not find are skipped and counted. An input reported more than once is embedded once.
+//
+// The inputs are embedded with [VoyageClient.EmbedBatch], as batchOpts configure, continuing
+// past failed requests; [BatchOpts.ResultWriter] is ignored. Inputs that fail again are
+// returned in the result and written to the failure report of ctx, if any, with their original
+// index and ID, so that report can be reprocessed in turn. The error is nil unless the report
+// cannot be read or the run stops early.
+func (c *VoyageClient) ReprocessFailures(ctx context.Context, report io.Reader, lookup func(id string) (string, bool), model string, opts *EmbeddingRequestOpts, batchOpts *BatchOpts) (*ReprocessResult, error) {
+	failed, err := readFailureReport(report)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReprocessResult{}
+	var (
+		inputs []FailureRecord
+		texts  []string
+	)
+	for _, rec := range failed {
+		text, ok := lookup(rec.key())
+		if !ok {
+			result.Missing++
+			continue
+		}
+		inputs = append(inputs, rec)
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return result, nil
+	}
+
+	// Report the inputs that fail again under their original identity.
+	parent := failureReporter(ctx)
+	ctx = context.WithValue(ctx, failureReportKey{}, &failureReport{emit: func(rec FailureRecord) error {
+		rec.Index, rec.ID = inputs[rec.Index].Index, inputs[rec.Index].ID
+		result.Failures = append(result.Failures, rec)
+		if parent == nil {
+			return nil
+		}
+		parent.mu.Lock()
+		defer parent.mu.Unlock()
+		return parent.emit(rec)
+	}})
+
+	bo := BatchOpts{}
+	if batchOpts != nil {
+		bo = *batchOpts
+	}
+	bo.ResultWriter = nil
+	bo.ContinueOnError = true
+	resp, err := c.EmbedBatch(ctx, texts, model, opts, &bo)
+	if resp == nil {
+		return nil, err
+	}
+	for _, obj := range resp.Data {
+		in := inputs[obj.Index]
+		result.Embedded = append(result.Embedded, ReprocessedInput{Index: in.Index, ID: in.ID, Embedding: obj.Embedding})
+	}
+	result.Usage = resp.Usage
+	return result, nil
+}
+
+// key returns the identity of the input of rec passed to the lookup of
+// [VoyageClient.ReprocessFailures].
+func (rec FailureRecord) key() string {
+	if rec.ID != "" {
+		return rec.ID
+	}
+	return strconv.Itoa(rec.Index)
+}
+
+// readFailureReport reads the records of a failure report, keeping the last record of each input
+// in the position of its first.
+func readFailureReport(r io.Reader) ([]FailureRecord, error) {
+	var records []FailureRecord
+	seen := map[string]int{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var rec FailureRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("voyage: read failure report line %d: %w", line, err)
+		}
+		if i, ok := seen[rec.key()]; ok {
+			records[i] = rec
+			continue
+		}
+		seen[rec.key()] = len(records)
+		records = append(records, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("voyage: read failure report: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,121 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestReprocessFailures(t *testing.T) {
+	srv := newMockServer(t)
+	var healthy atomic.Bool
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if !healthy.Load() && (slices.Contains(req.Input, "text 1") || slices.Contains(req.Input, "text 7")) {
+			return 400
+		}
+		return 0
+	}
+	client := srv.client()
+	texts := slices.Collect(textsOf(10))
+
+	var report bytes.Buffer
+	ctx := voyageai.WithFailureReport(context.Background(), &report)
+	if _, err := client.EmbedBatch(ctx, texts, "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 2, ContinueOnError: true}); err == nil {
+		t.Fatal("Expected the batch to fail")
+	}
+
+	healthy.Store(true)
+	sent := srv.requestCount()
+	var again bytes.Buffer
+	ctx = voyageai.WithFailureReport(context.Background(), &again)
+	result, err := client.ReprocessFailures(ctx, &report, func(id string) (string, bool) {
+		i, err := strconv.Atoi(id)
+		if err != nil || i >= len(texts) {
+			return "", false
+		}
+		return texts[i], true
+	}, "voyage-3.5", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to reprocess: %+v", err)
+	}
+
+	var resent []string
+	for _, req := range srv.requests[sent:] {
+		resent = append(resent, req.Input...)
+	}
+	if want := []string{"text 0", "text 1", "text 6", "text 7"}; !slices.Equal(resent, want) {
+		t.Errorf("Expected only the failed inputs to be sent again, got %v", resent)
+	}
+	var indices []int
+	for _, in := range result.Embedded {
+		indices = append(indices, in.Index)
+		if !slices.Equal(in.Embedding, fakeVector(texts[in.Index])) {
+			t.Errorf("Input %d: unexpected embedding %v", in.Index, in.Embedding)
+		}
+	}
+	if !slices.Equal(indices, []int{0, 1, 6, 7}) {
+		t.Errorf("Expected the failed inputs to be embedded, got %v", indices)
+	}
+	if len(result.Failures) != 0 || result.Missing != 0 || again.Len() != 0 {
+		t.Errorf("Expected no failures, got %+v and report %q", result, again.String())
+	}
+	if result.Usage.TotalTokens == 0 {
+		t.Error("Expected the usage of the requests")
+	}
+}
+
+func TestReprocessFailuresFailingAgain(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if slices.Contains(req.Input, "text 8") {
+			return 400
+		}
+		return 0
+	}
+	// A migration report, with a repeated record and a document that no longer exists.
+	var report strings.Builder
+	for _, id := range []int{3, 8, 3, 9} {
+		fmt.Fprintf(&report, `{"index":%d,"id":"doc-%d","input_hash":"","endpoint":"embeddings","model":"voyage-3.5","attempts":1,"error":"boom","time":"2025-01-01T00:00:00Z"}`+"\n", id, id)
+	}
+	docs := map[string]string{"doc-3": "text 3", "doc-8": "text 8"}
+
+	var again bytes.Buffer
+	ctx := voyageai.WithFailureReport(context.Background(), &again)
+	result, err := srv.client().ReprocessFailures(ctx, strings.NewReader(report.String()), func(id string) (string, bool) {
+		text, ok := docs[id]
+		return text, ok
+	}, "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to reprocess: %+v", err)
+	}
+	if srv.requestCount() != 2 {
+		t.Errorf("Expected each found document to be sent once, got %d requests", srv.requestCount())
+	}
+	if result.Missing != 1 || len(result.Embedded) != 1 || result.Embedded[0].ID != "doc-3" {
+		t.Errorf("Expected doc-3 embedded and doc-9 missing, got %+v", result)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].ID != "doc-8" || result.Failures[0].Index != 8 || result.Failures[0].StatusCode != 400 {
+		t.Fatalf("Expected doc-8 to fail again under its original identity, got %+v", result.Failures)
+	}
+
+	// The fresh report can be reprocessed in turn.
+	srv.fail = nil
+	result, err = srv.client().ReprocessFailures(context.Background(), &again, func(id string) (string, bool) {
+		text, ok := docs[id]
+		return text, ok
+	}, "voyage-3.5", nil, nil)
+	if err != nil || len(result.Embedded) != 1 || result.Embedded[0].ID != "doc-8" {
+		t.Errorf("Expected doc-8 to be embedded from the fresh report, got %+v, %v", result, err)
+	}
+
+	if _, err := srv.client().ReprocessFailures(context.Background(), strings.NewReader("{\n"), nil, "voyage-3.5", nil, nil); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package voyageai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// The optional fields of each request, by JSON name, that may be listed in SendNull.
+var (
+	embeddingOptionalFields        = []string{"input_type", "truncation", "output_dimension", "output_dtype", "encoding_format"}
+	multimodalOptionalFields       = []string{"input_type", "truncation", "output_encoding"}
+	rerankOptionalFields           = []string{"top_k", "return_documents", "truncation"}
+	contextualOptionalFields       = []string{"input_type", "output_dimension", "output_dtype", "encoding_format"}
+	multimodalRerankOptionalFields = []string{"top_k", "truncation"}
+)
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r EmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingRequest
+	return marshalWithNulls(plain(r), r.SendNull, embeddingOptionalFields)
+}
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r MultimodalRequest) MarshalJSON() ([]byte, error) {
+	type plain MultimodalRequest
+	return marshalWithNulls(plain(r), r.SendNull, multimodalOptionalFields)
+}
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r RerankRequest) MarshalJSON() ([]byte, error) {
+	type plain RerankRequest
+	return marshalWithNulls(plain(r), r.SendNull, rerankOptionalFields)
+}
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r rerankSharedRequest) MarshalJSON() ([]byte, error) {
+	type plain rerankSharedRequest
+	return marshalWithNulls(plain(r), r.SendNull, rerankOptionalFields)
+}
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r ContextualizedEmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type plain ContextualizedEmbeddingRequest
+	return marshalWithNulls(plain(r), r.SendNull, contextualOptionalFields)
+}
+
+// MarshalJSON encodes r, adding the fields listed in r.SendNull as null if they are unset.
+func (r MultimodalRerankRequest) MarshalJSON() ([]byte, error) {
+	type plain MultimodalRerankRequest
+	return marshalWithNulls(plain(r), r.SendNull, multimodalRerankOptionalFields)
+}
+
+// marshalWithNulls encodes the struct v, then appends each field in nulls that v left out with a
+// null value. Fields must be among optional.
+func marshalWithNulls(v any, nulls, optional []string) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(nulls) == 0 {
+		return b, err
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(b, &present); err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSuffix(b, []byte("}"))
+	for _, name := range nulls {
+		if !slices.Contains(optional, name) {
+			return nil, fmt.Errorf("voyage: %q is not an optional request field", name)
+		}
+		if _, ok := present[name]; ok {
+			continue
+		}
+		present[name] = nil
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = fmt.Appendf(b, "%q:null", name)
+	}
+	return append(b, '}'), nil
+}
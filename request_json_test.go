@@ -0,0 +1,101 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRequestSendNull(t *testing.T) {
+	const (
+		embedBase  = `{"input":["a"],"model":"m"`
+		mmBase     = `{"inputs":[],"model":"m"`
+		rerankBase = `{"query":"q","documents":["d"],"model":"m"`
+	)
+	cases := []struct {
+		name string
+		req  any
+		want string
+	}{
+		{"embed unset", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m"}, embedBase + `}`},
+		{"embed input_type null", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", SendNull: []string{"input_type"}}, embedBase + `,"input_type":null}`},
+		{"embed input_type value", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", InputType: voyageai.Opt("query"), SendNull: []string{"input_type"}}, embedBase + `,"input_type":"query"}`},
+		{"embed truncation null", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", SendNull: []string{"truncation"}}, embedBase + `,"truncation":null}`},
+		{"embed truncation value", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", Truncation: voyageai.Opt(false)}, embedBase + `,"truncation":false}`},
+		{"embed output_dimension null", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", SendNull: []string{"output_dimension"}}, embedBase + `,"output_dimension":null}`},
+		{"embed output_dimension value", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", OutputDimension: voyageai.Opt(256)}, embedBase + `,"output_dimension":256}`},
+		{"embed output_dtype null", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", SendNull: []string{"output_dtype"}}, embedBase + `,"output_dtype":null}`},
+		{"embed output_dtype value", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", OutputDType: voyageai.Opt("int8")}, embedBase + `,"output_dtype":"int8"}`},
+		{"embed encoding_format null", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", SendNull: []string{"encoding_format"}}, embedBase + `,"encoding_format":null}`},
+		{"embed encoding_format value", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", EncodingFormat: voyageai.Opt("base64")}, embedBase + `,"encoding_format":"base64"}`},
+		{"embed mixed", voyageai.EmbeddingRequest{Input: []string{"a"}, Model: "m", Truncation: voyageai.Opt(true), SendNull: []string{"output_dimension", "input_type", "truncation"}}, embedBase + `,"truncation":true,"output_dimension":null,"input_type":null}`},
+
+		{"multimodal unset", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m"}, mmBase + `}`},
+		{"multimodal input_type null", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", SendNull: []string{"input_type"}}, mmBase + `,"input_type":null}`},
+		{"multimodal input_type value", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", InputType: voyageai.Opt("document")}, mmBase + `,"input_type":"document"}`},
+		{"multimodal truncation null", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", SendNull: []string{"truncation"}}, mmBase + `,"truncation":null}`},
+		{"multimodal truncation value", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", Truncation: voyageai.Opt(true)}, mmBase + `,"truncation":true}`},
+		{"multimodal output_encoding null", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", SendNull: []string{"output_encoding"}}, mmBase + `,"output_encoding":null}`},
+		{"multimodal output_encoding value", voyageai.MultimodalRequest{Inputs: []voyageai.MultimodalContent{}, Model: "m", OuputEncoding: voyageai.Opt("base64")}, mmBase + `,"output_encoding":"base64"}`},
+
+		{"rerank unset", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m"}, rerankBase + `}`},
+		{"rerank top_k null", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", SendNull: []string{"top_k"}}, rerankBase + `,"top_k":null}`},
+		{"rerank top_k value", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", TopK: voyageai.Opt(3)}, rerankBase + `,"top_k":3}`},
+		{"rerank return_documents null", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", SendNull: []string{"return_documents"}}, rerankBase + `,"return_documents":null}`},
+		{"rerank return_documents value", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", ReturnDocuments: voyageai.Opt(true)}, rerankBase + `,"return_documents":true}`},
+		{"rerank truncation null", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", SendNull: []string{"truncation"}}, rerankBase + `,"truncation":null}`},
+		{"rerank truncation value", voyageai.RerankRequest{Query: "q", Documents: []string{"d"}, Model: "m", Truncation: voyageai.Opt(false)}, rerankBase + `,"truncation":false}`},
+	}
+	for _, c := range cases {
+		b, err := json.Marshal(c.req)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if string(b) != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, b)
+		}
+	}
+
+	if _, err := json.Marshal(voyageai.RerankRequest{Model: "m", SendNull: []string{"model"}}); err == nil {
+		t.Error("Expected an error for a field that is not optional")
+	}
+}
+
+func TestRequestSendNullFromOpts(t *testing.T) {
+	api := newMockServer(t)
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		api.handle(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "test", BaseURL: srv.URL})
+
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", &voyageai.EmbeddingRequestOpts{SendNull: []string{"input_type"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", &voyageai.EmbeddingRequestOpts{}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.Rerank("q", []string{"d"}, "rerank-2", &voyageai.RerankRequestOpts{SendNull: []string{"top_k"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	want := []string{
+		`{"input":["a"],"model":"voyage-3.5","input_type":null}`,
+		`{"input":["a"],"model":"voyage-3.5"}`,
+		`{"query":"q","documents":["d"],"model":"rerank-2","top_k":null}`,
+	}
+	for i, w := range want {
+		if i >= len(bodies) || bodies[i] != w {
+			t.Errorf("Request %d: expected %s, got %v", i, w, bodies)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package voyageai
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a copy of ctx that attaches md to the calls made with it, for
+// correlating what the client's hooks see, such as a trace ID or a feature name. Every hook
+// invoked for such a call receives the metadata: the Metadata fields of [RequestMetrics],
+// [CacheMetrics], [DegradedMetrics], [AuditEvent] and [RetryEvent], and a "metadata" group of
+// attributes on messages logged to [VoyageClientOpts.Logger]. It applies to every request of the
+// call, including retries and the requests batch helpers split it into, and is never sent to the
+// API.
+//
+// Keys already attached to ctx are kept unless md replaces them.
+func WithRequestMetadata(ctx context.Context, md map[string]string) context.Context {
+	merged := maps.Clone(requestMetadata(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(md))
+	}
+	maps.Copy(merged, md)
+	return context.WithValue(ctx, requestMetadataKey{}, merged)
+}
+
+// RequestMetadata returns a copy of the metadata attached to ctx with [WithRequestMetadata], or
+// nil if there is none.
+func RequestMetadata(ctx context.Context) map[string]string {
+	return maps.Clone(requestMetadata(ctx))
+}
+
+// requestMetadata returns the metadata attached to ctx. It must not be modified.
+func requestMetadata(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(requestMetadataKey{}).(map[string]string)
+	return md
+}
+
+// loggerFor returns the client's logger with the metadata of ctx attached.
+func (c *VoyageClient) loggerFor(ctx context.Context) *slog.Logger {
+	md := requestMetadata(ctx)
+	if len(md) == 0 {
+		return c.logger()
+	}
+	attrs := make([]any, 0, len(md))
+	for _, k := range slices.Sorted(maps.Keys(md)) {
+		attrs = append(attrs, slog.String(k, md[k]))
+	}
+	return c.logger().With(slog.Group("metadata", attrs...))
+}
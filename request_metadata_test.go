@@ -0,0 +1,140 @@
+package voyageai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRequestMetadataReachesHooks(t *testing.T) {
+	api := newMockServer(t)
+	api.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n == 1 {
+			return 500
+		}
+		return 0
+	}
+	metrics := &recordingMetrics{}
+	sink := &recordingSink{err: errors.New("disk full")}
+	var logs bytes.Buffer
+	var mu sync.Mutex
+	var retries []voyageai.RetryEvent
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:            "APIKEY",
+		BaseURL:        api.URL,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		Metrics:        metrics,
+		Audit:          &voyageai.AuditOpts{Sink: sink},
+		Logger:         slog.New(slog.NewJSONHandler(&logs, nil)),
+		Cache:          &voyageai.CacheOpts{Store: voyageai.NewMemoryCache(0), TTL: time.Hour},
+		OnRetry: func(e voyageai.RetryEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries = append(retries, e)
+		},
+	})
+
+	md := map[string]string{"trace_id": "t-1", "feature": "search", "tier": "gold"}
+	want := map[string]string{"trace_id": "t-1", "feature": "search", "tier": "gold"}
+	ctx := voyageai.WithRequestMetadata(context.Background(), md)
+	md["tier"] = "changed" // The context keeps its own copy.
+
+	if _, err := client.EmbedBatch(ctx, []string{"a", "b", "c", "d"}, "voyage-3.5", nil, &voyageai.BatchOpts{BatchSize: 2}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := client.EmbedContext(ctx, []string{"a", "e"}, "voyage-3.5", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(retries) != 1 || retries[0].Attempt != 1 || retries[0].Endpoint != "embeddings" || retries[0].Err == nil {
+		t.Errorf("Unexpected retries %+v", retries)
+	}
+	for _, e := range retries {
+		if !reflect.DeepEqual(e.Metadata, want) {
+			t.Errorf("Expected retry metadata %v, got %v", want, e.Metadata)
+		}
+	}
+	if len(metrics.requests) != 3 {
+		t.Errorf("Expected 3 requests observed, got %d", len(metrics.requests))
+	}
+	for _, m := range metrics.requests {
+		if !reflect.DeepEqual(m.Metadata, want) {
+			t.Errorf("Expected request metadata %v, got %v", want, m.Metadata)
+		}
+	}
+	if len(metrics.cache) == 0 {
+		t.Error("Expected cache lookups to be observed")
+	}
+	for _, m := range metrics.cache {
+		if !reflect.DeepEqual(m.Metadata, want) {
+			t.Errorf("Expected cache metadata %v, got %v", want, m.Metadata)
+		}
+	}
+	if len(sink.events) != 3 {
+		t.Errorf("Expected 3 audit events, got %d", len(sink.events))
+	}
+	for _, ev := range sink.events {
+		if !reflect.DeepEqual(ev.Metadata, want) {
+			t.Errorf("Expected audit metadata %v, got %v", want, ev.Metadata)
+		}
+	}
+
+	// Every audit failure is logged with the metadata as attributes.
+	lines := 0
+	sc := bufio.NewScanner(&logs)
+	for sc.Scan() {
+		var entry struct {
+			Msg      string            `json:"msg"`
+			Metadata map[string]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			t.Fatal(err.Error())
+		}
+		if !reflect.DeepEqual(entry.Metadata, want) {
+			t.Errorf("Expected logged metadata %v, got %+v", want, entry)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("Expected 3 log entries, got %d", lines)
+	}
+
+	// Nothing is sent to the API.
+	for _, h := range api.headers {
+		for _, values := range h {
+			for _, v := range values {
+				if strings.Contains(v, "t-1") || strings.Contains(v, "search") {
+					t.Errorf("Expected no metadata in the request headers, got %v", h)
+				}
+			}
+		}
+	}
+}
+
+func TestWithRequestMetadataMerges(t *testing.T) {
+	ctx := voyageai.WithRequestMetadata(context.Background(), map[string]string{"trace_id": "t-1", "tier": "gold"})
+	ctx = voyageai.WithRequestMetadata(ctx, map[string]string{"tier": "silver"})
+
+	got := voyageai.RequestMetadata(ctx)
+	if want := map[string]string{"trace_id": "t-1", "tier": "silver"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	got["trace_id"] = "changed"
+	if voyageai.RequestMetadata(ctx)["trace_id"] != "t-1" {
+		t.Error("Expected RequestMetadata to return a copy")
+	}
+	if voyageai.RequestMetadata(context.Background()) != nil {
+		t.Error("Expected no metadata without WithRequestMetadata")
+	}
+}
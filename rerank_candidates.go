@@ -0,0 +1,86 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// A document found by a first retrieval stage, such as a [VectorIndex] search, to be reranked by
+// [VoyageClient.RerankCandidates].
+type RerankCandidate struct {
+	Document string
+	Score    float64 // The document's similarity to the query in the first stage.
+}
+
+// Options for [VoyageClient.RerankCandidates].
+type CandidateRerankOpts struct {
+	RerankOpts *RerankRequestOpts // Optional parameters for the rerank request.
+	// Order the candidates by their first-stage scores instead of failing when reranking fails
+	// with a rate limit, server or network error after its retries, or the retry budget refuses
+	// a retry. The response is then marked [ResponseMetadata.Degraded], and the client's
+	// [MetricsHook] is told if it implements [DegradationObserver]. Other errors, such as
+	// validation errors, are returned as is.
+	FallbackToScores bool
+}
+
+// RerankCandidates reranks the candidates of a first retrieval stage against query with
+// [VoyageClient.RerankContext]. Indices in the response refer to candidates.
+//
+// With [CandidateRerankOpts.FallbackToScores], a rerank call that fails because the API is
+// unavailable yields the candidates in descending order of their first-stage scores instead,
+// each with its score as relevance score, trimmed to [RerankRequestOpts.TopK] and with documents
+// included as [RerankRequestOpts.ReturnDocuments] says. The response has no usage.
+func (c *VoyageClient) RerankCandidates(ctx context.Context, query string, candidates []RerankCandidate, model string, opts *CandidateRerankOpts) (*RerankResponse, error) {
+	if opts == nil {
+		opts = &CandidateRerankOpts{}
+	}
+	documents := make([]string, len(candidates))
+	for i, cand := range candidates {
+		documents[i] = cand.Document
+	}
+	resp, err := c.RerankContext(ctx, query, documents, model, opts.RerankOpts)
+	if err == nil || !opts.FallbackToScores || !shouldDegrade(ctx, err) {
+		return resp, err
+	}
+
+	if h, ok := c.opts.Metrics.(DegradationObserver); ok {
+		h.ObserveDegraded(DegradedMetrics{Endpoint: "rerank", Model: model, Err: err, Metadata: RequestMetadata(ctx)})
+	}
+	return scoreOrder(candidates, model, opts.RerankOpts), nil
+}
+
+// shouldDegrade reports whether a call that failed with err may be answered with a degraded
+// result: the API was unavailable, rather than the request invalid or ctx done.
+func shouldDegrade(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return isOverloaded(err) || isNetError(err) || errors.Is(err, ErrResponseStalled) || errors.Is(err, ErrRetryBudgetExhausted)
+}
+
+// scoreOrder returns a rerank response ordering candidates by their first-stage scores.
+func scoreOrder(candidates []RerankCandidate, model string, opts *RerankRequestOpts) *RerankResponse {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return candidates[order[i]].Score > candidates[order[j]].Score })
+	if opts != nil && opts.TopK != nil && *opts.TopK >= 0 && *opts.TopK < len(order) {
+		order = order[:*opts.TopK]
+	}
+	resp := &RerankResponse{
+		Object:   "list",
+		Data:     make([]RerankObject, len(order)),
+		Model:    model,
+		Metadata: ResponseMetadata{RequestedModel: model, Degraded: true},
+	}
+	withDocs := opts != nil && opts.ReturnDocuments != nil && *opts.ReturnDocuments
+	for k, i := range order {
+		resp.Data[k] = RerankObject{Index: i, RelevanceScore: float32(candidates[i].Score)}
+		if withDocs {
+			resp.Data[k].Document = &candidates[i].Document
+		}
+	}
+	return resp
+}
@@ -0,0 +1,89 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// degradationMetrics is a recordingMetrics that also records degraded results.
+type degradationMetrics struct {
+	recordingMetrics
+	degraded []voyageai.DegradedMetrics
+}
+
+func (d *degradationMetrics) ObserveDegraded(m voyageai.DegradedMetrics) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.degraded = append(d.degraded, m)
+}
+
+var rerankCandidates = []voyageai.RerankCandidate{
+	{Document: "low", Score: 0.2},
+	{Document: "high", Score: 0.9},
+	{Document: "mid", Score: 0.5},
+}
+
+func TestRerankCandidatesFallsBackToScores(t *testing.T) {
+	srv := newMockServer(t)
+	srv.failRerank = func(n int, req voyageai.RerankRequest) int { return 429 }
+	metrics := &degradationMetrics{}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond, Metrics: metrics})
+
+	topK, withDocs := 2, true
+	resp, err := client.RerankCandidates(context.Background(), "q", rerankCandidates, "rerank-2", &voyageai.CandidateRerankOpts{
+		RerankOpts:       &voyageai.RerankRequestOpts{TopK: &topK, ReturnDocuments: &withDocs},
+		FallbackToScores: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected a degraded ranking, got %v", err)
+	}
+	if !resp.Metadata.Degraded {
+		t.Error("Expected the response to be marked degraded")
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Index != 1 || resp.Data[1].Index != 2 {
+		t.Fatalf("Expected the top 2 candidates by score, got %+v", resp.Data)
+	}
+	if resp.Data[0].RelevanceScore != 0.9 || resp.Data[0].Document == nil || *resp.Data[0].Document != "high" {
+		t.Errorf("Expected the candidate's score and document, got %+v", resp.Data[0])
+	}
+	if len(srv.reranks) != 2 {
+		t.Errorf("Expected the rerank request to be retried first, got %d requests", len(srv.reranks))
+	}
+
+	if len(metrics.degraded) != 1 {
+		t.Fatalf("Expected one degradation event, got %+v", metrics.degraded)
+	}
+	event := metrics.degraded[0]
+	var apiErr *voyageai.APIError
+	if event.Endpoint != "rerank" || event.Model != "rerank-2" || !errors.As(event.Err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestRerankCandidatesPropagatesErrors(t *testing.T) {
+	srv := newMockServer(t)
+	metrics := &degradationMetrics{}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL, Metrics: metrics})
+	opts := &voyageai.CandidateRerankOpts{FallbackToScores: true}
+
+	resp, err := client.RerankCandidates(context.Background(), "q", rerankCandidates, "rerank-2", opts)
+	if err != nil || resp.Metadata.Degraded {
+		t.Fatalf("Expected the model's ranking, got %+v, %v", resp, err)
+	}
+
+	srv.failRerank = func(n int, req voyageai.RerankRequest) int { return 400 }
+	if _, err := client.RerankCandidates(context.Background(), "q", rerankCandidates, "rerank-2", opts); err == nil {
+		t.Error("Expected a validation error to be returned")
+	}
+	srv.failRerank = func(n int, req voyageai.RerankRequest) int { return 503 }
+	if _, err := client.RerankCandidates(context.Background(), "q", rerankCandidates, "rerank-2", nil); err == nil {
+		t.Error("Expected the error without the fallback option")
+	}
+	if len(metrics.degraded) != 0 {
+		t.Errorf("Expected no degradation events, got %+v", metrics.degraded)
+	}
+}
@@ -0,0 +1,128 @@
+package voyageai
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// The default number of chunks sent per request by [VoyageClient.RerankLongDocuments].
+const DefaultLongRerankWindow = 100
+
+// How [VoyageClient.RerankLongDocuments] combines the scores of a document's chunks.
+type Aggregation int
+
+const (
+	AggregateMax      Aggregation = iota // The score of the best chunk.
+	AggregateMean                        // The mean score of all chunks.
+	AggregateTopNMean                    // The mean score of the best [LongRerankOpts.TopN] chunks.
+)
+
+// Options for [VoyageClient.RerankLongDocuments].
+type LongRerankOpts struct {
+	Chunk       ChunkOpts // How documents are split. See [ChunkText].
+	Aggregation Aggregation
+	TopN        int // The number of chunks averaged by [AggregateTopNMean]. Defaults to 3.
+	// The maximum number of chunks per rerank request. Defaults to [DefaultLongRerankWindow].
+	Window     int
+	Truncation *bool // Passed to every request, see [RerankRequestOpts].
+}
+
+// A document ranked by [VoyageClient.RerankLongDocuments].
+type LongRerankResult struct {
+	Index     int     // The document's position in the input.
+	Score     float64 // The aggregate of the document's chunk scores.
+	BestChunk Chunk   // The document's highest scoring chunk, with its offsets in the document. Empty if the document has no text.
+	// The relevance score of BestChunk.
+	BestChunkScore float32
+}
+
+// RerankLongDocuments reranks documents too long for the model by splitting each into chunks,
+// reranking all chunks against query, and aggregating the chunk scores of each document as
+// opts.Aggregation says. Chunks are sent in requests of at most opts.Window chunks, all to model
+// without fallbacks so their scores are comparable.
+//
+// Every document is returned, ranked by descending score. Documents without text score zero.
+func (c *VoyageClient) RerankLongDocuments(ctx context.Context, query string, documents []string, model string, opts LongRerankOpts) ([]LongRerankResult, UsageObject, error) {
+	if len(documents) == 0 {
+		return nil, UsageObject{}, errors.New("voyage: rerank needs at least one document")
+	}
+	window := opts.Window
+	if window <= 0 {
+		window = DefaultLongRerankWindow
+	}
+	topN := opts.TopN
+	if topN <= 0 {
+		topN = 3
+	}
+
+	type docChunk struct {
+		doc   int
+		chunk Chunk
+	}
+	var chunks []docChunk
+	for i, doc := range documents {
+		for _, ch := range ChunkText(doc, opts.Chunk) {
+			chunks = append(chunks, docChunk{doc: i, chunk: ch})
+		}
+	}
+
+	scores := make([][]float32, len(documents))
+	results := make([]LongRerankResult, len(documents))
+	for i := range results {
+		results[i].Index = i
+	}
+	var usage UsageObject
+	for start := 0; start < len(chunks); start += window {
+		batch := chunks[start:min(start+window, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, ch := range batch {
+			texts[i] = ch.chunk.Text
+		}
+		resp, err := c.rerankContext(ctx, query, texts, model, &RerankRequestOpts{Truncation: opts.Truncation})
+		if err != nil {
+			return nil, usage, fmt.Errorf("voyage: rerank chunks %d-%d: %w", start, start+len(batch)-1, err)
+		}
+		usage = addUsage(usage, resp.Usage)
+		for _, obj := range resp.Data {
+			if obj.Index < 0 || obj.Index >= len(batch) {
+				return nil, usage, fmt.Errorf("voyage: rerank chunks %d-%d: response index %d out of range", start, start+len(batch)-1, obj.Index)
+			}
+			ch := batch[obj.Index]
+			scores[ch.doc] = append(scores[ch.doc], obj.RelevanceScore)
+			if r := &results[ch.doc]; len(scores[ch.doc]) == 1 || obj.RelevanceScore > r.BestChunkScore {
+				r.BestChunk, r.BestChunkScore = ch.chunk, obj.RelevanceScore
+			}
+		}
+	}
+
+	for i := range results {
+		results[i].Score = aggregateScores(scores[i], opts.Aggregation, topN)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results, usage, nil
+}
+
+// aggregateScores combines chunk scores. No scores aggregate to zero.
+func aggregateScores(scores []float32, agg Aggregation, topN int) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	switch agg {
+	case AggregateMean:
+	case AggregateTopNMean:
+		scores = slices.Clone(scores)
+		slices.SortFunc(scores, func(a, b float32) int { return cmp.Compare(b, a) })
+		scores = scores[:min(topN, len(scores))]
+	default:
+		return float64(slices.Max(scores))
+	}
+	var sum float64
+	for _, s := range scores {
+		sum += float64(s)
+	}
+	return sum / float64(len(scores))
+}
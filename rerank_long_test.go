@@ -0,0 +1,76 @@
+package voyageai_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRerankLongDocuments(t *testing.T) {
+	srv := newMockServer(t)
+	client := srv.client()
+	// With 3-token chunks, each pair of words is a chunk. The mock scores a chunk by the
+	// fraction of the query's words it contains.
+	docs := []string{
+		"aaaa bbbb cccc dddd",          // Chunks score 0, 0.
+		"red fish xxxx yyyy blue fish", // Chunks score 0.5, 0, 0.5.
+		"zzzz zzzz zzzz zzzz red blue", // Chunks score 0, 0, 1.
+		"red xxxx blue xxxx red xxxx",  // Chunks score 0.5, 0.5, 0.5.
+		"   ",                          // No chunks.
+	}
+	opts := voyageai.LongRerankOpts{Chunk: voyageai.ChunkOpts{MaxTokens: 3}, Window: 4}
+
+	cases := []struct {
+		agg    voyageai.Aggregation
+		scores map[int]float64
+	}{
+		{voyageai.AggregateMax, map[int]float64{0: 0, 1: 0.5, 2: 1, 3: 0.5, 4: 0}},
+		{voyageai.AggregateMean, map[int]float64{0: 0, 1: 1.0 / 3, 2: 1.0 / 3, 3: 0.5, 4: 0}},
+		{voyageai.AggregateTopNMean, map[int]float64{0: 0, 1: 0.5, 2: 0.5, 3: 0.5, 4: 0}},
+	}
+	for _, c := range cases {
+		o := opts
+		o.Aggregation, o.TopN = c.agg, 2
+		before := srv.rerankCount()
+		results, usage, err := client.RerankLongDocuments(context.Background(), "red blue", docs, "rerank-2", o)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		// 11 chunks in windows of 4.
+		if n := srv.rerankCount() - before; n != 3 {
+			t.Errorf("Aggregation %d: expected 3 requests, got %d", c.agg, n)
+		}
+		if usage.TotalTokens == 0 {
+			t.Errorf("Aggregation %d: expected usage to be reported", c.agg)
+		}
+		if len(results) != len(docs) {
+			t.Fatalf("Aggregation %d: expected every document, got %d", c.agg, len(results))
+		}
+		for i, r := range results {
+			if math.Abs(r.Score-c.scores[r.Index]) > 1e-6 {
+				t.Errorf("Aggregation %d: expected document %d to score %v, got %v", c.agg, r.Index, c.scores[r.Index], r.Score)
+			}
+			if i > 0 && r.Score > results[i-1].Score {
+				t.Errorf("Aggregation %d: expected descending scores, got %+v", c.agg, results)
+			}
+		}
+	}
+
+	// The best chunk is attributed to its own document across windows.
+	results, _, _ := client.RerankLongDocuments(context.Background(), "red blue", docs, "rerank-2", opts)
+	best := results[0]
+	if best.Index != 2 || best.BestChunk.Text != "red blue" || best.BestChunkScore != 1 {
+		t.Fatalf("Expected document 2's last chunk to win, got %+v", best)
+	}
+	if got := docs[2][best.BestChunk.Start:best.BestChunk.End]; got != best.BestChunk.Text {
+		t.Errorf("Expected the chunk offsets to point into the document, got %q", got)
+	}
+	for _, r := range results {
+		if r.BestChunk.Text != "" && !strings.Contains(docs[r.Index], r.BestChunk.Text) {
+			t.Errorf("Expected document %d's best chunk to come from it, got %q", r.Index, r.BestChunk.Text)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// The outcome of reranking the documents against one query of [VoyageClient.RerankMany].
+type RerankManyResult struct {
+	Query    string
+	Response *RerankResponse // The reranking results. Nil if Err is set.
+	Err      error           // The error returned for this query, if any.
+}
+
+// rerankSharedRequest is a [RerankRequest] whose documents have already been encoded.
+type rerankSharedRequest struct {
+	Query           string          `json:"query"`
+	Documents       json.RawMessage `json:"documents"`
+	Model           string          `json:"model"`
+	TopK            *int            `json:"top_k,omitempty"`
+	ReturnDocuments *bool           `json:"return_documents,omitempty"`
+	Truncation      *bool           `json:"truncation,omitempty"`
+	SendNull        []string        `json:"-"`
+}
+
+// RerankMany reranks the same documents against each of the queries, issuing one request per
+// query with at most concurrency requests in flight (1 if concurrency is not positive). The
+// documents are validated and encoded once and shared by every request.
+//
+// The results are indexed by query position. A failing query is reported in its result without
+// affecting the others; the returned error is only set if the call could not be made at all. The
+// returned usage is aggregated over the successful queries.
+func (c *VoyageClient) RerankMany(ctx context.Context, queries []string, documents []string, model string, opts *RerankRequestOpts, concurrency int) ([]RerankManyResult, UsageObject, error) {
+	if len(documents) == 0 {
+		return nil, UsageObject{}, errors.New("voyage: rerank needs at least one document")
+	}
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, UsageObject{}, err
+	}
+	docs, err := json.Marshal(documents)
+	if err != nil {
+		return nil, UsageObject{}, fmt.Errorf("marshal documents: %w", err)
+	}
+	if opts == nil {
+		opts = &RerankRequestOpts{}
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]RerankManyResult, len(queries))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		results[i].Query = query
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			reqBody := rerankSharedRequest{
+				Query:           query,
+				Documents:       docs,
+				Model:           model,
+				TopK:            opts.TopK,
+				ReturnDocuments: opts.ReturnDocuments,
+				Truncation:      opts.Truncation,
+				SendNull:        opts.SendNull,
+			}
+			release, err := c.reserveRerank(query, documents, model)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer release()
+
+			var respBody RerankResponse
+			if err := c.handleAPIRequest(ctx, &reqBody, &respBody, "/rerank"); err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Response = &respBody
+		}()
+	}
+	wg.Wait()
+
+	var usage UsageObject
+	for _, r := range results {
+		if r.Response != nil {
+			usage = addUsage(usage, r.Response.Usage)
+		}
+	}
+	return results, usage, nil
+}
@@ -0,0 +1,111 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRerankMany(t *testing.T) {
+	srv := newMockServer(t)
+	var inFlight, peak atomic.Int32
+	srv.failRerank = func(n int, req voyageai.RerankRequest) int {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if req.Query == "broken" {
+			return 500
+		}
+		return 0
+	}
+
+	queries := []string{"red apple", "green pear", "broken", "yellow banana", "red pear", "apple pie"}
+	docs := []string{"a red apple", "a green pear", "a yellow banana"}
+	results, usage, err := srv.client().RerankMany(context.Background(), queries, docs, "test-model", nil, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if n := srv.rerankCount(); n != len(queries) {
+		t.Errorf("Expected %d requests, got %d", len(queries), n)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", p)
+	}
+
+	wantTop := map[string]int{"red apple": 0, "green pear": 1, "yellow banana": 2, "apple pie": 0}
+	total := 0
+	for i, r := range results {
+		if r.Query != queries[i] {
+			t.Errorf("Result %d is for query %q", i, r.Query)
+		}
+		if r.Query == "broken" {
+			if r.Err == nil || r.Response != nil {
+				t.Errorf("Expected the failing query to report its error, got %+v", r)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Fatalf("Query %q failed: %v", r.Query, r.Err)
+		}
+		if top, ok := wantTop[r.Query]; ok && r.Response.Data[0].Index != top {
+			t.Errorf("Query %q: expected document %d first, got %d", r.Query, top, r.Response.Data[0].Index)
+		}
+		total += r.Response.Usage.TotalTokens
+	}
+	if usage.TotalTokens != total {
+		t.Errorf("Expected aggregated usage %d, got %d", total, usage.TotalTokens)
+	}
+}
+
+func TestRerankManyRequiresDocuments(t *testing.T) {
+	srv := newMockServer(t)
+	if _, _, err := srv.client().RerankMany(context.Background(), []string{"q"}, nil, "test-model", nil, 1); err == nil {
+		t.Error("Expected an error without documents")
+	}
+	if srv.rerankCount() != 0 {
+		t.Error("Expected no requests")
+	}
+}
+
+func TestRerankManySendNull(t *testing.T) {
+	bodies := make(chan map[string]json.RawMessage, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+		json.NewEncoder(w).Encode(voyageai.RerankResponse{Object: "list", Data: []voyageai.RerankObject{{Index: 0}}})
+	}))
+	t.Cleanup(srv.Close)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+
+	opts := &voyageai.RerankRequestOpts{TopK: voyageai.Opt(1), SendNull: []string{"top_k", "truncation"}}
+	results, _, err := client.RerankMany(context.Background(), []string{"a", "b"}, []string{"d"}, "rerank-2", opts, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatal(r.Err.Error())
+		}
+		body := <-bodies
+		if string(body["truncation"]) != "null" || string(body["top_k"]) != "1" {
+			t.Errorf("Expected truncation sent as null alongside top_k, got %v", body)
+		}
+		if _, ok := body["return_documents"]; ok {
+			t.Errorf("Expected unlisted fields to be left out, got %v", body)
+		}
+	}
+}
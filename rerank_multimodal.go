@@ -0,0 +1,91 @@
+package voyageai
+
+import (
+	"context"
+	"strings"
+)
+
+// A data structure for reranking multimodal documents with the /rerank endpoint. Use
+// [MultimodalRerankRequestOpts] when building a request for use with [VoyageClient].
+type MultimodalRerankRequest struct {
+	Query      MultimodalContent   `json:"query"`
+	Documents  []MultimodalContent `json:"documents"`
+	Model      string              `json:"model"`
+	TopK       *int                `json:"top_k,omitempty"`
+	Truncation *bool               `json:"truncation,omitempty"`
+	SendNull   []string            `json:"-"` // The JSON names of optional fields sent as null when unset, rather than left out.
+}
+
+// Additional request options that can be passed to [VoyageClient.RerankMultimodal].
+type MultimodalRerankRequestOpts struct {
+	TopK       *int  `json:"top_k,omitempty"`      // The number of most relevant documents to return. If not specified, the reranking results of all documents will be returned.
+	Truncation *bool `json:"truncation,omitempty"` // Whether to truncate the input to satisfy the "context length limit" on the query and the documents. Defaults to true.
+	// The JSON names of optional fields sent as an explicit null when unset, such as "top_k",
+	// rather than left out. Unset fields are left out by default.
+	SendNull []string `json:"-"`
+
+	// Skip the image URL checks configured with [VoyageClientOpts.ValidateImageURLs].
+	SkipImageURLValidation bool `json:"-"`
+}
+
+// Returns a pointer to a [RerankResponse] or an error if the request failed. It reranks documents
+// of text and images, built as for [VoyageClient.MultimodalEmbed], against a query that may also
+// contain images. It needs a multimodal reranker; text rerankers reject the request. Documents
+// are not returned in the response, whose results refer to them by index.
+//
+// Parameters:
+//   - query - The query, such as a [MultimodalContent] of a single text [Multimodal] input.
+//   - documents - The documents to be reranked.
+//   - model - Name of a multimodal rerank model.
+//   - opts - Optional parameters, see [MultimodalRerankRequestOpts]
+func (c *VoyageClient) RerankMultimodal(query MultimodalContent, documents []MultimodalContent, model string, opts *MultimodalRerankRequestOpts) (*RerankResponse, error) {
+	return c.RerankMultimodalContext(context.Background(), query, documents, model, opts)
+}
+
+// RerankMultimodalContext is like [VoyageClient.RerankMultimodal] but the request is bound to ctx, which can be used to cancel it.
+func (c *VoyageClient) RerankMultimodalContext(ctx context.Context, query MultimodalContent, documents []MultimodalContent, model string, opts *MultimodalRerankRequestOpts) (*RerankResponse, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return nil, err
+	}
+	all := append([]MultimodalContent{query}, documents...)
+	if c.opts.ValidateImageURLs != nil && (opts == nil || !opts.SkipImageURLValidation) {
+		if err := c.ValidateImageURLs(ctx, all); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkImagePayload(all); err != nil {
+		return nil, err
+	}
+
+	docs := make([]string, len(documents))
+	for i, doc := range documents {
+		docs[i] = doc.text()
+	}
+	release, err := c.reserveRerank(query.text(), docs, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	reqBody := MultimodalRerankRequest{Query: query, Documents: documents, Model: model}
+	if opts != nil {
+		reqBody.TopK = opts.TopK
+		reqBody.Truncation = opts.Truncation
+		reqBody.SendNull = opts.SendNull
+	}
+	var respBody RerankResponse
+	err = c.handleAPIRequest(ctx, &reqBody, &respBody, "/rerank")
+	return &respBody, err
+}
+
+// text returns the text parts of c joined by newlines, for counting its tokens.
+func (c MultimodalContent) text() string {
+	var parts []string
+	for _, part := range c.Content {
+		if part.Text != "" {
+			parts = append(parts, string(part.Text))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
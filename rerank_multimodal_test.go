@@ -0,0 +1,57 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRerankMultimodal(t *testing.T) {
+	var got voyageai.MultimodalRerankRequest
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/rerank" || json.Unmarshal(b, &got) != nil || json.Unmarshal(b, &raw) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2}],"model":"` + got.Model + `","usage":{"total_tokens":12}}`))
+	}))
+	t.Cleanup(srv.Close)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: srv.URL})
+
+	img, err := createDummyImage(4, 4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	query := voyageai.MultimodalContent{Content: []voyageai.MultimodalInput{voyageai.Multimodal(voyageai.Text("a red square"))}}
+	docs := []voyageai.MultimodalContent{
+		{Content: []voyageai.MultimodalInput{voyageai.Multimodal(voyageai.Text("a blue circle"))}},
+		{Content: []voyageai.MultimodalInput{
+			voyageai.Multimodal(voyageai.Text("a square")),
+			voyageai.Multimodal(voyageai.MustGetBase64Raw(bytes.NewReader(img.Bytes()))),
+		}},
+	}
+	resp, err := client.RerankMultimodal(query, docs, "multimodal-rerank", &voyageai.MultimodalRerankRequestOpts{TopK: voyageai.Opt(2), SendNull: []string{"truncation"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Index != 1 || resp.Usage.TotalTokens != 12 {
+		t.Errorf("Expected the ranked results, got %+v", resp)
+	}
+	if got.Model != "multimodal-rerank" || *got.TopK != 2 || len(got.Documents) != 2 || got.Query.Content[0].Text != "a red square" {
+		t.Errorf("Expected the query, documents and options to be sent, got %+v", got)
+	}
+	if img := got.Documents[1].Content[1]; img.Type != "image_base64" || !strings.HasPrefix(string(img.ImageBase64), "data:image/png;base64,") {
+		t.Errorf("Expected the image part to be sent, got %+v", img)
+	}
+	if v, ok := raw["truncation"]; !ok || v != nil {
+		t.Errorf("Expected truncation to be sent as null, got %v", raw)
+	}
+}
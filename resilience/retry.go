@@ -0,0 +1,140 @@
+// Package resilience provides the retry, backoff and rate limiting behind
+// [github.com/zamedic/voyageai.VoyageClient] as building blocks for other HTTP calls: a
+// [RetryPolicy] that retries any operation, and a [Transport] that applies it, with a [Limiter], to
+// every request of an [http.Client].
+//
+// The client sends every request through a Transport. Its base round tripper reads the whole
+// response, so responses that stall while their body is read are retried too, and its
+// [Classifier] retries the API errors [ShouldRetryStatus] allows, as the Transport does by
+// default, returning them as the client's own errors.
+package resilience
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A source of time, such as the client's [github.com/zamedic/voyageai.Clock].
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// How often and when an operation is retried.
+type RetryPolicy struct {
+	MaxAttempts int // The maximum number of attempts, including the first. Defaults to 1.
+	// Returns the wait before the given retry, counted from 1, after an attempt that failed with
+	// err. Retries are sent immediately if nil.
+	Backoff func(retry int, err error) time.Duration
+	// Called before every retry with the error of the previous attempt. A non-nil result stops
+	// retrying and is returned instead, such as when a retry budget is spent.
+	AllowRetry func(ctx context.Context, err error) error
+	Clock      Clock // Times the backoff. Defaults to the system clock.
+}
+
+// ConstantBackoff returns a [RetryPolicy.Backoff] that always waits d.
+func ConstantBackoff(d time.Duration) func(int, error) time.Duration {
+	return func(int, error) time.Duration { return d }
+}
+
+// Exponentially growing waits between retries. Use its Delay method as [RetryPolicy.Backoff].
+type Exponential struct {
+	Base time.Duration // The wait before the first retry.
+	Max  time.Duration // The longest wait. Unlimited if zero.
+	// The factor the wait grows by with every retry. Defaults to 2 if not greater than 1.
+	Multiplier float64
+}
+
+// Delay returns the wait before the given retry, counted from 1: Base multiplied by Multiplier
+// for every retry after the first, capped at Max, and jittered to a random duration between half
+// of that and all of it, so clients that failed together do not retry together.
+func (e Exponential) Delay(retry int, _ error) time.Duration {
+	m := e.Multiplier
+	if m <= 1 {
+		m = 2
+	}
+	d := float64(e.Base)
+	for i := 1; i < retry && (e.Max <= 0 || d < float64(e.Max)) && d <= math.MaxInt64/m; i++ {
+		d *= m
+	}
+	if e.Max > 0 {
+		d = min(d, float64(e.Max))
+	}
+	if d <= 0 {
+		return 0
+	}
+	full := time.Duration(d)
+	return full/2 + rand.N(full/2+1)
+}
+
+// ParseRetryAfter returns the wait a response asked for with its Retry-After header, given as
+// either a number of seconds or an HTTP date, which is counted from now. ok is false if the
+// header is missing or invalid. A date in the past is a wait of zero.
+func ParseRetryAfter(h http.Header, now time.Time) (wait time.Duration, ok bool) {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(t.Sub(now), 0), true
+}
+
+// Attempts returns the maximum number of attempts.
+func (p RetryPolicy) Attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+// Do calls attempt, with the attempt number counted from 0, until it returns an error it does not
+// mark retryable, or the attempts are used up. It returns the number of attempts made and the
+// error of the last one. If ctx is done while waiting to retry, or the policy refuses a retry,
+// it returns that error instead.
+func (p RetryPolicy) Do(ctx context.Context, attempt func(n int) (retry bool, err error)) (int, error) {
+	clock := p.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	var lastErr error
+	for i := 0; i < p.Attempts(); i++ {
+		if i > 0 {
+			if p.AllowRetry != nil {
+				if err := p.AllowRetry(ctx, lastErr); err != nil {
+					return i, err
+				}
+			}
+			if p.Backoff != nil {
+				if d := p.Backoff(i, lastErr); d > 0 {
+					select {
+					case <-clock.After(d):
+					case <-ctx.Done():
+						return i, ctx.Err()
+					}
+				}
+			}
+		}
+		retry, err := attempt(i)
+		if err == nil || !retry {
+			return i + 1, err
+		}
+		lastErr = err
+	}
+	return p.Attempts(), lastErr
+}
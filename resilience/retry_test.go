@@ -0,0 +1,69 @@
+package resilience_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai/resilience"
+)
+
+func TestExponentialDelay(t *testing.T) {
+	e := resilience.Exponential{Base: 100 * time.Millisecond, Max: time.Second}
+	for retry, full := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		for range 20 {
+			if d := e.Delay(retry, nil); d < full/2 || d > full {
+				t.Errorf("Retry %d: expected a delay between %v and %v, got %v", retry, full/2, full, d)
+			}
+		}
+	}
+	if d := (resilience.Exponential{}).Delay(3, nil); d != 0 {
+		t.Errorf("Expected no delay without a base, got %v", d)
+	}
+	if d := (resilience.Exponential{Base: time.Second}).Delay(200, nil); d <= 0 {
+		t.Errorf("Expected an unlimited delay not to overflow, got %v", d)
+	}
+
+	e = resilience.Exponential{Base: 100 * time.Millisecond, Max: time.Second, Multiplier: 3}
+	for retry, full := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 300 * time.Millisecond, 3: 900 * time.Millisecond, 4: time.Second} {
+		for range 20 {
+			if d := e.Delay(retry, nil); d < full/2 || d > full {
+				t.Errorf("Multiplier 3, retry %d: expected a delay between %v and %v, got %v", retry, full/2, full, d)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		got, ok := resilience.ParseRetryAfter(h, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: expected %v, %v, got %v, %v", tt.value, tt.want, tt.ok, got, ok)
+		}
+	}
+}
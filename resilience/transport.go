@@ -0,0 +1,187 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Admits requests against a quota. [github.com/zamedic/voyageai.Limiter] implementations, such as
+// [github.com/zamedic/voyageai.TokenBucketLimiter], satisfy it.
+type Limiter interface {
+	// Acquire blocks until a request with the given estimated number of tokens may be sent.
+	Acquire(ctx context.Context, tokens int) error
+	// Feedback is called with the HTTP status of every admitted request, or zero if no response
+	// was received.
+	Feedback(ctx context.Context, statusCode int)
+}
+
+// Decides whether a request that got resp, or failed with err, should be retried. It returns nil
+// to stop, or the error of the attempt to pass to the [RetryPolicy] before retrying.
+type Classifier func(resp *http.Response, err error) error
+
+// Receives an observation of every attempt made by a [Transport]. It must be safe for
+// concurrent use.
+type MetricsHook interface {
+	ObserveAttempt(AttemptMetrics)
+}
+
+// One attempt of a request.
+type AttemptMetrics struct {
+	Method     string
+	URL        string
+	Attempt    int           // The attempt number, counted from 0.
+	StatusCode int           // Zero if no response was received.
+	Err        error         // The error of the round trip, if any.
+	Duration   time.Duration // The time the round trip took.
+	Retry      bool          // Whether the classifier asked for a retry.
+}
+
+// Options for [NewTransport].
+type Options struct {
+	RetryPolicy RetryPolicy
+	Limiter     Limiter     // Consulted before every attempt. No limit by default.
+	Classifier  Classifier  // Defaults to [DefaultClassifier].
+	MetricsHook MetricsHook // Optional.
+	// Returns the estimated tokens of a request, passed to Limiter. Zero by default.
+	Tokens func(*http.Request) int
+}
+
+// An [http.RoundTripper] that retries requests as its [RetryPolicy] says and admits every attempt
+// through its [Limiter].
+type Transport struct {
+	base http.RoundTripper
+	opts Options
+}
+
+// NewTransport returns a Transport sending requests with base, or [http.DefaultTransport] if
+// base is nil.
+func NewTransport(base http.RoundTripper, opts Options) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.Classifier == nil {
+		opts.Classifier = DefaultClassifier
+	}
+	return &Transport{base: base, opts: opts}
+}
+
+// ShouldRetryStatus reports whether a response with the given status is worth retrying: every
+// error status except 400, 401 and 422, which reject the request itself. This is the rule
+// [github.com/zamedic/voyageai.VoyageClient] retries by.
+func ShouldRetryStatus(code int) bool {
+	switch {
+	case code < 400:
+		return false
+	case code == 400, code == 401, code == 422:
+		return false
+	}
+	return true
+}
+
+// DefaultClassifier retries responses as [ShouldRetryStatus] says, with a [*StatusError], and
+// round trips that failed for any reason other than their context being done.
+func DefaultClassifier(resp *http.Response, err error) error {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return err
+	}
+	if ShouldRetryStatus(resp.StatusCode) {
+		return &StatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	return nil
+}
+
+// StatusError is the error [DefaultClassifier] retries a response with. It is passed to
+// [RetryPolicy.Backoff] and [RetryPolicy.AllowRetry].
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("resilience: retryable status %d", e.StatusCode)
+}
+
+// RoundTrip sends req, retrying as the transport's options say. A request with a body is only
+// retried if it has GetBody to rewind it. When every attempt is retried, the last response is
+// returned; a response is only an error if no attempt got one.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var resp *http.Response
+	_, err := t.opts.RetryPolicy.Do(ctx, func(n int) (bool, error) {
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			resp = nil
+		}
+		attemptReq := req
+		if n > 0 {
+			attemptReq = req.Clone(ctx)
+			if hasBody(req) {
+				body, err := req.GetBody()
+				if err != nil {
+					return false, fmt.Errorf("resilience: rewind request body: %w", err)
+				}
+				attemptReq.Body = body
+			}
+		}
+		if t.opts.Limiter != nil {
+			tokens := 0
+			if t.opts.Tokens != nil {
+				tokens = t.opts.Tokens(req)
+			}
+			if err := t.opts.Limiter.Acquire(ctx, tokens); err != nil {
+				return false, err
+			}
+		}
+
+		start := time.Now()
+		r, err := t.base.RoundTrip(attemptReq)
+		status := 0
+		if r != nil {
+			status = r.StatusCode
+		}
+		if t.opts.Limiter != nil {
+			t.opts.Limiter.Feedback(ctx, status)
+		}
+		retryErr := t.opts.Classifier(r, err)
+		if hasBody(req) && req.GetBody == nil {
+			retryErr = nil
+		}
+		retry := retryErr != nil
+		if t.opts.MetricsHook != nil {
+			t.opts.MetricsHook.ObserveAttempt(AttemptMetrics{
+				Method:     req.Method,
+				URL:        req.URL.String(),
+				Attempt:    n,
+				StatusCode: status,
+				Err:        err,
+				Duration:   time.Since(start),
+				Retry:      retry,
+			})
+		}
+		resp = r
+		if retry {
+			return true, retryErr
+		}
+		return false, err
+	})
+	if resp != nil && (err == nil || ctx.Err() == nil) {
+		// The last attempt got a response, even if it was retryable: hand it over.
+		return resp, nil
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil, err
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
@@ -0,0 +1,220 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai/resilience"
+)
+
+// scriptedServer answers requests with the given statuses in turn, then with 200, and records
+// the body of every request.
+type scriptedServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+func newScriptedServer(t *testing.T, statuses ...int) *scriptedServer {
+	s := &scriptedServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, string(b))
+		status := 200
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+type recordingHook struct {
+	mu       sync.Mutex
+	attempts []resilience.AttemptMetrics
+}
+
+func (h *recordingHook) ObserveAttempt(m resilience.AttemptMetrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts = append(h.attempts, m)
+}
+
+type countingLimiter struct {
+	acquired int
+	feedback []int
+}
+
+func (l *countingLimiter) Acquire(ctx context.Context, tokens int) error {
+	l.acquired++
+	return nil
+}
+
+func (l *countingLimiter) Feedback(ctx context.Context, statusCode int) {
+	l.feedback = append(l.feedback, statusCode)
+}
+
+func TestTransportRetries(t *testing.T) {
+	srv := newScriptedServer(t, 503, 429)
+	hook := &recordingHook{}
+	limiter := &countingLimiter{}
+	var backoffs []string
+	client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Options{
+		RetryPolicy: resilience.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff: func(retry int, err error) time.Duration {
+				var se *resilience.StatusError
+				if errors.As(err, &se) {
+					backoffs = append(backoffs, se.Header.Get("Retry-After"))
+				}
+				return time.Millisecond
+			},
+		},
+		Limiter:     limiter,
+		MetricsHook: hook,
+	})}
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected the third attempt to succeed, got %d", resp.StatusCode)
+	}
+	if strings.Join(srv.bodies, ",") != "payload,payload,payload" {
+		t.Errorf("Expected the body to be sent with every attempt, got %q", srv.bodies)
+	}
+	if len(backoffs) != 2 || backoffs[0] != "1" {
+		t.Errorf("Expected the backoff to see the retried responses, got %q", backoffs)
+	}
+	if limiter.acquired != 3 || len(limiter.feedback) != 3 || limiter.feedback[0] != 503 || limiter.feedback[2] != 200 {
+		t.Errorf("Expected every attempt to pass the limiter, got %+v", limiter)
+	}
+	if len(hook.attempts) != 3 || !hook.attempts[0].Retry || hook.attempts[2].Retry || hook.attempts[1].StatusCode != 429 {
+		t.Errorf("Unexpected attempts %+v", hook.attempts)
+	}
+}
+
+func TestTransportReturnsFinalResponse(t *testing.T) {
+	for _, tc := range []struct {
+		statuses []int
+		want     int
+		requests int
+	}{
+		{[]int{400}, 400, 1},
+		{[]int{422}, 422, 1},
+		{[]int{500, 500, 500}, 500, 3},
+		{[]int{404, 401}, 401, 2},
+	} {
+		srv := newScriptedServer(t, tc.statuses...)
+		client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Options{RetryPolicy: resilience.RetryPolicy{MaxAttempts: 3}})}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want || string(body) != http.StatusText(tc.want) || len(srv.bodies) != tc.requests {
+			t.Errorf("%v: expected %d after %d requests, got %d %q after %d", tc.statuses, tc.want, tc.requests, resp.StatusCode, body, len(srv.bodies))
+		}
+	}
+}
+
+func TestTransportAllowRetry(t *testing.T) {
+	srv := newScriptedServer(t, 500, 500)
+	budget := errors.New("budget spent")
+	client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Options{RetryPolicy: resilience.RetryPolicy{
+		MaxAttempts: 3,
+		AllowRetry:  func(context.Context, error) error { return budget },
+	}})}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 500 || len(srv.bodies) != 1 {
+		t.Errorf("Expected the first response without retries, got %d after %d requests", resp.StatusCode, len(srv.bodies))
+	}
+}
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportResponseAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := resilience.NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	}), resilience.Options{RetryPolicy: resilience.RetryPolicy{MaxAttempts: 3}})
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil || resp == nil || resp.StatusCode != 200 {
+		t.Errorf("Expected the final response despite the cancellation, got %v, %v", resp, err)
+	}
+}
+
+func TestTransportClassifierError(t *testing.T) {
+	srv := newScriptedServer(t, 418)
+	teapot := errors.New("teapot")
+	var seen []error
+	client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Options{
+		RetryPolicy: resilience.RetryPolicy{
+			MaxAttempts: 2,
+			Backoff: func(retry int, err error) time.Duration {
+				seen = append(seen, err)
+				return 0
+			},
+		},
+		Classifier: func(resp *http.Response, err error) error {
+			if resp != nil && resp.StatusCode == 418 {
+				return teapot
+			}
+			return nil
+		},
+	})}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(seen) != 1 || seen[0] != teapot {
+		t.Errorf("Expected the classifier's error before the retry, got %d and %v", resp.StatusCode, seen)
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	boom := errors.New("boom")
+	var calls []int
+	attempts, err := resilience.RetryPolicy{MaxAttempts: 4}.Do(context.Background(), func(n int) (bool, error) {
+		calls = append(calls, n)
+		return n < 1, boom
+	})
+	if attempts != 2 || !errors.Is(err, boom) || len(calls) != 2 {
+		t.Errorf("Expected a non-retryable error to stop after 2 attempts, got %d, %v", attempts, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts, err = resilience.RetryPolicy{MaxAttempts: 3, Backoff: resilience.ConstantBackoff(time.Hour)}.Do(ctx, func(n int) (bool, error) {
+		cancel()
+		return true, boom
+	})
+	if attempts != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation during the backoff, got %d, %v", attempts, err)
+	}
+}
@@ -0,0 +1,91 @@
+package voyageai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The number of leading values shown when an embedding is formatted as a string.
+const previewValues = 3
+
+// Len returns the number of embeddings in r.
+func (r *EmbeddingResponse) Len() int {
+	return len(r.Data)
+}
+
+// Dimension returns the dimension shared by every embedding in r, or 0 if r holds no
+// embeddings. It returns an error naming the first embedding whose dimension differs from that of
+// the first.
+func (r *EmbeddingResponse) Dimension() (int, error) {
+	if len(r.Data) == 0 {
+		return 0, nil
+	}
+	dim := len(r.Data[0].Embedding)
+	for _, obj := range r.Data[1:] {
+		if len(obj.Embedding) != dim {
+			return 0, fmt.Errorf("voyage: embedding %d has dimension %d, expected %d", obj.Index, len(obj.Embedding), dim)
+		}
+	}
+	return dim, nil
+}
+
+// String summarizes r with its model, size, dimension, the first values of the first embedding
+// and its usage, rather than every value.
+func (r EmbeddingResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "EmbeddingResponse{model=%s embeddings=%d", r.Model, len(r.Data))
+	if dim, err := r.Dimension(); err != nil {
+		b.WriteString(" dim=mixed")
+	} else if dim > 0 {
+		fmt.Fprintf(&b, " dim=%d first=%s", dim, previewVector(r.Data[0].Embedding))
+	}
+	fmt.Fprintf(&b, " usage=%s}", formatUsage(r.Usage))
+	return b.String()
+}
+
+// String summarizes o with its index, dimension and first values, rather than every value.
+func (o EmbeddingObject) String() string {
+	return fmt.Sprintf("EmbeddingObject{index=%d dim=%d %s}", o.Index, len(o.Embedding), previewVector(o.Embedding))
+}
+
+// String summarizes r with its model, number of results, the first results and its usage, rather
+// than the documents.
+func (r RerankResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RerankResponse{model=%s results=%d", r.Model, len(r.Data))
+	if len(r.Data) > 0 {
+		parts := make([]string, 0, previewValues)
+		for _, obj := range r.Data[:min(len(r.Data), previewValues)] {
+			parts = append(parts, fmt.Sprintf("#%d:%.4g", obj.Index, obj.RelevanceScore))
+		}
+		if len(r.Data) > previewValues {
+			parts = append(parts, "…")
+		}
+		fmt.Fprintf(&b, " top=[%s]", strings.Join(parts, " "))
+	}
+	fmt.Fprintf(&b, " usage=%s}", formatUsage(r.Usage))
+	return b.String()
+}
+
+// previewVector formats the first values of v, with an ellipsis if any are left out.
+func previewVector(v []float32) string {
+	parts := make([]string, 0, previewValues+1)
+	for _, x := range v[:min(len(v), previewValues)] {
+		parts = append(parts, fmt.Sprintf("%.4g", x))
+	}
+	if len(v) > previewValues {
+		parts = append(parts, "…")
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func formatUsage(u UsageObject) string {
+	s := fmt.Sprintf("{total_tokens=%d", u.TotalTokens)
+	if u.TextTokens != nil {
+		s += fmt.Sprintf(" text_tokens=%d", *u.TextTokens)
+	}
+	if u.ImagePixels != nil {
+		s += fmt.Sprintf(" image_pixels=%d", *u.ImagePixels)
+	}
+	return s + "}"
+}
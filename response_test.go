@@ -0,0 +1,110 @@
+package voyageai_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbeddingResponseDimension(t *testing.T) {
+	resp := &voyageai.EmbeddingResponse{Data: []voyageai.EmbeddingObject{
+		{Index: 0, Embedding: []float32{1, 2, 3}},
+		{Index: 1, Embedding: []float32{4, 5, 6}},
+	}}
+	if dim, err := resp.Dimension(); err != nil || dim != 3 {
+		t.Errorf("Expected dimension 3, got %d and %v", dim, err)
+	}
+	if resp.Len() != 2 {
+		t.Errorf("Expected 2 embeddings, got %d", resp.Len())
+	}
+
+	resp.Data = append(resp.Data, voyageai.EmbeddingObject{Index: 2, Embedding: []float32{7, 8}})
+	_, err := resp.Dimension()
+	if err == nil || !strings.Contains(err.Error(), "embedding 2 has dimension 2, expected 3") {
+		t.Errorf("Expected an error naming embedding 2, got %v", err)
+	}
+
+	empty := &voyageai.EmbeddingResponse{}
+	if dim, err := empty.Dimension(); err != nil || dim != 0 {
+		t.Errorf("Expected dimension 0 for an empty response, got %d and %v", dim, err)
+	}
+	if empty.Len() != 0 {
+		t.Errorf("Expected 0 embeddings, got %d", empty.Len())
+	}
+}
+
+func TestResponseStrings(t *testing.T) {
+	textTokens := 7
+	resp := voyageai.EmbeddingResponse{
+		Model: "voyage-3",
+		Data: []voyageai.EmbeddingObject{
+			{Index: 0, Embedding: []float32{0.5, -0.25, 0.125, 1, 2}},
+			{Index: 1, Embedding: []float32{0, 0, 0, 0, 0}},
+		},
+		Usage: voyageai.UsageObject{TotalTokens: 7, TextTokens: &textTokens},
+	}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			"response",
+			fmt.Sprint(resp),
+			"EmbeddingResponse{model=voyage-3 embeddings=2 dim=5 first=[0.5 -0.25 0.125 …] usage={total_tokens=7 text_tokens=7}}",
+		},
+		{
+			"pointer",
+			fmt.Sprintf("%v", &resp),
+			"EmbeddingResponse{model=voyage-3 embeddings=2 dim=5 first=[0.5 -0.25 0.125 …] usage={total_tokens=7 text_tokens=7}}",
+		},
+		{
+			"object",
+			fmt.Sprint(resp.Data[0]),
+			"EmbeddingObject{index=0 dim=5 [0.5 -0.25 0.125 …]}",
+		},
+		{
+			"short object",
+			fmt.Sprint(voyageai.EmbeddingObject{Index: 3, Embedding: []float32{1, 2}}),
+			"EmbeddingObject{index=3 dim=2 [1 2]}",
+		},
+		{
+			"empty",
+			fmt.Sprint(voyageai.EmbeddingResponse{Model: "voyage-3"}),
+			"EmbeddingResponse{model=voyage-3 embeddings=0 usage={total_tokens=0}}",
+		},
+		{
+			"mixed",
+			fmt.Sprint(voyageai.EmbeddingResponse{Model: "voyage-3", Data: []voyageai.EmbeddingObject{
+				{Embedding: []float32{1}}, {Index: 1, Embedding: []float32{1, 2}},
+			}}),
+			"EmbeddingResponse{model=voyage-3 embeddings=2 dim=mixed usage={total_tokens=0}}",
+		},
+		{
+			"rerank",
+			fmt.Sprint(voyageai.RerankResponse{
+				Model: "rerank-2",
+				Data: []voyageai.RerankObject{
+					{Index: 2, RelevanceScore: 0.9},
+					{Index: 0, RelevanceScore: 0.5},
+					{Index: 3, RelevanceScore: 0.25},
+					{Index: 1, RelevanceScore: 0.125},
+				},
+				Usage: voyageai.UsageObject{TotalTokens: 40},
+			}),
+			"RerankResponse{model=rerank-2 results=4 top=[#2:0.9 #0:0.5 #3:0.25 …] usage={total_tokens=40}}",
+		},
+		{
+			"empty rerank",
+			fmt.Sprint(voyageai.RerankResponse{Model: "rerank-2"}),
+			"RerankResponse{model=rerank-2 results=0 usage={total_tokens=0}}",
+		},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, tt.got)
+		}
+	}
+}
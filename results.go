@@ -0,0 +1,84 @@
+package voyageai
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// The serialization format used by a result writer. See [BatchOpts.ResultWriter].
+type ResultFormat int
+
+const (
+	// One JSON-encoded [EmbeddingRecord] per line.
+	ResultFormatJSONL ResultFormat = iota
+)
+
+// A single embedding as written by a result writer.
+type EmbeddingRecord struct {
+	Index     int       `json:"index"`      // The position of the input in the original request.
+	Model     string    `json:"model"`      // Name of the model.
+	InputHash string    `json:"input_hash"` // The hex-encoded SHA-256 hash of the input text. See [HashInput].
+	Embedding []float32 `json:"embedding"`
+}
+
+// HashInput returns the hex-encoded SHA-256 hash of text, as recorded in [EmbeddingRecord.InputHash].
+func HashInput(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// resultWriter serializes records to an io.Writer. It is safe for concurrent use.
+type resultWriter struct {
+	mu  sync.Mutex
+	out *countingWriter
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newResultWriter(w io.Writer, format ResultFormat) (*resultWriter, error) {
+	if format != ResultFormatJSONL {
+		return nil, fmt.Errorf("voyage: unsupported result format %d", format)
+	}
+	out := &countingWriter{w: w}
+	buf := bufio.NewWriter(out)
+	return &resultWriter{out: out, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+// write serializes the embeddings of texts, whose first element is the input at offset, and
+// flushes them to the underlying writer. It returns the number of bytes written.
+func (rw *resultWriter) write(model string, offset int, texts []string, embs [][]float32) (int64, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	start := rw.out.n
+	for i, emb := range embs {
+		rec := EmbeddingRecord{
+			Index:     offset + i,
+			Model:     model,
+			InputHash: HashInput(texts[i]),
+			Embedding: emb,
+		}
+		if err := rw.enc.Encode(&rec); err != nil {
+			return rw.out.n - start, err
+		}
+	}
+	err := rw.buf.Flush()
+	return rw.out.n - start, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
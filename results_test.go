@@ -0,0 +1,84 @@
+package voyageai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedBatchResultWriter(t *testing.T) {
+	srv := newMockServer(t)
+	texts := make([]string, 23)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	var out bytes.Buffer
+	resp, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, &voyageai.BatchOpts{
+		BatchSize:    5,
+		ResultWriter: &out,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(resp.Data) != 0 {
+		t.Errorf("Expected no in-memory data, got %d embeddings", len(resp.Data))
+	}
+	if resp.Usage.TotalTokens == 0 {
+		t.Error("Expected aggregated usage")
+	}
+
+	seen := map[int]int{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var rec voyageai.EmbeddingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err.Error())
+		}
+		seen[rec.Index]++
+		if rec.Model != "test-model" || rec.InputHash != voyageai.HashInput(texts[rec.Index]) {
+			t.Errorf("Unexpected record %+v", rec)
+		}
+		if !slices.Equal(rec.Embedding, fakeVector(texts[rec.Index])) {
+			t.Errorf("Wrong embedding for index %d", rec.Index)
+		}
+	}
+	for i := range texts {
+		if seen[i] != 1 {
+			t.Errorf("Expected input %d to be written exactly once, got %d", i, seen[i])
+		}
+	}
+}
+
+type failingWriter struct{ after int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.after <= 0 {
+		return 0, errors.New("no space left on device")
+	}
+	w.after--
+	return len(p), nil
+}
+
+func TestEmbedBatchResultWriterError(t *testing.T) {
+	srv := newMockServer(t)
+	texts := []string{"a", "b", "c", "d", "e", "f"}
+
+	_, err := srv.client().EmbedBatch(context.Background(), texts, "test-model", nil, &voyageai.BatchOpts{
+		BatchSize:    2,
+		ResultWriter: &failingWriter{after: 1},
+	})
+	if err == nil {
+		t.Fatal("Expected the write error to abort the run")
+	}
+	if n := srv.requestCount(); n != 2 {
+		t.Errorf("Expected the run to stop after the failed write, got %d requests", n)
+	}
+}
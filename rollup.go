@@ -0,0 +1,152 @@
+package voyageai
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for [RollupOpts].
+const (
+	DefaultRollupInterval  = time.Hour
+	DefaultRollupRetention = 48 * time.Hour
+)
+
+// Configures the time-windowed usage rollups of a client. See [VoyageClient.UsageBuckets].
+type RollupOpts struct {
+	// The length of each bucket. Buckets start at multiples of Interval since the zero time, so
+	// hourly buckets start on the hour and daily buckets at midnight UTC. Defaults to
+	// [DefaultRollupInterval].
+	Interval time.Duration
+	// How long buckets are kept, rounded up to a whole number of intervals. Defaults to
+	// [DefaultRollupRetention].
+	Retention time.Duration
+}
+
+// Usage accumulated over a number of requests.
+type UsageTotal struct {
+	Requests      int
+	Usage         UsageObject
+	EstimatedCost float64 // The cost in US dollars estimated with [EstimateCost]. Models without a known price are not counted.
+}
+
+func (t *UsageTotal) add(usage UsageObject, cost float64) {
+	t.Requests++
+	t.Usage = addUsage(t.Usage, usage)
+	t.EstimatedCost += cost
+}
+
+// The usage of one model at one endpoint during one interval.
+type UsageBucket struct {
+	Start    time.Time // The start of the interval. The interval ends at Start plus [RollupOpts.Interval].
+	Endpoint string    // The API endpoint, such as "embeddings" or "rerank".
+	Model    string    // The model that served the requests.
+	UsageTotal
+}
+
+type rollupKey struct {
+	endpoint, model string
+}
+
+type rollupSlot struct {
+	start  time.Time
+	totals map[rollupKey]*UsageTotal
+}
+
+// usageRollup is a ring buffer of per-interval usage, so memory is bounded by the number of
+// intervals retained times the number of models and endpoints used.
+type usageRollup struct {
+	interval time.Duration
+
+	mu    sync.Mutex
+	slots []rollupSlot
+}
+
+func newUsageRollup(opts *RollupOpts) *usageRollup {
+	r := &usageRollup{interval: DefaultRollupInterval}
+	retention := DefaultRollupRetention
+	if opts != nil && opts.Interval > 0 {
+		r.interval = opts.Interval
+	}
+	if opts != nil && opts.Retention > 0 {
+		retention = opts.Retention
+	}
+	n := max(int((retention+r.interval-1)/r.interval), 1)
+	r.slots = make([]rollupSlot, n)
+	return r
+}
+
+// slot returns the slot for the interval starting at start.
+func (r *usageRollup) slot(start time.Time) *rollupSlot {
+	i := start.UnixNano() / int64(r.interval) % int64(len(r.slots))
+	if i < 0 {
+		i += int64(len(r.slots))
+	}
+	return &r.slots[i]
+}
+
+func (r *usageRollup) record(now time.Time, endpoint, model string, usage UsageObject, cost float64) {
+	start := now.Truncate(r.interval)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.slot(start)
+	if !s.start.Equal(start) || s.totals == nil {
+		// The slot holds an interval that has left the retention window.
+		*s = rollupSlot{start: start, totals: map[rollupKey]*UsageTotal{}}
+	}
+	k := rollupKey{endpoint, model}
+	t := s.totals[k]
+	if t == nil {
+		t = &UsageTotal{}
+		s.totals[k] = t
+	}
+	t.add(usage, cost)
+}
+
+// buckets returns the retained buckets starting at or after since, in order of start, endpoint
+// and model.
+func (r *usageRollup) buckets(now, since time.Time) []UsageBucket {
+	oldest := now.Truncate(r.interval).Add(-time.Duration(len(r.slots)-1) * r.interval)
+	since = since.Truncate(r.interval)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []UsageBucket
+	for _, s := range r.slots {
+		if s.totals == nil || s.start.Before(oldest) || s.start.Before(since) || s.start.After(now) {
+			continue
+		}
+		for k, t := range s.totals {
+			out = append(out, UsageBucket{Start: s.start, Endpoint: k.endpoint, Model: k.model, UsageTotal: *t})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Model < b.Model
+	})
+	return out
+}
+
+// UsageBuckets returns the usage of successful requests per interval, endpoint and model, for
+// the intervals retained as configured by [VoyageClientOpts.UsageRollup], oldest first. Intervals
+// without requests are omitted.
+func (c *VoyageClient) UsageBuckets() []UsageBucket {
+	return c.rollup.buckets(c.clock().Now(), time.Time{})
+}
+
+// UsageSince returns the total usage of successful requests since t. t is rounded down to the
+// start of its interval, and usage older than the retention is not counted.
+func (c *VoyageClient) UsageSince(t time.Time) UsageTotal {
+	var total UsageTotal
+	for _, b := range c.rollup.buckets(c.clock().Now(), t) {
+		total.Requests += b.Requests
+		total.Usage = addUsage(total.Usage, b.Usage)
+		total.EstimatedCost += b.EstimatedCost
+	}
+	return total
+}
@@ -0,0 +1,109 @@
+package voyageai_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestUsageBuckets(t *testing.T) {
+	api := newMockServer(t)
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:         "APIKEY",
+		BaseURL:     api.URL,
+		Clock:       clock,
+		UsageRollup: &voyageai.RollupOpts{Interval: time.Hour, Retention: 3 * time.Hour},
+	})
+	start := clock.Now()
+
+	clock.Advance(10 * time.Minute)
+	client.Embed([]string{"abcd"}, "voyage-3", nil)
+	client.Embed([]string{"ab"}, "voyage-3", nil)
+	client.Embed([]string{"abc"}, "voyage-3-lite", nil)
+	clock.Advance(time.Hour)
+	client.Rerank("q", []string{"doc"}, "rerank-2", nil)
+
+	cost4, _ := voyageai.EstimateCost("voyage-3", 4)
+	cost2, _ := voyageai.EstimateCost("voyage-3", 2)
+	want := []voyageai.UsageBucket{
+		{Start: start, Endpoint: "embeddings", Model: "voyage-3", UsageTotal: voyageai.UsageTotal{Requests: 2, Usage: voyageai.UsageObject{TotalTokens: 6}, EstimatedCost: cost4 + cost2}},
+		{Start: start, Endpoint: "embeddings", Model: "voyage-3-lite"},
+		{Start: start.Add(time.Hour), Endpoint: "rerank", Model: "rerank-2"},
+	}
+	want[1].Requests, want[1].Usage.TotalTokens = 1, 3
+	want[1].EstimatedCost, _ = voyageai.EstimateCost("voyage-3-lite", 3)
+	want[2].Requests, want[2].Usage.TotalTokens = 1, 4
+	want[2].EstimatedCost, _ = voyageai.EstimateCost("rerank-2", 4)
+	if got := client.UsageBuckets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+
+	if got := client.UsageSince(start.Add(time.Hour + 30*time.Minute)); got.Requests != 1 || got.Usage.TotalTokens != 4 {
+		t.Errorf("Expected the current hour only, got %+v", got)
+	}
+	if got := client.UsageSince(start); got.Requests != 4 || got.Usage.TotalTokens != 13 {
+		t.Errorf("Expected all usage, got %+v", got)
+	}
+
+	// Failed requests are not counted.
+	api.fail = func(int, voyageai.EmbeddingRequest) int { return 400 }
+	client.Embed([]string{"abc"}, "voyage-3", nil)
+	if got := client.UsageSince(start); got.Requests != 4 {
+		t.Errorf("Expected a failed request not to count, got %+v", got)
+	}
+}
+
+func TestUsageBucketsEviction(t *testing.T) {
+	api := newMockServer(t)
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:         "APIKEY",
+		BaseURL:     api.URL,
+		Clock:       clock,
+		UsageRollup: &voyageai.RollupOpts{Interval: time.Hour, Retention: 3 * time.Hour},
+	})
+	start := clock.Now()
+	for range 5 {
+		client.Embed([]string{"a"}, "voyage-3", nil)
+		clock.Advance(time.Hour)
+	}
+	clock.Advance(-time.Hour)
+
+	// Only the last three hours are retained.
+	var starts []time.Time
+	for _, b := range client.UsageBuckets() {
+		starts = append(starts, b.Start)
+	}
+	want := []time.Time{start.Add(2 * time.Hour), start.Add(3 * time.Hour), start.Add(4 * time.Hour)}
+	if !reflect.DeepEqual(starts, want) {
+		t.Errorf("Expected buckets starting at %v, got %v", want, starts)
+	}
+	if got := client.UsageSince(start); got.Requests != 3 {
+		t.Errorf("Expected evicted usage not to count, got %+v", got)
+	}
+
+	// A slot that is not reused still leaves the window once its interval is too old.
+	clock.Advance(2 * time.Hour)
+	if got := client.UsageBuckets(); len(got) != 1 || !got[0].Start.Equal(start.Add(4*time.Hour)) {
+		t.Errorf("Expected only the last bucket, got %+v", got)
+	}
+	clock.Advance(24 * time.Hour)
+	if got := client.UsageBuckets(); len(got) != 0 {
+		t.Errorf("Expected no buckets after a day without requests, got %+v", got)
+	}
+
+	// Daily buckets start at midnight UTC.
+	daily := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:         "APIKEY",
+		BaseURL:     api.URL,
+		Clock:       clock,
+		UsageRollup: &voyageai.RollupOpts{Interval: 24 * time.Hour},
+	})
+	daily.Embed([]string{"a"}, "voyage-3", nil)
+	if got := daily.UsageBuckets(); len(got) != 1 || got[0].Start.Hour() != 0 || got[0].Start.Minute() != 0 {
+		t.Errorf("Expected a bucket starting at midnight, got %+v", got)
+	}
+}
@@ -0,0 +1,119 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Rules for [NewModelRouter].
+type RouterRules struct {
+	// Texts of at most this many estimated tokens that match no domain pattern are routed to Lite.
+	// Zero routes every such text to Default.
+	MaxLiteTokens int
+	// Regular expressions, each with the model for texts that match it. Patterns are tried in
+	// lexical order, before the token limit.
+	DomainPatterns map[string]string
+	Lite           string // The model for short texts. Defaults to "voyage-3.5-lite".
+	Default        string // The model for every other text. Defaults to "voyage-3-large".
+}
+
+// Routes texts between embedding models by simple, deterministic rules. See [NewModelRouter].
+type ModelRouter struct {
+	rules    RouterRules
+	patterns []routePattern
+}
+
+type routePattern struct {
+	source string
+	re     *regexp.Regexp
+	model  string
+}
+
+// NewModelRouter returns a router applying rules: a text matching one of the domain patterns goes
+// to that pattern's model, a text within the lite token limit to the lite model, and any other
+// text to the default model. It returns an error if a pattern does not compile.
+func NewModelRouter(rules RouterRules) (*ModelRouter, error) {
+	if rules.Lite == "" {
+		rules.Lite = "voyage-3.5-lite"
+	}
+	if rules.Default == "" {
+		rules.Default = "voyage-3-large"
+	}
+	r := &ModelRouter{rules: rules}
+	for source, model := range rules.DomainPatterns {
+		re, err := regexp.Compile(source)
+		if err != nil {
+			return nil, fmt.Errorf("voyage: domain pattern %q: %w", source, err)
+		}
+		r.patterns = append(r.patterns, routePattern{source: source, re: re, model: model})
+	}
+	sort.Slice(r.patterns, func(i, j int) bool { return r.patterns[i].source < r.patterns[j].source })
+	return r, nil
+}
+
+// Route returns the model for text and the reason it was chosen. Token counts are
+// [EstimateTokens] estimates.
+func (r *ModelRouter) Route(text string) (model string, reason string) {
+	for _, p := range r.patterns {
+		if p.re.MatchString(text) {
+			return p.model, fmt.Sprintf("matches domain pattern %q", p.source)
+		}
+	}
+	tokens := EstimateTokens(text)
+	if tokens <= r.rules.MaxLiteTokens {
+		return r.rules.Lite, fmt.Sprintf("%d tokens, within the lite limit of %d", tokens, r.rules.MaxLiteTokens)
+	}
+	return r.rules.Default, fmt.Sprintf("%d tokens, over the lite limit of %d", tokens, r.rules.MaxLiteTokens)
+}
+
+// An embedding returned by [VoyageClient.EmbedRouted].
+type RoutedEmbedding struct {
+	Index     int    // The position of the input in texts.
+	Model     string // The model the input was routed to.
+	Reason    string // Why the model was chosen. See [ModelRouter.Route].
+	Embedding []float32
+}
+
+// The result of [VoyageClient.EmbedRouted].
+type RoutedResponse struct {
+	Data  []RoutedEmbedding      // One embedding per input, in input order.
+	Usage map[string]UsageObject // The usage of each model used.
+}
+
+// EmbedRouted routes every text with router and embeds the texts of each model with one
+// [VoyageClient.EmbedBatch] call, one model after another in lexical order. Embeddings of
+// different models are not comparable, so each is returned with the model that produced it.
+// The first failing model stops the call.
+func (c *VoyageClient) EmbedRouted(ctx context.Context, texts []string, router *ModelRouter, opts *EmbeddingRequestOpts) (*RoutedResponse, error) {
+	out := &RoutedResponse{Data: make([]RoutedEmbedding, len(texts)), Usage: map[string]UsageObject{}}
+	groups := map[string][]int{}
+	for i, text := range texts {
+		model, reason := router.Route(text)
+		out.Data[i] = RoutedEmbedding{Index: i, Model: model, Reason: reason}
+		groups[model] = append(groups[model], i)
+	}
+	models := make([]string, 0, len(groups))
+	for m := range groups {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+
+	for _, model := range models {
+		indices := groups[model]
+		batch := make([]string, len(indices))
+		for j, i := range indices {
+			batch[j] = texts[i]
+		}
+		resp, err := c.EmbedBatch(ctx, batch, model, opts, nil)
+		if err != nil {
+			return nil, fmt.Errorf("voyage: embed with %s: %w", model, err)
+		}
+		for _, obj := range resp.Data {
+			out.Data[indices[obj.Index]].Embedding = obj.Embedding
+		}
+		out.Usage[model] = resp.Usage
+	}
+	return out, nil
+}
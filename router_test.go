@@ -0,0 +1,91 @@
+package voyageai_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestModelRouterRoute(t *testing.T) {
+	router, err := voyageai.NewModelRouter(voyageai.RouterRules{
+		MaxLiteTokens: 5,
+		DomainPatterns: map[string]string{
+			`(?i)\bstatute\b`: "voyage-law-2",
+			`\bfunc\b`:        "voyage-code-3",
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, tc := range []struct{ text, model, reason string }{
+		{"cheap flights", "voyage-3.5-lite", "4 tokens, within the lite limit of 5"},
+		{"what are the cheapest flights to Lisbon in May", "voyage-3-large", "12 tokens, over the lite limit of 5"},
+		{"Statute of limitations", "voyage-law-2", `matches domain pattern "(?i)\\bstatute\\b"`},
+		// Patterns are tried in lexical order, so the first one wins.
+		{"func statute()", "voyage-law-2", `matches domain pattern "(?i)\\bstatute\\b"`},
+		{"func main()", "voyage-code-3", `matches domain pattern "\\bfunc\\b"`},
+	} {
+		model, reason := router.Route(tc.text)
+		if model != tc.model || reason != tc.reason {
+			t.Errorf("%q: expected %s (%s), got %s (%s)", tc.text, tc.model, tc.reason, model, reason)
+		}
+	}
+
+	if _, err := voyageai.NewModelRouter(voyageai.RouterRules{DomainPatterns: map[string]string{"(": "m"}}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestEmbedRouted(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embedModel = func(model, text string) []float32 {
+		return []float32{float32(len(model)), float32(len(text))}
+	}
+	router, err := voyageai.NewModelRouter(voyageai.RouterRules{
+		MaxLiteTokens:  3,
+		DomainPatterns: map[string]string{"contract": "voyage-law-2"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	texts := []string{"hi", "a much longer question about things", "contract terms", "yo", "another long and detailed query"}
+	resp, err := srv.client().EmbedRouted(context.Background(), texts, router, nil)
+	if err != nil {
+		t.Fatalf("Failed to embed: %+v", err)
+	}
+
+	if srv.requestCount() != 3 {
+		t.Fatalf("Expected one request per model, got %d", srv.requestCount())
+	}
+	got := map[string][]string{}
+	for _, req := range srv.requests {
+		got[req.Model] = req.Input
+	}
+	if !slices.Equal(got["voyage-3.5-lite"], []string{"hi", "yo"}) ||
+		!slices.Equal(got["voyage-3-large"], []string{texts[1], texts[4]}) ||
+		!slices.Equal(got["voyage-law-2"], []string{"contract terms"}) {
+		t.Errorf("Unexpected grouping %q", got)
+	}
+
+	models := []string{"voyage-3.5-lite", "voyage-3-large", "voyage-law-2", "voyage-3.5-lite", "voyage-3-large"}
+	for i, e := range resp.Data {
+		if e.Index != i || e.Model != models[i] {
+			t.Errorf("Input %d: expected %s, got %+v", i, models[i], e)
+		}
+		if want := []float32{float32(len(models[i])), float32(len(texts[i]))}; !slices.Equal(e.Embedding, want) {
+			t.Errorf("Input %d: expected embedding %v, got %v", i, want, e.Embedding)
+		}
+		if _, reason := router.Route(texts[i]); e.Reason != reason {
+			t.Errorf("Input %d: unexpected reason %q", i, e.Reason)
+		}
+	}
+	if !strings.HasPrefix(resp.Data[2].Reason, "matches domain pattern") {
+		t.Errorf("Expected the pattern to be recorded, got %q", resp.Data[2].Reason)
+	}
+	if len(resp.Usage) != 3 || resp.Usage["voyage-law-2"].TotalTokens == 0 {
+		t.Errorf("Expected the usage of every model, got %+v", resp.Usage)
+	}
+}
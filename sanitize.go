@@ -0,0 +1,146 @@
+package voyageai
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	unorm "golang.org/x/text/unicode/norm"
+)
+
+// Options for [SanitizeText] and [VoyageClientOpts.Sanitize]. Invalid UTF-8 and control
+// characters are always cleaned up; the other steps are opt-in.
+type SanitizeOpts struct {
+	NFC                bool // Apply Unicode normalization form C.
+	CollapseWhitespace bool // Trim the text and collapse runs of whitespace. See [SanitizeText].
+}
+
+// What [SanitizeText] changed in a text.
+type SanitizeReport struct {
+	InvalidUTF8         int  // The number of bytes that were not valid UTF-8. Each run of them is replaced with U+FFFD.
+	ControlChars        int  // The number of control characters removed.
+	Normalized          bool // Whether NFC normalization changed the text.
+	CollapsedWhitespace bool // Whether collapsing whitespace changed the text.
+}
+
+// Changed reports whether the text was altered.
+func (r SanitizeReport) Changed() bool {
+	return r.InvalidUTF8 > 0 || r.ControlChars > 0 || r.Normalized || r.CollapsedWhitespace
+}
+
+// An input altered by sanitization. See [VoyageClientOpts.Sanitize].
+type SanitizedInput struct {
+	Index  int // The index of the input, or -1 for a rerank query.
+	Report SanitizeReport
+}
+
+// SanitizeText cleans up text for embedding and reports what it changed. In order, it:
+//
+//   - replaces each run of invalid UTF-8 bytes with U+FFFD,
+//   - removes C0 and C1 control characters and DEL, except tab and newline,
+//   - applies NFC normalization if opts.NFC is set,
+//   - if opts.CollapseWhitespace is set, trims leading and trailing whitespace and collapses
+//     every other run of whitespace to a single space, a newline if it contains one newline, or a
+//     blank line if it contains several.
+//
+// Sanitizing a sanitized text with the same options leaves it unchanged.
+func SanitizeText(s string, opts SanitizeOpts) (string, SanitizeReport) {
+	var report SanitizeReport
+	var b strings.Builder
+	invalid := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if r == utf8.RuneError && size == 1 {
+			report.InvalidUTF8++
+			if !invalid {
+				b.WriteRune(utf8.RuneError)
+			}
+			invalid = true
+			continue
+		}
+		invalid = false
+		if isStrippedControl(r) {
+			report.ControlChars++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	out := b.String()
+
+	if opts.NFC && !unorm.NFC.IsNormalString(out) {
+		out = unorm.NFC.String(out)
+		report.Normalized = true
+	}
+	if opts.CollapseWhitespace {
+		if collapsed := collapseWhitespace(out); collapsed != out {
+			out = collapsed
+			report.CollapsedWhitespace = true
+		}
+	}
+	return out, report
+}
+
+func isStrippedControl(r rune) bool {
+	return r != '\t' && r != '\n' && (r < 0x20 || (r >= 0x7f && r <= 0x9f))
+}
+
+// collapseWhitespace trims s and collapses its whitespace as described on [SanitizeText].
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	s = strings.TrimFunc(s, unicode.IsSpace)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !unicode.IsSpace(r) {
+			b.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+		newlines := 0
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			if r == '\n' {
+				newlines++
+			}
+			i += size
+		}
+		switch {
+		case newlines > 1:
+			b.WriteString("\n\n")
+		case newlines == 1:
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// sanitizeInputs applies the client's sanitization to texts. It returns texts itself if
+// sanitization is off or changed nothing, and otherwise a copy along with a report per altered
+// input.
+func (c *VoyageClient) sanitizeInputs(texts []string) ([]string, []SanitizedInput) {
+	if c.opts.Sanitize == nil {
+		return texts, nil
+	}
+	var out []string
+	var reports []SanitizedInput
+	for i, s := range texts {
+		clean, report := SanitizeText(s, *c.opts.Sanitize)
+		if !report.Changed() {
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), texts...)
+		}
+		out[i] = clean
+		reports = append(reports, SanitizedInput{Index: i, Report: report})
+	}
+	if out == nil {
+		return texts, nil
+	}
+	return out, reports
+}
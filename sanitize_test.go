@@ -0,0 +1,101 @@
+package voyageai_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestSanitizeText(t *testing.T) {
+	all := voyageai.SanitizeOpts{NFC: true, CollapseWhitespace: true}
+	tests := []struct {
+		name   string
+		in     string
+		opts   voyageai.SanitizeOpts
+		want   string
+		report voyageai.SanitizeReport
+	}{
+		{"clean", "plain text\twith tab\nand newline", voyageai.SanitizeOpts{}, "plain text\twith tab\nand newline", voyageai.SanitizeReport{}},
+		{"invalid utf-8", "a\xff\xfeb\xc3", voyageai.SanitizeOpts{}, "a\ufffdb\ufffd", voyageai.SanitizeReport{InvalidUTF8: 3}},
+		{"nul", "a\x00b", voyageai.SanitizeOpts{}, "ab", voyageai.SanitizeReport{ControlChars: 1}},
+		{"c0 and del", "\x1b[1mbold\x7f\r\n", voyageai.SanitizeOpts{}, "[1mbold\n", voyageai.SanitizeReport{ControlChars: 3}},
+		{"c1", "a\u0085b\u009fc", voyageai.SanitizeOpts{}, "abc", voyageai.SanitizeReport{ControlChars: 2}},
+		{"nfc off", "cafe\u0301", voyageai.SanitizeOpts{}, "cafe\u0301", voyageai.SanitizeReport{}},
+		{"nfc", "cafe\u0301", voyageai.SanitizeOpts{NFC: true}, "caf\u00e9", voyageai.SanitizeReport{Normalized: true}},
+		{"already nfc", "caf\u00e9", voyageai.SanitizeOpts{NFC: true}, "caf\u00e9", voyageai.SanitizeReport{}},
+		{"collapse", "  a \t b  c\n \n\n d\ne  ", voyageai.SanitizeOpts{CollapseWhitespace: true}, "a b c\n\nd\ne", voyageai.SanitizeReport{CollapsedWhitespace: true}},
+		{"everything", " \x00cafe\u0301\xff  menu ", all, "caf\u00e9\ufffd menu", voyageai.SanitizeReport{InvalidUTF8: 1, ControlChars: 1, Normalized: true, CollapsedWhitespace: true}},
+	}
+	for _, tt := range tests {
+		got, report := voyageai.SanitizeText(tt.in, tt.opts)
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		if report != tt.report {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.report, report)
+		}
+		if report.Changed() != (tt.in != tt.want) {
+			t.Errorf("%s: expected Changed to be %v", tt.name, tt.in != tt.want)
+		}
+
+		again, report := voyageai.SanitizeText(got, tt.opts)
+		if again != got || report.Changed() {
+			t.Errorf("%s: expected sanitizing %q again to change nothing, got %q and %+v", tt.name, got, again, report)
+		}
+	}
+}
+
+func TestClientSanitize(t *testing.T) {
+	api := newMockServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:      "APIKEY",
+		BaseURL:  api.URL,
+		Sanitize: &voyageai.SanitizeOpts{CollapseWhitespace: true},
+	})
+
+	inputs := []string{"fine", "nul\x00byte", "  spaced   out "}
+	resp, err := client.Embed(inputs, "voyage-3", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := api.inputs(); !reflect.DeepEqual(got, []string{"fine", "nulbyte", "spaced out"}) {
+		t.Errorf("Expected sanitized inputs to be sent, got %q", got)
+	}
+	if inputs[1] != "nul\x00byte" {
+		t.Error("Expected the caller's slice to be left alone")
+	}
+	want := []voyageai.SanitizedInput{
+		{Index: 1, Report: voyageai.SanitizeReport{ControlChars: 1}},
+		{Index: 2, Report: voyageai.SanitizeReport{CollapsedWhitespace: true}},
+	}
+	if !reflect.DeepEqual(resp.Sanitized, want) {
+		t.Errorf("Expected %+v, got %+v", want, resp.Sanitized)
+	}
+
+	rr, err := client.Rerank("query\x07", []string{"doc", "doc\x00"}, "rerank-2", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if q := api.reranks[0]; q.Query != "query" || !reflect.DeepEqual(q.Documents, []string{"doc", "doc"}) {
+		t.Errorf("Expected a sanitized rerank request, got %+v", q)
+	}
+	wantRerank := []voyageai.SanitizedInput{
+		{Index: -1, Report: voyageai.SanitizeReport{ControlChars: 1}},
+		{Index: 1, Report: voyageai.SanitizeReport{ControlChars: 1}},
+	}
+	if !reflect.DeepEqual(rr.Sanitized, wantRerank) {
+		t.Errorf("Expected %+v, got %+v", wantRerank, rr.Sanitized)
+	}
+}
+
+func TestClientSanitizeOffByDefault(t *testing.T) {
+	api := newMockServer(t)
+	resp, err := api.client().Embed([]string{"nul\x00byte"}, "voyage-3", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := api.inputs(); got[0] != "nul\x00byte" || resp.Sanitized != nil {
+		t.Errorf("Expected inputs to be sent unchanged, got %q and %+v", got, resp.Sanitized)
+	}
+}
@@ -0,0 +1,105 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+)
+
+// Options for [SemanticChunk].
+type SemanticChunkOpts struct {
+	WindowSentences int                   // The number of sentences embedded together to represent each sentence. Defaults to 1.
+	Threshold       float32               // A sentence starts a new chunk when its similarity to the current chunk falls below this value. Must be in (0, 1].
+	MaxChunkTokens  int                   // The maximum estimated tokens per chunk. Defaults to [DefaultChunkTokens].
+	EmbedOpts       *EmbeddingRequestOpts // Optional parameters for the embedding requests.
+}
+
+// SemanticChunk splits text into chunks of related sentences.
+//
+// The text is split into sentences and every sentence is embedded together with the following
+// WindowSentences-1 sentences. Sentences are then added to the current chunk in order until the
+// cosine similarity between a sentence's window and the mean of the windows already in the chunk
+// drops below [SemanticChunkOpts.Threshold], or adding it would exceed
+// [SemanticChunkOpts.MaxChunkTokens]; either starts a new chunk. Sentences that are longer than
+// the token limit on their own are first split with [ChunkText].
+//
+// The returned usage covers the embedding requests made to compute the breakpoints.
+func SemanticChunk(ctx context.Context, client *VoyageClient, text string, model string, opts SemanticChunkOpts) ([]Chunk, *UsageObject, error) {
+	if opts.Threshold <= 0 || opts.Threshold > 1 {
+		return nil, nil, fmt.Errorf("voyage: semantic chunk threshold must be in (0, 1], got %v", opts.Threshold)
+	}
+	window := opts.WindowSentences
+	if window <= 0 {
+		window = 1
+	}
+	maxTokens := opts.MaxChunkTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+	tok, _ := client.tokenizer()
+	count := func(s string) (int, error) {
+		n, err := tok.CountTokens(model, s)
+		if err != nil {
+			return 0, fmt.Errorf("voyage: count tokens: %w", err)
+		}
+		return n, nil
+	}
+
+	var sentences []Chunk
+	for _, s := range splitSentences(text) {
+		n, err := count(s.Text)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n <= maxTokens {
+			sentences = append(sentences, s)
+			continue
+		}
+		for _, piece := range ChunkText(s.Text, ChunkOpts{MaxTokens: maxTokens}) {
+			sentences = append(sentences, Chunk{Text: piece.Text, Start: s.Start + piece.Start, End: s.Start + piece.End})
+		}
+	}
+	if len(sentences) == 0 {
+		return nil, &UsageObject{}, nil
+	}
+
+	windows := make([]string, len(sentences))
+	for i, s := range sentences {
+		last := sentences[min(i+window, len(sentences))-1]
+		windows[i] = text[s.Start:last.End]
+	}
+	resp, err := client.EmbedBatch(ctx, windows, model, opts.EmbedOpts, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	embs := resp.Data
+
+	var chunks []Chunk
+	start := 0
+	centroid := append([]float32(nil), embs[0].Embedding...)
+	emit := func(end int) {
+		s, e := sentences[start].Start, sentences[end-1].End
+		chunks = append(chunks, Chunk{Text: text[s:e], Start: s, End: e})
+	}
+	for i := 1; i < len(sentences); i++ {
+		n, err := count(text[sentences[start].Start:sentences[i].End])
+		if err != nil {
+			return nil, nil, err
+		}
+		emb := embs[i].Embedding
+		if n > maxTokens || cosine(centroid, emb) < float64(opts.Threshold) {
+			emit(i)
+			start = i
+			centroid = append(centroid[:0], emb...)
+			continue
+		}
+
+		// Update the running mean of the chunk's window embeddings.
+		size := float32(i - start + 1)
+		for d := range centroid {
+			centroid[d] += (emb[d] - centroid[d]) / size
+		}
+	}
+	emit(len(sentences))
+
+	return chunks, &resp.Usage, nil
+}
@@ -0,0 +1,85 @@
+package voyageai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// topicVector scripts embeddings by topic: each mention of a topic word adds to its dimension.
+func topicVector(text string) []float32 {
+	return []float32{
+		float32(strings.Count(text, "cat")),
+		float32(strings.Count(text, "dog")),
+		float32(strings.Count(text, "fish")),
+	}
+}
+
+func TestSemanticChunkBreakpoints(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embed = topicVector
+
+	text := "The cat sleeps. A cat purrs.\n\nMy dog barks! The dog runs. Is the dog tired? Some fish swim. Fish glow."
+	chunks, usage, err := voyageai.SemanticChunk(context.Background(), srv.client(), text, "test-model", voyageai.SemanticChunkOpts{Threshold: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	want := []string{
+		"The cat sleeps. A cat purrs.",
+		"My dog barks! The dog runs. Is the dog tired?",
+		"Some fish swim.",
+		"Fish glow.",
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, c := range chunks {
+		if c.Text != want[i] || text[c.Start:c.End] != c.Text {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], c.Text)
+		}
+	}
+	if usage == nil || usage.TotalTokens == 0 {
+		t.Error("Expected the embedding usage to be reported")
+	}
+	if n := srv.requestCount(); n != 1 {
+		t.Errorf("Expected the windows to be embedded in a single batch, got %d requests", n)
+	}
+}
+
+func TestSemanticChunkWindowAndMaxTokens(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embed = topicVector
+
+	text := "One cat. Two cat. Three cat. Four cat. Five cat. Six cat."
+	chunks, _, err := voyageai.SemanticChunk(context.Background(), srv.client(), text, "test-model", voyageai.SemanticChunkOpts{
+		WindowSentences: 2,
+		Threshold:       0.5,
+		MaxChunkTokens:  5,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, c := range chunks {
+		if voyageai.EstimateTokens(c.Text) > 5 {
+			t.Errorf("Chunk %q exceeds the token limit", c.Text)
+		}
+	}
+	if len(chunks) != 3 {
+		t.Errorf("Expected the similar sentences to be split only by size, got %d chunks", len(chunks))
+	}
+
+	for _, req := range srv.requests {
+		if req.Input[0] != "One cat. Two cat." {
+			t.Errorf("Expected windows of two sentences, got %q", req.Input[0])
+		}
+	}
+}
+
+func TestSemanticChunkThresholdValidation(t *testing.T) {
+	if _, _, err := voyageai.SemanticChunk(context.Background(), voyageai.NewClient(nil), "text", "m", voyageai.SemanticChunkOpts{}); err == nil {
+		t.Error("Expected an error for a zero threshold")
+	}
+}
@@ -0,0 +1,40 @@
+package voyageai
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitSentences splits text into sentences, ending a sentence after '.', '!' or '?' followed by
+// whitespace and at blank lines. Surrounding whitespace is excluded from each sentence's offsets.
+func splitSentences(text string) []Chunk {
+	var sentences []Chunk
+	emit := func(start, end int) {
+		s := text[start:end]
+		trimmed := strings.TrimLeftFunc(s, unicode.IsSpace)
+		start += len(s) - len(trimmed)
+		trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		if trimmed != "" {
+			sentences = append(sentences, Chunk{Text: trimmed, Start: start, End: start + len(trimmed)})
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		next := i + size
+		if next < len(text) {
+			nr, _ := utf8.DecodeRuneInString(text[next:])
+			endsSentence := strings.ContainsRune(".!?", r) && unicode.IsSpace(nr)
+			blankLine := r == '\n' && strings.HasPrefix(strings.TrimLeft(text[next:], " \t\r"), "\n")
+			if endsSentence || blankLine {
+				emit(start, next)
+				start = next
+			}
+		}
+		i = next
+	}
+	emit(start, len(text))
+	return sentences
+}
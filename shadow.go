@@ -0,0 +1,196 @@
+package voyageai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for [ShadowOpts].
+const (
+	DefaultShadowMaxInFlight = 4
+	DefaultShadowTimeout     = 30 * time.Second
+)
+
+// Options for mirroring requests to a second target. See [VoyageClientOpts.Shadow].
+type ShadowOpts struct {
+	BaseURL string // The base URL of the target, such as a candidate gateway. Defaults to the client's.
+	// The model sent to the target in place of the request's, resolved through the client's
+	// aliases. The request's model by default.
+	Model string
+	// The fraction of successful requests mirrored, from 0 to 1. Requests are picked evenly: at a
+	// rate of 0.25, every fourth request is mirrored.
+	Rate float64
+	// The most mirrored requests in flight at once. Requests that would exceed it are not
+	// mirrored. Defaults to [DefaultShadowMaxInFlight].
+	MaxInFlight int
+	// How long a mirrored request may take. Defaults to [DefaultShadowTimeout].
+	Timeout time.Duration
+	// Called with the outcome of every mirrored request, from the goroutine that sent it.
+	Compare func(ShadowComparison)
+}
+
+// A request mirrored by [VoyageClientOpts.Shadow], with both responses.
+type ShadowComparison struct {
+	Endpoint Endpoint
+	Request  []byte // The JSON body sent to the shadow target.
+	// The responses of the primary and the shadow target: *EmbeddingResponse for the embedding
+	// endpoints and *RerankResponse for reranking. Primary is a copy, so it is unaffected by
+	// changes the caller makes to its response. Shadow is nil if ShadowErr is set.
+	Primary, Shadow any
+	ShadowErr       error
+	ShadowDuration  time.Duration
+}
+
+// shadowDispatcher mirrors a sample of requests in the background.
+type shadowDispatcher struct {
+	opts  ShadowOpts
+	slots chan struct{}
+
+	mu     sync.Mutex
+	credit float64 // Accumulates Rate per request; a request is mirrored when it reaches 1.
+}
+
+func newShadowDispatcher(opts *ShadowOpts) *shadowDispatcher {
+	if opts == nil || opts.Rate <= 0 || opts.Compare == nil {
+		return nil
+	}
+	d := &shadowDispatcher{opts: *opts}
+	if d.opts.MaxInFlight <= 0 {
+		d.opts.MaxInFlight = DefaultShadowMaxInFlight
+	}
+	if d.opts.Timeout <= 0 {
+		d.opts.Timeout = DefaultShadowTimeout
+	}
+	d.slots = make(chan struct{}, d.opts.MaxInFlight)
+	return d
+}
+
+// sample reports whether the next request is mirrored.
+func (d *shadowDispatcher) sample() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.credit += min(d.opts.Rate, 1)
+	if d.credit < 1 {
+		return false
+	}
+	d.credit--
+	return true
+}
+
+// mirror sends a copy of a successful request to the shadow target in the background, unless it
+// is not sampled or too many mirrored requests are in flight. It never blocks. respBody must
+// already be decoded, and the shadow response is decoded likewise.
+func (c *VoyageClient) mirror(ctx context.Context, path string, reqBody, respBody any) {
+	d := c.shadow
+	if d == nil || !d.sample() {
+		return
+	}
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		return
+	}
+	body, primary, err := c.shadowRequest(reqBody, respBody)
+	if err != nil {
+		<-d.slots
+		c.loggerFor(ctx).Warn("voyage: shadow request not sent", "error", err)
+		return
+	}
+	base := d.opts.BaseURL
+	if base == "" {
+		base = c.baseURL
+	}
+	url := strings.TrimSuffix(base, "/") + path
+
+	go func() {
+		defer func() { <-d.slots }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.opts.Timeout)
+		defer cancel()
+		shadow := reflect.New(reflect.TypeOf(respBody).Elem()).Interface()
+		start := c.clock().Now()
+		err := c.sendShadow(ctx, url, body, shadow)
+		if err == nil {
+			err = decodeResponse(reqBody, shadow)
+		}
+		cmp := ShadowComparison{
+			Endpoint:       Endpoint(strings.TrimPrefix(path, "/")),
+			Request:        body,
+			Primary:        primary,
+			Shadow:         shadow,
+			ShadowErr:      err,
+			ShadowDuration: c.clock().Now().Sub(start),
+		}
+		if err != nil {
+			cmp.Shadow = nil
+		}
+		d.opts.Compare(cmp)
+	}()
+}
+
+// shadowRequest returns the body of the mirrored request, with the shadow model if one is set,
+// and a copy of the primary response.
+func (c *VoyageClient) shadowRequest(reqBody, respBody any) ([]byte, any, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	if model := c.shadow.opts.Model; model != "" {
+		resolved, err := c.ResolveModel(model)
+		if err != nil {
+			return nil, nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, nil, err
+		}
+		fields["model"], _ = json.Marshal(resolved)
+		if body, err = json.Marshal(fields); err != nil {
+			return nil, nil, err
+		}
+	}
+	b, err := json.Marshal(respBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	primary := reflect.New(reflect.TypeOf(respBody).Elem()).Interface()
+	if err := json.Unmarshal(b, primary); err != nil {
+		return nil, nil, err
+	}
+	return body, primary, nil
+}
+
+// sendShadow posts body to url once and decodes the response into respBody. It bypasses the
+// client's limits, retries and accounting, which are for the primary target.
+func (c *VoyageClient) sendShadow(ctx context.Context, url string, body []byte, respBody any) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp, b, c.clock().Now())
+	}
+	if err := json.Unmarshal(b, respBody); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if x, ok := respBody.(extraCarrier); ok && !c.opts.PreserveUnknownFields {
+		x.clearExtras()
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package voyageai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestShadowSampling(t *testing.T) {
+	primary := newMockServer(t)
+	candidate := newMockServer(t)
+	results := make(chan voyageai.ShadowComparison, 10)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "test",
+		BaseURL: primary.URL,
+		Aliases: map[string]string{"candidate": "voyage-3.5-lite"},
+		Shadow: &voyageai.ShadowOpts{
+			BaseURL: candidate.URL,
+			Model:   "candidate",
+			Rate:    0.25,
+			Compare: func(c voyageai.ShadowComparison) { results <- c },
+		},
+	})
+
+	for i := range 8 {
+		if _, err := client.EmbedContext(context.Background(), []string{"text", string(rune('a' + i))}, "voyage-3.5", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	for range 2 {
+		select {
+		case c := <-results:
+			if c.Endpoint != voyageai.EndpointEmbeddings || c.ShadowErr != nil {
+				t.Fatalf("Unexpected comparison %+v", c)
+			}
+			p, s := c.Primary.(*voyageai.EmbeddingResponse), c.Shadow.(*voyageai.EmbeddingResponse)
+			if p.Model != "voyage-3.5" || s.Model != "voyage-3.5-lite" || len(p.Data) != 2 || len(s.Data) != 2 {
+				t.Errorf("Expected both responses, got %v and %v", p, s)
+			}
+			var req voyageai.EmbeddingRequest
+			if err := json.Unmarshal(c.Request, &req); err != nil || req.Model != "voyage-3.5-lite" {
+				t.Errorf("Expected the shadow request to use the resolved model, got %s", c.Request)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected two comparisons")
+		}
+	}
+	select {
+	case c := <-results:
+		t.Errorf("Expected only every fourth request to be mirrored, got another %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if n := candidate.requestCount(); n != 2 {
+		t.Errorf("Expected 2 shadow requests, got %d", n)
+	}
+}
+
+func TestShadowNeverBlocks(t *testing.T) {
+	primary := newMockServer(t)
+	release := make(chan struct{})
+	hung := make(chan struct{}, 10)
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hung <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(candidate.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	results := make(chan voyageai.ShadowComparison, 10)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "test",
+		BaseURL: primary.URL,
+		Shadow: &voyageai.ShadowOpts{
+			BaseURL:     candidate.URL,
+			Rate:        1,
+			MaxInFlight: 1,
+			Compare:     func(c voyageai.ShadowComparison) { results <- c },
+		},
+	})
+
+	start := time.Now()
+	for range 3 {
+		if _, err := client.RerankContext(context.Background(), "q", []string{"d"}, "rerank-2", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		time.Sleep(10 * time.Millisecond) // Let the first shadow request reach the server.
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected the hung shadow target not to delay calls, took %v", d)
+	}
+	<-hung
+	select {
+	case <-hung:
+		t.Error("Expected requests beyond MaxInFlight not to be mirrored")
+	default:
+	}
+
+	close(release)
+	select {
+	case c := <-results:
+		var apiErr *voyageai.APIError
+		if !errors.As(c.ShadowErr, &apiErr) || apiErr.StatusCode != 500 || c.Shadow != nil {
+			t.Errorf("Expected the shadow error, got %+v", c)
+		}
+		if p, ok := c.Primary.(*voyageai.RerankResponse); !ok || len(p.Data) != 1 {
+			t.Errorf("Expected the primary response, got %v", c.Primary)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a comparison once the shadow target answered")
+	}
+}
+
+func TestShadowDecodesBase64(t *testing.T) {
+	results := make(chan voyageai.ShadowComparison, 1)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "test",
+		BaseURL: dtypeServer(t).URL,
+		Shadow: &voyageai.ShadowOpts{
+			BaseURL: dtypeServer(t).URL,
+			Rate:    1,
+			Compare: func(c voyageai.ShadowComparison) { results <- c },
+		},
+	})
+
+	opts := &voyageai.EmbeddingRequestOpts{OutputDType: voyageai.Opt(voyageai.DTypeInt8), EncodingFormat: voyageai.Opt(voyageai.EncodingBase64)}
+	if _, err := client.Embed([]string{"a"}, "voyage-3.5", opts); err != nil {
+		t.Fatal(err.Error())
+	}
+	select {
+	case c := <-results:
+		if c.ShadowErr != nil {
+			t.Fatal(c.ShadowErr.Error())
+		}
+		for name, resp := range map[string]any{"primary": c.Primary, "shadow": c.Shadow} {
+			obj := resp.(*voyageai.EmbeddingResponse).Data[0]
+			if got, err := obj.Int8s(); err != nil || !reflect.DeepEqual(got, testInt8s) {
+				t.Errorf("Expected the %s embedding %v, got %v, %v", name, testInt8s, got, err)
+			}
+			if want := []float32{-128, 0, 127}; !reflect.DeepEqual(obj.Embedding, want) {
+				t.Errorf("Expected the %s embedding decoded as int8, got %v", name, obj.Embedding)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a comparison")
+	}
+}
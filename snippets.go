@@ -0,0 +1,144 @@
+package voyageai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Options for [ExtractSnippets].
+type SnippetOpts struct {
+	WindowTokens int                   // The maximum tokens per window. Defaults to 64.
+	Stride       int                   // The number of sentences between the starts of consecutive windows. Defaults to 1.
+	TopPerDoc    int                   // The number of snippets returned per document. Defaults to 1.
+	EmbedOpts    *EmbeddingRequestOpts // Optional parameters for the embedding requests. InputType is set by ExtractSnippets.
+}
+
+// The default [SnippetOpts.WindowTokens].
+const DefaultSnippetTokens = 64
+
+// A passage of a document that matches a query. See [ExtractSnippets].
+type Snippet struct {
+	Chunk         // The passage, with its byte offsets in the document.
+	Score float64 // The cosine similarity between the passage and the query.
+}
+
+// ExtractSnippets finds the passages of each document that best match query, to show why a
+// document was retrieved.
+//
+// Each document is split into sentences, and windows of consecutive sentences of up to
+// [SnippetOpts.WindowTokens] tokens start every [SnippetOpts.Stride] sentences. Sentences longer
+// than a window are first split with [ChunkText]. The query is embedded with input type "query"
+// and the windows of every document with input type "document", in as few requests as
+// [VoyageClient.EmbedBatch] needs, and each window is scored by its cosine similarity to the
+// query.
+//
+// The result holds the snippets of each document at its position in docs, best first: up to
+// [SnippetOpts.TopPerDoc] windows that do not overlap. Documents without text have none. The
+// returned usage covers every embedding request.
+func ExtractSnippets(ctx context.Context, client *VoyageClient, query string, docs []string, model string, opts SnippetOpts) ([][]Snippet, *UsageObject, error) {
+	windowTokens := opts.WindowTokens
+	if windowTokens <= 0 {
+		windowTokens = DefaultSnippetTokens
+	}
+	stride := max(opts.Stride, 1)
+	top := max(opts.TopPerDoc, 1)
+	tok, _ := client.tokenizer()
+	count := func(s string) (int, error) {
+		n, err := tok.CountTokens(model, s)
+		if err != nil {
+			return 0, fmt.Errorf("voyage: count tokens: %w", err)
+		}
+		return n, nil
+	}
+
+	// Split every document into windows, collecting them for a single batch.
+	windows := make([][]Chunk, len(docs))
+	var texts []string
+	for d, doc := range docs {
+		var sentences []Chunk
+		for _, s := range splitSentences(doc) {
+			n, err := count(s.Text)
+			if err != nil {
+				return nil, nil, err
+			}
+			if n <= windowTokens {
+				sentences = append(sentences, s)
+				continue
+			}
+			for _, piece := range ChunkText(s.Text, ChunkOpts{MaxTokens: windowTokens}) {
+				sentences = append(sentences, Chunk{Text: piece.Text, Start: s.Start + piece.Start, End: s.Start + piece.End})
+			}
+		}
+		for i := 0; i < len(sentences); i += stride {
+			end := i + 1
+			for end < len(sentences) {
+				n, err := count(doc[sentences[i].Start:sentences[end].End])
+				if err != nil {
+					return nil, nil, err
+				}
+				if n > windowTokens {
+					break
+				}
+				end++
+			}
+			s, e := sentences[i].Start, sentences[end-1].End
+			windows[d] = append(windows[d], Chunk{Text: doc[s:e], Start: s, End: e})
+			texts = append(texts, doc[s:e])
+		}
+	}
+	if len(texts) == 0 {
+		return make([][]Snippet, len(docs)), &UsageObject{}, nil
+	}
+
+	queryResp, err := client.embedContext(ctx, []string{query}, model, withInputType(opts.EmbedOpts, "query"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("voyage: embed query: %w", err)
+	}
+	if len(queryResp.Data) != 1 {
+		return nil, nil, fmt.Errorf("voyage: embed query: expected 1 embedding, got %d", len(queryResp.Data))
+	}
+	queryEmb := queryResp.Data[0].Embedding
+	resp, err := client.EmbedBatch(ctx, texts, model, withInputType(opts.EmbedOpts, "document"), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	usage := addUsage(queryResp.Usage, resp.Usage)
+
+	result := make([][]Snippet, len(docs))
+	k := 0
+	for d, ws := range windows {
+		scored := make([]Snippet, len(ws))
+		for i, w := range ws {
+			scored[i] = Snippet{Chunk: w, Score: cosine(queryEmb, resp.Data[k].Embedding)}
+			k++
+		}
+		sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+		for _, s := range scored {
+			if len(result[d]) == top {
+				break
+			}
+			overlaps := false
+			for _, picked := range result[d] {
+				if s.Start < picked.End && picked.Start < s.End {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				result[d] = append(result[d], s)
+			}
+		}
+	}
+	return result, &usage, nil
+}
+
+// withInputType returns a copy of opts with the given input type.
+func withInputType(opts *EmbeddingRequestOpts, inputType string) *EmbeddingRequestOpts {
+	cp := EmbeddingRequestOpts{}
+	if opts != nil {
+		cp = *opts
+	}
+	cp.InputType = &inputType
+	return &cp
+}
@@ -0,0 +1,80 @@
+package voyageai_test
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestExtractSnippets(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embed = topicVector
+	docs := []string{
+		"The cat sleeps.  My dog barks loudly. Fish swim.",
+		"",
+		"Dogs? A dog and a dog play.\n\nNothing else.",
+	}
+	snippets, usage, err := voyageai.ExtractSnippets(context.Background(), srv.client(), "dog", docs, "test-model", voyageai.SnippetOpts{WindowTokens: 6})
+	if err != nil {
+		t.Fatalf("Failed to extract snippets: %+v", err)
+	}
+	if len(snippets) != len(docs) || len(snippets[1]) != 0 {
+		t.Fatalf("Expected one entry per document and none for the empty one, got %+v", snippets)
+	}
+	for d, want := range map[int]string{0: "My dog barks loudly.", 2: "A dog and a dog play."} {
+		if len(snippets[d]) != 1 {
+			t.Fatalf("Document %d: expected one snippet, got %+v", d, snippets[d])
+		}
+		s := snippets[d][0]
+		if s.Text != want || docs[d][s.Start:s.End] != want || math.Abs(s.Score-1) > 1e-6 {
+			t.Errorf("Document %d: expected %q with score 1, got %q at %d-%d with score %v", d, want, s.Text, s.Start, s.End, s.Score)
+		}
+	}
+	if usage == nil || usage.TotalTokens == 0 {
+		t.Error("Expected the embedding usage to be reported")
+	}
+
+	if n := srv.requestCount(); n != 2 {
+		t.Fatalf("Expected the query and one batch of windows, got %d requests", n)
+	}
+	query, windows := srv.requests[0], srv.requests[1]
+	if query.InputType == nil || *query.InputType != "query" || windows.InputType == nil || *windows.InputType != "document" {
+		t.Errorf("Expected the query and document input types, got %v and %v", query.InputType, windows.InputType)
+	}
+	if !slices.Contains(windows.Input, "Fish swim.") || !slices.Contains(windows.Input, "Nothing else.") {
+		t.Errorf("Expected the windows of every document in one request, got %q", windows.Input)
+	}
+}
+
+func TestExtractSnippetsTopAndStride(t *testing.T) {
+	srv := newMockServer(t)
+	srv.embed = topicVector
+	doc := "A cat. A dog. A dog barks. A fish."
+
+	for _, tc := range []struct {
+		opts voyageai.SnippetOpts
+		want []string
+	}{
+		// The window of the first two sentences overlaps the best one, so the fish comes third.
+		{voyageai.SnippetOpts{WindowTokens: 4, TopPerDoc: 3}, []string{"A dog.", "A dog barks.", "A fish."}},
+		{voyageai.SnippetOpts{WindowTokens: 4, TopPerDoc: 4, Stride: 2}, []string{"A dog barks.", "A cat. A dog."}},
+	} {
+		snippets, _, err := voyageai.ExtractSnippets(context.Background(), srv.client(), "dog", []string{doc}, "test-model", tc.opts)
+		if err != nil {
+			t.Fatalf("Failed to extract snippets: %+v", err)
+		}
+		var got []string
+		for _, s := range snippets[0] {
+			got = append(got, s.Text)
+			if doc[s.Start:s.End] != s.Text {
+				t.Errorf("Snippet %q does not match its offsets", s.Text)
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%+v: expected %q, got %q", tc.opts, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+// A [voyageai.CacheStore] backed by the cache table of a [Store].
+type Cache struct {
+	db *sql.DB
+}
+
+var _ voyageai.CacheStore = (*Cache)(nil)
+
+// Cache returns a [voyageai.CacheStore] that keeps its entries in the store's database.
+func (s *Store) Cache() *Cache {
+	return &Cache{db: s.db}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (voyageai.CacheEntry, bool, error) {
+	var (
+		value               []byte
+		storedAt, expiresAt int64
+	)
+	err := c.db.QueryRowContext(ctx, `SELECT value, stored_at, expires_at FROM cache_entries WHERE key = ?`, key).
+		Scan(&value, &storedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return voyageai.CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return voyageai.CacheEntry{}, false, fmt.Errorf("sqlitestore: cache get: %w", err)
+	}
+	entry := voyageai.CacheEntry{Value: value, StoredAt: time.Unix(0, storedAt)}
+	if expiresAt != 0 {
+		entry.ExpiresAt = time.Unix(0, expiresAt)
+	}
+	return entry, true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, entry voyageai.CacheEntry) error {
+	var expiresAt int64
+	if !entry.ExpiresAt.IsZero() {
+		expiresAt = entry.ExpiresAt.UnixNano()
+	}
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO cache_entries (key, value, stored_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			value = excluded.value, stored_at = excluded.stored_at, expires_at = excluded.expires_at`,
+		key, entry.Value, entry.StoredAt.UnixNano(), expiresAt)
+	if err != nil {
+		return fmt.Errorf("sqlitestore: cache set: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes the entries that expired before t and returns how many were deleted.
+// Entries that never expire are kept. Note that expired entries may still be useful to
+// [voyageai.CacheOpts.StaleIfError].
+func (c *Cache) DeleteExpired(ctx context.Context, t time.Time) (int64, error) {
+	res, err := c.db.ExecContext(ctx, `DELETE FROM cache_entries WHERE expires_at != 0 AND expires_at < ?`, t.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("sqlitestore: cache delete: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS embeddings (
+	id         TEXT PRIMARY KEY,
+	model      TEXT NOT NULL,
+	dim        INTEGER NOT NULL,
+	dtype      TEXT NOT NULL,
+	vector     BLOB NOT NULL,
+	metadata   TEXT,
+	created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS embeddings_created_at ON embeddings (created_at);
+
+CREATE TABLE IF NOT EXISTS cache_entries (
+	key        TEXT PRIMARY KEY,
+	value      BLOB NOT NULL,
+	stored_at  INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cache_entries_expires_at ON cache_entries (expires_at);
@@ -0,0 +1,279 @@
+// Package sqlitestore stores embeddings and response cache entries in a SQLite database, for
+// tools that need durable storage without running a vector database. It uses the cgo-free
+// modernc.org/sqlite driver.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zamedic/voyageai"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// An embedding as stored in a [Store].
+type Record struct {
+	ID    string
+	Model string
+	// How the vector is stored: "float" (the default) for float32 values, or "float16" for
+	// half-precision values as converted by [voyageai.ToFloat16Bits], which halves their size at
+	// a small loss of precision. Other values, such as the output data type the vector was
+	// requested with, are recorded as is and stored as "float".
+	DType    string
+	Vector   []float32
+	Metadata map[string]string
+	// When the record was stored. Set to the current time by [Store.Upsert] if zero.
+	CreatedAt time.Time
+}
+
+// A SQLite database holding embeddings and, through [Store.Cache], response cache entries.
+// It is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the SQLite database at path, creating it if needed, and applies any pending
+// migrations.
+func Open(path string) (*Store, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitestore: open: %w", err)
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a [Store] backed by db, which must use a SQLite driver, after applying any pending
+// migrations. Closing the store closes db.
+func New(db *sql.DB) (*Store, error) {
+	s := &Store{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies the embedded migrations that have not been applied yet, in order of their
+// numeric prefix. Each runs in its own transaction together with recording its version.
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("sqlitestore: migrate: %w", err)
+	}
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/")
+		prefix, _, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("sqlitestore: migration %s has no version", base)
+		}
+		if err := s.apply(ctx, version, name); err != nil {
+			return fmt.Errorf("sqlitestore: migration %s: %w", base, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) apply(ctx context.Context, version int, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	script, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Upsert stores rec, replacing any record with the same ID.
+func (s *Store) Upsert(ctx context.Context, rec Record) error {
+	if rec.DType == "" {
+		rec.DType = "float"
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	var meta []byte
+	if rec.Metadata != nil {
+		var err error
+		if meta, err = json.Marshal(rec.Metadata); err != nil {
+			return fmt.Errorf("sqlitestore: marshal metadata: %w", err)
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO embeddings (id, model, dim, dtype, vector, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			model = excluded.model, dim = excluded.dim, dtype = excluded.dtype,
+			vector = excluded.vector, metadata = excluded.metadata, created_at = excluded.created_at`,
+		rec.ID, rec.Model, len(rec.Vector), rec.DType, encodeVector(rec.Vector, rec.DType), nullString(meta), rec.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("sqlitestore: upsert %q: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// Get returns the record stored under id. ok is false if there is none.
+func (s *Store) Get(ctx context.Context, id string) (rec Record, ok bool, err error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+recordColumns+` FROM embeddings WHERE id = ?`, id)
+	rec, err = scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, fmt.Errorf("sqlitestore: get %q: %w", id, err)
+	}
+	return rec, true, nil
+}
+
+// DeleteOlderThan deletes the records created before t and returns how many were deleted.
+func (s *Store) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM embeddings WHERE created_at < ?`, t.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("sqlitestore: delete: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// All iterates over every record in ID order, for bulk export. Iteration stops at the first
+// error, which is yielded with a zero record.
+func (s *Store) All(ctx context.Context) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		rows, err := s.db.QueryContext(ctx, `SELECT `+recordColumns+` FROM embeddings ORDER BY id`)
+		if err != nil {
+			yield(Record{}, fmt.Errorf("sqlitestore: query: %w", err))
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			rec, err := scanRecord(rows)
+			if err != nil {
+				yield(Record{}, fmt.Errorf("sqlitestore: scan: %w", err))
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(Record{}, fmt.Errorf("sqlitestore: query: %w", err))
+		}
+	}
+}
+
+const dtypeFloat16 = "float16"
+
+const recordColumns = `id, model, dim, dtype, vector, metadata, created_at`
+
+func scanRecord(row interface{ Scan(...any) error }) (Record, error) {
+	var (
+		rec       Record
+		dim       int
+		vector    []byte
+		meta      sql.NullString
+		createdAt int64
+	)
+	if err := row.Scan(&rec.ID, &rec.Model, &dim, &rec.DType, &vector, &meta, &createdAt); err != nil {
+		return Record{}, err
+	}
+	v, ok := decodeVector(vector, rec.DType)
+	if !ok || len(v) != dim {
+		return Record{}, fmt.Errorf("record %q has a corrupt vector", rec.ID)
+	}
+	rec.Vector = v
+	if meta.Valid {
+		if err := json.Unmarshal([]byte(meta.String), &rec.Metadata); err != nil {
+			return Record{}, fmt.Errorf("record %q: unmarshal metadata: %w", rec.ID, err)
+		}
+	}
+	rec.CreatedAt = time.Unix(0, createdAt)
+	return rec, nil
+}
+
+func nullString(b []byte) sql.NullString {
+	return sql.NullString{String: string(b), Valid: b != nil}
+}
+
+// encodeVector serializes v as little-endian float32 values, the encoding used for vectors by
+// voyageai.VectorIndex files, or as little-endian half-precision values for the "float16" dtype.
+func encodeVector(v []float32, dtype string) []byte {
+	if dtype == dtypeFloat16 {
+		b := make([]byte, 0, 2*len(v))
+		for _, h := range voyageai.ToFloat16Bits(v) {
+			b = binary.LittleEndian.AppendUint16(b, h)
+		}
+		return b
+	}
+	b := make([]byte, 0, 4*len(v))
+	for _, f := range v {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte, dtype string) ([]float32, bool) {
+	if dtype == dtypeFloat16 {
+		if len(b)%2 != 0 {
+			return nil, false
+		}
+		bits := make([]uint16, len(b)/2)
+		for i := range bits {
+			bits[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return voyageai.FromFloat16Bits(bits), true
+	}
+	if len(b)%4 != 0 {
+		return nil, false
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v, true
+}
@@ -0,0 +1,182 @@
+package sqlitestore_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+	"github.com/zamedic/voyageai/sqlitestore"
+)
+
+func openStore(t *testing.T, path string) *sqlitestore.Store {
+	t.Helper()
+	s, err := sqlitestore.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+	s := openStore(t, path)
+
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := sqlitestore.Record{
+		ID:        "doc-1",
+		Model:     "voyage-3",
+		Vector:    []float32{0.5, -1.25, 3},
+		Metadata:  map[string]string{"lang": "en"},
+		CreatedAt: created,
+	}
+	if err := s.Upsert(ctx, rec); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, ok, err := s.Get(ctx, "doc-1")
+	if err != nil || !ok {
+		t.Fatalf("Expected the record, got %v", err)
+	}
+	rec.DType = "float"
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("Expected created at %v, got %v", created, got.CreatedAt)
+	}
+	got.CreatedAt = created
+	if !reflect.DeepEqual(got, rec) {
+		t.Errorf("Expected %+v, got %+v", rec, got)
+	}
+
+	rec.Vector, rec.DType, rec.Metadata = []float32{1, 2}, "int8", nil
+	if err := s.Upsert(ctx, rec); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, _, _ = s.Get(ctx, "doc-1")
+	if !reflect.DeepEqual(got.Vector, []float32{1, 2}) || got.DType != "int8" || got.Metadata != nil {
+		t.Errorf("Expected the record to be replaced, got %+v", got)
+	}
+	if _, ok, err := s.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Expected no record, got %v, %v", ok, err)
+	}
+
+	// Reopening applies no migration twice and keeps the data.
+	s.Close()
+	s = openStore(t, path)
+	if _, ok, _ := s.Get(ctx, "doc-1"); !ok {
+		t.Error("Expected the record to survive reopening")
+	}
+}
+
+func TestStoreDeleteOlderThanAndAll(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t, filepath.Join(t.TempDir(), "store.db"))
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		s.Upsert(ctx, sqlitestore.Record{ID: fmt.Sprint("doc-", i), Model: "m", Vector: []float32{float32(i)}, CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+	n, err := s.DeleteOlderThan(ctx, base.Add(2*time.Hour))
+	if err != nil || n != 2 {
+		t.Errorf("Expected 2 deletions, got %d, %v", n, err)
+	}
+
+	var ids []string
+	for rec, err := range s.All(ctx) {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ids = append(ids, rec.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"doc-2", "doc-3", "doc-4"}) {
+		t.Errorf("Unexpected remaining records %v", ids)
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := openStore(t, filepath.Join(t.TempDir(), "store.db")).Cache()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entry := voyageai.CacheEntry{Value: []byte("v"), StoredAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := c.Set(ctx, "k", entry); err != nil {
+		t.Fatal(err.Error())
+	}
+	c.Set(ctx, "forever", voyageai.CacheEntry{Value: []byte("f"), StoredAt: now})
+	got, ok, err := c.Get(ctx, "k")
+	if err != nil || !ok || string(got.Value) != "v" || !got.ExpiresAt.Equal(entry.ExpiresAt) || !got.StoredAt.Equal(now) {
+		t.Errorf("Unexpected entry %+v, %v, %v", got, ok, err)
+	}
+	if got, _, _ := c.Get(ctx, "forever"); !got.ExpiresAt.IsZero() {
+		t.Errorf("Expected no expiry, got %v", got.ExpiresAt)
+	}
+
+	if n, _ := c.DeleteExpired(ctx, now.Add(2*time.Hour)); n != 1 {
+		t.Errorf("Expected 1 expired entry to be deleted, got %d", n)
+	}
+	if _, ok, _ := c.Get(ctx, "k"); ok {
+		t.Error("Expected the expired entry to be gone")
+	}
+	if _, ok, _ := c.Get(ctx, "forever"); !ok {
+		t.Error("Expected the entry without expiry to be kept")
+	}
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t, filepath.Join(t.TempDir(), "store.db"))
+	cache := s.Cache()
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 25 {
+				id := fmt.Sprintf("w%d-%d", w, i)
+				if err := s.Upsert(ctx, sqlitestore.Record{ID: id, Model: "m", Vector: []float32{float32(w), float32(i)}}); err != nil {
+					t.Error(err.Error())
+					return
+				}
+				if rec, ok, err := s.Get(ctx, id); err != nil || !ok || rec.Vector[1] != float32(i) {
+					t.Errorf("Unexpected read of %s: %+v, %v", id, rec, err)
+				}
+				if err := cache.Set(ctx, id, voyageai.CacheEntry{Value: []byte(id)}); err != nil {
+					t.Error(err.Error())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	n := 0
+	for _, err := range s.All(ctx) {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		n++
+	}
+	if n != 200 {
+		t.Errorf("Expected 200 records, got %d", n)
+	}
+}
+
+func TestStoreFloat16(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t, filepath.Join(t.TempDir(), "store.db"))
+	vec := []float32{0.1, -2.5, 1000.3, 3e-6}
+	if err := s.Upsert(ctx, sqlitestore.Record{ID: "half", Model: "m", DType: "float16", Vector: vec}); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, _, err := s.Get(ctx, "half")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	want := voyageai.FromFloat16Bits(voyageai.ToFloat16Bits(vec))
+	if got.DType != "float16" || !reflect.DeepEqual(got.Vector, want) {
+		t.Errorf("Expected the half-precision vector %v, got %+v", want, got)
+	}
+}
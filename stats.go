@@ -0,0 +1,100 @@
+package voyageai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Returned, wrapping the error of the last attempt, when a request that could be retried is not
+// because the client's retry budget is used up. See [VoyageClientOpts.MaxRetriesPerMinute].
+var ErrRetryBudgetExhausted = errors.New("voyage: retry budget exhausted")
+
+// Counters describing the requests made by a client. See [VoyageClient.Stats].
+type ClientStats struct {
+	Attempts      int64 // The number of HTTP requests sent, including retries.
+	Retries       int64 // The number of attempts that were retries of a failed attempt.
+	RetriesDenied int64 // The number of retries not made because the retry budget was used up.
+	// The number of retries currently available in the retry budget, rounded down. Zero if the
+	// client has no retry budget.
+	RetryBudgetRemaining int
+}
+
+// clientStats holds the counters behind [ClientStats].
+type clientStats struct {
+	attempts, retries, retriesDenied atomic.Int64
+}
+
+// Stats returns counters describing the requests made by the client so far.
+func (c *VoyageClient) Stats() ClientStats {
+	s := ClientStats{
+		Attempts:      c.stats.attempts.Load(),
+		Retries:       c.stats.retries.Load(),
+		RetriesDenied: c.stats.retriesDenied.Load(),
+	}
+	if c.retryBudget != nil {
+		s.RetryBudgetRemaining = int(c.retryBudget.remaining())
+	}
+	return s
+}
+
+// retryBudget is a token bucket of retries shared by every call on a client. It holds up to
+// perMinute tokens and refills continuously at perMinute tokens per minute.
+type retryBudget struct {
+	clock     Clock
+	perMinute float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRetryBudget(clock Clock, perMinute int) *retryBudget {
+	return &retryBudget{clock: clock, perMinute: float64(perMinute), tokens: float64(perMinute), last: clock.Now()}
+}
+
+// refill adds the tokens accrued since the last refill. b.mu must be held.
+func (b *retryBudget) refill() {
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.perMinute, b.tokens+elapsed.Minutes()*b.perMinute)
+	}
+	b.last = now
+}
+
+// allow takes a token if one is available.
+func (b *retryBudget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *retryBudget) remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return b.tokens
+}
+
+// allowRetry reports whether a failed attempt may be retried under the client's retry budget,
+// and counts the retry. err is the error of the failed attempt.
+func (c *VoyageClient) allowRetry(ctx context.Context, err error) error {
+	tenant := tenantID(ctx)
+	if c.retryBudget != nil && !c.retryBudget.allow() {
+		c.stats.retriesDenied.Add(1)
+		c.tenants.addStats(tenant, func(s *ClientStats) { s.RetriesDenied++ })
+		return fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err)
+	}
+	c.stats.retries.Add(1)
+	c.tenants.addStats(tenant, func(s *ClientStats) { s.Retries++ })
+	return nil
+}
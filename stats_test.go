@@ -0,0 +1,64 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestRetryBudget(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int { return 500 }
+	clock := newFakeClock()
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:                 "APIKEY",
+		BaseURL:             srv.URL,
+		MaxRetries:          3,
+		RetryBaseDelay:      -1, // Retry at once, since the fake clock does not move.
+		MaxRetriesPerMinute: 5,
+		Clock:               clock,
+	})
+
+	var wg sync.WaitGroup
+	var exhausted atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.EmbedContext(context.Background(), []string{"x"}, "test-model", nil)
+			if err == nil {
+				t.Error("Expected an error")
+			}
+			if errors.Is(err, voyageai.ErrRetryBudgetExhausted) {
+				exhausted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every call makes its first attempt, and only five retries are allowed between them.
+	if n := srv.requestCount(); n != 25 {
+		t.Errorf("Expected 25 requests, got %d", n)
+	}
+	stats := client.Stats()
+	if stats.Attempts != 25 || stats.Retries != 5 || stats.RetryBudgetRemaining != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if exhausted.Load() < 15 || stats.RetriesDenied != int64(exhausted.Load()) {
+		t.Errorf("Expected at least 15 calls to exhaust the budget, got %d (%d denied)", exhausted.Load(), stats.RetriesDenied)
+	}
+
+	clock.Advance(30 * time.Second)
+	if got := client.Stats().RetryBudgetRemaining; got != 2 {
+		t.Errorf("Expected the budget to refill to 2 after half a minute, got %d", got)
+	}
+	clock.Advance(time.Hour)
+	if got := client.Stats().RetryBudgetRemaining; got != 5 {
+		t.Errorf("Expected the budget to be capped at 5, got %d", got)
+	}
+}
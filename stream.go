@@ -0,0 +1,199 @@
+package voyageai
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+// A text to embed with [VoyageClient.EmbedStream], tagged with a caller-assigned index.
+type IndexedText struct {
+	Index int
+	Text  string
+}
+
+// An embedding produced by [VoyageClient.EmbedStream] for the input with the same Index.
+type EmbedResult struct {
+	Index     int
+	Embedding []float32
+}
+
+// Configuration for [VoyageClient.EmbedStream].
+type StreamConfig struct {
+	BatchSize   int           // The maximum number of texts per request. Defaults to [DefaultBatchSize].
+	MaxInFlight int           // The maximum number of concurrent requests. Defaults to 1.
+	Linger      time.Duration // How long a partial batch may wait for more input before it is sent. Zero means partial batches are only sent when the input channel is closed.
+}
+
+// EmbedStream embeds texts as they arrive on in and delivers their embeddings on the returned
+// results channel as each batch completes.
+//
+// Inputs are accumulated into batches of [StreamConfig.BatchSize] texts, and no more than
+// [StreamConfig.MaxInFlight] requests are outstanding at a time. When that limit is reached,
+// or the consumer stops reading results, EmbedStream stops reading from in, so memory use stays
+// bounded by the batch size and in-flight limit. Results within a batch are delivered in input
+// order but batches may complete out of order; use [EmbedResult.Index] to correlate them.
+//
+// Closing in flushes the remaining inputs and then closes both returned channels. If a request
+// fails or ctx is cancelled, the first error is sent on the error channel, outstanding requests
+// are cancelled, and both channels are closed. The error channel is buffered, so it does not
+// need to be read for the stream to finish.
+func (c *VoyageClient) EmbedStream(ctx context.Context, in <-chan IndexedText, model string, opts *EmbeddingRequestOpts, cfg StreamConfig) (<-chan EmbedResult, <-chan error) {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	maxInFlight := cfg.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+
+	out := make(chan EmbedResult)
+	errc := make(chan error, 1)
+	runCtx, cancel := context.WithCancel(ctx)
+
+	var once sync.Once
+	fail := func(err error) {
+		once.Do(func() {
+			errc <- err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxInFlight)
+	send := func(batch []IndexedText) {
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			texts := make([]string, len(batch))
+			for i, item := range batch {
+				texts[i] = item.Text
+			}
+			resp, err := c.embedContext(runCtx, texts, model, opts)
+			if err != nil {
+				fail(err)
+				return
+			}
+			for _, obj := range resp.Data {
+				if obj.Index < 0 || obj.Index >= len(batch) {
+					continue
+				}
+				select {
+				case out <- EmbedResult{Index: batch[obj.Index].Index, Embedding: obj.Embedding}:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			wg.Wait()
+			if err := ctx.Err(); err != nil {
+				fail(err)
+			}
+			cancel()
+			close(out)
+			close(errc)
+		}()
+
+		var batch []IndexedText
+		var timer *time.Timer
+		var linger <-chan time.Time
+		flush := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, linger = nil, nil
+			}
+			if len(batch) > 0 {
+				send(batch)
+				batch = nil
+			}
+		}
+
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, item)
+				if len(batch) >= batchSize {
+					flush()
+				} else if len(batch) == 1 && cfg.Linger > 0 {
+					timer = time.NewTimer(cfg.Linger)
+					linger = timer.C
+				}
+			case <-linger:
+				flush()
+			case <-runCtx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+
+	return out, errc
+}
+
+// EmbedIterator is like [VoyageClient.EmbedStream] for texts read from an iterator, such as the
+// lines of a file or the rows of a query, indexed by their position in texts. It returns an
+// iterator over the embeddings in the order their batches complete.
+//
+// texts is read no faster than the embeddings are consumed, so memory use stays bounded. If a
+// request fails or ctx is cancelled, the error is yielded once with an empty [EmbedResult] and
+// iteration ends. Stopping the iteration early cancels the requests in flight. Either way, the
+// iteration only ends once texts is no longer being read, so texts may release its resources,
+// such as an open file, as soon as it does.
+func (c *VoyageClient) EmbedIterator(ctx context.Context, texts iter.Seq[string], model string, opts *EmbeddingRequestOpts, cfg StreamConfig) iter.Seq2[EmbedResult, error] {
+	return func(yield func(EmbedResult, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		in := make(chan IndexedText)
+		out, errc := c.EmbedStream(ctx, in, model, opts, cfg)
+		fed := make(chan struct{}) // Closed once texts is no longer read.
+		defer func() {
+			// Stop reading texts and wait for it and the stream to wind down.
+			cancel()
+			for range out {
+			}
+			<-fed
+		}()
+
+		go func() {
+			defer close(fed)
+			defer close(in)
+			i := 0
+			for text := range texts {
+				select {
+				case in <- IndexedText{Index: i, Text: text}:
+				case <-ctx.Done():
+					return
+				}
+				i++
+			}
+		}()
+
+		for res := range out {
+			if !yield(res, nil) {
+				return
+			}
+		}
+		if err := <-errc; err != nil {
+			yield(EmbedResult{}, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package voyageai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestEmbedStreamPreservesIndices(t *testing.T) {
+	srv := newMockServer(t)
+	in := make(chan voyageai.IndexedText)
+	out, errc := srv.client().EmbedStream(context.Background(), in, "test-model", nil, voyageai.StreamConfig{BatchSize: 3, MaxInFlight: 2})
+
+	go func() {
+		for i := range 10 {
+			// Use sparse, caller-assigned indices.
+			in <- voyageai.IndexedText{Index: i * 7, Text: fmt.Sprintf("text %d", i)}
+		}
+		close(in)
+	}()
+
+	seen := 0
+	for res := range out {
+		if !slices.Equal(res.Embedding, fakeVector(fmt.Sprintf("text %d", res.Index/7))) {
+			t.Errorf("Wrong embedding for index %d", res.Index)
+		}
+		seen++
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err.Error())
+	}
+	if seen != 10 {
+		t.Errorf("Expected 10 results, got %d", seen)
+	}
+	if n := srv.requestCount(); n != 4 {
+		t.Errorf("Expected 4 requests, got %d", n)
+	}
+}
+
+func TestEmbedStreamBackpressure(t *testing.T) {
+	srv := newMockServer(t)
+	in := make(chan voyageai.IndexedText)
+	out, errc := srv.client().EmbedStream(context.Background(), in, "test-model", nil, voyageai.StreamConfig{BatchSize: 2, MaxInFlight: 1})
+
+	var produced atomic.Int32
+	go func() {
+		for i := range 20 {
+			in <- voyageai.IndexedText{Index: i, Text: fmt.Sprintf("text %d", i)}
+			produced.Add(1)
+		}
+		close(in)
+	}()
+
+	// Nobody reads the results yet: the first batch blocks delivering its results, which holds
+	// the only in-flight slot, so the stream stops reading input after the next batch fills.
+	time.Sleep(200 * time.Millisecond)
+	if n := srv.requestCount(); n != 1 {
+		t.Errorf("Expected exactly 1 request while results are not consumed, got %d", n)
+	}
+	if p := produced.Load(); p > 5 {
+		t.Errorf("Expected the producer to be blocked, but it sent %d inputs", p)
+	}
+
+	seen := 0
+	for range out {
+		seen++
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err.Error())
+	}
+	if seen != 20 {
+		t.Errorf("Expected 20 results, got %d", seen)
+	}
+}
+
+func TestEmbedStreamLingerFlush(t *testing.T) {
+	srv := newMockServer(t)
+	in := make(chan voyageai.IndexedText)
+	out, _ := srv.client().EmbedStream(context.Background(), in, "test-model", nil, voyageai.StreamConfig{BatchSize: 100, Linger: 20 * time.Millisecond})
+	defer close(in)
+
+	for i := range 3 {
+		in <- voyageai.IndexedText{Index: i, Text: fmt.Sprintf("text %d", i)}
+	}
+
+	for i := range 3 {
+		select {
+		case res := <-out:
+			if res.Index != i {
+				t.Errorf("Expected index %d, got %d", i, res.Index)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Partial batch was not flushed after the linger period")
+		}
+	}
+}
+
+func TestEmbedStreamCancellation(t *testing.T) {
+	srv := newMockServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan voyageai.IndexedText)
+	out, errc := srv.client().EmbedStream(ctx, in, "test-model", nil, voyageai.StreamConfig{BatchSize: 2})
+
+	in <- voyageai.IndexedText{Index: 0, Text: "a"}
+	in <- voyageai.IndexedText{Index: 1, Text: "b"}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Results channel was not closed after cancellation")
+	}
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestEmbedStreamRequestError(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int { return 400 }
+	in := make(chan voyageai.IndexedText, 1)
+	out, errc := srv.client().EmbedStream(context.Background(), in, "test-model", nil, voyageai.StreamConfig{})
+
+	in <- voyageai.IndexedText{Index: 0, Text: "a"}
+	close(in)
+	for range out {
+		t.Error("Expected no results")
+	}
+	if err := <-errc; err == nil {
+		t.Error("Expected the request error to be reported")
+	}
+}
+
+func TestEmbedIterator(t *testing.T) {
+	srv := newMockServer(t)
+	client := srv.client()
+	texts := func(yield func(string) bool) {
+		for i := range 10 {
+			if !yield(fmt.Sprintf("text %d", i)) {
+				return
+			}
+		}
+	}
+
+	seen := make([]bool, 10)
+	for res, err := range client.EmbedIterator(context.Background(), texts, "test-model", nil, voyageai.StreamConfig{BatchSize: 4, MaxInFlight: 2}) {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !slices.Equal(res.Embedding, fakeVector(fmt.Sprintf("text %d", res.Index))) {
+			t.Errorf("Wrong embedding for index %d", res.Index)
+		}
+		seen[res.Index] = true
+	}
+	if slices.Contains(seen, false) {
+		t.Errorf("Expected a result for every text, got %v", seen)
+	}
+	if n := srv.requestCount(); n != 3 {
+		t.Errorf("Expected 3 requests, got %d", n)
+	}
+
+	// Stopping early stops reading texts before the iteration returns.
+	var reading atomic.Bool
+	tracked := func(yield func(string) bool) {
+		reading.Store(true)
+		defer reading.Store(false)
+		texts(yield)
+	}
+	for range client.EmbedIterator(context.Background(), tracked, "test-model", nil, voyageai.StreamConfig{BatchSize: 1}) {
+		break
+	}
+	if reading.Load() {
+		t.Error("Expected texts to be done when the iteration ends")
+	}
+
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int { return 400 }
+	var errs int
+	for res, err := range client.EmbedIterator(context.Background(), texts, "test-model", nil, voyageai.StreamConfig{}) {
+		if err == nil {
+			t.Errorf("Expected no results, got %+v", res)
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("Expected the request error to be yielded once, got %d errors", errs)
+	}
+}
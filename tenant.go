@@ -0,0 +1,100 @@
+package voyageai
+
+import (
+	"context"
+	"sync"
+)
+
+// The tenant ID that usage and stats of calls made without [WithTenant] are attributed to.
+const UnattributedTenant = "unattributed"
+
+// A customer on whose behalf calls are made. See [WithTenant].
+type Tenant struct {
+	ID  string // Identifies the tenant in [VoyageClient.UsageByTenant] and [VoyageClient.StatsByTenant].
+	Key string // The API key used for the tenant's requests. Defaults to the client's key.
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx that makes calls using it authenticate with the tenant's key
+// and attributes their usage and stats to the tenant's ID. Calls without a tenant use the
+// client's key and are attributed to [UnattributedTenant].
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+func tenantFrom(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(Tenant)
+	return t, ok
+}
+
+// tenantID returns the ID that the calls of ctx are attributed to.
+func tenantID(ctx context.Context) string {
+	if t, ok := tenantFrom(ctx); ok && t.ID != "" {
+		return t.ID
+	}
+	return UnattributedTenant
+}
+
+// The usage attributed to one tenant. See [VoyageClient.UsageByTenant].
+type TenantUsage struct {
+	Usage         UsageObject
+	EstimatedCost float64 // The cost in US dollars estimated with [EstimateCost]. Models without a known price are not counted.
+}
+
+// tenantCounters holds the per-tenant breakdown of a client's usage and stats.
+type tenantCounters struct {
+	mu    sync.Mutex
+	usage map[string]TenantUsage
+	stats map[string]ClientStats
+}
+
+func (t *tenantCounters) addUsage(id string, usage UsageObject, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.usage == nil {
+		t.usage = map[string]TenantUsage{}
+	}
+	u := t.usage[id]
+	u.Usage = addUsage(u.Usage, usage)
+	u.EstimatedCost += cost
+	t.usage[id] = u
+}
+
+func (t *tenantCounters) addStats(id string, update func(*ClientStats)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stats == nil {
+		t.stats = map[string]ClientStats{}
+	}
+	s := t.stats[id]
+	update(&s)
+	t.stats[id] = s
+}
+
+// UsageByTenant returns the usage reported by successful requests, keyed by tenant ID. See
+// [WithTenant]. Tenants without any usage are omitted.
+func (c *VoyageClient) UsageByTenant() map[string]TenantUsage {
+	t := c.tenants
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]TenantUsage, len(t.usage))
+	for id, u := range t.usage {
+		u.Usage = addUsage(u.Usage, UsageObject{}) // Copies the pointer fields.
+		out[id] = u
+	}
+	return out
+}
+
+// StatsByTenant returns the request counters of [VoyageClient.Stats], keyed by tenant ID. See
+// [WithTenant]. The retry budget is shared by all tenants, so RetryBudgetRemaining is always zero.
+func (c *VoyageClient) StatsByTenant() map[string]ClientStats {
+	t := c.tenants
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]ClientStats, len(t.stats))
+	for id, s := range t.stats {
+		out[id] = s
+	}
+	return out
+}
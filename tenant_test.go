@@ -0,0 +1,85 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestWithTenant(t *testing.T) {
+	srv := newMockServer(t)
+	// Every input names the tenant whose key must authenticate its request.
+	var mismatches []string
+	var mu sync.Mutex
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var req voyageai.EmbeddingRequest
+		json.Unmarshal(b, &req)
+		want := "BEARER APIKEY"
+		if tenant, _, ok := strings.Cut(req.Input[0], ":"); ok {
+			want = "BEARER key-" + tenant
+		}
+		if got := r.Header.Get("Authorization"); got != want {
+			mu.Lock()
+			mismatches = append(mismatches, fmt.Sprintf("%s sent with %q", req.Input[0], got))
+			mu.Unlock()
+		}
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		srv.handle(w, r)
+	})
+	client := srv.client()
+
+	var wg sync.WaitGroup
+	sent := map[string]int{}
+	for _, id := range []string{"acme", "globex"} {
+		ctx := voyageai.WithTenant(context.Background(), voyageai.Tenant{ID: id, Key: "key-" + id})
+		for i := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				text := id + ":" + strings.Repeat("x", i%3)
+				mu.Lock()
+				sent[id] += len(text)
+				mu.Unlock()
+				if _, err := client.EmbedContext(ctx, []string{text}, "voyage-3", nil); err != nil {
+					t.Error(err.Error())
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if _, err := client.EmbedContext(context.Background(), []string{"anonymous"}, "voyage-3", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("Requests sent with the wrong key: %v", mismatches)
+	}
+
+	usage := client.UsageByTenant()
+	acme, globex := sent["acme"], sent["globex"]
+	if usage["acme"].Usage.TotalTokens != acme || usage["globex"].Usage.TotalTokens != globex {
+		t.Errorf("Expected usage of %d and %d, got %+v", acme, globex, usage)
+	}
+	if usage[voyageai.UnattributedTenant].Usage.TotalTokens != len("anonymous") {
+		t.Errorf("Expected the anonymous call to be unattributed, got %+v", usage)
+	}
+	if usage["acme"].EstimatedCost <= 0 {
+		t.Errorf("Expected an estimated cost, got %+v", usage["acme"])
+	}
+	if total := client.Usage().TotalTokens; total != acme+globex+len("anonymous") {
+		t.Errorf("Expected the client total to include every tenant, got %d", total)
+	}
+
+	stats := client.StatsByTenant()
+	if stats["acme"].Attempts != 20 || stats["globex"].Attempts != 20 || stats[voyageai.UnattributedTenant].Attempts != 1 {
+		t.Errorf("Unexpected per-tenant stats %+v", stats)
+	}
+}
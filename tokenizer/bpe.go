@@ -0,0 +1,369 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A BPE tokenizer read from a Hugging Face tokenizer.json file, as published for Voyage AI's
+// models. It supports byte-level BPE, as used by GPT-2, and SentencePiece-style BPE with a
+// metaspace, together with the normalizers, pre-tokenizers and template post-processors they are
+// commonly combined with. It is safe for concurrent use.
+type BPE struct {
+	vocab        map[string]int
+	ranks        map[[2]string]int // The priority of each merge, lowest first.
+	unk          string
+	byteFallback bool
+
+	normalize   []func(string) string
+	preTokenize []func([]string) []string
+	byteLevel   bool // Whether pieces are mapped to GPT-2's byte alphabet before merging.
+	special     int  // The special tokens the post-processor adds to every text.
+}
+
+// ErrUnsupported is returned, wrapped, by [LoadBPE] for a tokenizer.json that uses a model or
+// component this package does not implement.
+var ErrUnsupported = errors.New("tokenizer: unsupported tokenizer")
+
+type component struct {
+	Type string `json:"type"`
+
+	// Sequence
+	Normalizers   []component `json:"normalizers"`
+	Pretokenizers []component `json:"pretokenizers"`
+	// Prepend, Metaspace
+	Prepend        string `json:"prepend"`
+	Replacement    string `json:"replacement"`
+	PrependScheme  string `json:"prepend_scheme"`
+	AddPrefixSpace *bool  `json:"add_prefix_space"`
+	Split          *bool  `json:"split"`
+	// Replace
+	Pattern struct {
+		String *string `json:"String"`
+	} `json:"pattern"`
+	Content string `json:"content"`
+	// ByteLevel
+	UseRegex *bool `json:"use_regex"`
+	// TemplateProcessing
+	Single []map[string]json.RawMessage `json:"single"`
+	// BPE
+	Vocab        map[string]int    `json:"vocab"`
+	Merges       []json.RawMessage `json:"merges"`
+	UnkToken     *string           `json:"unk_token"`
+	ByteFallback bool              `json:"byte_fallback"`
+}
+
+// LoadBPE reads a tokenizer.json file. It fails with [ErrUnsupported] if the tokenizer is not a
+// BPE tokenizer this package can reproduce.
+func LoadBPE(r io.Reader) (*BPE, error) {
+	var doc struct {
+		Normalizer    *component `json:"normalizer"`
+		PreTokenizer  *component `json:"pre_tokenizer"`
+		PostProcessor *component `json:"post_processor"`
+		Model         component  `json:"model"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("tokenizer: decode: %w", err)
+	}
+	if doc.Model.Type != "BPE" {
+		return nil, fmt.Errorf("%w: model %q", ErrUnsupported, doc.Model.Type)
+	}
+	t := &BPE{vocab: doc.Model.Vocab, ranks: map[[2]string]int{}, byteFallback: doc.Model.ByteFallback}
+	if doc.Model.UnkToken != nil {
+		t.unk = *doc.Model.UnkToken
+	}
+	for i, m := range doc.Model.Merges {
+		var pair []string
+		var s string
+		if json.Unmarshal(m, &s) == nil {
+			pair = strings.SplitN(s, " ", 2)
+		} else if err := json.Unmarshal(m, &pair); err != nil {
+			return nil, fmt.Errorf("tokenizer: merge %d: %w", i, err)
+		}
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("tokenizer: merge %d is not a pair", i)
+		}
+		if _, ok := t.ranks[[2]string{pair[0], pair[1]}]; !ok {
+			t.ranks[[2]string{pair[0], pair[1]}] = i
+		}
+	}
+	if doc.Normalizer != nil {
+		if err := t.addNormalizer(*doc.Normalizer); err != nil {
+			return nil, err
+		}
+	}
+	if doc.PreTokenizer != nil {
+		if err := t.addPreTokenizer(*doc.PreTokenizer); err != nil {
+			return nil, err
+		}
+	}
+	if p := doc.PostProcessor; p != nil {
+		switch p.Type {
+		case "TemplateProcessing":
+			for _, item := range p.Single {
+				if _, ok := item["SpecialToken"]; ok {
+					t.special++
+				}
+			}
+		case "ByteLevel":
+		default:
+			return nil, fmt.Errorf("%w: post-processor %q", ErrUnsupported, p.Type)
+		}
+	}
+	return t, nil
+}
+
+func (t *BPE) addNormalizer(c component) error {
+	switch c.Type {
+	case "Sequence":
+		for _, n := range c.Normalizers {
+			if err := t.addNormalizer(n); err != nil {
+				return err
+			}
+		}
+	case "NFC":
+		t.normalize = append(t.normalize, norm.NFC.String)
+	case "NFKC":
+		t.normalize = append(t.normalize, norm.NFKC.String)
+	case "Lowercase":
+		t.normalize = append(t.normalize, strings.ToLower)
+	case "Prepend":
+		t.normalize = append(t.normalize, func(s string) string {
+			if s == "" {
+				return s
+			}
+			return c.Prepend + s
+		})
+	case "Replace":
+		if c.Pattern.String == nil {
+			return fmt.Errorf("%w: regular expression Replace normalizer", ErrUnsupported)
+		}
+		old := *c.Pattern.String
+		t.normalize = append(t.normalize, func(s string) string { return strings.ReplaceAll(s, old, c.Content) })
+	default:
+		return fmt.Errorf("%w: normalizer %q", ErrUnsupported, c.Type)
+	}
+	return nil
+}
+
+func (t *BPE) addPreTokenizer(c component) error {
+	switch c.Type {
+	case "Sequence":
+		for _, p := range c.Pretokenizers {
+			if err := t.addPreTokenizer(p); err != nil {
+				return err
+			}
+		}
+	case "ByteLevel":
+		t.byteLevel = true
+		prefix := c.AddPrefixSpace != nil && *c.AddPrefixSpace
+		split := c.UseRegex == nil || *c.UseRegex
+		t.preTokenize = append(t.preTokenize, func(pieces []string) []string {
+			var out []string
+			for i, p := range pieces {
+				if prefix && i == 0 && !strings.HasPrefix(p, " ") {
+					p = " " + p
+				}
+				if split {
+					out = append(out, splitGPT2(p)...)
+				} else {
+					out = append(out, p)
+				}
+			}
+			return out
+		})
+	case "Metaspace":
+		repl := c.Replacement
+		if repl == "" {
+			repl = "▁"
+		}
+		scheme := c.PrependScheme
+		if scheme == "" {
+			scheme = "always"
+			if c.AddPrefixSpace != nil && !*c.AddPrefixSpace {
+				scheme = "never"
+			}
+		}
+		split := c.Split == nil || *c.Split
+		t.preTokenize = append(t.preTokenize, func(pieces []string) []string {
+			var out []string
+			for i, p := range pieces {
+				p = strings.ReplaceAll(p, " ", repl)
+				if (scheme == "always" || scheme == "first" && i == 0) && !strings.HasPrefix(p, repl) {
+					p = repl + p
+				}
+				if !split {
+					out = append(out, p)
+					continue
+				}
+				for p != "" {
+					// Every replacement character starts a new piece.
+					k := strings.Index(p[1:], repl)
+					if k < 0 {
+						out = append(out, p)
+						break
+					}
+					out = append(out, p[:k+1])
+					p = p[k+1:]
+				}
+			}
+			return out
+		})
+	case "WhitespaceSplit":
+		t.preTokenize = append(t.preTokenize, func(pieces []string) []string {
+			var out []string
+			for _, p := range pieces {
+				out = append(out, strings.Fields(p)...)
+			}
+			return out
+		})
+	default:
+		return fmt.Errorf("%w: pre-tokenizer %q", ErrUnsupported, c.Type)
+	}
+	return nil
+}
+
+// Count returns the number of tokens text is encoded as, including the special tokens the
+// tokenizer adds to every text.
+func (t *BPE) Count(text string) int {
+	for _, n := range t.normalize {
+		text = n(text)
+	}
+	pieces := []string{text}
+	for _, p := range t.preTokenize {
+		pieces = p(pieces)
+	}
+	n := t.special
+	for _, p := range pieces {
+		if p == "" {
+			continue
+		}
+		for _, sym := range t.merge(t.symbols(p)) {
+			if _, ok := t.vocab[sym]; ok {
+				n++
+			} else if t.byteFallback {
+				n += len(sym)
+			} else if t.unk != "" {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// symbols splits a piece into the initial symbols merging starts from: its characters, after
+// mapping its bytes to GPT-2's byte alphabet for byte-level tokenizers.
+func (t *BPE) symbols(p string) []string {
+	if t.byteLevel {
+		syms := make([]string, len(p))
+		for i := 0; i < len(p); i++ {
+			syms[i] = byteAlphabet[p[i]]
+		}
+		return syms
+	}
+	syms := make([]string, 0, utf8.RuneCountInString(p))
+	for _, r := range p {
+		syms = append(syms, string(r))
+	}
+	return syms
+}
+
+// merge applies the merges to syms, always the one of lowest rank first, until none apply.
+func (t *BPE) merge(syms []string) []string {
+	for len(syms) > 1 {
+		best, at := -1, -1
+		for i := 0; i+1 < len(syms); i++ {
+			if r, ok := t.ranks[[2]string{syms[i], syms[i+1]}]; ok && (best < 0 || r < best) {
+				best, at = r, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		syms[at] += syms[at+1]
+		syms = append(syms[:at+1], syms[at+2:]...)
+	}
+	return syms
+}
+
+// byteAlphabet maps every byte to the printable character GPT-2's byte-level BPE represents it
+// with: printable Latin-1 characters stand for themselves, the rest are shifted past 255.
+var byteAlphabet = func() [256]string {
+	var a [256]string
+	shift := 0
+	for b := 0; b < 256; b++ {
+		if b >= '!' && b <= '~' || b >= 0xA1 && b <= 0xAC || b >= 0xAE {
+			a[b] = string(rune(b))
+		} else {
+			a[b] = string(rune(256 + shift))
+			shift++
+		}
+	}
+	return a
+}()
+
+// splitGPT2 splits s as GPT-2's pre-tokenization pattern does:
+//
+//	's|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+
+func splitGPT2(s string) []string {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r):
+			return 1
+		case unicode.IsNumber(r):
+			return 2
+		case unicode.IsSpace(r):
+			return 3
+		}
+		return 4
+	}
+	var out []string
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		if rest[0] == '\'' {
+			matched := false
+			for _, c := range []string{"'s", "'t", "'re", "'ve", "'m", "'ll", "'d"} {
+				if strings.HasPrefix(rest, c) {
+					out = append(out, c)
+					i += len(c)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		start := i
+		r, size := utf8.DecodeRuneInString(rest)
+		if r == ' ' && len(rest) > 1 {
+			if next, _ := utf8.DecodeRuneInString(rest[1:]); class(next) != 3 {
+				i++
+				r, size = next, utf8.RuneLen(next)
+			}
+		}
+		c := class(r)
+		i += size
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if class(r) != c {
+				break
+			}
+			i += size
+		}
+		if c == 3 && i < len(s) && i-start > 1 {
+			// Leave the last whitespace character for the next token, as (?!\S) does.
+			_, last := utf8.DecodeLastRuneInString(s[start:i])
+			i -= last
+		}
+		out = append(out, s[start:i])
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package tokenizer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai/tokenizer"
+)
+
+// A byte-level BPE tokenizer knowing "hello" and " world" as single tokens, which adds a start
+// and an end token to every text.
+const byteLevelJSON = `{
+	"normalizer": {"type": "NFC"},
+	"pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+	"post_processor": {"type": "TemplateProcessing", "single": [
+		{"SpecialToken": {"id": "<s>", "type_id": 0}},
+		{"Sequence": {"id": "A", "type_id": 0}},
+		{"SpecialToken": {"id": "</s>", "type_id": 0}}
+	]},
+	"model": {
+		"type": "BPE",
+		"unk_token": "<unk>",
+		"vocab": {"<unk>": 0, "h": 1, "e": 2, "l": 3, "o": 4, "w": 5, "r": 6, "d": 7, "Ġ": 8, "!": 9, "Ċ": 10,
+			"he": 11, "ll": 12, "hell": 13, "hello": 14, "Ġw": 15, "or": 16, "Ġwor": 17, "ld": 18, "Ġworld": 19},
+		"merges": ["h e", "l l", "he ll", "hell o", "Ġ w", "o r", "Ġw or", "l d", ["Ġwor", "ld"]]
+	}
+}`
+
+// A SentencePiece-style BPE tokenizer with byte fallback, knowing "▁hi" as a single token.
+const metaspaceJSON = `{
+	"pre_tokenizer": {"type": "Metaspace", "replacement": "▁", "prepend_scheme": "always"},
+	"model": {
+		"type": "BPE",
+		"byte_fallback": true,
+		"vocab": {"▁": 0, "h": 1, "i": 2, "▁h": 3, "▁hi": 4},
+		"merges": ["▁ h", "▁h i"]
+	}
+}`
+
+func TestBPEByteLevel(t *testing.T) {
+	bpe, err := tokenizer.LoadBPE(strings.NewReader(byteLevelJSON))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 2},
+		{"hello", 3},
+		{"hello world", 4},
+		{"hello  world!", 6}, // "hello", " ", " world", "!"
+		{"hello\nworld", 7},  // "hello", "\n", "w", "or", "ld"
+		{"hello's", 5},       // "hello", and "'s" as two unknown tokens
+	}
+	for _, tt := range tests {
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("%q: expected %d tokens, got %d", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestBPEMetaspace(t *testing.T) {
+	bpe, err := tokenizer.LoadBPE(strings.NewReader(metaspaceJSON))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hi", 1},
+		{"hi hi", 2},
+		{"hi é", 4}, // "▁hi", "▁", and the two bytes of "é"
+	}
+	for _, tt := range tests {
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("%q: expected %d tokens, got %d", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestLoadBPEUnsupported(t *testing.T) {
+	for _, doc := range []string{
+		`{"model": {"type": "WordPiece"}}`,
+		`{"pre_tokenizer": {"type": "Split"}, "model": {"type": "BPE"}}`,
+		`{"normalizer": {"type": "Replace", "pattern": {"Regex": "\\s+"}}, "model": {"type": "BPE"}}`,
+	} {
+		if _, err := tokenizer.LoadBPE(strings.NewReader(doc)); !errors.Is(err, tokenizer.ErrUnsupported) {
+			t.Errorf("%s: expected ErrUnsupported, got %v", doc, err)
+		}
+	}
+}
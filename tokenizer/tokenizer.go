@@ -0,0 +1,137 @@
+// Package tokenizer counts tokens locally as Voyage AI's models do, so inputs can be checked
+// against batch and context limits, and costs estimated, before calling the API.
+//
+// Voyage AI publishes the tokenizer of each model on Hugging Face as a tokenizer.json file; see
+// [HuggingFaceURL]. The files are not bundled with this package, so load the ones you need into a
+// [Registry], which counts with them and falls back to [voyageai.EstimateTokens] for other
+// models. A Registry is a [voyageai.Tokenizer], so it can also replace the estimate in the
+// client's own checks:
+//
+//	tokenizer.Default.LoadFile("voyage-3.5.json", voyageai.ModelVoyage35)
+//	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Tokenizer: tokenizer.Default})
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/zamedic/voyageai"
+)
+
+// Returned, wrapped, by a [Registry.Strict] registry for a model without a loaded tokenizer.
+var ErrNoTokenizer = errors.New("tokenizer: no tokenizer loaded for model")
+
+// HuggingFaceURL returns the URL of the tokenizer.json file Voyage AI publishes for model.
+func HuggingFaceURL(model string) string {
+	return "https://huggingface.co/voyageai/" + model + "/resolve/main/tokenizer.json"
+}
+
+// The tokenizers of a set of models. The zero value has none, and is ready to use. It is safe
+// for concurrent use.
+type Registry struct {
+	// Fail with [ErrNoTokenizer] for models without a loaded tokenizer, rather than estimating
+	// their tokens with [voyageai.EstimateTokens].
+	Strict bool
+
+	mu     sync.RWMutex
+	models map[string]*BPE
+}
+
+// The registry used by the package-level functions.
+var Default = &Registry{}
+
+// Register sets the tokenizer of models, such as the models of a family that share one.
+func (r *Registry) Register(t *BPE, models ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.models == nil {
+		r.models = map[string]*BPE{}
+	}
+	for _, m := range models {
+		r.models[m] = t
+	}
+}
+
+// Load reads a tokenizer.json file with [LoadBPE] and registers it for models.
+func (r *Registry) Load(rd io.Reader, models ...string) error {
+	t, err := LoadBPE(rd)
+	if err != nil {
+		return err
+	}
+	r.Register(t, models...)
+	return nil
+}
+
+// LoadFile is like [Registry.Load] for the file at path.
+func (r *Registry) LoadFile(path string, models ...string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := r.Load(f, models...); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (r *Registry) lookup(model string) (*BPE, error) {
+	r.mu.RLock()
+	t := r.models[model]
+	r.mu.RUnlock()
+	if t == nil && r.Strict {
+		return nil, fmt.Errorf("%w %q", ErrNoTokenizer, model)
+	}
+	return t, nil
+}
+
+// CountTokens returns the number of tokens in text for model, implementing [voyageai.Tokenizer].
+func (r *Registry) CountTokens(model string, text string) (int, error) {
+	return r.CountTexts(model, []string{text})
+}
+
+// CountTexts returns the total number of tokens in texts for model, as an embedding request
+// with them as inputs uses.
+func (r *Registry) CountTexts(model string, texts []string) (int, error) {
+	t, err := r.lookup(model)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, text := range texts {
+		if t != nil {
+			total += t.Count(text)
+		} else {
+			total += voyageai.EstimateTokens(text)
+		}
+	}
+	return total, nil
+}
+
+// CountRerank returns the number of tokens a rerank request of query and documents uses with
+// model, which counts the query once for every document.
+func (r *Registry) CountRerank(model string, query string, documents []string) (int, error) {
+	q, err := r.CountTexts(model, []string{query})
+	if err != nil {
+		return 0, err
+	}
+	docs, err := r.CountTexts(model, documents)
+	if err != nil {
+		return 0, err
+	}
+	return q*len(documents) + docs, nil
+}
+
+// CountTokens returns the total number of tokens in texts for model with the [Default] registry.
+func CountTokens(model string, texts []string) (int, error) {
+	return Default.CountTexts(model, texts)
+}
+
+// CountTokensForRerank returns the number of tokens of a rerank request with the [Default]
+// registry. See [Registry.CountRerank].
+func CountTokensForRerank(model string, query string, documents []string) (int, error) {
+	return Default.CountRerank(model, query, documents)
+}
@@ -0,0 +1,60 @@
+package tokenizer_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+	"github.com/zamedic/voyageai/tokenizer"
+)
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(path, []byte(byteLevelJSON), 0o644); err != nil {
+		t.Fatal(err.Error())
+	}
+	r := &tokenizer.Registry{}
+	if err := r.LoadFile(path, "voyage-a", "voyage-b"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, model := range []string{"voyage-a", "voyage-b"} {
+		if n, err := r.CountTexts(model, []string{"hello", "hello world"}); err != nil || n != 7 {
+			t.Errorf("%s: expected 7 tokens, got %d, %v", model, n, err)
+		}
+	}
+	if n, err := r.CountRerank("voyage-a", "hello", []string{"hello world", "hello"}); err != nil || n != 2*3+4+3 {
+		t.Errorf("Expected the query to count once per document, got %d, %v", n, err)
+	}
+
+	text := "an unregistered model"
+	if n, err := r.CountTokens("other", text); err != nil || n != voyageai.EstimateTokens(text) {
+		t.Errorf("Expected the estimate for other models, got %d, %v", n, err)
+	}
+	r.Strict = true
+	if _, err := r.CountTokens("other", text); !errors.Is(err, tokenizer.ErrNoTokenizer) {
+		t.Errorf("Expected ErrNoTokenizer, got %v", err)
+	}
+
+	if err := r.Load(strings.NewReader(`{"model": {"type": "Unigram"}}`), "voyage-a"); err == nil {
+		t.Error("Expected an unsupported tokenizer to fail")
+	}
+	if n, _ := r.CountTokens("voyage-a", "hello"); n != 3 {
+		t.Errorf("Expected a failed load to keep the registered tokenizer, got %d tokens", n)
+	}
+}
+
+func TestRegistryAsClientTokenizer(t *testing.T) {
+	r := &tokenizer.Registry{}
+	if err := r.Load(strings.NewReader(byteLevelJSON), voyageai.ModelVoyage35); err != nil {
+		t.Fatal(err.Error())
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", Tokenizer: r, MaxTokensPerRequest: 3})
+	_, err := client.Embed([]string{"hello world"}, voyageai.ModelVoyage35, nil)
+	if !errors.Is(err, voyageai.ErrBudgetExceeded) {
+		t.Errorf("Expected the request's 4 tokens to exceed the limit of 3, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package voyageai
+
+import "unicode/utf8"
+
+// EstimateTokens returns a rough estimate of the number of tokens in text without calling the API.
+// ASCII characters are counted as a quarter of a token each and all other characters as a whole
+// token, which errs on the side of overestimating for most languages.
+func EstimateTokens(text string) int {
+	return (tokenQuarters(text) + 3) / 4
+}
+
+// tokenQuarters returns the estimated token count of text in quarter tokens.
+func tokenQuarters(text string) int {
+	q := 0
+	for _, r := range text {
+		q += runeQuarters(r)
+	}
+	return q
+}
+
+func runeQuarters(r rune) int {
+	if r < utf8.RuneSelf {
+		return 1
+	}
+	return 4
+}
+
+// Counts the tokens in a text as a given model's tokenizer would.
+// Configure one with [VoyageClientOpts.Tokenizer] to replace the [EstimateTokens] heuristic in
+// client-side checks.
+type Tokenizer interface {
+	CountTokens(model string, text string) (int, error)
+}
+
+// The tokenizer used when none is configured. It is approximate, so callers that enforce limits
+// with it leave a safety margin (see heuristicMargin).
+type heuristicTokenizer struct{}
+
+func (heuristicTokenizer) CountTokens(model string, text string) (int, error) {
+	return EstimateTokens(text), nil
+}
+
+// heuristicMargin is the percentage of a limit that is usable when counting tokens with the
+// heuristic tokenizer.
+const heuristicMargin = 90
+
+// tokenizer returns the configured tokenizer and the percentage of a token limit that may be used
+// with it.
+func (c *VoyageClient) tokenizer() (Tokenizer, int) {
+	if c.opts.Tokenizer != nil {
+		return c.opts.Tokenizer, 100
+	}
+	return heuristicTokenizer{}, heuristicMargin
+}
@@ -0,0 +1,86 @@
+package voyageai_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zamedic/voyageai"
+)
+
+type traceKey struct{}
+
+func TestTraceInjector(t *testing.T) {
+	srv := newMockServer(t)
+	srv.fail = func(n int, req voyageai.EmbeddingRequest) int {
+		if n < 3 {
+			return 500
+		}
+		return 0
+	}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:            "APIKEY",
+		BaseURL:        srv.URL,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		TraceInjector: func(ctx context.Context, h http.Header) {
+			if tp, ok := ctx.Value(traceKey{}).(string); ok {
+				h.Set("traceparent", tp)
+				h.Set("tracestate", "vendor=1")
+			}
+		},
+	})
+
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := context.WithValue(context.Background(), traceKey{}, tp)
+	if _, err := client.EmbedContext(ctx, []string{"x"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	headers := srv.requestHeaders()
+	if len(headers) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(headers))
+	}
+	for i, h := range headers {
+		if h.Get("traceparent") != tp || h.Get("tracestate") != "vendor=1" {
+			t.Errorf("Attempt %d: missing trace headers: %v", i+1, h)
+		}
+	}
+}
+
+// recordingTracer is a voyageai.RequestTracer that tags the request context and keeps the
+// measurements of every ended request.
+type recordingTracer struct {
+	ended []voyageai.RequestMetrics
+}
+
+func (r *recordingTracer) StartRequest(ctx context.Context, endpoint, model string) (context.Context, func(voyageai.RequestMetrics)) {
+	ctx = context.WithValue(ctx, traceKey{}, endpoint+" "+model)
+	return ctx, func(m voyageai.RequestMetrics) { r.ended = append(r.ended, m) }
+}
+
+func TestRequestTracer(t *testing.T) {
+	srv := newMockServer(t)
+	tracer := &recordingTracer{}
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key:     "APIKEY",
+		BaseURL: srv.URL,
+		Tracer:  tracer,
+		TraceInjector: func(ctx context.Context, h http.Header) {
+			if span, ok := ctx.Value(traceKey{}).(string); ok {
+				h.Set("X-Span", span)
+			}
+		},
+	})
+
+	if _, err := client.Embed([]string{"a", "b"}, "test-model", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := srv.requestHeaders()[0].Get("X-Span"); got != "embeddings test-model" {
+		t.Errorf("Expected the tracer's context to be used for the request, got %q", got)
+	}
+	if len(tracer.ended) != 1 || tracer.ended[0].Inputs != 2 || tracer.ended[0].StatusCode != 200 || tracer.ended[0].Attempts != 1 {
+		t.Errorf("Expected the request to end with its measurements, got %+v", tracer.ended)
+	}
+}
@@ -0,0 +1,124 @@
+package voyageai
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TruncateToContext shortens text to fit within the context length of model, as counted by the
+// client's [Tokenizer]. The text is cut at the last sentence boundary that fits, or the last
+// whitespace if no sentence boundary is close enough, and trailing whitespace is removed. Texts
+// that already fit are returned unchanged with truncated set to false.
+//
+// Without a configured tokenizer the token count is estimated, so only 90% of the context length
+// is used as a safety margin.
+func (c *VoyageClient) TruncateToContext(text string, model string) (string, bool, error) {
+	model, err := c.ResolveModel(model)
+	if err != nil {
+		return "", false, err
+	}
+	info, ok := c.lookupModel(model)
+	if !ok {
+		return "", false, fmt.Errorf("voyage: unknown context length for model %q", model)
+	}
+	tok, usable := c.tokenizer()
+	limit := info.ContextLength * usable / 100
+
+	count := func(s string) (int, error) {
+		n, err := tok.CountTokens(model, s)
+		if err != nil {
+			return 0, fmt.Errorf("voyage: count tokens: %w", err)
+		}
+		return n, nil
+	}
+	return truncateTokens(text, limit, count)
+}
+
+// truncateTokens shortens text to at most limit tokens as counted by count, cutting it as
+// [VoyageClient.TruncateToContext] does.
+func truncateTokens(text string, limit int, count func(string) (int, error)) (string, bool, error) {
+	n, err := count(text)
+	if err != nil {
+		return "", false, err
+	}
+	if n <= limit {
+		return text, false, nil
+	}
+
+	// Find the longest prefix that fits, cutting on rune boundaries. text[:lo] fits, and hi is
+	// the longest prefix that may; both are rune boundaries, so lo < mid <= hi.
+	lo, hi := 0, len(text)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		for mid < hi && !utf8.RuneStart(text[mid]) {
+			mid++
+		}
+		n, err := count(text[:mid])
+		if err != nil {
+			return "", false, err
+		}
+		if n <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+			for hi > lo && !utf8.RuneStart(text[hi]) {
+				hi--
+			}
+		}
+	}
+
+	cut := cutBoundary(text[:lo], lo < len(text) && isSpaceByte(text[lo]))
+	return strings.TrimRightFunc(text[:cut], unicode.IsSpace), true, nil
+}
+
+// cutBoundary returns the length of the longest prefix of s that ends at a sentence boundary, as
+// long as that keeps at least half of s. Otherwise it falls back to the last whitespace and then
+// to the whole of s. spaceAfter reports whether s is followed by whitespace in the source text.
+func cutBoundary(s string, spaceAfter bool) int {
+	isSentenceEnd := func(b byte) bool { return strings.IndexByte(".!?\n", b) >= 0 }
+	if spaceAfter && len(s) > 0 && isSentenceEnd(s[len(s)-1]) {
+		return len(s)
+	}
+
+	sentence := -1
+	for i := len(s) - 1; i > 0; i-- {
+		if isSpaceByte(s[i]) && isSentenceEnd(s[i-1]) {
+			sentence = i
+			break
+		}
+	}
+	if sentence >= len(s)/2 {
+		return sentence
+	}
+	if spaceAfter {
+		return len(s)
+	}
+	if space := strings.LastIndexFunc(s, unicode.IsSpace); space > 0 {
+		return space
+	}
+	return len(s)
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// truncateInputs applies [VoyageClient.TruncateToContext] to every text, returning the resulting
+// texts and the indices of those that were shortened. texts is not modified.
+func (c *VoyageClient) truncateInputs(texts []string, model string) ([]string, []int, error) {
+	out := make([]string, len(texts))
+	var truncated []int
+	for i, text := range texts {
+		t, cut, err := c.TruncateToContext(text, model)
+		if err != nil {
+			return nil, nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		out[i] = t
+		if cut {
+			truncated = append(truncated, i)
+		}
+	}
+	return out, truncated, nil
+}
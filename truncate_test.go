@@ -0,0 +1,108 @@
+package voyageai_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// wordTokenizer counts whitespace-separated words.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(model, text string) (int, error) {
+	return len(strings.Fields(text)), nil
+}
+
+func newTokenizerClient(url string) *voyageai.VoyageClient {
+	return voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: url, Tokenizer: wordTokenizer{}})
+}
+
+func TestTruncateToContextSentenceBoundary(t *testing.T) {
+	cl := newTokenizerClient("")
+	// rerank-2-lite has a context length of 8000 tokens.
+	sentence := strings.Repeat("word ", 999) + "end. "
+	text := strings.Repeat(sentence, 9)
+
+	got, truncated, err := cl.TruncateToContext(text, voyageai.ModelRerank2Lite)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !truncated {
+		t.Fatal("Expected the text to be truncated")
+	}
+	if want := strings.TrimSpace(strings.Repeat(sentence, 8)); got != want {
+		t.Errorf("Expected a cut after the 8th sentence, got %d words ending in %q", len(strings.Fields(got)), got[len(got)-10:])
+	}
+}
+
+func TestTruncateToContextWhitespaceBoundary(t *testing.T) {
+	cl := newTokenizerClient("")
+	// A single sentence: the cut has to fall between words.
+	text := "Intro. " + strings.Repeat("lorem ", 9000)
+
+	got, truncated, err := cl.TruncateToContext(text, voyageai.ModelRerank2Lite)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !truncated || len(strings.Fields(got)) != 8000 || !strings.HasSuffix(got, "lorem") {
+		t.Errorf("Expected 8000 whole words, got %d (truncated=%v)", len(strings.Fields(got)), truncated)
+	}
+}
+
+func TestTruncateToContextMultibyte(t *testing.T) {
+	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", Tokenizer: byteTokenizer{}})
+	// rerank-2-lite has a context length of 8000 tokens, which the four byte emoji ends exactly.
+	prefix := strings.Repeat("x", 7996) + "😀"
+	for _, text := range []string{prefix + "yyyy", prefix + "😀😀", "é" + prefix[2:] + "ab"} {
+		got, truncated, err := cl.TruncateToContext(text, voyageai.ModelRerank2Lite)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if want := text[:8000]; !truncated || got != want {
+			t.Errorf("Expected the longest prefix of 8000 bytes, got %d bytes ending in %q", len(got), got[max(len(got)-8, 0):])
+		}
+	}
+}
+
+func TestTruncateToContextHeuristicMargin(t *testing.T) {
+	cl := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY"})
+	text := strings.Repeat("abcd ", 10000)
+
+	got, truncated, err := cl.TruncateToContext(text, voyageai.ModelRerank2Lite)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !truncated || voyageai.EstimateTokens(got) > 7200 {
+		t.Errorf("Expected the estimate to stay within 90%% of the limit, got %d", voyageai.EstimateTokens(got))
+	}
+
+	if _, _, err := cl.TruncateToContext("text", "unknown-model"); err == nil {
+		t.Error("Expected an error for an unknown model")
+	}
+}
+
+func TestEmbedTruncateToContext(t *testing.T) {
+	srv := newMockServer(t)
+	cl := newTokenizerClient(srv.URL)
+
+	short := "  untouched\ttext with odd   spacing  "
+	long := strings.Repeat("word ", 20000)
+	resp, err := cl.EmbedContext(context.Background(), []string{short, long, short}, voyageai.ModelVoyageLaw2, &voyageai.EmbeddingRequestOpts{TruncateToContext: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !slices.Equal(resp.Truncated, []int{1}) {
+		t.Errorf("Expected only input 1 to be truncated, got %v", resp.Truncated)
+	}
+
+	sent := srv.inputs()
+	if sent[0] != short || sent[2] != short {
+		t.Error("Expected untouched inputs to be sent byte-identical")
+	}
+	if n := len(strings.Fields(sent[1])); n != 16000 {
+		t.Errorf("Expected the long input to be cut to 16000 tokens, got %d", n)
+	}
+}
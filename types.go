@@ -3,12 +3,17 @@ package voyageai
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"image"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"net/http"
+	"time"
+
+	"github.com/zamedic/voyageai/resilience"
 )
 
 // A list of models supported by the Voyage AI API.
@@ -24,6 +29,7 @@ const (
 	ModelVoyageCode3       Model = "voyage-code-3"
 	ModelVoyageFinance2    Model = "voyage-finance-2"
 	ModelVoyageLaw2        Model = "voyage-law-2"
+	ModelVoyageContext3    Model = "voyage-context-3"
 	ModelRerank2           Model = "rerank-2"
 	ModelRerank2Lite       Model = "rerank-2-lite"
 )
@@ -58,6 +64,8 @@ type EmbeddingRequest struct {
 	// The data type for the embeddings to be returned. Defaults to float.
 	OutputDType    *string `json:"output_dtype,omitempty"`
 	EncodingFormat *string `json:"encoding_format,omitempty"`
+	// The JSON names of optional fields sent as null when unset, rather than left out.
+	SendNull []string `json:"-"`
 }
 
 // Additional request options that can be passed to [VoyageClient.Embed]
@@ -65,15 +73,30 @@ type EmbeddingRequestOpts struct {
 	InputType       *string `json:"input_type,omitempty"`       // Type of the input text. Defaults to null. Other options: query, document.
 	Truncation      *bool   `json:"truncation,omitempty"`       // Whether to truncate the input texts to fit within the context length. Defaults to true.
 	OutputDimension *int    `json:"output_dimension,omitempty"` // The number of dimensions for resulting output embeddings. Defaults to null.
-	OutputDType     *string `json:"output_dtype,omitempty"`     // The data type for the embeddings to be returned. Defaults to float.
-	EncodingFormat  *string `json:"encoding_format,omitempty"`  // Format in which the embeddings are encoded. Defaults to null. Other options: base64.
+	OutputDType     *string `json:"output_dtype,omitempty"`     // The data type for the embeddings to be returned, such as [DTypeInt8]. Defaults to float.
+	// Format in which the embeddings are encoded. Defaults to null. Other options: [EncodingBase64],
+	// whose embeddings the client decodes as OutputDType into [EmbeddingObject.Embedding].
+	EncodingFormat *string `json:"encoding_format,omitempty"`
+	// The JSON names of optional fields sent as an explicit null when unset, such as
+	// "input_type", rather than left out. Unset fields are left out by default.
+	SendNull []string `json:"-"`
+
+	// Shorten inputs client-side with [VoyageClient.TruncateToContext] before sending them.
+	// The indices of shortened inputs are reported in [EmbeddingResponse.Truncated].
+	TruncateToContext bool `json:"-"`
 }
 
 // An embedding object. Part of the data returned by the /embed endpoint
 type EmbeddingObject struct {
-	Object    string    `json:"object"`    // The object type, which is always "embedding".
-	Embedding []float32 `json:"embedding"` // An array of embedding objects.
-	Index     int       `json:"index"`     // An integer representing the index of the embedding within the list of embeddings.
+	Object string `json:"object"` // The object type, which is always "embedding".
+	// The embedding. Integer data types are held as their values, and binary and ubinary
+	// embeddings as their packed bytes; see [EmbeddingObject.UnpackBits].
+	Embedding []float32 `json:"embedding"`
+	Index     int       `json:"index"` // An integer representing the index of the embedding within the list of embeddings.
+	// Fields of the object this client does not know. See [VoyageClientOpts.PreserveUnknownFields].
+	Extra map[string]json.RawMessage `json:"-"`
+
+	packed []byte // The decoded bytes of an embedding sent as base64, nil otherwise.
 }
 
 // Contains details about system usage.
@@ -81,6 +104,8 @@ type UsageObject struct {
 	TotalTokens int  `json:"total_tokens"`           // The total number of tokens used for computing the embeddings.
 	ImagePixels *int `json:"image_pixels,omitempty"` // The total number of image pixels in the list of inputs.
 	TextTokens  *int `json:"text_tokens,omitempty"`  // The total number of text tokens in the list of inputs.
+	// Fields of the object this client does not know. See [VoyageClientOpts.PreserveUnknownFields].
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // The response from the /embed and /multimodalembed endpoints
@@ -89,6 +114,32 @@ type EmbeddingResponse struct {
 	Data   []EmbeddingObject `json:"data"`   // An array of embedding objects.
 	Model  string            `json:"model"`  // Name of the model.
 	Usage  UsageObject       `json:"usage"`  // An object containing usage details
+
+	// The indices of inputs that were shortened client-side. See [EmbeddingRequestOpts.TruncateToContext].
+	Truncated []int `json:"-"`
+	// The inputs that were altered client-side. See [VoyageClientOpts.Sanitize].
+	Sanitized []SanitizedInput `json:"-"`
+	// Details about how the client produced the response.
+	Metadata ResponseMetadata `json:"-"`
+	// Fields of the response this client does not know. See [VoyageClientOpts.PreserveUnknownFields].
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Details about how the client produced a response, as opposed to the fields returned by the API.
+type ResponseMetadata struct {
+	RequestedModel string // The model the call asked for.
+	ServedModel    string // The model that served the request.
+	Fallback       bool   // Whether ServedModel is a fallback. See [VoyageClientOpts.Fallbacks].
+	// Whether some results are expired cache entries, served because the API call failed. See
+	// [CacheOpts.StaleIfError].
+	Stale bool
+	// Whether the results are a fallback ordering rather than the model's, because the API call
+	// failed. See [CandidateRerankOpts.FallbackToScores].
+	Degraded bool
+	// The HTTP response the results were read from. Nil if no request was sent, such as for
+	// cache hits, and for responses combined from several requests, such as those of
+	// [VoyageClient.EmbedBatch].
+	HTTP *HTTPResponse
 }
 
 type text string
@@ -133,6 +184,7 @@ func imageToBytes(img image.Image, format string) ([]byte, error) {
 }
 
 // Reads all image data from an io.Reader and converts it to a base64 encoded data URL for use with [MultimodalInput].
+// The image is decoded and re-encoded in its own format; use [GetBase64Raw] to send the original bytes.
 func GetBase64(img io.Reader) (imageBase64, error) {
 	dimg, format, err := image.Decode(img)
 	if err != nil {
@@ -170,10 +222,12 @@ type MultimodalInput struct {
 	// Currently supported mediatypes are: image/png, image/jpeg, image/webp, and image/gif.
 	ImageBase64 imageBase64 `json:"image_base64,omitempty"`
 	ImageURL    imageURL    `json:"image_url,omitempty"`
+	// An image_base64 image encoded as the request is sent, in place of ImageBase64. See [ImageStream].
+	ImageStream *ImageStream `json:"-"`
 }
 
 // Multimodal returns a new MultimodalInput.
-// v must be of type text, imageBase64, or imageURL.
+// v must be of type text, imageBase64, imageURL or *[ImageStream].
 // An empty [MultimodalInput] will be returned for all other types.
 func Multimodal(v any) MultimodalInput {
 	switch v := v.(type) {
@@ -192,6 +246,11 @@ func Multimodal(v any) MultimodalInput {
 			Type:     "image_url",
 			ImageURL: v,
 		}
+	case *ImageStream:
+		return MultimodalInput{
+			Type:        "image_base64",
+			ImageStream: v,
+		}
 	default:
 		return MultimodalInput{}
 	}
@@ -212,28 +271,71 @@ type MultimodalRequest struct {
 	InputType     *string             `json:"input_type,omitempty"`      // Type of the input. Options: None, query, document. Defaults to null.
 	Truncation    *bool               `json:"truncation,omitempty"`      // Whether to truncate the inputs to fit within the context length. Defaults to True.
 	OuputEncoding *string             `json:"output_encoding,omitempty"` // Format in which the embeddings are encoded. Defaults to null.
+	SendNull      []string            `json:"-"`                         // The JSON names of optional fields sent as null when unset, rather than left out.
 }
 
 // Additional request options that can be passed to [VoyageClient.MultimodalEmbed].
 type MultimodalRequestOpts struct {
 	InputType     *string `json:"input_type,omitempty"`
 	Truncation    *bool   `json:"truncation,omitempty"`
-	OuputEncoding *string `json:"output_encoding,omitempty"`
+	OuputEncoding *string `json:"output_encoding,omitempty"` // Defaults to null. Other options: [EncodingBase64].
+	// The JSON names of optional fields sent as an explicit null when unset, such as
+	// "input_type", rather than left out. Unset fields are left out by default.
+	SendNull []string `json:"-"`
+
+	// Skip the image URL checks configured with [VoyageClientOpts.ValidateImageURLs].
+	SkipImageURLValidation bool `json:"-"`
 }
 
 type VoyageError struct {
 	Detail string `json:"detail"`
 }
 
+// An error response from the API. The errors of failed calls wrap it, so it can be retrieved
+// with [errors.As].
 type APIError struct {
 	StatusCode int
-	Response   []byte
+	Response   []byte      // The raw body of the response.
+	Header     http.Header // The headers of the response.
+	// The wait the response asked for with its Retry-After header, in seconds or as an HTTP date
+	// counted from when the response arrived. Zero if the header is missing or invalid.
+	RetryAfter time.Duration
+	RequestID  string // The request ID reported by the API, if any.
+	Detail     string // The detail message of the response body, if it has one.
+}
+
+// newAPIError returns the error for a failed response with the given body, received at now.
+func newAPIError(resp *http.Response, body []byte, now time.Time) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Response: body, Header: resp.Header, RequestID: requestID(resp.Header)}
+	e.RetryAfter, _ = resilience.ParseRetryAfter(resp.Header, now)
+	var ve VoyageError
+	if json.Unmarshal(body, &ve) == nil {
+		e.Detail = ve.Detail
+	}
+	return e
 }
 
 func (e *APIError) Error() string {
 	return fmt.Sprintf("voyageai: API error %d: %s", e.StatusCode, e.Response)
 }
 
+// IsRateLimit reports whether the request was rejected for exceeding a rate limit.
+func (e *APIError) IsRateLimit() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsAuth reports whether the request was rejected for its API key, because it is invalid or
+// lacks permission.
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsRetryable reports whether the request may succeed if sent again, by the rule the client
+// retries by: [resilience.ShouldRetryStatus].
+func (e *APIError) IsRetryable() bool {
+	return resilience.ShouldRetryStatus(e.StatusCode)
+}
+
 // A data structure that matches the expected fields of the /rerank endpoint.
 // Use [RerankRequestOpts] when building a request for use with [VoyageClient].
 // For more details, see the Voyage AI docs "[API reference]."
@@ -246,6 +348,7 @@ type RerankRequest struct {
 	TopK            *int     `json:"top_k,omitempty"`
 	ReturnDocuments *bool    `json:"return_documents,omitempty"`
 	Truncation      *bool    `json:"truncation,omitempty"`
+	SendNull        []string `json:"-"` // The JSON names of optional fields sent as null when unset, rather than left out.
 }
 
 // Additional request options that can be passed to [VoyageClient.Rerank].
@@ -256,6 +359,9 @@ type RerankRequestOpts struct {
 	ReturnDocuments *bool `json:"return_documents,omitempty"`
 	// Whether to truncate the input to satisfy the "context length limit" on the query and the documents. Defaults to true.
 	Truncation *bool `json:"truncation,omitempty"`
+	// The JSON names of optional fields sent as an explicit null when unset, such as "top_k",
+	// rather than left out. Unset fields are left out by default.
+	SendNull []string `json:"-"`
 }
 
 // An object containing reranking results.
@@ -263,6 +369,8 @@ type RerankObject struct {
 	Index          int     `json:"index"`              // The index of the document in the input list.
 	RelevanceScore float32 `json:"relevance_score"`    // The relevance score of the document with respect to the query.
 	Document       *string `json:"document,omitempty"` // The document string. Only returned when return_documents is set to true.
+	// Fields of the object this client does not know. See [VoyageClientOpts.PreserveUnknownFields].
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // The response from the /rerank endpoint
@@ -271,4 +379,11 @@ type RerankResponse struct {
 	Data   []RerankObject `json:"data"`   // An array of the reranking results, sorted by the descending order of relevance scores.
 	Model  string         `json:"model"`  // Name of the model.
 	Usage  UsageObject    `json:"usage"`  // An object containing usage details
+
+	// The query and documents that were altered client-side. See [VoyageClientOpts.Sanitize].
+	Sanitized []SanitizedInput `json:"-"`
+	// Details about how the client produced the response.
+	Metadata ResponseMetadata `json:"-"`
+	// Fields of the response this client does not know. See [VoyageClientOpts.PreserveUnknownFields].
+	Extra map[string]json.RawMessage `json:"-"`
 }
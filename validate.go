@@ -0,0 +1,258 @@
+package voyageai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Matched by the error of a request rejected by [VoyageClientOpts.ValidateInputs].
+var ErrInvalidRequest = errors.New("voyage: request exceeds model limits")
+
+// The error of a request that breaks the limits of its model, returned before it is sent when
+// [VoyageClientOpts.ValidateInputs] is set. It matches [ErrInvalidRequest].
+type ValidationError struct {
+	Model  string
+	Inputs []InvalidInput // Every limit the request breaks, in request order.
+}
+
+// A limit broken by a request. See [ValidationError].
+type InvalidInput struct {
+	// The position of the input or document in the request, or -1 for a rerank query and for
+	// limits on the request as a whole.
+	Index  int
+	Reason string // What is wrong, such as "input 3 has 40000 tokens, more than the context length of 32000".
+}
+
+// The most reasons listed by [ValidationError.Error].
+const maxListedReasons = 5
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, maxListedReasons)
+	for _, in := range e.Inputs[:min(len(e.Inputs), maxListedReasons)] {
+		reasons = append(reasons, in.Reason)
+	}
+	msg := fmt.Sprintf("%v of %s: %s", ErrInvalidRequest, e.Model, strings.Join(reasons, "; "))
+	if more := len(e.Inputs) - maxListedReasons; more > 0 {
+		msg += fmt.Sprintf("; and %d more", more)
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalidRequest }
+
+// validateRequest checks reqBody against the limits of its model in the client's registry, if
+// [VoyageClientOpts.ValidateInputs] is set. Models without known limits are not checked.
+func (c *VoyageClient) validateRequest(reqBody any) error {
+	if !c.opts.ValidateInputs {
+		return nil
+	}
+	model := requestModel(reqBody)
+	info, ok := c.lookupModel(model)
+	if !ok {
+		return nil
+	}
+	v := &validation{c: c, info: info, err: ValidationError{Model: model}}
+	var err error
+	switch r := reqBody.(type) {
+	case *EmbeddingRequest:
+		err = v.texts(r.Input, truncates(r.Truncation))
+	case *ContextualizedEmbeddingRequest:
+		err = v.documents(r.Inputs)
+	case *MultimodalRequest:
+		err = v.multimodal(r.Inputs, truncates(r.Truncation))
+	case *RerankRequest:
+		err = v.rerank(r.Query, r.Documents, truncates(r.Truncation))
+	case *rerankSharedRequest:
+		var docs []string
+		json.Unmarshal(r.Documents, &docs)
+		err = v.rerank(r.Query, docs, truncates(r.Truncation))
+	case *MultimodalRerankRequest:
+		err = v.multimodalRerank(r.Query, r.Documents, truncates(r.Truncation))
+	}
+	if err != nil {
+		return err
+	}
+	if len(v.err.Inputs) > 0 {
+		return &v.err
+	}
+	return nil
+}
+
+// truncates reports whether the API truncates inputs to the context length, as it does unless
+// asked not to.
+func truncates(truncation *bool) bool {
+	return truncation == nil || *truncation
+}
+
+// validation collects the limits a request breaks.
+type validation struct {
+	c    *VoyageClient
+	info ModelInfo
+	err  ValidationError
+}
+
+func (v *validation) add(index int, format string, args ...any) {
+	v.err.Inputs = append(v.err.Inputs, InvalidInput{Index: index, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *validation) count(texts ...string) (int, error) {
+	return v.c.countTokens(v.err.Model, texts...)
+}
+
+// inputs checks the number of inputs of an embedding request.
+func (v *validation) inputs(n int) {
+	if max := v.info.MaxBatchInputs; max > 0 && n > max {
+		v.add(-1, "request has %d inputs, more than the %d allowed", n, max)
+	}
+}
+
+// total checks the tokens of an embedding request.
+func (v *validation) total(tokens int) {
+	if max := v.info.MaxBatchTokens; max > 0 && tokens > max {
+		v.add(-1, "request has %d tokens, more than the %d allowed", tokens, max)
+	}
+}
+
+// input checks the tokens of a single input, which are only limited if it is not truncated.
+func (v *validation) input(name string, index, tokens int, truncate bool) {
+	if !truncate && v.info.ContextLength > 0 && tokens > v.info.ContextLength {
+		v.add(index, "%s %d has %d tokens, more than the context length of %d", name, index, tokens, v.info.ContextLength)
+	}
+}
+
+func (v *validation) texts(texts []string, truncate bool) error {
+	v.inputs(len(texts))
+	total := 0
+	for i, text := range texts {
+		n, err := v.count(text)
+		if err != nil {
+			return err
+		}
+		v.input("input", i, n, truncate)
+		total += n
+	}
+	v.total(total)
+	return nil
+}
+
+// documents checks a contextualized embedding request, whose documents are never truncated.
+func (v *validation) documents(docs [][]string) error {
+	v.inputs(len(docs))
+	total := 0
+	for i, chunks := range docs {
+		n, err := v.count(chunks...)
+		if err != nil {
+			return err
+		}
+		v.input("document", i, n, false)
+		total += n
+	}
+	v.total(total)
+	return nil
+}
+
+func (v *validation) multimodal(inputs []MultimodalContent, truncate bool) error {
+	v.inputs(len(inputs))
+	total := 0
+	for i, in := range inputs {
+		n, err := v.content(i, fmt.Sprintf("input %d", i), in)
+		if err != nil {
+			return err
+		}
+		v.input("input", i, n, truncate)
+		total += n
+	}
+	v.total(total)
+	return nil
+}
+
+// content returns the tokens of a multimodal input, labelled in reasons as label, counting its
+// images at [pixelsPerToken], and checks the size of each of its base64 images. Images sent as
+// URLs or streams are not counted.
+func (v *validation) content(index int, label string, in MultimodalContent) (int, error) {
+	tokens, err := v.count(in.text())
+	if err != nil {
+		return 0, err
+	}
+	for j, part := range in.Content {
+		if part.ImageBase64 == "" {
+			continue
+		}
+		size, pixels := imageSize(part.ImageBase64)
+		if size > MaxImageBytes {
+			v.add(index, "%s image %d has %d bytes, more than the %d allowed", label, j, size, MaxImageBytes)
+		}
+		if pixels > MaxImagePixels {
+			v.add(index, "%s image %d has %d pixels, more than the %d allowed", label, j, pixels, MaxImagePixels)
+		}
+		tokens += pixels / pixelsPerToken
+	}
+	return tokens, nil
+}
+
+// imageSize returns the decoded size of a base64 data URL image and its number of pixels, which
+// is zero if the image format is unknown.
+func imageSize(img imageBase64) (size, pixels int) {
+	_, data, _ := strings.Cut(string(img), ";base64,")
+	size = base64.StdEncoding.DecodedLen(len(data))
+	cfg, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return size, 0
+	}
+	return size, cfg.Width * cfg.Height
+}
+
+func (v *validation) rerank(query string, documents []string, truncate bool) error {
+	q, err := v.count(query)
+	if err != nil {
+		return err
+	}
+	docs := make([]int, len(documents))
+	for i, doc := range documents {
+		if docs[i], err = v.count(doc); err != nil {
+			return err
+		}
+	}
+	v.rerankTokens(q, docs, truncate)
+	return nil
+}
+
+func (v *validation) multimodalRerank(query MultimodalContent, documents []MultimodalContent, truncate bool) error {
+	q, err := v.content(-1, "query", query)
+	if err != nil {
+		return err
+	}
+	docs := make([]int, len(documents))
+	for i, doc := range documents {
+		if docs[i], err = v.content(i, fmt.Sprintf("document %d", i), doc); err != nil {
+			return err
+		}
+	}
+	v.rerankTokens(q, docs, truncate)
+	return nil
+}
+
+// rerankTokens checks a rerank request of a query and documents of the given tokens. The query
+// counts once for every document.
+func (v *validation) rerankTokens(query int, docs []int, truncate bool) {
+	if max := v.info.MaxDocuments; max > 0 && len(docs) > max {
+		v.add(-1, "request has %d documents, more than the %d allowed", len(docs), max)
+	}
+	if max := v.info.MaxQueryTokens; !truncate && max > 0 && query > max {
+		v.add(-1, "query has %d tokens, more than the %d allowed", query, max)
+	}
+	total := 0
+	for i, n := range docs {
+		if limit := v.info.ContextLength; !truncate && limit > 0 && query+n > limit {
+			v.add(i, "document %d has %d tokens, more than the %d left by the query in the context length of %d", i, n, max(limit-query, 0), limit)
+		}
+		total += query + n
+	}
+	if max := v.info.MaxRerankTokens; max > 0 && total > max {
+		v.add(-1, "request has %d tokens, more than the %d allowed", total, max)
+	}
+}
@@ -0,0 +1,101 @@
+package voyageai_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// pngHeader returns the start of a grayscale PNG of the given dimensions, enough to read its
+// configuration but not its pixels, as a data URL.
+func pngHeader(width, height uint32) voyageai.MultimodalInput {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 0, 0, 0, 0)
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)-4))
+	data = append(data, ihdr...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+	return voyageai.MultimodalInput{Type: "image_base64", ImageBase64: voyageai.MustGetBase64Raw(strings.NewReader(string(data)))}
+}
+
+func TestValidateInputs(t *testing.T) {
+	reg := voyageai.NewModelRegistry()
+	if err := reg.Load(strings.NewReader(registryOverride)); err != nil {
+		t.Fatal(err.Error())
+	}
+	api := newMockServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{
+		Key: "APIKEY", BaseURL: api.URL, Tokenizer: wordTokenizer{}, Models: reg, ValidateInputs: true,
+	})
+
+	// voyage-test-1 allows 2 inputs and 10 tokens per request, and 100 tokens per input.
+	if _, err := client.Embed([]string{words(3), words(4)}, "voyage-test-1", nil); err != nil {
+		t.Fatalf("Expected a request within the limits to be sent, got %v", err)
+	}
+	_, err := client.Embed([]string{words(3), words(4), words(101)}, "voyage-test-1", &voyageai.EmbeddingRequestOpts{Truncation: voyageai.Opt(false)})
+	var verr *voyageai.ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, voyageai.ErrInvalidRequest) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	want := []voyageai.InvalidInput{
+		{Index: -1, Reason: "request has 3 inputs, more than the 2 allowed"},
+		{Index: 2, Reason: "input 2 has 101 tokens, more than the context length of 100"},
+		{Index: -1, Reason: "request has 108 tokens, more than the 10 allowed"},
+	}
+	if verr.Model != "voyage-test-1" || len(verr.Inputs) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, verr)
+	}
+	for i := range want {
+		if verr.Inputs[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], verr.Inputs[i])
+		}
+	}
+	if api.requestCount() != 1 {
+		t.Errorf("Expected the invalid request not to be sent, got %d requests", api.requestCount())
+	}
+
+	// Inputs over the context length are truncated by the API unless truncation is disabled.
+	_, err = client.Embed([]string{words(101)}, "voyage-test-1", nil)
+	if !errors.As(err, &verr) || len(verr.Inputs) != 1 || verr.Inputs[0].Index != -1 {
+		t.Errorf("Expected only the request's tokens to be over the limit, got %v", err)
+	}
+}
+
+func TestValidateRerankAndImages(t *testing.T) {
+	api := newMockServer(t)
+	client := voyageai.NewClient(&voyageai.VoyageClientOpts{Key: "APIKEY", BaseURL: api.URL, Tokenizer: wordTokenizer{}, ValidateInputs: true})
+
+	// rerank-2-lite allows 2000 query tokens and 8000 for a query and document combined.
+	opts := &voyageai.RerankRequestOpts{Truncation: voyageai.Opt(false)}
+	_, err := client.Rerank(words(2001), []string{words(10), words(6000)}, voyageai.ModelRerank2Lite, opts)
+	var verr *voyageai.ValidationError
+	if !errors.As(err, &verr) || len(verr.Inputs) != 2 {
+		t.Fatalf("Expected the query and a document to be over the limit, got %v", err)
+	}
+	if verr.Inputs[0].Index != -1 || verr.Inputs[1].Index != 1 ||
+		verr.Inputs[1].Reason != "document 1 has 6000 tokens, more than the 5999 left by the query in the context length of 8000" {
+		t.Errorf("Unexpected reasons %+v", verr.Inputs)
+	}
+	if api.rerankCount() != 0 {
+		t.Errorf("Expected no request to be sent, got %d", api.rerankCount())
+	}
+
+	inputs := []voyageai.MultimodalContent{
+		{Content: []voyageai.MultimodalInput{voyageai.Multimodal(voyageai.Text("a picture")), pngHeader(100, 100)}},
+		{Content: []voyageai.MultimodalInput{pngHeader(5000, 4000)}},
+	}
+	_, err = client.MultimodalEmbed(inputs, voyageai.ModelVoyageMultimodal3, nil)
+	if !errors.As(err, &verr) || len(verr.Inputs) != 1 ||
+		verr.Inputs[0].Reason != "input 1 image 0 has 20000000 pixels, more than the 16000000 allowed" {
+		t.Fatalf("Expected the large image to be reported, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "input 1 image 0") {
+		t.Errorf("Expected the error to list the offending input, got %q", err)
+	}
+}
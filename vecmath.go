@@ -0,0 +1,92 @@
+package voyageai
+
+import (
+	"fmt"
+	"math"
+)
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func norm(a []float32) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// cosine returns the cosine similarity of two vectors of equal length, or 0 if either is zero.
+func cosine(a, b []float32) float64 {
+	na, nb := norm(a), norm(b)
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot(a, b) / (na * nb)
+}
+
+// normalized returns a copy of v scaled to unit length. Zero vectors are returned as zero vectors.
+func normalized(v []float32) []float32 {
+	out := make([]float32, len(v))
+	n := norm(v)
+	if n == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = float32(float64(x) / n)
+	}
+	return out
+}
+
+// checkDims returns the common dimension of vecs, or an error naming the first vector whose
+// dimension differs from the first.
+func checkDims(vecs [][]float32) (int, error) {
+	if len(vecs) == 0 {
+		return 0, nil
+	}
+	dim := len(vecs[0])
+	for i, v := range vecs {
+		if len(v) != dim {
+			return 0, fmt.Errorf("voyage: vector %d has dimension %d, expected %d", i, len(v), dim)
+		}
+	}
+	return dim, nil
+}
+
+// A measure of how close two vectors are.
+type Metric int
+
+const (
+	MetricCosine    Metric = iota // Cosine similarity. Voyage AI embeddings are normalized, so this equals their dot product.
+	MetricEuclidean               // Euclidean (L2) distance.
+)
+
+func (m Metric) String() string {
+	switch m {
+	case MetricCosine:
+		return "cosine"
+	case MetricEuclidean:
+		return "euclidean"
+	default:
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+}
+
+func euclidean(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// distance returns the distance between a and b under m: 1 - cosine similarity for
+// [MetricCosine] and the L2 distance for [MetricEuclidean].
+func (m Metric) distance(a, b []float32) float64 {
+	if m == MetricEuclidean {
+		return euclidean(a, b)
+	}
+	return 1 - cosine(a, b)
+}
@@ -0,0 +1,295 @@
+package voyageai
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Returned by [VectorIndex.Add] when the ID is already present.
+var ErrDuplicateID = errors.New("voyage: duplicate vector id")
+
+// An in-memory collection of vectors searchable by similarity.
+// It is safe for concurrent use: searches run in parallel while writes are serialized.
+type VectorIndex struct {
+	dim    int
+	metric Metric
+	pq     *PQCodebook // Set for an index created with NewPQVectorIndex, which stores codes instead of vectors.
+
+	mu    sync.RWMutex
+	ids   []string
+	vecs  [][]float32
+	codes [][]byte
+	norms []float64
+	meta  []map[string]string
+	pos   map[string]int
+}
+
+// A search result from a [VectorIndex].
+type Hit struct {
+	ID string
+	// The cosine similarity for [MetricCosine], or the Euclidean distance for [MetricEuclidean].
+	Score    float64
+	Metadata map[string]string
+}
+
+// Returns a new, empty [VectorIndex] for vectors of the given dimension.
+func NewVectorIndex(dim int, metric Metric) *VectorIndex {
+	return &VectorIndex{dim: dim, metric: metric, pos: map[string]int{}}
+}
+
+// Returns a new, empty [VectorIndex] that stores every vector as its product quantization code
+// under cb, taking one byte per subspace instead of four bytes per dimension. Searches score the
+// codes with asymmetric distance computation, so results are approximate. For [MetricCosine] the
+// exact norm of each vector is kept alongside its code.
+func NewPQVectorIndex(cb *PQCodebook, metric Metric) *VectorIndex {
+	x := NewVectorIndex(cb.Dim(), metric)
+	x.pq = cb
+	return x
+}
+
+// Codebook returns the codebook of an index created with [NewPQVectorIndex], or nil.
+func (x *VectorIndex) Codebook() *PQCodebook {
+	return x.pq
+}
+
+// Dim returns the dimension of the vectors in the index.
+func (x *VectorIndex) Dim() int {
+	return x.dim
+}
+
+// Metric returns the metric used to score searches.
+func (x *VectorIndex) Metric() Metric {
+	return x.metric
+}
+
+// Len returns the number of vectors in the index.
+func (x *VectorIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.ids)
+}
+
+// Add inserts a vector under id. It returns [ErrDuplicateID] if id is already present; use
+// [VectorIndex.Upsert] to replace existing entries. The vector is copied.
+func (x *VectorIndex) Add(id string, vec []float32, metadata map[string]string) error {
+	return x.put(id, vec, metadata, false)
+}
+
+// Upsert inserts a vector under id, replacing any existing entry with the same id.
+func (x *VectorIndex) Upsert(id string, vec []float32, metadata map[string]string) error {
+	return x.put(id, vec, metadata, true)
+}
+
+func (x *VectorIndex) put(id string, vec []float32, metadata map[string]string, replace bool) error {
+	if len(vec) != x.dim {
+		return fmt.Errorf("voyage: vector %q has dimension %d, expected %d", id, len(vec), x.dim)
+	}
+	var v []float32
+	var code []byte
+	if x.pq != nil {
+		code = x.pq.encode(vec)
+	} else {
+		v = append([]float32(nil), vec...)
+	}
+	n := norm(vec)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if i, ok := x.pos[id]; ok {
+		if !replace {
+			return fmt.Errorf("%w: %q", ErrDuplicateID, id)
+		}
+		x.norms[i], x.meta[i] = n, metadata
+		if x.pq != nil {
+			x.codes[i] = code
+		} else {
+			x.vecs[i] = v
+		}
+		return nil
+	}
+	x.pos[id] = len(x.ids)
+	x.ids = append(x.ids, id)
+	if x.pq != nil {
+		x.codes = append(x.codes, code)
+	} else {
+		x.vecs = append(x.vecs, v)
+	}
+	x.norms = append(x.norms, n)
+	x.meta = append(x.meta, metadata)
+	return nil
+}
+
+// Vector returns the vector stored under id, decoded from its code for an index created with
+// [NewPQVectorIndex]. The result must not be modified.
+func (x *VectorIndex) Vector(id string) ([]float32, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i, ok := x.pos[id]
+	if !ok {
+		return nil, false
+	}
+	return x.vector(i), true
+}
+
+// vector returns the i-th stored vector, decoding it if the index holds codes.
+func (x *VectorIndex) vector(i int) []float32 {
+	if x.pq != nil {
+		return x.pq.decode(x.codes[i])
+	}
+	return x.vecs[i]
+}
+
+// AddEmbeddings adds every embedding in resp, using ids[obj.Index] as the ID of each
+// [EmbeddingObject]. It stops at the first error.
+func (x *VectorIndex) AddEmbeddings(ids []string, resp *EmbeddingResponse) error {
+	for _, obj := range resp.Data {
+		if obj.Index < 0 || obj.Index >= len(ids) {
+			return fmt.Errorf("voyage: no id for embedding index %d", obj.Index)
+		}
+		if err := x.Add(ids[obj.Index], obj.Embedding, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the vector stored under id. It reports whether the id was present.
+func (x *VectorIndex) Delete(id string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	i, ok := x.pos[id]
+	if !ok {
+		return false
+	}
+	last := len(x.ids) - 1
+	x.ids[i], x.norms[i], x.meta[i] = x.ids[last], x.norms[last], x.meta[last]
+	x.pos[x.ids[i]] = i
+	x.ids, x.norms, x.meta = x.ids[:last], x.norms[:last], x.meta[:last]
+	if x.pq != nil {
+		x.codes[i] = x.codes[last]
+		x.codes = x.codes[:last]
+	} else {
+		x.vecs[i] = x.vecs[last]
+		x.vecs = x.vecs[:last]
+	}
+	delete(x.pos, id)
+	return true
+}
+
+// Options for [VectorIndex.SearchFiltered].
+type SearchOpts struct {
+	// Only entries whose metadata contains every key with an equal value are considered.
+	Match map[string]string
+	// Only entries for which Filter returns true are considered. It is called with the index's
+	// read lock held, so it must not modify the index.
+	Filter func(id string, metadata map[string]string) bool
+	// The maximum number of matching entries to score. Once reached the scan stops and the best
+	// hits among those scored are returned, trading recall for latency. Zero means no limit.
+	MaxCandidates int
+}
+
+// matches reports whether an entry passes the filters in o.
+func (o SearchOpts) matches(id string, metadata map[string]string) bool {
+	for k, want := range o.Match {
+		if got, ok := metadata[k]; !ok || got != want {
+			return false
+		}
+	}
+	return o.Filter == nil || o.Filter(id, metadata)
+}
+
+// Search returns the k entries most similar to query, most similar first. Ties are broken by ID.
+// Fewer than k hits are returned if the index holds fewer than k vectors.
+func (x *VectorIndex) Search(query []float32, k int) ([]Hit, error) {
+	return x.SearchFiltered(query, k, SearchOpts{})
+}
+
+// SearchFiltered is like [VectorIndex.Search] but only considers entries that pass the filters
+// in opts. Filters are applied during the scan, so up to k matching hits are returned however
+// rare the matches are; fewer are returned only if fewer entries match.
+func (x *VectorIndex) SearchFiltered(query []float32, k int, opts SearchOpts) ([]Hit, error) {
+	if len(query) != x.dim {
+		return nil, fmt.Errorf("voyage: query has dimension %d, expected %d", len(query), x.dim)
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	qnorm := norm(query)
+	var table pqTable
+	if x.pq != nil {
+		table = x.pq.table(query, x.metric)
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	h := &hitHeap{better: x.better}
+	scanned := 0
+	for i := range x.ids {
+		if opts.MaxCandidates > 0 && scanned >= opts.MaxCandidates {
+			break
+		}
+		if !opts.matches(x.ids[i], x.meta[i]) {
+			continue
+		}
+		scanned++
+
+		var score float64
+		switch {
+		case table != nil && x.metric == MetricEuclidean:
+			score = math.Sqrt(table.score(x.codes[i]))
+		case table != nil:
+			if qnorm != 0 && x.norms[i] != 0 {
+				score = table.score(x.codes[i]) / (qnorm * x.norms[i])
+			}
+		case x.metric == MetricEuclidean:
+			score = euclidean(query, x.vecs[i])
+		case qnorm != 0 && x.norms[i] != 0:
+			score = dot(query, x.vecs[i]) / (qnorm * x.norms[i])
+		}
+
+		hit := Hit{ID: x.ids[i], Score: score, Metadata: x.meta[i]}
+		if h.Len() < k {
+			heap.Push(h, hit)
+		} else if x.better(hit, h.hits[0]) {
+			h.hits[0] = hit
+			heap.Fix(h, 0)
+		}
+	}
+
+	hits := make([]Hit, h.Len())
+	for i := len(hits) - 1; i >= 0; i-- {
+		hits[i] = heap.Pop(h).(Hit)
+	}
+	return hits, nil
+}
+
+// better reports whether a ranks before b.
+func (x *VectorIndex) better(a, b Hit) bool {
+	if a.Score != b.Score {
+		if x.metric == MetricEuclidean {
+			return a.Score < b.Score
+		}
+		return a.Score > b.Score
+	}
+	return a.ID < b.ID
+}
+
+// hitHeap is a heap with the worst hit at the root.
+type hitHeap struct {
+	hits   []Hit
+	better func(a, b Hit) bool
+}
+
+func (h *hitHeap) Len() int           { return len(h.hits) }
+func (h *hitHeap) Less(i, j int) bool { return h.better(h.hits[j], h.hits[i]) }
+func (h *hitHeap) Swap(i, j int)      { h.hits[i], h.hits[j] = h.hits[j], h.hits[i] }
+func (h *hitHeap) Push(v any)         { h.hits = append(h.hits, v.(Hit)) }
+func (h *hitHeap) Pop() any {
+	last := h.hits[len(h.hits)-1]
+	h.hits = h.hits[:len(h.hits)-1]
+	return last
+}
@@ -0,0 +1,286 @@
+package voyageai
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// The on-disk format written by [VectorIndex.Save] is, with all integers little-endian:
+//
+//	magic   [4]byte "VXIX"
+//	version uint16
+//	dim     uint32
+//	metric  uint8
+//	storage uint8 (from version 2; version 1 files always hold float32 vectors)
+//	count   uint64
+//	codebook as written by PQCodebook.Save, for StoragePQ only
+//	count records of:
+//	  id        uint32 length, bytes
+//	  metadata  uint32 pair count, then per pair: uint32 length, key bytes, uint32 length, value bytes
+//	  vector    dim float32 or half-precision values, or one code byte per subspace, as given by storage
+//	  norm      float64, for StoragePQ only
+const (
+	indexMagic   = "VXIX"
+	indexVersion = 2
+
+	// Upper bounds used to reject corrupt headers before allocating.
+	maxIndexDim      = 1 << 16
+	maxIndexFieldLen = 1 << 24
+)
+
+// Returned by [LoadVectorIndex] when the input is not a complete, valid index.
+var ErrCorruptIndex = errors.New("voyage: corrupt vector index")
+
+// How vectors are stored in a saved [VectorIndex].
+type VectorStorage uint8
+
+const (
+	StorageFloat32 VectorStorage = iota // Exact single-precision values.
+	StorageFloat16                      // Half-precision values, rounded as by [ToFloat16Bits]. Halves the size of the vectors.
+	StoragePQ                           // Product quantization codes along with their codebook. Only valid for an index created with [NewPQVectorIndex].
+)
+
+// Save writes the index to w in a versioned binary format readable by [LoadVectorIndex]. An index
+// created with [NewPQVectorIndex] is saved with [StoragePQ], all others with [StorageFloat32].
+func (x *VectorIndex) Save(w io.Writer) error {
+	if x.pq != nil {
+		return x.SaveAs(w, StoragePQ)
+	}
+	return x.SaveAs(w, StorageFloat32)
+}
+
+// SaveAs is like [VectorIndex.Save] but stores the vectors as given by storage. Saving a PQ index
+// with [StorageFloat32] or [StorageFloat16] stores its decoded vectors.
+func (x *VectorIndex) SaveAs(w io.Writer, storage VectorStorage) error {
+	switch {
+	case storage == StoragePQ && x.pq == nil:
+		return errors.New("voyage: only an index created with NewPQVectorIndex can be saved as PQ codes")
+	case storage > StoragePQ:
+		return fmt.Errorf("voyage: unknown vector storage %d", storage)
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	buf = append(buf, indexMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, indexVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(x.dim))
+	buf = append(buf, byte(x.metric), byte(storage))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(x.ids)))
+	if storage == StoragePQ {
+		buf = x.pq.appendTo(buf)
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+
+	for i, id := range x.ids {
+		buf = appendString(buf[:0], id)
+
+		keys := make([]string, 0, len(x.meta[i]))
+		for k := range x.meta[i] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(keys)))
+		for _, k := range keys {
+			buf = appendString(buf, k)
+			buf = appendString(buf, x.meta[i][k])
+		}
+
+		if storage == StoragePQ {
+			buf = append(buf, x.codes[i]...)
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x.norms[i]))
+		} else {
+			for _, f := range x.vector(i) {
+				if storage == StorageFloat16 {
+					buf = binary.LittleEndian.AppendUint16(buf, float32ToHalf(f))
+				} else {
+					buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(f))
+				}
+			}
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s)))
+	return append(buf, s...)
+}
+
+// LoadVectorIndex reads an index written by [VectorIndex.Save] or [VectorIndex.SaveAs]. It returns
+// an error wrapping [ErrCorruptIndex] if the input is truncated or malformed, and rejects unknown
+// format versions.
+func LoadVectorIndex(r io.Reader) (*VectorIndex, error) {
+	br := bufio.NewReader(r)
+	d := &indexDecoder{r: br}
+
+	magic := d.bytes(4)
+	if d.err == nil && string(magic) != indexMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrCorruptIndex, magic)
+	}
+	version := d.uint16()
+	if d.err == nil && (version < 1 || version > indexVersion) {
+		return nil, fmt.Errorf("voyage: unsupported vector index version %d", version)
+	}
+	dim := d.uint32()
+	metric := Metric(d.uint8())
+	storage := StorageFloat32
+	if version >= 2 {
+		storage = VectorStorage(d.uint8())
+	}
+	count := d.uint64()
+	if d.err != nil {
+		return nil, d.fail("header")
+	}
+	if dim == 0 || dim > maxIndexDim {
+		return nil, fmt.Errorf("%w: invalid dimension %d", ErrCorruptIndex, dim)
+	}
+	if metric != MetricCosine && metric != MetricEuclidean {
+		return nil, fmt.Errorf("%w: unknown metric %d", ErrCorruptIndex, metric)
+	}
+	if storage > StoragePQ {
+		return nil, fmt.Errorf("%w: unknown vector storage %d", ErrCorruptIndex, storage)
+	}
+
+	x := NewVectorIndex(int(dim), metric)
+	if storage == StoragePQ {
+		cb, err := readPQCodebook(d)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptIndex, err)
+		}
+		if cb.Dim() != int(dim) {
+			return nil, fmt.Errorf("%w: codebook has dimension %d, expected %d", ErrCorruptIndex, cb.Dim(), dim)
+		}
+		x = NewPQVectorIndex(cb, metric)
+	}
+	for i := uint64(0); i < count; i++ {
+		id := d.string()
+		var meta map[string]string
+		if n := d.uint32(); n > 0 && d.err == nil {
+			meta = make(map[string]string, min(n, 1024))
+			for range n {
+				k := d.string()
+				v := d.string()
+				if d.err != nil {
+					break
+				}
+				meta[k] = v
+			}
+		}
+		if storage == StoragePQ {
+			code := d.bytes(x.pq.Subspaces())
+			n := math.Float64frombits(d.uint64())
+			if d.err != nil {
+				return nil, d.fail(fmt.Sprintf("record %d of %d", i, count))
+			}
+			if err := x.addCode(id, code, n, meta); err != nil {
+				return nil, fmt.Errorf("%w: record %d: %v", ErrCorruptIndex, i, err)
+			}
+			continue
+		}
+		vec := make([]float32, dim)
+		for j := range vec {
+			if storage == StorageFloat16 {
+				vec[j] = halfToFloat32(d.uint16())
+			} else {
+				vec[j] = math.Float32frombits(d.uint32())
+			}
+		}
+		if d.err != nil {
+			return nil, d.fail(fmt.Sprintf("record %d of %d", i, count))
+		}
+		if err := x.Add(id, vec, meta); err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrCorruptIndex, i, err)
+		}
+	}
+	return x, nil
+}
+
+// addCode adds a loaded PQ code with the norm of the vector it was encoded from.
+func (x *VectorIndex) addCode(id string, code []byte, n float64, metadata map[string]string) error {
+	if err := x.pq.checkCode(code); err != nil {
+		return err
+	}
+	if _, ok := x.pos[id]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateID, id)
+	}
+	x.pos[id] = len(x.ids)
+	x.ids = append(x.ids, id)
+	x.codes = append(x.codes, code)
+	x.norms = append(x.norms, n)
+	x.meta = append(x.meta, metadata)
+	return nil
+}
+
+// SaveFile writes the index to the file at path. The file is replaced atomically, so it always
+// holds either the previous or the new index.
+func (x *VectorIndex) SaveFile(path string) error {
+	return writeFileAtomicFunc(path, x.Save)
+}
+
+// LoadVectorIndexFile reads an index from the file at path. See [LoadVectorIndex].
+func LoadVectorIndexFile(path string) (*VectorIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadVectorIndex(f)
+}
+
+// indexDecoder reads little-endian values, remembering the first error.
+type indexDecoder struct {
+	r   io.Reader
+	err error
+	buf [8]byte
+}
+
+func (d *indexDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return b
+}
+
+func (d *indexDecoder) fixed(n int) []byte {
+	if d.err != nil {
+		return d.buf[:n]
+	}
+	_, d.err = io.ReadFull(d.r, d.buf[:n])
+	return d.buf[:n]
+}
+
+func (d *indexDecoder) uint8() uint8   { return d.fixed(1)[0] }
+func (d *indexDecoder) uint16() uint16 { return binary.LittleEndian.Uint16(d.fixed(2)) }
+func (d *indexDecoder) uint32() uint32 { return binary.LittleEndian.Uint32(d.fixed(4)) }
+func (d *indexDecoder) uint64() uint64 { return binary.LittleEndian.Uint64(d.fixed(8)) }
+
+func (d *indexDecoder) string() string {
+	n := d.uint32()
+	if d.err == nil && n > maxIndexFieldLen {
+		d.err = fmt.Errorf("field length %d too large", n)
+	}
+	return string(d.bytes(int(n)))
+}
+
+// fail returns an error describing where decoding stopped.
+func (d *indexDecoder) fail(where string) error {
+	if errors.Is(d.err, io.EOF) || errors.Is(d.err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: truncated in %s", ErrCorruptIndex, where)
+	}
+	return fmt.Errorf("%w: %s: %v", ErrCorruptIndex, where, d.err)
+}
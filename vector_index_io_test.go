@@ -0,0 +1,130 @@
+package voyageai_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func sampleIndex(t *testing.T) *voyageai.VectorIndex {
+	t.Helper()
+	rng := rand.New(rand.NewPCG(8, 0))
+	idx := voyageai.NewVectorIndex(12, voyageai.MetricEuclidean)
+	for i := range 50 {
+		meta := map[string]string{"n": fmt.Sprint(i)}
+		if i%3 == 0 {
+			meta = nil
+		}
+		if err := idx.Add(fmt.Sprintf("doc-%d", i), randomVector(rng, 12), meta); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	return idx
+}
+
+func TestVectorIndexRoundTrip(t *testing.T) {
+	idx := sampleIndex(t)
+	path := filepath.Join(t.TempDir(), "index.bin")
+	if err := idx.SaveFile(path); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded, err := voyageai.LoadVectorIndexFile(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if loaded.Len() != idx.Len() || loaded.Dim() != 12 || loaded.Metric() != voyageai.MetricEuclidean {
+		t.Fatalf("Loaded index differs: len %d dim %d metric %v", loaded.Len(), loaded.Dim(), loaded.Metric())
+	}
+
+	rng := rand.New(rand.NewPCG(9, 0))
+	for range 10 {
+		q := randomVector(rng, 12)
+		want, _ := idx.Search(q, 7)
+		got, _ := loaded.Search(q, 7)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Search results differ after loading:\n%+v\n%+v", got, want)
+		}
+	}
+}
+
+func TestLoadVectorIndexTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleIndex(t).Save(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	data := buf.Bytes()
+
+	for _, n := range []int{0, 3, 10, 19, len(data) / 2, len(data) - 1} {
+		_, err := voyageai.LoadVectorIndex(bytes.NewReader(data[:n]))
+		if !errors.Is(err, voyageai.ErrCorruptIndex) {
+			t.Errorf("Expected ErrCorruptIndex for %d of %d bytes, got %v", n, len(data), err)
+		}
+	}
+}
+
+func TestLoadVectorIndexRejectsUnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	sampleIndex(t).Save(&buf)
+	data := buf.Bytes()
+	data[4] = 99
+
+	if _, err := voyageai.LoadVectorIndex(bytes.NewReader(data)); err == nil {
+		t.Error("Expected an error for an unknown version")
+	}
+}
+
+func TestVectorIndexFloat16Storage(t *testing.T) {
+	idx := sampleIndex(t)
+	var full, half bytes.Buffer
+	idx.Save(&full)
+	if err := idx.SaveAs(&half, voyageai.StorageFloat16); err != nil {
+		t.Fatal(err.Error())
+	}
+	if half.Len() >= full.Len()-50*12*2+1 {
+		t.Errorf("Expected float16 storage to save 2 bytes per value, got %d and %d bytes", half.Len(), full.Len())
+	}
+	loaded, err := voyageai.LoadVectorIndex(&half)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rng := rand.New(rand.NewPCG(10, 0))
+	for range 10 {
+		q := randomVector(rng, 12)
+		want, _ := idx.Search(q, 5)
+		got, _ := loaded.Search(q, 5)
+		for i := range want {
+			if got[i].ID != want[i].ID || math.Abs(got[i].Score-want[i].Score) > 1e-2 {
+				t.Fatalf("Search results differ after a float16 round trip:\n%+v\n%+v", got, want)
+			}
+		}
+	}
+}
+
+func TestLoadVectorIndexVersion1(t *testing.T) {
+	var buf bytes.Buffer
+	idx := sampleIndex(t)
+	idx.Save(&buf)
+	// Version 1 files have no storage byte after the metric.
+	data := append([]byte(nil), buf.Bytes()[:11]...)
+	data[4] = 1
+	data = append(data, buf.Bytes()[12:]...)
+
+	loaded, err := voyageai.LoadVectorIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	q := randomVector(rand.New(rand.NewPCG(11, 0)), 12)
+	want, _ := idx.Search(q, 5)
+	got, _ := loaded.Search(q, 5)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search results differ for a version 1 file:\n%+v\n%+v", got, want)
+	}
+}
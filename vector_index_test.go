@@ -0,0 +1,220 @@
+package voyageai_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+func TestVectorIndexCRUD(t *testing.T) {
+	idx := voyageai.NewVectorIndex(2, voyageai.MetricCosine)
+
+	if err := idx.Add("a", []float32{1, 0}, map[string]string{"lang": "en"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := idx.Add("b", []float32{0, 1}, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := idx.Add("a", []float32{1, 1}, nil); !errors.Is(err, voyageai.ErrDuplicateID) {
+		t.Errorf("Expected ErrDuplicateID, got %v", err)
+	}
+	if err := idx.Add("c", []float32{1, 2, 3}, nil); err == nil {
+		t.Error("Expected a dimension error")
+	}
+	if err := idx.Upsert("b", []float32{-1, 0}, map[string]string{"v": "2"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if idx.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", idx.Len())
+	}
+	if v, ok := idx.Vector("b"); !ok || v[0] != -1 || v[1] != 0 {
+		t.Errorf("Expected the upserted vector, got %v", v)
+	}
+	if _, ok := idx.Vector("z"); ok {
+		t.Error("Expected no vector for a missing id")
+	}
+
+	hits, err := idx.Search([]float32{1, 0.1}, 5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(hits) != 2 || hits[0].ID != "a" || hits[0].Metadata["lang"] != "en" || hits[1].Metadata["v"] != "2" {
+		t.Errorf("Unexpected hits %+v", hits)
+	}
+
+	if !idx.Delete("a") || idx.Delete("a") {
+		t.Error("Expected the first delete to succeed and the second to report a missing id")
+	}
+	hits, _ = idx.Search([]float32{1, 0}, 5)
+	if len(hits) != 1 || hits[0].ID != "b" {
+		t.Errorf("Unexpected hits after delete %+v", hits)
+	}
+	if _, err := idx.Search([]float32{1}, 1); err == nil {
+		t.Error("Expected a dimension error for the query")
+	}
+}
+
+func TestVectorIndexAddEmbeddings(t *testing.T) {
+	idx := voyageai.NewVectorIndex(2, voyageai.MetricCosine)
+	resp := &voyageai.EmbeddingResponse{Data: []voyageai.EmbeddingObject{
+		{Index: 1, Embedding: []float32{0, 1}},
+		{Index: 0, Embedding: []float32{1, 0}},
+	}}
+	if err := idx.AddEmbeddings([]string{"first", "second"}, resp); err != nil {
+		t.Fatal(err.Error())
+	}
+	hits, _ := idx.Search([]float32{0, 1}, 1)
+	if hits[0].ID != "second" {
+		t.Errorf("Expected embeddings to be matched to ids by index, got %+v", hits)
+	}
+}
+
+func bruteForce(vecs [][]float32, query []float32, metric voyageai.Metric, k int) []string {
+	type scored struct {
+		id    string
+		score float64
+	}
+	var all []scored
+	for i, v := range vecs {
+		var dot, nq, nv, l2 float64
+		for d := range v {
+			dot += float64(v[d]) * float64(query[d])
+			nq += float64(query[d]) * float64(query[d])
+			nv += float64(v[d]) * float64(v[d])
+			l2 += (float64(v[d]) - float64(query[d])) * (float64(v[d]) - float64(query[d]))
+		}
+		s := dot / math.Sqrt(nq*nv)
+		if metric == voyageai.MetricEuclidean {
+			s = -math.Sqrt(l2)
+		}
+		all = append(all, scored{fmt.Sprint(i), s})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	var ids []string
+	for _, s := range all[:k] {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+func TestVectorIndexMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 0))
+	var vecs [][]float32
+	for range 500 {
+		vecs = append(vecs, randomVector(rng, 16))
+	}
+
+	for _, metric := range []voyageai.Metric{voyageai.MetricCosine, voyageai.MetricEuclidean} {
+		idx := voyageai.NewVectorIndex(16, metric)
+		for i, v := range vecs {
+			idx.Add(fmt.Sprint(i), v, nil)
+		}
+		for range 20 {
+			query := randomVector(rng, 16)
+			hits, err := idx.Search(query, 10)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			want := bruteForce(vecs, query, metric, 10)
+			for i := range want {
+				if hits[i].ID != want[i] {
+					t.Fatalf("%v: rank %d: expected %s, got %s", metric, i, want[i], hits[i].ID)
+				}
+			}
+		}
+	}
+}
+
+func TestVectorIndexConcurrentSearch(t *testing.T) {
+	rng := rand.New(rand.NewPCG(6, 0))
+	idx := voyageai.NewVectorIndex(8, voyageai.MetricCosine)
+	for i := range 200 {
+		idx.Add(fmt.Sprint(i), randomVector(rng, 8), nil)
+	}
+	query := randomVector(rng, 8)
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				if w == 0 {
+					idx.Upsert(fmt.Sprint("new", i), query, nil)
+					idx.Delete(fmt.Sprint(i))
+					continue
+				}
+				if _, err := idx.Search(query, 5); err != nil {
+					t.Error(err.Error())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if idx.Len() != 200 {
+		t.Errorf("Expected 200 entries, got %d", idx.Len())
+	}
+}
+
+func TestVectorIndexSearchFiltered(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 0))
+	idx := voyageai.NewVectorIndex(8, voyageai.MetricCosine)
+	for i := range 100 {
+		meta := map[string]string{"lang": "de", "source": "docs"}
+		if i%10 == 0 {
+			meta["lang"] = "en"
+		}
+		idx.Add(fmt.Sprint(i), randomVector(rng, 8), meta)
+	}
+	query := randomVector(rng, 8)
+
+	all, _ := idx.Search(query, 100)
+	scores := map[string]float64{}
+	for _, h := range all {
+		scores[h.ID] = h.Score
+	}
+
+	hits, err := idx.SearchFiltered(query, 5, voyageai.SearchOpts{Match: map[string]string{"lang": "en", "source": "docs"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(hits) != 5 {
+		t.Fatalf("Expected 5 hits, got %d", len(hits))
+	}
+	var want []string
+	for _, h := range all {
+		if h.Metadata["lang"] == "en" && len(want) < 5 {
+			want = append(want, h.ID)
+		}
+	}
+	for i, h := range hits {
+		if h.ID != want[i] || h.Metadata["lang"] != "en" {
+			t.Errorf("Hit %d: expected %s, got %+v", i, want[i], h)
+		}
+		if h.Score != scores[h.ID] {
+			t.Errorf("Expected filtering to keep the score of %s, got %v and %v", h.ID, h.Score, scores[h.ID])
+		}
+	}
+
+	hits, _ = idx.SearchFiltered(query, 20, voyageai.SearchOpts{
+		Match:  map[string]string{"lang": "en"},
+		Filter: func(id string, _ map[string]string) bool { return id != "0" },
+	})
+	if len(hits) != 9 {
+		t.Errorf("Expected only the 9 matching entries, got %d", len(hits))
+	}
+	if hits, _ := idx.SearchFiltered(query, 5, voyageai.SearchOpts{Match: map[string]string{"lang": "fr"}}); len(hits) != 0 {
+		t.Errorf("Expected no hits, got %+v", hits)
+	}
+
+	hits, _ = idx.SearchFiltered(query, 10, voyageai.SearchOpts{MaxCandidates: 3})
+	if len(hits) != 3 {
+		t.Errorf("Expected the scan to stop after 3 candidates, got %d hits", len(hits))
+	}
+}
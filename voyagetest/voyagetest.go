@@ -0,0 +1,148 @@
+// Package voyagetest helps tests compare embeddings, which differ in their last bits between
+// runs, models and platforms, against expected values and golden files.
+package voyagetest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/zamedic/voyageai"
+)
+
+// Set by the -update flag of the test binary. When true, [Golden] rewrites golden files instead
+// of comparing against them.
+var Update = flag.Bool("update", false, "rewrite embedding golden files")
+
+// The most mismatches [AssertEmbeddingsApprox] reports per call.
+const maxReported = 10
+
+// ApproxEqual reports whether a and b have the same length and every pair of values differs by at
+// most tol. If not, it also returns the first index at which they differ, which is the length of
+// the shorter vector when only the lengths differ. NaN never equals anything.
+func ApproxEqual(a, b []float32, tol float64) (bool, int) {
+	for i := range min(len(a), len(b)) {
+		if !(math.Abs(float64(a[i])-float64(b[i])) <= tol) {
+			return false, i
+		}
+	}
+	if len(a) != len(b) {
+		return false, min(len(a), len(b))
+	}
+	return true, -1
+}
+
+// ApproxEqualMatrix is [ApproxEqual] for lists of vectors. If they differ, it returns the row and
+// column of the first difference; the column is -1 when the numbers of rows differ.
+func ApproxEqualMatrix(a, b [][]float32, tol float64) (ok bool, row, col int) {
+	for i := range min(len(a), len(b)) {
+		if ok, j := ApproxEqual(a[i], b[i], tol); !ok {
+			return false, i, j
+		}
+	}
+	if len(a) != len(b) {
+		return false, min(len(a), len(b)), -1
+	}
+	return true, -1, -1
+}
+
+// AssertEmbeddingsApprox marks the test as failed unless got equals want within tol, as by
+// [ApproxEqualMatrix]. It reports each differing value with its position, the expected and actual
+// values and their difference, up to ten of them.
+func AssertEmbeddingsApprox(t testing.TB, got, want [][]float32, tol float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("voyagetest: expected %d embeddings, got %d", len(want), len(got))
+		return
+	}
+	reported := 0
+	for i := range got {
+		if len(got[i]) != len(want[i]) {
+			if reported < maxReported {
+				t.Errorf("voyagetest: embedding %d: expected %d dimensions, got %d", i, len(want[i]), len(got[i]))
+			}
+			reported++
+			continue
+		}
+		for j := range got[i] {
+			delta := float64(got[i][j]) - float64(want[i][j])
+			if math.Abs(delta) <= tol {
+				continue
+			}
+			if reported < maxReported {
+				t.Errorf("voyagetest: embedding %d[%d]: expected %g, got %g (delta %+g, tolerance %g)", i, j, want[i][j], got[i][j], delta, tol)
+			}
+			reported++
+		}
+	}
+	if reported > maxReported {
+		t.Errorf("voyagetest: %d more differences not shown", reported-maxReported)
+	}
+}
+
+// SaveEmbeddings writes vecs to the file at path in the format of [voyageai.VectorIndex.Save],
+// with each vector stored exactly under its position as ID. All vectors must have the same
+// dimension. Missing parent directories are created.
+func SaveEmbeddings(path string, vecs [][]float32) error {
+	dim := 0
+	if len(vecs) > 0 {
+		dim = len(vecs[0])
+	}
+	idx := voyageai.NewVectorIndex(dim, voyageai.MetricCosine)
+	for i, v := range vecs {
+		if err := idx.Add(strconv.Itoa(i), v, nil); err != nil {
+			return fmt.Errorf("voyagetest: embedding %d: %w", i, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return idx.SaveFile(path)
+}
+
+// LoadEmbeddings reads the vectors written by [SaveEmbeddings] to the file at path, in order.
+func LoadEmbeddings(path string) ([][]float32, error) {
+	idx, err := voyageai.LoadVectorIndexFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vecs := make([][]float32, idx.Len())
+	for i := range vecs {
+		v, ok := idx.Vector(strconv.Itoa(i))
+		if !ok {
+			return nil, fmt.Errorf("voyagetest: %s: no embedding %d", path, i)
+		}
+		vecs[i] = v
+	}
+	return vecs, nil
+}
+
+// Golden compares got against the embeddings in the golden file at path with
+// [AssertEmbeddingsApprox]. When the test binary runs with -update, it writes got to path
+// instead, so the golden files of a package are refreshed with
+//
+//	go test . -update
+//
+// A missing golden file fails the test with a hint to run with -update.
+func Golden(t testing.TB, path string, got [][]float32, tol float64) {
+	t.Helper()
+	if *Update {
+		if err := SaveEmbeddings(path, got); err != nil {
+			t.Fatalf("voyagetest: update %s: %v", path, err)
+		}
+		return
+	}
+	want, err := LoadEmbeddings(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("voyagetest: golden file %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("voyagetest: load %s: %v", path, err)
+	}
+	AssertEmbeddingsApprox(t, got, want, tol)
+}
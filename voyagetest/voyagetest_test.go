@@ -0,0 +1,147 @@
+package voyagetest_test
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamedic/voyageai/voyagetest"
+)
+
+// recorder collects the failures reported to it instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func TestApproxEqual(t *testing.T) {
+	a := []float32{0.1, -0.2, 0.3}
+	cases := []struct {
+		b    []float32
+		tol  float64
+		ok   bool
+		diff int
+	}{
+		{[]float32{0.1, -0.2, 0.3}, 0, true, -1},
+		{[]float32{0.1000001, -0.2000001, 0.2999999}, 1e-6, true, -1},
+		{[]float32{0.1, -0.2001, 0.3}, 1e-6, false, 1},
+		{[]float32{0.1, -0.2}, 1e-6, false, 2},
+		{[]float32{0.1, float32(math.NaN()), 0.3}, 1, false, 1},
+	}
+	for i, c := range cases {
+		if ok, diff := voyagetest.ApproxEqual(a, c.b, c.tol); ok != c.ok || diff != c.diff {
+			t.Errorf("Case %d: expected %v at %d, got %v at %d", i, c.ok, c.diff, ok, diff)
+		}
+	}
+
+	m := [][]float32{{1, 2}, {3, 4}}
+	if ok, row, col := voyagetest.ApproxEqualMatrix(m, [][]float32{{1, 2}, {3, 4.0000001}}, 1e-5); !ok || row != -1 || col != -1 {
+		t.Errorf("Expected matrices to match, got a difference at %d,%d", row, col)
+	}
+	if ok, row, col := voyagetest.ApproxEqualMatrix(m, [][]float32{{1, 2}, {3, 5}}, 1e-5); ok || row != 1 || col != 1 {
+		t.Errorf("Expected a difference at 1,1, got %v at %d,%d", ok, row, col)
+	}
+	if ok, row, col := voyagetest.ApproxEqualMatrix(m, m[:1], 1e-5); ok || row != 1 || col != -1 {
+		t.Errorf("Expected a missing row at 1, got %v at %d,%d", ok, row, col)
+	}
+}
+
+func TestAssertEmbeddingsApprox(t *testing.T) {
+	want := [][]float32{{0.5, 0.25}, {1, 0}}
+
+	r := &recorder{}
+	voyagetest.AssertEmbeddingsApprox(r, [][]float32{{0.5000001, 0.25}, {1, 1e-7}}, want, 1e-6)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected noise within tolerance to pass, got %v", r.errors)
+	}
+
+	r = &recorder{}
+	voyagetest.AssertEmbeddingsApprox(r, [][]float32{{0.5, 0.25}, {1, 0.5}}, want, 1e-6)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "embedding 1[1]: expected 0, got 0.5 (delta +0.5") {
+		t.Errorf("Expected a focused report of the mismatch, got %v", r.errors)
+	}
+
+	got := make([][]float32, 20)
+	for i := range got {
+		got[i] = []float32{9, 9}
+	}
+	r = &recorder{}
+	voyagetest.AssertEmbeddingsApprox(r, got[:2], want, 0)
+	voyagetest.AssertEmbeddingsApprox(r, got, want, 0)
+	if len(r.errors) != 5 || !strings.Contains(r.errors[4], "expected 2 embeddings, got 20") {
+		t.Errorf("Expected 4 differences and a count mismatch, got %v", r.errors)
+	}
+
+	// Only the first differences are listed.
+	r = &recorder{}
+	wide := make([][]float32, 20)
+	for i := range wide {
+		wide[i] = []float32{0, 0}
+	}
+	voyagetest.AssertEmbeddingsApprox(r, got, wide, 0)
+	if len(r.errors) != 11 || r.errors[10] != "voyagetest: 30 more differences not shown" {
+		t.Errorf("Expected 10 differences and a summary, got %v", r.errors)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "golden.vxix")
+	vecs := [][]float32{{0.1, -0.2, 0.3}, {float32(math.Pi), 0, -1e-9}}
+
+	if err := voyagetest.SaveEmbeddings(path, vecs); err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded, err := voyagetest.LoadEmbeddings(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ok, row, col := voyagetest.ApproxEqualMatrix(loaded, vecs, 0); !ok {
+		t.Errorf("Expected an exact round trip, got a difference at %d,%d", row, col)
+	}
+
+	r := &recorder{}
+	voyagetest.Golden(r, path, [][]float32{{0.1, -0.2, 0.3000001}, vecs[1]}, 1e-6)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected the golden file to match, got %v", r.errors)
+	}
+	voyagetest.Golden(r, path, [][]float32{{0.1, -0.2, 0.4}, vecs[1]}, 1e-6)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "embedding 0[2]") {
+		t.Errorf("Expected a mismatch against the golden file, got %v", r.errors)
+	}
+
+	r = &recorder{}
+	voyagetest.Golden(r, filepath.Join(t.TempDir(), "missing.vxix"), vecs, 0)
+	if !r.fatal || !strings.Contains(r.errors[0], "-update") {
+		t.Errorf("Expected a missing golden file to suggest -update, got %v", r.errors)
+	}
+
+	// With -update the golden file is rewritten.
+	*voyagetest.Update = true
+	defer func() { *voyagetest.Update = false }()
+	r = &recorder{}
+	voyagetest.Golden(r, path, vecs[:1], 0)
+	if len(r.errors) != 0 {
+		t.Fatalf("Expected the update to succeed, got %v", r.errors)
+	}
+	if loaded, _ := voyagetest.LoadEmbeddings(path); len(loaded) != 1 {
+		t.Errorf("Expected the golden file to be rewritten, got %d embeddings", len(loaded))
+	}
+
+	if err := voyagetest.SaveEmbeddings(path, [][]float32{{1, 2}, {3}}); err == nil {
+		t.Error("Expected an error for embeddings of different dimensions")
+	}
+}